@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/backfill"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <dir>",
+	Short: "Measure adapter parse throughput on a directory of logs",
+	Long: `Parse every log file under a directory without sending anything and
+report files/sec, events/sec and MB/sec. Use it to tune backfillWorkers.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		agentName, _ := cmd.Flags().GetString("agent")
+
+		if concurrency <= 0 {
+			concurrency = cfg.Collection.BackfillWorkers
+		}
+
+		registry := adapters.DefaultRegistry(cfg.ProjectID, nil, log)
+		configureAdapters(registry, cfg)
+
+		benchConfig := backfill.BenchConfig{
+			Registry:    registry,
+			Concurrency: concurrency,
+		}
+		if agentName != "" {
+			benchConfig.AgentName = mapAgentName(agentName)
+			benchConfig.Filter = agentPathFilter(cfg, agentName)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("⏱️  Benchmarking %s (concurrency %d)...\n\n", args[0], concurrency)
+
+		result, err := backfill.Bench(ctx, benchConfig, args[0])
+		if err != nil && result == nil {
+			return fmt.Errorf("benchmark failed: %w", err)
+		}
+
+		printBenchResult(result)
+		return err
+	},
+}
+
+// printBenchResult prints the benchmark summary table
+func printBenchResult(result *backfill.BenchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Files\t%d\n", result.Files)
+	if result.SkippedFiles > 0 {
+		fmt.Fprintf(w, "Skipped files\t%d\t(no adapter recognized the format)\n", result.SkippedFiles)
+	}
+	fmt.Fprintf(w, "Events\t%d\n", result.Events)
+	fmt.Fprintf(w, "Parse errors\t%d\n", result.ParseErrors)
+	fmt.Fprintf(w, "Data\t%.2f MB\n", float64(result.Bytes)/(1024*1024))
+	fmt.Fprintf(w, "Duration\t%s\n", result.Duration)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Files/sec\t%.1f\n", result.FilesPerSec())
+	fmt.Fprintf(w, "Events/sec\t%.1f\n", result.EventsPerSec())
+	fmt.Fprintf(w, "MB/sec\t%.2f\n", result.MBPerSec())
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().Int("concurrency", 0, "Files parsed concurrently (default: collection.backfillWorkers)")
+	benchCmd.Flags().StringP("agent", "a", "", "Parse every file with this agent's adapter instead of detecting it")
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var bufferCmd = &cobra.Command{
+	Use:   "buffer",
+	Short: "Manage the local event buffer",
+	Long:  "Inspect and maintain the local SQLite buffer used for offline event storage",
+}
+
+var bufferPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete events buffered longer ago than a retention window",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		retention, err := config.ParseDuration(olderThan)
+		if err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+
+		bufferConfig := buffer.Config{
+			DBPath:  cfg.Buffer.DBPath,
+			MaxSize: cfg.Buffer.MaxSize,
+			Logger:  log,
+		}
+		buf, err := buffer.NewBuffer(bufferConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create buffer: %w", err)
+		}
+		defer buf.Close()
+
+		pruned, err := buf.PruneOlderThan(retention)
+		if err != nil {
+			return fmt.Errorf("failed to prune buffer: %w", err)
+		}
+
+		fmt.Printf("🧹 Pruned %d events older than %s\n", pruned, olderThan)
+		return nil
+	},
+}
+
+var bufferPeekCmd = &cobra.Command{
+	Use:   "peek",
+	Short: "Show buffered events without removing them",
+	Long: `Print a summary of the events waiting in the local buffer, oldest first,
+without removing them. Useful for finding out why events aren't syncing.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		agent, _ := cmd.Flags().GetString("agent")
+		eventType, _ := cmd.Flags().GetString("type")
+		since, _ := cmd.Flags().GetString("since")
+
+		if limit <= 0 {
+			return fmt.Errorf("--limit must be positive")
+		}
+
+		filter := buffer.PeekFilter{EventType: eventType}
+		if agent != "" {
+			filter.AgentID = mapAgentName(agent)
+		}
+		if since != "" {
+			window, err := config.ParseDuration(since)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			filter.Since = time.Now().Add(-window)
+		}
+
+		bufferConfig := buffer.Config{
+			DBPath:  cfg.Buffer.DBPath,
+			MaxSize: cfg.Buffer.MaxSize,
+			Logger:  log,
+		}
+		buf, err := buffer.NewBuffer(bufferConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create buffer: %w", err)
+		}
+		defer buf.Close()
+
+		events, err := buf.Peek(limit, filter)
+		if err != nil {
+			return fmt.Errorf("failed to read buffer: %w", err)
+		}
+
+		total, err := buf.Count()
+		if err != nil {
+			return fmt.Errorf("failed to count buffered events: %w", err)
+		}
+
+		printBufferedEvents(os.Stdout, events, total)
+		return nil
+	},
+}
+
+// printBufferedEvents prints one line per event followed by how many of
+// the buffered events were shown
+func printBufferedEvents(out io.Writer, events []*types.AgentEvent, total int) {
+	if len(events) == 0 {
+		fmt.Fprintf(out, "No matching events (%d buffered)\n", total)
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tAGENT\tTYPE\tSESSION")
+	for _, event := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			event.Timestamp.Local().Format(time.RFC3339), event.AgentID, event.Type, event.SessionID)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nShowing %d of %d buffered events\n", len(events), total)
+}
+
+var bufferDeadLetterCmd = &cobra.Command{
+	Use:   "dead-letter",
+	Short: "Inspect and requeue events the backend kept rejecting",
+	Long: `Events the backend rejects more than collection.maxRetries times, e.g.
+because they fail its schema checks, are moved out of the buffer's send order
+into a dead-letter store so they stop holding up the flush. List them to see
+why they were rejected, and retry them once the cause is fixed.`,
+}
+
+var bufferDeadLetterListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show dead-lettered events and why they were rejected",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit <= 0 {
+			return fmt.Errorf("--limit must be positive")
+		}
+
+		buf, err := openConfiguredBuffer()
+		if err != nil {
+			return err
+		}
+		defer buf.Close()
+
+		letters, err := buf.DeadLetters(limit)
+		if err != nil {
+			return fmt.Errorf("failed to read dead letters: %w", err)
+		}
+		total, err := buf.DeadLetterCount()
+		if err != nil {
+			return fmt.Errorf("failed to count dead letters: %w", err)
+		}
+
+		printDeadLetters(os.Stdout, letters, total)
+		return nil
+	},
+}
+
+var bufferDeadLetterRetryCmd = &cobra.Command{
+	Use:   "retry [event-id...]",
+	Short: "Move dead-lettered events back into the buffer",
+	Long: `Move dead-lettered events back into the buffer with their rejection count
+reset, so the collector sends them again. Without event IDs every
+dead-lettered event is requeued.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		buf, err := openConfiguredBuffer()
+		if err != nil {
+			return err
+		}
+		defer buf.Close()
+
+		requeued, err := buf.RetryDeadLetters(args)
+		if err != nil {
+			return fmt.Errorf("failed to requeue dead letters: %w", err)
+		}
+
+		fmt.Printf("🔁 Requeued %d dead-lettered events\n", requeued)
+		return nil
+	},
+}
+
+// openConfiguredBuffer loads the configuration and opens its buffer
+func openConfiguredBuffer() (*buffer.Buffer, error) {
+	var err error
+	cfg, err = config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	configureLogging(cfg)
+
+	buf, err := buffer.NewBuffer(buffer.Config{
+		DBPath:  cfg.Buffer.DBPath,
+		MaxSize: cfg.Buffer.MaxSize,
+		Logger:  log,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create buffer: %w", err)
+	}
+	return buf, nil
+}
+
+// printDeadLetters prints one line per dead-lettered event with the reason
+// it was rejected, followed by how many of them were shown
+func printDeadLetters(out io.Writer, letters []*buffer.DeadLetter, total int) {
+	if len(letters) == 0 {
+		fmt.Fprintln(out, "No dead-lettered events")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FAILED\tEVENT\tAGENT\tTYPE\tATTEMPTS\tREASON")
+	for _, letter := range letters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			letter.FailedAt.Local().Format(time.RFC3339), letter.Event.ID, letter.Event.AgentID,
+			letter.Event.Type, letter.Attempts, letter.Reason)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nShowing %d of %d dead-lettered events\n", len(letters), total)
+}
+
+func init() {
+	rootCmd.AddCommand(bufferCmd)
+	bufferCmd.AddCommand(bufferPruneCmd)
+	bufferCmd.AddCommand(bufferPeekCmd)
+	bufferCmd.AddCommand(bufferDeadLetterCmd)
+	bufferDeadLetterCmd.AddCommand(bufferDeadLetterListCmd)
+	bufferDeadLetterCmd.AddCommand(bufferDeadLetterRetryCmd)
+
+	bufferPruneCmd.Flags().String("older-than", "30d", "Delete events older than this (e.g. 30d, 12h)")
+
+	bufferPeekCmd.Flags().IntP("limit", "n", 20, "Maximum number of events to show")
+	bufferPeekCmd.Flags().StringP("agent", "a", "", "Only show events from this agent")
+	bufferPeekCmd.Flags().StringP("type", "t", "", "Only show events of this type (e.g. llm_request)")
+	bufferPeekCmd.Flags().String("since", "", "Only show events newer than this (e.g. 24h, 7d)")
+
+	bufferDeadLetterListCmd.Flags().IntP("limit", "n", 20, "Maximum number of events to show")
+}
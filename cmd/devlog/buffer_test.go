@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func TestPrintBufferedEvents(t *testing.T) {
+	timestamp := time.Date(2025, 10, 31, 12, 0, 0, 0, time.Local)
+	events := []*types.AgentEvent{
+		{ID: "1", Timestamp: timestamp, Type: types.EventTypeLLMRequest, AgentID: "github-copilot", SessionID: "session-a"},
+		{ID: "2", Timestamp: timestamp.Add(time.Second), Type: types.EventTypeToolUse, AgentID: "claude", SessionID: "session-b"},
+	}
+
+	var out bytes.Buffer
+	printBufferedEvents(&out, events, 5)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected header, 2 events, blank line and summary, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "TIMESTAMP AGENT TYPE SESSION" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	want := []string{timestamp.Format(time.RFC3339), "github-copilot", types.EventTypeLLMRequest, "session-a"}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected event line %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "claude") || !strings.Contains(lines[2], types.EventTypeToolUse) {
+		t.Errorf("unexpected event line %q", lines[2])
+	}
+	if lines[4] != "Showing 2 of 5 buffered events" {
+		t.Errorf("unexpected summary %q", lines[4])
+	}
+}
+
+func TestPrintBufferedEvents_Empty(t *testing.T) {
+	var out bytes.Buffer
+	printBufferedEvents(&out, nil, 3)
+
+	if got := out.String(); got != "No matching events (3 buffered)\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestPrintDeadLetters(t *testing.T) {
+	failedAt := time.Date(2025, 10, 31, 12, 0, 0, 0, time.Local)
+	letters := []*buffer.DeadLetter{
+		{
+			Event:    &types.AgentEvent{ID: "evt-1", Type: types.EventTypeLLMRequest, AgentID: "github-copilot"},
+			Reason:   "missing field sessionId",
+			Attempts: 3,
+			FailedAt: failedAt,
+		},
+	}
+
+	var out bytes.Buffer
+	printDeadLetters(&out, letters, 4)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, 1 event, blank line and summary, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "FAILED EVENT AGENT TYPE ATTEMPTS REASON" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	want := []string{failedAt.Format(time.RFC3339), "evt-1", "github-copilot", types.EventTypeLLMRequest, "3", "missing", "field", "sessionId"}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected event line %q", lines[1])
+	}
+	if lines[3] != "Showing 1 of 4 dead-lettered events" {
+		t.Errorf("unexpected summary %q", lines[3])
+	}
+
+	out.Reset()
+	printDeadLetters(&out, nil, 0)
+	if got := out.String(); got != "No dead-lettered events\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/backfill"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/dedup"
+	"github.com/codervisor/devlog/internal/sink"
+	"github.com/codervisor/devlog/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Sync new agent activity and exit",
+	Long: `Collect what agents logged since the last run, send it and exit, for CI
+pipelines and cron jobs that don't run the collector as a daemon.
+
+Each source is synced from where the previous run (or start) left off;
+sources seen for the first time go back --initial-sync-days days. The exit
+status is non-zero if a source failed to sync or events couldn't be sent.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		once, _ := cmd.Flags().GetBool("once")
+		if !once {
+			return fmt.Errorf("collect only runs a single pass; pass --once, or use start to keep collecting")
+		}
+		initialSyncDays, _ := cmd.Flags().GetInt("initial-sync-days")
+		flushTimeout, _ := cmd.Flags().GetDuration("flush-timeout")
+		from, to, err := initialSyncRange("", "", initialSyncDays, time.Now())
+		if err != nil {
+			return err
+		}
+
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		buf, err := buffer.NewBuffer(buffer.Config{
+			DBPath:      cfg.Buffer.DBPath,
+			MaxSize:     cfg.Buffer.MaxSize,
+			MaxAttempts: max(cfg.Collection.MaxRetries, 1),
+			Logger:      log,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create buffer: %w", err)
+		}
+		defer buf.Close()
+
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to open dedup index: %w", err)
+		}
+		defer sentIndex.Close()
+
+		// Events go to the backend client unless a local sink is configured
+		var apiClient *client.Client
+		var eventSink sink.Sink
+		progress := io.Writer(os.Stdout)
+		if cfg.Sink.Local() {
+			localSink, err := openLocalSink(cfg)
+			if err != nil {
+				return err
+			}
+			defer localSink.Close()
+			eventSink = localSink
+
+			// Keep stdout clean for the event stream
+			if cfg.Sink.Type == "stdout" {
+				progress = os.Stderr
+			}
+		} else {
+			apiClient = newBackfillClient(cfg)
+			apiClient.Start()
+			defer apiClient.Stop()
+			eventSink = apiClient
+		}
+
+		hierarchyCache, closeHierarchyCache := newHierarchyCache(cfg, apiClient)
+		defer closeHierarchyCache()
+		registry := adapters.DefaultRegistry(cfg.ProjectID, hierarchyCache, log)
+		configureAdapters(registry, cfg)
+
+		// Backfill only buffers; the flush afterwards sends everything once
+		manager, err := backfill.NewBackfillManager(backfill.Config{
+			Registry:    registry,
+			Buffer:      buf,
+			StateDBPath: cfg.Buffer.DBPath,
+			Dedup:       sentIndex,
+			Grace:       backfillGracePolicy(cfg),
+			ParseErrors: backfillParseErrorPolicy(cfg),
+			MaxFileSize: cfg.Collection.BackfillMaxFileBytes,
+			Logger:      log,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create backfill manager: %w", err)
+		}
+		defer manager.Close()
+
+		discovered, err := discoverAgentLogs(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to discover logs: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		registry.SetContext(ctx)
+		return collectOnce(ctx, progress, manager, cfg, discovered, from, to, apiClient, eventSink, buf, flushTimeout)
+	},
+}
+
+// collectOnce syncs every discovered source from its stored position into
+// the buffer, then sends the buffer through eventSink for up to
+// flushTimeout. It returns an error if any source failed or events were
+// left buffered.
+func collectOnce(ctx context.Context, w io.Writer, manager *backfill.BackfillManager, cfg *config.Config,
+	discovered map[string][]watcher.DiscoveredLog, from, to time.Time,
+	apiClient *client.Client, eventSink sink.Sink, buf *buffer.Buffer, flushTimeout time.Duration) error {
+	var errs []error
+	collected := 0
+	for agentName, logs := range discovered {
+		if agentCfg, ok := cfg.Agents[agentName]; ok && !agentCfg.Enabled {
+			continue
+		}
+
+		logPaths := make([]string, 0, len(logs))
+		for _, logInfo := range logs {
+			logPaths = append(logPaths, logInfo.Path)
+		}
+
+		result, err := manager.BackfillPaths(ctx, backfill.BackfillConfig{
+			AgentName: mapAgentName(agentName),
+			FromDate:  from,
+			ToDate:    to,
+			BatchSize: 100,
+			Workers:   cfg.Collection.BackfillWorkers,
+			Filter:    agentPathFilter(cfg, agentName),
+		}, logPaths)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", agentName, err))
+		}
+		if result != nil {
+			collected += result.ProcessedEvents
+			fmt.Fprintf(w, "🔄 %s: %d new events from %d sources\n", agentName, result.ProcessedEvents, len(logPaths))
+			printParseErrors(result.ParseErrors, false)
+			printSkippedFiles(result.SkippedFiles)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	flushCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+	sent, left := drainOnShutdown(flushCtx, apiClient, eventSink, buf, cfg.Collection.BatchSize)
+	fmt.Fprintf(w, "✅ Collected %d events, sent %d, %d left buffered\n", collected, sent, left)
+
+	if left > 0 {
+		errs = append(errs, fmt.Errorf("%d events could not be sent and stay buffered for the next run", left))
+	}
+	return errors.Join(errs...)
+}
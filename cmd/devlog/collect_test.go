@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/backfill"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/dedup"
+	"github.com/codervisor/devlog/internal/sink"
+	"github.com/codervisor/devlog/internal/watcher"
+)
+
+// writeCopilotSession writes a one-request Copilot chat session
+func writeCopilotSession(t *testing.T, dir, name string, at time.Time) {
+	t.Helper()
+	session := fmt.Sprintf(`{"version":3,"requesterUsername":"dev","requests":[{"requestId":"%s","timestamp":%d,"message":{"text":"Fix the build"},"response":[{"value":"Done"}]}]}`,
+		name, at.UnixMilli())
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(session), 0644); err != nil {
+		t.Fatalf("failed to write session: %v", err)
+	}
+}
+
+// countLines returns the number of lines in path
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open sink output: %v", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
+
+func TestCollectOnce_SendsOnlyNewEvents(t *testing.T) {
+	dir := t.TempDir()
+	sessions := filepath.Join(dir, "workspaceStorage", "ws-1", "chatSessions")
+	if err := os.MkdirAll(sessions, 0755); err != nil {
+		t.Fatalf("failed to create sessions dir: %v", err)
+	}
+	writeCopilotSession(t, sessions, "session-1", time.Now().Add(-time.Hour))
+
+	cfg := &config.Config{
+		ProjectID:  "1",
+		Agents:     map[string]config.AgentConfig{"copilot": {Enabled: true, LogPath: "auto"}},
+		Collection: config.CollectionConfig{BatchSize: 50},
+		Buffer:     config.BufferConfig{DBPath: filepath.Join(dir, "buffer.db"), MaxSize: 1000},
+		Sink:       config.SinkConfig{Type: "file", Path: filepath.Join(dir, "events.ndjson")},
+	}
+	discovered := map[string][]watcher.DiscoveredLog{
+		"copilot": {{AgentName: "copilot", Path: sessions, IsDir: true, Exists: true}},
+	}
+
+	// Each run opens its own state, like separate cron invocations
+	collect := func() string {
+		t.Helper()
+		buf, err := buffer.NewBuffer(buffer.Config{DBPath: cfg.Buffer.DBPath, MaxSize: cfg.Buffer.MaxSize})
+		if err != nil {
+			t.Fatalf("failed to create buffer: %v", err)
+		}
+		defer buf.Close()
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, nil)
+		if err != nil {
+			t.Fatalf("failed to open dedup index: %v", err)
+		}
+		defer sentIndex.Close()
+		fileSink, err := sink.NewFileSink(cfg.Sink.Path)
+		if err != nil {
+			t.Fatalf("failed to open sink: %v", err)
+		}
+		defer fileSink.Close()
+
+		manager, err := backfill.NewBackfillManager(backfill.Config{
+			Registry:    adapters.DefaultRegistry(cfg.ProjectID, nil, nil),
+			Buffer:      buf,
+			StateDBPath: cfg.Buffer.DBPath,
+			Dedup:       sentIndex,
+		})
+		if err != nil {
+			t.Fatalf("failed to create backfill manager: %v", err)
+		}
+		defer manager.Close()
+
+		var out bytes.Buffer
+		from, to := time.Now().AddDate(0, 0, -1), time.Now()
+		if err := collectOnce(context.Background(), &out, manager, cfg, discovered, from, to, nil, fileSink, buf, 10*time.Second); err != nil {
+			t.Fatalf("collect failed: %v\n%s", err, out.String())
+		}
+		if count, _ := buf.Count(); count != 0 {
+			t.Errorf("expected the buffer to be flushed, %d events left", count)
+		}
+		return out.String()
+	}
+
+	collect()
+	first := countLines(t, cfg.Sink.Path)
+	if first == 0 {
+		t.Fatal("expected the first run to send the session's events")
+	}
+
+	collect()
+	if lines := countLines(t, cfg.Sink.Path); lines != first {
+		t.Fatalf("expected a run with nothing new to send nothing, got %d more events", lines-first)
+	}
+
+	writeCopilotSession(t, sessions, "session-2", time.Now().Add(-time.Minute))
+	collect()
+	if lines := countLines(t, cfg.Sink.Path); lines != 2*first {
+		t.Errorf("expected only the new session's %d events, sink has %d after %d", first, lines, first)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the collector configuration",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a configuration file",
+	Long: `Generate a validated configuration file from the defaults.
+
+Values not given with --backend-url, --api-key or --project-id are asked for
+interactively; press Enter to keep the default shown in brackets.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+
+		// Check before prompting so the user doesn't answer for nothing
+		if !force && config.Exists(configPath) {
+			return fmt.Errorf("%w: %s (use --force to overwrite)", config.ErrConfigExists, configPath)
+		}
+
+		defaults := config.DefaultConfig()
+		in := bufio.NewReader(cmd.InOrStdin())
+		opts := config.InitOptions{}
+		opts.BackendURL = flagOrPrompt(cmd, in, "backend-url", "Backend URL", defaults.BackendURL)
+		opts.APIKey = flagOrPrompt(cmd, in, "api-key", "API key", defaults.APIKey)
+		opts.ProjectID = flagOrPrompt(cmd, in, "project-id", "Project ID", defaults.ProjectID)
+
+		generated, err := config.NewInitConfig(opts)
+		if err != nil {
+			return err
+		}
+		if err := config.WriteInitConfig(generated, configPath, force); err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Configuration written to %s\n\n", configPath)
+		printEnabledAgents(generated)
+		return nil
+	},
+}
+
+// flagOrPrompt returns the flag's value when it was set, and otherwise asks
+// for it on in, falling back to def on an empty answer or EOF
+func flagOrPrompt(cmd *cobra.Command, in *bufio.Reader, flag, label, def string) string {
+	if cmd.Flags().Changed(flag) {
+		value, _ := cmd.Flags().GetString(flag)
+		return value
+	}
+
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	answer, err := in.ReadString('\n')
+	if err == io.EOF && answer == "" {
+		fmt.Println()
+	}
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// printEnabledAgents lists the enabled agents with the log paths discovered
+// for each
+func printEnabledAgents(cfg *config.Config) {
+	names := make([]string, 0, len(cfg.Agents))
+	for name, agentCfg := range cfg.Agents {
+		if agentCfg.Enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Println("🤖 Enabled agents:")
+	for _, name := range names {
+		fmt.Printf("\n   %s\n", name)
+
+		if logPath := cfg.Agents[name].LogPath; logPath != "" && logPath != "auto" {
+			fmt.Printf("      📁 %s\n", logPath)
+			continue
+		}
+
+		discovered, err := watcher.DiscoverAgentLogs(name)
+		if err != nil {
+			fmt.Printf("      ⚠️  %v\n", err)
+			continue
+		}
+		if len(discovered) == 0 {
+			fmt.Println("      No logs discovered yet")
+		}
+		for _, logInfo := range discovered {
+			fmt.Printf("      📁 %s\n", logInfo.Path)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+
+	configInitCmd.Flags().String("backend-url", "", "Devlog backend URL")
+	configInitCmd.Flags().String("api-key", "", "API key for the backend")
+	configInitCmd.Flags().String("project-id", "", "Project ID to attach to events")
+	configInitCmd.Flags().Bool("force", false, "Overwrite an existing configuration file")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var testConnectionCmd = &cobra.Command{
+	Use:   "test-connection",
+	Short: "Check connectivity and credentials without sending real data",
+	Long: `Check that the backend is reachable over the configured proxy and TLS
+settings, negotiate its capabilities and send one synthetic event flagged as a
+dry run to the backend's validate endpoint, if it advertises one. Failures explain what to fix, e.g. a rejected API key, a wrong URL or
+an untrusted certificate.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		if cfg.Sink.Local() {
+			fmt.Printf("📝 Sink: %s (backend not used, nothing to test)\n", cfg.Sink.Type)
+			return nil
+		}
+
+		report := newBackfillClient(cfg).TestConnection()
+		printConnectionReport(os.Stdout, report)
+		if !report.OK() {
+			return fmt.Errorf("connection test failed")
+		}
+		return nil
+	},
+}
+
+// printConnectionReport prints each check of a connection test and, for
+// the failed one, its error and hint
+func printConnectionReport(w io.Writer, report *client.ConnectionReport) {
+	fmt.Fprintf(w, "🔌 Testing connection to %s\n\n", report.URL)
+	for _, step := range report.Steps {
+		if step.Err != nil {
+			fmt.Fprintf(w, "❌ %s: %v\n", step.Name, step.Err)
+			if step.Hint != "" {
+				fmt.Fprintf(w, "   → %s\n", step.Hint)
+			}
+			continue
+		}
+		if step.Skipped {
+			fmt.Fprintf(w, "⏭️  %s: %s\n", step.Name, step.Detail)
+			continue
+		}
+		fmt.Fprintf(w, "✅ %s: %s\n", step.Name, step.Detail)
+	}
+	switch {
+	case report.OK() && report.Skipped():
+		fmt.Fprintln(w, "\nThe collector can reach the backend; skipped checks weren't verified.")
+	case report.OK():
+		fmt.Fprintln(w, "\nThe collector can reach the backend and its credentials are accepted.")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(testConnectionCmd)
+}
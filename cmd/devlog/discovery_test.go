@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/watcher"
+)
+
+func TestAgentDiscovery(t *testing.T) {
+	cfg := &config.Config{Agents: map[string]config.AgentConfig{
+		"copilot": {Enabled: true, LogPath: "auto"},
+		"claude":  {Enabled: false, LogPath: "auto"},
+		"cursor":  {Enabled: true, LogPath: "/srv/cursor-logs"},
+		"gemini":  {Enabled: true},
+	}}
+
+	got := agentDiscovery(cfg)
+	want := map[string]watcher.AgentSettings{
+		"copilot": {},
+		"claude":  {Disabled: true},
+		"cursor":  {LogPath: "/srv/cursor-logs"},
+		"gemini":  {},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected settings for %d agents, got %v", len(want), got)
+	}
+	for agentName, settings := range want {
+		if got[agentName] != settings {
+			t.Errorf("%s: expected %+v, got %+v", agentName, settings, got[agentName])
+		}
+	}
+
+	if agentDiscovery(nil) != nil {
+		t.Error("Expected no settings without a configuration")
+	}
+}
+
+func TestAgentPathFilters_MergeExcludeFlag(t *testing.T) {
+	cfg := &config.Config{Agents: map[string]config.AgentConfig{
+		"copilot": {Enabled: true, Exclude: []string{"*.bak"}},
+	}}
+	excludePatterns = []string{"archive"}
+	defer func() { excludePatterns = nil }()
+
+	filters := agentPathFilters(cfg)
+	copilot := filters["github-copilot"]
+	for _, path := range []string{"session.bak", "archive/session.json", "tmp/session.tmp"} {
+		if copilot.Allows(path) {
+			t.Errorf("Expected copilot to skip %s", path)
+		}
+	}
+	if !copilot.Allows("chatSessions/session.json") {
+		t.Error("Expected copilot to keep chatSessions/session.json")
+	}
+
+	// Agents without configuration get the flag's patterns too
+	claude, ok := filters["claude"]
+	if !ok {
+		t.Fatal("Expected a filter for the unconfigured claude agent")
+	}
+	if claude.Allows("archive/session.jsonl") {
+		t.Error("Expected claude to skip archive/session.jsonl")
+	}
+	if !claude.Allows("session.bak") {
+		t.Error("Expected copilot's excludes not to apply to claude")
+	}
+}
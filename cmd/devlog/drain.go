@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/sink"
+)
+
+// flushBuffered sends the oldest batchSize buffered events through eventSink
+// and deletes those it accepted or dropped as invalid; rejected events stay
+// buffered until they have been rejected too often and are dead-lettered. It
+// returns the number of events accepted. The batch is leased
+// while it is sent, so a crash before the deletion leaves it to be retried
+// once the lease expires.
+func flushBuffered(buf *buffer.Buffer, eventSink sink.Sink, batchSize int) (int, error) {
+	events, err := buf.Retrieve(batchSize)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	result, err := eventSink.SendBatch(events)
+	if err != nil {
+		ids := make([]string, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		if releaseErr := buf.Release(ids); releaseErr != nil {
+			log.Warnf("Failed to release unsent events: %v", releaseErr)
+		}
+		return 0, err
+	}
+
+	done := result.Accepted
+	for _, invalid := range result.Invalid {
+		done = append(done, invalid.ID)
+	}
+	if len(done) > 0 {
+		if err := buf.Delete(done); err != nil {
+			return 0, fmt.Errorf("failed to delete sent events: %w", err)
+		}
+	}
+	if len(result.Rejected) > 0 {
+		log.Warnf("%d buffered events were rejected by the backend", len(result.Rejected))
+		reasons := make(map[string]string, len(result.Rejected))
+		for _, r := range result.Rejected {
+			reasons[r.ID] = r.Reason
+		}
+		if _, err := buf.Reject(reasons); err != nil {
+			log.Warnf("Failed to record rejected events: %v", err)
+		}
+	}
+	return len(result.Accepted), nil
+}
+
+// flushBacklog sends buffered events in batchSize batches until the buffer
+// is empty, a send fails, the backend becomes unavailable or ctx is done, and
+// returns the number of events accepted. With one worker, batches go out
+// oldest first, one after the other; more workers send that many batches at
+// once, each still in order, so a later batch may arrive before an earlier
+// one. apiClient is nil for local sinks.
+func flushBacklog(ctx context.Context, apiClient *client.Client, eventSink sink.Sink, buf *buffer.Buffer, batchSize, workers int) (int, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var sent atomic.Int64
+	var stop atomic.Bool
+	var errOnce sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil && !stop.Load() {
+				if apiClient != nil && apiClient.CircuitOpen() {
+					return
+				}
+				n, err := flushBuffered(buf, eventSink, batchSize)
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					stop.Store(true)
+					return
+				}
+				if n == 0 {
+					return
+				}
+				sent.Add(int64(n))
+			}
+		}()
+	}
+	wg.Wait()
+	return int(sent.Load()), firstErr
+}
+
+// drainOnShutdown sends what is still pending once intake has stopped: the
+// client's queued batch, then the buffer, until it is empty, the backend
+// stops taking events or ctx is done. It returns how many events were sent
+// and how many are left buffered for the next run. apiClient is nil for
+// local sinks, which have nothing queued.
+func drainOnShutdown(ctx context.Context, apiClient *client.Client, eventSink sink.Sink, buf *buffer.Buffer, batchSize int) (drained, left int) {
+	var sent atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// A batch that fails to send is buffered through OnSendFailure
+		if apiClient != nil {
+			queued, _ := apiClient.GetStats()["pending_events"].(int)
+			if err := apiClient.FlushSync(); err != nil {
+				log.Warnf("Failed to flush queued events on shutdown: %v", err)
+			} else {
+				sent.Add(int64(queued))
+			}
+		}
+
+		for ctx.Err() == nil {
+			if apiClient != nil && apiClient.CircuitOpen() {
+				return
+			}
+			n, err := flushBuffered(buf, eventSink, batchSize)
+			if err != nil {
+				log.Warnf("Failed to flush buffered events on shutdown: %v", err)
+				return
+			}
+			if n == 0 {
+				return
+			}
+			sent.Add(int64(n))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("Shutdown timeout reached, leaving the remaining events buffered")
+	}
+	return int(sent.Load()), bufferedCount(buf)
+}
+
+// bufferedCount returns the number of buffered events, or 0 if it can't be read
+func bufferedCount(buf *buffer.Buffer) int {
+	count, err := buf.Count()
+	if err != nil {
+		log.Warnf("Failed to count buffered events: %v", err)
+	}
+	return count
+}
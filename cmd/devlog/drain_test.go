@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func drainTestEvent(i int) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:        fmt.Sprintf("evt-%d", i),
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "github-copilot",
+		SessionID: "session-1",
+	}
+}
+
+// newDrainFixture returns a buffer holding buffered events and a client
+// holding queued ones, sending to a backend that fails while down is set
+func newDrainFixture(t *testing.T, queued, buffered int, down *atomic.Bool) (*client.Client, *buffer.Buffer, *atomic.Int64) {
+	t.Helper()
+
+	received := &atomic.Int64{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != client.DefaultIngestPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var events []json.RawMessage
+		json.NewDecoder(r.Body).Decode(&events)
+		received.Add(int64(len(events)))
+	}))
+	t.Cleanup(server.Close)
+
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	t.Cleanup(func() { buf.Close() })
+
+	for i := 0; i < buffered; i++ {
+		if _, err := buf.Store(drainTestEvent(i)); err != nil {
+			t.Fatalf("failed to buffer event: %v", err)
+		}
+	}
+
+	apiClient := client.NewClient(client.Config{
+		BaseURL:     server.URL,
+		APIKey:      "key",
+		BatchSize:   2,
+		MaxRetries:  1,
+		MaxBackoff:  10 * time.Millisecond,
+		ManualFlush: true,
+		OnSendFailure: func(events []*types.AgentEvent, err error) {
+			for _, event := range events {
+				buf.Store(event)
+			}
+		},
+	})
+	for i := 0; i < queued; i++ {
+		if err := apiClient.SendEvent(drainTestEvent(100 + i)); err != nil {
+			t.Fatalf("failed to queue event: %v", err)
+		}
+	}
+
+	return apiClient, buf, received
+}
+
+func TestDrainOnShutdown_FlushesQueuedAndBufferedEvents(t *testing.T) {
+	var down atomic.Bool
+	apiClient, buf, received := newDrainFixture(t, 3, 5, &down)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained, left := drainOnShutdown(ctx, apiClient, apiClient, buf, 2)
+
+	if drained != 8 || left != 0 {
+		t.Errorf("Expected 8 events drained and none left, got %d drained and %d left", drained, left)
+	}
+	if n := received.Load(); n != 8 {
+		t.Errorf("Expected the backend to receive 8 events, got %d", n)
+	}
+}
+
+func TestDrainOnShutdown_BackendDownKeepsEventsBuffered(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	apiClient, buf, received := newDrainFixture(t, 3, 5, &down)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	drained, left := drainOnShutdown(ctx, apiClient, apiClient, buf, 2)
+
+	if drained != 0 || left != 8 {
+		t.Errorf("Expected nothing drained and 8 events left, got %d drained and %d left", drained, left)
+	}
+	if n := received.Load(); n != 0 {
+		t.Errorf("Expected the backend to receive nothing, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the drain to give up quickly, took %v", elapsed)
+	}
+}
+
+func TestDrainOnShutdown_Timeout(t *testing.T) {
+	var down atomic.Bool
+	apiClient, buf, _ := newDrainFixture(t, 0, 5, &down)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	drained, left := drainOnShutdown(ctx, apiClient, apiClient, buf, 2)
+
+	if drained != 0 || left != 5 {
+		t.Errorf("Expected an expired drain to leave all 5 events buffered, got %d drained and %d left", drained, left)
+	}
+}
+
+// newBacklogFixture returns a client for a backend that records the IDs of
+// each batch it receives, and a buffer holding a backlog of events with
+// increasing timestamps
+func newBacklogFixture(t *testing.T, backlog int) (*client.Client, *buffer.Buffer, func() [][]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != client.DefaultIngestPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var events []types.AgentEvent
+		json.NewDecoder(r.Body).Decode(&events)
+		ids := make([]string, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		mu.Lock()
+		batches = append(batches, ids)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: backlog})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	t.Cleanup(func() { buf.Close() })
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < backlog; i++ {
+		event := drainTestEvent(i)
+		event.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if _, err := buf.Store(event); err != nil {
+			t.Fatalf("failed to buffer event: %v", err)
+		}
+	}
+
+	apiClient := client.NewClient(client.Config{
+		BaseURL:     server.URL,
+		APIKey:      "key",
+		BatchSize:   50,
+		MaxRetries:  1,
+		MaxBackoff:  10 * time.Millisecond,
+		ManualFlush: true,
+	})
+	return apiClient, buf, func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+}
+
+func TestFlushBacklog_DrainsInBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{"one worker", 1},
+		{"parallel workers", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiClient, buf, batches := newBacklogFixture(t, 450)
+
+			flushed, err := flushBacklog(context.Background(), apiClient, apiClient, buf, 50, tt.workers)
+			if err != nil {
+				t.Fatalf("flushBacklog failed: %v", err)
+			}
+			if flushed != 450 {
+				t.Errorf("Expected 450 events flushed, got %d", flushed)
+			}
+			if left := bufferedCount(buf); left != 0 {
+				t.Errorf("Expected an empty buffer, %d events left", left)
+			}
+
+			got := batches()
+			if len(got) != 9 {
+				t.Fatalf("Expected 9 batches of 50, got %d", len(got))
+			}
+			seen := map[string]bool{}
+			for _, batch := range got {
+				if len(batch) != 50 {
+					t.Errorf("Expected batches of 50 events, got %d", len(batch))
+				}
+				for _, id := range batch {
+					if seen[id] {
+						t.Errorf("Event %s was sent twice", id)
+					}
+					seen[id] = true
+				}
+			}
+
+			// A single worker sends the whole backlog oldest first
+			if tt.workers == 1 {
+				i := 0
+				for _, batch := range got {
+					for _, id := range batch {
+						if want := fmt.Sprintf("evt-%d", i); id != want {
+							t.Fatalf("Expected %s at position %d, got %s", want, i, id)
+						}
+						i++
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFlushBacklog_StopsWhenBackendFails(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	apiClient, buf, received := newDrainFixture(t, 0, 5, &down)
+
+	flushed, err := flushBacklog(context.Background(), apiClient, apiClient, buf, 2, 2)
+	if err == nil {
+		t.Error("Expected an error from a failing backend")
+	}
+	if flushed != 0 || received.Load() != 0 {
+		t.Errorf("Expected nothing flushed, got %d", flushed)
+	}
+	if left := bufferedCount(buf); left != 5 {
+		t.Errorf("Expected all 5 events to stay buffered, %d left", left)
+	}
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,8 +20,14 @@ import (
 	"github.com/codervisor/devlog/internal/buffer"
 	"github.com/codervisor/devlog/internal/client"
 	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/dedup"
 	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/internal/logging"
+	"github.com/codervisor/devlog/internal/redact"
+	"github.com/codervisor/devlog/internal/sink"
+	"github.com/codervisor/devlog/internal/status"
 	"github.com/codervisor/devlog/internal/watcher"
+	"github.com/codervisor/devlog/pkg/types"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -26,15 +37,31 @@ var (
 	log        = logrus.New()
 	configPath string
 	cfg        *config.Config
+
+	// configSource says how configPath was chosen, for logging
+	configSource string
+
+	// excludePatterns are the --exclude globs, skipped on top of each
+	// agent's configured excludes
+	excludePatterns []string
 )
 
+// bufferFlushInterval is how often buffered events are retried
+const bufferFlushInterval = 30 * time.Second
+
 // agentNameMap maps config agent names to adapter agent names
 var agentNameMap = map[string]string{
-	"copilot": "github-copilot",
-	"claude":  "claude",
-	"cursor":  "cursor",
-	"cline":   "cline",
-	"aider":   "aider",
+	"copilot":   "github-copilot",
+	"claude":    "claude",
+	"cursor":    "cursor",
+	"cline":     "cline",
+	"aider":     "aider",
+	"neovim":    "neovim",
+	"continue":  "continue",
+	"jetbrains": "jetbrains",
+	"gemini":    "gemini",
+	"tabnine":   "tabnine",
+	"proxy":     "proxy",
 }
 
 // mapAgentName converts config agent name to adapter agent name
@@ -45,6 +72,240 @@ func mapAgentName(configName string) string {
 	return configName
 }
 
+// agentPathFilter builds the log discovery filter for an agent from its
+// configured patterns and those given with --exclude
+func agentPathFilter(cfg *config.Config, agentName string) watcher.PathFilter {
+	agentCfg := cfg.Agents[agentName]
+	exclude := append(append([]string{}, agentCfg.Exclude...), excludePatterns...)
+	return watcher.NewPathFilter(agentCfg.Include, exclude)
+}
+
+// agentPathFilters builds discovery filters for every known or configured
+// agent, keyed by adapter name
+func agentPathFilters(cfg *config.Config) map[string]watcher.PathFilter {
+	filters := make(map[string]watcher.PathFilter)
+	for agentName := range agentNameMap {
+		filters[mapAgentName(agentName)] = agentPathFilter(cfg, agentName)
+	}
+	for agentName := range cfg.Agents {
+		filters[mapAgentName(agentName)] = agentPathFilter(cfg, agentName)
+	}
+	return filters
+}
+
+// agentDebounces returns the debounce windows configured per agent, keyed
+// by adapter name
+func agentDebounces(cfg *config.Config) map[string]int {
+	debounces := make(map[string]int)
+	for agentName, agentCfg := range cfg.Agents {
+		if agentCfg.DebounceMs > 0 {
+			debounces[mapAgentName(agentName)] = agentCfg.DebounceMs
+		}
+	}
+	return debounces
+}
+
+// agentDiscovery returns the discovery settings of every configured agent,
+// keyed by agent name: disabled agents are skipped and a logPath other than
+// "auto" replaces the default locations
+func agentDiscovery(cfg *config.Config) map[string]watcher.AgentSettings {
+	if cfg == nil {
+		return nil
+	}
+	settings := make(map[string]watcher.AgentSettings)
+	for agentName, agentCfg := range cfg.Agents {
+		agentSettings := watcher.AgentSettings{Disabled: !agentCfg.Enabled}
+		if agentCfg.LogPath != "auto" {
+			agentSettings.LogPath = agentCfg.LogPath
+		}
+		settings[agentName] = agentSettings
+	}
+	return settings
+}
+
+// discoverAgentLogs discovers the logs of the agents cfg enables
+func discoverAgentLogs(cfg *config.Config) (map[string][]watcher.DiscoveredLog, error) {
+	return watcher.DiscoverConfiguredAgentLogs(agentDiscovery(cfg))
+}
+
+// Batch sequence streams of the commands that send to the backend. The
+// collector and a backfill can run at once and number their batches apart.
+const (
+	collectorStream = "collector"
+	backfillStream  = "backfill"
+)
+
+// batchSequencePath returns where the client persists the batch sequence
+// counter of stream, alongside the buffer database. The collector keeps the
+// file it has always used.
+func batchSequencePath(cfg *config.Config, stream string) string {
+	name := "batch.seq"
+	if stream != collectorStream {
+		name = "batch-" + stream + ".seq"
+	}
+	return filepath.Join(filepath.Dir(cfg.Buffer.DBPath), name)
+}
+
+// clientDestinations converts the configured mirror backends for the client
+func clientDestinations(cfg *config.Config) []client.Destination {
+	var destinations []client.Destination
+	for _, dest := range cfg.Destinations {
+		destinations = append(destinations, client.Destination{
+			Name:       dest.Name,
+			BaseURL:    dest.URL,
+			APIKey:     dest.APIKey,
+			BestEffort: dest.BestEffort,
+		})
+	}
+	return destinations
+}
+
+// backfillParseErrorPolicy builds the policy for aborting unparseable files
+func backfillParseErrorPolicy(cfg *config.Config) backfill.ParseErrorPolicy {
+	return backfill.ParseErrorPolicy{
+		MaxRate:  cfg.Collection.BackfillMaxParseErrorRate,
+		MinLines: cfg.Collection.BackfillParseErrorMinLines,
+	}
+}
+
+// newHierarchyCache builds the workspace hierarchy cache, persisted in the
+// state database when collection.hierarchyCacheTTL is set. The returned func
+// closes the disk cache.
+func newHierarchyCache(cfg *config.Config, apiClient *client.Client) (*hierarchy.HierarchyCache, func()) {
+	cache := hierarchy.NewHierarchyCache(apiClient, log)
+
+	ttl, _ := cfg.GetHierarchyCacheTTL()
+	if ttl <= 0 {
+		return cache, func() {}
+	}
+
+	disk, err := hierarchy.NewDiskCache(cfg.Buffer.DBPath, ttl)
+	if err != nil {
+		log.Warnf("Hierarchy disk cache unavailable: %v", err)
+		return cache, func() {}
+	}
+	cache.SetDiskCache(disk)
+	return cache, func() { disk.Close() }
+}
+
+// openLocalSink opens the stdout or file sink selected by sink.type
+func openLocalSink(cfg *config.Config) (*sink.NDJSONSink, error) {
+	if cfg.Sink.Type == "file" {
+		return sink.NewFileSink(cfg.Sink.Path)
+	}
+	return sink.NewStdoutSink(), nil
+}
+
+// backfillGracePolicy builds the backfill retry grace policy from configuration
+func backfillGracePolicy(cfg *config.Config) backfill.GracePolicy {
+	window, _ := cfg.GetBackfillRetryWindow()
+	return backfill.GracePolicy{
+		MaxAttempts: cfg.Collection.BackfillMaxAttempts,
+		Window:      window,
+	}
+}
+
+// configureLogging applies the configured log level and format
+func configureLogging(cfg *config.Config) {
+	if err := logging.Configure(log, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Warnf("Invalid logging configuration: %v", err)
+	}
+}
+
+// adapterOptions builds adapter options from the loaded configuration
+func adapterOptions(cfg *config.Config) adapters.Options {
+	var redactor *redact.Redactor
+	if cfg.Collection.Redact {
+		redactor = newRedactor(cfg)
+	}
+
+	return adapters.Options{
+		PromptSplitThreshold: cfg.Collection.PromptSplitThreshold,
+		MaxPromptChars:       cfg.Collection.MaxPromptChars,
+		MaxResponseChars:     cfg.Collection.MaxResponseChars,
+		SessionStartCommit:   cfg.Collection.SessionStartCommit,
+		RepoInfo:             cfg.Collection.RepoInfo,
+		GitHead:              cfg.Collection.GitHead,
+		CoalesceFileReads:    cfg.Collection.CoalesceFileReads,
+		SessionSummaries:     cfg.Collection.SessionSummaries,
+		CanceledTurns:        cfg.Collection.CanceledTurns,
+		SessionBudget: adapters.SessionBudget{
+			MaxTokens: cfg.Collection.SessionBudget.MaxTokens,
+			MaxCost:   cfg.Collection.SessionBudget.MaxCost,
+			Alert:     cfg.Collection.SessionBudget.Alert,
+		},
+		ProjectResolution: adapters.ProjectResolutionMode(cfg.ProjectResolutionMode),
+		Redactor:          redactor,
+		EventTypes: adapters.EventTypeFilter{
+			Include: cfg.Collection.IncludeEventTypes,
+			Exclude: cfg.Collection.ExcludeEventTypes,
+		},
+	}
+}
+
+// newRedactor builds the secret redactor from the configured patterns, which
+// were already checked by config validation
+func newRedactor(cfg *config.Config) *redact.Redactor {
+	redactor, err := redact.New(cfg.Collection.RedactPatterns)
+	if err != nil {
+		redactor, _ = redact.New(nil)
+	}
+	return redactor
+}
+
+// configureAdapters applies the collection options to every adapter, then
+// the per-agent overrides from the agents section
+func configureAdapters(registry *adapters.Registry, cfg *config.Config) {
+	opts := adapterOptions(cfg)
+	registry.Configure(opts)
+
+	for agentName, agentCfg := range cfg.Agents {
+		redactOverride := agentCfg.Redact != nil && *agentCfg.Redact != cfg.Collection.Redact
+		projectOverride := agentCfg.ProjectOverride()
+		if !redactOverride && projectOverride == 0 {
+			continue
+		}
+
+		agentOpts := opts
+		if redactOverride {
+			agentOpts.Redactor = nil
+			if *agentCfg.Redact {
+				agentOpts.Redactor = newRedactor(cfg)
+			}
+		}
+		agentOpts.ProjectOverride = projectOverride
+		agentOpts.WorkspaceOverride = agentCfg.WorkspaceID
+		if err := registry.ConfigureAdapter(mapAgentName(agentName), agentOpts); err != nil {
+			log.Warnf("Failed to configure %s adapter: %v", agentName, err)
+		}
+	}
+
+	configureProxyFields(registry, cfg)
+}
+
+// configureProxyFields applies the field mapping configured for the proxy
+// agent to its adapter
+func configureProxyFields(registry *adapters.Registry, cfg *config.Config) {
+	agentCfg, ok := cfg.Agents["proxy"]
+	if !ok || len(agentCfg.Fields) == 0 {
+		return
+	}
+	adapter, err := registry.Get("proxy")
+	if err != nil {
+		return
+	}
+	proxy, ok := adapter.(*adapters.ProxyLogAdapter)
+	if !ok {
+		return
+	}
+	fields, err := adapters.ParseProxyFields(agentCfg.Fields)
+	if err != nil {
+		log.Warnf("Failed to configure proxy adapter: %v", err)
+		return
+	}
+	proxy.SetFields(fields)
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -60,6 +321,10 @@ and forwards events to the Devlog backend.
 
 Supports: GitHub Copilot, Claude Code, Cursor, and more.`,
 	Version: version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configPath, configSource = config.Discover(configPath)
+		log.Debugf("Using configuration %s (%s)", configPath, configSource)
+	},
 }
 
 var startCmd = &cobra.Command{
@@ -68,33 +333,51 @@ var startCmd = &cobra.Command{
 	Long: `Start the collector daemon to monitor AI agent logs.
 
 By default, the collector will sync historical data before starting real-time
-watching. Use --no-history to skip historical sync (for power users).`,
+watching. Use --watch-only (or its older name --no-history) to skip the
+historical sync and only watch for new events, or --dry-run to preview what
+the historical sync would do and exit. To sync history and exit without
+watching, use 'devlog collect --once'.
+
+While watching, the collector rescans the agents' log locations every
+--discovery-interval for workspaces created since it started; 0 disables the
+rescan.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Info("Starting Devlog Collector...")
 		log.Infof("Version: %s", version)
 
 		// Parse flags
 		skipHistory, _ := cmd.Flags().GetBool("no-history")
+		watchOnly, _ := cmd.Flags().GetBool("watch-only")
+		skipHistory = skipHistory || watchOnly
+		discoveryInterval, _ := cmd.Flags().GetDuration("discovery-interval")
 		initialSyncDays, _ := cmd.Flags().GetInt("initial-sync-days")
+		initialSyncFrom, _ := cmd.Flags().GetString("initial-sync-from")
+		initialSyncTo, _ := cmd.Flags().GetString("initial-sync-to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		if dryRun && skipHistory {
+			return fmt.Errorf("--dry-run previews the historical sync and can't be combined with --watch-only or --no-history")
+		}
+		syncFrom, syncTo, err := initialSyncRange(initialSyncFrom, initialSyncTo, initialSyncDays, time.Now())
+		if err != nil {
+			return err
+		}
+		if initialSyncFrom != "" && cmd.Flags().Changed("initial-sync-days") {
+			log.Warn("--initial-sync-from is set, ignoring --initial-sync-days")
+		}
 
 		// Load configuration
-		var err error
 		cfg, err = config.LoadConfig(configPath)
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
-		log.Infof("Configuration loaded from: %s", configPath)
+		configureLogging(cfg)
+		log.Infof("Configuration loaded from: %s (%s)", configPath, configSource)
 		log.Infof("Backend URL: %s", cfg.BackendURL)
 		log.Infof("Project ID: %s", cfg.ProjectID)
 		log.Infof("Batch size: %d events", cfg.Collection.BatchSize)
 		log.Infof("Batch interval: %s", cfg.Collection.BatchInterval)
 
-		// Configure logging level
-		level, err := logrus.ParseLevel(cfg.Logging.Level)
-		if err == nil {
-			log.SetLevel(level)
-		}
-
 		// List enabled agents
 		log.Info("Enabled agents:")
 		for agentName, agentCfg := range cfg.Agents {
@@ -104,15 +387,26 @@ watching. Use --no-history to skip historical sync (for power users).`,
 		}
 
 		// Initialize adapter registry with hierarchy cache
-		hiererchyCache := hierarchy.NewHierarchyCache(nil, log)
+		hiererchyCache, closeHierarchyCache := newHierarchyCache(cfg, nil)
+		defer closeHierarchyCache()
 		registry := adapters.DefaultRegistry(cfg.ProjectID, hiererchyCache, log)
+		configureAdapters(registry, cfg)
 		log.Infof("Registered %d agent adapters", len(registry.List()))
 
+		// Preview the historical sync and exit before anything is sent
+		if dryRun {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			registry.SetContext(ctx)
+			return previewHistoricalSync(ctx, os.Stdout, cfg, registry, syncFrom, syncTo)
+		}
+
 		// Initialize buffer
 		bufferConfig := buffer.Config{
-			DBPath:  cfg.Buffer.DBPath,
-			MaxSize: cfg.Buffer.MaxSize,
-			Logger:  log,
+			DBPath:      cfg.Buffer.DBPath,
+			MaxSize:     cfg.Buffer.MaxSize,
+			MaxAttempts: max(cfg.Collection.MaxRetries, 1),
+			Logger:      log,
 		}
 		buf, err := buffer.NewBuffer(bufferConfig)
 		if err != nil {
@@ -120,35 +414,134 @@ watching. Use --no-history to skip historical sync (for power users).`,
 		}
 		defer buf.Close()
 
+		// Shared with any backfill running alongside, so neither sends an
+		// event the other already has
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to open dedup index: %w", err)
+		}
+		defer sentIndex.Close()
+
 		// Initialize API client
 		batchInterval, _ := cfg.GetBatchInterval()
+		maxBackoff, _ := cfg.GetMaxBackoff()
 		clientConfig := client.Config{
-			BaseURL:    cfg.BackendURL,
-			APIKey:     cfg.APIKey,
-			BatchSize:  cfg.Collection.BatchSize,
-			BatchDelay: batchInterval,
-			MaxRetries: cfg.Collection.MaxRetries,
-			Logger:     log,
-		}
-		apiClient := client.NewClient(clientConfig)
-		apiClient.Start()
-		defer apiClient.Stop()
+			BaseURL:      cfg.BackendURL,
+			APIKey:       cfg.APIKey,
+			BatchSize:    cfg.Collection.BatchSize,
+			BatchDelay:   batchInterval,
+			MaxRetries:   cfg.Collection.MaxRetries,
+			MaxBackoff:   maxBackoff,
+			Logger:       log,
+
+			Proxy:              cfg.Proxy,
+			CACertPath:         cfg.CACertPath,
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			ClientCertPath:     cfg.ClientCertPath,
+			ClientKeyPath:      cfg.ClientKeyPath,
+
+			IngestPath:      cfg.IngestPath,
+			SingleEventPath: cfg.SingleEventPath,
+			HealthPath:      cfg.HealthPath,
+
+			Destinations: clientDestinations(cfg),
+
+			MaxEventBytes:   cfg.Collection.MaxEventBytes,
+			MaxEventsPerSec: cfg.Collection.MaxEventsPerSec,
+			SequencePath:    batchSequencePath(cfg, collectorStream),
+			SequenceStream:  collectorStream,
+
+			Validation:     client.ValidationMode(cfg.Collection.Validation),
+			KnownAgents:    registry.List(),
+			DeadLetterPath: cfg.Collection.DeadLetterPath,
+
+			AuditLogPath:     cfg.Collection.AuditLogPath,
+			AuditLogMaxBytes: cfg.Collection.AuditLogMaxBytes,
+
+			// Buffer queued events whose batch could not be delivered
+			OnSendFailure: func(events []*types.AgentEvent, err error) {
+				log.Warnf("Failed to send %d events, buffering: %v", len(events), err)
+				for _, event := range events {
+					if _, err := buf.Store(event); err != nil {
+						log.Errorf("Failed to buffer event: %v", err)
+					}
+				}
+			},
+		}
 
-		// Check backend connectivity
-		log.Info("Checking backend connectivity...")
-		if err := apiClient.HealthCheck(); err != nil {
-			log.Warnf("Backend health check failed: %v", err)
-			log.Info("Will buffer events locally until backend is available")
+		// Events go to the backend client unless a local sink is configured
+		var apiClient *client.Client
+		var eventSink sink.Sink
+		progress := io.Writer(os.Stdout)
+		if cfg.Sink.Local() {
+			localSink, err := openLocalSink(cfg)
+			if err != nil {
+				return err
+			}
+			defer localSink.Close()
+			eventSink = localSink
+			log.Infof("Writing events to the %s sink instead of the backend", cfg.Sink.Type)
+
+			// Keep stdout clean for the event stream
+			if cfg.Sink.Type == "stdout" {
+				progress = os.Stderr
+			}
 		} else {
-			log.Info("Backend is reachable")
+			apiClient = client.NewClient(clientConfig)
+			apiClient.Start()
+			defer apiClient.Stop()
+			eventSink = apiClient
+
+			// Check backend connectivity
+			log.Info("Checking backend connectivity...")
+			if err := apiClient.HealthCheck(); err != nil {
+				log.Warnf("Backend health check failed: %v", err)
+				log.Info("Will buffer events locally until backend is available")
+			} else {
+				log.Info("Backend is reachable")
+				if _, err := apiClient.Negotiate(); err != nil {
+					log.Warnf("Failed to query backend capabilities: %v", err)
+				}
+			}
 		}
 
 		// Initialize file watcher
 		watcherConfig := watcher.Config{
-			Registry:       registry,
-			EventQueueSize: 1000,
-			DebounceMs:     100,
-			Logger:         log,
+			Registry:        registry,
+			EventQueueSize:  1000,
+			DebounceMs:      100,
+			Logger:          log,
+			Filters:         agentPathFilters(cfg),
+			AgentDebounceMs: agentDebounces(cfg),
+			Discovery:       agentDiscovery(cfg),
+		}
+
+		// Resume watched files where the last run stopped, past anything
+		// backfill has collected from them since
+		offsetStore, err := watcher.NewOffsetStore(cfg.Buffer.DBPath)
+		if err != nil {
+			log.Warnf("Failed to open watcher offsets, active files may be emitted again: %v", err)
+		} else {
+			defer offsetStore.Close()
+			if pruned, err := offsetStore.PruneMissing(); err != nil {
+				log.Warnf("Failed to prune watcher offsets: %v", err)
+			} else if pruned > 0 {
+				log.Debugf("Pruned watcher offsets of %d deleted files", pruned)
+			}
+			watcherConfig.Offsets = offsetStore
+		}
+		backfillState, err := backfill.NewStateStore(cfg.Buffer.DBPath)
+		if err != nil {
+			log.Warnf("Failed to open backfill state: %v", err)
+		} else {
+			defer backfillState.Close()
+			watcherConfig.ProcessedBytes = func(agentName, filePath string) int64 {
+				state, err := backfillState.Load(agentName, filePath)
+				if err != nil {
+					return 0
+				}
+				return state.LastByteOffset
+			}
 		}
 		fileWatcher, err := watcher.NewWatcher(watcherConfig)
 		if err != nil {
@@ -160,9 +553,23 @@ watching. Use --no-history to skip historical sync (for power users).`,
 			return fmt.Errorf("failed to start watcher: %w", err)
 		}
 
+		// Serve live status for the status command and monitoring
+		if cfg.StatusAddr != "" {
+			startedAt := time.Now()
+			statusServer := status.NewServer(func() status.Snapshot {
+				return collectorSnapshot(startedAt, cfg, fileWatcher, apiClient, buf)
+			}, log)
+			if err := statusServer.Start(cfg.StatusAddr); err != nil {
+				log.Warnf("Status server not started: %v", err)
+			} else {
+				defer statusServer.Stop(context.Background())
+				log.Infof("Serving status on http://%s/status", statusServer.Addr())
+			}
+		}
+
 		// Discover and watch agent logs
 		log.Info("Discovering agent logs...")
-		discovered, err := watcher.DiscoverAllAgentLogs()
+		discovered, err := discoverAgentLogs(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to discover logs: %w", err)
 		}
@@ -170,14 +577,18 @@ watching. Use --no-history to skip historical sync (for power users).`,
 		// Create context for graceful shutdown
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
+		registry.SetContext(ctx)
 
-		// Sync historical data before starting watcher (unless --no-history)
+		// Sync historical data before starting watcher (unless --watch-only)
 		if !skipHistory {
 			log.Info("Syncing historical data...")
 
 			// Initialize hierarchy cache with client for backfill
-			hierarchyCacheWithClient := hierarchy.NewHierarchyCache(apiClient, log)
+			hierarchyCacheWithClient, closeBackfillCache := newHierarchyCache(cfg, apiClient)
+			defer closeBackfillCache()
 			backfillRegistry := adapters.DefaultRegistry(cfg.ProjectID, hierarchyCacheWithClient, log)
+			configureAdapters(backfillRegistry, cfg)
+			backfillRegistry.SetContext(ctx)
 
 			// Create backfill manager
 			backfillConfig := backfill.Config{
@@ -185,6 +596,10 @@ watching. Use --no-history to skip historical sync (for power users).`,
 				Buffer:      buf,
 				Client:      apiClient,
 				StateDBPath: cfg.Buffer.DBPath,
+				Dedup:       sentIndex,
+				Grace:       backfillGracePolicy(cfg),
+				ParseErrors: backfillParseErrorPolicy(cfg),
+				MaxFileSize: cfg.Collection.BackfillMaxFileBytes,
 				Logger:      log,
 			}
 			manager, err := backfill.NewBackfillManager(backfillConfig)
@@ -193,56 +608,51 @@ watching. Use --no-history to skip historical sync (for power users).`,
 			} else {
 				defer manager.Close()
 
-				// Calculate date range for initial sync
-				fromDate := time.Now().AddDate(0, 0, -initialSyncDays)
-				toDate := time.Now()
-
 				totalSynced := 0
 				totalSkipped := 0
-				totalSources := 0
-				currentSource := 0
-
-				// Count total sources first
-				for _, logs := range discovered {
-					totalSources += len(logs)
-				}
 
 				syncStartTime := time.Now()
 
 				for agentName, logs := range discovered {
 					adapterName := mapAgentName(agentName)
 
+					logPaths := make([]string, 0, len(logs))
 					for _, logInfo := range logs {
-						currentSource++
-						// Show progress
-						fmt.Printf("\r🔄 Syncing [%d/%d]: %s...", currentSource, totalSources, filepath.Base(filepath.Dir(logInfo.Path)))
-
-						bfConfig := backfill.BackfillConfig{
-							AgentName: adapterName,
-							LogPath:   logInfo.Path,
-							FromDate:  fromDate,
-							ToDate:    toDate,
-							BatchSize: 100,
-						}
+						logPaths = append(logPaths, logInfo.Path)
+					}
 
-						result, err := manager.Backfill(ctx, bfConfig)
-						if err != nil {
-							log.Warnf("Failed to sync historical data for %s: %v", logInfo.Path, err)
+					// Show progress
+					fmt.Fprintf(progress, "\r🔄 Syncing %d %s sources...", len(logPaths), agentName)
+
+					bfConfig := backfill.BackfillConfig{
+						AgentName:  adapterName,
+						FromDate:   syncFrom,
+						ToDate:     syncTo,
+						BatchSize:  100,
+						Workers:    cfg.Collection.BackfillWorkers,
+						Filter:     agentPathFilter(cfg, agentName),
+						ProgressCB: newProgressPrinter(progress, fmt.Sprintf("🔄 Syncing %d %s sources", len(logPaths), agentName)),
+					}
+
+					result, err := manager.BackfillPaths(ctx, bfConfig, logPaths)
+					if err != nil {
+						log.Warnf("Failed to sync historical data for %s: %v", agentName, err)
+						if result == nil {
 							continue
 						}
-
-						totalSynced += result.ProcessedEvents
-						totalSkipped += result.SkippedEvents
 					}
+
+					totalSynced += result.ProcessedEvents
+					totalSkipped += result.SkippedEvents
 				}
 
 				syncDuration := time.Since(syncStartTime)
-				fmt.Println() // New line after progress
+				fmt.Fprintln(progress) // New line after progress
 				log.Infof("✅ Historical sync complete in %s: %d events synced, %d skipped (already synced)", 
 					syncDuration.Round(time.Millisecond), totalSynced, totalSkipped)
 			}
 		} else {
-			log.Info("Skipping historical sync (--no-history flag)")
+			log.Info("Skipping historical sync (watch only)")
 		}
 
 		for agentName, logs := range discovered {
@@ -270,78 +680,125 @@ watching. Use --no-history to skip historical sync (for power users).`,
 			}
 		}
 
-		// Start dynamic workspace discovery (check every 60 seconds for new workspaces)
-		fileWatcher.StartDynamicDiscovery(60*time.Second, func(path string, adapter adapters.AgentAdapter) {
+		// Start dynamic workspace discovery, rescanning for new workspaces
+		// every --discovery-interval
+		started := fileWatcher.StartDynamicDiscovery(discoveryInterval, func(path string, adapter adapters.AgentAdapter) {
 			log.Infof("New workspace discovered: %s", path)
 			if err := fileWatcher.Watch(path, adapter); err != nil {
 				log.Warnf("Failed to watch new workspace %s: %v", path, err)
 			}
 		})
+		if !started {
+			log.Info("Dynamic workspace discovery disabled (--discovery-interval 0)")
+		}
+
+		// Queue an event for sending. An error means the event was not
+		// queued (backend down); events in batches that later fail are
+		// buffered by OnSendFailure.
+		queueEvent := func(event *types.AgentEvent) {
+			if !sentIndex.Claim(buffer.EventHash(event)) {
+				log.Debugf("Skipping already sent event %s (%s)", event.ID, event.Type)
+				return
+			}
+			if err := eventSink.SendEvent(event); err != nil {
+				log.Debugf("Failed to queue event, buffering: %v", err)
+				// Buffer if send fails
+				if _, err := buf.Store(event); err != nil {
+					log.Errorf("Failed to buffer event: %v", err)
+				}
+			}
+		}
+
+		// Background loops, waited for on shutdown before draining
+		var workers sync.WaitGroup
 
 		// Process events from watcher to client
+		workers.Add(1)
 		go func() {
+			defer workers.Done()
 			for {
 				select {
 				case <-ctx.Done():
-					return
-				case event := <-fileWatcher.EventQueue():
-					// Try to send immediately
-					if err := apiClient.SendEvent(event); err != nil {
-						log.Warnf("Failed to send event, buffering: %v", err)
-						// Buffer if send fails
-						if err := buf.Store(event); err != nil {
-							log.Errorf("Failed to buffer event: %v", err)
+					// Queue what the watcher already emitted so it is drained
+					for {
+						select {
+						case event := <-fileWatcher.EventQueue():
+							queueEvent(event)
+						default:
+							return
 						}
 					}
+				case event := <-fileWatcher.EventQueue():
+					queueEvent(event)
 				}
 			}
 		}()
 
-		// Periodically flush buffered events
+		// Periodically flush buffered events, jittered so collectors that
+		// came back together don't flush in lockstep
+		workers.Add(1)
 		go func() {
-			ticker := time.NewTicker(30 * time.Second)
-			defer ticker.Stop()
+			defer workers.Done()
+			timer := time.NewTimer(client.Jitter(bufferFlushInterval, 0.2))
+			defer timer.Stop()
 
 			for {
 				select {
 				case <-ctx.Done():
 					return
-				case <-ticker.C:
+				case <-timer.C:
+					timer.Reset(client.Jitter(bufferFlushInterval, 0.2))
+
 					count, _ := buf.Count()
 					if count == 0 {
 						continue
 					}
 
+					// Leave events buffered while the backend is known to be down
+					if apiClient != nil && apiClient.CircuitOpen() {
+						log.Debugf("Backend unavailable, keeping %d events buffered", count)
+						continue
+					}
+
 					log.Infof("Attempting to flush %d buffered events", count)
 
-					// Retrieve events from buffer
-					events, err := buf.Retrieve(cfg.Collection.BatchSize)
+					// Send the backlog in batches, oldest first
+					flushed, err := flushBacklog(ctx, apiClient, eventSink, buf, cfg.Collection.BatchSize, cfg.Collection.FlushWorkers)
+					if flushed > 0 {
+						log.Infof("Flushed %d buffered events", flushed)
+					}
 					if err != nil {
-						log.Errorf("Failed to retrieve buffered events: %v", err)
-						continue
+						log.Warnf("Failed to send buffered events: %v", err)
 					}
+				}
+			}
+		}()
 
-					// Try to send each buffered event
-					sentIDs := []string{}
-					for _, event := range events {
-						if err := apiClient.SendEvent(event); err != nil {
-							log.Warnf("Failed to send buffered event: %v", err)
-							break // Stop if send fails
-						}
-						sentIDs = append(sentIDs, event.ID)
+		// Periodically prune buffered events, and the dedup hashes of sent
+		// ones, older than the retention window
+		if retention, _ := cfg.GetBufferRetention(); retention > 0 {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				ticker := time.NewTicker(time.Hour)
+				defer ticker.Stop()
+
+				for {
+					if _, err := buf.PruneOlderThan(retention); err != nil {
+						log.Warnf("Failed to prune buffer: %v", err)
+					}
+					if _, err := sentIndex.PruneOlderThan(retention); err != nil {
+						log.Warnf("Failed to prune dedup index: %v", err)
 					}
 
-					// Delete successfully sent events
-					if len(sentIDs) > 0 {
-						if err := buf.Delete(sentIDs); err != nil {
-							log.Errorf("Failed to delete sent events: %v", err)
-						} else {
-							log.Infof("Flushed %d buffered events", len(sentIDs))
-						}
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
 					}
 				}
-			}
-		}()
+			}()
+		}
 
 		log.Info("Collector started successfully")
 		log.Info("Press Ctrl+C to stop gracefully")
@@ -352,10 +809,23 @@ watching. Use --no-history to skip historical sync (for power users).`,
 		<-sigChan
 
 		log.Info("Shutting down gracefully...")
-		cancel()
 
-		// Give components time to clean up
-		time.Sleep(2 * time.Second)
+		// Stop intake, then send what is still queued or buffered, all
+		// within the shutdown timeout
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelDrain()
+		cancel()
+		stopped := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-drainCtx.Done():
+		}
+		drained, left := drainOnShutdown(drainCtx, apiClient, eventSink, buf, cfg.Collection.BatchSize)
+		log.Infof("Drained %d events on shutdown, %d left buffered", drained, left)
 
 		log.Info("Collector stopped")
 		return nil
@@ -367,6 +837,10 @@ var versionCmd = &cobra.Command{
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Devlog Collector v%s\n", version)
+
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			checkForUpdate(os.Stdout, latestReleaseURL, version)
+		}
 	},
 }
 
@@ -388,55 +862,80 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("⚠️  Configuration: Failed to load (%v)\n", err)
 		} else {
-			fmt.Printf("✅ Configuration: Loaded from %s\n", configPath)
+			fmt.Printf("✅ Configuration: Loaded from %s (%s)\n", configPath, configSource)
 			fmt.Printf("   Backend URL: %s\n", cfg.BackendURL)
 			fmt.Printf("   Project ID: %s\n", cfg.ProjectID)
 		}
 		fmt.Println()
 
-		// Check backend connectivity
-		if cfg != nil {
-			batchInterval, _ := cfg.GetBatchInterval()
-			clientConfig := client.Config{
-				BaseURL:    cfg.BackendURL,
-				APIKey:     cfg.APIKey,
-				BatchSize:  cfg.Collection.BatchSize,
-				BatchDelay: batchInterval,
-				MaxRetries: cfg.Collection.MaxRetries,
-				Logger:     log,
-			}
-			apiClient := client.NewClient(clientConfig)
-			if err := apiClient.HealthCheck(); err != nil {
-				fmt.Printf("❌ Backend: Unreachable (%v)\n", err)
-			} else {
-				fmt.Printf("✅ Backend: Connected\n")
+		// Ask a running collector first; inspect its files only if none is up
+		if live := fetchLiveStatus(cfg); live != nil {
+			printLiveStatus(os.Stdout, live)
+			fmt.Println()
+		} else {
+			// Check backend connectivity
+			if cfg != nil && cfg.Sink.Local() {
+				fmt.Printf("📝 Sink: %s (backend not used)\n", cfg.Sink.Type)
+			} else if cfg != nil {
+				batchInterval, _ := cfg.GetBatchInterval()
+				clientConfig := client.Config{
+					BaseURL:    cfg.BackendURL,
+					APIKey:     cfg.APIKey,
+					BatchSize:  cfg.Collection.BatchSize,
+					BatchDelay: batchInterval,
+					MaxRetries: cfg.Collection.MaxRetries,
+					Logger:     log,
+
+					Proxy:              cfg.Proxy,
+					CACertPath:         cfg.CACertPath,
+					InsecureSkipVerify: cfg.InsecureSkipVerify,
+					ClientCertPath:     cfg.ClientCertPath,
+					ClientKeyPath:      cfg.ClientKeyPath,
+
+					IngestPath:      cfg.IngestPath,
+					SingleEventPath: cfg.SingleEventPath,
+					HealthPath:      cfg.HealthPath,
+				}
+				apiClient := client.NewClient(clientConfig)
+				if err := apiClient.HealthCheck(); err != nil {
+					fmt.Printf("❌ Backend: Unreachable (%v)\n", err)
+				} else {
+					fmt.Printf("✅ Backend: Connected\n")
+					if caps, err := apiClient.Negotiate(); err == nil {
+						if caps.Negotiated {
+							fmt.Printf("   Ingest: %s (schema v%d)\n", caps.IngestPath, caps.SchemaVersion)
+						} else {
+							fmt.Printf("   Ingest: %s (default, backend reports no capabilities)\n", caps.IngestPath)
+						}
+					}
+				}
 			}
-		}
-		fmt.Println()
+			fmt.Println()
 
-		// Check buffer state
-		if cfg != nil {
-			bufferConfig := buffer.Config{
-				DBPath:  cfg.Buffer.DBPath,
-				MaxSize: cfg.Buffer.MaxSize,
-				Logger:  log,
-			}
-			buf, err := buffer.NewBuffer(bufferConfig)
-			if err == nil {
-				defer buf.Close()
-				count, _ := buf.Count()
-				if count > 0 {
-					fmt.Printf("📦 Buffer: %d events pending\n", count)
-				} else {
-					fmt.Printf("📦 Buffer: Empty (all events synced)\n")
+			// Check buffer state
+			if cfg != nil {
+				bufferConfig := buffer.Config{
+					DBPath:  cfg.Buffer.DBPath,
+					MaxSize: cfg.Buffer.MaxSize,
+					Logger:  log,
+				}
+				buf, err := buffer.NewBuffer(bufferConfig)
+				if err == nil {
+					defer buf.Close()
+					count, _ := buf.Count()
+					if count > 0 {
+						fmt.Printf("📦 Buffer: %d events pending\n", count)
+					} else {
+						fmt.Printf("📦 Buffer: Empty (all events synced)\n")
+					}
 				}
 			}
+			fmt.Println()
 		}
-		fmt.Println()
 
 		// Discover all agent logs and show sync status
 		fmt.Println("🤖 Agents:")
-		discovered, err := watcher.DiscoverAllAgentLogs()
+		discovered, err := discoverAgentLogs(cfg)
 		if err != nil {
 			fmt.Printf("   ⚠️  Failed to discover agents: %v\n", err)
 			return nil
@@ -497,6 +996,9 @@ var statusCmd = &cobra.Command{
 				case backfill.StatusInProgress:
 					fmt.Printf("      📁 %s - 🔄 syncing (%d events)\n", 
 						filepath.Base(filepath.Dir(logInfo.Path)), state.TotalEventsProcessed)
+				case backfill.StatusRetrying:
+					fmt.Printf("      📁 %s - 🔁 retrying (attempt %d): %s\n",
+						filepath.Base(filepath.Dir(logInfo.Path)), state.RetryCount, state.ErrorMessage)
 				case backfill.StatusFailed:
 					fmt.Printf("      📁 %s - ❌ failed: %s\n", 
 						filepath.Base(filepath.Dir(logInfo.Path)), state.ErrorMessage)
@@ -526,13 +1028,13 @@ var backfillRunCmd = &cobra.Command{
 	Long: `Process historical logs for the specified agent and date range.
 
 DEPRECATED: The 'start' command now automatically syncs historical data.
-Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-history'.`,
+Use 'devlog-collector start' instead. To skip historical sync, use 'start --watch-only'.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Show deprecation warning
 		fmt.Println("⚠️  DEPRECATED: The 'backfill run' command is deprecated.")
 		fmt.Println("   The 'start' command now automatically syncs historical data.")
 		fmt.Println("   Use 'devlog-collector start' for automatic sync,")
-		fmt.Println("   or 'devlog-collector start --no-history' to skip historical sync.")
+		fmt.Println("   or 'devlog-collector start --watch-only' to skip historical sync.")
 		fmt.Println()
 
 		// Load configuration
@@ -541,16 +1043,24 @@ Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-h
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+		configureLogging(cfg)
 
 		// Parse flags
 		agentName, _ := cmd.Flags().GetString("agent")
 		fromDate, _ := cmd.Flags().GetString("from")
 		toDate, _ := cmd.Flags().GetString("to")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		verify, _ := cmd.Flags().GetBool("verify")
+		dumpErrors, _ := cmd.Flags().GetBool("dump-errors")
 		days, _ := cmd.Flags().GetInt("days")
 		allWorkspaces, _ := cmd.Flags().GetBool("all-workspaces")
+		workers, _ := cmd.Flags().GetInt("workers")
 		specificWorkspaces, _ := cmd.Flags().GetStringSlice("workspaces")
 
+		if workers <= 0 {
+			workers = cfg.Collection.BackfillWorkers
+		}
+
 		// Parse dates
 		var from, to time.Time
 		if fromDate != "" {
@@ -583,23 +1093,22 @@ Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-h
 		}
 		defer buf.Close()
 
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to open dedup index: %w", err)
+		}
+		defer sentIndex.Close()
+
 		// Initialize API client
-		batchInterval, _ := cfg.GetBatchInterval()
-		clientConfig := client.Config{
-			BaseURL:    cfg.BackendURL,
-			APIKey:     cfg.APIKey,
-			BatchSize:  cfg.Collection.BatchSize,
-			BatchDelay: batchInterval,
-			MaxRetries: cfg.Collection.MaxRetries,
-			Logger:     log,
-		}
-		apiClient := client.NewClient(clientConfig)
+		apiClient := newBackfillClient(cfg)
 		apiClient.Start()
 		defer apiClient.Stop()
 
 		// Initialize hierarchy cache and adapters (needs client)
-		hiererchyCache := hierarchy.NewHierarchyCache(apiClient, log)
+		hiererchyCache, closeHierarchyCache := newHierarchyCache(cfg, apiClient)
+		defer closeHierarchyCache()
 		registry := adapters.DefaultRegistry(cfg.ProjectID, hiererchyCache, log)
+		configureAdapters(registry, cfg)
 
 		// Create backfill manager
 		backfillConfig := backfill.Config{
@@ -607,6 +1116,10 @@ Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-h
 			Buffer:      buf,
 			Client:      apiClient,
 			StateDBPath: cfg.Buffer.DBPath,
+			Dedup:       sentIndex,
+			Grace:       backfillGracePolicy(cfg),
+			ParseErrors: backfillParseErrorPolicy(cfg),
+			MaxFileSize: cfg.Collection.BackfillMaxFileBytes,
 			Logger:      log,
 		}
 		manager, err := backfill.NewBackfillManager(backfillConfig)
@@ -672,60 +1185,47 @@ Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-h
 			logPaths = []string{logPath}
 		}
 
-		// Progress callback
-		startTime := time.Now()
-		progressFunc := func(p backfill.Progress) {
-			elapsed := time.Since(startTime)
-			eventsPerSec := float64(p.EventsProcessed) / elapsed.Seconds()
-
-			fmt.Printf("\rProgress: [%-20s] %.1f%% | Events: %d | Speed: %.1f/s",
-				progressBar(p.Percentage),
-				p.Percentage,
-				p.EventsProcessed,
-				eventsPerSec,
-			)
-		}
+		progressFunc := newProgressPrinter(os.Stdout, "Progress")
 
-		// Run backfill for each log path
-		ctx := context.Background()
+		// Run backfill across all log paths; Ctrl+C stops every worker
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		registry.SetContext(ctx)
 		adapterName := mapAgentName(agentName)
 
-		// Aggregate results
-		totalResult := &backfill.BackfillResult{}
-		overallStart := time.Now()
+		if len(logPaths) > 1 {
+			fmt.Printf("\nProcessing %d workspaces with %d workers\n", len(logPaths), workers)
+		}
 
-		for i, logPath := range logPaths {
-			if len(logPaths) > 1 {
-				fmt.Printf("\n[%d/%d] Processing: %s\n", i+1, len(logPaths), logPath)
-			}
+		bfConfig := backfill.BackfillConfig{
+			AgentName:  adapterName,
+			FromDate:   from,
+			ToDate:     to,
+			DryRun:     dryRun,
+			BatchSize:  100,
+			Workers:    workers,
+			Filter:     agentPathFilter(cfg, agentName),
+			DumpErrors: dumpErrors,
+			ProgressCB: progressFunc,
+		}
 
-			bfConfig := backfill.BackfillConfig{
-				AgentName:  adapterName,
-				LogPath:    logPath,
-				FromDate:   from,
-				ToDate:     to,
-				DryRun:     dryRun,
-				BatchSize:  100,
-				ProgressCB: progressFunc,
+		if verify {
+			drifts, err := manager.Verify(ctx, bfConfig, logPaths)
+			if err != nil && drifts == nil {
+				return fmt.Errorf("verify failed: %w", err)
 			}
+			printVerifyReport(drifts)
+			return err
+		}
 
-			result, err := manager.Backfill(ctx, bfConfig)
-			if err != nil {
-				log.Warnf("Failed to process %s: %v", logPath, err)
-				totalResult.ErrorEvents++
-				continue
+		totalResult, err := manager.BackfillPaths(ctx, bfConfig, logPaths)
+		if err != nil {
+			log.Warnf("Backfill interrupted: %v", err)
+			if totalResult == nil {
+				return err
 			}
-
-			// Aggregate results
-			totalResult.TotalEvents += result.TotalEvents
-			totalResult.ProcessedEvents += result.ProcessedEvents
-			totalResult.SkippedEvents += result.SkippedEvents
-			totalResult.ErrorEvents += result.ErrorEvents
-			totalResult.BytesProcessed += result.BytesProcessed
 		}
 
-		totalResult.Duration = time.Since(overallStart)
-
 		// Print summary
 		fmt.Println("\n\n✓ Backfill completed")
 		if len(logPaths) > 1 {
@@ -739,11 +1239,137 @@ Use 'devlog-collector start' instead. To skip historical sync, use 'start --no-h
 			fmt.Printf("Throughput: %.1f events/sec\n", float64(totalResult.ProcessedEvents)/totalResult.Duration.Seconds())
 		}
 		fmt.Printf("Data processed: %.2f MB\n", float64(totalResult.BytesProcessed)/(1024*1024))
+		printModelUsage(totalResult.Models)
+		printParseErrors(totalResult.ParseErrors, dumpErrors)
+		printSkippedFiles(totalResult.SkippedFiles)
 
 		return nil
 	},
 }
 
+// newBackfillClient creates the API client backfill commands send events
+// through
+func newBackfillClient(cfg *config.Config) *client.Client {
+	batchInterval, _ := cfg.GetBatchInterval()
+	maxBackoff, _ := cfg.GetMaxBackoff()
+	return client.NewClient(client.Config{
+		BaseURL:    cfg.BackendURL,
+		APIKey:     cfg.APIKey,
+		BatchSize:  cfg.Collection.BatchSize,
+		BatchDelay: batchInterval,
+		MaxRetries: cfg.Collection.MaxRetries,
+		MaxBackoff: maxBackoff,
+		Logger:     log,
+
+		Proxy:              cfg.Proxy,
+		CACertPath:         cfg.CACertPath,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ClientCertPath:     cfg.ClientCertPath,
+		ClientKeyPath:      cfg.ClientKeyPath,
+
+		IngestPath:      cfg.IngestPath,
+		SingleEventPath: cfg.SingleEventPath,
+		HealthPath:      cfg.HealthPath,
+
+		Destinations: clientDestinations(cfg),
+
+		MaxEventBytes:   cfg.Collection.MaxEventBytes,
+		MaxEventsPerSec: cfg.Collection.MaxEventsPerSec,
+		SequencePath:    batchSequencePath(cfg, backfillStream),
+		SequenceStream:  backfillStream,
+
+		Validation:     client.ValidationMode(cfg.Collection.Validation),
+		DeadLetterPath: cfg.Collection.DeadLetterPath,
+
+		AuditLogPath:     cfg.Collection.AuditLogPath,
+		AuditLogMaxBytes: cfg.Collection.AuditLogMaxBytes,
+	})
+}
+
+// printParseErrors lists the files with lines that failed to parse and,
+// when they were dumped, where the lines were written
+func printParseErrors(parseErrors map[string]int, dumped bool) {
+	if len(parseErrors) == 0 {
+		return
+	}
+
+	files := make([]string, 0, len(parseErrors))
+	for file := range parseErrors {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	fmt.Printf("\nParse errors in %d files:\n", len(files))
+	for _, file := range files {
+		if dumped {
+			fmt.Printf("  %s: %d lines (see %s)\n", file, parseErrors[file], backfill.QuarantinePath(file))
+		} else {
+			fmt.Printf("  %s: %d lines\n", file, parseErrors[file])
+		}
+	}
+	if !dumped {
+		fmt.Println("Run again with --dump-errors to write the failing lines next to each file.")
+	}
+}
+
+// printModelUsage lists the turns and tokens of each model, most tokens first
+func printModelUsage(models map[string]adapters.ModelUsage) {
+	if len(models) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if models[names[i]].Tokens != models[names[j]].Tokens {
+			return models[names[i]].Tokens > models[names[j]].Tokens
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Println("Models used:")
+	for _, name := range names {
+		fmt.Printf("  %s: %d turns, %d tokens\n", name, models[name].Turns, models[name].Tokens)
+	}
+}
+
+// printSkippedFiles lists the files skipped for looking binary or being too large
+func printSkippedFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	fmt.Printf("\nSkipped %d files that don't look like text logs or exceed collection.backfillMaxFileBytes:\n", len(files))
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+}
+
+// printVerifyReport lists the files whose re-parsed events differ from
+// what was recorded when they were backfilled
+func printVerifyReport(drifts []*backfill.FileDrift) {
+	drifted := 0
+	for _, drift := range drifts {
+		diffs := drift.Differences()
+		if len(diffs) == 0 {
+			continue
+		}
+		drifted++
+		fmt.Printf("⚠️  %s\n", drift.FilePath)
+		for _, diff := range diffs {
+			fmt.Printf("    %s\n", diff)
+		}
+	}
+
+	if drifted == 0 {
+		fmt.Printf("✓ Verified %d files: no drift\n", len(drifts))
+		return
+	}
+	fmt.Printf("\n%d of %d files drifted. Run 'sync reset' and backfill again to resend them.\n", drifted, len(drifts))
+}
+
 var backfillStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check backfill status",
@@ -754,6 +1380,7 @@ var backfillStatusCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+		configureLogging(cfg)
 
 		// Create backfill manager
 		backfillConfig := backfill.Config{
@@ -794,6 +1421,9 @@ var backfillStatusCmd = &cobra.Command{
 			if state.CompletedAt != nil {
 				fmt.Printf("  Completed: %s\n", state.CompletedAt.Format(time.RFC3339))
 			}
+			if state.RetryCount > 0 {
+				fmt.Printf("  Failed attempts: %d\n", state.RetryCount)
+			}
 			if state.ErrorMessage != "" {
 				fmt.Printf("  Error: %s\n", state.ErrorMessage)
 			}
@@ -804,20 +1434,99 @@ var backfillStatusCmd = &cobra.Command{
 	},
 }
 
-func progressBar(percentage float64) string {
-	filled := int(percentage / 5) // 20 chars = 100%
-	if filled > 20 {
-		filled = 20
-	}
-	bar := ""
-	for i := 0; i < 20; i++ {
-		if i < filled {
-			bar += "█"
-		} else {
-			bar += "░"
+var backfillResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume interrupted backfills",
+	Long: `Continue every paused or interrupted backfill of an agent from where it stopped.
+Use 'backfill status' to see which files are paused.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
 		}
-	}
-	return bar
+		configureLogging(cfg)
+
+		agentName, _ := cmd.Flags().GetString("agent")
+		adapterName := mapAgentName(agentName)
+
+		// Initialize buffer
+		bufferConfig := buffer.Config{
+			DBPath:  cfg.Buffer.DBPath,
+			MaxSize: cfg.Buffer.MaxSize,
+			Logger:  log,
+		}
+		buf, err := buffer.NewBuffer(bufferConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create buffer: %w", err)
+		}
+		defer buf.Close()
+
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to open dedup index: %w", err)
+		}
+		defer sentIndex.Close()
+
+		apiClient := newBackfillClient(cfg)
+		apiClient.Start()
+		defer apiClient.Stop()
+
+		hierarchyCache, closeHierarchyCache := newHierarchyCache(cfg, apiClient)
+		defer closeHierarchyCache()
+		registry := adapters.DefaultRegistry(cfg.ProjectID, hierarchyCache, log)
+		configureAdapters(registry, cfg)
+
+		manager, err := backfill.NewBackfillManager(backfill.Config{
+			Registry:    registry,
+			Buffer:      buf,
+			Client:      apiClient,
+			StateDBPath: cfg.Buffer.DBPath,
+			Dedup:       sentIndex,
+			Grace:       backfillGracePolicy(cfg),
+			ParseErrors: backfillParseErrorPolicy(cfg),
+			MaxFileSize: cfg.Collection.BackfillMaxFileBytes,
+			Logger:      log,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create backfill manager: %w", err)
+		}
+		defer manager.Close()
+
+		// Ctrl+C pauses the file being resumed again
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		registry.SetContext(ctx)
+
+		result, err := manager.Resume(ctx, adapterName)
+		if errors.Is(err, backfill.ErrNoPausedBackfill) {
+			fmt.Printf("No paused backfill for agent: %s\n", agentName)
+			return nil
+		}
+		if err != nil {
+			log.Warnf("Resume incomplete: %v", err)
+			if result == nil {
+				return err
+			}
+		}
+
+		for _, file := range result.ResumedFiles {
+			fmt.Printf("Resumed: %s\n", file)
+		}
+
+		fmt.Println("\n✓ Backfill resumed")
+		fmt.Printf("Files resumed: %d\n", len(result.ResumedFiles))
+		fmt.Printf("Duration: %s\n", result.Duration)
+		fmt.Printf("Events processed: %d\n", result.ProcessedEvents)
+		fmt.Printf("Events skipped: %d (duplicates)\n", result.SkippedEvents)
+		fmt.Printf("Errors: %d\n", result.ErrorEvents)
+		fmt.Printf("Data processed: %.2f MB\n", float64(result.BytesProcessed)/(1024*1024))
+		printParseErrors(result.ParseErrors, false)
+		printSkippedFiles(result.SkippedFiles)
+
+		return err
+	},
 }
 
 var syncCmd = &cobra.Command{
@@ -829,7 +1538,11 @@ var syncCmd = &cobra.Command{
 var syncStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check sync status for all agents",
-	Long:  "Display the synchronization status for all discovered agent logs",
+	Long: `Display the synchronization status for all discovered agent logs.
+
+--stale flags logs whose last completed sync is older than the given duration,
+or that never completed one; --only-problems lists just the pending, failed
+and stale ones.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
 		var err error
@@ -837,6 +1550,7 @@ var syncStatusCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+		configureLogging(cfg)
 
 		// Create backfill manager (uses same state store)
 		backfillConfig := backfill.Config{
@@ -851,9 +1565,15 @@ var syncStatusCmd = &cobra.Command{
 
 		// Get agent filter
 		agentFilter, _ := cmd.Flags().GetString("agent")
+		staleAfter, _ := cmd.Flags().GetDuration("stale")
+		onlyProblems, _ := cmd.Flags().GetBool("only-problems")
+		if staleAfter < 0 {
+			return fmt.Errorf("--stale must not be negative")
+		}
+		now := time.Now()
 
 		// Discover all agent logs
-		discovered, err := watcher.DiscoverAllAgentLogs()
+		discovered, err := discoverAgentLogs(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to discover logs: %w", err)
 		}
@@ -865,6 +1585,7 @@ var syncStatusCmd = &cobra.Command{
 		totalSynced := 0
 		totalPending := 0
 		totalInProgress := 0
+		totalStale := 0
 
 		for agentName, logs := range discovered {
 			adapterName := mapAgentName(agentName)
@@ -874,10 +1595,9 @@ var syncStatusCmd = &cobra.Command{
 				continue
 			}
 
-			fmt.Printf("🤖 %s (%d log sources)\n", agentName, len(logs))
-
 			states, err := manager.Status(adapterName)
 			if err != nil {
+				fmt.Printf("🤖 %s (%d log sources)\n", agentName, len(logs))
 				fmt.Printf("   ❌ Error getting status: %v\n", err)
 				continue
 			}
@@ -888,16 +1608,36 @@ var syncStatusCmd = &cobra.Command{
 				stateMap[state.LogFilePath] = state
 			}
 
+			headerShown := false
 			for _, logInfo := range logs {
-				state, exists := stateMap[logInfo.Path]
-				if !exists {
-					fmt.Printf("   📁 %s\n", logInfo.Path)
+				state := stateMap[logInfo.Path]
+				stale := syncIsStale(state, staleAfter, now)
+				if stale {
+					totalStale++
+				}
+				if onlyProblems && !syncNeedsAttention(state, stale) {
+					if state.Status == backfill.StatusCompleted {
+						totalSynced++
+					} else {
+						totalInProgress++
+					}
+					continue
+				}
+
+				if !headerShown {
+					fmt.Printf("🤖 %s (%d log sources)\n", agentName, len(logs))
+					headerShown = true
+				}
+				fmt.Printf("   📁 %s\n", logInfo.Path)
+				if stale {
+					fmt.Printf("      ⚠️  Stale: %s\n", staleDescription(state, now))
+				}
+				if state == nil {
 					fmt.Printf("      Status: ⏳ pending (not yet synced)\n")
 					totalPending++
 					continue
 				}
 
-				fmt.Printf("   📁 %s\n", logInfo.Path)
 				switch state.Status {
 				case backfill.StatusCompleted:
 					fmt.Printf("      Status: ✅ synced (%d events)\n", state.TotalEventsProcessed)
@@ -911,6 +1651,9 @@ var syncStatusCmd = &cobra.Command{
 				case backfill.StatusPaused:
 					fmt.Printf("      Status: ⏸️  paused (%d events)\n", state.TotalEventsProcessed)
 					totalPending++
+				case backfill.StatusRetrying:
+					fmt.Printf("      Status: 🔁 retrying (attempt %d): %s\n", state.RetryCount, state.ErrorMessage)
+					totalPending++
 				case backfill.StatusFailed:
 					fmt.Printf("      Status: ❌ failed: %s\n", state.ErrorMessage)
 					totalPending++
@@ -919,18 +1662,87 @@ var syncStatusCmd = &cobra.Command{
 					totalPending++
 				}
 			}
-			fmt.Println()
+			if headerShown {
+				fmt.Println()
+			}
 		}
 
 		fmt.Println("Summary:")
 		fmt.Printf("  ✅ Synced:      %d\n", totalSynced)
 		fmt.Printf("  🔄 In progress: %d\n", totalInProgress)
 		fmt.Printf("  ⏳ Pending:     %d\n", totalPending)
+		if staleAfter > 0 {
+			fmt.Printf("  ⚠️  Stale:       %d (not synced within %s)\n", totalStale, staleAfter)
+		}
 
 		return nil
 	},
 }
 
+var syncResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Clear sync state to force a full re-import",
+	Long: `Delete the recorded sync state of one agent (--agent) or of all agents
+(--all), so their logs are processed from the start on the next sync.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		agentName, _ := cmd.Flags().GetString("agent")
+		all, _ := cmd.Flags().GetBool("all")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if (agentName == "") == !all {
+			return fmt.Errorf("specify either --agent or --all")
+		}
+
+		// Load configuration
+		var err error
+		cfg, err = config.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		configureLogging(cfg)
+
+		scope := "all agents"
+		adapterName := ""
+		if !all {
+			scope = agentName
+			adapterName = mapAgentName(agentName)
+		}
+
+		if !yes {
+			fmt.Printf("This clears the sync state of %s; their logs will be re-imported on the next sync. Continue? [y/N]: ", scope)
+			answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+
+		sentIndex, err := dedup.NewIndex(cfg.Buffer.DBPath, log)
+		if err != nil {
+			return fmt.Errorf("failed to open dedup index: %w", err)
+		}
+		defer sentIndex.Close()
+
+		manager, err := backfill.NewBackfillManager(backfill.Config{
+			StateDBPath: cfg.Buffer.DBPath,
+			Dedup:       sentIndex,
+			Logger:      log,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create sync manager: %w", err)
+		}
+		defer manager.Close()
+
+		reset, err := manager.Reset(adapterName)
+		if err != nil {
+			return fmt.Errorf("failed to reset sync state: %w", err)
+		}
+
+		fmt.Printf("🔄 Reset %d sync states for %s\n", reset, scope)
+		return nil
+	},
+}
+
 func init() {
 	// Configure logging
 	log.SetFormatter(&logrus.TextFormatter{
@@ -944,17 +1756,35 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(backfillCmd)
 	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(collectCmd)
 
 	// Add backfill subcommands
 	backfillCmd.AddCommand(backfillRunCmd)
 	backfillCmd.AddCommand(backfillStatusCmd)
+	backfillCmd.AddCommand(backfillResumeCmd)
 
 	// Add sync subcommands
 	syncCmd.AddCommand(syncStatusCmd)
+	syncCmd.AddCommand(syncResetCmd)
 
 	// Start command flags
-	startCmd.Flags().Bool("no-history", false, "Skip historical sync (only watch for new events)")
+	startCmd.Flags().Bool("watch-only", false, "Skip historical sync and only watch for new events")
+	startCmd.Flags().Bool("no-history", false, "Same as --watch-only")
+	startCmd.Flags().Duration("discovery-interval", time.Minute, "How often to rescan for new workspaces while watching (0 disables)")
 	startCmd.Flags().Int("initial-sync-days", 90, "Number of days to sync on first run")
+	startCmd.Flags().String("initial-sync-from", "", "Sync history from this date (YYYY-MM-DD), overriding --initial-sync-days")
+	startCmd.Flags().String("initial-sync-to", "", "Sync history up to and including this date (YYYY-MM-DD)")
+	startCmd.Flags().Bool("dry-run", false, "Preview the historical sync without sending events or recording progress, then exit")
+	startCmd.Flags().Duration("shutdown-timeout", 10*time.Second, "How long to keep sending queued and buffered events on shutdown")
+	startCmd.Flags().StringSliceVar(&excludePatterns, "exclude", nil, "Glob patterns of log files to skip, on top of the configured excludes (comma-separated)")
+
+	// Collect command flags
+	collectCmd.Flags().Bool("once", false, "Sync new events, send them and exit")
+	collectCmd.Flags().Int("initial-sync-days", 90, "Number of days to sync for sources not seen before")
+	collectCmd.Flags().Duration("flush-timeout", time.Minute, "How long to keep sending collected events before giving up")
+
+	// Version flags
+	versionCmd.Flags().Bool("check", false, "Check whether a newer release is available")
 
 	// Backfill run flags
 	backfillRunCmd.Flags().StringP("agent", "a", "copilot", "Agent name (copilot, claude, cursor)")
@@ -962,17 +1792,31 @@ func init() {
 	backfillRunCmd.Flags().StringP("to", "t", "", "End date (YYYY-MM-DD)")
 	backfillRunCmd.Flags().IntP("days", "d", 0, "Backfill last N days (alternative to from/to)")
 	backfillRunCmd.Flags().Bool("dry-run", false, "Preview without processing")
+	backfillRunCmd.Flags().Bool("verify", false, "Re-parse logs and report drift from what was backfilled, without sending")
+	backfillRunCmd.Flags().Bool("dump-errors", false, "Write lines that fail to parse to <logfile>.parse-errors")
 	backfillRunCmd.Flags().Bool("all-workspaces", false, "Process all discovered workspaces")
 	backfillRunCmd.Flags().StringSlice("workspaces", []string{}, "Specific workspace IDs to process (comma-separated)")
+	backfillRunCmd.Flags().Int("workers", 0, "Number of log files to process concurrently (default from config, 1)")
+	backfillRunCmd.Flags().StringSliceVar(&excludePatterns, "exclude", nil, "Glob patterns of log files to skip, on top of the configured excludes (comma-separated)")
 
 	// Backfill status flags
 	backfillStatusCmd.Flags().StringP("agent", "a", "", "Agent name to check")
 
+	// Backfill resume flags
+	backfillResumeCmd.Flags().StringP("agent", "a", "copilot", "Agent whose paused backfills to resume")
+
 	// Sync status flags
 	syncStatusCmd.Flags().StringP("agent", "a", "", "Filter by agent name")
+	syncStatusCmd.Flags().Duration("stale", 0, "Flag logs not synced within this long (e.g. 24h)")
+	syncStatusCmd.Flags().Bool("only-problems", false, "Show only pending, failed and stale logs")
+
+	// Sync reset flags
+	syncResetCmd.Flags().StringP("agent", "a", "", "Agent whose sync state to reset")
+	syncResetCmd.Flags().Bool("all", false, "Reset the sync state of all agents")
+	syncResetCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c",
-		"~/.devlog/collector.json", "Path to configuration file")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "",
+		"Path to configuration file (default: $DEVLOG_CONFIG, $XDG_CONFIG_HOME/devlog/collector.json, ./.devlog/collector.json or "+config.DefaultPath+")")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose logging")
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/backfill"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPreviewHistoricalSync_SendsAndRecordsNothing(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	logDir := t.TempDir()
+	lines := []string{
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","prompt":"Hello","prompt_tokens":1}`,
+		`{"timestamp":"2025-10-31T10:00:01Z","type":"llm_response","conversation_id":"conv_1","response":"Hi","response_tokens":1}`,
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "session.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	quiet := logrus.New()
+	quiet.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewClaudeAdapter("test-project", nil, quiet)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	// Only the enabled claude agent is previewed, from its configured path
+	disabledDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(disabledDir, "session.jsonl"), []byte(lines[0]+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	cfg := &config.Config{BackendURL: server.URL, APIKey: "key", Agents: map[string]config.AgentConfig{}}
+	for agentName := range watcher.AgentLogLocations {
+		cfg.Agents[agentName] = config.AgentConfig{Enabled: false, LogPath: disabledDir}
+	}
+	cfg.Agents["claude"] = config.AgentConfig{Enabled: true, LogPath: logDir}
+	cfg.Buffer.DBPath = dbPath
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A second preview sees the same events as new, since the first recorded nothing
+	for run := 1; run <= 2; run++ {
+		var out bytes.Buffer
+		if err := previewHistoricalSync(context.Background(), &out, cfg, registry, from, to); err != nil {
+			t.Fatalf("run %d: preview failed: %v", run, err)
+		}
+		if !strings.Contains(out.String(), "2 events would be synced from 1 sources (0 already synced)") {
+			t.Errorf("run %d: unexpected summary:\n%s", run, out.String())
+		}
+		if !strings.Contains(out.String(), logDir) {
+			t.Errorf("run %d: expected a line for %s:\n%s", run, logDir, out.String())
+		}
+		if strings.Contains(out.String(), disabledDir) {
+			t.Errorf("run %d: expected disabled agents not to be previewed:\n%s", run, out.String())
+		}
+	}
+
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Expected no requests to the backend, got %d", n)
+	}
+
+	store, err := backfill.NewStateStore(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open state store: %v", err)
+	}
+	defer store.Close()
+	states, err := store.ListByAgent("claude")
+	if err != nil {
+		t.Fatalf("failed to list state: %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("Expected no sync state to be recorded, got %d entries", len(states))
+	}
+}
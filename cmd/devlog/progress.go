@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/backfill"
+)
+
+func progressBar(percentage float64) string {
+	filled := int(percentage / 5) // 20 chars = 100%
+	if filled > 20 {
+		filled = 20
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", 20-filled)
+}
+
+// formatETA renders an estimated time left, or "--" when there is none yet
+func formatETA(eta time.Duration) string {
+	if eta <= 0 {
+		return "--"
+	}
+	return eta.Round(time.Second).String()
+}
+
+// newProgressPrinter returns a progress callback that redraws one line on w,
+// prefixed with label, showing the percentage, events per second since the
+// printer was created, and the estimated time left on the current file.
+// Updates from concurrent workers are serialized.
+func newProgressPrinter(w io.Writer, label string) backfill.ProgressFunc {
+	var mu sync.Mutex
+	startTime := time.Now()
+
+	return func(p backfill.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		eventsPerSec := float64(p.EventsProcessed) / time.Since(startTime).Seconds()
+		fmt.Fprintf(w, "\r%s: [%-20s] %.1f%% | Events: %d | Speed: %.1f/s | ETA: %-8s",
+			label,
+			progressBar(p.Percentage),
+			p.Percentage,
+			p.EventsProcessed,
+			eventsPerSec,
+			formatETA(p.EstimatedTime),
+		)
+	}
+}
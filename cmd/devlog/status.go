@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/status"
+	"github.com/codervisor/devlog/internal/watcher"
+)
+
+// liveStatusTimeout bounds the status command's query of a running collector
+const liveStatusTimeout = time.Second
+
+// collectorSnapshot reports the live state of the start command's components.
+// apiClient is nil when events go to a local sink.
+func collectorSnapshot(startedAt time.Time, cfg *config.Config, fileWatcher *watcher.Watcher, apiClient *client.Client, buf *buffer.Buffer) status.Snapshot {
+	snapshot := status.Snapshot{
+		Version:   version,
+		PID:       os.Getpid(),
+		StartedAt: startedAt,
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+		Sink:      "backend",
+		Watcher:   fileWatcher.GetStats(),
+	}
+	if cfg.Sink.Local() {
+		snapshot.Sink = cfg.Sink.Type
+	}
+	if apiClient != nil {
+		snapshot.Client = apiClient.GetStats()
+		snapshot.LastFlush = apiClient.LastFlush()
+	}
+	if count, err := buf.Count(); err == nil {
+		snapshot.BufferDepth = count
+	}
+	return snapshot
+}
+
+// fetchLiveStatus returns the snapshot of a collector running with cfg, or
+// nil if none answers on its status address
+func fetchLiveStatus(cfg *config.Config) *status.Snapshot {
+	if cfg == nil || cfg.StatusAddr == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveStatusTimeout)
+	defer cancel()
+	snapshot, err := status.Fetch(ctx, cfg.StatusAddr)
+	if err != nil {
+		log.Debugf("No running collector at %s: %v", cfg.StatusAddr, err)
+		return nil
+	}
+	return snapshot
+}
+
+// printLiveStatus prints the state reported by a running collector
+func printLiveStatus(w io.Writer, snapshot *status.Snapshot) {
+	fmt.Fprintf(w, "🟢 Collector: Running (pid %d, version %s, up %s)\n", snapshot.PID, snapshot.Version, snapshot.Uptime)
+	fmt.Fprintf(w, "   Watching: %v paths, %v/%v events queued\n",
+		snapshot.Watcher["watching_count"], snapshot.Watcher["queue_size"], snapshot.Watcher["queue_capacity"])
+
+	if snapshot.Client == nil {
+		fmt.Fprintf(w, "📝 Sink: %s (backend not used)\n", snapshot.Sink)
+	} else {
+		fmt.Fprintf(w, "🔌 Backend: circuit %v, %v events awaiting the next batch\n",
+			snapshot.Client["breaker_state"], snapshot.Client["pending_events"])
+		if snapshot.LastFlush.IsZero() {
+			fmt.Fprintln(w, "   Last flush: none yet")
+		} else {
+			fmt.Fprintf(w, "   Last flush: %s (%s ago)\n",
+				snapshot.LastFlush.Local().Format(time.DateTime), time.Since(snapshot.LastFlush).Round(time.Second))
+		}
+	}
+
+	if snapshot.BufferDepth > 0 {
+		fmt.Fprintf(w, "📦 Buffer: %d events pending\n", snapshot.BufferDepth)
+	} else {
+		fmt.Fprintf(w, "📦 Buffer: Empty (all events synced)\n")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/status"
+	"github.com/codervisor/devlog/internal/watcher"
+)
+
+func TestFetchLiveStatus_RunningCollector(t *testing.T) {
+	fileWatcher, err := watcher.NewWatcher(watcher.Config{Registry: adapters.NewRegistry(), EventQueueSize: 10})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+	buf.Store(drainTestEvent(1))
+
+	cfg := &config.Config{Sink: config.SinkConfig{Type: "stdout"}}
+	server := status.NewServer(func() status.Snapshot {
+		return collectorSnapshot(time.Now().Add(-time.Minute), cfg, fileWatcher, nil, buf)
+	}, nil)
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start status server: %v", err)
+	}
+	defer server.Stop(context.Background())
+
+	cfg.StatusAddr = server.Addr()
+	live := fetchLiveStatus(cfg)
+	if live == nil {
+		t.Fatal("Expected the running collector to answer")
+	}
+	if live.BufferDepth != 1 || live.Sink != "stdout" {
+		t.Errorf("Unexpected snapshot: %+v", live)
+	}
+
+	var out bytes.Buffer
+	printLiveStatus(&out, live)
+	for _, want := range []string{"Collector: Running", "up 1m0s", "Sink: stdout", "Buffer: 1 events pending"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestFetchLiveStatus_NoCollector(t *testing.T) {
+	if live := fetchLiveStatus(&config.Config{StatusAddr: "127.0.0.1:1"}); live != nil {
+		t.Errorf("Expected no live status, got %+v", live)
+	}
+	if live := fetchLiveStatus(&config.Config{}); live != nil {
+		t.Errorf("Expected no live status without a status address, got %+v", live)
+	}
+}
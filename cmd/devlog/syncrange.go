@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// syncDateLayout is the format of the --initial-sync-from/--initial-sync-to dates
+const syncDateLayout = "2006-01-02"
+
+// initialSyncRange returns the date range of start's historical sync. An
+// explicit from date takes precedence over the relative days window; an
+// explicit to date includes that whole day. Without either the range is the
+// last days days up to now.
+func initialSyncRange(from, to string, days int, now time.Time) (time.Time, time.Time, error) {
+	fromDate := now.AddDate(0, 0, -days)
+	toDate := now
+
+	if from != "" {
+		parsed, err := time.Parse(syncDateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --initial-sync-from %q: expected a date as YYYY-MM-DD", from)
+		}
+		fromDate = parsed
+	}
+	if to != "" {
+		parsed, err := time.Parse(syncDateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --initial-sync-to %q: expected a date as YYYY-MM-DD", to)
+		}
+		toDate = parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	if fromDate.After(toDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("initial sync range starts %s, after it ends %s",
+			fromDate.Format(syncDateLayout), toDate.Format(syncDateLayout))
+	}
+	return fromDate, toDate, nil
+}
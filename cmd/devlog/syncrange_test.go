@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitialSyncRange(t *testing.T) {
+	now := time.Date(2025, 11, 20, 15, 30, 0, 0, time.UTC)
+	date := func(s string) time.Time {
+		d, _ := time.Parse(syncDateLayout, s)
+		return d
+	}
+	endOf := func(s string) time.Time {
+		return date(s).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		days     int
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{"Relative window", "", "", 90, now.AddDate(0, 0, -90), now},
+		{"From overrides days", "2025-01-15", "", 90, date("2025-01-15"), now},
+		{"To includes the whole day", "", "2025-11-01", 30, now.AddDate(0, 0, -30), endOf("2025-11-01")},
+		{"Absolute range", "2024-06-01", "2024-06-30", 7, date("2024-06-01"), endOf("2024-06-30")},
+		{"Single day", "2024-06-01", "2024-06-01", 7, date("2024-06-01"), endOf("2024-06-01")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := initialSyncRange(tt.from, tt.to, tt.days, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !from.Equal(tt.wantFrom) {
+				t.Errorf("Expected range to start %s, got %s", tt.wantFrom, from)
+			}
+			if !to.Equal(tt.wantTo) {
+				t.Errorf("Expected range to end %s, got %s", tt.wantTo, to)
+			}
+		})
+	}
+}
+
+func TestInitialSyncRange_Invalid(t *testing.T) {
+	now := time.Date(2025, 11, 20, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantErr  string
+	}{
+		{"Unparseable from", "15/01/2025", "", "--initial-sync-from"},
+		{"Unparseable to", "", "2025-13-01", "--initial-sync-to"},
+		{"From after to", "2025-06-02", "2025-06-01", "after it ends"},
+		{"From in the future", "2025-12-01", "", "after it ends"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := initialSyncRange(tt.from, tt.to, 90, now)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codervisor/devlog/internal/backfill"
+)
+
+// syncIsStale reports whether a log's last completed sync is older than
+// threshold at now, or whether it never completed. state is nil for logs
+// that were never synced. A zero threshold disables the check.
+func syncIsStale(state *backfill.BackfillState, threshold time.Duration, now time.Time) bool {
+	if threshold <= 0 {
+		return false
+	}
+	if state == nil || state.CompletedAt == nil {
+		return true
+	}
+	return now.Sub(*state.CompletedAt) > threshold
+}
+
+// syncNeedsAttention reports whether `sync status --only-problems` shows a
+// log: one that is pending, paused, retrying, failed or stale rather than
+// synced or syncing
+func syncNeedsAttention(state *backfill.BackfillState, stale bool) bool {
+	if state == nil || stale {
+		return true
+	}
+	return state.Status != backfill.StatusCompleted && state.Status != backfill.StatusInProgress
+}
+
+// staleDescription explains why a stale log is flagged
+func staleDescription(state *backfill.BackfillState, now time.Time) string {
+	if state == nil || state.CompletedAt == nil {
+		return "never completed a sync"
+	}
+	return fmt.Sprintf("last synced %s ago", now.Sub(*state.CompletedAt).Round(time.Minute))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/backfill"
+)
+
+func TestSyncIsStale(t *testing.T) {
+	now := time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)
+	completed := func(ago time.Duration) *backfill.BackfillState {
+		at := now.Add(-ago)
+		return &backfill.BackfillState{Status: backfill.StatusCompleted, CompletedAt: &at}
+	}
+
+	tests := []struct {
+		name      string
+		state     *backfill.BackfillState
+		threshold time.Duration
+		want      bool
+	}{
+		{"Recent sync", completed(2 * time.Hour), 24 * time.Hour, false},
+		{"Old sync", completed(72 * time.Hour), 24 * time.Hour, true},
+		{"Exactly at the threshold", completed(24 * time.Hour), 24 * time.Hour, false},
+		{"Never synced", nil, 24 * time.Hour, true},
+		{"Never completed", &backfill.BackfillState{Status: backfill.StatusFailed}, 24 * time.Hour, true},
+		{"Disabled", completed(720 * time.Hour), 0, false},
+		{"Disabled for unsynced logs", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncIsStale(tt.state, tt.threshold, now); got != tt.want {
+				t.Errorf("Expected stale %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSyncNeedsAttention(t *testing.T) {
+	now := time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	old := now.Add(-48 * time.Hour)
+	threshold := 24 * time.Hour
+
+	tests := []struct {
+		name  string
+		state *backfill.BackfillState
+		want  bool
+	}{
+		{"Synced recently", &backfill.BackfillState{Status: backfill.StatusCompleted, CompletedAt: &recent}, false},
+		{"Synced long ago", &backfill.BackfillState{Status: backfill.StatusCompleted, CompletedAt: &old}, true},
+		{"Syncing again", &backfill.BackfillState{Status: backfill.StatusInProgress, CompletedAt: &recent}, false},
+		{"First sync running", &backfill.BackfillState{Status: backfill.StatusInProgress}, true},
+		{"Failed", &backfill.BackfillState{Status: backfill.StatusFailed, CompletedAt: &recent}, true},
+		{"Retrying", &backfill.BackfillState{Status: backfill.StatusRetrying, CompletedAt: &recent}, true},
+		{"Paused", &backfill.BackfillState{Status: backfill.StatusPaused, CompletedAt: &recent}, true},
+		{"Pending", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale := syncIsStale(tt.state, threshold, now)
+			if got := syncNeedsAttention(tt.state, stale); got != tt.want {
+				t.Errorf("Expected needs attention %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStaleDescription(t *testing.T) {
+	now := time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)
+	at := now.Add(-(50*time.Hour + 20*time.Second))
+
+	if got := staleDescription(&backfill.BackfillState{CompletedAt: &at}, now); got != "last synced 50h0m0s ago" {
+		t.Errorf("Unexpected description %q", got)
+	}
+	if got := staleDescription(nil, now); got != "never completed a sync" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
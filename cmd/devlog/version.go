@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latestReleaseURL is the GitHub API endpoint for the newest collector release
+var latestReleaseURL = "https://api.github.com/repos/codervisor/devlog/releases/latest"
+
+// releaseCheckTimeout bounds the update check so an offline machine isn't
+// kept waiting
+const releaseCheckTimeout = 3 * time.Second
+
+// fetchLatestRelease returns the tag of the latest release published at url
+func fetchLatestRelease(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "devlog-collector/"+version)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("releases API returned %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to decode release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// checkForUpdate prints whether a release newer than current is published at
+// url. Failing to reach it is reported, not returned: the check is advisory.
+func checkForUpdate(w io.Writer, url, current string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseCheckTimeout)
+	defer cancel()
+
+	tag, err := fetchLatestRelease(ctx, url)
+	if err != nil {
+		log.Debugf("Update check failed: %v", err)
+		fmt.Fprintln(w, "⚠️  Could not check for updates (offline?)")
+		return
+	}
+
+	switch cmp, ok := compareVersions(tag, current); {
+	case !ok:
+		fmt.Fprintf(w, "ℹ️  Latest release is %s\n", tag)
+	case cmp > 0:
+		fmt.Fprintf(w, "⬆️  Update available: %s (you have v%s)\n", tag, strings.TrimPrefix(current, "v"))
+	default:
+		fmt.Fprintln(w, "✅ You are running the latest version")
+	}
+}
+
+// compareVersions compares two dotted versions, ignoring a leading "v" and
+// any pre-release or build suffix. It returns -1, 0 or 1, and false if
+// either isn't a version.
+func compareVersions(a, b string) (int, bool) {
+	pa, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	pb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+
+	var parts []int
+	for _, field := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
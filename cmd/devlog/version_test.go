@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckForUpdate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v1.2.0"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"1.0.0", "Update available: v1.2.0 (you have v1.0.0)"},
+		{"1.2.0", "latest version"},
+		{"1.10.0", "latest version"},
+		{"dev", "Latest release is v1.2.0"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		checkForUpdate(&out, server.URL, tt.current)
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("current %s: expected %q in %q", tt.current, tt.want, out.String())
+		}
+	}
+}
+
+func TestCheckForUpdate_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	for _, url := range []string{server.URL, "http://127.0.0.1:1"} {
+		var out bytes.Buffer
+		checkForUpdate(&out, url, "1.0.0")
+		if !strings.Contains(out.String(), "Could not check for updates") {
+			t.Errorf("%s: expected a failed check to be reported, got %q", url, out.String())
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.2.0", "1.2.0", 0, true},
+		{"1.2", "1.2.0", 0, true},
+		{"v1.10.0", "1.9.3", 1, true},
+		{"1.0.0", "v2.0.0-beta.1", -1, true},
+		{"collector-1.0", "1.0.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareVersions(tt.a, tt.b)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("compareVersions(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/codervisor/devlog/internal/config"
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/spf13/cobra"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Inspect and override workspace-to-project mappings",
+	Long: `Show how VS Code and Cursor workspaces on this machine map to projects,
+and pin a workspace to a project when the backend resolves it wrongly.`,
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List local workspaces and the projects they resolve to",
+	Long: `Read the VS Code, VS Code Insiders and Cursor workspace storage on this
+machine and print each workspace's project folder, Git repository and
+pinned project, if any. Nothing is sent to the backend.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		output, _ := cmd.Flags().GetString("output")
+
+		mappings, err := hierarchy.ListWorkspaceMappings(log)
+		if err != nil {
+			return fmt.Errorf("failed to list workspaces: %w", err)
+		}
+
+		// Pins live in the state database; listing works without them
+		if disk, err := openWorkspacePins(); err != nil {
+			log.Debugf("Pinned mappings unavailable: %v", err)
+		} else {
+			defer disk.Close()
+			pinned, err := disk.Pinned()
+			if err != nil {
+				return err
+			}
+			for _, mapping := range mappings {
+				mapping.PinnedProjectID = pinned[mapping.WorkspaceID]
+			}
+		}
+
+		if output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", output, err)
+			}
+			defer file.Close()
+
+			if err := writeWorkspaceMappingsJSON(file, mappings); err != nil {
+				return err
+			}
+			fmt.Printf("📝 Exported %d workspace mappings to %s\n", len(mappings), output)
+			return nil
+		}
+
+		if asJSON {
+			return writeWorkspaceMappingsJSON(os.Stdout, mappings)
+		}
+		printWorkspaceMappings(os.Stdout, mappings)
+		return nil
+	},
+}
+
+var workspaceMapCmd = &cobra.Command{
+	Use:   "map",
+	Short: "Pin a workspace to a project",
+	Long: `Pin a workspace to a project ID, overriding the project the backend
+resolves for it. The pin is kept in the hierarchy cache until removed with
+--clear. Pins require the hierarchy disk cache (collection.hierarchyCacheTTL).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workspaceID, _ := cmd.Flags().GetString("workspace-id")
+		projectID, _ := cmd.Flags().GetInt("project-id")
+		unpin, _ := cmd.Flags().GetBool("clear")
+
+		if workspaceID == "" {
+			return fmt.Errorf("--workspace-id is required")
+		}
+		if !unpin && projectID <= 0 {
+			return fmt.Errorf("--project-id must be a positive project ID (or use --clear)")
+		}
+
+		disk, err := openWorkspacePins()
+		if err != nil {
+			return err
+		}
+		defer disk.Close()
+
+		if unpin {
+			removed, err := disk.Unpin(workspaceID)
+			if err != nil {
+				return err
+			}
+			if !removed {
+				fmt.Printf("Workspace %s was not pinned\n", workspaceID)
+				return nil
+			}
+			fmt.Printf("✅ Removed the pinned project of workspace %s\n", workspaceID)
+			return nil
+		}
+
+		if err := disk.Pin(workspaceID, projectID); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Pinned workspace %s to project %d\n", workspaceID, projectID)
+		if ttl, _ := cfg.GetHierarchyCacheTTL(); ttl <= 0 {
+			fmt.Println("⚠️  collection.hierarchyCacheTTL is not set, so the collector won't read pins until it is")
+		}
+		return nil
+	},
+}
+
+// openWorkspacePins loads the configuration and opens the hierarchy disk
+// cache that holds pinned workspace mappings
+func openWorkspacePins() (*hierarchy.DiskCache, error) {
+	var err error
+	cfg, err = config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	configureLogging(cfg)
+
+	ttl, _ := cfg.GetHierarchyCacheTTL()
+	disk, err := hierarchy.NewDiskCache(cfg.Buffer.DBPath, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hierarchy cache: %w", err)
+	}
+	return disk, nil
+}
+
+// writeWorkspaceMappingsJSON writes the mappings as an indented JSON array
+func writeWorkspaceMappingsJSON(out io.Writer, mappings []*hierarchy.WorkspaceMapping) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mappings); err != nil {
+		return fmt.Errorf("failed to encode workspace mappings: %w", err)
+	}
+	return nil
+}
+
+// printWorkspaceMappings prints one line per workspace
+func printWorkspaceMappings(out io.Writer, mappings []*hierarchy.WorkspaceMapping) {
+	if len(mappings) == 0 {
+		fmt.Fprintln(out, "No VS Code or Cursor workspaces found")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKSPACE ID\tTYPE\tPROJECT\tPINNED\tPATH")
+	for _, m := range mappings {
+		wsType, project, pinned, path := m.WorkspaceType, m.Project(), "-", m.Path
+		if m.PinnedProjectID > 0 {
+			pinned = fmt.Sprintf("%d", m.PinnedProjectID)
+		}
+		if m.Error != "" {
+			wsType, project, path = "-", "-", "("+m.Error+")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.WorkspaceID, wsType, project, pinned, path)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d workspaces\n", len(mappings))
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceMapCmd)
+
+	workspaceListCmd.Flags().Bool("json", false, "Print the mappings as JSON")
+	workspaceListCmd.Flags().StringP("output", "o", "", "Export the mappings as JSON to this file")
+
+	workspaceMapCmd.Flags().String("workspace-id", "", "Workspace ID (the workspaceStorage directory name)")
+	workspaceMapCmd.Flags().Int("project-id", 0, "Project ID to pin the workspace to")
+	workspaceMapCmd.Flags().Bool("clear", false, "Remove the workspace's pinned project")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+)
+
+func testWorkspaceMappings() []*hierarchy.WorkspaceMapping {
+	return []*hierarchy.WorkspaceMapping{
+		{WorkspaceID: "ws-broken", StoragePath: "/storage/ws-broken", Error: "no workspace.json"},
+		{
+			WorkspaceID:     "ws-repo",
+			WorkspaceType:   hierarchy.WorkspaceTypeFolder,
+			Path:            "/src/devlog",
+			RemoteURL:       "https://github.com/codervisor/devlog",
+			RepoOwner:       "codervisor",
+			RepoName:        "devlog",
+			PinnedProjectID: 42,
+		},
+	}
+}
+
+func TestPrintWorkspaceMappings(t *testing.T) {
+	var out bytes.Buffer
+	printWorkspaceMappings(&out, testWorkspaceMappings())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected header, 2 workspaces, blank line and total, got:\n%s", out.String())
+	}
+	if got := strings.Join(strings.Fields(lines[0]), " "); got != "WORKSPACE ID TYPE PROJECT PINNED PATH" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "(no workspace.json)") {
+		t.Errorf("expected the resolution error on %q", lines[1])
+	}
+	if got := strings.Join(strings.Fields(lines[2]), " "); got != "ws-repo folder codervisor/devlog 42 /src/devlog" {
+		t.Errorf("unexpected workspace line %q", lines[2])
+	}
+}
+
+func TestWriteWorkspaceMappingsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeWorkspaceMappingsJSON(&out, testWorkspaceMappings()); err != nil {
+		t.Fatalf("writeWorkspaceMappingsJSON: %v", err)
+	}
+
+	var decoded []hierarchy.WorkspaceMapping
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].PinnedProjectID != 42 || decoded[1].RepoName != "devlog" {
+		t.Errorf("unexpected round trip: %+v", decoded)
+	}
+}
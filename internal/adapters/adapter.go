@@ -1,10 +1,25 @@
 package adapters
 
 import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/internal/redact"
 	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
-// AgentAdapter defines the interface for parsing agent-specific log formats
+// AgentAdapter defines the interface for parsing agent-specific log formats.
+//
+// The watcher and backfill read appended NDJSON/text logs line by line with
+// ParseLine, which wraps ParseLogLine, and JSON documents rewritten in place
+// (see ParsesWholeFile) with ParseLogFile. Adapters may also implement RangeParser,
+// OffsetParser, WholeFileParser, ConfidenceScorer and SetOptions(Options); embedding
+// *BaseAdapter provides Name and the options methods. Adapters built outside
+// this package are added to DefaultRegistry with Register.
 type AgentAdapter interface {
 	// Name returns the adapter name (e.g., "copilot", "claude", "cursor")
 	Name() string
@@ -20,17 +35,149 @@ type AgentAdapter interface {
 	SupportsFormat(sample string) bool
 }
 
+// RangeParser is implemented by adapters that can skip records outside a
+// date range while parsing a file, before events are built for them. A zero
+// from or to leaves that end of the range open.
+type RangeParser interface {
+	ParseLogFileInRange(filePath string, from, to time.Time) ([]*types.AgentEvent, error)
+}
+
+// OffsetParser is implemented by whole-file adapters that can resume a
+// document that was appended to, building events only for the records that
+// start at or past offset, a byte offset into the file as stored on disk
+type OffsetParser interface {
+	ParseLogFileFrom(filePath string, offset int64) ([]*types.AgentEvent, error)
+}
+
+// inRange reports whether t falls within [from, to], treating zero bounds as open
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+// Options configures optional adapter behavior. The zero value keeps the
+// default behavior of every adapter.
+type Options struct {
+	// PromptSplitThreshold is the prompt size in bytes above which the prompt
+	// is truncated and the overflow is emitted as attachment events (0 = disabled)
+	PromptSplitThreshold int
+
+	// MaxPromptChars and MaxResponseChars cut prompts and responses to that
+	// many characters, keeping their length fields at the original size
+	// (0 = unlimited). A split prompt is cut after its overflow is moved to
+	// attachments.
+	MaxPromptChars   int
+	MaxResponseChars int
+
+	// SessionStartCommit attaches the commit the workspace was at when a
+	// session's first event happened, the newest commit on HEAD made by
+	// then, as context["sessionStartCommit"]
+	SessionStartCommit bool
+
+	// RepoInfo attaches repoOwner/repoName parsed from the workspace's git
+	// origin remote to event context
+	RepoInfo bool
+
+	// GitHead attaches the branch and commit checked out in the workspace
+	// when each event was parsed as context["branch"] and context["commit"]
+	GitHead bool
+
+	// CoalesceFileReads collapses consecutive reads of the same file within
+	// a turn into one file_read event carrying Data["readCount"]
+	CoalesceFileReads bool
+
+	// SessionBudget flags sessions whose total tokens or cost exceed the
+	// configured limits
+	SessionBudget SessionBudget
+
+	// SessionSummaries emits a session_summary event with a session's
+	// turns, token and cost totals, files touched and duration once the
+	// session ends, or has gone 30 minutes without events
+	SessionSummaries bool
+
+	// CanceledTurns emits an llm_canceled event, with the prompt and any
+	// partial response, for turns the user canceled; they are dropped
+	// otherwise
+	CanceledTurns bool
+
+	// ProjectResolution decides whether the configured project ID or the
+	// project resolved from the workspace hierarchy is attached to events
+	ProjectResolution ProjectResolutionMode
+
+	// ProjectOverride pins every event to this project ID, ahead of the
+	// resolution mode (0 = no override). WorkspaceOverride likewise sets the
+	// workspace ID when non-zero.
+	ProjectOverride   int
+	WorkspaceOverride int
+
+	// Redactor masks secrets in prompts, responses and tool arguments
+	// (nil = disabled)
+	Redactor *redact.Redactor
+
+	// EventTypes drops events of unwanted types before they leave the
+	// adapter, so they are never buffered or sent
+	EventTypes EventTypeFilter
+}
+
+// ProjectResolutionMode selects which project ID wins when the workspace
+// hierarchy resolves to a project other than the configured one
+type ProjectResolutionMode string
+
+const (
+	// ProjectPreferHierarchy uses the resolved project when there is one and
+	// the configured project otherwise (the default)
+	ProjectPreferHierarchy ProjectResolutionMode = "prefer-hierarchy"
+	// ProjectPreferConfig always uses the configured project
+	ProjectPreferConfig ProjectResolutionMode = "prefer-config"
+	// ProjectHierarchyOnly drops events whose workspace didn't resolve
+	ProjectHierarchyOnly ProjectResolutionMode = "hierarchy-only"
+)
+
+// gitLookupTTL bounds how long a workspace HEAD lookup is reused
+const gitLookupTTL = 30 * time.Second
+
 // BaseAdapter provides common functionality for all adapters
 type BaseAdapter struct {
 	name      string
 	projectID string
+	options   Options
+	logger    *logrus.Logger
+	ctx       context.Context // bounds the backend lookups made while parsing
+
+	gitCache       *hierarchy.GitCache
+	sessionMu      sync.Mutex
+	sessionCommits map[string]string // session ID -> HEAD commit at first event
+	sessionOrder   []string          // session IDs in sessionCommits, oldest first
+	mismatchLogged map[string]bool   // log files whose project mismatch was logged
+	summaries      *sessionSummarizer
+
+	rootMu          sync.Mutex
+	workspaceRoots  map[string]string        // log file -> project folder named in its content
+	lineHierarchies map[string]lineHierarchy // line-based log -> workspace context of its lines
 }
 
 // NewBaseAdapter creates a new base adapter
-func NewBaseAdapter(name, projectID string) *BaseAdapter {
+func NewBaseAdapter(name, projectID string, log *logrus.Logger) *BaseAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
 	return &BaseAdapter{
-		name:      name,
-		projectID: projectID,
+		name:           name,
+		projectID:      projectID,
+		logger:         log,
+		ctx:            context.Background(),
+		gitCache:       hierarchy.NewGitCache(gitLookupTTL),
+		sessionCommits: make(map[string]string),
+		mismatchLogged: make(map[string]bool),
+		summaries:      newSessionSummarizer(),
+		workspaceRoots: make(map[string]string),
+
+		lineHierarchies: make(map[string]lineHierarchy),
 	}
 }
 
@@ -43,3 +190,234 @@ func (b *BaseAdapter) Name() string {
 func (b *BaseAdapter) ProjectID() string {
 	return b.projectID
 }
+
+// Options returns the adapter options
+func (b *BaseAdapter) Options() Options {
+	return b.options
+}
+
+// SetOptions updates the adapter options
+func (b *BaseAdapter) SetOptions(opts Options) {
+	b.options = opts
+}
+
+// SetContext sets the context of the caller parsing with the adapter, so
+// canceling it abandons the backend lookups parsing makes, such as
+// resolving the workspace hierarchy
+func (b *BaseAdapter) SetContext(ctx context.Context) {
+	b.ctx = ctx
+}
+
+// postProcess applies option-driven transformations to events parsed from
+// filePath. hierarchyCtx is the workspace context resolved for the file, if any.
+func (b *BaseAdapter) postProcess(filePath string, hierarchyCtx *hierarchy.WorkspaceContext, events []*types.AgentEvent) []*types.AgentEvent {
+	events = b.processEvents(filePath, hierarchyCtx, events)
+	if b.options.CoalesceFileReads {
+		events = coalesceFileReads(events)
+	}
+	if b.options.SessionBudget.enabled() {
+		events = applySessionBudgets(events, b.options.SessionBudget)
+	}
+	if b.options.SessionSummaries {
+		events = b.summaries.summarize(filePath, events, true)
+	}
+	if b.options.EventTypes.enabled() {
+		events = filterEventTypes(events, b.options.EventTypes)
+	}
+	return events
+}
+
+// newEvent builds an event of the adapter with a copy of the shared context
+func (b *BaseAdapter) newEvent(eventType, sessionID string, timestamp time.Time, context, data map[string]interface{}) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       timestamp,
+		Type:            eventType,
+		AgentID:         b.name,
+		SessionID:       sessionID,
+		LegacyProjectID: b.projectID,
+		Context:         copyContext(context),
+		Data:            data,
+	}
+}
+
+// copyContext returns a shallow copy of an event context
+func copyContext(context map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(context)+1)
+	for k, v := range context {
+		copied[k] = v
+	}
+	return copied
+}
+
+// sessionTimestamp places the index-th record of a session that carries no
+// time of its own index milliseconds after the session started, keeping the
+// records in order. The time must be the same on every parse for the events
+// to be recognized as sent, so a session whose start isn't known either is
+// placed at the Unix epoch rather than at something that changes, such as
+// the file's mtime.
+func sessionTimestamp(start time.Time, index int) time.Time {
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+	return start.Add(time.Duration(index) * time.Millisecond)
+}
+
+// processEvents applies project resolution and the options that act on each
+// event by itself. It is shared by postProcess and processLine, so events
+// parsed line by line get the same treatment as those of a whole file.
+// Oversized prompts are split before text fields are truncated so the
+// attachments keep the whole prompt.
+func (b *BaseAdapter) processEvents(filePath string, hierarchyCtx *hierarchy.WorkspaceContext, events []*types.AgentEvent) []*types.AgentEvent {
+	events = b.resolveProject(filePath, hierarchyCtx, events)
+	if b.options.Redactor != nil {
+		redactEvents(events, b.options.Redactor)
+	}
+	if b.options.SessionStartCommit {
+		b.attachSessionStartCommits(filePath, events)
+	}
+	if b.options.RepoInfo {
+		b.attachRepoInfo(filePath, events)
+	}
+	if b.options.GitHead {
+		b.attachGitHead(filePath, events)
+	}
+	if b.options.PromptSplitThreshold > 0 {
+		events = splitOversizedPrompts(events, b.options.PromptSplitThreshold)
+	}
+	if b.options.MaxPromptChars > 0 || b.options.MaxResponseChars > 0 {
+		truncateTextFields(events, b.options.MaxPromptChars, b.options.MaxResponseChars)
+	}
+	return events
+}
+
+// processLine attaches the workspace context of filePath, if any, to the
+// events parsed from one of its lines, applies the per-event options, adds
+// the session summaries that are due and drops events the event type filter
+// excludes
+func (b *BaseAdapter) processLine(filePath string, hierarchyCtx *hierarchy.WorkspaceContext, events []*types.AgentEvent) []*types.AgentEvent {
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+	events = b.processEvents(filePath, hierarchyCtx, events)
+	if b.options.SessionSummaries {
+		events = b.summaries.summarize(filePath, events, false)
+	}
+	if b.options.EventTypes.enabled() {
+		events = filterEventTypes(events, b.options.EventTypes)
+	}
+	return events
+}
+
+// endOfLog returns the events due once the line-based log filePath was read
+// to its end: the summaries of its sessions that went idle
+func (b *BaseAdapter) endOfLog(filePath string) []*types.AgentEvent {
+	if !b.options.SessionSummaries {
+		return nil
+	}
+	events := b.summaries.endOfLog(filePath)
+	if b.options.EventTypes.enabled() {
+		events = filterEventTypes(events, b.options.EventTypes)
+	}
+	return events
+}
+
+// lineProcessor is implemented by adapters embedding *BaseAdapter
+type lineProcessor interface {
+	processLine(filePath string, hierarchyCtx *hierarchy.WorkspaceContext, events []*types.AgentEvent) []*types.AgentEvent
+	endOfLog(filePath string) []*types.AgentEvent
+}
+
+// lineParser is implemented by adapters whose line parsing follows the
+// state of each log, such as requests still waiting for a response. It
+// returns the events for one line of filePath; resetLog drops the state
+// followed for filePath.
+type lineParser interface {
+	parseFileLine(filePath, line string) ([]*types.AgentEvent, error)
+	resetLog(filePath string)
+}
+
+// ResetLog tells the adapter the line-based log filePath is read again from
+// its start, as when it was truncated or replaced, so the state it followed
+// from the log's earlier lines doesn't carry over.
+func ResetLog(adapter AgentAdapter, filePath string) {
+	if parser, ok := adapter.(lineParser); ok {
+		parser.resetLog(filePath)
+	}
+}
+
+// EndOfLog tells the adapter the line-based log filePath was read to its
+// end and returns the events waiting on it: the summaries of the log's
+// sessions that went idle. Callers reading a log with ParseLine call it
+// each time they reach the end.
+func EndOfLog(adapter AgentAdapter, filePath string) []*types.AgentEvent {
+	if processor, ok := adapter.(lineProcessor); ok {
+		return processor.endOfLog(filePath)
+	}
+	return nil
+}
+
+// ParseLine parses one line of the line-based log filePath. On top of
+// ParseLogLine it follows the state adapters keep per log, attaches the
+// log's workspace hierarchy and applies the options ParseLogFile applies to
+// each event, such as project resolution and redaction, and the event type
+// filter, so callers reading a log line by line emit the same events as a
+// whole-file parse would. Session summaries follow a session's end, and
+// EndOfLog; other options that need a whole session (coalesced file reads
+// and session budgets) only apply to ParseLogFile. It returns the events
+// for the line, none for lines without one.
+func ParseLine(adapter AgentAdapter, filePath, line string) ([]*types.AgentEvent, error) {
+	var events []*types.AgentEvent
+	if parser, ok := adapter.(lineParser); ok {
+		parsed, err := parser.parseFileLine(filePath, line)
+		if err != nil || len(parsed) == 0 {
+			return nil, err
+		}
+		events = parsed
+	} else {
+		event, err := adapter.ParseLogLine(line)
+		if err != nil || event == nil {
+			return nil, err
+		}
+		events = []*types.AgentEvent{event}
+	}
+
+	if processor, ok := adapter.(lineProcessor); ok {
+		var hierarchyCtx *hierarchy.WorkspaceContext
+		if resolver, ok := adapter.(logHierarchyResolver); ok {
+			hierarchyCtx = resolver.logHierarchy(filePath)
+		}
+		return processor.processLine(filePath, hierarchyCtx, events), nil
+	}
+	kept := events[:0]
+	for _, event := range events {
+		if KeepsEvent(adapter, event) {
+			kept = append(kept, event)
+		}
+	}
+	return kept, nil
+}
+
+// WholeFileParser is implemented by adapters that decide for themselves which
+// files ParsesWholeFile reports as documents
+type WholeFileParser interface {
+	ParsesWholeFile(filePath string) bool
+}
+
+// ParsesWholeFile reports whether filePath must be parsed as one document
+// with ParseLogFile rather than line by line. Copilot chat sessions, Neovim
+// chat histories, Continue sessions, JetBrains chats and Tabnine
+// conversations are JSON documents rewritten in place; everything else is
+// appended NDJSON/text unless the adapter implements WholeFileParser.
+func ParsesWholeFile(adapter AgentAdapter, filePath string) bool {
+	if parser, ok := adapter.(WholeFileParser); ok {
+		return parser.ParsesWholeFile(filePath)
+	}
+	switch adapter.Name() {
+	case "github-copilot", "neovim", "continue", "jetbrains", "tabnine":
+		return LogExt(filePath) == ".json"
+	}
+	return false
+}
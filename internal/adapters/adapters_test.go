@@ -1,7 +1,19 @@
 package adapters
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
 )
 
 func TestRegistry(t *testing.T) {
@@ -42,3 +54,125 @@ func TestRegistry(t *testing.T) {
 		t.Errorf("expected github-copilot, got %s", detected.Name())
 	}
 }
+
+// namedAdapter is a minimal out-of-package style adapter
+type namedAdapter struct {
+	*BaseAdapter
+}
+
+func (a *namedAdapter) ParseLogLine(line string) (*types.AgentEvent, error) { return nil, nil }
+
+func (a *namedAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) { return nil, nil }
+
+func (a *namedAdapter) SupportsFormat(sample string) bool { return false }
+
+func TestRegistry_SetContextBoundsHierarchyLookups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+	apiClient := client.NewClient(client.Config{BaseURL: server.URL, Logger: log})
+	defer apiClient.Stop()
+	cache := hierarchy.NewHierarchyCache(apiClient, log)
+
+	logFile := filepath.Join(t.TempDir(), "workspaceStorage", "ws-slow", "session.jsonl")
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_a","prompt":"Hi"}`
+	if err := os.WriteFile(logFile, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewRegistry()
+	if err := registry.Register(NewClaudeAdapter("test-project", cache, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	registry.SetContext(ctx)
+
+	adapter, err := registry.Get("claude")
+	if err != nil {
+		t.Fatalf("failed to get adapter: %v", err)
+	}
+	start := time.Now()
+	events, err := adapter.ParseLogFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the canceled lookup to return at once, took %v", elapsed)
+	}
+	if len(events) != 1 || events[0].WorkspaceID != 0 {
+		t.Errorf("Expected the event without hierarchy, got %+v", events)
+	}
+}
+
+func TestRegister_AddsFactoryToDefaultRegistry(t *testing.T) {
+	var gotProjectID string
+	Register("registry-test-tool", func(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) AgentAdapter {
+		gotProjectID = projectID
+		return &namedAdapter{NewBaseAdapter("registry-test-tool", projectID, log)}
+	})
+	// A factory whose adapter reports another name is skipped
+	Register("registry-test-misnamed", func(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) AgentAdapter {
+		return &namedAdapter{NewBaseAdapter("something-else", projectID, log)}
+	})
+
+	registry := DefaultRegistry("test-project", nil, nil)
+
+	adapter, err := registry.Get("registry-test-tool")
+	if err != nil {
+		t.Fatalf("Expected the registered adapter in the default registry: %v", err)
+	}
+	if adapter.Name() != "registry-test-tool" || gotProjectID != "test-project" {
+		t.Errorf("Expected the factory to build the adapter for test-project, got %s for %q", adapter.Name(), gotProjectID)
+	}
+	if _, err := registry.Get("claude"); err != nil {
+		t.Errorf("Expected the built-in adapters alongside registered ones: %v", err)
+	}
+	if _, err := registry.Get("something-else"); err == nil {
+		t.Error("Expected the misnamed adapter to be skipped")
+	}
+}
+
+func TestRegister_PanicsOnDuplicate(t *testing.T) {
+	factory := func(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) AgentAdapter {
+		return &namedAdapter{NewBaseAdapter("registry-test-duplicate", projectID, log)}
+	}
+	Register("registry-test-duplicate", factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering the same name twice to panic")
+		}
+	}()
+	Register("registry-test-duplicate", factory)
+}
+
+func TestParsesWholeFile_WholeFileParser(t *testing.T) {
+	adapter := &wholeFileAdapter{namedAdapter{NewBaseAdapter("registry-test-docs", "test-project", nil)}}
+
+	if !ParsesWholeFile(adapter, "/logs/chat.yaml") {
+		t.Error("Expected the adapter to decide that its YAML files are documents")
+	}
+	if ParsesWholeFile(adapter, "/logs/chat.jsonl") {
+		t.Error("Expected other files to be parsed line by line")
+	}
+}
+
+// wholeFileAdapter parses YAML documents whole
+type wholeFileAdapter struct {
+	namedAdapter
+}
+
+func (a *wholeFileAdapter) ParsesWholeFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".yaml")
+}
@@ -0,0 +1,87 @@
+package adapters
+
+import (
+	"unicode/utf8"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// splitOversizedPrompts truncates prompts longer than threshold bytes and
+// emits the overflow as attachment events linked to the originating request.
+// Data["promptLength"] keeps describing the whole prompt.
+func splitOversizedPrompts(events []*types.AgentEvent, threshold int) []*types.AgentEvent {
+	result := make([]*types.AgentEvent, 0, len(events))
+
+	for _, event := range events {
+		result = append(result, event)
+
+		if event.Type != types.EventTypeLLMRequest {
+			continue
+		}
+
+		prompt, ok := event.Data["prompt"].(string)
+		if !ok || len(prompt) <= threshold {
+			continue
+		}
+
+		chunks := chunkString(prompt, threshold)
+		if _, ok := event.Data["promptLength"]; !ok {
+			event.Data["promptLength"] = len(prompt)
+		}
+		event.Data["prompt"] = chunks[0]
+		event.Data["promptTruncated"] = true
+		event.Data["attachmentCount"] = len(chunks) - 1
+
+		// Link attachments by request ID, falling back to the prompt event ID
+		requestID, ok := event.Data["requestId"]
+		if !ok {
+			requestID = event.ID
+			event.Data["requestId"] = requestID
+		}
+
+		for i, chunk := range chunks[1:] {
+			result = append(result, &types.AgentEvent{
+				ID:              uuid.New().String(),
+				Timestamp:       event.Timestamp,
+				Type:            types.EventTypeAttachment,
+				AgentID:         event.AgentID,
+				AgentVersion:    event.AgentVersion,
+				SessionID:       event.SessionID,
+				ProjectID:       event.ProjectID,
+				MachineID:       event.MachineID,
+				WorkspaceID:     event.WorkspaceID,
+				LegacyProjectID: event.LegacyProjectID,
+				Data: map[string]interface{}{
+					"requestId":     requestID,
+					"parentEventId": event.ID,
+					"chunkIndex":    i,
+					"chunkCount":    len(chunks) - 1,
+					"content":       chunk,
+					"contentLength": len(chunk),
+				},
+			})
+		}
+	}
+
+	return result
+}
+
+// chunkString splits s into chunks of at most size bytes without breaking
+// multi-byte UTF-8 characters
+func chunkString(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			// Size smaller than a single rune; emit the whole rune
+			_, cut = utf8.DecodeRuneInString(s)
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	return append(chunks, s)
+}
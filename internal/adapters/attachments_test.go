@@ -0,0 +1,110 @@
+package adapters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopilotAdapter_SplitsOversizedPrompt(t *testing.T) {
+	prompt := "Fix this: " + strings.Repeat("x", 2500)
+
+	testSession := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{
+				RequestID:  "request_big",
+				ResponseID: "response_big",
+				Timestamp:  int64(1730372400000),
+				Message:    CopilotMessage{Text: prompt},
+				Response: []CopilotResponseItem{
+					{Value: json.RawMessage(`"Done."`)},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "big-session.json")
+	data, err := json.Marshal(testSession)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(testFile, data, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{PromptSplitThreshold: 1000})
+
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	var request *types.AgentEvent
+	var attachments []*types.AgentEvent
+	for _, event := range events {
+		switch event.Type {
+		case types.EventTypeLLMRequest:
+			request = event
+		case types.EventTypeAttachment:
+			attachments = append(attachments, event)
+		}
+	}
+
+	require.NotNil(t, request)
+	require.Len(t, attachments, 2, "2510 bytes at 1000 per chunk should yield prompt + 2 attachments")
+
+	assert.Len(t, request.Data["prompt"], 1000)
+	assert.Equal(t, true, request.Data["promptTruncated"])
+	assert.Equal(t, 2, request.Data["attachmentCount"])
+
+	reassembled := request.Data["prompt"].(string)
+	for i, attachment := range attachments {
+		assert.Equal(t, "request_big", attachment.Data["requestId"])
+		assert.Equal(t, request.ID, attachment.Data["parentEventId"])
+		assert.Equal(t, i, attachment.Data["chunkIndex"])
+		assert.Equal(t, request.SessionID, attachment.SessionID)
+		reassembled += attachment.Data["content"].(string)
+	}
+	assert.Equal(t, prompt, reassembled, "prompt plus attachments should preserve the full text")
+}
+
+func TestCopilotAdapter_NoSplitWhenDisabled(t *testing.T) {
+	testSession := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{
+				RequestID: "request_big",
+				Timestamp: int64(1730372400000),
+				Message:   CopilotMessage{Text: strings.Repeat("x", 5000)},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "big-session.json")
+	data, err := json.Marshal(testSession)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(testFile, data, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	for _, event := range events {
+		assert.NotEqual(t, types.EventTypeAttachment, event.Type)
+	}
+}
+
+func TestChunkString_PreservesRunes(t *testing.T) {
+	s := strings.Repeat("é", 10) // 2 bytes per rune
+	chunks := chunkString(s, 5)
+
+	assert.Equal(t, s, strings.Join(chunks, ""))
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 5)
+		assert.True(t, len(chunk)%2 == 0, "chunk %q split a rune", chunk)
+	}
+}
@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// BenchmarkParseLogFile parses the fixture of each whole-file adapter, for
+// comparing their throughput with `go test -bench ParseLogFile`
+func BenchmarkParseLogFile(b *testing.B) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	benchmarks := []struct {
+		name    string
+		fixture string
+		adapter AgentAdapter
+	}{
+		{"Copilot", "copilot-multi-model.json", NewCopilotAdapter("test-project", nil, log)},
+		{"Continue", "continue-session.json", NewContinueAdapter("test-project", nil, log)},
+		{"JetBrains", "jetbrains-chat.json", NewJetBrainsAdapter("test-project", nil, log)},
+		{"Tabnine", "tabnine-conversation.json", NewTabnineAdapter("test-project", nil, log)},
+		{"NeovimAvante", "avante-history.json", NewNeovimAdapter("test-project", nil, log)},
+		{"NeovimCodeCompanion", "codecompanion-history.json", NewNeovimAdapter("test-project", nil, log)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			path := filepath.Join("testdata", bm.fixture)
+			info, err := os.Stat(path)
+			require.NoError(b, err)
+
+			b.SetBytes(info.Size())
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				events, err := bm.adapter.ParseLogFile(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(events) == 0 {
+					b.Fatalf("no events parsed from %s", bm.fixture)
+				}
+			}
+		})
+	}
+}
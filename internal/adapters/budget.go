@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// SessionBudget sets per-session token and cost limits. A zero limit is not
+// enforced.
+type SessionBudget struct {
+	MaxTokens int
+	MaxCost   float64
+
+	// Alert emits a budget_exceeded event for each session over budget in
+	// addition to flagging the session's events
+	Alert bool
+}
+
+// enabled reports whether any limit is set
+func (b SessionBudget) enabled() bool {
+	return b.MaxTokens > 0 || b.MaxCost > 0
+}
+
+// sessionTotals aggregates the metrics of one session's events
+type sessionTotals struct {
+	tokens int
+	cost   float64
+	last   *types.AgentEvent
+}
+
+// eventTokens returns the tokens an event accounts for, preferring the
+// reported total over the prompt/response split
+func eventTokens(m *types.EventMetrics) int {
+	if m == nil {
+		return 0
+	}
+	if m.TokenCount > 0 {
+		return m.TokenCount
+	}
+	return m.PromptTokens + m.ResponseTokens
+}
+
+// applySessionBudgets totals tokens and cost per session and sets
+// context["budgetExceeded"] on every event of sessions over budget, emitting
+// an alert event after each such session's last event when enabled
+func applySessionBudgets(events []*types.AgentEvent, budget SessionBudget) []*types.AgentEvent {
+	totals := make(map[string]*sessionTotals)
+	for _, event := range events {
+		t, ok := totals[event.SessionID]
+		if !ok {
+			t = &sessionTotals{}
+			totals[event.SessionID] = t
+		}
+		t.tokens += eventTokens(event.Metrics)
+		if event.Metrics != nil {
+			t.cost += event.Metrics.Cost
+		}
+		t.last = event
+	}
+
+	exceeded := func(t *sessionTotals) bool {
+		return (budget.MaxTokens > 0 && t.tokens > budget.MaxTokens) ||
+			(budget.MaxCost > 0 && t.cost > budget.MaxCost)
+	}
+
+	result := make([]*types.AgentEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, event)
+
+		t := totals[event.SessionID]
+		if !exceeded(t) {
+			continue
+		}
+		setContext(event, "budgetExceeded", true)
+
+		if budget.Alert && event == t.last {
+			result = append(result, budgetAlert(event, t, budget))
+		}
+	}
+
+	return result
+}
+
+// budgetAlert builds the alert event for a session over budget, modeled on
+// the session's last event
+func budgetAlert(last *types.AgentEvent, t *sessionTotals, budget SessionBudget) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       last.Timestamp,
+		Type:            types.EventTypeBudgetExceeded,
+		AgentID:         last.AgentID,
+		AgentVersion:    last.AgentVersion,
+		SessionID:       last.SessionID,
+		ProjectID:       last.ProjectID,
+		MachineID:       last.MachineID,
+		WorkspaceID:     last.WorkspaceID,
+		LegacyProjectID: last.LegacyProjectID,
+		Context: map[string]interface{}{
+			"budgetExceeded": true,
+		},
+		Data: map[string]interface{}{
+			"totalTokens": t.tokens,
+			"totalCost":   t.cost,
+			"maxTokens":   budget.MaxTokens,
+			"maxCost":     budget.MaxCost,
+		},
+		Metrics: &types.EventMetrics{
+			TokenCount: t.tokens,
+			Cost:       t.cost,
+		},
+	}
+}
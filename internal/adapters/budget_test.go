@@ -0,0 +1,65 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaudeAdapter_SessionBudget(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_big","prompt":"Refactor everything","tokens_used":600}`,
+		`{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_big","response":"Done","tokens_used":700}`,
+		`{"timestamp":"2025-10-31T10:01:00Z","type":"llm_request","conversation_id":"conv_small","prompt":"Fix a typo","tokens_used":50}`,
+		`{"timestamp":"2025-10-31T10:01:05Z","type":"llm_response","conversation_id":"conv_small","response":"Fixed","tokens_used":40}`,
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionBudget: SessionBudget{MaxTokens: 1000, Alert: true}})
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+
+	var alerts []*types.AgentEvent
+	for _, event := range events {
+		if event.Type == types.EventTypeBudgetExceeded {
+			alerts = append(alerts, event)
+			continue
+		}
+
+		switch event.SessionID {
+		case "conv_big":
+			assert.Equal(t, true, event.Context["budgetExceeded"], "events of the over-budget session are flagged")
+		case "conv_small":
+			assert.NotContains(t, event.Context, "budgetExceeded", "events under budget are not flagged")
+		}
+	}
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "conv_big", alerts[0].SessionID)
+	assert.Equal(t, 1300, alerts[0].Data["totalTokens"])
+	assert.Equal(t, 1000, alerts[0].Data["maxTokens"])
+	assert.Same(t, alerts[0], events[2], "alert follows the session's last event")
+}
+
+func TestApplySessionBudgets_Cost(t *testing.T) {
+	events := []*types.AgentEvent{
+		{SessionID: "s1", Metrics: &types.EventMetrics{Cost: 0.75}},
+		{SessionID: "s1", Metrics: &types.EventMetrics{Cost: 0.50}},
+		{SessionID: "s2", Metrics: &types.EventMetrics{Cost: 0.10}},
+	}
+
+	result := applySessionBudgets(events, SessionBudget{MaxCost: 1.0})
+
+	require.Len(t, result, 3, "no alert events unless enabled")
+	assert.Equal(t, true, result[0].Context["budgetExceeded"])
+	assert.Equal(t, true, result[1].Context["budgetExceeded"])
+	assert.Nil(t, result[2].Context)
+}
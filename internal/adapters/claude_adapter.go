@@ -4,8 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/codervisor/devlog/internal/hierarchy"
@@ -19,6 +19,10 @@ type ClaudeAdapter struct {
 	*BaseAdapter
 	hierarchy *hierarchy.HierarchyCache
 	log       *logrus.Logger
+	turns     *claudeTurnTracker
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*claudeLogSessions // log file -> conversations of its lines
 }
 
 // NewClaudeAdapter creates a new Claude adapter
@@ -27,9 +31,11 @@ func NewClaudeAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache
 		log = logrus.New()
 	}
 	return &ClaudeAdapter{
-		BaseAdapter: NewBaseAdapter("claude", projectID),
+		BaseAdapter: NewBaseAdapter("claude", projectID, log),
 		hierarchy:   hierarchyCache,
 		log:         log,
+		turns:       newClaudeTurnTracker(),
+		sessions:    make(map[string]*claudeLogSessions),
 	}
 }
 
@@ -41,6 +47,7 @@ type ClaudeLogEntry struct {
 	Message     string                 `json:"message"`
 	Type        string                 `json:"type,omitempty"`
 	ConversationID string              `json:"conversation_id,omitempty"`
+	RequestID   string                 `json:"request_id,omitempty"`
 	Model       string                 `json:"model,omitempty"`
 	Prompt      string                 `json:"prompt,omitempty"`
 	Response    string                 `json:"response,omitempty"`
@@ -50,28 +57,79 @@ type ClaudeLogEntry struct {
 	ToolName    string                 `json:"tool_name,omitempty"`
 	ToolInput   interface{}            `json:"tool_input,omitempty"`
 	ToolOutput  interface{}            `json:"tool_output,omitempty"`
+	Error       interface{}            `json:"error,omitempty"`
 	FilePath    string                 `json:"file_path,omitempty"`
 	Action      string                 `json:"action,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ParseLogLine parses a single log line from Claude Desktop
+// A line without a conversation id continues the conversation of the line
+// parsed before it.
 func (a *ClaudeAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	sessions := a.logSessions("")
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	event, _, err := a.parseLine(line, "", sessions.current)
+	if event != nil {
+		sessions.observe(event)
+	}
+	return event, err
+}
+
+// parseFileLine parses a line of filePath, following the conversations of
+// the file: a line without a conversation id continues the one before it,
+// and a switch to a conversation the file hasn't held before is marked with
+// a session_start event. Requests of the file that the line's time shows
+// went unanswered are reported ahead of its events.
+func (a *ClaudeAdapter) parseFileLine(filePath, line string) ([]*types.AgentEvent, error) {
+	sessions := a.logSessions(filePath)
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+
+	event, orphaned, err := a.parseLine(line, filePath, sessions.current)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*types.AgentEvent, 0, len(orphaned)+2)
+	for _, turn := range orphaned {
+		events = append(events, a.orphanNotice(turn))
+	}
+	if event != nil {
+		if start := sessions.observe(event); start != nil {
+			events = append(events, start)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// parseLine parses a line of filePath, assigning lines without a
+// conversation id to fallbackSession. It also returns the requests of the
+// file that went unanswered for the turn window.
+func (a *ClaudeAdapter) parseLine(line, filePath, fallbackSession string) (*types.AgentEvent, []pendingClaudeTurn, error) {
 	line = strings.TrimSpace(line)
 	if line == "" {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	var entry ClaudeLogEntry
 	if err := json.Unmarshal([]byte(line), &entry); err != nil {
 		// Not JSON, skip
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// Detect event type and create appropriate event
 	eventType := a.detectEventType(&entry)
 	if eventType == "" {
-		return nil, nil // Unknown event type, skip
+		return nil, nil, nil // Unknown event type, skip
+	}
+
+	sessionID := entry.ConversationID
+	if sessionID == "" {
+		sessionID = fallbackSession
 	}
 
 	timestamp := a.parseTimestamp(entry.Timestamp)
@@ -80,19 +138,20 @@ func (a *ClaudeAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
 		Timestamp:       timestamp,
 		Type:            eventType,
 		AgentID:         a.name,
-		SessionID:       entry.ConversationID,
+		SessionID:       sessionID,
 		LegacyProjectID: a.projectID,
 		Context:         a.extractContext(&entry),
 		Data:            a.extractData(&entry, eventType),
 		Metrics:         a.extractMetrics(&entry),
 	}
+	orphaned := a.turns.track(event, entry.RequestID, filePath)
 
-	return event, nil
+	return event, orphaned, nil
 }
 
 // ParseLogFile parses a Claude Desktop log file (JSONL format)
 func (a *ClaudeAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
-	file, err := os.Open(filePath)
+	file, err := OpenLogFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
@@ -100,18 +159,7 @@ func (a *ClaudeAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, erro
 
 	// Try to resolve hierarchy context from file path
 	// Claude logs might be in a project-specific directory
-	var hierarchyCtx *hierarchy.WorkspaceContext
-	workspaceID := extractWorkspaceIDFromPath(filePath)
-	if workspaceID != "" && a.hierarchy != nil {
-		ctx, err := a.hierarchy.Resolve(workspaceID)
-		if err != nil {
-			a.log.Warnf("Failed to resolve workspace %s: %v - continuing without hierarchy", workspaceID, err)
-		} else {
-			hierarchyCtx = ctx
-			a.log.Debugf("Resolved hierarchy for workspace %s: project=%d, machine=%d",
-				workspaceID, ctx.ProjectID, ctx.MachineID)
-		}
-	}
+	hierarchyCtx := resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
 
 	var events []*types.AgentEvent
 	scanner := bufio.NewScanner(file)
@@ -120,27 +168,35 @@ func (a *ClaudeAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, erro
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 
+	// The file is read from its start, so its conversations and requests are
+	// followed afresh
+	a.turns.forget(filePath)
+	sessions := newClaudeLogSessions()
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
 		
-		event, err := a.ParseLogLine(line)
+		// Unanswered requests are flagged once the whole file is read
+		event, _, err := a.parseLine(line, filePath, sessions.current)
 		if err != nil {
 			a.log.Debugf("Failed to parse line %d: %v", lineNum, err)
 			continue
 		}
 		
 		if event != nil {
+			lineEvents := []*types.AgentEvent{event}
+			if start := sessions.observe(event); start != nil {
+				lineEvents = []*types.AgentEvent{start, event}
+			}
+
 			// Add hierarchy context if available
-			if hierarchyCtx != nil {
-				event.ProjectID = hierarchyCtx.ProjectID
-				event.MachineID = hierarchyCtx.MachineID
-				event.WorkspaceID = hierarchyCtx.WorkspaceID
-				event.Context["projectName"] = hierarchyCtx.ProjectName
-				event.Context["machineName"] = hierarchyCtx.MachineName
+			for _, lineEvent := range lineEvents {
+				if hierarchyCtx != nil {
+					setHierarchy(lineEvent, hierarchyCtx)
+				}
+				events = append(events, lineEvent)
 			}
-			events = append(events, event)
 		}
 	}
 
@@ -148,7 +204,16 @@ func (a *ClaudeAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, erro
 		return nil, fmt.Errorf("error reading log file: %w", err)
 	}
 
-	return events, nil
+	markOrphanedRequests(events)
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// logHierarchy returns the workspace context events parsed from the lines of
+// filePath are attached to
+func (a *ClaudeAdapter) logHierarchy(filePath string) *hierarchy.WorkspaceContext {
+	return a.cachedLineHierarchy(filePath, func() *hierarchy.WorkspaceContext {
+		return resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
+	})
 }
 
 // detectEventType determines the event type from a log entry
@@ -165,6 +230,12 @@ func (a *ClaudeAdapter) detectEventType(entry *ClaudeLogEntry) string {
 		return types.EventTypeFileRead
 	case "file_write", "file_modify":
 		return types.EventTypeFileWrite
+	case "llm_canceled", "canceled", "cancelled", "aborted":
+		// Dropped unless canceled turns are reported
+		if a.options.CanceledTurns {
+			return types.EventTypeLLMCanceled
+		}
+		return ""
 	}
 
 	// Infer from message content
@@ -259,6 +330,16 @@ func (a *ClaudeAdapter) extractData(entry *ClaudeLogEntry, eventType string) map
 			data["response"] = entry.Response
 			data["responseLength"] = len(entry.Response)
 		}
+	case types.EventTypeLLMCanceled:
+		// The prompt and whatever part of the response had arrived
+		if entry.Prompt != "" {
+			data["prompt"] = entry.Prompt
+			data["promptLength"] = len(entry.Prompt)
+		}
+		if entry.Response != "" {
+			data["response"] = entry.Response
+			data["responseLength"] = len(entry.Response)
+		}
 	case types.EventTypeToolUse:
 		if entry.ToolName != "" {
 			data["toolName"] = entry.ToolName
@@ -269,6 +350,10 @@ func (a *ClaudeAdapter) extractData(entry *ClaudeLogEntry, eventType string) map
 		if entry.ToolOutput != nil {
 			data["toolOutput"] = entry.ToolOutput
 		}
+		if entry.Error != nil {
+			data["error"] = entry.Error
+		}
+		addToolOutcome(data, entry.ToolName, entry.ToolInput, entry.ToolOutput, toolSucceeded(entry.ToolOutput, entry.Error))
 	case types.EventTypeFileRead, types.EventTypeFileWrite:
 		if entry.FilePath != "" {
 			data["filePath"] = entry.FilePath
@@ -281,6 +366,9 @@ func (a *ClaudeAdapter) extractData(entry *ClaudeLogEntry, eventType string) map
 	if entry.ConversationID != "" {
 		data["conversationId"] = entry.ConversationID
 	}
+	if entry.RequestID != "" {
+		data["requestId"] = entry.RequestID
+	}
 	
 	return data
 }
@@ -313,3 +401,18 @@ func (a *ClaudeAdapter) SupportsFormat(sample string) bool {
 		strings.Contains(strings.ToLower(entry.Message), "claude") ||
 		strings.Contains(strings.ToLower(entry.Message), "anthropic")
 }
+
+// Confidence scores a sample SupportsFormat accepts by the fields only
+// Claude logs have, since Cursor logs share conversation_id and model
+func (a *ClaudeAdapter) Confidence(sample string) float64 {
+	if !a.SupportsFormat(sample) {
+		return 0
+	}
+
+	score := fieldScore(jsonKeys(sample), claudeOnlyFields, cursorOnlyFields)
+	var entry ClaudeLogEntry
+	if json.Unmarshal([]byte(sample), &entry) == nil && mentions(entry.Message, "claude", "anthropic") {
+		score += 0.2
+	}
+	return clampConfidence(score)
+}
@@ -370,3 +370,43 @@ func TestClaudeAdapter_IntegrationWithHierarchy(t *testing.T) {
 	assert.Equal(t, 0, event.MachineID) // Not set
 	assert.Equal(t, 0, event.WorkspaceID) // Not set
 }
+
+func TestClaudeAdapter_ToolOutcome(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	tests := []struct {
+		name       string
+		line       string
+		success    interface{}
+		outputSize int
+	}{
+		{
+			name:       "output",
+			line:       `{"timestamp":"2025-10-31T10:00:00Z","type":"tool_use","tool_name":"Read","tool_input":{"file_path":"main.go"},"tool_output":"package main"}`,
+			success:    true,
+			outputSize: len("package main"),
+		},
+		{
+			name:    "error",
+			line:    `{"timestamp":"2025-10-31T10:00:00Z","type":"tool_use","tool_name":"Read","tool_input":{"file_path":"main.go"},"error":"file not found"}`,
+			success: false,
+		},
+		{
+			name: "call without result",
+			line: `{"timestamp":"2025-10-31T10:00:00Z","type":"tool_use","tool_name":"Read","tool_input":{"file_path":"main.go"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := adapter.ParseLogLine(tt.line)
+			require.NoError(t, err)
+			require.NotNil(t, event)
+
+			assert.Equal(t, tt.success, event.Data["success"])
+			assert.Equal(t, ToolCategoryRead, event.Data["toolCategory"])
+			assert.Equal(t, len(`{"file_path":"main.go"}`), event.Data["inputSize"])
+			assert.Equal(t, tt.outputSize, event.Data["outputSize"])
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"sync"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// claudeLogSessions follows the conversations of one Claude log. Aggregated
+// logs, such as exported archives, hold several conversations, possibly
+// interleaved; a line without a conversation id continues the conversation
+// of the line before it.
+type claudeLogSessions struct {
+	mu      sync.Mutex
+	current string         // conversation of the last line that had one
+	started map[string]int // conversation -> its position among those of the log
+}
+
+func newClaudeLogSessions() *claudeLogSessions {
+	return &claudeLogSessions{started: make(map[string]int)}
+}
+
+// observe records the conversation of an event parsed from the log. When
+// the log switches to a conversation it hasn't held before, it returns a
+// session_start event to emit ahead of the event. The log's first
+// conversation needs none, the log starting being its boundary, and a
+// conversation picked up again continues its session.
+func (s *claudeLogSessions) observe(event *types.AgentEvent) *types.AgentEvent {
+	id := event.SessionID
+	if id == "" {
+		return nil
+	}
+	s.current = id
+	if _, ok := s.started[id]; ok {
+		return nil
+	}
+
+	s.started[id] = len(s.started) + 1
+	if len(s.started) == 1 {
+		return nil
+	}
+	return claudeSessionStart(event, s.started[id])
+}
+
+// logSessions returns the conversations followed for the lines of filePath
+func (a *ClaudeAdapter) logSessions(filePath string) *claudeLogSessions {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	sessions, ok := a.sessions[filePath]
+	if !ok {
+		sessions = newClaudeLogSessions()
+		a.sessions[filePath] = sessions
+	}
+	return sessions
+}
+
+// resetLog forgets the conversations and pending requests followed for the
+// lines of filePath
+func (a *ClaudeAdapter) resetLog(filePath string) {
+	a.turns.forget(filePath)
+
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	delete(a.sessions, filePath)
+}
+
+// claudeSessionStart builds the session_start event for the conversation of
+// first, the index-th to start in its log
+func claudeSessionStart(first *types.AgentEvent, index int) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       first.Timestamp,
+		Type:            types.EventTypeSessionStart,
+		AgentID:         first.AgentID,
+		AgentVersion:    first.AgentVersion,
+		SessionID:       first.SessionID,
+		ProjectID:       first.ProjectID,
+		MachineID:       first.MachineID,
+		WorkspaceID:     first.WorkspaceID,
+		LegacyProjectID: first.LegacyProjectID,
+		Context:         copyContext(first.Context),
+		Data: map[string]interface{}{
+			"conversationId": first.SessionID,
+			"sessionIndex":   index,
+		},
+	}
+}
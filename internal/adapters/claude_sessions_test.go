@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaudeAdapter_InterleavedSessions(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile("testdata/claude-interleaved-sessions.jsonl")
+	require.NoError(t, err)
+
+	assertInterleavedSessions(t, events)
+}
+
+// assertInterleavedSessions checks the events parsed from
+// testdata/claude-interleaved-sessions.jsonl
+func assertInterleavedSessions(t *testing.T, events []*types.AgentEvent) {
+	t.Helper()
+
+	starts := eventsOfType(events, types.EventTypeSessionStart)
+	require.Len(t, starts, 2, "one session start per conversation after the first")
+	for i, id := range []string{"conv_docs", "conv_ci"} {
+		assert.Equal(t, id, starts[i].SessionID)
+		assert.Equal(t, id, starts[i].Data["conversationId"])
+		assert.Equal(t, i+2, starts[i].Data["sessionIndex"])
+	}
+
+	// Each start comes right before its conversation's first event and none
+	// follows a switch back to a conversation already started
+	seen := map[string]bool{"conv_api": true}
+	for i, event := range events {
+		if event.Type == types.EventTypeSessionStart {
+			require.Less(t, i+1, len(events))
+			next := events[i+1]
+			assert.Equal(t, event.SessionID, next.SessionID)
+			assert.Equal(t, next.Timestamp, event.Timestamp)
+			assert.False(t, seen[event.SessionID], "session %s started twice", event.SessionID)
+			seen[event.SessionID] = true
+			continue
+		}
+		assert.True(t, seen[event.SessionID], "event of %s before its session start", event.SessionID)
+	}
+
+	// Lines without a conversation id continue the conversation before them
+	writes := eventsOfType(events, types.EventTypeFileWrite)
+	require.Len(t, writes, 2)
+	assert.Equal(t, "conv_api", writes[0].SessionID)
+	assert.Equal(t, "conv_ci", writes[1].SessionID)
+
+	sessions := make(map[string]int)
+	for _, event := range events {
+		if event.Type != types.EventTypeSessionStart {
+			sessions[event.SessionID]++
+		}
+	}
+	assert.Equal(t, map[string]int{"conv_api": 5, "conv_docs": 2, "conv_ci": 3}, sessions)
+}
+
+func TestClaudeAdapter_InterleavedSessionsLineByLine(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	path := "testdata/claude-interleaved-sessions.jsonl"
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var events []*types.AgentEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		parsed, err := ParseLine(adapter, path, line)
+		require.NoError(t, err)
+		events = append(events, parsed...)
+	}
+	assertInterleavedSessions(t, events)
+}
+
+func TestClaudeAdapter_SingleSessionHasNoStart(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	path := writeClaudeLog(t,
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"First"}`,
+		`{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Done"}`,
+	)
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Empty(t, eventsOfType(events, types.EventTypeSessionStart))
+}
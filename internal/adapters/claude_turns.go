@@ -0,0 +1,216 @@
+package adapters
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// claudeTurnWindow bounds how long after a request its response may be
+// logged and still be paired with it
+const claudeTurnWindow = 10 * time.Minute
+
+// maxPendingClaudeTurns bounds the requests kept waiting for a response;
+// past it the oldest is given up on
+const maxPendingClaudeTurns = 1000
+
+// claudeTurnKey identifies a request within a conversation
+type claudeTurnKey struct {
+	conversationID string
+	requestID      string
+}
+
+// pendingClaudeTurn is a request still waiting for its response
+type pendingClaudeTurn struct {
+	key           claudeTurnKey
+	correlationID string
+	timestamp     time.Time
+	file          string // log the request was read from
+}
+
+// claudeTurnTracker pairs Claude Desktop requests with responses that were
+// logged on separate lines under the same conversation and request id. It
+// keeps state across lines and files, so a response streamed in after its
+// request was already sent is still linked to it. Requests expire by the
+// time of the log they were read from: a log's lines are in time order, but
+// logs are read in any order, so newer lines of one say nothing about the
+// requests of another.
+type claudeTurnTracker struct {
+	mu      sync.Mutex
+	pending map[claudeTurnKey]pendingClaudeTurn
+	latest  map[string]time.Time // log file -> newest timestamp read from it while it has pending requests
+}
+
+func newClaudeTurnTracker() *claudeTurnTracker {
+	return &claudeTurnTracker{
+		pending: make(map[claudeTurnKey]pendingClaudeTurn),
+		latest:  make(map[string]time.Time),
+	}
+}
+
+// claudeCorrelationID derives the correlation id of a turn from its
+// conversation, request id and request time. It is the same on every parse
+// of a log, so the events it is set on hash the same way each time.
+func claudeCorrelationID(key claudeTurnKey, requested time.Time) string {
+	name := fmt.Sprintf("%s/%s/%d", key.conversationID, key.requestID, requested.UnixNano())
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// track records a request, or pairs a response (or the cancellation that
+// ended the turn) with its pending request. Both events of a pair get the
+// turn's claudeCorrelationID as Data["correlationId"], and the response's
+// Metrics.DurationMs is the time between them. Events without a request id
+// are left alone. It returns the requests of file the event's time expired.
+func (t *claudeTurnTracker) track(event *types.AgentEvent, requestID, file string) []pendingClaudeTurn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	expired := t.expire(file, event.Timestamp)
+
+	if requestID == "" {
+		return expired
+	}
+	key := claudeTurnKey{conversationID: event.SessionID, requestID: requestID}
+
+	switch event.Type {
+	case types.EventTypeLLMRequest:
+		if _, ok := t.pending[key]; !ok && len(t.pending) >= maxPendingClaudeTurns {
+			t.dropOldest()
+		}
+		correlationID := claudeCorrelationID(key, event.Timestamp)
+		t.pending[key] = pendingClaudeTurn{key: key, correlationID: correlationID, timestamp: event.Timestamp, file: file}
+		if event.Timestamp.After(t.latest[file]) {
+			t.latest[file] = event.Timestamp
+		}
+		event.Data["correlationId"] = correlationID
+
+	case types.EventTypeLLMResponse, types.EventTypeLLMCanceled:
+		turn, ok := t.pending[key]
+		if !ok || event.Timestamp.Before(turn.timestamp) {
+			return expired
+		}
+		delete(t.pending, key)
+		t.forgetIdle(turn.file)
+
+		event.Data["correlationId"] = turn.correlationID
+		if event.Metrics == nil {
+			event.Metrics = &types.EventMetrics{}
+		}
+		event.Metrics.DurationMs = event.Timestamp.Sub(turn.timestamp).Milliseconds()
+	}
+	return expired
+}
+
+// expire drops the requests read from file that fell out of the window
+// before the newest timestamp read from it, and returns them oldest first;
+// a response arriving after that is left unpaired
+func (t *claudeTurnTracker) expire(file string, now time.Time) []pendingClaudeTurn {
+	latest, ok := t.latest[file]
+	if !ok {
+		return nil
+	}
+	if now.After(latest) {
+		latest = now
+		t.latest[file] = now
+	}
+
+	cutoff := latest.Add(-claudeTurnWindow)
+	var expired []pendingClaudeTurn
+	for key, turn := range t.pending {
+		if turn.file == file && turn.timestamp.Before(cutoff) {
+			delete(t.pending, key)
+			expired = append(expired, turn)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].timestamp.Before(expired[j].timestamp)
+	})
+	t.forgetIdle(file)
+	return expired
+}
+
+// dropOldest gives up on the request waiting longest
+func (t *claudeTurnTracker) dropOldest() {
+	var oldest claudeTurnKey
+	var turn pendingClaudeTurn
+	found := false
+	for key, pending := range t.pending {
+		if !found || pending.timestamp.Before(turn.timestamp) {
+			oldest, turn, found = key, pending, true
+		}
+	}
+	if found {
+		delete(t.pending, oldest)
+		t.forgetIdle(turn.file)
+	}
+}
+
+// forget drops the pending requests read from file, for when it is read
+// again from its start
+func (t *claudeTurnTracker) forget(file string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, turn := range t.pending {
+		if turn.file == file {
+			delete(t.pending, key)
+		}
+	}
+	delete(t.latest, file)
+}
+
+// forgetIdle stops following the time of a file without pending requests
+func (t *claudeTurnTracker) forgetIdle(file string) {
+	for _, turn := range t.pending {
+		if turn.file == file {
+			return
+		}
+	}
+	delete(t.latest, file)
+}
+
+// orphanNotice reports a request read line by line that got no response
+// within the turn window. The request was emitted before that was known, so
+// rather than flagging it as ParseLogFile does, an error_encountered event
+// carries its correlation id and Data["orphaned"].
+func (a *ClaudeAdapter) orphanNotice(turn pendingClaudeTurn) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       turn.timestamp.Add(claudeTurnWindow),
+		Type:            types.EventTypeError,
+		AgentID:         a.name,
+		SessionID:       turn.key.conversationID,
+		LegacyProjectID: a.projectID,
+		Context:         make(map[string]interface{}),
+		Data: map[string]interface{}{
+			"error":         fmt.Sprintf("no response within %s", claudeTurnWindow),
+			"requestId":     turn.key.requestID,
+			"correlationId": turn.correlationID,
+			"orphaned":      true,
+		},
+	}
+}
+
+// markOrphanedRequests flags requests in events whose response isn't among
+// them with Data["orphaned"]
+func markOrphanedRequests(events []*types.AgentEvent) {
+	answered := make(map[interface{}]bool)
+	for _, event := range events {
+		if event.Type == types.EventTypeLLMResponse {
+			if id, ok := event.Data["correlationId"]; ok {
+				answered[id] = true
+			}
+		}
+	}
+
+	for _, event := range events {
+		if event.Type != types.EventTypeLLMRequest {
+			continue
+		}
+		if id, ok := event.Data["correlationId"]; ok && !answered[id] {
+			event.Data["orphaned"] = true
+		}
+	}
+}
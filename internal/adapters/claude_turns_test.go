@@ -0,0 +1,233 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeClaudeLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "claude.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	return path
+}
+
+func TestClaudeAdapter_PairsSplitTurns(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	path := writeClaudeLog(t,
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"First"}`,
+		`{"timestamp":"2025-10-31T10:00:01Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_2","prompt":"Second"}`,
+		`{"timestamp":"2025-10-31T10:00:04.5Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_2","response":"Two","response_tokens":3}`,
+		`{"timestamp":"2025-10-31T10:00:06Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"One"}`,
+	)
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	first, second, secondReply, firstReply := events[0], events[1], events[2], events[3]
+
+	require.NotEmpty(t, first.Data["correlationId"])
+	assert.NotEqual(t, first.Data["correlationId"], second.Data["correlationId"])
+	assert.Equal(t, first.Data["correlationId"], firstReply.Data["correlationId"])
+	assert.Equal(t, second.Data["correlationId"], secondReply.Data["correlationId"])
+	assert.Equal(t, "req_1", firstReply.Data["requestId"])
+
+	require.NotNil(t, firstReply.Metrics)
+	assert.Equal(t, int64(6000), firstReply.Metrics.DurationMs)
+	require.NotNil(t, secondReply.Metrics)
+	assert.Equal(t, int64(3500), secondReply.Metrics.DurationMs)
+	assert.Equal(t, 3, secondReply.Metrics.ResponseTokens)
+
+	for _, event := range events {
+		assert.NotContains(t, event.Data, "orphaned")
+	}
+}
+
+func TestClaudeAdapter_ReparseHashesTheSame(t *testing.T) {
+	path := writeClaudeLog(t,
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"First"}`,
+		`{"timestamp":"2025-10-31T10:00:06Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"One"}`,
+		`{"timestamp":"2025-10-31T10:20:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_2","prompt":"Lost"}`,
+	)
+
+	hashes := func(adapter *ClaudeAdapter) []string {
+		events, err := adapter.ParseLogFile(path)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		var hashes []string
+		for _, event := range events {
+			hashes = append(hashes, buffer.EventHash(event))
+		}
+		return hashes
+	}
+
+	// Re-parsing, by the same adapter or after a restart, must not make the
+	// turns look new to dedup
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	first := hashes(adapter)
+	assert.Equal(t, first, hashes(adapter))
+	assert.Equal(t, first, hashes(NewClaudeAdapter("test-project", nil, nil)))
+}
+
+func TestClaudeAdapter_OrphanedRequest(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	path := writeClaudeLog(t,
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Lost"}`,
+		`{"timestamp":"2025-10-31T10:01:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_2","prompt":"Answered"}`,
+		`{"timestamp":"2025-10-31T10:01:02Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_2","response":"Yes"}`,
+	)
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, true, events[0].Data["orphaned"])
+	assert.NotContains(t, events[1].Data, "orphaned")
+	assert.Equal(t, events[1].Data["correlationId"], events[2].Data["correlationId"])
+}
+
+func TestClaudeAdapter_SplitTurnsAcrossLines(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	request, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Hi"}`)
+	require.NoError(t, err)
+	// Same request id in another conversation isn't the same turn
+	other, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:01Z","type":"llm_response","conversation_id":"conv_2","request_id":"req_1","response":"Hey"}`)
+	require.NoError(t, err)
+	response, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:02Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Hello"}`)
+	require.NoError(t, err)
+
+	assert.NotContains(t, other.Data, "correlationId")
+	assert.Nil(t, other.Metrics)
+	assert.Equal(t, request.Data["correlationId"], response.Data["correlationId"])
+	assert.Equal(t, int64(2000), response.Metrics.DurationMs)
+
+	// A response is paired at most once
+	again, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:03Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Hello"}`)
+	require.NoError(t, err)
+	assert.NotContains(t, again.Data, "correlationId")
+}
+
+func TestClaudeAdapter_ResponseOutsideWindow(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	_, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Hi"}`)
+	require.NoError(t, err)
+	late, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:30:00Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Hello"}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.EventTypeLLMResponse, late.Type)
+	assert.NotContains(t, late.Data, "correlationId")
+	assert.Empty(t, adapter.turns.pending)
+}
+
+func TestClaudeAdapter_WindowIsPerFile(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	// Reading a newer log doesn't expire the requests of an older one
+	request, err := ParseLine(adapter, "old.jsonl", `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Hi"}`)
+	require.NoError(t, err)
+	require.Len(t, request, 1)
+	newer, err := ParseLine(adapter, "new.jsonl", `{"timestamp":"2025-11-02T09:00:00Z","type":"llm_request","conversation_id":"conv_2","request_id":"req_1","prompt":"Later"}`)
+	require.NoError(t, err)
+	require.Len(t, newer, 1)
+
+	response, err := ParseLine(adapter, "old.jsonl", `{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Hello"}`)
+	require.NoError(t, err)
+	require.Len(t, response, 1)
+	assert.Equal(t, request[0].Data["correlationId"], response[0].Data["correlationId"])
+	require.NotNil(t, response[0].Metrics)
+	assert.Equal(t, int64(5000), response[0].Metrics.DurationMs)
+}
+
+func TestClaudeAdapter_OrphanedRequestReadLineByLine(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	lost, err := ParseLine(adapter, "claude.jsonl", `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Lost"}`)
+	require.NoError(t, err)
+	require.Len(t, lost, 1)
+
+	// Still within the window
+	events, err := ParseLine(adapter, "claude.jsonl", `{"timestamp":"2025-10-31T10:05:00Z","type":"file_read","conversation_id":"conv_1","file_path":"/src/main.go"}`)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	// The request was sent already, so its orphaning is reported ahead of
+	// the line that shows the window passed
+	events, err = ParseLine(adapter, "claude.jsonl", `{"timestamp":"2025-10-31T10:11:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_2","prompt":"Again"}`)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	notice := events[0]
+	assert.Equal(t, types.EventTypeError, notice.Type)
+	assert.Equal(t, "conv_1", notice.SessionID)
+	assert.Equal(t, lost[0].Data["correlationId"], notice.Data["correlationId"])
+	assert.Equal(t, "req_1", notice.Data["requestId"])
+	assert.Equal(t, true, notice.Data["orphaned"])
+	assert.Equal(t, lost[0].Timestamp.Add(claudeTurnWindow), notice.Timestamp)
+	assert.Equal(t, "Again", events[1].Data["prompt"])
+
+	// A late response is left unpaired and reports nothing more
+	events, err = ParseLine(adapter, "claude.jsonl", `{"timestamp":"2025-10-31T10:12:00Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Too late"}`)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.NotContains(t, events[0].Data, "correlationId")
+}
+
+func TestClaudeTurnTracker_BoundsPendingRequests(t *testing.T) {
+	tracker := newClaudeTurnTracker()
+	start := time.Date(2025, 10, 31, 10, 0, 0, 0, time.UTC)
+	for i := 0; i <= maxPendingClaudeTurns; i++ {
+		event := &types.AgentEvent{
+			ID:        fmt.Sprintf("event_%d", i),
+			Type:      types.EventTypeLLMRequest,
+			SessionID: "conv_1",
+			Timestamp: start.Add(time.Duration(i) * time.Millisecond),
+			Data:      map[string]interface{}{},
+		}
+		tracker.track(event, fmt.Sprintf("req_%d", i), "claude.jsonl")
+	}
+
+	assert.Len(t, tracker.pending, maxPendingClaudeTurns)
+	assert.NotContains(t, tracker.pending, claudeTurnKey{conversationID: "conv_1", requestID: "req_0"}, "the oldest request is given up on")
+}
+
+func TestClaudeAdapter_CanceledTurns(t *testing.T) {
+	request := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Rewrite the whole module"}`
+	canceled := `{"timestamp":"2025-10-31T10:00:04Z","type":"cancelled","conversation_id":"conv_1","request_id":"req_1","response":"Sure, starting with","message":"Request cancelled by user"}`
+
+	t.Run("enabled", func(t *testing.T) {
+		adapter := NewClaudeAdapter("test-project", nil, nil)
+		adapter.SetOptions(Options{CanceledTurns: true})
+
+		req, err := adapter.ParseLogLine(request)
+		require.NoError(t, err)
+		event, err := adapter.ParseLogLine(canceled)
+		require.NoError(t, err)
+		require.NotNil(t, event)
+
+		assert.Equal(t, types.EventTypeLLMCanceled, event.Type)
+		assert.Equal(t, "Sure, starting with", event.Data["response"])
+		assert.Equal(t, req.Data["correlationId"], event.Data["correlationId"])
+		assert.Equal(t, int64(4000), event.Metrics.DurationMs)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		adapter := NewClaudeAdapter("test-project", nil, nil)
+
+		_, err := adapter.ParseLogLine(request)
+		require.NoError(t, err)
+		event, err := adapter.ParseLogLine(canceled)
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	})
+}
@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// coalesceFileReads collapses runs of consecutive file_read events for the
+// same file within the same turn into the first event of the run, recording
+// the number of reads as Data["readCount"]
+func coalesceFileReads(events []*types.AgentEvent) []*types.AgentEvent {
+	result := make([]*types.AgentEvent, 0, len(events))
+	var last *types.AgentEvent
+
+	for _, event := range events {
+		if last != nil && sameFileReadTurn(last, event) {
+			last.Data["readCount"] = last.Data["readCount"].(int) + 1
+			continue
+		}
+
+		last = nil
+		if event.Type == types.EventTypeFileRead && event.Data != nil {
+			if _, ok := event.Data["filePath"].(string); ok {
+				event.Data["readCount"] = 1
+				last = event
+			}
+		}
+		result = append(result, event)
+	}
+
+	return result
+}
+
+// sameFileReadTurn reports whether next reads the same file as prev within
+// the same session and request
+func sameFileReadTurn(prev, next *types.AgentEvent) bool {
+	if next.Type != types.EventTypeFileRead || next.Data == nil {
+		return false
+	}
+	if prev.SessionID != next.SessionID {
+		return false
+	}
+	if prev.Data["requestId"] != next.Data["requestId"] {
+		return false
+	}
+	path, ok := next.Data["filePath"].(string)
+	return ok && path == prev.Data["filePath"]
+}
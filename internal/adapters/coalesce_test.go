@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRepeatedReadSession(t *testing.T) string {
+	kind := "codeblockUri"
+	read := func(path string) CopilotResponseItem {
+		return CopilotResponseItem{Kind: &kind, URI: map[string]interface{}{"path": path}}
+	}
+
+	session := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{
+				RequestID:  "request_1",
+				ResponseID: "response_1",
+				Timestamp:  int64(1730372400000),
+				Message:    CopilotMessage{Text: "Explain main.go"},
+				Response: []CopilotResponseItem{
+					read("/project/main.go"),
+					read("/project/main.go"),
+					read("/project/main.go"),
+					read("/project/util.go"),
+				},
+			},
+			{
+				RequestID:  "request_2",
+				ResponseID: "response_2",
+				Timestamp:  int64(1730372460000),
+				Message:    CopilotMessage{Text: "And again"},
+				Response: []CopilotResponseItem{
+					read("/project/main.go"),
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func fileReads(events []*types.AgentEvent) []*types.AgentEvent {
+	var reads []*types.AgentEvent
+	for _, event := range events {
+		if event.Type == types.EventTypeFileRead {
+			reads = append(reads, event)
+		}
+	}
+	return reads
+}
+
+func TestCopilotAdapter_CoalesceFileReads(t *testing.T) {
+	path := writeRepeatedReadSession(t)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{CoalesceFileReads: true})
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+
+	reads := fileReads(events)
+	require.Len(t, reads, 3)
+
+	assert.Equal(t, "/project/main.go", reads[0].Data["filePath"])
+	assert.Equal(t, "request_1", reads[0].Data["requestId"])
+	assert.Equal(t, 3, reads[0].Data["readCount"])
+
+	assert.Equal(t, "/project/util.go", reads[1].Data["filePath"])
+	assert.Equal(t, 1, reads[1].Data["readCount"])
+
+	// A read in the next turn is not merged into the previous one
+	assert.Equal(t, "/project/main.go", reads[2].Data["filePath"])
+	assert.Equal(t, "request_2", reads[2].Data["requestId"])
+	assert.Equal(t, 1, reads[2].Data["readCount"])
+}
+
+func TestCopilotAdapter_CoalesceFileReadsDisabled(t *testing.T) {
+	path := writeRepeatedReadSession(t)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+
+	reads := fileReads(events)
+	require.Len(t, reads, 5)
+	for _, read := range reads {
+		assert.NotContains(t, read.Data, "readCount")
+	}
+}
@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gzipSuffix marks log files compressed by log rotation or archiving
+const gzipSuffix = ".gz"
+
+// IsCompressed reports whether filePath is a gzip-compressed log file
+func IsCompressed(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), gzipSuffix)
+}
+
+// LogExt returns the extension of filePath, ignoring a trailing .gz so that
+// session.jsonl.gz reports ".jsonl"
+func LogExt(filePath string) string {
+	if IsCompressed(filePath) {
+		filePath = filePath[:len(filePath)-len(gzipSuffix)]
+	}
+	return filepath.Ext(filePath)
+}
+
+// trimLogExt removes the extension, and a trailing .gz, from a file name
+func trimLogExt(name string) string {
+	if IsCompressed(name) {
+		name = name[:len(name)-len(gzipSuffix)]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// OpenLogFile opens filePath for reading, transparently decompressing it when
+// the path ends in .gz. Closing the returned reader closes the file.
+func OpenLogFile(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCompressed(filePath) {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", filePath, err)
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// readLogFile reads the whole of filePath, decompressing it if needed
+func readLogFile(filePath string) ([]byte, error) {
+	r, err := OpenLogFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// gzipFile closes both the gzip stream and the underlying file
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
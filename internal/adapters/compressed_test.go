@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeGzip writes content gzip-compressed to path
+func writeGzip(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	_, err = gz.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+}
+
+func TestLogExt(t *testing.T) {
+	tests := []struct {
+		path    string
+		ext     string
+		trimmed string
+	}{
+		{"session.jsonl", ".jsonl", "session"},
+		{"session.jsonl.gz", ".jsonl", "session"},
+		{"/logs/abc123.json.GZ", ".json", "abc123"},
+		{"archive.gz", "", "archive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.ext, LogExt(tt.path))
+			assert.Equal(t, tt.trimmed, trimLogExt(filepath.Base(tt.path)))
+		})
+	}
+}
+
+func TestClaudeAdapter_ParseGzippedLogFile(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+
+	content := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_123","prompt":"Hello","prompt_tokens":1}
+{"timestamp":"2025-10-31T10:00:01Z","type":"llm_response","conversation_id":"conv_123","response":"Hi there!","response_tokens":2}
+{"timestamp":"2025-10-31T10:00:02Z","level":"debug","message":"Debug info"}
+`
+	testFile := filepath.Join(t.TempDir(), "claude-test.jsonl.gz")
+	writeGzip(t, testFile, []byte(content))
+
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, types.EventTypeLLMRequest, events[0].Type)
+	assert.Equal(t, types.EventTypeLLMResponse, events[1].Type)
+}
+
+func TestCopilotAdapter_ParseGzippedSession(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+
+	content, err := os.ReadFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	testFile := filepath.Join(t.TempDir(), "copilot-text-edit-group.json.gz")
+	writeGzip(t, testFile, content)
+
+	assert.True(t, ParsesWholeFile(adapter, testFile))
+
+	expected, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	require.Len(t, events, len(expected))
+	for i := range events {
+		assert.Equal(t, expected[i].Type, events[i].Type)
+		assert.Equal(t, "copilot-text-edit-group", events[i].SessionID)
+	}
+}
+
+func TestOpenLogFile_CorruptGzip(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "broken.jsonl.gz")
+	require.NoError(t, os.WriteFile(testFile, []byte("not gzip"), 0644))
+
+	_, err := OpenLogFile(testFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,383 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ContinueAdapter parses Continue.dev chat sessions saved as
+// ~/.continue/sessions/<sessionId>.json. Continue rewrites a session file
+// whenever the chat changes.
+type ContinueAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+}
+
+// NewContinueAdapter creates a new Continue adapter
+func NewContinueAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *ContinueAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &ContinueAdapter{
+		BaseAdapter: NewBaseAdapter("continue", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+	}
+}
+
+// continueSessionIndex is the file listing session metadata next to the
+// session files; it holds no chat history
+const continueSessionIndex = "sessions.json"
+
+// ContinueSessionInfo is the entry of a session in the session index
+type ContinueSessionInfo struct {
+	SessionID   string      `json:"sessionId"`
+	DateCreated interface{} `json:"dateCreated,omitempty"` // Epoch milliseconds, as a string or a number
+}
+
+// ContinueSession is a saved Continue chat
+type ContinueSession struct {
+	SessionID          string                `json:"sessionId"`
+	Title              string                `json:"title,omitempty"`
+	WorkspaceDirectory string                `json:"workspaceDirectory,omitempty"`
+	History            []ContinueHistoryItem `json:"history"`
+}
+
+// ContinueHistoryItem is one message of a session together with the context
+// and tool state Continue recorded for it
+type ContinueHistoryItem struct {
+	Message       ContinueMessage        `json:"message"`
+	ContextItems  []ContinueContextItem  `json:"contextItems,omitempty"`
+	PromptLogs    []ContinuePromptLog    `json:"promptLogs,omitempty"`
+	ToolCallState *ContinueToolCallState `json:"toolCallState,omitempty"`
+}
+
+// ContinueMessage is a chat message. Roles are "user", "assistant",
+// "thinking", "tool" and "system"; content is a string or a list of parts.
+type ContinueMessage struct {
+	Role       string             `json:"role"`
+	Content    json.RawMessage    `json:"content,omitempty"`
+	ToolCalls  []ContinueToolCall `json:"toolCalls,omitempty"`
+	ToolCallID string             `json:"toolCallId,omitempty"`
+}
+
+// ContinueToolCall is an OpenAI-style function call requested by the model
+type ContinueToolCall struct {
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// ContinueContextItem is a file, selection or tool output attached to a message
+type ContinueContextItem struct {
+	Name        string                  `json:"name,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Content     string                  `json:"content,omitempty"`
+	URI         *ContinueContextItemURI `json:"uri,omitempty"`
+}
+
+// ContinueContextItemURI locates a context item; Type is "file" or "url"
+type ContinueContextItemURI struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// ContinuePromptLog records the model call that produced an assistant message
+type ContinuePromptLog struct {
+	ModelTitle        string `json:"modelTitle,omitempty"`
+	CompletionOptions struct {
+		Model string `json:"model,omitempty"`
+	} `json:"completionOptions"`
+}
+
+// ContinueToolCallState is the outcome of the tool call an assistant message made
+type ContinueToolCallState struct {
+	ToolCallID string                `json:"toolCallId"`
+	Status     string                `json:"status,omitempty"`
+	Output     []ContinueContextItem `json:"output,omitempty"`
+}
+
+// continueFileTools maps Continue's built-in file tools to the event they
+// imply, besides the tool_use event itself
+var continueFileTools = map[string]string{
+	"read_file":          types.EventTypeFileRead,
+	"create_new_file":    types.EventTypeFileWrite,
+	"edit_existing_file": types.EventTypeFileModify,
+}
+
+// ParseLogLine is not supported; sessions are JSON documents rewritten in place
+func (a *ContinueAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	return nil, fmt.Errorf("line-based parsing not supported for Continue sessions")
+}
+
+// ParseLogFile parses a Continue session file
+func (a *ContinueAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	if filepath.Base(filePath) == continueSessionIndex {
+		return nil, nil
+	}
+
+	data, err := readLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session ContinueSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse session JSON: %w", err)
+	}
+
+	sessionID := session.SessionID
+	if sessionID == "" {
+		sessionID = trimLogExt(filepath.Base(filePath))
+	}
+
+	// Messages carry no timestamps; order them after the session's creation
+	start := continueSessionCreated(filepath.Join(filepath.Dir(filePath), continueSessionIndex), sessionID)
+	events := a.parseSession(&session, sessionID, start)
+	return a.postProcess(filePath, nil, events), nil
+}
+
+// continueSessionCreated returns when the session was created according to
+// the session index at indexPath, or the zero time if it isn't listed
+func continueSessionCreated(indexPath, sessionID string) time.Time {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return time.Time{}
+	}
+	var sessions []ContinueSessionInfo
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return time.Time{}
+	}
+
+	for _, info := range sessions {
+		if info.SessionID != sessionID {
+			continue
+		}
+		switch v := info.DateCreated.(type) {
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+				return epochTime(n)
+			}
+		case float64:
+			if v > 0 {
+				return epochTime(int64(v))
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// parseSession converts the session history into events, placing the
+// messages after start by their position
+func (a *ContinueAdapter) parseSession(session *ContinueSession, sessionID string, start time.Time) []*types.AgentEvent {
+	context := map[string]interface{}{}
+	if session.WorkspaceDirectory != "" {
+		context["workspacePath"] = fileURIToPath(session.WorkspaceDirectory)
+	}
+	if session.Title != "" {
+		context["title"] = session.Title
+	}
+
+	// Tool results arrive as separate messages or as the calling message's
+	// tool state; index both by call ID
+	results := make(map[string]string)
+	statuses := make(map[string]string)
+	for _, item := range session.History {
+		if item.Message.Role == "tool" && item.Message.ToolCallID != "" {
+			results[item.Message.ToolCallID] = continueContentText(item.Message.Content)
+		}
+		if state := item.ToolCallState; state != nil && state.ToolCallID != "" {
+			statuses[state.ToolCallID] = state.Status
+			if _, ok := results[state.ToolCallID]; !ok && len(state.Output) > 0 {
+				var output []string
+				for _, out := range state.Output {
+					output = append(output, out.Content)
+				}
+				results[state.ToolCallID] = strings.Join(output, "\n")
+			}
+		}
+	}
+
+	var events []*types.AgentEvent
+	turn := 0
+	requestID := ""
+	for i, item := range session.History {
+		timestamp := sessionTimestamp(start, i)
+		msg := item.Message
+		text := continueContentText(msg.Content)
+
+		switch msg.Role {
+		case "user":
+			turn++
+			requestID = fmt.Sprintf("%s-%d", sessionID, turn)
+			event := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, map[string]interface{}{
+				"requestId":    requestID,
+				"prompt":       text,
+				"promptLength": len(text),
+			})
+			event.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(text)}
+			events = append(events, event)
+
+			for _, ctxItem := range item.ContextItems {
+				if ctxItem.URI == nil || ctxItem.URI.Type != "file" {
+					continue
+				}
+				events = append(events, a.newEvent(types.EventTypeFileRead, sessionID, timestamp, context, map[string]interface{}{
+					"requestId": requestID,
+					"filePath":  fileURIToPath(ctxItem.URI.Value),
+				}))
+			}
+
+		case "assistant":
+			eventContext := context
+			if model := continueModel(item.PromptLogs); model != "" {
+				eventContext = copyContext(context)
+				eventContext["model"] = model
+			}
+
+			for _, call := range msg.ToolCalls {
+				data := map[string]interface{}{
+					"requestId":  requestID,
+					"toolName":   call.Function.Name,
+					"toolCallId": call.ID,
+				}
+				if call.Function.Arguments != "" {
+					data["toolArgs"] = call.Function.Arguments
+				}
+				if result, ok := results[call.ID]; ok {
+					data["toolOutput"] = result
+				}
+				if status := statuses[call.ID]; status != "" {
+					data["toolStatus"] = status
+				}
+				events = append(events, a.newEvent(types.EventTypeToolUse, sessionID, timestamp, eventContext, data))
+
+				if fileEvent := a.fileToolEvent(call, requestID, sessionID, timestamp, eventContext); fileEvent != nil {
+					events = append(events, fileEvent)
+				}
+			}
+
+			if text != "" {
+				event := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, eventContext, map[string]interface{}{
+					"requestId":      requestID,
+					"response":       text,
+					"responseLength": len(text),
+				})
+				event.Metrics = &types.EventMetrics{ResponseTokens: estimateTokens(text)}
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// fileToolEvent returns the file event implied by a call to one of
+// Continue's built-in file tools, or nil for other tools
+func (a *ContinueAdapter) fileToolEvent(call ContinueToolCall, requestID, sessionID string, timestamp time.Time, context map[string]interface{}) *types.AgentEvent {
+	eventType, ok := continueFileTools[call.Function.Name]
+	if !ok {
+		return nil
+	}
+
+	var args struct {
+		Filepath string `json:"filepath"`
+	}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil || args.Filepath == "" {
+		return nil
+	}
+
+	return a.newEvent(eventType, sessionID, timestamp, context, map[string]interface{}{
+		"requestId":  requestID,
+		"filePath":   args.Filepath,
+		"toolCallId": call.ID,
+	})
+}
+
+// continueContentText flattens message content, which is either a string
+// or a list of parts of which only text parts are kept
+func continueContentText(content json.RawMessage) string {
+	if len(content) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// continueModel returns the model that produced a message
+func continueModel(logs []ContinuePromptLog) string {
+	for _, log := range logs {
+		if log.CompletionOptions.Model != "" {
+			return log.CompletionOptions.Model
+		}
+		if log.ModelTitle != "" {
+			return log.ModelTitle
+		}
+	}
+	return ""
+}
+
+// fileURIToPath converts a file:// URI to a local path; other values are
+// returned unchanged
+func fileURIToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	path := parsed.Path
+	// file:///c:/Users/... on Windows
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// SupportsFormat checks if this adapter can handle the given log format
+func (a *ContinueAdapter) SupportsFormat(sample string) bool {
+	var session ContinueSession
+	if err := json.Unmarshal([]byte(sample), &session); err != nil {
+		return false
+	}
+	if session.SessionID == "" || session.History == nil {
+		return false
+	}
+	for _, item := range session.History {
+		if item.Message.Role == "" {
+			return false
+		}
+	}
+	return true
+}
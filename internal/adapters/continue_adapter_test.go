@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContinueAdapter_ParseLogFile(t *testing.T) {
+	adapter := NewContinueAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "continue-session.json"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	tools := eventsOfType(events, types.EventTypeToolUse)
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	modifies := eventsOfType(events, types.EventTypeFileModify)
+	require.Len(t, requests, 2)
+	require.Len(t, responses, 2)
+	require.Len(t, tools, 2)
+	require.Len(t, reads, 2, "one file context item and one read_file call")
+	require.Len(t, modifies, 1)
+
+	assert.Equal(t, "Why does PruneOlderThan vacuum the database?", requests[0].Data["prompt"])
+	assert.Equal(t, "Lower the threshold to 10%.", requests[1].Data["prompt"])
+	assert.Equal(t, filepath.FromSlash("/home/dev/devlog/internal/buffer/buffer.go"), reads[0].Data["filePath"])
+	assert.Equal(t, "internal/buffer/buffer.go", reads[1].Data["filePath"])
+
+	// Output from the tool state of the calling message
+	assert.Equal(t, "read_file", tools[0].Data["toolName"])
+	assert.Equal(t, "call_read", tools[0].Data["toolCallId"])
+	assert.Equal(t, "const vacuumFreeRatio = 0.25", tools[0].Data["toolOutput"])
+	assert.Equal(t, "done", tools[0].Data["toolStatus"])
+	assert.Equal(t, "claude-3-5-sonnet-latest", tools[0].Context["model"])
+	assert.Equal(t, requests[0].Data["requestId"], tools[0].Data["requestId"])
+
+	// Output from a separate tool message
+	assert.Equal(t, "edit_existing_file", tools[1].Data["toolName"])
+	assert.Equal(t, "File not writable", tools[1].Data["toolOutput"])
+	assert.Equal(t, "errored", tools[1].Data["toolStatus"])
+	assert.Equal(t, requests[1].Data["requestId"], modifies[0].Data["requestId"])
+
+	assert.Equal(t, "Done.", responses[1].Data["response"])
+
+	for i, event := range events {
+		assert.Equal(t, "continue", event.AgentID)
+		assert.Equal(t, "7f3c9a52-1b2e-4f6a-9d3e-2c1b0a987654", event.SessionID)
+		assert.Equal(t, filepath.FromSlash("/home/dev/devlog"), event.Context["workspacePath"])
+		if i > 0 {
+			assert.False(t, event.Timestamp.Before(events[i-1].Timestamp), "events should stay in order")
+		}
+	}
+}
+
+func TestContinueAdapter_TimestampsAreStable(t *testing.T) {
+	dir := t.TempDir()
+	session, err := os.ReadFile(filepath.Join("testdata", "continue-session.json"))
+	require.NoError(t, err)
+	path := filepath.Join(dir, "7f3c9a52-1b2e-4f6a-9d3e-2c1b0a987654.json")
+	require.NoError(t, os.WriteFile(path, session, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sessions.json"),
+		[]byte(`[{"sessionId":"7f3c9a52-1b2e-4f6a-9d3e-2c1b0a987654","title":"x","dateCreated":"1730372400000"}]`), 0644))
+
+	adapter := NewContinueAdapter("test-project", nil, nil)
+	parse := func() ([]*types.AgentEvent, []string) {
+		t.Helper()
+		events, err := adapter.ParseLogFile(path)
+		require.NoError(t, err)
+		var hashes []string
+		for _, event := range events {
+			hashes = append(hashes, buffer.EventHash(event))
+		}
+		return events, hashes
+	}
+
+	events, first := parse()
+	require.NotEmpty(t, events)
+	assert.Equal(t, time.UnixMilli(1730372400000), events[0].Timestamp, "messages follow the session's creation")
+
+	// Continue rewrites the file as the chat goes on, moving its mtime
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, again := parse()
+	assert.Equal(t, first, again)
+}
+
+func TestContinueAdapter_SkipsSessionIndex(t *testing.T) {
+	indexFile := filepath.Join(t.TempDir(), "sessions.json")
+	require.NoError(t, os.WriteFile(indexFile, []byte(`[{"sessionId":"abc","title":"x","dateCreated":"1730372400000"}]`), 0644))
+
+	adapter := NewContinueAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(indexFile)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestContinueAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewContinueAdapter("test-project", nil, nil)
+
+	session, err := os.ReadFile(filepath.Join("testdata", "continue-session.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"continue session", string(session), true},
+		{"empty session", `{"sessionId":"abc","title":"New Session","history":[]}`, true},
+		{"session index", `[{"sessionId":"abc"}]`, false},
+		{"copilot session", `{"version":3,"requests":[]}`, false},
+		{"codecompanion chat", `{"save_id":"1","messages":[{"role":"llm","content":"hi"}]}`, false},
+		{"not json", `hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, adapter.SupportsFormat(tt.sample))
+		})
+	}
+}
+
+func TestDefaultRegistry_DetectsContinueSession(t *testing.T) {
+	session, err := os.ReadFile(filepath.Join("testdata", "continue-session.json"))
+	require.NoError(t, err)
+
+	registry := DefaultRegistry("test-project", nil, nil)
+	detected, err := registry.DetectAdapter(string(session))
+	require.NoError(t, err)
+	assert.Equal(t, "continue", detected.Name())
+}
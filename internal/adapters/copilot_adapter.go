@@ -3,7 +3,6 @@ package adapters
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -18,8 +17,6 @@ import (
 // CopilotAdapter parses GitHub Copilot chat session logs
 type CopilotAdapter struct {
 	*BaseAdapter
-	sessionID    string
-	workspaceID  string // VS Code workspace ID from file path
 	hierarchy    *hierarchy.HierarchyCache
 	log          *logrus.Logger
 	projectIDInt int // Parsed integer project ID
@@ -38,14 +35,23 @@ func NewCopilotAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCach
 	}
 
 	return &CopilotAdapter{
-		BaseAdapter:  NewBaseAdapter("github-copilot", projectID),
-		sessionID:    uuid.New().String(),
+		BaseAdapter:  NewBaseAdapter("github-copilot", projectID, log),
 		hierarchy:    hierarchyCache,
 		log:          log,
 		projectIDInt: projID,
 	}
 }
 
+// copilotFileState is what the events of one chat session file share. It is
+// built by each parse and passed down, so one adapter can parse several
+// files concurrently.
+type copilotFileState struct {
+	sessionID     string
+	workspaceID   string // VS Code workspace ID from file path
+	workspaceRoot string // Project folder of the workspace, for relative file paths
+	hierarchyCtx  *hierarchy.WorkspaceContext
+}
+
 // CopilotChatSession represents a Copilot chat session file
 type CopilotChatSession struct {
 	Version           int              `json:"version"`
@@ -90,9 +96,34 @@ type CopilotResponseItem struct {
 	InvocationMessage json.RawMessage        `json:"invocationMessage,omitempty"` // Can be string or object
 	PastTenseMessage  json.RawMessage        `json:"pastTenseMessage,omitempty"`  // Can be string or object
 	IsComplete        bool                   `json:"isComplete,omitempty"`
+	ResultDetails     json.RawMessage        `json:"resultDetails,omitempty"` // Tool input and output, or the URIs a tool returned
 	Source            *CopilotToolSource     `json:"source,omitempty"`
 	URI               map[string]interface{} `json:"uri,omitempty"`
 	Edits             []interface{}          `json:"edits,omitempty"`
+	InlineReference   map[string]interface{} `json:"inlineReference,omitempty"` // URI or {uri, range} location
+	Name              string                 `json:"name,omitempty"`            // Display name of an inline reference
+	Command           *CopilotCommand        `json:"command,omitempty"`
+	Title             string                 `json:"title,omitempty"` // Confirmation title
+}
+
+// CopilotToolResultDetails is the input and output VS Code keeps for some
+// tool calls, MCP tools in particular
+type CopilotToolResultDetails struct {
+	Input   string              `json:"input"`
+	Output  []CopilotToolOutput `json:"output"`
+	IsError bool                `json:"isError,omitempty"`
+}
+
+// CopilotToolOutput is one part of a tool call's output
+type CopilotToolOutput struct {
+	Value  string `json:"value"`
+	IsText bool   `json:"isText,omitempty"`
+}
+
+// CopilotCommand is a command button offered in a response
+type CopilotCommand struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
 }
 
 // CopilotContent represents nested content with flexible value type
@@ -112,14 +143,16 @@ type CopilotVariableData struct {
 	Variables []CopilotVariable `json:"variables"`
 }
 
-// CopilotVariable represents a context variable (file, workspace info, etc)
+// CopilotVariable represents a context variable (file, selection, symbol,
+// terminal output, etc). Value is a URI or location object for files and
+// symbols, and may be plain text for terminal output.
 type CopilotVariable struct {
-	ID        string                 `json:"id"`
-	Name      string                 `json:"name"`
-	Value     map[string]interface{} `json:"value"`
-	Kind      string                 `json:"kind"`
-	IsRoot    bool                   `json:"isRoot"`
-	AutoAdded bool                   `json:"automaticallyAdded"`
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Value     interface{} `json:"value"`
+	Kind      string      `json:"kind"`
+	IsRoot    bool        `json:"isRoot"`
+	AutoAdded bool        `json:"automaticallyAdded"`
 }
 
 // ParseLogLine is deprecated - Copilot uses chat session files, not line-based logs
@@ -129,14 +162,38 @@ func (a *CopilotAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
 
 // ParseLogFile parses a Copilot chat session file
 func (a *CopilotAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	return a.ParseLogFileInRange(filePath, time.Time{}, time.Time{})
+}
+
+// ParseLogFileInRange parses a Copilot chat session file, skipping requests
+// whose timestamp falls outside [from, to] without extracting their events
+func (a *CopilotAdapter) ParseLogFileInRange(filePath string, from, to time.Time) ([]*types.AgentEvent, error) {
+	return a.parseChatSession(filePath, from, to, 0)
+}
+
+// ParseLogFileFrom parses the requests appended to a Copilot chat session
+// file past offset. An editing session's state is rewritten rather than
+// appended to, so it is parsed whole.
+func (a *CopilotAdapter) ParseLogFileFrom(filePath string, offset int64) ([]*types.AgentEvent, error) {
+	if IsCompressed(filePath) {
+		// Offsets into the document count decompressed bytes
+		offset = 0
+	}
+	return a.parseChatSession(filePath, time.Time{}, time.Time{}, offset)
+}
+
+// parseChatSession parses the requests of a Copilot session file made within
+// [from, to] that start at or past offset
+func (a *CopilotAdapter) parseChatSession(filePath string, from, to time.Time, offset int64) ([]*types.AgentEvent, error) {
 	// Extract workspace ID from path first
-	// Path format: .../workspaceStorage/{workspace-id}/chatSessions/{session-id}.json
+	// Path format: .../workspaceStorage/{workspace-id}/chatSessions/{session-id}.json,
+	// or chatEditingSessions/{session-id}/state.json for agent mode edits
 	workspaceID := extractWorkspaceIDFromPath(filePath)
 
 	// Resolve hierarchy context if workspace ID found and hierarchy cache available
 	var hierarchyCtx *hierarchy.WorkspaceContext
 	if workspaceID != "" && a.hierarchy != nil {
-		ctx, err := a.hierarchy.Resolve(workspaceID)
+		ctx, err := a.hierarchy.Resolve(a.ctx, workspaceID)
 		if err != nil {
 			a.log.Warnf("Failed to resolve workspace %s: %v - continuing without hierarchy", workspaceID, err)
 		} else {
@@ -146,57 +203,95 @@ func (a *CopilotAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, err
 		}
 	}
 
-	// Read the entire file
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read chat session file: %w", err)
+	state := &copilotFileState{
+		sessionID:     extractSessionID(filePath),
+		workspaceID:   workspaceID,
+		workspaceRoot: workspaceRootForLog(filePath),
+		hierarchyCtx:  hierarchyCtx,
 	}
 
-	// Parse as JSON
-	var session CopilotChatSession
-	if err := json.Unmarshal(data, &session); err != nil {
-		return nil, fmt.Errorf("failed to parse chat session JSON: %w", err)
+	if isCopilotEditingSession(filePath) {
+		events, err := a.parseEditingSession(state, filePath, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return a.postProcess(filePath, hierarchyCtx, events), nil
 	}
 
-	// Extract session ID from filename
-	sessionID := extractSessionID(filePath)
-	a.sessionID = sessionID
-
-	// Extract workspace ID from file path
-	a.workspaceID = extractWorkspaceIDFromPath(filePath)
+	file, err := OpenLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat session file: %w", err)
+	}
+	defer file.Close()
 
 	var events []*types.AgentEvent
+	var requestEvents []*types.AgentEvent // LLM request events, which carry session fields
+
+	// Stream the session so only one request is decoded at a time
+	var session CopilotChatSession
+	err = decodeChatSession(file, &session, func(request *CopilotRequest, i int, start int64) {
+		// Skip requests parsed before and those outside the requested date
+		// range
+		if start < offset || !inRange(parseTimestamp(request.Timestamp), from, to) {
+			return
+		}
 
-	// Process each request in the session
-	for i, request := range session.Requests {
-		// Skip canceled requests
+		// Canceled requests are dropped unless they are to be reported
 		if request.IsCanceled {
-			continue
+			if a.options.CanceledTurns {
+				event := a.createLLMCanceledEvent(state, &session, request)
+				requestEvents = append(requestEvents, event)
+				events = append(events, event)
+			}
+			return
 		}
 
 		// Extract events from this request
-		requestEvents, err := a.extractEventsFromRequest(&session, &request, i, hierarchyCtx)
+		extracted, err := a.extractEventsFromRequest(state, &session, request, i)
 		if err != nil {
 			// Log error but continue processing
-			continue
+			return
 		}
 
-		events = append(events, requestEvents...)
+		for _, event := range extracted {
+			if event.Type == types.EventTypeLLMRequest {
+				requestEvents = append(requestEvents, event)
+			}
+		}
+		events = append(events, extracted...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chat session JSON: %w", err)
 	}
 
-	return events, nil
+	// Session fields may follow the requests array in the file, so they are
+	// only known once the whole document has been read
+	for _, event := range requestEvents {
+		event.Context["username"] = session.RequesterUsername
+		event.Context["location"] = session.InitialLocation
+		event.Context["workspacePath"] = session.InitialLocation
+	}
+
+	return a.postProcess(filePath, hierarchyCtx, events), nil
 }
 
-// extractSessionID extracts the session ID from the filename
+// extractSessionID extracts the session ID from the filename, or from the
+// directory of an editing session's state file
 func extractSessionID(filePath string) string {
+	if isCopilotEditingSession(filePath) {
+		return filepath.Base(filepath.Dir(filePath))
+	}
 	filename := filepath.Base(filePath)
-	// Remove .json extension
-	sessionID := strings.TrimSuffix(filename, ".json")
+	// Remove .json (or .json.gz) extension
+	sessionID := trimLogExt(filename)
 	return sessionID
 }
 
 // extractWorkspaceIDFromPath extracts the workspace ID from the file path
-// Expected path format: .../workspaceStorage/{workspace-id}/chatSessions/{session-id}.json
+// Expected path formats:
+//
+//	.../workspaceStorage/{workspace-id}/chatSessions/{session-id}.json
+//	.../workspaceStorage/{workspace-id}/chatEditingSessions/{session-id}/state.json
 func extractWorkspaceIDFromPath(filePath string) string {
 	// Normalize path separators
 	normalizedPath := filepath.ToSlash(filePath)
@@ -224,54 +319,77 @@ func parseTimestamp(ts interface{}) time.Time {
 		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
 			return t
 		}
+		// Epoch timestamp stored as a quoted number
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return epochTime(n)
+		}
 	case float64:
-		// Unix timestamp in milliseconds
-		return time.Unix(0, int64(v)*int64(time.Millisecond))
+		// Unix timestamp, normally in milliseconds
+		return epochTime(int64(v))
 	case int64:
-		// Unix timestamp in milliseconds
-		return time.Unix(0, v*int64(time.Millisecond))
+		// Unix timestamp, normally in milliseconds
+		return epochTime(v)
 	}
 	// Fallback to now
 	return time.Now()
 }
 
+// epochMillisThreshold separates epoch seconds from epoch milliseconds: as
+// milliseconds it is early 1973, as seconds it is thousands of years away
+const epochMillisThreshold = 100_000_000_000
+
+// epochTime converts a Unix timestamp in seconds or milliseconds, told
+// apart by magnitude
+func epochTime(n int64) time.Time {
+	if n < epochMillisThreshold && n > -epochMillisThreshold {
+		return time.Unix(n, 0)
+	}
+	return time.UnixMilli(n)
+}
+
 // extractEventsFromRequest extracts all events from a single request-response turn
 func (a *CopilotAdapter) extractEventsFromRequest(
+	state *copilotFileState,
 	session *CopilotChatSession,
 	request *CopilotRequest,
 	requestIndex int,
-	hierarchyCtx *hierarchy.WorkspaceContext,
 ) ([]*types.AgentEvent, error) {
 	var events []*types.AgentEvent
 
 	timestamp := parseTimestamp(request.Timestamp)
 
 	// 1. Create LLM Request Event
-	events = append(events, a.createLLMRequestEvent(session, request, timestamp, hierarchyCtx))
+	events = append(events, a.createLLMRequestEvent(state, session, request, timestamp))
 
-	// 2. Extract file reference events from variables
+	// 2. Extract file reference and editor context events from variables
 	for _, variable := range request.VariableData.Variables {
-		if event := a.createFileReferenceEvent(request, &variable, timestamp, hierarchyCtx); event != nil {
+		if event := a.createFileReferenceEvent(state, request, &variable, timestamp); event != nil {
+			events = append(events, event)
+		} else if event := a.createContextEvent(state, request, &variable, timestamp); event != nil {
 			events = append(events, event)
 		}
 	}
 
 	// 3. Extract tool invocations and collect response text
-	toolEvents, responseText := a.extractToolAndResponseEvents(request, timestamp, hierarchyCtx)
+	toolEvents, responseText, annotations := a.extractToolAndResponseEvents(state, request, timestamp)
 	events = append(events, toolEvents...)
 
 	// 4. Create LLM Response Event
-	events = append(events, a.createLLMResponseEvent(request, responseText, timestamp, hierarchyCtx))
+	response := a.createLLMResponseEvent(state, request, responseText, timestamp)
+	for key, value := range annotations {
+		response.Data[key] = value
+	}
+	events = append(events, response)
 
 	return events, nil
 }
 
 // createLLMRequestEvent creates an event for the user's request
 func (a *CopilotAdapter) createLLMRequestEvent(
+	state *copilotFileState,
 	session *CopilotChatSession,
 	request *CopilotRequest,
 	timestamp time.Time,
-	hierarchyCtx *hierarchy.WorkspaceContext,
 ) *types.AgentEvent {
 	promptText := request.Message.Text
 	promptLength := len(promptText)
@@ -282,14 +400,14 @@ func (a *CopilotAdapter) createLLMRequestEvent(
 		Type:            types.EventTypeLLMRequest,
 		AgentID:         a.name,
 		AgentVersion:    "1.0.0",
-		SessionID:       a.sessionID,
+		SessionID:       state.sessionID,
 		ProjectID:       a.projectIDInt,
 		LegacyProjectID: a.projectID, // Keep for backward compatibility
 		Context: map[string]interface{}{
 			"username":       session.RequesterUsername,
 			"location":       session.InitialLocation,
 			"variablesCount": len(request.VariableData.Variables),
-			"workspaceId":    a.workspaceID,
+			"workspaceId":    state.workspaceID,
 			"workspacePath":  session.InitialLocation,
 		},
 		Data: map[string]interface{}{
@@ -304,12 +422,33 @@ func (a *CopilotAdapter) createLLMRequestEvent(
 	}
 
 	// Add hierarchy context if available
-	if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-		event.ProjectID = hierarchyCtx.ProjectID
-		event.MachineID = hierarchyCtx.MachineID
-		event.WorkspaceID = hierarchyCtx.WorkspaceID
-		event.Context["projectName"] = hierarchyCtx.ProjectName
-		event.Context["machineName"] = hierarchyCtx.MachineName
+	if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+		event.ProjectID = state.hierarchyCtx.ProjectID
+		event.MachineID = state.hierarchyCtx.MachineID
+		event.WorkspaceID = state.hierarchyCtx.WorkspaceID
+		event.Context["projectName"] = state.hierarchyCtx.ProjectName
+		event.Context["machineName"] = state.hierarchyCtx.MachineName
+	}
+
+	return event
+}
+
+// createLLMCanceledEvent creates an event for a request the user canceled,
+// with the prompt and whatever part of the response had arrived
+func (a *CopilotAdapter) createLLMCanceledEvent(
+	state *copilotFileState,
+	session *CopilotChatSession,
+	request *CopilotRequest,
+) *types.AgentEvent {
+	timestamp := parseTimestamp(request.Timestamp)
+
+	event := a.createLLMRequestEvent(state, session, request, timestamp)
+	event.Type = types.EventTypeLLMCanceled
+
+	_, responseText, _ := a.extractToolAndResponseEvents(state, request, timestamp)
+	if responseText != "" {
+		event.Data["response"] = responseText
+		event.Data["responseLength"] = len(responseText)
 	}
 
 	return event
@@ -317,10 +456,10 @@ func (a *CopilotAdapter) createLLMRequestEvent(
 
 // createLLMResponseEvent creates an event for the agent's response
 func (a *CopilotAdapter) createLLMResponseEvent(
+	state *copilotFileState,
 	request *CopilotRequest,
 	responseText string,
 	timestamp time.Time,
-	hierarchyCtx *hierarchy.WorkspaceContext,
 ) *types.AgentEvent {
 	responseLength := len(responseText)
 
@@ -330,7 +469,7 @@ func (a *CopilotAdapter) createLLMResponseEvent(
 		Type:            types.EventTypeLLMResponse,
 		AgentID:         a.name,
 		AgentVersion:    "1.0.0",
-		SessionID:       a.sessionID,
+		SessionID:       state.sessionID,
 		ProjectID:       a.projectIDInt,
 		LegacyProjectID: a.projectID,
 		Data: map[string]interface{}{
@@ -345,10 +484,10 @@ func (a *CopilotAdapter) createLLMResponseEvent(
 	}
 
 	// Add hierarchy context if available
-	if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-		event.ProjectID = hierarchyCtx.ProjectID
-		event.MachineID = hierarchyCtx.MachineID
-		event.WorkspaceID = hierarchyCtx.WorkspaceID
+	if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+		event.ProjectID = state.hierarchyCtx.ProjectID
+		event.MachineID = state.hierarchyCtx.MachineID
+		event.WorkspaceID = state.hierarchyCtx.WorkspaceID
 	}
 
 	return event
@@ -356,13 +495,14 @@ func (a *CopilotAdapter) createLLMResponseEvent(
 
 // createFileReferenceEvent creates an event for a file reference from variables
 func (a *CopilotAdapter) createFileReferenceEvent(
+	state *copilotFileState,
 	request *CopilotRequest,
 	variable *CopilotVariable,
 	timestamp time.Time,
-	hierarchyCtx *hierarchy.WorkspaceContext,
 ) *types.AgentEvent {
 	// Extract file path from variable value
-	filePath := extractFilePath(variable.Value)
+	uri, _ := variable.Value.(map[string]interface{})
+	filePath := extractFilePath(uri)
 	if filePath == "" {
 		return nil
 	}
@@ -373,52 +513,205 @@ func (a *CopilotAdapter) createFileReferenceEvent(
 		Type:            types.EventTypeFileRead,
 		AgentID:         a.name,
 		AgentVersion:    "1.0.0",
-		SessionID:       a.sessionID,
+		SessionID:       state.sessionID,
 		ProjectID:       a.projectIDInt,
 		LegacyProjectID: a.projectID,
 		Data: map[string]interface{}{
 			"requestId":    request.RequestID,
-			"filePath":     filePath,
 			"variableId":   variable.ID,
 			"variableName": variable.Name,
 			"kind":         variable.Kind,
 			"automatic":    variable.AutoAdded,
 		},
 	}
+	setFilePath(event.Data, filePath, state.workspaceRoot)
+
+	// Add hierarchy context if available
+	if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+		event.ProjectID = state.hierarchyCtx.ProjectID
+		event.MachineID = state.hierarchyCtx.MachineID
+		event.WorkspaceID = state.hierarchyCtx.WorkspaceID
+	}
+
+	return event
+}
+
+// copilotContextSnippetChars caps the variable value kept on a context event
+const copilotContextSnippetChars = 500
+
+// createContextEvent creates a context event for a selection, symbol or
+// terminal variable, keeping its kind, location and a snippet of its value.
+// It returns nil for other variables.
+func (a *CopilotAdapter) createContextEvent(
+	state *copilotFileState,
+	request *CopilotRequest,
+	variable *CopilotVariable,
+	timestamp time.Time,
+) *types.AgentEvent {
+	kind := copilotContextKind(variable)
+	if kind == "" {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"requestId":    request.RequestID,
+		"variableId":   variable.ID,
+		"variableName": variable.Name,
+		"variableKind": variable.Kind,
+		"kind":         kind,
+		"automatic":    variable.AutoAdded,
+	}
+	value, _ := variable.Value.(map[string]interface{})
+	location := value
+	if loc, ok := value["location"].(map[string]interface{}); ok {
+		location = loc
+	}
+	if uri, ok := location["uri"].(map[string]interface{}); ok {
+		if filePath := extractFilePath(uri); filePath != "" {
+			setFilePath(data, filePath, state.workspaceRoot)
+		}
+	}
+	if r, ok := location["range"].(map[string]interface{}); ok {
+		if line, ok := r["startLineNumber"].(float64); ok {
+			data["startLine"] = int(line)
+		}
+		if line, ok := r["endLineNumber"].(float64); ok {
+			data["endLine"] = int(line)
+		}
+	}
+	if snippet := copilotVariableText(variable.Value); snippet != "" {
+		data["snippet"] = snippet
+		truncateField(data, "snippet", copilotContextSnippetChars)
+	}
+
+	event := &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       timestamp,
+		Type:            types.EventTypeContext,
+		AgentID:         a.name,
+		AgentVersion:    "1.0.0",
+		SessionID:       state.sessionID,
+		ProjectID:       a.projectIDInt,
+		LegacyProjectID: a.projectID,
+		Data:            data,
+	}
 
 	// Add hierarchy context if available
-	if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-		event.ProjectID = hierarchyCtx.ProjectID
-		event.MachineID = hierarchyCtx.MachineID
-		event.WorkspaceID = hierarchyCtx.WorkspaceID
+	if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+		event.ProjectID = state.hierarchyCtx.ProjectID
+		event.MachineID = state.hierarchyCtx.MachineID
+		event.WorkspaceID = state.hierarchyCtx.WorkspaceID
 	}
 
 	return event
 }
 
-// extractToolAndResponseEvents extracts tool invocation events and concatenates response text
+// copilotContextKind classifies a variable as "selection", "symbol" or
+// "terminal" from its kind and ID (e.g. "vscode.implicit.selection",
+// "copilot.terminalSelection"), or returns "" for other variables
+func copilotContextKind(variable *CopilotVariable) string {
+	id := strings.ToLower(variable.ID)
+	switch {
+	case variable.Kind == "symbol":
+		return "symbol"
+	case strings.HasPrefix(variable.Kind, "terminal") || strings.Contains(id, "terminal"):
+		return "terminal"
+	case strings.Contains(id, "selection"):
+		return "selection"
+	}
+	return ""
+}
+
+// copilotVariableText returns the text a variable carries: the value itself
+// when it is a string, or its text, value, command or output field
+func copilotVariableText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, key := range []string{"text", "value", "command", "output"} {
+			if text, ok := v[key].(string); ok && text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// copilotIgnoredKinds are response item kinds that carry nothing worth
+// recording and are not reported as unhandled
+var copilotIgnoredKinds = map[string]bool{
+	"undoStop":              true,
+	"prepareToolInvocation": true,
+}
+
+// extractToolAndResponseEvents extracts tool invocation events, concatenates
+// response text and collects annotations for the response event: inline
+// references, commands, confirmations, progress messages, and a count of
+// item kinds the adapter doesn't know
 func (a *CopilotAdapter) extractToolAndResponseEvents(
+	state *copilotFileState,
 	request *CopilotRequest,
 	timestamp time.Time,
-	hierarchyCtx *hierarchy.WorkspaceContext,
-) ([]*types.AgentEvent, string) {
+) ([]*types.AgentEvent, string, map[string]interface{}) {
 	var events []*types.AgentEvent
 	var responseTextParts []string
+	var references, commands, confirmations, progress []string
+	unhandled := make(map[string]int)
 	timeOffset := time.Duration(0)
 
 	for _, item := range request.Response {
+		kind := ""
+		if item.Kind != nil {
+			kind = *item.Kind
+		}
+
 		// Handle different response item kinds
-		if item.Kind == nil {
+		switch {
+		case item.Kind == nil || kind == "markdownContent":
 			// Plain text response - extract value flexibly
-			if valueText := extractValueAsString(item.Value); valueText != "" {
+			valueText := extractValueAsString(item.Value)
+			if valueText == "" && item.Content != nil {
+				valueText = extractValueAsString(item.Content.Value)
+			}
+			if valueText != "" {
 				responseTextParts = append(responseTextParts, valueText)
 			}
-		} else if *item.Kind == "toolInvocationSerialized" {
+		case kind == "inlineReference":
+			// A file or symbol link rendered inside the markdown
+			path := canonicalPath(inlineReferencePath(item.InlineReference))
+			if path != "" {
+				references = append(references, path)
+			}
+			if name := item.Name; name != "" {
+				responseTextParts = append(responseTextParts, name)
+			} else if path != "" {
+				responseTextParts = append(responseTextParts, filepath.Base(path))
+			}
+		case kind == "command":
+			if item.Command != nil {
+				title := item.Command.Title
+				if title == "" {
+					title = item.Command.ID
+				}
+				commands = append(commands, title)
+			}
+		case kind == "confirmation":
+			if item.Title != "" {
+				confirmations = append(confirmations, item.Title)
+			}
+		case kind == "progressMessage":
+			if item.Content != nil {
+				if text := extractValueAsString(item.Content.Value); text != "" {
+					progress = append(progress, text)
+				}
+			}
+		case kind == "toolInvocationSerialized":
 			// Tool invocation
 			timeOffset += 100 * time.Millisecond
-			event := a.createToolInvocationEvent(request, &item, timestamp.Add(timeOffset), hierarchyCtx)
+			event := a.createToolInvocationEvent(state, request, &item, timestamp.Add(timeOffset))
 			events = append(events, event)
-		} else if *item.Kind == "codeblockUri" {
+		case kind == "codeblockUri":
 			// File reference from codeblock
 			filePath := extractFilePath(item.URI)
 			if filePath != "" {
@@ -429,60 +722,96 @@ func (a *CopilotAdapter) extractToolAndResponseEvents(
 					Type:            types.EventTypeFileRead,
 					AgentID:         a.name,
 					AgentVersion:    "1.0.0",
-					SessionID:       a.sessionID,
+					SessionID:       state.sessionID,
 					ProjectID:       a.projectIDInt,
 					LegacyProjectID: a.projectID,
 					Data: map[string]interface{}{
 						"requestId": request.RequestID,
-						"filePath":  filePath,
 						"source":    "codeblock",
 					},
 				}
+				setFilePath(event.Data, filePath, state.workspaceRoot)
 				// Add hierarchy context if available
-				if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-					event.ProjectID = hierarchyCtx.ProjectID
-					event.MachineID = hierarchyCtx.MachineID
-					event.WorkspaceID = hierarchyCtx.WorkspaceID
+				if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+					event.ProjectID = state.hierarchyCtx.ProjectID
+					event.MachineID = state.hierarchyCtx.MachineID
+					event.WorkspaceID = state.hierarchyCtx.WorkspaceID
 				}
 				events = append(events, event)
 			}
-		} else if *item.Kind == "textEditGroup" {
+		case kind == "textEditGroup":
 			// File modifications
 			timeOffset += 100 * time.Millisecond
+			linesAdded, linesRemoved := textEditLineStats(item.Edits)
 			event := &types.AgentEvent{
 				ID:              uuid.New().String(),
 				Timestamp:       timestamp.Add(timeOffset),
 				Type:            types.EventTypeFileModify,
 				AgentID:         a.name,
 				AgentVersion:    "1.0.0",
-				SessionID:       a.sessionID,
+				SessionID:       state.sessionID,
 				ProjectID:       a.projectIDInt,
 				LegacyProjectID: a.projectID,
 				Data: map[string]interface{}{
-					"requestId": request.RequestID,
-					"editCount": len(item.Edits),
+					"requestId":    request.RequestID,
+					"editCount":    len(item.Edits),
+					"linesAdded":   linesAdded,
+					"linesRemoved": linesRemoved,
 				},
 			}
+			if filePath := extractFilePath(item.URI); filePath != "" {
+				setFilePath(event.Data, filePath, state.workspaceRoot)
+			}
 			// Add hierarchy context if available
-			if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-				event.ProjectID = hierarchyCtx.ProjectID
-				event.MachineID = hierarchyCtx.MachineID
-				event.WorkspaceID = hierarchyCtx.WorkspaceID
+			if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+				event.ProjectID = state.hierarchyCtx.ProjectID
+				event.MachineID = state.hierarchyCtx.MachineID
+				event.WorkspaceID = state.hierarchyCtx.WorkspaceID
 			}
 			events = append(events, event)
+		case copilotIgnoredKinds[kind]:
+		default:
+			// Unknown kinds are counted so new formats show up in the data
+			unhandled[kind]++
 		}
 	}
 
+	annotations := make(map[string]interface{})
+	if len(references) > 0 {
+		annotations["inlineReferences"] = references
+	}
+	if len(commands) > 0 {
+		annotations["commands"] = commands
+	}
+	if len(confirmations) > 0 {
+		annotations["confirmations"] = confirmations
+	}
+	if len(progress) > 0 {
+		annotations["progressMessages"] = progress
+	}
+	if len(unhandled) > 0 {
+		annotations["unhandledKinds"] = unhandled
+	}
+
 	responseText := strings.Join(responseTextParts, "")
-	return events, responseText
+	return events, responseText, annotations
+}
+
+// inlineReferencePath returns the file path of an inline reference, which
+// is either a URI or a location wrapping one
+func inlineReferencePath(ref map[string]interface{}) string {
+	if uri, ok := ref["uri"].(map[string]interface{}); ok {
+		return extractFilePath(uri)
+	}
+	return extractFilePath(ref)
 }
 
 // createToolInvocationEvent creates an event for a tool invocation
 func (a *CopilotAdapter) createToolInvocationEvent(
+	state *copilotFileState,
 	request *CopilotRequest,
 	item *CopilotResponseItem,
 	timestamp time.Time,
-	hierarchyCtx *hierarchy.WorkspaceContext,
 ) *types.AgentEvent {
 	data := map[string]interface{}{
 		"requestId":  request.RequestID,
@@ -506,23 +835,51 @@ func (a *CopilotAdapter) createToolInvocationEvent(
 		data["source"] = item.Source.Label
 	}
 
+	// The call succeeded if it completed without the error flag of its
+	// recorded output or, when there is none, an error in its result
+	// message. Sizes come from the recorded input and output, or from the
+	// messages when there are none.
+	var input, output interface{} = data["invocationMessage"], data["result"]
+	result, _ := data["result"].(string)
+	failed := reportsToolError(result)
+	var details CopilotToolResultDetails
+	if len(item.ResultDetails) > 0 {
+		if err := json.Unmarshal(item.ResultDetails, &details); err == nil {
+			input = details.Input
+			var out strings.Builder
+			for _, part := range details.Output {
+				out.WriteString(part.Value)
+			}
+			output = out.String()
+			failed = details.IsError
+		} else {
+			output = item.ResultDetails
+		}
+	}
+	success := item.IsComplete && !failed
+	toolName := item.ToolID
+	if toolName == "" {
+		toolName = item.ToolName
+	}
+	addToolOutcome(data, toolName, input, output, &success)
+
 	event := &types.AgentEvent{
 		ID:              uuid.New().String(),
 		Timestamp:       timestamp,
 		Type:            types.EventTypeToolUse,
 		AgentID:         a.name,
 		AgentVersion:    "1.0.0",
-		SessionID:       a.sessionID,
+		SessionID:       state.sessionID,
 		ProjectID:       a.projectIDInt,
 		LegacyProjectID: a.projectID,
 		Data:            data,
 	}
 
 	// Add hierarchy context if available
-	if hierarchyCtx != nil && hierarchyCtx.ProjectID > 0 {
-		event.ProjectID = hierarchyCtx.ProjectID
-		event.MachineID = hierarchyCtx.MachineID
-		event.WorkspaceID = hierarchyCtx.WorkspaceID
+	if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+		event.ProjectID = state.hierarchyCtx.ProjectID
+		event.MachineID = state.hierarchyCtx.MachineID
+		event.WorkspaceID = state.hierarchyCtx.WorkspaceID
 	}
 
 	return event
@@ -566,6 +923,11 @@ func extractFilePath(uri map[string]interface{}) string {
 		return fsPath
 	}
 
+	// Fall back to the encoded URI string
+	if external, ok := uri["external"].(string); ok {
+		return external
+	}
+
 	return ""
 }
 
@@ -605,6 +967,56 @@ func extractValueAsString(raw json.RawMessage) string {
 	return ""
 }
 
+// textEditLineStats computes lines added and removed by the edits of a
+// textEditGroup. VS Code serializes edits as batches (arrays) of
+// {text, range} objects; bare edit objects and missing fields are tolerated.
+func textEditLineStats(edits []interface{}) (added, removed int) {
+	for _, entry := range edits {
+		switch e := entry.(type) {
+		case []interface{}:
+			a, r := textEditLineStats(e)
+			added += a
+			removed += r
+		case map[string]interface{}:
+			a, r := textEditLines(e)
+			added += a
+			removed += r
+		}
+	}
+	return added, removed
+}
+
+// textEditLines returns the lines added and removed by a single text edit.
+// A range ending at column 1 stops before that line, so it isn't counted.
+func textEditLines(edit map[string]interface{}) (added, removed int) {
+	if text, ok := edit["text"].(string); ok && text != "" {
+		added = strings.Count(text, "\n")
+		if !strings.HasSuffix(text, "\n") {
+			added++
+		}
+	}
+
+	rng, ok := edit["range"].(map[string]interface{})
+	if !ok {
+		return added, 0
+	}
+
+	startLine, _ := rng["startLineNumber"].(float64)
+	startCol, _ := rng["startColumn"].(float64)
+	endLine, _ := rng["endLineNumber"].(float64)
+	endCol, _ := rng["endColumn"].(float64)
+
+	if endLine < startLine || (endLine == startLine && endCol <= startCol) {
+		return added, 0 // Empty range: a pure insertion
+	}
+
+	removed = int(endLine - startLine)
+	if endCol > 1 {
+		removed++
+	}
+	return added, removed
+}
+
 // estimateTokens estimates token count from text (rough approximation)
 func estimateTokens(text string) int {
 	// Simple heuristic: ~1.3 tokens per word
@@ -621,10 +1033,17 @@ func (a *CopilotAdapter) SupportsFormat(sample string) bool {
 	}
 
 	// Check for Copilot chat session structure
-	return session.Version > 0 && len(session.Requests) > 0
+	if session.Version > 0 && len(session.Requests) > 0 {
+		return true
+	}
+	return isEditingSessionSample(sample)
 }
 
-// SetSessionID updates the session ID (useful when starting a new session)
-func (a *CopilotAdapter) SetSessionID(sessionID string) {
-	a.sessionID = sessionID
+// Confidence rates accepted samples highly: a versioned document with a
+// requests array (or an editing session) is specific to Copilot
+func (a *CopilotAdapter) Confidence(sample string) float64 {
+	if a.SupportsFormat(sample) {
+		return 0.9
+	}
+	return 0
 }
@@ -2,8 +2,11 @@ package adapters
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -171,6 +174,30 @@ func TestCopilotAdapter_ParseTimestamp(t *testing.T) {
 	}
 }
 
+func TestCopilotAdapter_ParseTimestampEpochStrings(t *testing.T) {
+	want := time.Date(2024, 10, 31, 11, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input interface{}
+		want  time.Time
+	}{
+		{"Quoted milliseconds", "1730372400000", want},
+		{"Quoted seconds", "1730372400", want},
+		{"Quoted milliseconds with whitespace", " 1730372400000 ", want},
+		{"Numeric seconds", float64(1730372400), want},
+		{"Numeric milliseconds", float64(1730372400000), want},
+		{"RFC3339 string", "2024-10-31T11:00:00Z", want},
+		{"RFC3339 string with offset", "2024-10-31T12:00:00+01:00", want},
+		{"RFC3339Nano string", "2024-10-31T11:00:00.250Z", want.Add(250 * time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(parseTimestamp(tt.input)), "got %v", parseTimestamp(tt.input))
+		})
+	}
+}
+
 func TestCopilotAdapter_ExtractFilePath(t *testing.T) {
 	tests := []struct {
 		name string
@@ -191,6 +218,13 @@ func TestCopilotAdapter_ExtractFilePath(t *testing.T) {
 			},
 			want: "/workspace/test.ts",
 		},
+		{
+			name: "External field",
+			uri: map[string]interface{}{
+				"external": "file:///workspace/my%20test.ts",
+			},
+			want: "file:///workspace/my%20test.ts",
+		},
 		{
 			name: "Empty URI",
 			uri:  map[string]interface{}{},
@@ -314,6 +348,16 @@ func TestCopilotAdapter_ExtractSessionID(t *testing.T) {
 			filePath: "/path/to/session",
 			want:     "session",
 		},
+		{
+			name:     "Editing session state",
+			filePath: "/ws/abc123/chatEditingSessions/3b36cddd-95cf-446f-9888-5165fac29787/state.json",
+			want:     "3b36cddd-95cf-446f-9888-5165fac29787",
+		},
+		{
+			name:     "State file outside an editing session",
+			filePath: "/path/to/state.json",
+			want:     "state",
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,7 +370,7 @@ func TestCopilotAdapter_ExtractSessionID(t *testing.T) {
 
 func TestCopilotAdapter_CreateLLMRequestEvent(t *testing.T) {
 	adapter := NewCopilotAdapter("test-project", nil, nil)
-	adapter.sessionID = "test-session"
+	state := &copilotFileState{sessionID: "test-session"}
 
 	session := &CopilotChatSession{
 		RequesterUsername: "testuser",
@@ -345,7 +389,7 @@ func TestCopilotAdapter_CreateLLMRequestEvent(t *testing.T) {
 	}
 
 	timestamp := time.Now()
-	event := adapter.createLLMRequestEvent(session, request, timestamp, nil)
+	event := adapter.createLLMRequestEvent(state, session, request, timestamp)
 
 	assert.NotNil(t, event)
 	assert.Equal(t, types.EventTypeLLMRequest, event.Type)
@@ -370,6 +414,60 @@ func TestCopilotAdapter_CreateLLMRequestEvent(t *testing.T) {
 	assert.Greater(t, event.Metrics.PromptTokens, 0)
 }
 
+func TestCopilotAdapter_ConcurrentParse(t *testing.T) {
+	// One adapter parses sessions from several workspaces at once; each
+	// file's events must carry that file's session and workspace
+	tmpDir := t.TempDir()
+	var files []string
+	for i := 0; i < 8; i++ {
+		session := CopilotChatSession{
+			Version:           3,
+			RequesterUsername: "testuser",
+			Requests: []CopilotRequest{
+				{
+					RequestID: fmt.Sprintf("req_%d", i),
+					Timestamp: int64(1730372400000 + i*1000),
+					Message:   CopilotMessage{Text: fmt.Sprintf("Request %d", i)},
+					Response:  []CopilotResponseItem{{Value: json.RawMessage(`"Response"`)}},
+				},
+			},
+		}
+		dir := filepath.Join(tmpDir, "workspaceStorage", fmt.Sprintf("ws%d", i), "chatSessions")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		file := filepath.Join(dir, fmt.Sprintf("session-%d.json", i))
+		data, err := json.Marshal(session)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(file, data, 0644))
+		files = append(files, file)
+	}
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	results := make([][]*types.AgentEvent, len(files))
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for i, file := range files {
+			wg.Add(1)
+			go func(i int, file string) {
+				defer wg.Done()
+				results[i], errs[i] = adapter.ParseLogFile(file)
+			}(i, file)
+		}
+		wg.Wait()
+
+		for i := range files {
+			require.NoError(t, errs[i])
+			require.NotEmpty(t, results[i])
+			for _, event := range results[i] {
+				assert.Equal(t, fmt.Sprintf("session-%d", i), event.SessionID)
+				if event.Type == types.EventTypeLLMRequest {
+					assert.Equal(t, fmt.Sprintf("ws%d", i), event.Context["workspaceId"])
+				}
+			}
+		}
+	}
+}
+
 func TestCopilotAdapter_SkipCanceledRequests(t *testing.T) {
 	testSession := CopilotChatSession{
 		Version:           3,
@@ -411,6 +509,67 @@ func TestCopilotAdapter_SkipCanceledRequests(t *testing.T) {
 	}
 }
 
+func TestCopilotAdapter_CanceledTurns(t *testing.T) {
+	testSession := CopilotChatSession{
+		Version:           3,
+		RequesterUsername: "testuser",
+		Requests: []CopilotRequest{
+			{
+				RequestID:  "req_1",
+				Timestamp:  int64(1730372400000),
+				Message:    CopilotMessage{Text: "Rewrite the whole module"},
+				Response:   []CopilotResponseItem{{Value: json.RawMessage(`"Sure, starting with"`)}},
+				IsCanceled: true,
+			},
+			{
+				RequestID: "req_2",
+				Timestamp: int64(1730372401000),
+				Message:   CopilotMessage{Text: "Just the parser then"},
+				Response:  []CopilotResponseItem{{Value: json.RawMessage(`"Done."`)}},
+			},
+		},
+	}
+
+	testFile := filepath.Join(t.TempDir(), "test-canceled.json")
+	data, err := json.Marshal(testSession)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(testFile, data, 0644))
+
+	t.Run("enabled", func(t *testing.T) {
+		adapter := NewCopilotAdapter("test-project", nil, nil)
+		adapter.SetOptions(Options{CanceledTurns: true})
+
+		events, err := adapter.ParseLogFile(testFile)
+		require.NoError(t, err)
+
+		canceled := eventsOfType(events, types.EventTypeLLMCanceled)
+		require.Len(t, canceled, 1)
+		assert.Equal(t, "req_1", canceled[0].Data["requestId"])
+		assert.Equal(t, "Rewrite the whole module", canceled[0].Data["prompt"])
+		assert.Equal(t, "Sure, starting with", canceled[0].Data["response"])
+		assert.Equal(t, "testuser", canceled[0].Context["username"])
+
+		// The canceled turn produces no request or response of its own
+		for _, event := range events {
+			if event.Type != types.EventTypeLLMCanceled {
+				assert.Equal(t, "req_2", event.Data["requestId"], "unexpected %s event", event.Type)
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		adapter := NewCopilotAdapter("test-project", nil, nil)
+
+		events, err := adapter.ParseLogFile(testFile)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			assert.NotEqual(t, types.EventTypeLLMCanceled, event.Type)
+			assert.Equal(t, "req_2", event.Data["requestId"], "unexpected %s event", event.Type)
+		}
+	})
+}
+
 // Helper function
 func strPtr(s string) *string {
 	return &s
@@ -432,6 +591,11 @@ func TestExtractWorkspaceIDFromPath(t *testing.T) {
 			filePath: "C:/Users/username/AppData/Roaming/Code/User/workspaceStorage/xyz789/chatSessions/session.json",
 			want:     "xyz789",
 		},
+		{
+			name:     "Editing session path",
+			filePath: "/home/user/.config/Code/User/workspaceStorage/abc123def456/chatEditingSessions/session1/state.json",
+			want:     "abc123def456",
+		},
 		{
 			name:     "No workspaceStorage",
 			filePath: "/some/other/path/session.json",
@@ -554,3 +718,260 @@ func TestCopilotAdapter_RealFileWithArrayValue(t *testing.T) {
 	assert.Greater(t, eventTypes[types.EventTypeLLMRequest], 0, "Should have request events")
 	assert.Greater(t, eventTypes[types.EventTypeLLMResponse], 0, "Should have response events")
 }
+
+func TestCopilotAdapter_TextEditGroupDiffStats(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+
+	var modifications []*types.AgentEvent
+	for _, event := range events {
+		if event.Type == types.EventTypeFileModify {
+			modifications = append(modifications, event)
+		}
+	}
+	require.Len(t, modifications, 3)
+
+	// Batched edits: a 1-line replacement with 3 lines plus a 1-line insertion
+	assert.Equal(t, "/home/dev/project/main.go", modifications[0].Data["filePath"])
+	assert.Equal(t, 4, modifications[0].Data["linesAdded"])
+	assert.Equal(t, 1, modifications[0].Data["linesRemoved"])
+
+	// Missing range, in-line replacement and a pure deletion
+	assert.Equal(t, "/home/dev/project/util.go", modifications[1].Data["filePath"])
+	assert.Equal(t, 2, modifications[1].Data["linesAdded"])
+	assert.Equal(t, 4, modifications[1].Data["linesRemoved"])
+
+	// Malformed edits and no URI
+	assert.NotContains(t, modifications[2].Data, "filePath")
+	assert.Equal(t, 0, modifications[2].Data["linesAdded"])
+	assert.Equal(t, 0, modifications[2].Data["linesRemoved"])
+}
+
+func TestCopilotAdapter_ParseLogFileInRange(t *testing.T) {
+	// One request a day for four weeks
+	start := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	session := CopilotChatSession{Version: 3, RequesterUsername: "testuser"}
+	for day := 0; day < 28; day++ {
+		session.Requests = append(session.Requests, CopilotRequest{
+			RequestID: fmt.Sprintf("req_%d", day),
+			Timestamp: start.AddDate(0, 0, day).UnixMilli(),
+			Message:   CopilotMessage{Text: fmt.Sprintf("Day %d", day)},
+			Response:  []CopilotResponseItem{{Value: json.RawMessage(`"Done"`)}},
+		})
+	}
+
+	testFile := filepath.Join(t.TempDir(), "weeks.json")
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(testFile, data, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+
+	// Days 20-26 inclusive
+	from := start.AddDate(0, 0, 20).Add(-time.Hour)
+	to := start.AddDate(0, 0, 26).Add(time.Hour)
+	events, err := adapter.ParseLogFileInRange(testFile, from, to)
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	require.Len(t, requests, 7)
+	assert.Equal(t, "req_20", requests[0].Data["requestId"])
+	assert.Equal(t, "req_26", requests[6].Data["requestId"])
+	for _, event := range events {
+		assert.False(t, event.Timestamp.Before(from), "event before range: %s", event.Timestamp)
+		assert.False(t, event.Timestamp.After(to), "event after range: %s", event.Timestamp)
+	}
+
+	// Open-ended ranges
+	events, err = adapter.ParseLogFileInRange(testFile, from, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, eventsOfType(events, types.EventTypeLLMRequest), 8)
+
+	events, err = adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+	assert.Len(t, eventsOfType(events, types.EventTypeLLMRequest), 28)
+}
+
+func TestCopilotAdapter_ResponseItemKinds(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile("testdata/copilot-response-kinds.json")
+	require.NoError(t, err)
+
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	require.Len(t, responses, 1)
+	data := responses[0].Data
+
+	// Inline references are rendered into the text by name or file name
+	assert.Equal(t, "The server is started in main.go by run.", data["response"])
+	assert.Equal(t, []string{
+		"/home/dev/project/cmd/server/main.go",
+		"/home/dev/project/internal/app/app.go",
+	}, data["inlineReferences"])
+
+	assert.Equal(t, []string{"Start Debugging"}, data["commands"])
+	assert.Equal(t, []string{"Run the server?"}, data["confirmations"])
+	assert.Equal(t, []string{`Searching workspace for "ListenAndServe"`}, data["progressMessages"])
+
+	// Unknown kinds are counted; ignored kinds are not reported
+	assert.Equal(t, map[string]int{"thinking": 2, "mcpServersStarting": 1}, data["unhandledKinds"])
+}
+
+func TestCopilotAdapter_ResponseWithoutAnnotations(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	require.NotEmpty(t, responses)
+	for _, key := range []string{"inlineReferences", "commands", "confirmations", "progressMessages", "unhandledKinds"} {
+		assert.NotContains(t, responses[0].Data, key)
+	}
+}
+
+func TestCopilotAdapter_ToolInvocationOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       CopilotResponseItem
+		success    bool
+		category   string
+		inputSize  int
+		outputSize int
+	}{
+		{
+			name: "completed",
+			item: CopilotResponseItem{
+				ToolID:            "copilot_readFile",
+				InvocationMessage: json.RawMessage(`"Reading main.go"`),
+				PastTenseMessage:  json.RawMessage(`"Read main.go"`),
+				IsComplete:        true,
+			},
+			success:    true,
+			category:   ToolCategoryRead,
+			inputSize:  len("Reading main.go"),
+			outputSize: len("Read main.go"),
+		},
+		{
+			name: "error in result message",
+			item: CopilotResponseItem{
+				ToolID:           "run_in_terminal",
+				PastTenseMessage: json.RawMessage(`{"text":"Command failed with exit code 1"}`),
+				IsComplete:       true,
+			},
+			success:    false,
+			category:   ToolCategoryTerminal,
+			outputSize: len("Command failed with exit code 1"),
+		},
+		{
+			name: "not completed",
+			item: CopilotResponseItem{
+				ToolID:           "copilot_replaceString",
+				PastTenseMessage: json.RawMessage(`"Edited main.go"`),
+			},
+			success:    false,
+			category:   ToolCategoryEdit,
+			outputSize: len("Edited main.go"),
+		},
+		{
+			name: "MCP tool with result details",
+			item: CopilotResponseItem{
+				ToolID:           "mcp_github_search_issues",
+				PastTenseMessage: json.RawMessage(`"Ran search_issues"`),
+				IsComplete:       true,
+				ResultDetails:    json.RawMessage(`{"input":"{\"q\":\"bug\"}","output":[{"isText":true,"value":"[{\"number\":1}]"}]}`),
+			},
+			success:    true,
+			category:   ToolCategorySearch,
+			inputSize:  len(`{"q":"bug"}`),
+			outputSize: len(`[{"number":1}]`),
+		},
+		{
+			name: "MCP tool reporting an error",
+			item: CopilotResponseItem{
+				ToolID:           "mcp_github_get_issue",
+				PastTenseMessage: json.RawMessage(`"Ran get_issue"`),
+				IsComplete:       true,
+				ResultDetails:    json.RawMessage(`{"input":"{}","output":[{"isText":true,"value":"Not Found"}],"isError":true}`),
+			},
+			success:    false,
+			category:   ToolCategoryRead,
+			inputSize:  2,
+			outputSize: len("Not Found"),
+		},
+	}
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	state := &copilotFileState{sessionID: "session_1"}
+	request := &CopilotRequest{RequestID: "req_1"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := adapter.createToolInvocationEvent(state, request, &tt.item, time.Now())
+
+			assert.Equal(t, tt.success, event.Data["success"])
+			assert.Equal(t, tt.category, event.Data["toolCategory"])
+			assert.Equal(t, tt.inputSize, event.Data["inputSize"])
+			assert.Equal(t, tt.outputSize, event.Data["outputSize"])
+		})
+	}
+}
+
+func TestCopilotAdapter_ContextVariables(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "copilot-context-variables.json"))
+	require.NoError(t, err)
+
+	// File variables stay file reads
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	require.Len(t, reads, 1)
+	assert.Equal(t, "/workspace/internal/buffer/buffer.go", reads[0].Data["filePath"])
+
+	contexts := eventsOfType(events, types.EventTypeContext)
+	require.Len(t, contexts, 3, "the workspace variable carries no editor context")
+
+	selection := contexts[0]
+	assert.Equal(t, "selection", selection.Data["kind"])
+	assert.Equal(t, "vscode.implicit.selection", selection.Data["variableId"])
+	assert.Equal(t, "/workspace/internal/buffer/buffer_test.go", selection.Data["filePath"])
+	assert.Equal(t, 42, selection.Data["startLine"])
+	assert.Equal(t, 57, selection.Data["endLine"])
+	assert.Equal(t, true, selection.Data["automatic"])
+	assert.Equal(t, "req_context", selection.Data["requestId"])
+
+	terminal := contexts[1]
+	assert.Equal(t, "terminal", terminal.Data["kind"])
+	assert.Equal(t, "terminalSelection", terminal.Data["variableName"])
+	assert.Contains(t, terminal.Data["snippet"], "--- FAIL: TestBuffer_Retrieve")
+	assert.Nil(t, terminal.Data["filePath"])
+
+	symbol := contexts[2]
+	assert.Equal(t, "symbol", symbol.Data["kind"])
+	assert.Equal(t, "/workspace/internal/buffer/buffer.go", symbol.Data["filePath"])
+	assert.Equal(t, 251, symbol.Data["startLine"])
+
+	for _, event := range contexts {
+		assert.Equal(t, "github-copilot", event.AgentID)
+		assert.Equal(t, reads[0].SessionID, event.SessionID)
+	}
+}
+
+func TestCopilotAdapter_ContextSnippetIsTruncated(t *testing.T) {
+	output := strings.Repeat("x", copilotContextSnippetChars+100)
+	variable := &CopilotVariable{
+		ID:    "copilot.terminalLastCommand",
+		Name:  "terminalLastCommand",
+		Value: map[string]interface{}{"command": "go test ./...", "output": output},
+	}
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	event := adapter.createContextEvent(&copilotFileState{sessionID: "s1"}, &CopilotRequest{RequestID: "r1"}, variable, time.Now())
+	require.NotNil(t, event)
+	assert.Equal(t, "terminal", event.Data["kind"])
+	assert.Equal(t, "go test ./...", event.Data["snippet"])
+
+	variable.Value = output
+	event = adapter.createContextEvent(&copilotFileState{sessionID: "s1"}, &CopilotRequest{RequestID: "r1"}, variable, time.Now())
+	require.NotNil(t, event)
+	assert.Equal(t, true, event.Data["snippetTruncated"])
+	assert.Equal(t, len(output), event.Data["snippetLength"])
+}
@@ -0,0 +1,246 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// copilotEditingStateFile is the file agent mode keeps for each editing
+// session: .../workspaceStorage/{workspace-id}/chatEditingSessions/{session-id}/state.json
+const copilotEditingStateFile = "state.json"
+
+// CopilotEditingSession is the state of a chat editing session, the files
+// agent mode changed for each request of the chat session with the same ID
+type CopilotEditingSession struct {
+	Version        int                     `json:"version"`
+	SessionID      string                  `json:"sessionId"`
+	LinearHistory  []CopilotEditingRequest `json:"linearHistory"`
+	RecentSnapshot *CopilotEditingStop     `json:"recentSnapshot,omitempty"`
+}
+
+// CopilotEditingRequest holds the edit stops of one chat request
+type CopilotEditingRequest struct {
+	RequestID string               `json:"requestId"`
+	Stops     []CopilotEditingStop `json:"stops"`
+}
+
+// CopilotEditingStop is a snapshot of the edited files at one point in a request
+type CopilotEditingStop struct {
+	StopID  string                `json:"stopId,omitempty"`
+	Entries []CopilotEditingEntry `json:"entries"`
+}
+
+// CopilotEditingEntry is one edited file in a snapshot
+type CopilotEditingEntry struct {
+	Resource      json.RawMessage           `json:"resource"` // URI string or URI object
+	LanguageID    string                    `json:"languageId,omitempty"`
+	OriginalHash  string                    `json:"originalHash"`
+	CurrentHash   string                    `json:"currentHash"`
+	State         int                       `json:"state"` // 0 pending, 1 accepted, 2 rejected
+	TelemetryInfo *CopilotEditTelemetryInfo `json:"telemetryInfo,omitempty"`
+}
+
+// CopilotEditTelemetryInfo names the request and model behind an edit
+type CopilotEditTelemetryInfo struct {
+	RequestID string `json:"requestId"`
+	AgentID   string `json:"agentId,omitempty"`
+	ModelID   string `json:"modelId,omitempty"`
+}
+
+// copilotEditStates names the values of CopilotEditingEntry.State
+var copilotEditStates = map[int]string{
+	0: "pending",
+	1: "accepted",
+	2: "rejected",
+}
+
+// isCopilotEditingSession reports whether filePath is the state file of a
+// chat editing session rather than a chat session
+func isCopilotEditingSession(filePath string) bool {
+	if !strings.EqualFold(trimLogExt(filepath.Base(filePath)), "state") {
+		return false
+	}
+	return filepath.Base(filepath.Dir(filepath.Dir(filePath))) == "chatEditingSessions"
+}
+
+// parseEditingSession emits a file_modify event for each file content agent
+// mode produced. The state file has no timestamps, so an edit is dated by
+// its content snapshot under contents/, or else by the request that made it
+// in the chat session of the same ID. The state file's own mtime changes
+// each time VS Code rewrites it and would move the edits with it.
+func (a *CopilotAdapter) parseEditingSession(state *copilotFileState, filePath string, from, to time.Time) ([]*types.AgentEvent, error) {
+	file, err := OpenLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read editing session file: %w", err)
+	}
+	defer file.Close()
+
+	var session CopilotEditingSession
+	if err := json.NewDecoder(file).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to parse editing session JSON: %w", err)
+	}
+
+	sessionID := session.SessionID
+	if sessionID == "" {
+		sessionID = state.sessionID
+	}
+	requestTimes, lastRequest := copilotRequestTimes(filePath, sessionID)
+	contentsDir := filepath.Join(filepath.Dir(filePath), "contents")
+
+	// Later stops repeat the entries of earlier ones, so each resulting file
+	// content is reported once
+	seen := make(map[string]bool)
+	var events []*types.AgentEvent
+	emit := func(requestID string, entry *CopilotEditingEntry) {
+		path := editingEntryPath(entry.Resource)
+		if path == "" || entry.CurrentHash == "" || entry.CurrentHash == entry.OriginalHash {
+			return
+		}
+		key := path + "\x00" + entry.CurrentHash
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		if entry.TelemetryInfo != nil && entry.TelemetryInfo.RequestID != "" {
+			requestID = entry.TelemetryInfo.RequestID
+		}
+
+		// An edit of the request in progress is dated by the session's
+		// last request
+		timestamp, ok := requestTimes[requestID]
+		if !ok {
+			timestamp = lastRequest
+		}
+		if info, err := os.Stat(filepath.Join(contentsDir, entry.CurrentHash)); err == nil {
+			timestamp = info.ModTime()
+		}
+		if !inRange(timestamp, from, to) {
+			return
+		}
+		event := &types.AgentEvent{
+			ID:              uuid.New().String(),
+			Timestamp:       timestamp,
+			Type:            types.EventTypeFileModify,
+			AgentID:         a.name,
+			AgentVersion:    "1.0.0",
+			SessionID:       state.sessionID,
+			ProjectID:       a.projectIDInt,
+			LegacyProjectID: a.projectID,
+			Context: map[string]interface{}{
+				"workspaceId": state.workspaceID,
+				"source":      "chatEditingSession",
+			},
+			Data: map[string]interface{}{
+				"requestId": requestID,
+				"editState": copilotEditStates[entry.State],
+			},
+		}
+		setFilePath(event.Data, path, state.workspaceRoot)
+		if entry.LanguageID != "" {
+			event.Data["languageId"] = entry.LanguageID
+		}
+		if entry.TelemetryInfo != nil && entry.TelemetryInfo.ModelID != "" {
+			event.Data["modelId"] = entry.TelemetryInfo.ModelID
+		}
+		if state.hierarchyCtx != nil && state.hierarchyCtx.ProjectID > 0 {
+			event.ProjectID = state.hierarchyCtx.ProjectID
+			event.MachineID = state.hierarchyCtx.MachineID
+			event.WorkspaceID = state.hierarchyCtx.WorkspaceID
+		}
+		events = append(events, event)
+	}
+
+	covered := make(map[string]bool)
+	for _, request := range session.LinearHistory {
+		covered[request.RequestID] = true
+		for _, stop := range request.Stops {
+			for i := range stop.Entries {
+				emit(request.RequestID, &stop.Entries[i])
+			}
+		}
+	}
+	// Edits of the request in progress are only in the recent snapshot. It
+	// also carries the files of finished requests, which the history above
+	// already reported under their own stops.
+	if session.RecentSnapshot != nil {
+		for i := range session.RecentSnapshot.Entries {
+			entry := &session.RecentSnapshot.Entries[i]
+			if entry.TelemetryInfo != nil && covered[entry.TelemetryInfo.RequestID] {
+				continue
+			}
+			emit("", entry)
+		}
+	}
+
+	return events, nil
+}
+
+// copilotRequestTimes returns when each request of a chat session was made,
+// and the time of its last request, reading the chat session file stored
+// next to the editing session: an editing session at
+// {workspace-id}/chatEditingSessions/{session-id}/state.json belongs to the
+// chat session at {workspace-id}/chatSessions/{session-id}.json. Without one
+// there are no times and the last request is the zero time.
+func copilotRequestTimes(statePath, sessionID string) (map[string]time.Time, time.Time) {
+	times := make(map[string]time.Time)
+	var last time.Time
+
+	sessionsDir := filepath.Join(filepath.Dir(filepath.Dir(filepath.Dir(statePath))), "chatSessions")
+	for _, name := range []string{sessionID + ".json", sessionID + ".json" + gzipSuffix} {
+		file, err := OpenLogFile(filepath.Join(sessionsDir, name))
+		if err != nil {
+			continue
+		}
+		var session CopilotChatSession
+		decodeChatSession(file, &session, func(request *CopilotRequest, _ int, _ int64) {
+			timestamp := parseTimestamp(request.Timestamp)
+			if request.RequestID != "" {
+				times[request.RequestID] = timestamp
+			}
+			if timestamp.After(last) {
+				last = timestamp
+			}
+		})
+		file.Close()
+		break
+	}
+	return times, last
+}
+
+// editingEntryPath returns the local path of an edited file, whose resource
+// is stored either as a URI string or as a serialized URI object
+func editingEntryPath(raw json.RawMessage) string {
+	var uri string
+	if err := json.Unmarshal(raw, &uri); err == nil {
+		if !strings.HasPrefix(uri, "file://") {
+			return ""
+		}
+		return uri
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		if scheme, ok := object["scheme"].(string); ok && scheme != "file" {
+			return ""
+		}
+		return extractFilePath(object)
+	}
+	return ""
+}
+
+// isEditingSessionSample reports whether sample is a chat editing session
+func isEditingSessionSample(sample string) bool {
+	var session CopilotEditingSession
+	if err := json.Unmarshal([]byte(sample), &session); err != nil {
+		return false
+	}
+	return session.SessionID != "" && (len(session.LinearHistory) > 0 || session.RecentSnapshot != nil)
+}
@@ -0,0 +1,147 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEditingSession copies the editing session fixture to the layout VS
+// Code uses and returns the state file's path
+func writeEditingSession(t *testing.T) string {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/copilot-editing-session.json")
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "workspaceStorage", "ws-edit", "chatEditingSessions", "b7e1c9a2-5d3f-4e8a-9c1b-2f6d8e4a7b30")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "contents"), 0755))
+	statePath := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(statePath, data, 0644))
+	return statePath
+}
+
+func TestCopilotAdapter_EditingSession(t *testing.T) {
+	statePath := writeEditingSession(t)
+
+	// The snapshot of b2 dates the first edit
+	edited := time.Date(2025, 10, 31, 12, 0, 0, 0, time.UTC)
+	snapshot := filepath.Join(filepath.Dir(statePath), "contents", "b2")
+	require.NoError(t, os.WriteFile(snapshot, []byte("package main\n"), 0644))
+	require.NoError(t, os.Chtimes(snapshot, edited, edited))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(statePath)
+	require.NoError(t, err)
+
+	// main.go changed twice; README.md is unchanged and untitled: isn't a file
+	modifies := eventsOfType(events, types.EventTypeFileModify)
+	require.Len(t, modifies, 2)
+	for _, event := range modifies {
+		assert.Equal(t, "b7e1c9a2-5d3f-4e8a-9c1b-2f6d8e4a7b30", event.SessionID)
+		assert.Equal(t, "ws-edit", event.Context["workspaceId"])
+		assert.Equal(t, "/home/dev/project/src/main.go", event.Data["filePath"])
+	}
+
+	first := modifies[0]
+	assert.Equal(t, "request_1", first.Data["requestId"])
+	assert.Equal(t, "accepted", first.Data["editState"])
+	assert.Equal(t, "go", first.Data["languageId"])
+	assert.Equal(t, "gpt-4.1", first.Data["modelId"])
+	assert.True(t, first.Timestamp.Equal(edited))
+
+	second := modifies[1]
+	assert.Equal(t, "request_2", second.Data["requestId"])
+	assert.Equal(t, "rejected", second.Data["editState"])
+}
+
+// writeEditingChatSession writes the chat session the fixture's editing
+// session belongs to, with its requests made at the given Unix milliseconds
+func writeEditingChatSession(t *testing.T, statePath string, request1, request2 int64) {
+	t.Helper()
+
+	sessionsDir := filepath.Join(filepath.Dir(filepath.Dir(filepath.Dir(statePath))), "chatSessions")
+	require.NoError(t, os.MkdirAll(sessionsDir, 0755))
+	session := fmt.Sprintf(`{"version":3,"requests":[`+
+		`{"requestId":"request_1","timestamp":%d,"message":{"text":"Refactor main"}},`+
+		`{"requestId":"request_2","timestamp":%d,"message":{"text":"Try again"}}]}`, request1, request2)
+	require.NoError(t, os.WriteFile(filepath.Join(sessionsDir, "b7e1c9a2-5d3f-4e8a-9c1b-2f6d8e4a7b30.json"), []byte(session), 0644))
+}
+
+func TestCopilotAdapter_EditingSessionDatedByRequests(t *testing.T) {
+	statePath := writeEditingSession(t)
+	writeEditingChatSession(t, statePath, 1761912000000, 1761912060000)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	first, err := adapter.ParseLogFile(statePath)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, int64(1761912000000), first[0].Timestamp.UnixMilli())
+	assert.Equal(t, int64(1761912060000), first[1].Timestamp.UnixMilli())
+
+	// VS Code rewrites the state file as the session goes on; the edits
+	// must keep their times
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(statePath, later, later))
+	second, err := adapter.ParseLogFile(statePath)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	for i := range first {
+		assert.True(t, first[i].Timestamp.Equal(second[i].Timestamp))
+	}
+}
+
+func TestCopilotAdapter_EditingSessionSkipsFinishedRequestsInSnapshot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "workspaceStorage", "ws-edit", "chatEditingSessions", "s-1")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	statePath := filepath.Join(dir, "state.json")
+	state := `{"version":2,"sessionId":"s-1","linearHistory":[{"requestId":"request_1","stops":[{"entries":[` +
+		`{"resource":"file:///home/dev/project/a.go","originalHash":"a1","currentHash":"b2","state":1}]}]}],` +
+		`"recentSnapshot":{"entries":[` +
+		`{"resource":"file:///home/dev/project/a.go","originalHash":"a1","currentHash":"c3","state":1,"telemetryInfo":{"requestId":"request_1"}},` +
+		`{"resource":"file:///home/dev/project/b.go","originalHash":"d4","currentHash":"e5","state":0,"telemetryInfo":{"requestId":"request_2"}}]}}`
+	require.NoError(t, os.WriteFile(statePath, []byte(state), 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(statePath)
+	require.NoError(t, err)
+
+	// request_1 is reported from its stops only
+	require.Len(t, events, 2)
+	assert.Equal(t, "request_1", events[0].Data["requestId"])
+	assert.Equal(t, "/home/dev/project/a.go", events[0].Data["filePath"])
+	assert.Equal(t, "request_2", events[1].Data["requestId"])
+	assert.Equal(t, "/home/dev/project/b.go", events[1].Data["filePath"])
+}
+
+func TestCopilotAdapter_EditingSessionInRange(t *testing.T) {
+	statePath := writeEditingSession(t)
+
+	// Without snapshots, edits are dated by their requests
+	old := time.Now().Add(-48 * time.Hour)
+	writeEditingChatSession(t, statePath, old.UnixMilli(), old.Add(time.Minute).UnixMilli())
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFileInRange(statePath, time.Now().Add(-24*time.Hour), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	events, err = adapter.ParseLogFileInRange(statePath, old.Add(-time.Hour), time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestCopilotAdapter_SupportsEditingSessionFormat(t *testing.T) {
+	data, err := os.ReadFile("testdata/copilot-editing-session.json")
+	require.NoError(t, err)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	assert.True(t, adapter.SupportsFormat(string(data)))
+	assert.True(t, ParsesWholeFile(adapter, "/ws/chatEditingSessions/abc/state.json"))
+}
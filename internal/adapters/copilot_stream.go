@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// decodeChatSession streams a Copilot chat session document from r. Each
+// element of the requests array is decoded on its own and passed to
+// onRequest with the byte offset it starts at, so only one request is held
+// in memory at a time no matter how long the session is. The remaining top-level fields are decoded into
+// session once the whole document has been read; session.Requests is left
+// empty.
+func decodeChatSession(r io.Reader, session *CopilotChatSession, onRequest func(request *CopilotRequest, index int, offset int64)) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	// Header fields are few and small; collect them and let encoding/json
+	// apply its usual field matching once the document is complete
+	header := make(map[string]json.RawMessage)
+	index := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v in chat session", tok)
+		}
+
+		if !strings.EqualFold(key, "requests") {
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return err
+			}
+			header[key] = value
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			continue // "requests": null
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("expected requests to be an array, got %v", tok)
+		}
+		for dec.More() {
+			// Past the previous request, before the comma separating it
+			offset := dec.InputOffset()
+			var request CopilotRequest
+			if err := dec.Decode(&request); err != nil {
+				return err
+			}
+			onRequest(&request, index, offset)
+			index++
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, reject anything after the session object
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid data after top-level value")
+		}
+		return err
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, session)
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %q in chat session, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeChatSession builds a session with n requests, each carrying a
+// response of roughly responseBytes
+func largeChatSession(n, responseBytes int) CopilotChatSession {
+	response, _ := json.Marshal(strings.Repeat("x", responseBytes))
+	session := CopilotChatSession{
+		Version:           3,
+		RequesterUsername: "testuser",
+		ResponderUsername: "GitHub Copilot",
+		InitialLocation:   "panel",
+	}
+	for i := 0; i < n; i++ {
+		session.Requests = append(session.Requests, CopilotRequest{
+			RequestID: fmt.Sprintf("req_%d", i),
+			Timestamp: int64(1730372400000 + i*1000),
+			ModelID:   "copilot/gpt-4o",
+			Message:   CopilotMessage{Text: fmt.Sprintf("Request %d", i)},
+			Response: []CopilotResponseItem{
+				{Value: json.RawMessage(response)},
+			},
+			IsCanceled: i%10 == 9,
+		})
+	}
+	return session
+}
+
+// countingReader records how many bytes have been read from r
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestDecodeChatSession_StreamsRequests(t *testing.T) {
+	session := largeChatSession(500, 4096)
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+
+	reader := &countingReader{r: bytes.NewReader(data)}
+	var decoded CopilotChatSession
+	var readAtFirst, count int
+	err = decodeChatSession(reader, &decoded, func(request *CopilotRequest, index int, offset int64) {
+		if index == 0 {
+			readAtFirst = reader.n
+		}
+		assert.Equal(t, fmt.Sprintf("req_%d", index), request.RequestID)
+		count++
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 500, count)
+	assert.Equal(t, len(data), reader.n)
+	// The first request is handed over long before the rest of the file is read
+	assert.Less(t, readAtFirst, len(data)/50, "decoder should not buffer the whole session")
+
+	assert.Equal(t, "testuser", decoded.RequesterUsername)
+	assert.Equal(t, "panel", decoded.InitialLocation)
+	assert.Empty(t, decoded.Requests)
+}
+
+func TestDecodeChatSession_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not an object", `[]`},
+		{"requests not an array", `{"requests": {}}`},
+		{"truncated", `{"version": 3, "requests": [{"requestId": "a"}`},
+		{"trailing data", `{"version": 3}{"version": 3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var session CopilotChatSession
+			err := decodeChatSession(strings.NewReader(tt.input), &session, func(*CopilotRequest, int, int64) {})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCopilotAdapter_ParseLargeSession(t *testing.T) {
+	session := largeChatSession(500, 4096)
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+
+	testFile := filepath.Join(t.TempDir(), "large-session.json")
+	require.NoError(t, os.WriteFile(testFile, data, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	// Every tenth request is canceled
+	assert.Len(t, eventsOfType(events, types.EventTypeLLMRequest), 450)
+	assert.Len(t, eventsOfType(events, types.EventTypeLLMResponse), 450)
+}
+
+func TestCopilotAdapter_SessionFieldsAfterRequests(t *testing.T) {
+	// VS Code doesn't guarantee key order; the session header may come last
+	input := `{
+		"requests": [{
+			"requestId": "req_1",
+			"timestamp": 1730372400000,
+			"message": {"text": "Hello"},
+			"response": [{"value": "Hi"}]
+		}],
+		"version": 3,
+		"requesterUsername": "testuser",
+		"initialLocation": "panel"
+	}`
+
+	testFile := filepath.Join(t.TempDir(), "header-last.json")
+	require.NoError(t, os.WriteFile(testFile, []byte(input), 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "testuser", requests[0].Context["username"])
+	assert.Equal(t, "panel", requests[0].Context["location"])
+	assert.Equal(t, "panel", requests[0].Context["workspacePath"])
+}
+
+func BenchmarkCopilotAdapter_ParseLargeSession(b *testing.B) {
+	session := largeChatSession(2000, 8192)
+	data, err := json.Marshal(session)
+	require.NoError(b, err)
+
+	testFile := filepath.Join(b.TempDir(), "large-session.json")
+	require.NoError(b, os.WriteFile(testFile, data, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := adapter.ParseLogFile(testFile); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
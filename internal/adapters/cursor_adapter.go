@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -28,7 +27,7 @@ func NewCursorAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache
 		log = logrus.New()
 	}
 	return &CursorAdapter{
-		BaseAdapter: NewBaseAdapter("cursor", projectID),
+		BaseAdapter: NewBaseAdapter("cursor", projectID, log),
 		hierarchy:   hierarchyCache,
 		log:         log,
 	}
@@ -51,6 +50,8 @@ type CursorLogEntry struct {
 	CompletionTokens int               `json:"completion_tokens,omitempty"`
 	Tool        string                 `json:"tool,omitempty"`
 	ToolArgs    interface{}            `json:"tool_args,omitempty"`
+	ToolOutput  interface{}            `json:"tool_output,omitempty"`
+	Error       interface{}            `json:"error,omitempty"`
 	File        string                 `json:"file,omitempty"`
 	Operation   string                 `json:"operation,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
@@ -94,25 +95,14 @@ func (a *CursorAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
 
 // ParseLogFile parses a Cursor log file
 func (a *CursorAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
-	file, err := os.Open(filePath)
+	file, err := OpenLogFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer file.Close()
 
 	// Try to resolve hierarchy context
-	var hierarchyCtx *hierarchy.WorkspaceContext
-	workspaceID := extractWorkspaceIDFromPath(filePath)
-	if workspaceID != "" && a.hierarchy != nil {
-		ctx, err := a.hierarchy.Resolve(workspaceID)
-		if err != nil {
-			a.log.Warnf("Failed to resolve workspace %s: %v - continuing without hierarchy", workspaceID, err)
-		} else {
-			hierarchyCtx = ctx
-			a.log.Debugf("Resolved hierarchy for workspace %s: project=%d, machine=%d",
-				workspaceID, ctx.ProjectID, ctx.MachineID)
-		}
-	}
+	hierarchyCtx := resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
 
 	var events []*types.AgentEvent
 	scanner := bufio.NewScanner(file)
@@ -135,11 +125,7 @@ func (a *CursorAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, erro
 		if event != nil {
 			// Add hierarchy context if available
 			if hierarchyCtx != nil {
-				event.ProjectID = hierarchyCtx.ProjectID
-				event.MachineID = hierarchyCtx.MachineID
-				event.WorkspaceID = hierarchyCtx.WorkspaceID
-				event.Context["projectName"] = hierarchyCtx.ProjectName
-				event.Context["machineName"] = hierarchyCtx.MachineName
+				setHierarchy(event, hierarchyCtx)
 			}
 			events = append(events, event)
 		}
@@ -149,7 +135,15 @@ func (a *CursorAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, erro
 		return nil, fmt.Errorf("error reading log file: %w", err)
 	}
 
-	return events, nil
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// logHierarchy returns the workspace context events parsed from the lines of
+// filePath are attached to
+func (a *CursorAdapter) logHierarchy(filePath string) *hierarchy.WorkspaceContext {
+	return a.cachedLineHierarchy(filePath, func() *hierarchy.WorkspaceContext {
+		return resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
+	})
 }
 
 // parsePlainTextLine attempts to parse plain text log lines
@@ -312,6 +306,13 @@ func (a *CursorAdapter) extractData(entry *CursorLogEntry, eventType string) map
 		if entry.ToolArgs != nil {
 			data["toolArgs"] = entry.ToolArgs
 		}
+		if entry.ToolOutput != nil {
+			data["toolOutput"] = entry.ToolOutput
+		}
+		if entry.Error != nil {
+			data["error"] = entry.Error
+		}
+		addToolOutcome(data, entry.Tool, entry.ToolArgs, entry.ToolOutput, toolSucceeded(entry.ToolOutput, entry.Error))
 	case types.EventTypeFileRead, types.EventTypeFileWrite:
 		if entry.File != "" {
 			data["filePath"] = entry.File
@@ -354,3 +355,23 @@ func (a *CursorAdapter) SupportsFormat(sample string) bool {
 	return strings.Contains(lower, "cursor") && 
 		(strings.Contains(lower, "ai") || strings.Contains(lower, "completion"))
 }
+
+// Confidence scores a sample SupportsFormat accepts by the fields only
+// Cursor logs have, since Claude logs share conversation_id and model
+func (a *CursorAdapter) Confidence(sample string) float64 {
+	if !a.SupportsFormat(sample) {
+		return 0
+	}
+
+	keys := jsonKeys(sample)
+	if keys == nil {
+		// Plain text naming Cursor
+		return DefaultConfidence
+	}
+	score := fieldScore(keys, cursorOnlyFields, claudeOnlyFields)
+	var entry CursorLogEntry
+	if json.Unmarshal([]byte(sample), &entry) == nil && mentions(entry.Message, "cursor") {
+		score += 0.2
+	}
+	return clampConfidence(score)
+}
@@ -345,3 +345,21 @@ func TestCursorAdapter_GetSessionID(t *testing.T) {
 		})
 	}
 }
+
+func TestCursorAdapter_ToolOutcome(t *testing.T) {
+	adapter := NewCursorAdapter("test-project", nil, nil)
+
+	succeeded, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:00Z","type":"tool_use","session_id":"s1","tool":"run_terminal_cmd","tool_args":{"command":"go test"},"tool_output":"ok"}`)
+	require.NoError(t, err)
+	require.NotNil(t, succeeded)
+	assert.Equal(t, true, succeeded.Data["success"])
+	assert.Equal(t, ToolCategoryTerminal, succeeded.Data["toolCategory"])
+	assert.Equal(t, 2, succeeded.Data["outputSize"])
+
+	failed, err := adapter.ParseLogLine(`{"timestamp":"2025-10-31T10:00:00Z","type":"tool_use","session_id":"s1","tool":"run_terminal_cmd","tool_args":{"command":"go test"},"error":"exit status 1"}`)
+	require.NoError(t, err)
+	require.NotNil(t, failed)
+	assert.Equal(t, false, failed.Data["success"])
+	assert.Equal(t, "exit status 1", failed.Data["error"])
+	assert.Equal(t, len(`{"command":"go test"}`), failed.Data["inputSize"])
+}
@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ConfidenceScorer is implemented by adapters that can say how likely a log
+// sample is to be in their format, from 0 (not theirs) to 1 (certainly
+// theirs). Registry.DetectAdapter uses it to choose between adapters whose
+// formats overlap.
+type ConfidenceScorer interface {
+	Confidence(sample string) float64
+}
+
+// DefaultConfidence is the score of a sample accepted by the SupportsFormat
+// of an adapter that doesn't implement ConfidenceScorer
+const DefaultConfidence = 0.5
+
+// confidence scores sample for adapter
+func confidence(adapter AgentAdapter, sample string) float64 {
+	if scorer, ok := adapter.(ConfidenceScorer); ok {
+		return scorer.Confidence(sample)
+	}
+	if adapter.SupportsFormat(sample) {
+		return DefaultConfidence
+	}
+	return 0
+}
+
+// jsonKeys returns the top-level keys of a JSON object sample, or nil if it
+// isn't one
+func jsonKeys(sample string) map[string]bool {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(sample), &object); err != nil {
+		return nil
+	}
+	keys := make(map[string]bool, len(object))
+	for key := range object {
+		keys[key] = true
+	}
+	return keys
+}
+
+// fieldScore scores an accepted JSON sample by its keys: it starts at
+// DefaultConfidence and moves up for each field only this format has and
+// down for each field only a format it is confused with has
+func fieldScore(keys map[string]bool, own, others []string) float64 {
+	score := DefaultConfidence
+	for _, key := range own {
+		if keys[key] {
+			score += 0.15
+		}
+	}
+	for _, key := range others {
+		if keys[key] {
+			score -= 0.15
+		}
+	}
+	return score
+}
+
+// clampConfidence keeps the score of an accepted sample within (0, 1], so
+// an accepted sample never scores as a rejected one
+func clampConfidence(score float64) float64 {
+	return min(max(score, 0.05), 1)
+}
+
+// mentions reports whether s contains any of words, ignoring case
+func mentions(s string, words ...string) bool {
+	lower := strings.ToLower(s)
+	for _, word := range words {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields that tell the Claude and Cursor line formats apart; both have
+// conversation_id, model, prompt and response
+var (
+	claudeOnlyFields = []string{"request_id", "tokens_used", "response_tokens", "tool_name", "tool_input", "tool_output", "file_path", "action"}
+	cursorOnlyFields = []string{"session_id", "tokens", "completion_tokens", "tool", "tool_args", "file", "operation"}
+)
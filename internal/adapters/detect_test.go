@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptAll is an adapter without Confidence that accepts every sample
+type acceptAll struct {
+	*BaseAdapter
+}
+
+func (a *acceptAll) ParseLogLine(line string) (*types.AgentEvent, error) { return nil, nil }
+
+func (a *acceptAll) ParseLogFile(filePath string) ([]*types.AgentEvent, error) { return nil, nil }
+
+func (a *acceptAll) SupportsFormat(sample string) bool { return true }
+
+func TestDetectAdapter_AmbiguousSamples(t *testing.T) {
+	registry := DefaultRegistry("1", nil, nil)
+
+	copilotSession, err := os.ReadFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   string
+	}{
+		{
+			// A Cursor log using a Claude model; both adapters accept it
+			name:   "Cursor log with a Claude model",
+			sample: `{"session_id":"s1","model":"claude-3.5-sonnet","prompt":"Refactor this","completion_tokens":42}`,
+			want:   "cursor",
+		},
+		{
+			name:   "Claude log",
+			sample: `{"conversation_id":"c1","model":"claude-3-opus","request_id":"r1","tokens_used":30,"message":"Claude replied"}`,
+			want:   "claude",
+		},
+		{
+			name:   "Cursor tool call",
+			sample: `{"conversation_id":"c1","model":"gpt-4","tool":"edit","tool_args":{"path":"main.go"},"file":"main.go"}`,
+			want:   "cursor",
+		},
+		{
+			name:   "Claude tool call",
+			sample: `{"conversation_id":"c1","model":"gpt-4","tool_name":"read_file","tool_input":{"path":"main.go"}}`,
+			want:   "claude",
+		},
+		{
+			name:   "Copilot chat session",
+			sample: string(copilotSession),
+			want:   "github-copilot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, err := registry.DetectAdapter(tt.sample)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, adapter.Name())
+		})
+	}
+}
+
+func TestDetectAdapter_TiesAreDeterministic(t *testing.T) {
+	registry := DefaultRegistry("1", nil, nil)
+
+	// Nothing tells Claude and Cursor apart; the first name wins every time
+	sample := `{"conversation_id":"c1","model":"gpt-4"}`
+	for i := 0; i < 20; i++ {
+		adapter, err := registry.DetectAdapter(sample)
+		require.NoError(t, err)
+		assert.Equal(t, "claude", adapter.Name())
+	}
+}
+
+func TestDetectAdapter_PrefersConfidentAdapter(t *testing.T) {
+	registry := DefaultRegistry("1", nil, nil)
+	require.NoError(t, registry.Register(&acceptAll{BaseAdapter: NewBaseAdapter("aaa-accept-all", "1", nil)}))
+
+	// Sorting first doesn't help an adapter that only says yes
+	copilotSession, err := os.ReadFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	adapter, err := registry.DetectAdapter(string(copilotSession))
+	require.NoError(t, err)
+	assert.Equal(t, "github-copilot", adapter.Name())
+
+	// But it still takes what no other adapter accepts
+	adapter, err = registry.DetectAdapter("plain text nobody claims")
+	require.NoError(t, err)
+	assert.Equal(t, "aaa-accept-all", adapter.Name())
+}
+
+func TestDetectAdapter_NoMatch(t *testing.T) {
+	registry := DefaultRegistry("1", nil, nil)
+	_, err := registry.DetectAdapter("plain text nobody claims")
+	assert.Error(t, err)
+}
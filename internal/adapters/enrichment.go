@@ -0,0 +1,165 @@
+package adapters
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// workspaceRootForLog resolves the project folder for a log stored under a
+// VS Code workspaceStorage/{workspace-id} directory, or "" if unknown
+func workspaceRootForLog(filePath string) string {
+	parts := strings.Split(filepath.ToSlash(filePath), "/")
+	for i, part := range parts {
+		if part == "workspaceStorage" && i+1 < len(parts) {
+			storageDir := filepath.FromSlash(strings.Join(parts[:i+2], "/"))
+			root, err := hierarchy.ResolveWorkspacePath(storageDir)
+			if err != nil {
+				return ""
+			}
+			return root
+		}
+	}
+	return ""
+}
+
+// setWorkspaceRoot records the project folder a log names in its content,
+// for logs that aren't stored under a VS Code workspaceStorage directory
+func (b *BaseAdapter) setWorkspaceRoot(filePath, root string) {
+	b.rootMu.Lock()
+	defer b.rootMu.Unlock()
+
+	if root == "" {
+		delete(b.workspaceRoots, filePath)
+		return
+	}
+	b.workspaceRoots[filePath] = root
+}
+
+// workspaceRoot returns the project folder for a log, preferring one recorded
+// with setWorkspaceRoot over the workspaceStorage layout
+func (b *BaseAdapter) workspaceRoot(filePath string) string {
+	b.rootMu.Lock()
+	root, ok := b.workspaceRoots[filePath]
+	b.rootMu.Unlock()
+
+	if ok {
+		return root
+	}
+	return workspaceRootForLog(filePath)
+}
+
+// maxSessionCommits bounds the sessions whose start commit is remembered.
+// Past it the session seen first is forgotten, and an event of it seen
+// again records the HEAD commit of that time.
+const maxSessionCommits = 1000
+
+// attachSessionStartCommits records the commit each session started at the
+// first time it is seen and attaches it to all of that session's events
+func (b *BaseAdapter) attachSessionStartCommits(filePath string, events []*types.AgentEvent) {
+	root := ""
+	resolved := false
+
+	b.sessionMu.Lock()
+	defer b.sessionMu.Unlock()
+
+	for _, event := range events {
+		commit, seen := b.sessionCommits[event.SessionID]
+		if !seen {
+			if !resolved {
+				root = b.workspaceRoot(filePath)
+				resolved = true
+			}
+			if root != "" {
+				commit = b.sessionStartCommit(root, event.Timestamp)
+			}
+			if len(b.sessionOrder) >= maxSessionCommits {
+				delete(b.sessionCommits, b.sessionOrder[0])
+				b.sessionOrder = b.sessionOrder[1:]
+			}
+			b.sessionCommits[event.SessionID] = commit
+			b.sessionOrder = append(b.sessionOrder, event.SessionID)
+		}
+
+		if commit != "" {
+			setContext(event, "sessionStartCommit", commit)
+		}
+	}
+}
+
+// sessionStartCommit returns the commit the workspace was at when a session
+// started at start: the newest commit on HEAD made by then, so a session
+// parsed long after it happened isn't credited to later work. A session
+// older than the history gets none, and one without a start time the
+// commit HEAD is at.
+func (b *BaseAdapter) sessionStartCommit(root string, start time.Time) string {
+	if start.IsZero() {
+		if info, err := b.gitCache.Get(root); err == nil {
+			return info.Commit
+		}
+		return ""
+	}
+
+	commit, err := hierarchy.CommitBefore(root, start)
+	if err != nil {
+		return ""
+	}
+	return commit
+}
+
+// attachRepoInfo attaches the owner and name of the workspace repository's
+// origin remote to every event
+func (b *BaseAdapter) attachRepoInfo(filePath string, events []*types.AgentEvent) {
+	root := b.workspaceRoot(filePath)
+	if root == "" {
+		return
+	}
+
+	info, err := b.gitCache.Get(root)
+	if err != nil {
+		return
+	}
+
+	owner, name, ok := hierarchy.ParseRepoOwnerName(info.RemoteURL)
+	if !ok {
+		return
+	}
+
+	for _, event := range events {
+		setContext(event, "repoOwner", owner)
+		setContext(event, "repoName", name)
+	}
+}
+
+// attachGitHead attaches the branch and commit the workspace has checked out
+// to every event. A detached HEAD has no branch, and workspaces that aren't
+// git repositories (or have no commits yet) get neither.
+func (b *BaseAdapter) attachGitHead(filePath string, events []*types.AgentEvent) {
+	root := b.workspaceRoot(filePath)
+	if root == "" {
+		return
+	}
+
+	info, err := b.gitCache.Get(root)
+	if err != nil {
+		return
+	}
+
+	for _, event := range events {
+		if info.Branch != "" {
+			setContext(event, "branch", info.Branch)
+		}
+		setContext(event, "commit", info.Commit)
+	}
+}
+
+// setContext sets a context key, allocating the context map if needed
+func setContext(event *types.AgentEvent, key string, value interface{}) {
+	if event.Context == nil {
+		event.Context = make(map[string]interface{})
+	}
+	event.Context[key] = value
+}
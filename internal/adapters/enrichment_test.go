@@ -0,0 +1,375 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureCommitTime is when initFixtureRepo's commit was made, before the
+// sessions of the fixtures
+var fixtureCommitTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// initFixtureRepo creates a git repository with a single commit and returns
+// the worktree and the commit hash
+func initFixtureRepo(t *testing.T, dir string) (*git.Worktree, string) {
+	t.Helper()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	return wt, commitFileAt(t, wt, dir, "README.md", "hello", fixtureCommitTime)
+}
+
+// commitFile writes a file into the worktree and commits it
+func commitFile(t *testing.T, wt *git.Worktree, dir, name, content string) string {
+	t.Helper()
+	return commitFileAt(t, wt, dir, name, content, time.Now())
+}
+
+// commitFileAt writes a file into the worktree and commits it at when
+func commitFileAt(t *testing.T, wt *git.Worktree, dir, name, content string, when time.Time) string {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	_, err := wt.Add(name)
+	require.NoError(t, err)
+
+	hash, err := wt.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: when},
+	})
+	require.NoError(t, err)
+	return hash.String()
+}
+
+// writeWorkspaceStorage lays out workspaceStorage/{id}/workspace.json
+// pointing at projectDir and returns the storage directory
+func writeWorkspaceStorage(t *testing.T, root, projectDir string) string {
+	t.Helper()
+
+	storageDir := filepath.Join(root, "workspaceStorage", "ws-abc")
+	require.NoError(t, os.MkdirAll(storageDir, 0755))
+
+	workspaceJSON, err := json.Marshal(map[string]string{"folder": "file://" + projectDir})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "workspace.json"), workspaceJSON, 0644))
+
+	return storageDir
+}
+
+// writeWorkspaceSession lays out workspaceStorage/{id}/chatSessions/{session}.json
+// pointing at projectDir and returns the session file path
+func writeWorkspaceSession(t *testing.T, root, projectDir string, session CopilotChatSession) string {
+	t.Helper()
+
+	storageDir := writeWorkspaceStorage(t, root, projectDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, "chatSessions"), 0755))
+
+	sessionFile := filepath.Join(storageDir, "chatSessions", "session-1.json")
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(sessionFile, data, 0644))
+
+	return sessionFile
+}
+
+func TestCopilotAdapter_SessionStartCommit(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	wt, firstCommit := initFixtureRepo(t, projectDir)
+
+	session := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{
+				RequestID: "request_1",
+				Timestamp: int64(1730372400000),
+				Message:   CopilotMessage{Text: "Refactor this"},
+				Response:  []CopilotResponseItem{{Value: json.RawMessage(`"Sure."`)}},
+			},
+		},
+	}
+	sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionStartCommit: true})
+
+	events, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+
+	for _, event := range events {
+		assert.Equal(t, firstCommit, event.Context["sessionStartCommit"], "event %s", event.Type)
+	}
+
+	// A later commit must not change the commit recorded at session start
+	secondCommit := commitFile(t, wt, projectDir, "main.go", "package main")
+	require.NotEqual(t, firstCommit, secondCommit)
+
+	events, err = adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	for _, event := range events {
+		assert.Equal(t, firstCommit, event.Context["sessionStartCommit"])
+	}
+}
+
+func TestClaudeAdapter_SessionStartCommitLineByLine(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	wt, firstCommit := initFixtureRepo(t, projectDir)
+	logFile := filepath.Join(writeWorkspaceStorage(t, root, projectDir), "claude.jsonl")
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionStartCommit: true})
+	adapter.gitCache = hierarchy.NewGitCache(0) // look HEAD up on every line
+
+	parse := func(line string) *types.AgentEvent {
+		t.Helper()
+		events, err := ParseLine(adapter, logFile, line)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			assert.Equal(t, events[0].Context["sessionStartCommit"], event.Context["sessionStartCommit"])
+		}
+		return events[len(events)-1]
+	}
+
+	event := parse(`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Refactor this"}`)
+	assert.Equal(t, firstCommit, event.Context["sessionStartCommit"])
+
+	// The session keeps the commit it started at; a new one records the
+	// commit made before it
+	secondCommit := commitFileAt(t, wt, projectDir, "main.go", "package main", time.Date(2025, 10, 31, 10, 0, 30, 0, time.UTC))
+	commitFile(t, wt, projectDir, "later.go", "package main")
+
+	event = parse(`{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_1","request_id":"req_1","response":"Done"}`)
+	assert.Equal(t, firstCommit, event.Context["sessionStartCommit"])
+
+	event = parse(`{"timestamp":"2025-10-31T10:01:00Z","type":"llm_request","conversation_id":"conv_2","request_id":"req_2","prompt":"And this"}`)
+	assert.Equal(t, secondCommit, event.Context["sessionStartCommit"])
+}
+
+func TestCopilotAdapter_SessionStartCommitFromHistory(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	wt, firstCommit := initFixtureRepo(t, projectDir)
+
+	// Backfilled long after the sessions, with work committed since
+	commitFile(t, wt, projectDir, "main.go", "package main")
+
+	session := func(timestamp time.Time) CopilotChatSession {
+		return CopilotChatSession{
+			Version: 3,
+			Requests: []CopilotRequest{{
+				RequestID: "request_1",
+				Timestamp: timestamp.UnixMilli(),
+				Message:   CopilotMessage{Text: "Refactor this"},
+			}},
+		}
+	}
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionStartCommit: true})
+
+	events, err := adapter.ParseLogFile(writeWorkspaceSession(t, root, projectDir, session(fixtureCommitTime.Add(time.Hour))))
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, firstCommit, events[0].Context["sessionStartCommit"])
+
+	// A session older than the history has no start commit
+	adapter = NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionStartCommit: true})
+	events, err = adapter.ParseLogFile(writeWorkspaceSession(t, root, projectDir, session(fixtureCommitTime.Add(-time.Hour))))
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.NotContains(t, events[0].Context, "sessionStartCommit")
+}
+
+func TestBaseAdapter_BoundsSessionCommits(t *testing.T) {
+	adapter := NewBaseAdapter("test", "test-project", nil)
+
+	events := make([]*types.AgentEvent, maxSessionCommits+1)
+	for i := range events {
+		events[i] = &types.AgentEvent{SessionID: fmt.Sprintf("session-%d", i)}
+	}
+	adapter.attachSessionStartCommits("session.jsonl", events)
+
+	assert.Len(t, adapter.sessionCommits, maxSessionCommits)
+	assert.Len(t, adapter.sessionOrder, maxSessionCommits)
+	assert.NotContains(t, adapter.sessionCommits, "session-0", "the oldest session is forgotten")
+	assert.Contains(t, adapter.sessionCommits, fmt.Sprintf("session-%d", maxSessionCommits))
+}
+
+func TestCopilotAdapter_SessionStartCommitDisabled(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	initFixtureRepo(t, projectDir)
+
+	session := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{RequestID: "request_1", Timestamp: int64(1730372400000), Message: CopilotMessage{Text: "Hi"}},
+		},
+	}
+	sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+
+	for _, event := range events {
+		assert.NotContains(t, event.Context, "sessionStartCommit")
+	}
+}
+
+func TestCopilotAdapter_RepoInfo(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	initFixtureRepo(t, projectDir)
+
+	repo, err := git.PlainOpen(projectDir)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:codervisor/devlog.git"},
+	})
+	require.NoError(t, err)
+
+	session := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{RequestID: "request_1", Timestamp: int64(1730372400000), Message: CopilotMessage{Text: "Hi"}},
+		},
+	}
+	sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{RepoInfo: true})
+
+	events, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+
+	for _, event := range events {
+		assert.Equal(t, "codervisor", event.Context["repoOwner"])
+		assert.Equal(t, "devlog", event.Context["repoName"])
+	}
+}
+
+func TestCopilotAdapter_GitHead(t *testing.T) {
+	session := CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{RequestID: "request_1", Timestamp: int64(1730372400000), Message: CopilotMessage{Text: "Hi"}},
+		},
+	}
+
+	t.Run("Branch", func(t *testing.T) {
+		root := t.TempDir()
+		projectDir := filepath.Join(root, "project")
+		require.NoError(t, os.MkdirAll(projectDir, 0755))
+		wt, commit := initFixtureRepo(t, projectDir)
+		require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/login"), Create: true}))
+		sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+		adapter := NewCopilotAdapter("test-project", nil, nil)
+		adapter.SetOptions(Options{GitHead: true})
+
+		events, err := adapter.ParseLogFile(sessionFile)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			assert.Equal(t, "feature/login", event.Context["branch"])
+			assert.Equal(t, commit, event.Context["commit"])
+		}
+	})
+
+	t.Run("Detached HEAD", func(t *testing.T) {
+		root := t.TempDir()
+		projectDir := filepath.Join(root, "project")
+		require.NoError(t, os.MkdirAll(projectDir, 0755))
+		wt, first := initFixtureRepo(t, projectDir)
+		commitFile(t, wt, projectDir, "main.go", "package main")
+		require.NoError(t, wt.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(first)}))
+		sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+		adapter := NewCopilotAdapter("test-project", nil, nil)
+		adapter.SetOptions(Options{GitHead: true})
+
+		events, err := adapter.ParseLogFile(sessionFile)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			assert.NotContains(t, event.Context, "branch")
+			assert.Equal(t, first, event.Context["commit"])
+		}
+	})
+
+	t.Run("Not a git repository", func(t *testing.T) {
+		root := t.TempDir()
+		projectDir := filepath.Join(root, "project")
+		require.NoError(t, os.MkdirAll(projectDir, 0755))
+		sessionFile := writeWorkspaceSession(t, root, projectDir, session)
+
+		adapter := NewCopilotAdapter("test-project", nil, nil)
+		adapter.SetOptions(Options{GitHead: true})
+
+		events, err := adapter.ParseLogFile(sessionFile)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		for _, event := range events {
+			assert.NotContains(t, event.Context, "branch")
+			assert.NotContains(t, event.Context, "commit")
+		}
+	})
+}
+
+func TestClaudeAdapter_RepoInfoAndGitHeadLineByLine(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	wt, commit := initFixtureRepo(t, projectDir)
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/login"), Create: true}))
+
+	repo, err := git.PlainOpen(projectDir)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:codervisor/devlog.git"},
+	})
+	require.NoError(t, err)
+	logFile := filepath.Join(writeWorkspaceStorage(t, root, projectDir), "claude.jsonl")
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{RepoInfo: true, GitHead: true})
+
+	events, err := ParseLine(adapter, logFile, `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"Hi"}`)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "codervisor", event.Context["repoOwner"])
+	assert.Equal(t, "devlog", event.Context["repoName"])
+	assert.Equal(t, "feature/login", event.Context["branch"])
+	assert.Equal(t, commit, event.Context["commit"])
+}
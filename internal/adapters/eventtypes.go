@@ -0,0 +1,57 @@
+package adapters
+
+import (
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// EventTypeFilter selects which event types adapters emit. A non-empty
+// Include keeps only the listed types; Exclude then drops types from what is
+// left. The zero value keeps every event.
+type EventTypeFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// enabled reports whether the filter drops anything
+func (f EventTypeFilter) enabled() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0
+}
+
+// Allows reports whether events of eventType pass the filter
+func (f EventTypeFilter) Allows(eventType string) bool {
+	if len(f.Include) > 0 && !containsType(f.Include, eventType) {
+		return false
+	}
+	return !containsType(f.Exclude, eventType)
+}
+
+func containsType(eventTypes []string, eventType string) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// filterEventTypes drops the events the filter doesn't allow
+func filterEventTypes(events []*types.AgentEvent, filter EventTypeFilter) []*types.AgentEvent {
+	kept := events[:0]
+	for _, event := range events {
+		if filter.Allows(event.Type) {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
+
+// KeepsEvent reports whether the adapter's event type filter allows event.
+// ParseLogFile and ParseLine apply the filter themselves; callers calling
+// ParseLogLine directly check each event with KeepsEvent.
+func KeepsEvent(adapter AgentAdapter, event *types.AgentEvent) bool {
+	configurable, ok := adapter.(interface{ Options() Options })
+	if !ok {
+		return true
+	}
+	return configurable.Options().EventTypes.Allows(event.Type)
+}
@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTypeFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    EventTypeFilter
+		eventType string
+		want      bool
+	}{
+		{"zero value keeps everything", EventTypeFilter{}, types.EventTypeFileRead, true},
+		{"excluded type", EventTypeFilter{Exclude: []string{types.EventTypeFileRead}}, types.EventTypeFileRead, false},
+		{"type not excluded", EventTypeFilter{Exclude: []string{types.EventTypeFileRead}}, types.EventTypeLLMRequest, true},
+		{"included type", EventTypeFilter{Include: []string{types.EventTypeLLMRequest}}, types.EventTypeLLMRequest, true},
+		{"type not included", EventTypeFilter{Include: []string{types.EventTypeLLMRequest}}, types.EventTypeToolUse, false},
+		{
+			"exclude wins over include",
+			EventTypeFilter{Include: []string{types.EventTypeLLMRequest}, Exclude: []string{types.EventTypeLLMRequest}},
+			types.EventTypeLLMRequest,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Allows(tt.eventType))
+		})
+	}
+}
+
+func TestContinueAdapter_EventTypeFilter(t *testing.T) {
+	sessionFile := filepath.Join("testdata", "continue-session.json")
+
+	adapter := NewContinueAdapter("test-project", nil, nil)
+	all, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	reads := len(eventsOfType(all, types.EventTypeFileRead))
+	require.NotZero(t, reads)
+
+	adapter.SetOptions(Options{EventTypes: EventTypeFilter{Exclude: []string{types.EventTypeFileRead}}})
+	filtered, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	assert.Len(t, filtered, len(all)-reads)
+	assert.Empty(t, eventsOfType(filtered, types.EventTypeFileRead))
+
+	adapter.SetOptions(Options{EventTypes: EventTypeFilter{Include: []string{types.EventTypeLLMRequest, types.EventTypeLLMResponse}}})
+	filtered, err = adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	for _, event := range filtered {
+		assert.Contains(t, []string{types.EventTypeLLMRequest, types.EventTypeLLMResponse}, event.Type)
+	}
+	assert.Len(t, filtered, len(eventsOfType(all, types.EventTypeLLMRequest))+len(eventsOfType(all, types.EventTypeLLMResponse)))
+}
+
+func TestKeepsEvent(t *testing.T) {
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	request := &types.AgentEvent{Type: types.EventTypeLLMRequest}
+	read := &types.AgentEvent{Type: types.EventTypeFileRead}
+
+	assert.True(t, KeepsEvent(adapter, read))
+
+	adapter.SetOptions(Options{EventTypes: EventTypeFilter{Exclude: []string{types.EventTypeFileRead}}})
+	assert.True(t, KeepsEvent(adapter, request))
+	assert.False(t, KeepsEvent(adapter, read))
+}
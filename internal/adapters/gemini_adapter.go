@@ -0,0 +1,427 @@
+package adapters
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// GeminiAdapter parses conversation logs of the Gemini CLI and Gemini Code
+// Assist, kept under ~/.gemini/tmp/<project hash>/ as JSONL with one
+// Gemini API message per line. Logs are appended to and read line by line;
+// the turns of each log are followed across its lines, and a function's
+// result, which arrives in a later user message, is an event of its own
+// carrying the call's ID.
+type GeminiAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+
+	logsMu sync.Mutex
+	logs   map[string]*geminiLog // log file -> conversations of its lines
+}
+
+// NewGeminiAdapter creates a new Gemini adapter
+func NewGeminiAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *GeminiAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &GeminiAdapter{
+		BaseAdapter: NewBaseAdapter("gemini", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+		logs:        make(map[string]*geminiLog),
+	}
+}
+
+// geminiLog follows the conversations of one Gemini log
+type geminiLog struct {
+	mu         sync.Mutex
+	turns      map[string]int    // session -> user turns so far
+	requestIDs map[string]string // session -> request ID of its current turn
+	prompts    map[string]string // session -> prompt of its current turn
+	cwd        string            // folder named by the last message that had one
+	last       time.Time         // time of the last message that had one
+	untimed    int               // messages since then without one
+}
+
+func newGeminiLog() *geminiLog {
+	return &geminiLog{
+		turns:      make(map[string]int),
+		requestIDs: make(map[string]string),
+		prompts:    make(map[string]string),
+	}
+}
+
+// geminiMaxLineBytes bounds one message line; function responses can carry
+// whole files
+const geminiMaxLineBytes = 16 << 20
+
+// GeminiMessage is one line of a conversation log: a Gemini API Content
+// ("user" or "model" role with a list of parts) plus the metadata the CLI
+// records with it
+type GeminiMessage struct {
+	SessionID     string               `json:"sessionId,omitempty"`
+	Timestamp     string               `json:"timestamp,omitempty"`
+	Role          string               `json:"role"`
+	Parts         []GeminiPart         `json:"parts"`
+	Model         string               `json:"model,omitempty"`
+	CWD           string               `json:"cwd,omitempty"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+// GeminiPart is one part of a message. Exactly one of Text, FunctionCall
+// and FunctionResponse is set; Thought marks the model's reasoning text.
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiFunctionCall is a tool call requested by the model
+type GeminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse is the result of a tool call, sent back to the model
+type GeminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// GeminiUsageMetadata holds the token counts the API reported for a model turn
+type GeminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+// geminiTools maps the Gemini CLI's built-in tools to the event they imply,
+// besides the tool_use event itself, and the argument naming the file or
+// command
+var geminiTools = map[string]struct{ eventType, arg string }{
+	"read_file":         {types.EventTypeFileRead, "absolute_path"},
+	"write_file":        {types.EventTypeFileWrite, "file_path"},
+	"replace":           {types.EventTypeFileModify, "file_path"},
+	"run_shell_command": {types.EventTypeCommandExec, "command"},
+}
+
+// ParseLogLine parses a single message line, returning the first of its
+// events; ParseLine returns them all. The line is taken to continue the
+// lines passed before it.
+func (a *GeminiAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	state := a.logState("")
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	events := a.parseLine(state, line, "")
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events[0], nil
+}
+
+// parseFileLine parses a line of filePath, following the turns of the file
+func (a *GeminiAdapter) parseFileLine(filePath, line string) ([]*types.AgentEvent, error) {
+	state := a.logState(filePath)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	events := a.parseLine(state, line, trimLogExt(filepath.Base(filePath)))
+	a.followWorkspace(filePath, state)
+	return events, nil
+}
+
+// logState returns the conversations followed for the lines of filePath
+func (a *GeminiAdapter) logState(filePath string) *geminiLog {
+	a.logsMu.Lock()
+	defer a.logsMu.Unlock()
+
+	state, ok := a.logs[filePath]
+	if !ok {
+		state = newGeminiLog()
+		a.logs[filePath] = state
+	}
+	return state
+}
+
+// resetLog forgets the conversations followed for the lines of filePath
+func (a *GeminiAdapter) resetLog(filePath string) {
+	a.logsMu.Lock()
+	defer a.logsMu.Unlock()
+	delete(a.logs, filePath)
+}
+
+// followWorkspace records the project folder the messages of filePath were
+// sent from, so their events are attached to its project and repository
+func (a *GeminiAdapter) followWorkspace(filePath string, state *geminiLog) {
+	if state.cwd != "" && a.workspaceRoot(filePath) == "" {
+		a.setWorkspaceRoot(filePath, projectFolderRoot(state.cwd))
+	}
+}
+
+// logHierarchy returns the workspace context events parsed from the lines of
+// filePath are attached to
+func (a *GeminiAdapter) logHierarchy(filePath string) *hierarchy.WorkspaceContext {
+	return a.cachedLineHierarchy(filePath, func() *hierarchy.WorkspaceContext {
+		return resolveRootHierarchy(a.ctx, a.hierarchy, a.log, a.workspaceRoot(filePath))
+	})
+}
+
+// ParseLogFile parses a Gemini conversation log. Lines that aren't messages
+// are skipped.
+func (a *GeminiAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	file, err := OpenLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	// The file is read from its start, so its turns are followed afresh
+	state := newGeminiLog()
+	fallbackSessionID := trimLogExt(filepath.Base(filePath))
+
+	var events []*types.AgentEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), geminiMaxLineBytes)
+	for scanner.Scan() {
+		events = append(events, a.parseLine(state, scanner.Text(), fallbackSessionID)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	a.followWorkspace(filePath, state)
+	hierarchyCtx := resolveRootHierarchy(a.ctx, a.hierarchy, a.log, a.workspaceRoot(filePath))
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// parseLine converts one message line of a log into events, assigning
+// messages without a session ID to fallbackSessionID. A message without a
+// timestamp is placed after the last one that had one by its position, so
+// it gets the same time whenever the log is read from its start. Lines that
+// aren't messages yield none.
+func (a *GeminiAdapter) parseLine(state *geminiLog, line, fallbackSessionID string) []*types.AgentEvent {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	var msg GeminiMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.Role == "" {
+		return nil
+	}
+
+	sessionID := msg.SessionID
+	if sessionID == "" {
+		sessionID = fallbackSessionID
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, msg.Timestamp)
+	if err == nil {
+		state.last, state.untimed = timestamp, 0
+	} else {
+		state.untimed++
+		timestamp = sessionTimestamp(state.last, state.untimed)
+	}
+	if msg.CWD != "" {
+		state.cwd = msg.CWD
+	}
+
+	context := map[string]interface{}{}
+	if msg.CWD != "" {
+		context["workspacePath"] = msg.CWD
+	}
+	if msg.Model != "" {
+		context["model"] = msg.Model
+	}
+
+	var events []*types.AgentEvent
+	text := geminiText(msg.Parts)
+	switch msg.Role {
+	case "user":
+		// Function results continue the turn of the calls they answer
+		requestID := state.requestIDs[sessionID]
+		for _, part := range msg.Parts {
+			if part.FunctionResponse != nil {
+				events = append(events, a.resultEvent(part.FunctionResponse, requestID, sessionID, timestamp, context))
+			}
+		}
+		if text == "" {
+			return events
+		}
+
+		state.turns[sessionID]++
+		requestID = fmt.Sprintf("%s-%d", sessionID, state.turns[sessionID])
+		state.requestIDs[sessionID] = requestID
+		state.prompts[sessionID] = text
+		events = append(events, a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, map[string]interface{}{
+			"requestId":    requestID,
+			"prompt":       text,
+			"promptLength": len(text),
+		}))
+
+	case "model":
+		requestID := state.requestIDs[sessionID]
+		for _, part := range msg.Parts {
+			if part.FunctionCall != nil {
+				events = append(events, a.callEvents(part.FunctionCall, requestID, sessionID, timestamp, context)...)
+			}
+		}
+
+		// Every model turn is a response, even one that only called tools,
+		// so its token usage is recorded
+		if text == "" && msg.UsageMetadata == nil {
+			return events
+		}
+		event := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, context, map[string]interface{}{
+			"requestId":      requestID,
+			"response":       text,
+			"responseLength": len(text),
+		})
+		event.Metrics = geminiMetrics(msg.UsageMetadata, state.prompts[sessionID], text)
+		// The turn's prompt is counted once, on its first response
+		delete(state.prompts, sessionID)
+		events = append(events, event)
+	}
+	return events
+}
+
+// callEvents returns the tool_use event for a function call, followed by the
+// file or command event implied by the Gemini CLI's built-in tools
+func (a *GeminiAdapter) callEvents(call *GeminiFunctionCall, requestID, sessionID string, timestamp time.Time, context map[string]interface{}) []*types.AgentEvent {
+	data := map[string]interface{}{
+		"requestId": requestID,
+		"toolName":  call.Name,
+	}
+	if call.ID != "" {
+		data["toolCallId"] = call.ID
+	}
+	if len(call.Args) > 0 {
+		if args, err := json.Marshal(call.Args); err == nil {
+			data["toolArgs"] = string(args)
+		}
+	}
+	events := []*types.AgentEvent{a.newEvent(types.EventTypeToolUse, sessionID, timestamp, context, data)}
+
+	tool, ok := geminiTools[call.Name]
+	if !ok {
+		return events
+	}
+	value, _ := call.Args[tool.arg].(string)
+	if value == "" {
+		return events
+	}
+	key := "filePath"
+	if tool.eventType == types.EventTypeCommandExec {
+		key = "command"
+	}
+	toolData := map[string]interface{}{
+		"requestId": requestID,
+		key:         value,
+	}
+	if call.ID != "" {
+		toolData["toolCallId"] = call.ID
+	}
+	return append(events, a.newEvent(tool.eventType, sessionID, timestamp, context, toolData))
+}
+
+// resultEvent returns the tool_use event for the result of a function call,
+// flagged with Data["toolResult"]. It carries the call's ID when the CLI
+// recorded one, and the tool's name otherwise, to be paired with the call.
+func (a *GeminiAdapter) resultEvent(resp *GeminiFunctionResponse, requestID, sessionID string, timestamp time.Time, context map[string]interface{}) *types.AgentEvent {
+	data := map[string]interface{}{
+		"requestId":  requestID,
+		"toolName":   resp.Name,
+		"toolOutput": geminiResponseText(resp.Response),
+		"toolResult": true,
+	}
+	if resp.ID != "" {
+		data["toolCallId"] = resp.ID
+	}
+	return a.newEvent(types.EventTypeToolUse, sessionID, timestamp, context, data)
+}
+
+// geminiText joins a message's text parts, leaving out the model's thoughts
+func geminiText(parts []GeminiPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" && !part.Thought {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// geminiResponseText flattens a function response. The CLI wraps tool
+// output as {"output": "..."}; other shapes are kept as JSON.
+func geminiResponseText(response json.RawMessage) string {
+	if len(response) == 0 {
+		return ""
+	}
+	var wrapped struct {
+		Output *string `json:"output"`
+	}
+	if err := json.Unmarshal(response, &wrapped); err == nil && wrapped.Output != nil {
+		return *wrapped.Output
+	}
+	return string(response)
+}
+
+// geminiMetrics returns the token counts the API reported for a model turn,
+// or estimates from the turn's prompt, if not counted yet, and the text
+// when the log has none
+func geminiMetrics(usage *GeminiUsageMetadata, prompt, text string) *types.EventMetrics {
+	if usage == nil {
+		return &types.EventMetrics{PromptTokens: estimateTokens(prompt), ResponseTokens: estimateTokens(text)}
+	}
+	total := usage.TotalTokenCount
+	if total == 0 {
+		total = usage.PromptTokenCount + usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+	}
+	return &types.EventMetrics{
+		PromptTokens:   usage.PromptTokenCount,
+		ResponseTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+		TokenCount:     total,
+	}
+}
+
+// SupportsFormat checks if this adapter can handle the given log format: the
+// first line must be a Gemini message with a user or model role
+func (a *GeminiAdapter) SupportsFormat(sample string) bool {
+	line := strings.TrimSpace(sample)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	var msg GeminiMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return false
+	}
+	if msg.Role != "user" && msg.Role != "model" || len(msg.Parts) == 0 {
+		return false
+	}
+	for _, part := range msg.Parts {
+		if part.Text == "" && part.FunctionCall == nil && part.FunctionResponse == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiAdapter_ParseLogFile(t *testing.T) {
+	adapter := NewGeminiAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "gemini-session.jsonl"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	tools := eventsOfType(events, types.EventTypeToolUse)
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	commands := eventsOfType(events, types.EventTypeCommandExec)
+	require.Len(t, requests, 2, "function results don't start a turn")
+	require.Len(t, responses, 4, "one per model message, including tool-only ones")
+	require.Len(t, tools, 4, "a call and its result each")
+	require.Len(t, reads, 1)
+	require.Len(t, commands, 1)
+
+	assert.Equal(t, "Why does PruneOlderThan vacuum the database?", requests[0].Data["prompt"])
+	assert.Nil(t, requests[0].Metrics, "prompt tokens come from the reported usage")
+
+	// A function-call turn: the call, its result from the next message, and
+	// the token usage of the model message that made it
+	assert.Equal(t, "read_file", tools[0].Data["toolName"])
+	assert.Equal(t, "call-read-1", tools[0].Data["toolCallId"])
+	assert.JSONEq(t, `{"absolute_path":"/home/dev/devlog/internal/buffer/buffer.go"}`, tools[0].Data["toolArgs"].(string))
+	assert.NotContains(t, tools[0].Data, "toolOutput", "the call is sent before its result arrives")
+	assert.Equal(t, "gemini-2.5-pro", tools[0].Context["model"])
+	assert.Equal(t, requests[0].Data["requestId"], tools[0].Data["requestId"])
+	assert.Equal(t, "/home/dev/devlog/internal/buffer/buffer.go", reads[0].Data["filePath"])
+
+	assert.Equal(t, true, tools[1].Data["toolResult"])
+	assert.Equal(t, "call-read-1", tools[1].Data["toolCallId"])
+	assert.Equal(t, "const vacuumFreeRatio = 0.25", tools[1].Data["toolOutput"])
+	assert.Equal(t, requests[0].Data["requestId"], tools[1].Data["requestId"])
+
+	assert.Equal(t, "", responses[0].Data["response"], "thoughts are not part of the response")
+	assert.Equal(t, &types.EventMetrics{PromptTokens: 1200, ResponseTokens: 47, TokenCount: 1247}, responses[0].Metrics)
+	assert.Equal(t, "It vacuums once a quarter of the pages are free.", responses[1].Data["response"])
+	assert.Equal(t, 1510, responses[1].Metrics.TokenCount)
+
+	// A call without an ID is paired with its result by name
+	assert.Equal(t, "run_shell_command", tools[2].Data["toolName"])
+	assert.Equal(t, "run_shell_command", tools[3].Data["toolName"])
+	assert.NotContains(t, tools[3].Data, "toolCallId")
+	assert.Contains(t, tools[3].Data["toolOutput"], "ok")
+	assert.Equal(t, "go test ./internal/buffer", commands[0].Data["command"])
+	assert.Equal(t, requests[1].Data["requestId"], commands[0].Data["requestId"])
+
+	for i, event := range events {
+		assert.Equal(t, "gemini", event.AgentID)
+		assert.Equal(t, "4b1f7e2a-9c3d-4e5f-8a6b-1c2d3e4f5a6b", event.SessionID)
+		assert.Equal(t, "/home/dev/devlog", event.Context["workspacePath"])
+		if i > 0 {
+			assert.False(t, event.Timestamp.Before(events[i-1].Timestamp), "events should stay in order")
+		}
+	}
+}
+
+func TestGeminiAdapter_EstimatesWithoutUsage(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "chat.jsonl")
+	content := `{"role":"user","parts":[{"text":"Explain the retry loop"}]}
+not json
+{"role":"model","parts":[{"text":"It backs off exponentially."}]}
+`
+	require.NoError(t, os.WriteFile(logFile, []byte(content), 0644))
+
+	adapter := NewGeminiAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "chat", events[0].SessionID, "session ID falls back to the file name")
+	assert.Nil(t, events[0].Metrics, "whether usage is reported is only known once the model answers")
+	require.NotNil(t, events[1].Metrics)
+	assert.Positive(t, events[1].Metrics.PromptTokens)
+	assert.Positive(t, events[1].Metrics.ResponseTokens)
+	assert.Zero(t, events[1].Metrics.TokenCount)
+}
+
+func TestGeminiAdapter_ParsesLineByLine(t *testing.T) {
+	path := filepath.Join("testdata", "gemini-session.jsonl")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	adapter := NewGeminiAdapter("test-project", nil, nil)
+	whole, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+
+	var lines []*types.AgentEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		events, err := ParseLine(adapter, path, line)
+		require.NoError(t, err)
+		lines = append(lines, events...)
+	}
+
+	// Lines emit what the whole file does, so rereading the file after it
+	// was tailed finds nothing new
+	require.Len(t, lines, len(whole))
+	for i := range whole {
+		assert.Equal(t, buffer.EventHash(whole[i]), buffer.EventHash(lines[i]), "event %d", i)
+	}
+}
+
+func TestGeminiAdapter_TimestampsWithoutTimeAreStable(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "chat.jsonl")
+	content := `{"timestamp":"2025-10-31T10:00:00Z","role":"user","parts":[{"text":"Explain the retry loop"}]}
+{"role":"model","parts":[{"text":"It backs off exponentially."}]}
+`
+	require.NoError(t, os.WriteFile(logFile, []byte(content), 0644))
+
+	adapter := NewGeminiAdapter("test-project", nil, nil)
+	first, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, first[0].Timestamp.Add(time.Millisecond), first[1].Timestamp, "placed after the message before it")
+
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(logFile, later, later))
+	again, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, buffer.EventHash(first[1]), buffer.EventHash(again[1]))
+}
+
+func TestGeminiAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewGeminiAdapter("test-project", nil, nil)
+
+	session, err := os.ReadFile(filepath.Join("testdata", "gemini-session.jsonl"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"gemini log", string(session), true},
+		{"function call", `{"role":"model","parts":[{"functionCall":{"name":"read_file","args":{}}}]}`, true},
+		{"no parts", `{"role":"user","parts":[]}`, false},
+		{"openai message", `{"role":"assistant","content":"hi"}`, false},
+		{"claude log", `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"c","prompt":"hi"}`, false},
+		{"continue session", `{"sessionId":"abc","title":"New Session","history":[]}`, false},
+		{"not json", `hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, adapter.SupportsFormat(tt.sample))
+		})
+	}
+}
+
+func TestDefaultRegistry_DetectsGeminiLog(t *testing.T) {
+	session, err := os.ReadFile(filepath.Join("testdata", "gemini-session.jsonl"))
+	require.NoError(t, err)
+
+	registry := DefaultRegistry("test-project", nil, nil)
+	detected, err := registry.DetectAdapter(string(session))
+	require.NoError(t, err)
+	assert.Equal(t, "gemini", detected.Name())
+	assert.False(t, ParsesWholeFile(detected, "session.jsonl"), "logs are appended to and tailed")
+}
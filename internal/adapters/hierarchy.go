@@ -0,0 +1,109 @@
+package adapters
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// lineHierarchyTTL bounds how long the workspace context looked up for a
+// line-based log is reused for its lines
+const lineHierarchyTTL = 30 * time.Second
+
+// logHierarchyResolver is implemented by adapters that attach the workspace
+// hierarchy of a line-based log to the events of its lines
+type logHierarchyResolver interface {
+	logHierarchy(filePath string) *hierarchy.WorkspaceContext
+}
+
+// lineHierarchy is a workspace context looked up for a log's lines, nil if
+// it didn't resolve
+type lineHierarchy struct {
+	ctx      *hierarchy.WorkspaceContext
+	resolved time.Time
+}
+
+// resolveLogHierarchy looks up the workspace context of a log stored under a
+// VS Code workspaceStorage/{workspace-id} directory, within ctx. It returns
+// nil if the log isn't stored under one or the workspace doesn't resolve.
+func resolveLogHierarchy(ctx context.Context, cache *hierarchy.HierarchyCache, log *logrus.Logger, filePath string) *hierarchy.WorkspaceContext {
+	workspaceID := extractWorkspaceIDFromPath(filePath)
+	if workspaceID == "" || cache == nil {
+		return nil
+	}
+
+	resolved, err := cache.Resolve(ctx, workspaceID)
+	if err != nil {
+		log.Warnf("Failed to resolve workspace %s: %v - continuing without hierarchy", workspaceID, err)
+		return nil
+	}
+	log.Debugf("Resolved hierarchy for workspace %s: project=%d, machine=%d",
+		workspaceID, resolved.ProjectID, resolved.MachineID)
+	return resolved
+}
+
+// projectFolderRoot returns the root of the Git repository containing the
+// folder a log names, or the folder itself if it isn't in one
+func projectFolderRoot(folder string) string {
+	folder = filepath.Clean(folder)
+	if root, err := hierarchy.FindGitRoot(folder); err == nil {
+		return root
+	}
+	return folder
+}
+
+// resolveRootHierarchy looks up the context of the project folder a log
+// names in its content, for logs kept outside any VS Code workspace, within
+// ctx. It returns nil if the log names none or the project doesn't resolve.
+func resolveRootHierarchy(ctx context.Context, cache *hierarchy.HierarchyCache, log *logrus.Logger, root string) *hierarchy.WorkspaceContext {
+	if root == "" || cache == nil {
+		return nil
+	}
+
+	resolved, err := cache.ResolveProjectRoot(ctx, root)
+	if err != nil {
+		log.Warnf("Failed to resolve project %s: %v - continuing without hierarchy", root, err)
+		return nil
+	}
+	log.Debugf("Resolved hierarchy for project %s: project=%d", root, resolved.ProjectID)
+	return resolved
+}
+
+// cachedLineHierarchy returns the workspace context of a line-based log,
+// looking it up with resolve at most once per lineHierarchyTTL so a workspace
+// the backend can't resolve isn't retried on every line
+func (b *BaseAdapter) cachedLineHierarchy(filePath string, resolve func() *hierarchy.WorkspaceContext) *hierarchy.WorkspaceContext {
+	b.rootMu.Lock()
+	cached, ok := b.lineHierarchies[filePath]
+	b.rootMu.Unlock()
+
+	if ok && time.Since(cached.resolved) < lineHierarchyTTL {
+		return cached.ctx
+	}
+
+	ctx := resolve()
+
+	b.rootMu.Lock()
+	defer b.rootMu.Unlock()
+	now := time.Now()
+	for path, entry := range b.lineHierarchies {
+		if now.Sub(entry.resolved) >= lineHierarchyTTL {
+			delete(b.lineHierarchies, path)
+		}
+	}
+	b.lineHierarchies[filePath] = lineHierarchy{ctx: ctx, resolved: now}
+	return ctx
+}
+
+// setHierarchy attaches a resolved workspace context to an event
+func setHierarchy(event *types.AgentEvent, ctx *hierarchy.WorkspaceContext) {
+	event.ProjectID = ctx.ProjectID
+	event.MachineID = ctx.MachineID
+	event.WorkspaceID = ctx.WorkspaceID
+	setContext(event, "projectName", ctx.ProjectName)
+	setContext(event, "machineName", ctx.MachineName)
+}
@@ -0,0 +1,257 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// JetBrainsAdapter parses chats saved by JetBrains AI Assistant
+// (<IDE caches>/JetBrains/<product>/aia/chats/*.json) and GitHub Copilot for
+// JetBrains (~/.config/github-copilot/intellij/chats/*.json). Both write one
+// JSON document per chat and rewrite it as the chat grows.
+type JetBrainsAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+}
+
+// NewJetBrainsAdapter creates a new JetBrains adapter
+func NewJetBrainsAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *JetBrainsAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &JetBrainsAdapter{
+		BaseAdapter: NewBaseAdapter("jetbrains", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+	}
+}
+
+// JetBrainsChat is a saved chat. ProjectPath is the IDE project the chat was
+// opened in; Plugin is "ai-assistant" or "github-copilot".
+type JetBrainsChat struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title,omitempty"`
+	Plugin      string             `json:"plugin,omitempty"`
+	IDE         string             `json:"ide,omitempty"`
+	ProjectPath string             `json:"projectPath,omitempty"`
+	CreatedAt   interface{}        `json:"createdAt,omitempty"`
+	Messages    []JetBrainsMessage `json:"messages"`
+}
+
+// JetBrainsMessage is one chat message. Roles are "user", "assistant" and
+// "system"; timestamps are Unix milliseconds or RFC3339 strings.
+type JetBrainsMessage struct {
+	ID          string                `json:"id,omitempty"`
+	Role        string                `json:"role"`
+	Text        string                `json:"text,omitempty"`
+	Timestamp   interface{}           `json:"timestamp,omitempty"`
+	Model       string                `json:"model,omitempty"`
+	Attachments []JetBrainsAttachment `json:"attachments,omitempty"`
+	ToolCalls   []JetBrainsToolCall   `json:"toolCalls,omitempty"`
+}
+
+// JetBrainsAttachment is a file or selection attached to a user message
+type JetBrainsAttachment struct {
+	Kind string `json:"kind"`
+	Path string `json:"path,omitempty"`
+}
+
+// JetBrainsToolCall is a tool the assistant ran while answering
+type JetBrainsToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+}
+
+// jetbrainsRoles are the message roles a JetBrains chat may contain
+var jetbrainsRoles = map[string]bool{
+	"user":      true,
+	"assistant": true,
+	"system":    true,
+}
+
+// ParseLogLine is not supported; chats are JSON documents rewritten in place
+func (a *JetBrainsAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	return nil, fmt.Errorf("line-based parsing not supported for JetBrains chats")
+}
+
+// ParseLogFile parses a JetBrains AI Assistant or Copilot chat file
+func (a *JetBrainsAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	data, err := readLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat file: %w", err)
+	}
+
+	var chat JetBrainsChat
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, fmt.Errorf("failed to parse chat JSON: %w", err)
+	}
+
+	sessionID := chat.ID
+	if sessionID == "" {
+		sessionID = trimLogExt(filepath.Base(filePath))
+	}
+
+	// There is no workspaceStorage to map the chat to a workspace; resolve
+	// the project folder through its Git repository instead
+	root := ""
+	if chat.ProjectPath != "" {
+		root = projectFolderRoot(fileURIToPath(chat.ProjectPath))
+	}
+	a.setWorkspaceRoot(filePath, root)
+	hierarchyCtx := resolveRootHierarchy(a.ctx, a.hierarchy, a.log, root)
+
+	events := a.parseChat(&chat, sessionID, root)
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// parseChat converts the chat messages into events
+func (a *JetBrainsAdapter) parseChat(chat *JetBrainsChat, sessionID, root string) []*types.AgentEvent {
+	context := map[string]interface{}{}
+	if root != "" {
+		context["workspacePath"] = root
+		if info, err := a.gitCache.Get(root); err == nil {
+			if info.Branch != "" {
+				context["gitBranch"] = info.Branch
+			}
+			context["gitCommit"] = info.Commit
+		}
+	}
+	if chat.Title != "" {
+		context["title"] = chat.Title
+	}
+	if chat.Plugin != "" {
+		context["plugin"] = chat.Plugin
+	}
+	if chat.IDE != "" {
+		context["ide"] = chat.IDE
+	}
+
+	// Messages without a timestamp are placed a millisecond apart after the
+	// last one that has one, or the chat's creation, so they keep the same
+	// times each time the chat is parsed
+	last := parseJetBrainsTimestamp(chat.CreatedAt, time.Time{})
+	untimed := 0
+
+	var events []*types.AgentEvent
+	turn := 0
+	requestID := ""
+	for _, msg := range chat.Messages {
+		timestamp := parseJetBrainsTimestamp(msg.Timestamp, time.Time{})
+		if timestamp.IsZero() {
+			untimed++
+			timestamp = sessionTimestamp(last, untimed)
+		} else {
+			last, untimed = timestamp, 0
+		}
+
+		switch msg.Role {
+		case "user":
+			turn++
+			requestID = msg.ID
+			if requestID == "" {
+				requestID = fmt.Sprintf("%s-%d", sessionID, turn)
+			}
+			event := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, map[string]interface{}{
+				"requestId":    requestID,
+				"prompt":       msg.Text,
+				"promptLength": len(msg.Text),
+			})
+			event.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(msg.Text)}
+			events = append(events, event)
+
+			for _, attachment := range msg.Attachments {
+				if attachment.Kind != "file" || attachment.Path == "" {
+					continue
+				}
+				events = append(events, a.newEvent(types.EventTypeFileRead, sessionID, timestamp, context, map[string]interface{}{
+					"requestId": requestID,
+					"filePath":  fileURIToPath(attachment.Path),
+				}))
+			}
+
+		case "assistant":
+			eventContext := context
+			if msg.Model != "" {
+				eventContext = copyContext(context)
+				eventContext["model"] = msg.Model
+			}
+
+			for _, call := range msg.ToolCalls {
+				data := map[string]interface{}{
+					"requestId": requestID,
+					"toolName":  call.Name,
+				}
+				if call.ID != "" {
+					data["toolCallId"] = call.ID
+				}
+				if call.Arguments != "" {
+					data["toolArgs"] = call.Arguments
+				}
+				if call.Result != "" {
+					data["toolOutput"] = call.Result
+				}
+				events = append(events, a.newEvent(types.EventTypeToolUse, sessionID, timestamp, eventContext, data))
+			}
+
+			if msg.Text != "" {
+				event := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, eventContext, map[string]interface{}{
+					"requestId":      requestID,
+					"response":       msg.Text,
+					"responseLength": len(msg.Text),
+				})
+				event.Metrics = &types.EventMetrics{ResponseTokens: estimateTokens(msg.Text)}
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// parseJetBrainsTimestamp handles Unix milliseconds and RFC3339 strings
+func parseJetBrainsTimestamp(ts interface{}, fallback time.Time) time.Time {
+	switch v := ts.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	case float64:
+		if v > 0 {
+			return time.UnixMilli(int64(v))
+		}
+	}
+	return fallback
+}
+
+// SupportsFormat checks if this adapter can handle the given log format
+func (a *JetBrainsAdapter) SupportsFormat(sample string) bool {
+	var chat JetBrainsChat
+	if err := json.Unmarshal([]byte(sample), &chat); err != nil {
+		return false
+	}
+	if chat.ID == "" || chat.Messages == nil {
+		return false
+	}
+	if chat.IDE == "" && chat.ProjectPath == "" && chat.Plugin == "" {
+		return false
+	}
+	for _, msg := range chat.Messages {
+		if !jetbrainsRoles[msg.Role] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJetBrainsAdapter_ParseLogFile(t *testing.T) {
+	adapter := NewJetBrainsAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "jetbrains-chat.json"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	tools := eventsOfType(events, types.EventTypeToolUse)
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	require.Len(t, requests, 2)
+	require.Len(t, responses, 2)
+	require.Len(t, tools, 1)
+	require.Len(t, reads, 1, "selections are not file reads")
+
+	assert.Equal(t, "Why does PruneOlderThan vacuum the database?", requests[0].Data["prompt"])
+	assert.Equal(t, "msg-1", requests[0].Data["requestId"])
+	assert.Equal(t, "5d2c7e1a-8b3f-4c9e-a1d2-6f0e9b8c7a65-2", requests[1].Data["requestId"])
+	assert.Equal(t, filepath.FromSlash("/home/dev/devlog/internal/buffer/buffer.go"), reads[0].Data["filePath"])
+
+	assert.Equal(t, "search_project", tools[0].Data["toolName"])
+	assert.Equal(t, "call_search", tools[0].Data["toolCallId"])
+	assert.Equal(t, "internal/buffer/buffer.go:31", tools[0].Data["toolOutput"])
+	assert.Equal(t, "gpt-4o", tools[0].Context["model"])
+	assert.Equal(t, "msg-1", tools[0].Data["requestId"])
+
+	assert.Equal(t, "Done.", responses[1].Data["response"])
+	assert.Equal(t, requests[1].Data["requestId"], responses[1].Data["requestId"])
+
+	// A message without a timestamp follows the previous message's
+	assert.Equal(t, int64(1730372405001), requests[1].Timestamp.UnixMilli())
+
+	for _, event := range events {
+		assert.Equal(t, "jetbrains", event.AgentID)
+		assert.Equal(t, "5d2c7e1a-8b3f-4c9e-a1d2-6f0e9b8c7a65", event.SessionID)
+		assert.Equal(t, filepath.FromSlash("/home/dev/devlog"), event.Context["workspacePath"])
+		assert.Equal(t, "ai-assistant", event.Context["plugin"])
+		assert.Equal(t, "GoLand2024.3", event.Context["ide"])
+	}
+}
+
+func TestJetBrainsAdapter_ResolvesProjectThroughGit(t *testing.T) {
+	root := t.TempDir()
+	repoDir := filepath.Join(root, "repo")
+	projectDir := filepath.Join(repoDir, "services", "api")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	_, commit := initFixtureRepo(t, repoDir)
+
+	chat := JetBrainsChat{
+		ID:          "chat-1",
+		Plugin:      "github-copilot",
+		ProjectPath: projectDir,
+		Messages:    []JetBrainsMessage{{Role: "user", Text: "Hi", Timestamp: float64(1730372400000)}},
+	}
+	data, err := json.Marshal(chat)
+	require.NoError(t, err)
+	chatFile := filepath.Join(root, "chats", "chat-1.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(chatFile), 0755))
+	require.NoError(t, os.WriteFile(chatFile, data, 0644))
+
+	adapter := NewJetBrainsAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionStartCommit: true})
+
+	events, err := adapter.ParseLogFile(chatFile)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, repoDir, events[0].Context["workspacePath"])
+	assert.Equal(t, "master", events[0].Context["gitBranch"])
+	assert.Equal(t, commit, events[0].Context["gitCommit"])
+	assert.Equal(t, commit, events[0].Context["sessionStartCommit"])
+}
+
+func TestJetBrainsAdapter_TimestampsAreStable(t *testing.T) {
+	chat := JetBrainsChat{
+		ID:        "chat-1",
+		Plugin:    "github-copilot",
+		CreatedAt: "2024-10-31T10:00:00Z",
+		Messages: []JetBrainsMessage{
+			{Role: "user", Text: "Hi"},
+			{Role: "assistant", Text: "Hello"},
+		},
+	}
+	data, err := json.Marshal(chat)
+	require.NoError(t, err)
+	chatFile := filepath.Join(t.TempDir(), "chat-1.json")
+	require.NoError(t, os.WriteFile(chatFile, data, 0644))
+
+	adapter := NewJetBrainsAdapter("test-project", nil, nil)
+	first, err := adapter.ParseLogFile(chatFile)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+
+	created := time.Date(2024, 10, 31, 10, 0, 0, 0, time.UTC)
+	assert.True(t, first[0].Timestamp.Equal(created.Add(time.Millisecond)))
+	assert.True(t, first[1].Timestamp.Equal(created.Add(2*time.Millisecond)))
+
+	// Rewriting the chat moves its mtime; the messages must not move with it
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(chatFile, later, later))
+	second, err := adapter.ParseLogFile(chatFile)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	for i := range first {
+		assert.True(t, first[i].Timestamp.Equal(second[i].Timestamp))
+	}
+}
+
+func TestJetBrainsAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewJetBrainsAdapter("test-project", nil, nil)
+
+	chat, err := os.ReadFile(filepath.Join("testdata", "jetbrains-chat.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"jetbrains chat", string(chat), true},
+		{"empty copilot chat", `{"id":"abc","plugin":"github-copilot","messages":[]}`, true},
+		{"no ide information", `{"id":"abc","messages":[{"role":"user","text":"hi"}]}`, false},
+		{"unknown role", `{"id":"abc","ide":"GoLand2024.3","messages":[{"role":"llm","text":"hi"}]}`, false},
+		{"continue session", `{"sessionId":"abc","history":[]}`, false},
+		{"codecompanion chat", `{"save_id":"1","messages":[{"role":"llm","content":"hi"}]}`, false},
+		{"not json", `hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, adapter.SupportsFormat(tt.sample))
+		})
+	}
+}
+
+func TestDefaultRegistry_DetectsJetBrainsChat(t *testing.T) {
+	chat, err := os.ReadFile(filepath.Join("testdata", "jetbrains-chat.json"))
+	require.NoError(t, err)
+
+	registry := DefaultRegistry("test-project", nil, nil)
+	detected, err := registry.DetectAdapter(string(chat))
+	require.NoError(t, err)
+	assert.Equal(t, "jetbrains", detected.Name())
+}
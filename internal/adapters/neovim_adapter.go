@@ -0,0 +1,334 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// NeovimAdapter parses chat histories saved by Neovim AI plugins:
+// Avante (avante/history/**/*.json) and CodeCompanion
+// (codecompanion-history/**/*.json). Both write one JSON document per chat.
+type NeovimAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+}
+
+// NewNeovimAdapter creates a new Neovim adapter
+func NewNeovimAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *NeovimAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &NeovimAdapter{
+		BaseAdapter: NewBaseAdapter("neovim", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+	}
+}
+
+// NeovimChatHistory is a saved chat of either plugin. Avante fills Entries,
+// CodeCompanion fills Messages.
+type NeovimChatHistory struct {
+	Title string `json:"title,omitempty"`
+
+	// Avante
+	Timestamp interface{}   `json:"timestamp,omitempty"`
+	Entries   []AvanteEntry `json:"entries,omitempty"`
+
+	// CodeCompanion
+	SaveID    string                 `json:"save_id,omitempty"` // Unix seconds the chat was saved first
+	CreatedAt interface{}            `json:"created_at,omitempty"`
+	UpdatedAt interface{}            `json:"updated_at,omitempty"`
+	CWD       string                 `json:"cwd,omitempty"`
+	Adapter   *CodeCompanionAdapter  `json:"adapter,omitempty"`
+	Messages  []CodeCompanionMessage `json:"messages,omitempty"`
+}
+
+// AvanteEntry is one request/response turn in an Avante history file
+type AvanteEntry struct {
+	Timestamp    string          `json:"timestamp,omitempty"`
+	Provider     string          `json:"provider,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	Request      string          `json:"request,omitempty"`
+	Response     string          `json:"response,omitempty"`
+	SelectedFile *AvanteFileRef  `json:"selected_file,omitempty"`
+	SelectedCode *AvanteCodeRef  `json:"selected_code,omitempty"`
+	ToolUses     []AvanteToolUse `json:"tool_uses,omitempty"`
+}
+
+// AvanteFileRef is the file that was selected when a request was sent
+type AvanteFileRef struct {
+	Filepath string `json:"filepath"`
+}
+
+// AvanteCodeRef is the code selection sent along with a request
+type AvanteCodeRef struct {
+	Filetype string `json:"filetype,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// AvanteToolUse is a tool call made while answering a request
+type AvanteToolUse struct {
+	ID     string      `json:"id,omitempty"`
+	Name   string      `json:"name"`
+	Input  interface{} `json:"input,omitempty"`
+	Result string      `json:"result,omitempty"`
+}
+
+// CodeCompanionAdapter is the LLM adapter a CodeCompanion chat used
+type CodeCompanionAdapter struct {
+	Name  string `json:"name,omitempty"`
+	Model string `json:"model,omitempty"`
+}
+
+// CodeCompanionMessage is one message of a CodeCompanion chat. Roles are
+// "user", "llm", "tool" and "system".
+type CodeCompanionMessage struct {
+	Role       string                  `json:"role"`
+	Content    string                  `json:"content,omitempty"`
+	ToolCalls  []CodeCompanionToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string                  `json:"tool_call_id,omitempty"`
+}
+
+// CodeCompanionToolCall is an OpenAI-style function call requested by the LLM
+type CodeCompanionToolCall struct {
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+// ParseLogLine is not supported; histories are JSON documents rewritten in place
+func (a *NeovimAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	return nil, fmt.Errorf("line-based parsing not supported for Neovim chat histories")
+}
+
+// ParseLogFile parses an Avante or CodeCompanion chat history file
+func (a *NeovimAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	data, err := readLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat history file: %w", err)
+	}
+
+	var history NeovimChatHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse chat history JSON: %w", err)
+	}
+
+	sessionID := trimLogExt(filepath.Base(filePath))
+
+	var events []*types.AgentEvent
+	switch {
+	case len(history.Entries) > 0:
+		events = a.parseAvante(&history, sessionID)
+	case len(history.Messages) > 0:
+		if history.SaveID != "" {
+			sessionID = history.SaveID
+		}
+		events = a.parseCodeCompanion(&history, sessionID)
+	}
+
+	// CodeCompanion chats name the folder Neovim was started in
+	root := ""
+	if history.CWD != "" {
+		root = projectFolderRoot(history.CWD)
+	}
+	a.setWorkspaceRoot(filePath, root)
+	hierarchyCtx := resolveRootHierarchy(a.ctx, a.hierarchy, a.log, root)
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// parseAvante converts Avante history entries into events. Entries without
+// a timestamp are placed after the history's own by their position.
+func (a *NeovimAdapter) parseAvante(history *NeovimChatHistory, sessionID string) []*types.AgentEvent {
+	start := parseNeovimTimestamp(history.Timestamp, time.Time{})
+
+	var events []*types.AgentEvent
+	for i, entry := range history.Entries {
+		timestamp := parseNeovimTimestamp(entry.Timestamp, sessionTimestamp(start, i))
+		requestID := fmt.Sprintf("%s-%d", sessionID, i)
+		context := map[string]interface{}{
+			"plugin":   "avante",
+			"provider": entry.Provider,
+			"model":    entry.Model,
+		}
+
+		request := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, map[string]interface{}{
+			"requestId":    requestID,
+			"prompt":       entry.Request,
+			"promptLength": len(entry.Request),
+		})
+		request.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(entry.Request)}
+		if entry.SelectedCode != nil && entry.SelectedCode.Content != "" {
+			request.Data["selectedCodeLength"] = len(entry.SelectedCode.Content)
+			request.Data["selectedCodeFiletype"] = entry.SelectedCode.Filetype
+		}
+		events = append(events, request)
+
+		if entry.SelectedFile != nil && entry.SelectedFile.Filepath != "" {
+			events = append(events, a.newEvent(types.EventTypeFileRead, sessionID, timestamp, context, map[string]interface{}{
+				"requestId": requestID,
+				"filePath":  entry.SelectedFile.Filepath,
+			}))
+		}
+
+		for _, tool := range entry.ToolUses {
+			data := map[string]interface{}{
+				"requestId":  requestID,
+				"toolName":   tool.Name,
+				"toolCallId": tool.ID,
+			}
+			if tool.Input != nil {
+				data["toolArgs"] = tool.Input
+			}
+			if tool.Result != "" {
+				data["toolOutput"] = tool.Result
+			}
+			events = append(events, a.newEvent(types.EventTypeToolUse, sessionID, timestamp, context, data))
+		}
+
+		if entry.Response != "" {
+			response := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp.Add(time.Second), context, map[string]interface{}{
+				"requestId":      requestID,
+				"response":       entry.Response,
+				"responseLength": len(entry.Response),
+			})
+			response.Metrics = &types.EventMetrics{ResponseTokens: estimateTokens(entry.Response)}
+			events = append(events, response)
+		}
+	}
+	return events
+}
+
+// parseCodeCompanion converts CodeCompanion chat messages into events. The
+// messages carry no timestamps, so every event is placed after the time the
+// chat was created by its position. updated_at moves with every save and
+// isn't used.
+func (a *NeovimAdapter) parseCodeCompanion(history *NeovimChatHistory, sessionID string) []*types.AgentEvent {
+	start := parseNeovimTimestamp(history.CreatedAt, time.Time{})
+	if start.IsZero() {
+		if seconds, err := strconv.ParseInt(history.SaveID, 10, 64); err == nil && seconds > 0 {
+			start = time.Unix(seconds, 0)
+		}
+	}
+	context := map[string]interface{}{
+		"plugin": "codecompanion",
+	}
+	if history.Adapter != nil {
+		context["provider"] = history.Adapter.Name
+		context["model"] = history.Adapter.Model
+	}
+	if history.CWD != "" {
+		context["workspacePath"] = history.CWD
+	}
+
+	// Tool results arrive as separate messages; attach them to their call
+	results := make(map[string]string)
+	for _, msg := range history.Messages {
+		if msg.Role == "tool" && msg.ToolCallID != "" {
+			results[msg.ToolCallID] = msg.Content
+		}
+	}
+
+	var events []*types.AgentEvent
+	turn := 0
+	requestID := ""
+	for i, msg := range history.Messages {
+		timestamp := sessionTimestamp(start, i)
+
+		switch msg.Role {
+		case "user":
+			turn++
+			requestID = fmt.Sprintf("%s-%d", sessionID, turn)
+			event := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, map[string]interface{}{
+				"requestId":    requestID,
+				"prompt":       msg.Content,
+				"promptLength": len(msg.Content),
+			})
+			event.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(msg.Content)}
+			events = append(events, event)
+
+		case "llm", "assistant":
+			for _, call := range msg.ToolCalls {
+				data := map[string]interface{}{
+					"requestId":  requestID,
+					"toolName":   call.Function.Name,
+					"toolCallId": call.ID,
+				}
+				if call.Function.Arguments != "" {
+					data["toolArgs"] = call.Function.Arguments
+				}
+				if result, ok := results[call.ID]; ok {
+					data["toolOutput"] = result
+				}
+				events = append(events, a.newEvent(types.EventTypeToolUse, sessionID, timestamp, context, data))
+			}
+
+			if msg.Content != "" {
+				event := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, context, map[string]interface{}{
+					"requestId":      requestID,
+					"response":       msg.Content,
+					"responseLength": len(msg.Content),
+				})
+				event.Metrics = &types.EventMetrics{ResponseTokens: estimateTokens(msg.Content)}
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// parseNeovimTimestamp handles Avante's local "2006-01-02 15:04:05" strings,
+// RFC3339 strings and CodeCompanion's Unix seconds
+func parseNeovimTimestamp(ts interface{}, fallback time.Time) time.Time {
+	switch v := ts.(type) {
+	case string:
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local); err == nil {
+			return t
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+	case float64:
+		if v > 0 {
+			return time.Unix(int64(v), 0)
+		}
+	}
+	return fallback
+}
+
+// SupportsFormat checks if this adapter can handle the given log format
+func (a *NeovimAdapter) SupportsFormat(sample string) bool {
+	var history NeovimChatHistory
+	if err := json.Unmarshal([]byte(sample), &history); err != nil {
+		return false
+	}
+
+	if len(history.Entries) > 0 {
+		return history.Entries[0].Request != "" || history.Entries[0].Response != ""
+	}
+
+	if history.SaveID != "" && len(history.Messages) > 0 {
+		return true
+	}
+	for _, msg := range history.Messages {
+		if msg.Role == "llm" {
+			return true
+		}
+	}
+	return false
+}
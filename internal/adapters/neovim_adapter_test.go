@@ -0,0 +1,148 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventsOfType returns the events of the given type in order
+func eventsOfType(events []*types.AgentEvent, eventType string) []*types.AgentEvent {
+	var matched []*types.AgentEvent
+	for _, event := range events {
+		if event.Type == eventType {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+func TestNeovimAdapter_ParseAvanteHistory(t *testing.T) {
+	adapter := NewNeovimAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "avante-history.json"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	tools := eventsOfType(events, types.EventTypeToolUse)
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	require.Len(t, requests, 2)
+	require.Len(t, responses, 2)
+	require.Len(t, tools, 1)
+	require.Len(t, reads, 1)
+
+	assert.Equal(t, "Split LoadConfig into load and validate steps", requests[0].Data["prompt"])
+	assert.Equal(t, "avante", requests[0].Context["plugin"])
+	assert.Equal(t, "claude-3-5-sonnet", requests[0].Context["model"])
+	assert.Equal(t, "go", requests[0].Data["selectedCodeFiletype"])
+	assert.Equal(t, "Here is the refactored loader.", responses[0].Data["response"])
+	assert.Equal(t, "internal/config/config.go", reads[0].Data["filePath"])
+
+	assert.Equal(t, "view", tools[0].Data["toolName"])
+	assert.Equal(t, "package config", tools[0].Data["toolOutput"])
+	assert.Equal(t, requests[0].Data["requestId"], tools[0].Data["requestId"])
+
+	for _, event := range events {
+		assert.Equal(t, "neovim", event.AgentID)
+		assert.Equal(t, "avante-history", event.SessionID)
+		assert.Equal(t, 2025, event.Timestamp.Year())
+	}
+	assert.True(t, requests[1].Timestamp.After(requests[0].Timestamp))
+}
+
+func TestNeovimAdapter_ParseCodeCompanionHistory(t *testing.T) {
+	adapter := NewNeovimAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "codecompanion-history.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 3, "system and tool messages do not produce events of their own")
+
+	assert.Equal(t, types.EventTypeLLMRequest, events[0].Type)
+	assert.Equal(t, "What does buffer.Store do?", events[0].Data["prompt"])
+
+	assert.Equal(t, types.EventTypeToolUse, events[1].Type)
+	assert.Equal(t, "read_file", events[1].Data["toolName"])
+	assert.Equal(t, "call_1", events[1].Data["toolCallId"])
+	assert.Equal(t, `{"path":"internal/buffer/buffer.go"}`, events[1].Data["toolArgs"])
+	assert.Equal(t, "package buffer", events[1].Data["toolOutput"])
+
+	assert.Equal(t, types.EventTypeLLMResponse, events[2].Type)
+	assert.Equal(t, "Store persists events to SQLite.", events[2].Data["response"])
+
+	for i, event := range events {
+		assert.Equal(t, "1730372400", event.SessionID)
+		assert.Equal(t, "codecompanion", event.Context["plugin"])
+		assert.Equal(t, "gpt-4o", event.Context["model"])
+		assert.Equal(t, "/home/dev/devlog", event.Context["workspacePath"])
+		assert.Equal(t, events[0].Data["requestId"], event.Data["requestId"])
+		if i > 0 {
+			assert.True(t, event.Timestamp.After(events[i-1].Timestamp), "events stay in message order")
+		}
+	}
+}
+
+func TestNeovimAdapter_CodeCompanionTimestampsAreStable(t *testing.T) {
+	adapter := NewNeovimAdapter("test-project", nil, nil)
+	path := filepath.Join(t.TempDir(), "1730372400.json")
+	chat := func(updatedAt int, messages ...string) {
+		t.Helper()
+		data := fmt.Sprintf(`{"save_id":"1730372400","updated_at":%d,"messages":[%s]}`, updatedAt, strings.Join(messages, ","))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	}
+	parse := func() ([]*types.AgentEvent, []string) {
+		t.Helper()
+		events, err := adapter.ParseLogFile(path)
+		require.NoError(t, err)
+		var hashes []string
+		for _, event := range events {
+			hashes = append(hashes, buffer.EventHash(event))
+		}
+		return events, hashes
+	}
+
+	chat(1730372400, `{"role":"user","content":"Hi"}`, `{"role":"llm","content":"Hello"}`)
+	events, first := parse()
+	require.Len(t, first, 2)
+	assert.Equal(t, int64(1730372400), events[0].Timestamp.Unix(), "messages are placed at the chat's creation")
+
+	// Saving the chat again moves updated_at and the file's mtime, neither
+	// of which may move the messages already sent
+	chat(1730376000, `{"role":"user","content":"Hi"}`, `{"role":"llm","content":"Hello"}`, `{"role":"user","content":"More"}`)
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, later, later))
+	_, again := parse()
+	require.Len(t, again, 3)
+	assert.Equal(t, first, again[:2])
+}
+
+func TestNeovimAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewNeovimAdapter("test-project", nil, nil)
+
+	for _, name := range []string{"avante-history.json", "codecompanion-history.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		assert.True(t, adapter.SupportsFormat(string(data)), name)
+	}
+
+	assert.False(t, adapter.SupportsFormat(`{"version":3,"requests":[{"requestId":"r1"}]}`))
+	assert.False(t, adapter.SupportsFormat(`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","prompt":"Hello"}`))
+	assert.False(t, adapter.SupportsFormat("not json"))
+}
+
+func TestNeovimAdapter_ParsesWholeFile(t *testing.T) {
+	adapter := NewNeovimAdapter("test-project", nil, nil)
+	assert.True(t, ParsesWholeFile(adapter, "history/0.json"))
+	assert.False(t, ParsesWholeFile(adapter, "nvim.log"))
+
+	_, err := adapter.ParseLogLine(`{"entries":[]}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// canonicalPath normalizes a file path taken from a VS Code URI so the same
+// file always yields the same string, whichever of path, fsPath or the
+// file:// URI string it came from and whichever OS wrote it: the URI's
+// URL-encoding is decoded, separators become "/", the slash VS Code puts
+// before Windows drive letters ("/C:/src") is dropped, drive letters are
+// lower-cased and "." and ".." are resolved. Only URIs are decoded; path
+// and fsPath hold the file name as is, "%" included.
+func canonicalPath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	if uri, ok := strings.CutPrefix(p, "file://"); ok {
+		p = uri
+		if decoded, err := url.PathUnescape(p); err == nil {
+			p = decoded
+		}
+	}
+	p = strings.ReplaceAll(p, `\`, "/")
+
+	if len(p) >= 3 && p[0] == '/' && hasDriveLetter(p[1:]) {
+		p = p[1:]
+	}
+	if hasDriveLetter(p) {
+		p = strings.ToLower(p[:1]) + p[1:]
+	}
+
+	// Keep the double slash of UNC paths (//server/share), which Clean folds
+	if strings.HasPrefix(p, "//") {
+		return "/" + path.Clean(p[1:])
+	}
+	return path.Clean(p)
+}
+
+// hasDriveLetter reports whether p starts with a Windows drive letter and colon
+func hasDriveLetter(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	c := p[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// relativePath returns the canonical path p relative to the workspace root,
+// or false if root is unknown or p lies outside it. Windows paths compare
+// case-insensitively, as the file system does.
+func relativePath(p, root string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	root = strings.TrimSuffix(canonicalPath(root), "/") + "/"
+	if len(p) <= len(root) {
+		return "", false
+	}
+
+	prefix := p[:len(root)]
+	if prefix == root || (hasDriveLetter(root) && strings.EqualFold(prefix, root)) {
+		return p[len(root):], true
+	}
+	return "", false
+}
+
+// setFilePath stores the canonical form of rawPath as data["filePath"] and,
+// when it lies inside the workspace root, the path relative to the root as
+// data["relativePath"]
+func setFilePath(data map[string]interface{}, rawPath, root string) {
+	filePath := canonicalPath(rawPath)
+	data["filePath"] = filePath
+	if rel, ok := relativePath(filePath, root); ok {
+		data["relativePath"] = rel
+	}
+}
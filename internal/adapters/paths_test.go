@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"macOS path", "/Users/dev/project/src/main.go", "/Users/dev/project/src/main.go"},
+		{"macOS URL-encoded URI", "file:///Users/dev/My%20Project/src/main%2Btest.go", "/Users/dev/My Project/src/main+test.go"},
+		{"file URI", "file:///Users/dev/project/main.go", "/Users/dev/project/main.go"},
+		{"percent in fsPath kept", "/Users/dev/My%20Project/main.go", "/Users/dev/My%20Project/main.go"},
+		{"Windows fsPath", `c:\Users\dev\project\src\main.go`, "c:/Users/dev/project/src/main.go"},
+		{"Windows URI path", "/C:/Users/dev/project/src/main.go", "c:/Users/dev/project/src/main.go"},
+		{"Windows URL-encoded URI", "file:///c%3A/Users/dev/my%20project/main.go", "c:/Users/dev/my project/main.go"},
+		{"percent in Windows fsPath kept", `c:\Users\dev\50%25 off\main.go`, "c:/Users/dev/50%25 off/main.go"},
+		{"Windows UNC", `\\server\share\project\main.go`, "//server/share/project/main.go"},
+		{"dot segments", "/home/dev/project/./src/../main.go", "/home/dev/project/main.go"},
+		{"invalid escape kept", "file:///home/dev/100%.txt", "/home/dev/100%.txt"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canonicalPath(tt.input))
+		})
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		root   string
+		want   string
+		wantOK bool
+	}{
+		{"inside root", "/Users/dev/project/src/main.go", "/Users/dev/project", "src/main.go", true},
+		{"root with trailing slash", "/Users/dev/project/main.go", "/Users/dev/project/", "main.go", true},
+		{"sibling with shared prefix", "/Users/dev/project-old/main.go", "/Users/dev/project", "", false},
+		{"outside root", "/etc/hosts", "/Users/dev/project", "", false},
+		{"root itself", "/Users/dev/project", "/Users/dev/project", "", false},
+		{"Windows root in other case", "c:/Users/Dev/Project/src/main.go", `C:\Users\dev\project`, "src/main.go", true},
+		{"unknown root", "/Users/dev/project/main.go", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := relativePath(tt.path, tt.root)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCopilotAdapter_RelativeFilePaths(t *testing.T) {
+	// A workspaceStorage directory whose workspace.json points at the
+	// project the sample session edits
+	storage := filepath.Join(t.TempDir(), "workspaceStorage", "abc123")
+	sessions := filepath.Join(storage, "chatSessions")
+	require.NoError(t, os.MkdirAll(sessions, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "workspace.json"),
+		[]byte(`{"folder":"file:///home/dev/project"}`), 0644))
+
+	content, err := os.ReadFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	sessionFile := filepath.Join(sessions, "session.json")
+	require.NoError(t, os.WriteFile(sessionFile, content, 0644))
+
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	events, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+
+	var modifications []*types.AgentEvent
+	for _, event := range events {
+		if event.Type == types.EventTypeFileModify {
+			modifications = append(modifications, event)
+		}
+	}
+	require.Len(t, modifications, 3)
+
+	assert.Equal(t, "/home/dev/project/main.go", modifications[0].Data["filePath"])
+	assert.Equal(t, "main.go", modifications[0].Data["relativePath"])
+	assert.Equal(t, "util.go", modifications[1].Data["relativePath"])
+	assert.NotContains(t, modifications[2].Data, "relativePath")
+}
@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"strconv"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// resolveProject applies the project resolution mode to events parsed from
+// filePath, logging when the resolved and configured projects disagree. A
+// per-agent project override wins over both.
+func (b *BaseAdapter) resolveProject(filePath string, hierarchyCtx *hierarchy.WorkspaceContext, events []*types.AgentEvent) []*types.AgentEvent {
+	if b.options.ProjectOverride > 0 {
+		for _, event := range events {
+			event.ProjectID = b.options.ProjectOverride
+			if b.options.WorkspaceOverride > 0 {
+				event.WorkspaceID = b.options.WorkspaceOverride
+			}
+		}
+		return events
+	}
+
+	configured, err := strconv.Atoi(b.projectID)
+	hasConfigured := err == nil && configured > 0
+	hasResolved := hierarchyCtx != nil && hierarchyCtx.ProjectID > 0
+
+	if hasConfigured && hasResolved && configured != hierarchyCtx.ProjectID && len(events) > 0 && b.firstMismatch(filePath) {
+		b.logger.Infof("Workspace for %s resolves to project %d (%s) but project %d is configured; using the %s",
+			filePath, hierarchyCtx.ProjectID, hierarchyCtx.ProjectName, configured, b.winningProject())
+	}
+
+	switch b.options.ProjectResolution {
+	case ProjectPreferConfig:
+		if hasConfigured {
+			for _, event := range events {
+				event.ProjectID = configured
+			}
+		}
+	case ProjectHierarchyOnly:
+		if !hasResolved && len(events) > 0 {
+			b.logger.Debugf("Dropping %d events from %s: workspace did not resolve to a project", len(events), filePath)
+			return nil
+		}
+	}
+
+	return events
+}
+
+// winningProject describes which project the resolution mode picks
+func (b *BaseAdapter) winningProject() string {
+	if b.options.ProjectResolution == ProjectPreferConfig {
+		return "configured project"
+	}
+	return "resolved project"
+}
+
+// firstMismatch reports whether a project mismatch for filePath is being
+// seen for the first time, so it is logged once rather than on every parse
+func (b *BaseAdapter) firstMismatch(filePath string) bool {
+	b.sessionMu.Lock()
+	defer b.sessionMu.Unlock()
+
+	if b.mismatchLogged[filePath] {
+		return false
+	}
+	b.mismatchLogged[filePath] = true
+	return true
+}
@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/models"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseWithResolution parses a Copilot session in workspace ws-abc with
+// project 7 configured. When resolvable, ws-abc resolves to project 42.
+func parseWithResolution(t *testing.T, mode ProjectResolutionMode, resolvable bool) []int {
+	t.Helper()
+
+	var projects []int
+	for _, event := range parseWithOptions(t, Options{ProjectResolution: mode}, resolvable) {
+		projects = append(projects, event.ProjectID)
+	}
+	return projects
+}
+
+// parseWithOptions parses the ws-abc session described above with opts
+func parseWithOptions(t *testing.T, opts Options, resolvable bool) []*types.AgentEvent {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	cache := hierarchy.NewHierarchyCache(nil, log)
+	if resolvable {
+		cache.Initialize([]*models.Workspace{{
+			ID:          3,
+			ProjectID:   42,
+			MachineID:   5,
+			WorkspaceID: "ws-abc",
+			Project:     &models.Project{FullName: "owner/resolved"},
+			Machine:     &models.Machine{Hostname: "dev-box"},
+		}})
+	}
+
+	root := t.TempDir()
+	sessionFile := writeWorkspaceSession(t, root, root, CopilotChatSession{
+		Version: 3,
+		Requests: []CopilotRequest{
+			{
+				RequestID: "request_1",
+				Timestamp: int64(1730372400000),
+				Message:   CopilotMessage{Text: "Which project is this?"},
+			},
+		},
+	})
+
+	adapter := NewCopilotAdapter("7", cache, log)
+	adapter.SetOptions(opts)
+
+	events, err := adapter.ParseLogFile(sessionFile)
+	require.NoError(t, err)
+	return events
+}
+
+func TestProjectResolution_Modes(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       ProjectResolutionMode
+		resolvable bool
+		want       int // project on every event; 0 means events are dropped
+	}{
+		{"default prefers hierarchy", "", true, 42},
+		{"prefer-hierarchy resolved", ProjectPreferHierarchy, true, 42},
+		{"prefer-hierarchy unresolved falls back to config", ProjectPreferHierarchy, false, 7},
+		{"prefer-config resolved", ProjectPreferConfig, true, 7},
+		{"prefer-config unresolved", ProjectPreferConfig, false, 7},
+		{"hierarchy-only resolved", ProjectHierarchyOnly, true, 42},
+		{"hierarchy-only unresolved drops events", ProjectHierarchyOnly, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects := parseWithResolution(t, tt.mode, tt.resolvable)
+
+			if tt.want == 0 {
+				assert.Empty(t, projects)
+				return
+			}
+			require.NotEmpty(t, projects)
+			for _, project := range projects {
+				assert.Equal(t, tt.want, project)
+			}
+		})
+	}
+}
+
+func TestProjectResolution_OverrideWins(t *testing.T) {
+	modes := []ProjectResolutionMode{"", ProjectPreferHierarchy, ProjectPreferConfig, ProjectHierarchyOnly}
+
+	for _, mode := range modes {
+		for _, resolvable := range []bool{true, false} {
+			opts := Options{ProjectResolution: mode, ProjectOverride: 99, WorkspaceOverride: 11}
+			events := parseWithOptions(t, opts, resolvable)
+
+			require.NotEmpty(t, events, "mode %q resolvable=%v", mode, resolvable)
+			for _, event := range events {
+				assert.Equal(t, 99, event.ProjectID)
+				assert.Equal(t, 11, event.WorkspaceID)
+			}
+		}
+	}
+}
+
+func TestProjectResolution_OverrideKeepsResolvedWorkspace(t *testing.T) {
+	events := parseWithOptions(t, Options{ProjectOverride: 99}, true)
+
+	require.NotEmpty(t, events)
+	for _, event := range events {
+		assert.Equal(t, 99, event.ProjectID)
+		assert.Equal(t, 3, event.WorkspaceID)
+	}
+}
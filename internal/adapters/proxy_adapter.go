@@ -0,0 +1,501 @@
+package adapters
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ProxyLogAdapter parses the JSONL request logs of an LLM gateway such as
+// LiteLLM or an internal OpenAI/Anthropic proxy. Each line is one call with
+// the request sent upstream, the response, the model, token usage and
+// latency; where a gateway keeps them is set with ProxyFields. Every line
+// becomes an llm_request and an llm_response event. Logs are appended to
+// and tailed line by line.
+type ProxyLogAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+	fields    ProxyFields
+
+	logsMu sync.Mutex
+	logs   map[string]*proxyLog // log file -> state of its lines
+}
+
+// proxyLog follows the time of one proxy log, for calls logged without one
+type proxyLog struct {
+	mu      sync.Mutex
+	last    time.Time // time of the last call that had one
+	untimed int       // calls since then without one
+}
+
+// NewProxyLogAdapter creates a new proxy log adapter reading
+// DefaultProxyFields
+func NewProxyLogAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *ProxyLogAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &ProxyLogAdapter{
+		BaseAdapter: NewBaseAdapter("proxy", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+		fields:      DefaultProxyFields,
+		logs:        make(map[string]*proxyLog),
+	}
+}
+
+// proxyMaxLineBytes bounds one logged call; requests carry whole
+// conversations
+const proxyMaxLineBytes = 32 << 20
+
+// ProxyFields locates the parts of a logged call as dotted paths into the
+// JSON record, e.g. "metadata.session_id" or "messages". Numeric segments
+// index arrays.
+type ProxyFields struct {
+	Request   string // Request body, its messages or the prompt text
+	Response  string // Response body or text
+	Model     string
+	Usage     string // OpenAI or Anthropic usage object
+	Latency   string // Milliseconds
+	Timestamp string // RFC 3339 or Unix seconds/milliseconds
+	Session   string
+	RequestID string
+}
+
+// DefaultProxyFields reads records shaped
+// {"id", "timestamp", "session_id", "request", "response", "model", "usage", "latency_ms"}
+var DefaultProxyFields = ProxyFields{
+	Request:   "request",
+	Response:  "response",
+	Model:     "model",
+	Usage:     "usage",
+	Latency:   "latency_ms",
+	Timestamp: "timestamp",
+	Session:   "session_id",
+	RequestID: "id",
+}
+
+// proxyFieldNames are the configuration keys of ProxyFields
+var proxyFieldNames = map[string]func(*ProxyFields) *string{
+	"request":   func(f *ProxyFields) *string { return &f.Request },
+	"response":  func(f *ProxyFields) *string { return &f.Response },
+	"model":     func(f *ProxyFields) *string { return &f.Model },
+	"usage":     func(f *ProxyFields) *string { return &f.Usage },
+	"latency":   func(f *ProxyFields) *string { return &f.Latency },
+	"timestamp": func(f *ProxyFields) *string { return &f.Timestamp },
+	"session":   func(f *ProxyFields) *string { return &f.Session },
+	"requestId": func(f *ProxyFields) *string { return &f.RequestID },
+}
+
+// ParseProxyFields returns DefaultProxyFields with the paths in overrides,
+// keyed by field name (request, response, model, usage, latency,
+// timestamp, session, requestId)
+func ParseProxyFields(overrides map[string]string) (ProxyFields, error) {
+	fields := DefaultProxyFields
+	for name, path := range overrides {
+		field, ok := proxyFieldNames[name]
+		if !ok {
+			known := make([]string, 0, len(proxyFieldNames))
+			for name := range proxyFieldNames {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return ProxyFields{}, fmt.Errorf("unknown proxy log field %q (expected one of %s)", name, strings.Join(known, ", "))
+		}
+		*field(&fields) = path
+	}
+	if fields.Request == "" || fields.Response == "" {
+		return ProxyFields{}, fmt.Errorf("proxy log fields request and response must not be empty")
+	}
+	return fields, nil
+}
+
+// SetFields changes where records keep each part of a call
+func (a *ProxyLogAdapter) SetFields(fields ProxyFields) {
+	a.fields = fields
+}
+
+// ParseLogLine parses a single logged call, returning its request event;
+// ParseLine returns the response too. A call without a timestamp is placed
+// after the call passed before it.
+func (a *ProxyLogAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	state := a.logState("")
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	events := a.parseLine(state, line, "", time.Time{}, time.Time{})
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events[0], nil
+}
+
+// parseFileLine parses a line of filePath, following the time of the file
+func (a *ProxyLogAdapter) parseFileLine(filePath, line string) ([]*types.AgentEvent, error) {
+	state := a.logState(filePath)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return a.parseLine(state, line, trimLogExt(filepath.Base(filePath)), time.Time{}, time.Time{}), nil
+}
+
+// logState returns the state followed for the lines of filePath
+func (a *ProxyLogAdapter) logState(filePath string) *proxyLog {
+	a.logsMu.Lock()
+	defer a.logsMu.Unlock()
+
+	state, ok := a.logs[filePath]
+	if !ok {
+		state = &proxyLog{}
+		a.logs[filePath] = state
+	}
+	return state
+}
+
+// resetLog forgets the state followed for the lines of filePath
+func (a *ProxyLogAdapter) resetLog(filePath string) {
+	a.logsMu.Lock()
+	defer a.logsMu.Unlock()
+	delete(a.logs, filePath)
+}
+
+// logHierarchy returns the workspace context events parsed from the lines of
+// filePath are attached to
+func (a *ProxyLogAdapter) logHierarchy(filePath string) *hierarchy.WorkspaceContext {
+	return a.cachedLineHierarchy(filePath, func() *hierarchy.WorkspaceContext {
+		return resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
+	})
+}
+
+// ParseLogFile parses a proxy log. Lines that aren't calls are skipped.
+func (a *ProxyLogAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	return a.ParseLogFileInRange(filePath, time.Time{}, time.Time{})
+}
+
+// ParseLogFileInRange parses the calls of a proxy log made within [from, to]
+func (a *ProxyLogAdapter) ParseLogFileInRange(filePath string, from, to time.Time) ([]*types.AgentEvent, error) {
+	file, err := OpenLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	// The file is read from its start, so its time is followed afresh
+	state := &proxyLog{}
+	fallbackSessionID := trimLogExt(filepath.Base(filePath))
+	hierarchyCtx := resolveLogHierarchy(a.ctx, a.hierarchy, a.log, filePath)
+
+	var events []*types.AgentEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), proxyMaxLineBytes)
+	for scanner.Scan() {
+		events = append(events, a.parseLine(state, scanner.Text(), fallbackSessionID, from, to)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// parseLine converts one logged call made within [from, to] into events. A
+// call without a timestamp is placed after the last one that had one, and
+// a call without an ID is named after its content, so both are the same
+// whenever the log is read from its start. Lines that aren't calls yield
+// none.
+func (a *ProxyLogAdapter) parseLine(state *proxyLog, line, fallbackSessionID string, from, to time.Time) []*types.AgentEvent {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil || lookupPath(record, a.fields.Request) == nil {
+		return nil
+	}
+
+	timestamp, ok := proxyTime(lookupPath(record, a.fields.Timestamp))
+	if ok {
+		state.last, state.untimed = timestamp, 0
+	} else {
+		state.untimed++
+		timestamp = sessionTimestamp(state.last, state.untimed)
+	}
+	if !inRange(timestamp, from, to) {
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(line))
+	fallbackRequestID := fmt.Sprintf("%s-%s", fallbackSessionID, hex.EncodeToString(sum[:8]))
+	return a.recordEvents(record, fallbackRequestID, fallbackSessionID, timestamp)
+}
+
+// recordEvents converts one logged call into its request and response events
+func (a *ProxyLogAdapter) recordEvents(record map[string]interface{}, fallbackRequestID, fallbackSessionID string, timestamp time.Time) []*types.AgentEvent {
+	request := lookupPath(record, a.fields.Request)
+	response := lookupPath(record, a.fields.Response)
+
+	sessionID := proxyString(lookupPath(record, a.fields.Session))
+	if sessionID == "" {
+		sessionID = fallbackSessionID
+	}
+	requestID := proxyString(lookupPath(record, a.fields.RequestID))
+	if requestID == "" {
+		requestID = proxyString(lookupPath(response, "id"))
+	}
+	if requestID == "" {
+		requestID = fallbackRequestID
+	}
+	model := proxyString(lookupPath(record, a.fields.Model))
+	if model == "" {
+		model = proxyString(lookupPath(request, "model"))
+	}
+	if model == "" {
+		model = proxyString(lookupPath(response, "model"))
+	}
+	usage := lookupPath(record, a.fields.Usage)
+	if usage == nil {
+		usage = lookupPath(response, "usage")
+	}
+
+	context := map[string]interface{}{}
+	if model != "" {
+		context["model"] = model
+	}
+
+	prompt := proxyPrompt(request)
+	requestData := map[string]interface{}{
+		"requestId":    requestID,
+		"prompt":       prompt,
+		"promptLength": len(prompt),
+	}
+	text := proxyResponse(response)
+	responseData := map[string]interface{}{
+		"requestId":      requestID,
+		"response":       text,
+		"responseLength": len(text),
+	}
+	if model != "" {
+		requestData["model"] = model
+		responseData["model"] = model
+	}
+
+	requestEvent := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, requestData)
+	responseEvent := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, context, responseData)
+
+	metrics, reported := proxyMetrics(usage)
+	if !reported {
+		requestEvent.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(prompt)}
+		metrics.ResponseTokens = estimateTokens(text)
+	}
+	if latency, ok := proxyNumber(lookupPath(record, a.fields.Latency)); ok && latency > 0 {
+		metrics.DurationMs = int64(latency)
+		// The response arrived once the call took its latency
+		responseEvent.Timestamp = timestamp.Add(time.Duration(latency * float64(time.Millisecond)))
+	}
+	responseEvent.Metrics = metrics
+
+	return []*types.AgentEvent{requestEvent, responseEvent}
+}
+
+// lookupPath walks a dotted path through nested objects and arrays,
+// returning nil when any segment is missing
+func lookupPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	for _, segment := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[segment]
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// proxyPrompt returns the prompt of a request: a string as is, or the last
+// user message of an OpenAI/Anthropic request body or message list
+func proxyPrompt(request interface{}) string {
+	switch v := request.(type) {
+	case string:
+		return v
+	case []interface{}:
+		for i := len(v) - 1; i >= 0; i-- {
+			if message, ok := v[i].(map[string]interface{}); ok && message["role"] == "user" {
+				return proxyContentText(message["content"])
+			}
+		}
+	case map[string]interface{}:
+		if messages, ok := v["messages"]; ok {
+			return proxyPrompt(messages)
+		}
+		if prompt, ok := v["prompt"]; ok {
+			return proxyContentText(prompt)
+		}
+		if input, ok := v["input"]; ok {
+			return proxyPrompt(input)
+		}
+	}
+	return ""
+}
+
+// proxyResponse returns the text of a response: a string as is, the first
+// choice of an OpenAI completion, or the text blocks of an Anthropic message
+func proxyResponse(response interface{}) string {
+	switch v := response.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if choice := lookupPath(v, "choices.0"); choice != nil {
+			if content := lookupPath(choice, "message.content"); content != nil {
+				return proxyContentText(content)
+			}
+			return proxyString(lookupPath(choice, "text"))
+		}
+		if content, ok := v["content"]; ok {
+			return proxyContentText(content)
+		}
+		return proxyString(v["output_text"])
+	}
+	return ""
+}
+
+// proxyContentText flattens message content, which is either a string or a
+// list of parts of which only text parts are kept
+func proxyContentText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var texts []string
+		for _, part := range v {
+			if text := proxyString(lookupPath(part, "text")); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// proxyMetrics returns the token counts of an OpenAI (prompt_tokens,
+// completion_tokens) or Anthropic (input_tokens, output_tokens) usage
+// object, and whether the call reported any
+func proxyMetrics(usage interface{}) (*types.EventMetrics, bool) {
+	metrics := &types.EventMetrics{}
+	promptTokens, hasPrompt := proxyTokens(usage, "prompt_tokens", "input_tokens", "promptTokens", "inputTokens")
+	responseTokens, hasResponse := proxyTokens(usage, "completion_tokens", "output_tokens", "completionTokens", "outputTokens")
+	total, hasTotal := proxyTokens(usage, "total_tokens", "totalTokens")
+	if !hasPrompt && !hasResponse && !hasTotal {
+		return metrics, false
+	}
+	if !hasTotal {
+		total = promptTokens + responseTokens
+	}
+	metrics.PromptTokens = promptTokens
+	metrics.ResponseTokens = responseTokens
+	metrics.TokenCount = total
+	return metrics, true
+}
+
+// proxyTokens returns the first of keys present in usage
+func proxyTokens(usage interface{}, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if n, ok := proxyNumber(lookupPath(usage, key)); ok {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// proxyNumber reads a JSON number, or a number written as a string
+func proxyNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// proxyString reads a JSON string, or formats a number as one
+func proxyString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// proxyTime reads an RFC 3339 timestamp or Unix time in seconds or, for
+// values too large to be seconds, milliseconds
+func proxyTime(value interface{}) (time.Time, bool) {
+	if s, ok := value.(string); ok {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	n, ok := proxyNumber(value)
+	if !ok || n <= 0 {
+		return time.Time{}, false
+	}
+	if n >= 1e12 {
+		return time.UnixMilli(int64(n)), true
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*1e9)), true
+}
+
+// SupportsFormat checks if this adapter can handle the given log format: the
+// first line must be a JSON record with a request and a response, plus a
+// model, usage or latency
+func (a *ProxyLogAdapter) SupportsFormat(sample string) bool {
+	line := strings.TrimSpace(sample)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return false
+	}
+	if lookupPath(record, a.fields.Request) == nil || lookupPath(record, a.fields.Response) == nil {
+		return false
+	}
+	for _, path := range []string{a.fields.Model, a.fields.Usage, a.fields.Latency} {
+		if lookupPath(record, path) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyLogAdapter_ParseLogFile(t *testing.T) {
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "proxy-requests.jsonl"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	require.Len(t, requests, 3, "lines that aren't calls are skipped")
+	require.Len(t, responses, 3)
+
+	// OpenAI chat completion with reported usage and latency
+	assert.Equal(t, "Why does the buffer evict by created_at?", requests[0].Data["prompt"], "the last user message is the prompt")
+	assert.Nil(t, requests[0].Metrics, "prompt tokens come from the reported usage")
+	assert.Equal(t, "Eviction is FIFO by insert time so the newest events survive.", responses[0].Data["response"])
+	assert.Equal(t, &types.EventMetrics{PromptTokens: 412, ResponseTokens: 38, TokenCount: 450, DurationMs: 1840}, responses[0].Metrics)
+	assert.Equal(t, "chatcmpl-9a1", requests[0].Data["requestId"])
+	assert.Equal(t, requests[0].Data["requestId"], responses[0].Data["requestId"])
+	assert.Equal(t, "gpt-4o", requests[0].Data["model"])
+	assert.Equal(t, "gpt-4o", responses[0].Context["model"])
+	assert.Equal(t, "team-gw-42", requests[0].SessionID)
+	assert.Equal(t, time.Date(2025, 11, 3, 9, 15, 2, 120_000_000, time.UTC), requests[0].Timestamp.UTC())
+	assert.Equal(t, requests[0].Timestamp.Add(1840*time.Millisecond), responses[0].Timestamp, "the response arrives after the latency")
+
+	// Anthropic message: model from the body, usage inside the response,
+	// Unix timestamp and latency as a string
+	assert.Equal(t, "Write a test for Retrieve ordering", requests[1].Data["prompt"])
+	assert.Equal(t, "Here is a table-driven test.", responses[1].Data["response"])
+	assert.Equal(t, "claude-sonnet-4-5", responses[1].Data["model"])
+	assert.Equal(t, &types.EventMetrics{PromptTokens: 980, ResponseTokens: 215, TokenCount: 1195, DurationMs: 2210}, responses[1].Metrics)
+	assert.Equal(t, int64(1762161330), requests[1].Timestamp.Unix())
+
+	// Plain strings without usage fall back to estimates and the file name
+	assert.Equal(t, "Summarize the diff", requests[2].Data["prompt"])
+	assert.Equal(t, "It adds an audit log.", responses[2].Data["response"])
+	assert.Equal(t, "proxy-requests", requests[2].SessionID)
+	assert.Regexp(t, `^proxy-requests-[0-9a-f]{16}$`, requests[2].Data["requestId"], "named after the call's content")
+	require.NotNil(t, requests[2].Metrics)
+	assert.Positive(t, requests[2].Metrics.PromptTokens)
+	assert.Positive(t, responses[2].Metrics.ResponseTokens)
+	assert.Zero(t, responses[2].Metrics.TokenCount)
+
+	for _, event := range events {
+		assert.Equal(t, "proxy", event.AgentID)
+	}
+}
+
+func TestProxyLogAdapter_ParseLogFileInRange(t *testing.T) {
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+
+	from := time.Date(2025, 11, 3, 9, 16, 0, 0, time.UTC)
+	path := filepath.Join("testdata", "proxy-requests.jsonl")
+	all, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+	events, err := adapter.ParseLogFileInRange(path, from, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "Summarize the diff", events[0].Data["prompt"])
+	assert.Equal(t, all[4].Data["requestId"], events[0].Data["requestId"], "request IDs don't shift with the range")
+}
+
+func TestProxyLogAdapter_ParsesLineByLine(t *testing.T) {
+	path := filepath.Join("testdata", "proxy-requests.jsonl")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+	whole, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+
+	var lines []*types.AgentEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		events, err := ParseLine(adapter, path, line)
+		require.NoError(t, err)
+		lines = append(lines, events...)
+	}
+
+	// Tailing the log emits what parsing it whole does
+	require.Len(t, lines, len(whole))
+	for i := range whole {
+		assert.Equal(t, buffer.EventHash(whole[i]), buffer.EventHash(lines[i]), "event %d", i)
+	}
+}
+
+func TestProxyLogAdapter_CallsWithoutTimeAreStable(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "gateway.jsonl")
+	content := `{"timestamp":"2025-11-03T09:20:00Z","request":"Summarize the diff","response":"It adds an audit log.","model":"gpt-4o-mini"}
+{"request":"And the tests?","response":"They cover rotation.","model":"gpt-4o-mini"}
+`
+	require.NoError(t, os.WriteFile(logFile, []byte(content), 0644))
+
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+	first, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	require.Len(t, first, 4)
+	assert.Equal(t, first[0].Timestamp.Add(time.Millisecond), first[2].Timestamp, "placed after the call before it")
+
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(logFile, later, later))
+	again, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, buffer.EventHash(first[2]), buffer.EventHash(again[2]))
+}
+
+func TestProxyLogAdapter_CustomFields(t *testing.T) {
+	// A LiteLLM-style payload: messages at the top level, metadata nested
+	logFile := filepath.Join(t.TempDir(), "litellm.jsonl")
+	content := `{"litellm_call_id":"c-1","startTime":"2025-11-03 10:00:00.250000","model":"azure/gpt-4o","messages":[{"role":"user","content":"Rename the helper"}],"response":{"choices":[{"message":{"content":"Renamed."}}]},"usage_object":{"prompt_tokens":12,"completion_tokens":3},"response_time_ms":310,"metadata":{"user_api_key_alias":"alice-laptop","session":"s-77"}}
+`
+	require.NoError(t, os.WriteFile(logFile, []byte(content), 0644))
+
+	fields, err := ParseProxyFields(map[string]string{
+		"request":   "messages",
+		"usage":     "usage_object",
+		"latency":   "response_time_ms",
+		"timestamp": "startTime",
+		"session":   "metadata.session",
+		"requestId": "litellm_call_id",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "response", fields.Response, "unset fields keep their default")
+
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+	adapter.SetFields(fields)
+	events, err := adapter.ParseLogFile(logFile)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "Rename the helper", events[0].Data["prompt"])
+	assert.Equal(t, "c-1", events[0].Data["requestId"])
+	assert.Equal(t, "s-77", events[0].SessionID)
+	assert.Equal(t, time.Date(2025, 11, 3, 10, 0, 0, 250_000_000, time.UTC), events[0].Timestamp)
+	assert.Equal(t, "Renamed.", events[1].Data["response"])
+	assert.Equal(t, &types.EventMetrics{PromptTokens: 12, ResponseTokens: 3, TokenCount: 15, DurationMs: 310}, events[1].Metrics)
+}
+
+func TestParseProxyFields_Errors(t *testing.T) {
+	_, err := ParseProxyFields(map[string]string{"prompt": "messages"})
+	assert.ErrorContains(t, err, `unknown proxy log field "prompt"`)
+
+	_, err = ParseProxyFields(map[string]string{"response": ""})
+	assert.Error(t, err)
+}
+
+func TestProxyLogAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+
+	log, err := os.ReadFile(filepath.Join("testdata", "proxy-requests.jsonl"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"proxy log", string(log), true},
+		{"strings with latency", `{"request":"hi","response":"hello","latency_ms":20}`, true},
+		{"no response", `{"request":"hi","model":"gpt-4o"}`, false},
+		{"request and response only", `{"request":"hi","response":"hello"}`, false},
+		{"claude log", `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"c","prompt":"hi","response":"hello","model":"claude"}`, false},
+		{"gemini message", `{"role":"user","parts":[{"text":"hi"}]}`, false},
+		{"not json", `hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, adapter.SupportsFormat(tt.sample))
+		})
+	}
+}
+
+func TestDefaultRegistry_DetectsProxyLog(t *testing.T) {
+	log, err := os.ReadFile(filepath.Join("testdata", "proxy-requests.jsonl"))
+	require.NoError(t, err)
+
+	registry := DefaultRegistry("test-project", nil, nil)
+	detected, err := registry.DetectAdapter(string(log))
+	require.NoError(t, err)
+	assert.Equal(t, "proxy", detected.Name())
+	assert.False(t, ParsesWholeFile(detected, "requests.jsonl"), "logs are appended to and tailed")
+}
@@ -0,0 +1,30 @@
+package adapters
+
+import (
+	"github.com/codervisor/devlog/internal/redact"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// redactedFields are the event data fields that carry free text captured
+// from prompts, responses and tool calls
+var redactedFields = []string{
+	"prompt",
+	"response",
+	"message",
+	"toolInput",
+	"toolOutput",
+	"toolArgs",
+	"invocationMessage",
+	"result",
+}
+
+// redactEvents masks secrets in the free-text fields of each event
+func redactEvents(events []*types.AgentEvent, redactor *redact.Redactor) {
+	for _, event := range events {
+		for _, field := range redactedFields {
+			if value, ok := event.Data[field]; ok {
+				event.Data[field] = redactor.Value(value)
+			}
+		}
+	}
+}
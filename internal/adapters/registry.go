@@ -1,7 +1,9 @@
 package adapters
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/codervisor/devlog/internal/hierarchy"
@@ -61,22 +63,137 @@ func (r *Registry) List() []string {
 	return names
 }
 
-// DetectAdapter tries to detect which adapter to use for a log sample
+// DetectAdapter returns the adapter most confident that a log sample is in
+// its format (see ConfidenceScorer). Ties go to the adapter whose name sorts
+// first, so the same sample always gets the same adapter.
 func (r *Registry) DetectAdapter(sample string) (AgentAdapter, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	names := make([]string, 0, len(r.adapters))
+	for name := range r.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var best AgentAdapter
+	var bestScore float64
+	for _, name := range names {
+		if score := confidence(r.adapters[name], sample); score > bestScore {
+			best, bestScore = r.adapters[name], score
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no adapter found for log format")
+	}
+
+	return best, nil
+}
+
+// Configure applies options to every registered adapter that supports them
+func (r *Registry) Configure(opts Options) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, adapter := range r.adapters {
-		if adapter.SupportsFormat(sample) {
-			return adapter, nil
+		if configurable, ok := adapter.(interface{ SetOptions(Options) }); ok {
+			configurable.SetOptions(opts)
 		}
 	}
+}
+
+// SetContext sets the caller's context on every registered adapter that
+// makes backend lookups while parsing
+func (r *Registry) SetContext(ctx context.Context) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, adapter := range r.adapters {
+		if bound, ok := adapter.(interface{ SetContext(context.Context) }); ok {
+			bound.SetContext(ctx)
+		}
+	}
+}
+
+// ConfigureAdapter applies options to a single adapter, overriding what
+// Configure set for it
+func (r *Registry) ConfigureAdapter(name string, opts Options) error {
+	adapter, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+
+	configurable, ok := adapter.(interface{ SetOptions(Options) })
+	if !ok {
+		return fmt.Errorf("adapter %s does not support options", name)
+	}
+
+	configurable.SetOptions(opts)
+	return nil
+}
+
+// Factory builds an adapter with the same arguments as the built-in
+// constructors. The adapter's Name must be the name it was registered under.
+type Factory func(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) AgentAdapter
+
+var (
+	factoriesMu sync.Mutex
+	factories   = make(map[string]Factory)
+)
 
-	return nil, fmt.Errorf("no adapter found for log format")
+// Register makes an adapter built outside this package available to
+// DefaultRegistry under name, typically from the init function of a package
+// compiled into the collector. It panics if factory is nil or name is
+// already registered, like database/sql.Register. Code that builds its own
+// registry with NewRegistry can call Registry.Register directly instead.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("adapters: Register factory is nil for " + name)
+	}
+	if _, exists := factories[name]; exists {
+		panic("adapters: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// registerFactories adds an adapter built by each registered factory, in
+// name order. Adapters that don't report their registered name or clash
+// with an adapter already in the registry are skipped.
+func (r *Registry) registerFactories(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) {
+	factoriesMu.Lock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	registered := make(map[string]Factory, len(factories))
+	for name, factory := range factories {
+		registered[name] = factory
+	}
+	factoriesMu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		adapter := registered[name](projectID, hierarchyCache, log)
+		if adapter == nil || adapter.Name() != name {
+			log.Warnf("Skipping adapter registered as %s: factory built no adapter of that name", name)
+			continue
+		}
+		if err := r.Register(adapter); err != nil {
+			log.Warnf("Skipping adapter registered as %s: %v", name, err)
+		}
+	}
 }
 
-// DefaultRegistry creates and populates a registry with all available adapters
+// DefaultRegistry creates and populates a registry with all available
+// adapters: the built-in ones, then those added with Register
 func DefaultRegistry(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *Registry {
+	if log == nil {
+		log = logrus.New()
+	}
+
 	registry := NewRegistry()
 
 	// Register Copilot adapter with hierarchy support
@@ -88,5 +205,25 @@ func DefaultRegistry(projectID string, hierarchyCache *hierarchy.HierarchyCache,
 	// Register Cursor adapter with hierarchy support
 	registry.Register(NewCursorAdapter(projectID, hierarchyCache, log))
 
+	// Register Neovim adapter (Avante/CodeCompanion chat histories)
+	registry.Register(NewNeovimAdapter(projectID, hierarchyCache, log))
+
+	// Register Continue adapter (~/.continue/sessions)
+	registry.Register(NewContinueAdapter(projectID, hierarchyCache, log))
+
+	// Register JetBrains adapter (AI Assistant and Copilot chats)
+	registry.Register(NewJetBrainsAdapter(projectID, hierarchyCache, log))
+
+	// Register Gemini adapter (Gemini CLI and Code Assist conversation logs)
+	registry.Register(NewGeminiAdapter(projectID, hierarchyCache, log))
+
+	// Register Tabnine adapter (Tabnine Chat conversations)
+	registry.Register(NewTabnineAdapter(projectID, hierarchyCache, log))
+
+	// Register proxy adapter (LLM gateway request logs, e.g. LiteLLM)
+	registry.Register(NewProxyLogAdapter(projectID, hierarchyCache, log))
+
+	registry.registerFactories(projectID, hierarchyCache, log)
+
 	return registry
 }
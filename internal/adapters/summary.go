@@ -0,0 +1,303 @@
+package adapters
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// sessionRollup aggregates the events parsed for one session
+type sessionRollup struct {
+	first, last *types.AgentEvent
+	events      int
+	turns       int
+	ended       bool
+	file        string    // log the last event was parsed from
+	touched     time.Time // when an event was last added
+	metrics     types.EventMetrics
+	files       map[string]bool
+	models      ModelTally
+	seen        map[string]bool // content hashes of the events added
+}
+
+func newSessionRollup() *sessionRollup {
+	return &sessionRollup{
+		files:  make(map[string]bool),
+		models: ModelTally{Usage: make(map[string]ModelUsage)},
+		seen:   make(map[string]bool),
+	}
+}
+
+// add folds event into the rollup, unless it was added before
+func (r *sessionRollup) add(event *types.AgentEvent) {
+	hash := buffer.EventHash(event)
+	if r.seen[hash] {
+		return
+	}
+	r.seen[hash] = true
+
+	if r.first == nil || event.Timestamp.Before(r.first.Timestamp) {
+		r.first = event
+	}
+	if r.last == nil || !event.Timestamp.Before(r.last.Timestamp) {
+		r.last = event
+	}
+
+	r.events++
+	switch event.Type {
+	case types.EventTypeLLMRequest:
+		r.turns++
+	case types.EventTypeSessionEnd:
+		r.ended = true
+	}
+	r.models.Add(event)
+	if m := event.Metrics; m != nil {
+		r.metrics.TokenCount += eventTokens(m)
+		r.metrics.PromptTokens += m.PromptTokens
+		r.metrics.ResponseTokens += m.ResponseTokens
+		r.metrics.Cost += m.Cost
+	}
+
+	switch event.Type {
+	case types.EventTypeFileRead, types.EventTypeFileWrite, types.EventTypeFileModify:
+		if path, ok := event.Data["filePath"].(string); ok && path != "" {
+			r.files[path] = true
+		}
+	}
+}
+
+// ModelUsage counts the turns and tokens attributed to one model
+type ModelUsage struct {
+	Turns  int `json:"turns"`
+	Tokens int `json:"tokens"`
+}
+
+// UnknownModel is the model LLM events that don't name one are counted under
+const UnknownModel = "unknown"
+
+// ModelTally counts the turns and tokens of LLM events per model. Copilot
+// names the model on requests only, so an event without one is counted
+// under the model of the earlier event with the same requestId.
+type ModelTally struct {
+	Usage    map[string]ModelUsage
+	requests map[string]string // requestId -> model
+}
+
+// Add counts event if it is an LLM request, response or cancellation
+func (t *ModelTally) Add(event *types.AgentEvent) {
+	switch event.Type {
+	case types.EventTypeLLMRequest, types.EventTypeLLMResponse, types.EventTypeLLMCanceled:
+	default:
+		return
+	}
+	if t.Usage == nil {
+		t.Usage = make(map[string]ModelUsage)
+	}
+	if t.requests == nil {
+		t.requests = make(map[string]string)
+	}
+
+	requestID, _ := event.Data["requestId"].(string)
+	model := eventModel(event)
+	if model == "" {
+		model = t.requests[requestID]
+	} else if requestID != "" {
+		t.requests[requestID] = model
+	}
+	if model == "" {
+		model = UnknownModel
+	}
+
+	usage := t.Usage[model]
+	if event.Type == types.EventTypeLLMRequest {
+		usage.Turns++
+	}
+	usage.Tokens += eventTokens(event.Metrics)
+	t.Usage[model] = usage
+}
+
+// eventModel returns the model an event names, under the keys adapters use
+func eventModel(event *types.AgentEvent) string {
+	for _, key := range []string{"modelId", "model"} {
+		if model, ok := event.Data[key].(string); ok && model != "" {
+			return model
+		}
+	}
+	return ""
+}
+
+// summaryIdleTimeout is how long a session goes without events before it
+// is taken to have ended and its summary is emitted
+const summaryIdleTimeout = 30 * time.Minute
+
+// maxSummarizedSessions bounds the sessions remembered as summarized. Past
+// it the session summarized first is forgotten, and events of it seen again
+// start a new rollup.
+const maxSummarizedSessions = 1000
+
+// sessionSummarizer rolls the events an adapter parses up per session and
+// emits a session_summary once per session: after its session_end event, or
+// once it has gone summaryIdleTimeout without events. A session is known to
+// have gone idle when its log was read to the end and its last event is
+// that old, or when nothing was added to it for that long. Events are told
+// apart by content hash, so a log parsed again, whole or from where it was
+// left, isn't counted twice.
+type sessionSummarizer struct {
+	mu        sync.Mutex
+	open      map[string]*sessionRollup // session ID -> rollup of a session not summarized yet
+	done      map[string]bool           // sessions summarized
+	doneOrder []string                  // sessions in done, oldest first
+	now       func() time.Time
+}
+
+func newSessionSummarizer() *sessionSummarizer {
+	return &sessionSummarizer{
+		open: make(map[string]*sessionRollup),
+		done: make(map[string]bool),
+		now:  time.Now,
+	}
+}
+
+// summarize folds the events parsed from filePath into the rollups of
+// their sessions and returns them with the summaries that are due: those of
+// sessions that ended, or went idle if the events run to the end of the log
+// (atEnd), after the session's last event, and those of sessions idle since
+// an earlier call at the end. Budget alerts are skipped since they repeat
+// the session's totals.
+func (s *sessionSummarizer) summarize(filePath string, events []*types.AgentEvent, atEnd bool) []*types.AgentEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	lastIndex := make(map[string]int)
+	for i, event := range events {
+		if event.SessionID == "" || s.done[event.SessionID] {
+			continue
+		}
+		lastIndex[event.SessionID] = i
+		if event.Type == types.EventTypeBudgetExceeded || event.Type == types.EventTypeSessionSummary {
+			continue
+		}
+		r, ok := s.open[event.SessionID]
+		if !ok {
+			r = newSessionRollup()
+			s.open[event.SessionID] = r
+		}
+		r.add(event)
+		r.file = filePath
+		r.touched = now
+	}
+
+	result := make([]*types.AgentEvent, 0, len(events))
+	for i, event := range events {
+		result = append(result, event)
+
+		if last, ok := lastIndex[event.SessionID]; !ok || last != i {
+			continue
+		}
+		if r, ok := s.open[event.SessionID]; ok && (r.ended || atEnd && s.idle(r, now)) {
+			result = append(result, sessionSummary(r))
+			s.finish(event.SessionID)
+		}
+	}
+
+	return append(result, s.due(now, func(r *sessionRollup) bool {
+		return now.Sub(r.touched) >= summaryIdleTimeout
+	})...)
+}
+
+// endOfLog returns the summaries of the sessions last seen in filePath that
+// went idle, now that the log was read to its end
+func (s *sessionSummarizer) endOfLog(filePath string) []*types.AgentEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.due(s.now(), func(r *sessionRollup) bool {
+		return r.file == filePath
+	})
+}
+
+// due summarizes the open sessions that are idle and match, ordered by
+// session ID
+func (s *sessionSummarizer) due(now time.Time, match func(r *sessionRollup) bool) []*types.AgentEvent {
+	var idle []string
+	for sessionID, r := range s.open {
+		if s.idle(r, now) && match(r) {
+			idle = append(idle, sessionID)
+		}
+	}
+	sort.Strings(idle)
+
+	summaries := make([]*types.AgentEvent, 0, len(idle))
+	for _, sessionID := range idle {
+		summaries = append(summaries, sessionSummary(s.open[sessionID]))
+		s.finish(sessionID)
+	}
+	return summaries
+}
+
+// idle reports whether a session's last event is summaryIdleTimeout old
+func (s *sessionSummarizer) idle(r *sessionRollup, now time.Time) bool {
+	return now.Sub(r.last.Timestamp) >= summaryIdleTimeout
+}
+
+// finish drops the rollup of a summarized session and remembers it as
+// summarized
+func (s *sessionSummarizer) finish(sessionID string) {
+	delete(s.open, sessionID)
+	if len(s.doneOrder) >= maxSummarizedSessions {
+		delete(s.done, s.doneOrder[0])
+		s.doneOrder = s.doneOrder[1:]
+	}
+	s.done[sessionID] = true
+	s.doneOrder = append(s.doneOrder, sessionID)
+}
+
+// sessionSummary builds the summary event for a session, modeled on its
+// last event
+func sessionSummary(r *sessionRollup) *types.AgentEvent {
+	last := r.last
+	duration := r.last.Timestamp.Sub(r.first.Timestamp).Milliseconds()
+
+	files := make([]string, 0, len(r.files))
+	for path := range r.files {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+
+	return &types.AgentEvent{
+		ID:              uuid.New().String(),
+		Timestamp:       last.Timestamp,
+		Type:            types.EventTypeSessionSummary,
+		AgentID:         last.AgentID,
+		AgentVersion:    last.AgentVersion,
+		SessionID:       last.SessionID,
+		ProjectID:       last.ProjectID,
+		MachineID:       last.MachineID,
+		WorkspaceID:     last.WorkspaceID,
+		LegacyProjectID: last.LegacyProjectID,
+		Context:         copyContext(last.Context),
+		Data: map[string]interface{}{
+			"startTime":    r.first.Timestamp,
+			"endTime":      last.Timestamp,
+			"eventCount":   r.events,
+			"turns":        r.turns,
+			"totalTokens":  r.metrics.TokenCount,
+			"totalCost":    r.metrics.Cost,
+			"filesTouched": files,
+			"models":       r.models.Usage,
+		},
+		Metrics: &types.EventMetrics{
+			TokenCount:     r.metrics.TokenCount,
+			PromptTokens:   r.metrics.PromptTokens,
+			ResponseTokens: r.metrics.ResponseTokens,
+			Cost:           r.metrics.Cost,
+			DurationMs:     duration,
+		},
+	}
+}
@@ -0,0 +1,260 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertSummaryTotals checks each session_summary against the sum of the
+// events of its session
+func assertSummaryTotals(t *testing.T, events []*types.AgentEvent) map[string]*types.AgentEvent {
+	t.Helper()
+
+	type totals struct {
+		events, turns, tokens int
+		files                 map[string]bool
+	}
+	sums := make(map[string]*totals)
+	summaries := make(map[string]*types.AgentEvent)
+	for _, event := range events {
+		if event.Type == types.EventTypeSessionSummary {
+			summaries[event.SessionID] = event
+			continue
+		}
+		s, ok := sums[event.SessionID]
+		if !ok {
+			s = &totals{files: make(map[string]bool)}
+			sums[event.SessionID] = s
+		}
+		s.events++
+		s.tokens += eventTokens(event.Metrics)
+		if event.Type == types.EventTypeLLMRequest {
+			s.turns++
+		}
+		if path, ok := event.Data["filePath"].(string); ok && strings.HasPrefix(event.Type, "file_") {
+			s.files[path] = true
+		}
+	}
+
+	require.Len(t, summaries, len(sums), "one summary per session")
+	for sessionID, s := range sums {
+		summary := summaries[sessionID]
+		require.NotNil(t, summary, "session %s has a summary", sessionID)
+		assert.Equal(t, s.events, summary.Data["eventCount"], sessionID)
+		assert.Equal(t, s.turns, summary.Data["turns"], sessionID)
+		assert.Equal(t, s.tokens, summary.Data["totalTokens"], sessionID)
+		assert.Equal(t, s.tokens, summary.Metrics.TokenCount, sessionID)
+		assert.Len(t, summary.Data["filesTouched"], len(s.files), sessionID)
+	}
+	return summaries
+}
+
+func TestClaudeAdapter_SessionSummaries(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_a","prompt":"Read main.go","tokens_used":100}`,
+		`{"timestamp":"2025-10-31T10:00:02Z","type":"file_read","conversation_id":"conv_a","file_path":"/src/main.go"}`,
+		`{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_a","response":"Done","tokens_used":250}`,
+		`{"timestamp":"2025-10-31T10:01:00Z","type":"llm_request","conversation_id":"conv_b","prompt":"Fix a typo","tokens_used":50}`,
+		`{"timestamp":"2025-10-31T10:02:00Z","type":"llm_request","conversation_id":"conv_a","prompt":"Now the tests","tokens_used":80}`,
+		`{"timestamp":"2025-10-31T10:02:30Z","type":"file_write","conversation_id":"conv_a","file_path":"/src/main_test.go"}`,
+		`{"timestamp":"2025-10-31T10:03:00Z","type":"llm_response","conversation_id":"conv_a","response":"Added","tokens_used":120}`,
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionSummaries: true})
+
+	events, err := adapter.ParseLogFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, len(lines)+3, "a session start for conv_b and two summaries")
+
+	summaries := assertSummaryTotals(t, events)
+
+	a := summaries["conv_a"]
+	assert.Equal(t, 2, a.Data["turns"])
+	assert.Equal(t, 550, a.Data["totalTokens"])
+	assert.Equal(t, []string{"/src/main.go", "/src/main_test.go"}, a.Data["filesTouched"])
+	assert.Equal(t, (3 * time.Minute).Milliseconds(), a.Metrics.DurationMs)
+	assert.Same(t, a, events[len(events)-1], "summary follows the session's last event")
+
+	b := summaries["conv_b"]
+	assert.Equal(t, 1, b.Data["turns"])
+	assert.Equal(t, int64(0), b.Metrics.DurationMs)
+	assert.Same(t, b, events[5], "summary follows the session's only event")
+}
+
+func TestCopilotAdapter_SessionSummaries(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionSummaries: true})
+
+	events, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+
+	summaries := assertSummaryTotals(t, events)
+	require.Len(t, summaries, 1)
+	for _, summary := range summaries {
+		assert.Same(t, summary, events[len(events)-1])
+		assert.Greater(t, summary.Data["turns"], 0)
+	}
+}
+
+func TestSessionSummaries_Disabled(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	assert.Empty(t, eventsOfType(events, types.EventTypeSessionSummary))
+}
+
+func TestSessionSummarizer_SkipsBudgetAlerts(t *testing.T) {
+	events := []*types.AgentEvent{
+		{SessionID: "s1", Type: types.EventTypeLLMRequest, Metrics: &types.EventMetrics{TokenCount: 700}},
+		{SessionID: "s1", Type: types.EventTypeLLMResponse, Metrics: &types.EventMetrics{TokenCount: 600}},
+	}
+	events = applySessionBudgets(events, SessionBudget{MaxTokens: 1000, Alert: true})
+	require.Len(t, events, 3)
+
+	result := newSessionSummarizer().summarize("session.jsonl", events, true)
+	require.Len(t, result, 4)
+	summary := result[3]
+	assert.Equal(t, types.EventTypeSessionSummary, summary.Type)
+	assert.Equal(t, 1300, summary.Data["totalTokens"], "the alert's totals aren't counted twice")
+	assert.Equal(t, 2, summary.Data["eventCount"])
+}
+
+func TestSessionSummarizer_SummarizesOnce(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionSummaries: true})
+
+	events, err := adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	require.Len(t, eventsOfType(events, types.EventTypeSessionSummary), 1)
+
+	events, err = adapter.ParseLogFile("testdata/copilot-text-edit-group.json")
+	require.NoError(t, err)
+	assert.Empty(t, eventsOfType(events, types.EventTypeSessionSummary), "the session was summarized already")
+}
+
+func TestSessionSummarizer_WaitsForEndOrIdle(t *testing.T) {
+	start := time.Date(2025, 10, 31, 10, 0, 0, 0, time.UTC)
+	now := start.Add(5 * time.Minute)
+	s := newSessionSummarizer()
+	s.now = func() time.Time { return now }
+
+	events := []*types.AgentEvent{
+		{SessionID: "active", Type: types.EventTypeLLMRequest, Timestamp: start},
+		{SessionID: "ended", Type: types.EventTypeLLMRequest, Timestamp: start},
+		{SessionID: "ended", Type: types.EventTypeSessionEnd, Timestamp: start.Add(time.Minute)},
+	}
+	result := s.summarize("session.jsonl", events, true)
+	require.Len(t, result, 4)
+	assert.Equal(t, types.EventTypeSessionSummary, result[3].Type)
+	assert.Equal(t, "ended", result[3].SessionID)
+
+	result = s.summarize("session.jsonl", []*types.AgentEvent{
+		{SessionID: "active", Type: types.EventTypeLLMResponse, Timestamp: start.Add(2 * time.Minute)},
+	}, true)
+	assert.Len(t, result, 1, "the session is still active")
+
+	now = now.Add(summaryIdleTimeout)
+	result = s.summarize("other.jsonl", nil, true)
+	require.Len(t, result, 1)
+	assert.Equal(t, "active", result[0].SessionID)
+	assert.Equal(t, 2, result[0].Data["eventCount"])
+
+	result = s.summarize("session.jsonl", events, true)
+	assert.Empty(t, eventsOfType(result, types.EventTypeSessionSummary), "sessions are summarized once")
+}
+
+func TestClaudeAdapter_SessionSummariesFromLines(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_a","prompt":"Read main.go","tokens_used":100}`,
+		`{"timestamp":"2025-10-31T10:00:05Z","type":"llm_response","conversation_id":"conv_a","response":"Done","tokens_used":250}`,
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionSummaries: true})
+
+	var events []*types.AgentEvent
+	for _, line := range lines {
+		parsed, err := ParseLine(adapter, path, line)
+		require.NoError(t, err)
+		events = append(events, parsed...)
+	}
+	assert.Empty(t, eventsOfType(events, types.EventTypeSessionSummary), "the log may go on")
+	assert.Empty(t, EndOfLog(adapter, "other.jsonl"))
+
+	events = append(events, EndOfLog(adapter, path)...)
+	summaries := assertSummaryTotals(t, events)
+	assert.Equal(t, 350, summaries["conv_a"].Data["totalTokens"])
+
+	assert.Empty(t, EndOfLog(adapter, path), "the session was summarized already")
+}
+
+func TestCopilotAdapter_SessionSummaryModels(t *testing.T) {
+	adapter := NewCopilotAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{SessionSummaries: true})
+
+	events, err := adapter.ParseLogFile("testdata/copilot-multi-model.json")
+	require.NoError(t, err)
+
+	summaries := assertSummaryTotals(t, events)
+	require.Len(t, summaries, 1)
+	var summary *types.AgentEvent
+	for _, s := range summaries {
+		summary = s
+	}
+
+	// Responses count under the model of their request
+	tokens := make(map[string]int)
+	requestModels := make(map[string]string)
+	for _, event := range events {
+		if event.Type == types.EventTypeLLMRequest {
+			model := event.Data["modelId"].(string)
+			if model == "" {
+				model = UnknownModel
+			}
+			requestModels[event.Data["requestId"].(string)] = model
+		}
+		requestID, _ := event.Data["requestId"].(string)
+		switch event.Type {
+		case types.EventTypeLLMRequest, types.EventTypeLLMResponse:
+			tokens[requestModels[requestID]] += eventTokens(event.Metrics)
+		}
+	}
+
+	models := summary.Data["models"].(map[string]ModelUsage)
+	assert.Equal(t, map[string]ModelUsage{
+		"copilot/gpt-4o":            {Turns: 2, Tokens: tokens["copilot/gpt-4o"]},
+		"copilot/claude-sonnet-4.5": {Turns: 1, Tokens: tokens["copilot/claude-sonnet-4.5"]},
+		UnknownModel:                {Turns: 1, Tokens: tokens[UnknownModel]},
+	}, models)
+	assert.Greater(t, models["copilot/gpt-4o"].Tokens, 0)
+
+	total := 0
+	for _, usage := range models {
+		total += usage.Tokens
+	}
+	assert.Equal(t, summary.Data["totalTokens"], total, "every token of the session is attributed to a model")
+}
+
+func TestModelTally_EventsWithoutRequest(t *testing.T) {
+	var tally ModelTally
+	tally.Add(&types.AgentEvent{Type: types.EventTypeLLMRequest, Data: map[string]interface{}{"model": "claude-opus"}, Metrics: &types.EventMetrics{TokenCount: 10}})
+	tally.Add(&types.AgentEvent{Type: types.EventTypeLLMResponse, Data: map[string]interface{}{}, Metrics: &types.EventMetrics{TokenCount: 5}})
+	tally.Add(&types.AgentEvent{Type: types.EventTypeFileRead, Data: map[string]interface{}{"modelId": "gpt-4o"}, Metrics: &types.EventMetrics{TokenCount: 99}})
+
+	assert.Equal(t, map[string]ModelUsage{
+		"claude-opus": {Turns: 1, Tokens: 10},
+		UnknownModel:  {Tokens: 5},
+	}, tally.Usage)
+}
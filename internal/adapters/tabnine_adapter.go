@@ -0,0 +1,231 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// TabnineAdapter parses conversations saved by Tabnine Chat
+// (~/.tabnine/chat/conversations/*.json). The IDE plugins write one JSON
+// document per conversation and rewrite it as the conversation grows.
+type TabnineAdapter struct {
+	*BaseAdapter
+	hierarchy *hierarchy.HierarchyCache
+	log       *logrus.Logger
+}
+
+// NewTabnineAdapter creates a new Tabnine adapter
+func NewTabnineAdapter(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) *TabnineAdapter {
+	if log == nil {
+		log = logrus.New()
+	}
+	return &TabnineAdapter{
+		BaseAdapter: NewBaseAdapter("tabnine", projectID, log),
+		hierarchy:   hierarchyCache,
+		log:         log,
+	}
+}
+
+// TabnineConversation is a saved conversation. WorkspaceFolder is the folder
+// open in the IDE when the conversation started.
+type TabnineConversation struct {
+	ConversationID  string           `json:"conversationId"`
+	Title           string           `json:"title,omitempty"`
+	IDE             string           `json:"ide,omitempty"`
+	WorkspaceFolder string           `json:"workspaceFolder,omitempty"`
+	CreatedAt       interface{}      `json:"createdAt,omitempty"`
+	Messages        []TabnineMessage `json:"messages"`
+}
+
+// TabnineMessage is one conversation message. Authors are "user" and "chat";
+// timestamps are Unix milliseconds or RFC3339 strings. Usage is only present
+// on answers from models that report it.
+type TabnineMessage struct {
+	ID           string        `json:"id,omitempty"`
+	Author       string        `json:"author"`
+	Text         string        `json:"text,omitempty"`
+	Timestamp    interface{}   `json:"timestamp,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	Intent       string        `json:"intent,omitempty"`
+	ContextFiles []string      `json:"contextFiles,omitempty"`
+	Usage        *TabnineUsage `json:"usage,omitempty"`
+}
+
+// TabnineUsage is the token usage reported for an answer
+type TabnineUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens,omitempty"`
+}
+
+// tabnineAuthors are the message authors a Tabnine conversation may contain
+var tabnineAuthors = map[string]bool{
+	"user": true,
+	"chat": true,
+}
+
+// ParseLogLine is not supported; conversations are JSON documents rewritten
+// in place
+func (a *TabnineAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	return nil, fmt.Errorf("line-based parsing not supported for Tabnine conversations")
+}
+
+// ParseLogFile parses a Tabnine Chat conversation file
+func (a *TabnineAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	data, err := readLogFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation file: %w", err)
+	}
+
+	var conversation TabnineConversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation JSON: %w", err)
+	}
+
+	sessionID := conversation.ConversationID
+	if sessionID == "" {
+		sessionID = trimLogExt(filepath.Base(filePath))
+	}
+
+	root := ""
+	if conversation.WorkspaceFolder != "" {
+		root = projectFolderRoot(fileURIToPath(conversation.WorkspaceFolder))
+	}
+	a.setWorkspaceRoot(filePath, root)
+	hierarchyCtx := resolveRootHierarchy(a.ctx, a.hierarchy, a.log, root)
+
+	events := a.parseConversation(&conversation, sessionID, root)
+	if hierarchyCtx != nil {
+		for _, event := range events {
+			setHierarchy(event, hierarchyCtx)
+		}
+	}
+	return a.postProcess(filePath, hierarchyCtx, events), nil
+}
+
+// parseConversation converts the conversation messages into events
+func (a *TabnineAdapter) parseConversation(conversation *TabnineConversation, sessionID, root string) []*types.AgentEvent {
+	context := map[string]interface{}{}
+	if root != "" {
+		context["workspacePath"] = root
+		if info, err := a.gitCache.Get(root); err == nil {
+			if info.Branch != "" {
+				context["gitBranch"] = info.Branch
+			}
+			context["gitCommit"] = info.Commit
+		}
+	}
+	if conversation.Title != "" {
+		context["title"] = conversation.Title
+	}
+	if conversation.IDE != "" {
+		context["ide"] = conversation.IDE
+	}
+
+	// Messages without a timestamp are placed a millisecond apart after the
+	// last one that has one, or the conversation's creation, so they keep
+	// the same times each time the conversation is parsed
+	last := parseJetBrainsTimestamp(conversation.CreatedAt, time.Time{})
+	untimed := 0
+
+	var events []*types.AgentEvent
+	turn := 0
+	requestID := ""
+	for _, msg := range conversation.Messages {
+		timestamp := parseJetBrainsTimestamp(msg.Timestamp, time.Time{})
+		if timestamp.IsZero() {
+			untimed++
+			timestamp = sessionTimestamp(last, untimed)
+		} else {
+			last, untimed = timestamp, 0
+		}
+
+		switch msg.Author {
+		case "user":
+			turn++
+			requestID = msg.ID
+			if requestID == "" {
+				requestID = fmt.Sprintf("%s-%d", sessionID, turn)
+			}
+			data := map[string]interface{}{
+				"requestId":    requestID,
+				"prompt":       msg.Text,
+				"promptLength": len(msg.Text),
+			}
+			if msg.Intent != "" {
+				data["intent"] = msg.Intent
+			}
+			event := a.newEvent(types.EventTypeLLMRequest, sessionID, timestamp, context, data)
+			event.Metrics = &types.EventMetrics{PromptTokens: estimateTokens(msg.Text)}
+			events = append(events, event)
+
+			for _, path := range msg.ContextFiles {
+				if path == "" {
+					continue
+				}
+				events = append(events, a.newEvent(types.EventTypeFileRead, sessionID, timestamp, context, map[string]interface{}{
+					"requestId": requestID,
+					"filePath":  fileURIToPath(path),
+				}))
+			}
+
+		case "chat":
+			if msg.Text == "" {
+				break
+			}
+			eventContext := context
+			if msg.Model != "" {
+				eventContext = copyContext(context)
+				eventContext["model"] = msg.Model
+			}
+			event := a.newEvent(types.EventTypeLLMResponse, sessionID, timestamp, eventContext, map[string]interface{}{
+				"requestId":      requestID,
+				"response":       msg.Text,
+				"responseLength": len(msg.Text),
+			})
+			event.Metrics = tabnineMetrics(msg.Usage, msg.Text)
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// tabnineMetrics returns the token counts Tabnine reported for an answer, or
+// an estimate from the text when the conversation has none
+func tabnineMetrics(usage *TabnineUsage, text string) *types.EventMetrics {
+	if usage == nil {
+		return &types.EventMetrics{ResponseTokens: estimateTokens(text)}
+	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.InputTokens + usage.OutputTokens
+	}
+	return &types.EventMetrics{
+		PromptTokens:   usage.InputTokens,
+		ResponseTokens: usage.OutputTokens,
+		TokenCount:     total,
+	}
+}
+
+// SupportsFormat checks if this adapter can handle the given log format
+func (a *TabnineAdapter) SupportsFormat(sample string) bool {
+	var conversation TabnineConversation
+	if err := json.Unmarshal([]byte(sample), &conversation); err != nil {
+		return false
+	}
+	if conversation.ConversationID == "" || conversation.Messages == nil {
+		return false
+	}
+	for _, msg := range conversation.Messages {
+		if !tabnineAuthors[msg.Author] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabnineAdapter_ParseLogFile(t *testing.T) {
+	adapter := NewTabnineAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "tabnine-conversation.json"))
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	reads := eventsOfType(events, types.EventTypeFileRead)
+	require.Len(t, requests, 2)
+	require.Len(t, responses, 2)
+	require.Len(t, reads, 1)
+
+	assert.Equal(t, "How does the client back off between retries?", requests[0].Data["prompt"])
+	assert.Equal(t, "q-1", requests[0].Data["requestId"])
+	assert.Equal(t, "explain-code", requests[0].Data["intent"])
+	assert.Equal(t, "b7e4c1d2-3f5a-4e6b-9c8d-1a2b3c4d5e6f-2", requests[1].Data["requestId"])
+	assert.Equal(t, filepath.FromSlash("/home/dev/devlog/internal/client/client.go"), reads[0].Data["filePath"])
+
+	assert.Equal(t, "tabnine-protected", responses[0].Context["model"])
+	assert.Equal(t, "claude-3-5-sonnet", responses[1].Context["model"])
+	assert.Equal(t, requests[1].Data["requestId"], responses[1].Data["requestId"])
+
+	// A message without a timestamp follows the previous message's
+	assert.Equal(t, responses[0].Timestamp.Add(time.Millisecond), requests[1].Timestamp)
+	assert.Equal(t, int64(1741079720000), responses[1].Timestamp.UnixMilli())
+
+	for _, event := range events {
+		assert.Equal(t, "tabnine", event.AgentID)
+		assert.Equal(t, "b7e4c1d2-3f5a-4e6b-9c8d-1a2b3c4d5e6f", event.SessionID)
+		assert.Equal(t, filepath.FromSlash("/home/dev/devlog"), event.Context["workspacePath"])
+		assert.Equal(t, "vscode", event.Context["ide"])
+	}
+}
+
+func TestTabnineAdapter_TimestampsAreStable(t *testing.T) {
+	conversation := `{"conversationId":"c-1","createdAt":"2025-03-04T09:15:00Z","messages":[` +
+		`{"author":"user","text":"Hi"},{"author":"chat","text":"Hello"}]}`
+	file := filepath.Join(t.TempDir(), "c-1.json")
+	require.NoError(t, os.WriteFile(file, []byte(conversation), 0644))
+
+	adapter := NewTabnineAdapter("test-project", nil, nil)
+	first, err := adapter.ParseLogFile(file)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+
+	created := time.Date(2025, 3, 4, 9, 15, 0, 0, time.UTC)
+	assert.True(t, first[0].Timestamp.Equal(created.Add(time.Millisecond)))
+	assert.True(t, first[1].Timestamp.Equal(created.Add(2*time.Millisecond)))
+
+	// The plugin rewrites the file as the conversation grows; its mtime
+	// must not move the messages
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(file, later, later))
+	second, err := adapter.ParseLogFile(file)
+	require.NoError(t, err)
+	require.Len(t, second, 2)
+	for i := range first {
+		assert.True(t, first[i].Timestamp.Equal(second[i].Timestamp))
+	}
+}
+
+func TestTabnineAdapter_Metrics(t *testing.T) {
+	adapter := NewTabnineAdapter("test-project", nil, nil)
+
+	events, err := adapter.ParseLogFile(filepath.Join("testdata", "tabnine-conversation.json"))
+	require.NoError(t, err)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	require.Len(t, responses, 2)
+
+	// Reported usage is used as is
+	require.NotNil(t, responses[0].Metrics)
+	assert.Equal(t, 812, responses[0].Metrics.PromptTokens)
+	assert.Equal(t, 27, responses[0].Metrics.ResponseTokens)
+	assert.Equal(t, 839, responses[0].Metrics.TokenCount)
+
+	// Without usage the response tokens are estimated
+	require.NotNil(t, responses[1].Metrics)
+	assert.Equal(t, estimateTokens(responses[1].Data["response"].(string)), responses[1].Metrics.ResponseTokens)
+	assert.Zero(t, responses[1].Metrics.PromptTokens)
+
+	// A missing total is the sum of input and output
+	metrics := tabnineMetrics(&TabnineUsage{InputTokens: 10, OutputTokens: 5}, "")
+	assert.Equal(t, 15, metrics.TokenCount)
+}
+
+func TestTabnineAdapter_SupportsFormat(t *testing.T) {
+	adapter := NewTabnineAdapter("test-project", nil, nil)
+
+	conversation, err := os.ReadFile(filepath.Join("testdata", "tabnine-conversation.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"tabnine conversation", string(conversation), true},
+		{"empty conversation", `{"conversationId":"abc","messages":[]}`, true},
+		{"unknown author", `{"conversationId":"abc","messages":[{"author":"assistant","text":"hi"}]}`, false},
+		{"no conversation id", `{"messages":[{"author":"user","text":"hi"}]}`, false},
+		{"jetbrains chat", `{"id":"abc","ide":"GoLand2024.3","messages":[{"role":"user","text":"hi"}]}`, false},
+		{"not json", `hello`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, adapter.SupportsFormat(tt.sample))
+		})
+	}
+}
+
+func TestDefaultRegistry_DetectsTabnineConversation(t *testing.T) {
+	conversation, err := os.ReadFile(filepath.Join("testdata", "tabnine-conversation.json"))
+	require.NoError(t, err)
+
+	registry := DefaultRegistry("test-project", nil, nil)
+	detected, err := registry.DetectAdapter(string(conversation))
+	require.NoError(t, err)
+	assert.Equal(t, "tabnine", detected.Name())
+	assert.True(t, ParsesWholeFile(detected, "conversation.json"))
+}
@@ -0,0 +1,137 @@
+package adapters
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Tool categories group the tool names of different agents for analytics
+const (
+	ToolCategoryRead     = "read"
+	ToolCategoryEdit     = "edit"
+	ToolCategorySearch   = "search"
+	ToolCategoryTerminal = "terminal"
+	ToolCategoryWeb      = "web"
+	ToolCategoryOther    = "other"
+)
+
+// toolCategoryWords maps words of tool names to categories, checked in
+// order so e.g. run_in_terminal is a terminal tool rather than other
+var toolCategoryWords = []struct {
+	category string
+	words    []string
+}{
+	{ToolCategoryTerminal, []string{"terminal", "bash", "shell", "command", "exec"}},
+	{ToolCategoryEdit, []string{"edit", "multiedit", "write", "replace", "insert", "create", "patch", "apply", "rename", "delete"}},
+	{ToolCategoryWeb, []string{"web", "webpage", "fetch", "http", "url", "browser"}},
+	{ToolCategorySearch, []string{"search", "grep", "glob", "find", "list", "codebase", "usages"}},
+	{ToolCategoryRead, []string{"read", "view", "open", "cat", "get"}},
+}
+
+// toolCategory normalizes a tool name such as copilot_searchCodebase,
+// run_in_terminal or Read into one of the ToolCategory values
+func toolCategory(name string) string {
+	words := make(map[string]bool)
+	for _, word := range splitToolName(name) {
+		words[word] = true
+	}
+	for _, entry := range toolCategoryWords {
+		for _, word := range entry.words {
+			if words[word] {
+				return entry.category
+			}
+		}
+	}
+	return ToolCategoryOther
+}
+
+// splitToolName splits a snake, kebab or camel case tool name into
+// lowercase words
+func splitToolName(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+var (
+	// toolErrorMarkers matches the words in a tool's result that mean it
+	// failed
+	toolErrorMarkers = regexp.MustCompile(`(?i)\b(error|failed|failure|could not|couldn't|unable to|denied|exception)\b`)
+
+	// toolResultOperands matches what a result message quotes rather than
+	// says: code spans, quoted text, links and file names such as error.go
+	toolResultOperands = regexp.MustCompile("`[^`]*`|\"[^\"]*\"|\\[[^\\]]*\\]\\([^)]*\\)|\\S+[./\\\\]\\w\\S*")
+)
+
+// reportsToolError reports whether a tool's result message says it failed.
+// Markers count as whole words outside the operands the message quotes, so
+// reading errors.go or searching for "error" isn't a failure.
+func reportsToolError(result string) bool {
+	return toolErrorMarkers.MatchString(toolResultOperands.ReplaceAllString(result, " "))
+}
+
+// payloadSize returns the size in bytes of a tool input or output: the
+// length of a string, or of the JSON encoding of anything else
+func payloadSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case json.RawMessage:
+		return len(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// addToolOutcome records the analytics fields of a tool_use event: its
+// category, input and output sizes and, when known, whether it succeeded
+func addToolOutcome(data map[string]interface{}, toolName string, input, output interface{}, success *bool) {
+	data["toolCategory"] = toolCategory(toolName)
+	data["inputSize"] = payloadSize(input)
+	data["outputSize"] = payloadSize(output)
+	if success != nil {
+		data["success"] = *success
+	}
+}
+
+// toolSucceeded derives the outcome of a tool call from a line-based log
+// entry: an error means it failed, an output without one that it succeeded.
+// Entries with neither, such as the call before its result, return nil.
+func toolSucceeded(output, errValue interface{}) *bool {
+	var success bool
+	switch {
+	case errValue != nil && errValue != false && errValue != "":
+		success = false
+	case output != nil:
+		success = true
+	default:
+		return nil
+	}
+	return &success
+}
@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolCategory(t *testing.T) {
+	tests := map[string]string{
+		"copilot_readFile":        ToolCategoryRead,
+		"Read":                    ToolCategoryRead,
+		"copilot_replaceString":   ToolCategoryEdit,
+		"insert_edit_into_file":   ToolCategoryEdit,
+		"MultiEdit":               ToolCategoryEdit,
+		"run_in_terminal":         ToolCategoryTerminal,
+		"Bash":                    ToolCategoryTerminal,
+		"copilot_searchCodebase":  ToolCategorySearch,
+		"copilot_findTextInFiles": ToolCategorySearch,
+		"Glob":                    ToolCategorySearch,
+		"fetch_webpage":           ToolCategoryWeb,
+		"WebFetch":                ToolCategoryWeb,
+		"think":                   ToolCategoryOther,
+		"":                        ToolCategoryOther,
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, toolCategory(name), name)
+	}
+}
+
+func TestPayloadSize(t *testing.T) {
+	assert.Equal(t, 0, payloadSize(nil))
+	assert.Equal(t, 5, payloadSize("hello"))
+	assert.Equal(t, len(`{"a":1}`), payloadSize(map[string]interface{}{"a": 1}))
+}
+
+func TestReportsToolError(t *testing.T) {
+	failures := []string{
+		"Failed to read main.go",
+		"Edit failed.",
+		"Error: permission denied",
+		"Couldn't run `go test`",
+		"Unable to fetch the page",
+	}
+	for _, result := range failures {
+		assert.True(t, reportsToolError(result), result)
+	}
+
+	successes := []string{
+		"Read errors.go",
+		"Read [](file:///src/error.go)",
+		"Read error.go, lines 1 to 40",
+		`Searched text for "error", 12 results`,
+		"Searched text for `failed|exception`",
+		"Ran `grep -rn error .`",
+		"Found ErrorBoundary in app.tsx",
+	}
+	for _, result := range successes {
+		assert.False(t, reportsToolError(result), result)
+	}
+}
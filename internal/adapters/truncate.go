@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// truncateTextFields caps Data["prompt"] and Data["response"] at maxPrompt
+// and maxResponse characters (0 = unlimited). The length fields keep
+// describing the original text.
+func truncateTextFields(events []*types.AgentEvent, maxPrompt, maxResponse int) {
+	for _, event := range events {
+		truncateField(event.Data, "prompt", maxPrompt)
+		truncateField(event.Data, "response", maxResponse)
+	}
+}
+
+// truncateField cuts data[field] to limit characters followed by a
+// "…[truncated N chars]" marker, recording the original byte length in
+// data[field+"Length"] if the adapter didn't, and sets data[field+"Truncated"]
+func truncateField(data map[string]interface{}, field string, limit int) {
+	if limit <= 0 {
+		return
+	}
+	text, ok := data[field].(string)
+	if !ok {
+		return
+	}
+	chars := utf8.RuneCountInString(text)
+	if chars <= limit {
+		return
+	}
+
+	cut := 0
+	for i := 0; i < limit; i++ {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		cut += size
+	}
+
+	if _, ok := data[field+"Length"]; !ok {
+		data[field+"Length"] = len(text)
+	}
+	data[field] = fmt.Sprintf("%s…[truncated %d chars]", text[:cut], chars-limit)
+	data[field+"Truncated"] = true
+}
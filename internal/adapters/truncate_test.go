@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateTextFields(t *testing.T) {
+	prompt := "Explain " + strings.Repeat("é", 20)
+	response := strings.Repeat("r", 50)
+	events := []*types.AgentEvent{
+		{Type: types.EventTypeLLMRequest, Data: map[string]interface{}{"prompt": prompt, "promptLength": len(prompt)}},
+		{Type: types.EventTypeLLMResponse, Data: map[string]interface{}{"response": response}},
+		{Type: types.EventTypeLLMRequest, Data: map[string]interface{}{"prompt": "short"}},
+	}
+
+	truncateTextFields(events, 10, 20)
+
+	// Cut by characters, not bytes, so multi-byte text stays valid
+	assert.Equal(t, "Explain éé…[truncated 18 chars]", events[0].Data["prompt"])
+	assert.Equal(t, len(prompt), events[0].Data["promptLength"], "the length stays the original one")
+	assert.Equal(t, true, events[0].Data["promptTruncated"])
+
+	assert.Equal(t, strings.Repeat("r", 20)+"…[truncated 30 chars]", events[1].Data["response"])
+	assert.Equal(t, 50, events[1].Data["responseLength"], "a missing length is filled in with the original")
+	assert.Equal(t, true, events[1].Data["responseTruncated"])
+
+	assert.Equal(t, "short", events[2].Data["prompt"])
+	assert.NotContains(t, events[2].Data, "promptTruncated")
+	assert.NotContains(t, events[2].Data, "promptLength")
+}
+
+func TestTruncateTextFields_ZeroIsUnlimited(t *testing.T) {
+	prompt := strings.Repeat("p", 100)
+	events := []*types.AgentEvent{
+		{Type: types.EventTypeLLMRequest, Data: map[string]interface{}{"prompt": prompt, "response": prompt}},
+	}
+
+	truncateTextFields(events, 0, 10)
+
+	assert.Equal(t, prompt, events[0].Data["prompt"])
+	assert.Equal(t, strings.Repeat("p", 10)+"…[truncated 90 chars]", events[0].Data["response"])
+}
+
+func TestProxyLogAdapter_TruncatesTextFields(t *testing.T) {
+	prompt := strings.Repeat("why ", 100)
+	response := strings.Repeat("because ", 100)
+	line := `{"request":"` + prompt + `","response":"` + response + `","model":"gpt-4o"}`
+
+	testFile := filepath.Join(t.TempDir(), "gateway.jsonl")
+	require.NoError(t, os.WriteFile(testFile, []byte(line+"\n"), 0644))
+
+	adapter := NewProxyLogAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{MaxPromptChars: 16, MaxResponseChars: 8})
+
+	events, err := adapter.ParseLogFile(testFile)
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	responses := eventsOfType(events, types.EventTypeLLMResponse)
+	require.Len(t, requests, 1)
+	require.Len(t, responses, 1)
+
+	assert.Equal(t, "why why why why …[truncated 384 chars]", requests[0].Data["prompt"])
+	assert.Equal(t, len(prompt), requests[0].Data["promptLength"])
+	assert.Equal(t, "because …[truncated 792 chars]", responses[0].Data["response"])
+	assert.Equal(t, len(response), responses[0].Data["responseLength"])
+}
+
+func TestClaudeAdapter_SplitsThenTruncatesLines(t *testing.T) {
+	prompt := "Review this: " + strings.Repeat("x", 2500)
+	line := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","request_id":"req_1","prompt":"` + prompt + `"}`
+
+	adapter := NewClaudeAdapter("test-project", nil, nil)
+	adapter.SetOptions(Options{PromptSplitThreshold: 1000, MaxPromptChars: 12})
+
+	events, err := ParseLine(adapter, "claude.jsonl", line)
+	require.NoError(t, err)
+
+	requests := eventsOfType(events, types.EventTypeLLMRequest)
+	attachments := eventsOfType(events, types.EventTypeAttachment)
+	require.Len(t, requests, 1)
+	require.Len(t, attachments, 2, "the prompt is split before it's truncated")
+
+	request := requests[0]
+	assert.Equal(t, "Review this:…[truncated 988 chars]", request.Data["prompt"])
+	assert.Equal(t, true, request.Data["promptTruncated"])
+	assert.Equal(t, len(prompt), request.Data["promptLength"], "the length stays the whole prompt's")
+
+	overflow := ""
+	for _, attachment := range attachments {
+		overflow += attachment.Data["content"].(string)
+	}
+	assert.Equal(t, prompt[1000:], overflow, "the attachments keep the rest of the prompt")
+}
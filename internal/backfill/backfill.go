@@ -2,28 +2,41 @@ package backfill
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/codervisor/devlog/internal/adapters"
 	"github.com/codervisor/devlog/internal/buffer"
 	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/internal/dedup"
+	"github.com/codervisor/devlog/internal/watcher"
 	"github.com/codervisor/devlog/pkg/types"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrNoPausedBackfill is returned by Resume when an agent has no interrupted
+// backfill to continue
+var ErrNoPausedBackfill = errors.New("no paused backfill")
+
 // BackfillManager manages historical log backfill operations
 type BackfillManager struct {
-	registry   *adapters.Registry
-	buffer     *buffer.Buffer
-	client     *client.Client
-	stateStore *StateStore
-	log        *logrus.Logger
+	registry    *adapters.Registry
+	buffer      *buffer.Buffer
+	client      *client.Client
+	dedup       *dedup.Index
+	stateStore  *StateStore
+	grace       GracePolicy
+	parseErrors ParseErrorPolicy
+	maxFileSize int64
+	log         *logrus.Logger
 }
 
 // Config holds backfill manager configuration
@@ -32,7 +45,60 @@ type Config struct {
 	Buffer      *buffer.Buffer
 	Client      *client.Client
 	StateDBPath string
+	Dedup       *dedup.Index // Shared with live collection; nil disables deduplication
+	Grace       GracePolicy
+	ParseErrors ParseErrorPolicy
+	MaxFileSize int64 // Log files larger than this many bytes are skipped (0 = no limit)
 	Logger      *logrus.Logger
+
+	// ReadOnlyState loads what was synced before but never records progress,
+	// so a dry run previews exactly what a real run would do and leaves the
+	// state untouched
+	ReadOnlyState bool
+}
+
+// GracePolicy controls how long a source that keeps erroring stays in
+// StatusRetrying before it is marked StatusFailed. A source fails once it
+// has used MaxAttempts attempts or has been failing for longer than Window,
+// whichever comes first; a zero Window disables the time limit.
+type GracePolicy struct {
+	MaxAttempts int
+	Window      time.Duration
+}
+
+// DefaultGracePolicy returns the grace policy used when none is configured
+func DefaultGracePolicy() GracePolicy {
+	return GracePolicy{
+		MaxAttempts: 3,
+		Window:      time.Hour,
+	}
+}
+
+// ParseErrorPolicy aborts line-based parsing of a file whose lines mostly
+// fail to parse, which usually means the adapter doesn't match the file. A
+// file is aborted once at least MinLines lines have been read and the share
+// of lines that failed to parse exceeds MaxRate; a zero MaxRate disables
+// the check.
+type ParseErrorPolicy struct {
+	MaxRate  float64
+	MinLines int
+}
+
+// DefaultParseErrorPolicy returns the parse error policy used when none is
+// configured
+func DefaultParseErrorPolicy() ParseErrorPolicy {
+	return ParseErrorPolicy{
+		MaxRate:  0.5,
+		MinLines: 100,
+	}
+}
+
+// exceeded reports whether errors out of lines breaks the policy
+func (p ParseErrorPolicy) exceeded(errors, lines int) bool {
+	if p.MaxRate <= 0 || lines < p.MinLines || lines == 0 {
+		return false
+	}
+	return float64(errors)/float64(lines) > p.MaxRate
 }
 
 // BackfillConfig specifies parameters for a backfill operation
@@ -43,6 +109,9 @@ type BackfillConfig struct {
 	ToDate     time.Time
 	DryRun     bool
 	BatchSize  int
+	Workers    int                // Files processed concurrently; values below 1 mean sequential
+	Filter     watcher.PathFilter // Include/exclude patterns; zero value applies the default excludes
+	DumpErrors bool               // Write lines that fail to parse to QuarantinePath of their file
 	ProgressCB ProgressFunc
 }
 
@@ -54,6 +123,14 @@ type BackfillResult struct {
 	ErrorEvents     int
 	Duration        time.Duration
 	BytesProcessed  int64
+	ParseErrors     map[string]int // Lines that failed to parse, by file; nil when there were none
+	ResumedFiles    []string       // Files picked up by Resume, in the order they were resumed
+	SkippedFiles    []string       // Files not handed to the adapter because they are binary or too large
+
+	// Models holds the turns and tokens per model of the processed events;
+	// nil when none were LLM events
+	Models map[string]adapters.ModelUsage
+	models adapters.ModelTally
 }
 
 // Progress represents the current progress of a backfill operation
@@ -64,30 +141,96 @@ type Progress struct {
 	TotalBytes      int64
 	EventsProcessed int
 	Percentage      float64
-	EstimatedTime   time.Duration
+	EstimatedTime   time.Duration // Time left on this file at the rate so far; 0 until it can be estimated
+}
+
+// estimateRemaining projects how long the rest of a file takes to read,
+// given that done of its remaining bytes were read in elapsed. Nothing read
+// yet gives no estimate.
+func estimateRemaining(done, remaining int64, elapsed time.Duration) time.Duration {
+	if done <= 0 || elapsed <= 0 || remaining <= done {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(remaining-done) / float64(done))
 }
 
-// ProgressFunc is a callback for progress updates
+// ProgressFunc is a callback for progress updates. With more than one
+// worker it may be called concurrently from different goroutines.
 type ProgressFunc func(Progress)
 
+// merge adds the counters of other into r
+func (r *BackfillResult) merge(other *BackfillResult) {
+	r.TotalEvents += other.TotalEvents
+	r.ProcessedEvents += other.ProcessedEvents
+	r.SkippedEvents += other.SkippedEvents
+	r.ErrorEvents += other.ErrorEvents
+	r.BytesProcessed += other.BytesProcessed
+	r.SkippedFiles = append(r.SkippedFiles, other.SkippedFiles...)
+	r.mergeParseErrors(other)
+	for model, usage := range other.Models {
+		if r.Models == nil {
+			r.Models = make(map[string]adapters.ModelUsage)
+		}
+		total := r.Models[model]
+		total.Turns += usage.Turns
+		total.Tokens += usage.Tokens
+		r.Models[model] = total
+	}
+}
+
+// countModels adds the model usage of processed events to r
+func (r *BackfillResult) countModels(events []*types.AgentEvent) {
+	r.models.Usage = r.Models
+	for _, event := range events {
+		r.models.Add(event)
+	}
+	r.Models = r.models.Usage
+}
+
+// mergeParseErrors adds the per-file parse error counts of other into r
+func (r *BackfillResult) mergeParseErrors(other *BackfillResult) {
+	if other == nil || len(other.ParseErrors) == 0 {
+		return
+	}
+	if r.ParseErrors == nil {
+		r.ParseErrors = make(map[string]int)
+	}
+	for file, count := range other.ParseErrors {
+		r.ParseErrors[file] += count
+	}
+}
+
 // NewBackfillManager creates a new backfill manager
 func NewBackfillManager(config Config) (*BackfillManager, error) {
 	if config.Logger == nil {
 		config.Logger = logrus.New()
 	}
 
+	if config.Grace.MaxAttempts <= 0 {
+		config.Grace = DefaultGracePolicy()
+	}
+
+	if config.ParseErrors.MinLines <= 0 {
+		config.ParseErrors = DefaultParseErrorPolicy()
+	}
+
 	// Initialize state store
 	stateStore, err := NewStateStore(config.StateDBPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create state store: %w", err)
 	}
+	stateStore.readOnly = config.ReadOnlyState
 
 	return &BackfillManager{
-		registry:   config.Registry,
-		buffer:     config.Buffer,
-		client:     config.Client,
-		stateStore: stateStore,
-		log:        config.Logger,
+		registry:    config.Registry,
+		buffer:      config.Buffer,
+		client:      config.Client,
+		dedup:       config.Dedup,
+		stateStore:  stateStore,
+		grace:       config.Grace,
+		parseErrors: config.ParseErrors,
+		maxFileSize: config.MaxFileSize,
+		log:         config.Logger,
 	}, nil
 }
 
@@ -133,54 +276,132 @@ func (bm *BackfillManager) Backfill(ctx context.Context, config BackfillConfig)
 	return result, nil
 }
 
-// backfillDirectory processes all log files in a directory
-func (bm *BackfillManager) backfillDirectory(ctx context.Context, config BackfillConfig, adapter adapters.AgentAdapter) (*BackfillResult, error) {
-	bm.log.Infof("Scanning directory: %s", config.LogPath)
+// BackfillPaths processes several log paths (files or directories) as one
+// operation, sharing the worker pool across all of them
+func (bm *BackfillManager) BackfillPaths(ctx context.Context, config BackfillConfig, paths []string) (*BackfillResult, error) {
+	startTime := time.Now()
 
-	// Find all log files
+	adapter, err := bm.registry.Get(config.AgentName)
+	if err != nil {
+		return nil, fmt.Errorf("no adapter found for agent %s: %w", config.AgentName, err)
+	}
+
+	combinedResult := &BackfillResult{}
 	var logFiles []string
-	err := filepath.Walk(config.LogPath, func(path string, info os.FileInfo, err error) error {
+	for _, path := range paths {
+		fileInfo, err := os.Stat(path)
 		if err != nil {
-			return err
+			bm.log.Warnf("Failed to stat %s: %v", path, err)
+			combinedResult.ErrorEvents++
+			continue
 		}
-		if !info.IsDir() && isLogFile(path) {
+
+		if !fileInfo.IsDir() {
 			logFiles = append(logFiles, path)
+			continue
 		}
-		return nil
-	})
 
+		files, err := findLogFiles(path, config.Filter)
+		if err != nil {
+			bm.log.Warnf("Failed to scan %s: %v", path, err)
+			combinedResult.ErrorEvents++
+			continue
+		}
+		logFiles = append(logFiles, files...)
+	}
+
+	bm.log.Infof("Found %d log files across %d paths", len(logFiles), len(paths))
+
+	result, err := bm.backfillFiles(ctx, config, adapter, logFiles)
+	combinedResult.merge(result)
+	combinedResult.Duration = time.Since(startTime)
+	if err != nil {
+		return combinedResult, err
+	}
+
+	bm.log.Infof("Backfill completed in %s", combinedResult.Duration)
+	bm.log.Infof("Processed: %d, Skipped: %d, Errors: %d",
+		combinedResult.ProcessedEvents, combinedResult.SkippedEvents, combinedResult.ErrorEvents)
+
+	return combinedResult, nil
+}
+
+// backfillDirectory processes all log files in a directory
+func (bm *BackfillManager) backfillDirectory(ctx context.Context, config BackfillConfig, adapter adapters.AgentAdapter) (*BackfillResult, error) {
+	bm.log.Infof("Scanning directory: %s", config.LogPath)
+
+	logFiles, err := findLogFiles(config.LogPath, config.Filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan directory: %w", err)
 	}
 
 	bm.log.Infof("Found %d log files", len(logFiles))
 
-	// Process each file
+	return bm.backfillFiles(ctx, config, adapter, logFiles)
+}
+
+// backfillFiles processes log files through a bounded pool of config.Workers
+// goroutines. Results are aggregated as each file finishes; on cancellation no
+// new files are started and the partial result is returned with ctx.Err().
+func (bm *BackfillManager) backfillFiles(ctx context.Context, config BackfillConfig, adapter adapters.AgentAdapter, logFiles []string) (*BackfillResult, error) {
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(logFiles) {
+		workers = len(logFiles)
+	}
+
 	combinedResult := &BackfillResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for logFile := range jobs {
+				fileLog := bm.log.WithFields(logrus.Fields{"agent": config.AgentName, "file": logFile})
+				fileLog.Info("Processing file")
+				result, err := bm.backfillFile(ctx, config, adapter, logFile)
+
+				mu.Lock()
+				if err != nil {
+					fileLog.WithError(err).Warn("Failed to process file")
+					combinedResult.ErrorEvents++
+					// Files aborted for their error rate still report what failed
+					combinedResult.mergeParseErrors(result)
+				} else {
+					combinedResult.merge(result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
 	for _, logFile := range logFiles {
 		select {
 		case <-ctx.Done():
-			return combinedResult, ctx.Err()
-		default:
+			break dispatch
+		case jobs <- logFile:
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		bm.log.Infof("Processing file: %s", filepath.Base(logFile))
-		result, err := bm.backfillFile(ctx, config, adapter, logFile)
-		if err != nil {
-			bm.log.Warnf("Failed to process %s: %v", logFile, err)
-			combinedResult.ErrorEvents++
-			continue
-		}
+	return combinedResult, ctx.Err()
+}
 
-		// Aggregate results
-		combinedResult.TotalEvents += result.TotalEvents
-		combinedResult.ProcessedEvents += result.ProcessedEvents
-		combinedResult.SkippedEvents += result.SkippedEvents
-		combinedResult.ErrorEvents += result.ErrorEvents
-		combinedResult.BytesProcessed += result.BytesProcessed
+// findLogFiles returns the log files beneath root that pass filter,
+// gzip-compressed rotations included, walking it as the watcher does. A zero
+// filter applies DefaultExcludes.
+func findLogFiles(root string, filter watcher.PathFilter) ([]string, error) {
+	if filter.Include == nil && filter.Exclude == nil {
+		filter = watcher.NewPathFilter(nil, nil)
 	}
-
-	return combinedResult, nil
+	return filter.Walk(root, isLogFile)
 }
 
 // backfillFile processes a single log file
@@ -191,68 +412,171 @@ func (bm *BackfillManager) backfillFile(ctx context.Context, config BackfillConf
 		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
 
+	// Determine if we should use file-based or line-based parsing
+	// Try ParseLogFile first - if adapter doesn't support it, fall back to line-based
+	useFileParsing := bm.shouldUseFileParsing(adapter, filePath)
+
+	// Line-based logs resume from a byte offset, which is meaningless once
+	// the file has been rotated or truncated. Whole-file logs are compared
+	// with the content parsed last.
+	if useFileParsing {
+		bm.detectRewrite(state, filePath)
+	} else {
+		bm.detectRotation(state, filePath)
+	}
+
 	// Skip if already completed
 	if state.Status == StatusCompleted {
 		bm.log.Infof("File already processed: %s", filePath)
 		return &BackfillResult{SkippedEvents: state.TotalEventsProcessed}, nil
 	}
 
+	// Binaries and oversized files with a log extension only produce
+	// confusing parse errors; leave them unrecorded in case they change
+	reason, err := checkTextLog(filePath, bm.maxFileSize)
+	if err != nil {
+		bm.markFailed(state, err.Error())
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if reason != "" {
+		bm.log.Warnf("Skipping %s: %s", filePath, reason)
+		return &BackfillResult{SkippedFiles: []string{filePath}}, nil
+	}
+
 	// Update status to in progress
 	state.Status = StatusInProgress
 	if err := bm.stateStore.Save(state); err != nil {
 		return nil, fmt.Errorf("failed to save state: %w", err)
 	}
 
-	// Determine if we should use file-based or line-based parsing
-	// Try ParseLogFile first - if adapter doesn't support it, fall back to line-based
-	useFileParsing := bm.shouldUseFileParsing(adapter, filePath)
-
 	if useFileParsing {
 		return bm.backfillFileWhole(ctx, config, adapter, filePath, state)
 	}
 	return bm.backfillFileLineByLine(ctx, config, adapter, filePath, state)
 }
 
-// shouldUseFileParsing determines if we should parse the entire file at once
-func (bm *BackfillManager) shouldUseFileParsing(adapter adapters.AgentAdapter, filePath string) bool {
-	// For Copilot chat sessions (JSON files), use file parsing
-	ext := filepath.Ext(filePath)
-	adapterName := adapter.Name()
+// detectRotation resets state to start from the beginning of the file when
+// the file is now smaller than the stored offset (truncation) or is a
+// different file than the one last processed (rotation)
+func (bm *BackfillManager) detectRotation(state *BackfillState, filePath string) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		// Let the open in the parse path report the error
+		return
+	}
+
+	currentID := fileID(info)
+	reason := ""
+	switch {
+	case info.Size() < state.LastByteOffset && !adapters.IsCompressed(filePath):
+		// Offsets into compressed files count decompressed bytes
+		reason = fmt.Sprintf("size %d is below offset %d", info.Size(), state.LastByteOffset)
+	case state.FileID != "" && currentID != "" && currentID != state.FileID:
+		reason = "file was replaced"
+	}
+	state.FileID = currentID
+
+	if reason == "" {
+		return
+	}
+
+	bm.log.Infof("Detected rotation of %s (%s), reprocessing from start", filepath.Base(filePath), reason)
+	state.Status = StatusNew
+	state.LastByteOffset = 0
+	state.LastTimestamp = nil
+	state.TotalEventsProcessed = 0
+	state.Summary = nil
+	state.CompletedAt = nil
+}
+
+// detectRewrite reopens a completed whole-file log that changed since it
+// was parsed. A file whose parsed prefix is intact was appended to and only
+// its new events are parsed; any other change reprocesses it from the start.
+// States recorded before checksums were kept stay completed.
+func (bm *BackfillManager) detectRewrite(state *BackfillState, filePath string) {
+	if state.Status != StatusCompleted || state.Checksum == nil {
+		return
+	}
+	intact, size, err := state.Checksum.matches(filePath)
+	if err != nil {
+		// Let the parse path report the error
+		return
+	}
 
-	// Copilot uses JSON session files - must use file parsing
-	if adapterName == "github-copilot" && ext == ".json" {
-		return true
+	switch {
+	case !intact:
+		bm.log.Infof("Detected rewrite of %s, reprocessing from start", filepath.Base(filePath))
+		state.LastTimestamp = nil
+		state.TotalEventsProcessed = 0
+		state.Summary = nil
+		state.Checksum = nil
+	case size != state.LastByteOffset:
+		bm.log.Infof("Detected append to %s, parsing new events", filepath.Base(filePath))
+	default:
+		return
 	}
+	state.Status = StatusNew
+	state.CompletedAt = nil
+}
 
-	// Other adapters with .jsonl or .ndjson use line parsing
-	return false
+// shouldUseFileParsing determines if we should parse the entire file at once
+func (bm *BackfillManager) shouldUseFileParsing(adapter adapters.AgentAdapter, filePath string) bool {
+	return adapters.ParsesWholeFile(adapter, filePath)
 }
 
 // backfillFileWhole parses an entire log file at once (for structured formats like JSON)
 func (bm *BackfillManager) backfillFileWhole(ctx context.Context, config BackfillConfig, adapter adapters.AgentAdapter, filePath string, state *BackfillState) (*BackfillResult, error) {
 	bm.log.Infof("Using file-based parsing for %s", filepath.Base(filePath))
+	started := time.Now()
 
-	// Get file size for progress tracking
-	fileInfo, err := os.Stat(filePath)
+	// Checksum the content before parsing it, so anything written in
+	// between is seen as new on the next scan
+	checksum, totalBytes, err := newPrefixChecksum(filePath)
 	if err != nil {
-		state.Status = StatusFailed
-		state.ErrorMessage = err.Error()
-		bm.stateStore.Save(state)
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+		bm.markFailed(state, err.Error())
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	totalBytes := fileInfo.Size()
 
-	// Parse entire file
-	events, err := adapter.ParseLogFile(filePath)
+	// A file parsed before was appended to (see detectRewrite). Adapters
+	// that support it resume from the end of the content parsed then;
+	// others parse it whole and leave the events parsed before to
+	// deduplication.
+	appended := state.Checksum != nil
+
+	// Parse entire file, letting adapters that support it skip records
+	// outside the date range before building their events
+	var events []*types.AgentEvent
+	rangeParser, isRangeParser := adapter.(adapters.RangeParser)
+	offsetParser, isOffsetParser := adapter.(adapters.OffsetParser)
+	switch {
+	case appended && isOffsetParser:
+		events, err = offsetParser.ParseLogFileFrom(filePath, state.Checksum.Length)
+	case isRangeParser && (!config.FromDate.IsZero() || !config.ToDate.IsZero()):
+		events, err = rangeParser.ParseLogFileInRange(filePath, config.FromDate, config.ToDate)
+	default:
+		events, err = adapter.ParseLogFile(filePath)
+	}
 	if err != nil {
-		state.Status = StatusFailed
-		state.ErrorMessage = fmt.Sprintf("parse error: %v", err)
-		bm.stateStore.Save(state)
+		bm.markFailed(state, fmt.Sprintf("parse error: %v", err))
 		bm.log.Errorf("Failed to parse %s: %v", filepath.Base(filePath), err)
 		return nil, fmt.Errorf("failed to parse file: %w", err)
 	}
 
-	bm.log.Infof("Parsed %d events from %s", len(events), filepath.Base(filePath))
+	// Counts of an append add up with those of the events already parsed
+	previous := 0
+	summary := &FileSummary{}
+	if appended {
+		previous = state.TotalEventsProcessed
+		if state.Summary != nil {
+			summary = state.Summary
+		}
+	}
+
+	bm.log.WithFields(logrus.Fields{
+		"agent":  config.AgentName,
+		"file":   filePath,
+		"events": len(events),
+	}).Info("Parsed events from log file")
 
 	// Initialize result
 	result := &BackfillResult{
@@ -267,7 +591,7 @@ func (bm *BackfillManager) backfillFileWhole(ctx context.Context, config Backfil
 		select {
 		case <-ctx.Done():
 			state.Status = StatusPaused
-			state.TotalEventsProcessed = result.ProcessedEvents
+			state.TotalEventsProcessed = previous + result.ProcessedEvents
 			bm.stateStore.Save(state)
 			return result, ctx.Err()
 		default:
@@ -312,9 +636,13 @@ func (bm *BackfillManager) backfillFileWhole(ctx context.Context, config Backfil
 				result.ErrorEvents += len(batch)
 			} else {
 				result.ProcessedEvents += len(batch)
+				result.countModels(batch)
+				summary.add(batch)
 			}
 		} else {
 			result.ProcessedEvents += len(batch)
+			result.countModels(batch)
+			summary.add(batch)
 		}
 
 		// Report progress
@@ -327,6 +655,7 @@ func (bm *BackfillManager) backfillFileWhole(ctx context.Context, config Backfil
 				EventsProcessed: result.ProcessedEvents,
 				Percentage:      float64(end) / float64(len(filteredEvents)) * 100,
 			}
+			progress.EstimatedTime = estimateRemaining(progress.BytesProcessed, totalBytes, time.Since(started))
 			config.ProgressCB(progress)
 		}
 	}
@@ -335,8 +664,13 @@ func (bm *BackfillManager) backfillFileWhole(ctx context.Context, config Backfil
 	now := time.Now()
 	state.Status = StatusCompleted
 	state.CompletedAt = &now
+	state.ErrorMessage = ""
+	state.RetryCount = 0
+	state.FirstErrorAt = nil
 	state.LastByteOffset = totalBytes
-	state.TotalEventsProcessed = result.ProcessedEvents
+	state.TotalEventsProcessed = previous + result.ProcessedEvents
+	state.Summary = summary
+	state.Checksum = checksum
 	if len(events) > 0 {
 		state.LastTimestamp = &events[len(events)-1].Timestamp
 	}
@@ -355,9 +689,7 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 	// Open file
 	file, err := os.Open(filePath)
 	if err != nil {
-		state.Status = StatusFailed
-		state.ErrorMessage = err.Error()
-		bm.stateStore.Save(state)
+		bm.markFailed(state, err.Error())
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
@@ -366,16 +698,46 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 	fileInfo, _ := file.Stat()
 	totalBytes := fileInfo.Size()
 
+	// Offsets into a compressed file count decompressed bytes, so resuming
+	// skips ahead through the stream instead of seeking, and progress is
+	// measured by how much of the compressed file has been read
+	var input io.Reader = file
+	compressed := adapters.IsCompressed(filePath)
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			bm.markFailed(state, err.Error())
+			return nil, fmt.Errorf("failed to decompress file: %w", err)
+		}
+		defer gz.Close()
+		input = gz
+	}
+	readPosition := func(offset int64) int64 {
+		if !compressed {
+			return offset
+		}
+		pos, _ := file.Seek(0, io.SeekCurrent)
+		return pos
+	}
+
 	// Seek to last position if resuming
 	if state.LastByteOffset > 0 {
 		bm.log.Infof("Resuming from byte offset: %d", state.LastByteOffset)
-		if _, err := file.Seek(state.LastByteOffset, 0); err != nil {
+		if compressed {
+			if _, err := io.CopyN(io.Discard, input, state.LastByteOffset); err != nil {
+				return nil, fmt.Errorf("failed to skip to offset: %w", err)
+			}
+		} else if _, err := file.Seek(state.LastByteOffset, 0); err != nil {
 			return nil, fmt.Errorf("failed to seek: %w", err)
 		}
 	}
 
+	// Resumed runs estimate time left from the bytes read in this run
+	started := time.Now()
+	startPosition := readPosition(state.LastByteOffset)
+
 	// Create scanner for streaming
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(input)
 	const maxCapacity = 512 * 1024 // 512KB
 	buf := make([]byte, maxCapacity)
 	scanner.Buffer(buf, maxCapacity)
@@ -385,6 +747,31 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 		ProcessedEvents: state.TotalEventsProcessed, // Start from existing count
 	}
 
+	// Counted on every return, including aborts and cancellation
+	errorCount := 0
+	defer func() {
+		if errorCount > 0 {
+			result.ParseErrors = map[string]int{filePath: errorCount}
+		}
+	}()
+
+	// Line numbers in the quarantine file count from where this run started
+	var dump *quarantine
+	if config.DumpErrors {
+		dump = newQuarantine(filePath, state.LastByteOffset > 0)
+		defer func() {
+			if err := dump.Close(); err != nil {
+				bm.log.Warnf("Failed to write %s: %v", dump.path, err)
+			}
+		}()
+	}
+
+	// The summary accumulates across resumed runs; a file partly processed
+	// before summaries were recorded keeps none
+	if state.Summary == nil && state.LastByteOffset == 0 {
+		state.Summary = &FileSummary{}
+	}
+
 	// Batch processing
 	if config.BatchSize == 0 {
 		config.BatchSize = 100
@@ -392,8 +779,34 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 	batch := make([]*types.AgentEvent, 0, config.BatchSize)
 	currentOffset := state.LastByteOffset
 	lastProgressUpdate := time.Now()
-	errorCount := 0
 	maxErrorsToLog := 10
+	if currentOffset == 0 {
+		adapters.ResetLog(adapter, filePath)
+	}
+
+	// Batch the events in the date range not sent before
+	addEvents := func(events []*types.AgentEvent) {
+		for _, event := range events {
+			result.TotalEvents++
+
+			// Filter by date range
+			if !config.FromDate.IsZero() && event.Timestamp.Before(config.FromDate) {
+				continue
+			}
+			if !config.ToDate.IsZero() && event.Timestamp.After(config.ToDate) {
+				continue
+			}
+
+			// Check for duplicate
+			if bm.isDuplicate(event) {
+				result.SkippedEvents++
+				continue
+			}
+
+			// Add to batch
+			batch = append(batch, event)
+		}
+	}
 
 	// Process lines
 	lineNum := 0
@@ -405,16 +818,16 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
-			// Save state before exiting
-			state.LastByteOffset = currentOffset
+			// Keep the offset of the last saved batch so events still in the
+			// pending batch are read again on resume rather than dropped
 			state.Status = StatusPaused
 			bm.stateStore.Save(state)
 			return result, ctx.Err()
 		default:
 		}
 
-		// Parse event
-		event, err := adapter.ParseLogLine(line)
+		// Parse the line's events
+		events, err := adapters.ParseLine(adapter, filePath, line)
 		if err != nil {
 			result.ErrorEvents++
 			// Log first N errors with sample data for debugging
@@ -427,36 +840,33 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 			}
 			errorCount++
 			currentOffset += lineBytes
-			continue
-		}
-
-		if event == nil {
-			// Not a relevant event line
-			currentOffset += lineBytes
-			continue
-		}
+			if err := dump.write(lineNum, line); err != nil {
+				bm.log.Warnf("Failed to quarantine line %d of %s: %v", lineNum, filePath, err)
+				dump.Close()
+				dump = nil
+			}
 
-		result.TotalEvents++
+			// Stop churning through a file the adapter can't read
+			if bm.parseErrors.exceeded(errorCount, lineNum) {
+				reason := fmt.Sprintf("aborted after %d lines: %d parse errors exceed the maximum error rate of %.0f%% (%s adapter may not match this file)",
+					lineNum, errorCount, bm.parseErrors.MaxRate*100, adapter.Name())
+				bm.log.Warnf("%s: %s", filePath, reason)
 
-		// Filter by date range
-		if !config.FromDate.IsZero() && event.Timestamp.Before(config.FromDate) {
-			currentOffset += lineBytes
-			continue
-		}
-		if !config.ToDate.IsZero() && event.Timestamp.After(config.ToDate) {
-			currentOffset += lineBytes
-			continue
-		}
+				state.Status = StatusFailed
+				state.ErrorMessage = reason
+				if err := bm.stateStore.Save(state); err != nil {
+					bm.log.Warnf("Failed to save state: %v", err)
+				}
 
-		// Check for duplicate
-		if bm.isDuplicate(event) {
-			result.SkippedEvents++
-			currentOffset += lineBytes
+				result.BytesProcessed = currentOffset
+				return result, fmt.Errorf("%s", reason)
+			}
 			continue
 		}
 
-		// Add to batch
-		batch = append(batch, event)
+		// Lines without a relevant event, or with filtered event types only,
+		// yield none
+		addEvents(events)
 		currentOffset += lineBytes
 
 		// Process batch when full
@@ -467,30 +877,35 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 					result.ErrorEvents += len(batch)
 				} else {
 					result.ProcessedEvents += len(batch)
+					addToSummary(state, result, batch)
 				}
 
 				// Update state
+				last := batch[len(batch)-1]
 				state.LastByteOffset = currentOffset
 				state.TotalEventsProcessed = result.ProcessedEvents
-				if event.Timestamp.After(time.Time{}) {
-					state.LastTimestamp = &event.Timestamp
+				if last.Timestamp.After(time.Time{}) {
+					state.LastTimestamp = &last.Timestamp
 				}
 				if err := bm.stateStore.Save(state); err != nil {
 					bm.log.Warnf("Failed to save state: %v", err)
 				}
 			} else {
 				result.ProcessedEvents += len(batch)
+				addToSummary(state, result, batch)
 			}
 
 			// Report progress
 			if config.ProgressCB != nil && time.Since(lastProgressUpdate) > time.Second {
+				position := readPosition(currentOffset)
 				progress := Progress{
 					AgentName:       config.AgentName,
 					FilePath:        filePath,
-					BytesProcessed:  currentOffset,
+					BytesProcessed:  position,
 					TotalBytes:      totalBytes,
 					EventsProcessed: result.ProcessedEvents,
-					Percentage:      float64(currentOffset) / float64(totalBytes) * 100,
+					Percentage:      float64(position) / float64(totalBytes) * 100,
+					EstimatedTime:   estimateRemaining(position-startPosition, totalBytes-startPosition, time.Since(started)),
 				}
 				config.ProgressCB(progress)
 				lastProgressUpdate = time.Now()
@@ -501,6 +916,11 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 		}
 	}
 
+	// Sessions the log ends with are summarized once it was read to the end
+	if scanner.Err() == nil {
+		addEvents(adapters.EndOfLog(adapter, filePath))
+	}
+
 	// Process remaining batch
 	if len(batch) > 0 {
 		if !config.DryRun {
@@ -509,9 +929,11 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 				result.ErrorEvents += len(batch)
 			} else {
 				result.ProcessedEvents += len(batch)
+				addToSummary(state, result, batch)
 			}
 		} else {
 			result.ProcessedEvents += len(batch)
+			addToSummary(state, result, batch)
 		}
 	}
 
@@ -522,9 +944,7 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 
 	// Check for scanner errors
 	if err := scanner.Err(); err != nil {
-		state.Status = StatusFailed
-		state.ErrorMessage = err.Error()
-		bm.stateStore.Save(state)
+		bm.markFailed(state, err.Error())
 		return result, fmt.Errorf("scanner error: %w", err)
 	}
 
@@ -532,6 +952,9 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 	now := time.Now()
 	state.Status = StatusCompleted
 	state.CompletedAt = &now
+	state.ErrorMessage = ""
+	state.RetryCount = 0
+	state.FirstErrorAt = nil
 	state.LastByteOffset = currentOffset
 	state.TotalEventsProcessed = result.ProcessedEvents
 	result.BytesProcessed = currentOffset
@@ -543,48 +966,78 @@ func (bm *BackfillManager) backfillFileLineByLine(ctx context.Context, config Ba
 	return result, nil
 }
 
+// addToSummary counts a processed batch into the run's model usage and the
+// file's summary, if one is kept
+func addToSummary(state *BackfillState, result *BackfillResult, batch []*types.AgentEvent) {
+	result.countModels(batch)
+	if state.Summary != nil {
+		state.Summary.add(batch)
+	}
+}
+
+// markFailed records a failed attempt on state and persists it. The source
+// stays in StatusRetrying until the grace policy is exhausted.
+func (bm *BackfillManager) markFailed(state *BackfillState, message string) {
+	now := time.Now()
+	state.RetryCount++
+	if state.FirstErrorAt == nil {
+		state.FirstErrorAt = &now
+	}
+	state.ErrorMessage = message
+
+	exhausted := state.RetryCount >= bm.grace.MaxAttempts ||
+		(bm.grace.Window > 0 && now.Sub(*state.FirstErrorAt) > bm.grace.Window)
+	if exhausted {
+		state.Status = StatusFailed
+	} else {
+		state.Status = StatusRetrying
+		bm.log.Warnf("Attempt %d/%d failed for %s, will retry: %s",
+			state.RetryCount, bm.grace.MaxAttempts, state.LogFilePath, message)
+	}
+
+	if err := bm.stateStore.Save(state); err != nil {
+		bm.log.Warnf("Failed to save state: %v", err)
+	}
+}
+
 // processBatch sends a batch of events to the client and buffer
 func (bm *BackfillManager) processBatch(ctx context.Context, batch []*types.AgentEvent) error {
 	for _, event := range batch {
+		// Live collection may have sent the event since isDuplicate checked
+		if bm.dedup != nil && !bm.dedup.Claim(buffer.EventHash(event)) {
+			continue
+		}
+
 		// For backfill operations, buffer events first for reliable storage
 		// The buffer will be processed by the normal collector sync mechanism
-		if err := bm.buffer.Store(event); err != nil {
+		if _, err := bm.buffer.Store(event); err != nil {
 			bm.log.Warnf("Failed to buffer event: %v", err)
 			// Continue to try sending directly as fallback
 		}
 
 		// Also try to send immediately if backend is available
-		// This is best-effort and failures are acceptable since we've buffered
+		// This is best-effort and failures are acceptable since we've buffered.
+		// Without a client (local sinks) the buffer flush delivers them.
+		if bm.client == nil {
+			continue
+		}
 		if err := bm.client.SendEvent(event); err != nil {
-			// SendEvent currently always returns nil, so this won't catch async send failures
-			// But we keep it for future compatibility
 			bm.log.Debugf("Failed to queue event for sending: %v", err)
 		}
 	}
 	return nil
 }
 
-// isDuplicate checks if an event has already been processed
+// isDuplicate checks if an event has already been sent, by backfill or by
+// live collection
 func (bm *BackfillManager) isDuplicate(event *types.AgentEvent) bool {
-	// TODO: Implement actual duplicate detection using event hash
-	// For now, return false (no deduplication)
-	// In production, this should check against a hash index in the buffer
-	return false
-}
-
-// eventHash creates a deterministic hash for an event
-func eventHash(event *types.AgentEvent) string {
-	data := fmt.Sprintf("%s:%s:%d:%v",
-		event.AgentID,
-		event.Type,
-		event.Timestamp.Unix(),
-		event.Data["requestId"],
-	)
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:])
+	return bm.dedup != nil && bm.dedup.Seen(buffer.EventHash(event))
 }
 
-// Resume resumes an interrupted backfill operation
+// Resume continues every interrupted backfill of an agent from where it
+// stopped. A file that fails doesn't stop the others; its error is joined into
+// the returned error alongside the combined result. It returns an error
+// wrapping ErrNoPausedBackfill when there is nothing to resume.
 func (bm *BackfillManager) Resume(ctx context.Context, agentName string) (*BackfillResult, error) {
 	// Load all paused/in-progress states for this agent
 	states, err := bm.stateStore.ListByAgent(agentName)
@@ -592,35 +1045,54 @@ func (bm *BackfillManager) Resume(ctx context.Context, agentName string) (*Backf
 		return nil, fmt.Errorf("failed to list states: %w", err)
 	}
 
-	var resumeState *BackfillState
+	var paths []string
 	for _, state := range states {
-		if state.Status == StatusPaused || state.Status == StatusInProgress {
-			resumeState = state
-			break
+		if state.Status == StatusPaused || state.Status == StatusInProgress || state.Status == StatusRetrying {
+			paths = append(paths, state.LogFilePath)
 		}
 	}
 
-	if resumeState == nil {
-		return nil, fmt.Errorf("no paused backfill found for agent: %s", agentName)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w for agent: %s", ErrNoPausedBackfill, agentName)
 	}
 
-	bm.log.Infof("Resuming backfill: %s", resumeState.LogFilePath)
-
 	// Get adapter
 	adapter, err := bm.registry.Get(agentName)
 	if err != nil {
 		return nil, fmt.Errorf("no adapter found: %w", err)
 	}
 
-	// Create config from state
-	config := BackfillConfig{
-		AgentName: agentName,
-		LogPath:   resumeState.LogFilePath,
-		BatchSize: 100,
+	startTime := time.Now()
+	result := &BackfillResult{}
+	var errs []error
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+
+		bm.log.Infof("Resuming backfill: %s", path)
+		config := BackfillConfig{
+			AgentName: agentName,
+			LogPath:   path,
+			BatchSize: 100,
+		}
+		fileResult, err := bm.backfillFile(ctx, config, adapter, path)
+		result.ResumedFiles = append(result.ResumedFiles, path)
+		if fileResult != nil {
+			result.merge(fileResult)
+		}
+		if err != nil && ctx.Err() == nil {
+			bm.log.Warnf("Failed to resume %s: %v", path, err)
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
 	}
+	result.Duration = time.Since(startTime)
 
-	// Resume processing
-	return bm.backfillFile(ctx, config, adapter, resumeState.LogFilePath)
+	// A cancelled resume leaves the remaining files paused for the next one
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	return result, errors.Join(errs...)
 }
 
 // Status returns the status of backfill operations for an agent
@@ -628,6 +1100,22 @@ func (bm *BackfillManager) Status(agentName string) ([]*BackfillState, error) {
 	return bm.stateStore.ListByAgent(agentName)
 }
 
+// Reset clears the backfill state of an agent, or of every agent when
+// agentName is empty, so the next sync re-imports its logs from the start.
+// It returns the number of states cleared. The dedup index doesn't record
+// agents, so it is cleared whole for the re-imported events to be sent again.
+func (bm *BackfillManager) Reset(agentName string) (int, error) {
+	if bm.dedup != nil {
+		if err := bm.dedup.Clear(); err != nil {
+			return 0, err
+		}
+	}
+	if agentName == "" {
+		return bm.stateStore.ResetAll()
+	}
+	return bm.stateStore.DeleteByAgent(agentName)
+}
+
 // Cancel cancels a running backfill operation
 func (bm *BackfillManager) Cancel(agentName string) error {
 	states, err := bm.stateStore.ListByAgent(agentName)
@@ -652,8 +1140,8 @@ func (bm *BackfillManager) Close() error {
 	return bm.stateStore.Close()
 }
 
-// isLogFile checks if a file is a log file
+// isLogFile checks if a file is a log file, possibly gzip-compressed
 func isLogFile(path string) bool {
-	ext := filepath.Ext(path)
+	ext := strings.ToLower(adapters.LogExt(path))
 	return ext == ".log" || ext == ".txt" || ext == ".json" || ext == ".jsonl" || ext == ".ndjson"
 }
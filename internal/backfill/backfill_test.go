@@ -0,0 +1,1144 @@
+package backfill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/internal/dedup"
+	"github.com/codervisor/devlog/internal/watcher"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// writeClaudeLogs creates count Claude log files under dir, each with
+// linesPerFile events, each in its own workspace subdirectory
+func writeClaudeLogs(t *testing.T, dir string, count, linesPerFile int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		var lines []string
+		for j := 0; j < linesPerFile; j++ {
+			lines = append(lines, fmt.Sprintf(
+				`{"timestamp":"2025-10-31T10:%02d:%02dZ","type":"llm_request","conversation_id":"conv_%d","prompt":"Prompt %d","prompt_tokens":2}`,
+				j/60, j%60, i, j))
+		}
+		// A non-event line counted as neither processed nor errored
+		lines = append(lines, `{"timestamp":"2025-10-31T10:00:00Z","level":"debug","message":"noise"}`)
+
+		workspace := filepath.Join(dir, fmt.Sprintf("workspace-%02d", i))
+		if err := os.MkdirAll(workspace, 0755); err != nil {
+			t.Fatalf("failed to create workspace dir: %v", err)
+		}
+		path := filepath.Join(workspace, "session.jsonl")
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write log file: %v", err)
+		}
+	}
+}
+
+func newTestManager(t *testing.T) *BackfillManager {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewClaudeAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+
+	return manager
+}
+
+func TestBackfill_WorkersMatchSequential(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 24, 50)
+
+	run := func(workers int) *BackfillResult {
+		manager := newTestManager(t)
+		result, err := manager.Backfill(context.Background(), BackfillConfig{
+			AgentName: "claude",
+			LogPath:   logDir,
+			DryRun:    true,
+			BatchSize: 10,
+			Workers:   workers,
+		})
+		if err != nil {
+			t.Fatalf("backfill with %d workers failed: %v", workers, err)
+		}
+
+		// Every file must have its state persisted as completed
+		states, err := manager.Status("claude")
+		if err != nil {
+			t.Fatalf("failed to list states: %v", err)
+		}
+		if len(states) != 24 {
+			t.Errorf("workers=%d: expected 24 states, got %d", workers, len(states))
+		}
+		for _, state := range states {
+			if state.Status != StatusCompleted {
+				t.Errorf("workers=%d: %s has status %s", workers, state.LogFilePath, state.Status)
+			}
+		}
+		return result
+	}
+
+	sequential := run(1)
+	parallel := run(8)
+
+	if sequential.TotalEvents != 24*50 {
+		t.Errorf("Expected %d total events, got %d", 24*50, sequential.TotalEvents)
+	}
+	if parallel.TotalEvents != sequential.TotalEvents ||
+		parallel.ProcessedEvents != sequential.ProcessedEvents ||
+		parallel.SkippedEvents != sequential.SkippedEvents ||
+		parallel.ErrorEvents != sequential.ErrorEvents ||
+		parallel.BytesProcessed != sequential.BytesProcessed {
+		t.Errorf("Parallel result %+v does not match sequential %+v", *parallel, *sequential)
+	}
+}
+
+func TestBackfillPaths_Cancelled(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 4, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	manager := newTestManager(t)
+	result, err := manager.BackfillPaths(ctx, BackfillConfig{
+		AgentName: "claude",
+		DryRun:    true,
+		Workers:   4,
+	}, []string{logDir})
+
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if result == nil || result.ProcessedEvents != 0 {
+		t.Errorf("Expected no events processed after cancellation, got %+v", result)
+	}
+}
+
+// flakyAdapter fails ParseLogFile a fixed number of times before succeeding.
+// It reports the Copilot name so backfill uses whole-file parsing.
+type flakyAdapter struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyAdapter) Name() string { return "github-copilot" }
+
+func (f *flakyAdapter) ParseLogLine(line string) (*types.AgentEvent, error) { return nil, nil }
+
+func (f *flakyAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, fmt.Errorf("file is locked")
+	}
+	return []*types.AgentEvent{
+		{ID: "evt-1", Type: types.EventTypeLLMRequest, Timestamp: time.Now()},
+	}, nil
+}
+
+func (f *flakyAdapter) SupportsFormat(sample string) bool { return true }
+
+func TestBackfill_RetriesWithinGracePolicy(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(logFile, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	adapter := &flakyAdapter{failures: 2}
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Grace:       GracePolicy{MaxAttempts: 3, Window: time.Hour},
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	config := BackfillConfig{AgentName: "github-copilot", LogPath: logFile, DryRun: true}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := manager.Backfill(context.Background(), config); err == nil {
+			t.Fatalf("attempt %d: expected error", attempt)
+		}
+
+		state, err := manager.stateStore.Load("github-copilot", logFile)
+		if err != nil {
+			t.Fatalf("failed to load state: %v", err)
+		}
+		if state.Status != StatusRetrying {
+			t.Errorf("attempt %d: expected status %s, got %s", attempt, StatusRetrying, state.Status)
+		}
+		if state.RetryCount != attempt {
+			t.Errorf("attempt %d: expected retry count %d, got %d", attempt, attempt, state.RetryCount)
+		}
+	}
+
+	result, err := manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("third attempt failed: %v", err)
+	}
+	if result.ProcessedEvents != 1 {
+		t.Errorf("Expected 1 processed event, got %d", result.ProcessedEvents)
+	}
+
+	state, err := manager.stateStore.Load("github-copilot", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Status != StatusCompleted {
+		t.Errorf("Expected status %s, got %s", StatusCompleted, state.Status)
+	}
+	if state.RetryCount != 0 || state.FirstErrorAt != nil {
+		t.Errorf("Expected retry tracking to reset, got count=%d firstErrorAt=%v", state.RetryCount, state.FirstErrorAt)
+	}
+}
+
+func TestBackfill_FailsAfterGraceExhausted(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(logFile, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(&flakyAdapter{failures: 10}); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Grace:       GracePolicy{MaxAttempts: 2},
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	config := BackfillConfig{AgentName: "github-copilot", LogPath: logFile, DryRun: true}
+	manager.Backfill(context.Background(), config)
+	manager.Backfill(context.Background(), config)
+
+	state, err := manager.stateStore.Load("github-copilot", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Status != StatusFailed {
+		t.Errorf("Expected status %s, got %s", StatusFailed, state.Status)
+	}
+}
+
+func TestBackfill_ReprocessesTruncatedFile(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 5)
+	logFile := filepath.Join(logDir, "workspace-00", "session.jsonl")
+
+	manager := newTestManager(t)
+	config := BackfillConfig{AgentName: "claude", LogPath: logFile, DryRun: true}
+
+	result, err := manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("first backfill failed: %v", err)
+	}
+	if result.ProcessedEvents != 5 {
+		t.Fatalf("Expected 5 processed events, got %d", result.ProcessedEvents)
+	}
+
+	// Truncate the log in place to fewer lines than before
+	shortLog := `{"timestamp":"2025-11-01T09:00:00Z","type":"llm_request","conversation_id":"conv_new","prompt":"Hi","prompt_tokens":1}` + "\n"
+	if err := os.WriteFile(logFile, []byte(shortLog), 0644); err != nil {
+		t.Fatalf("failed to truncate log: %v", err)
+	}
+
+	result, err = manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second backfill failed: %v", err)
+	}
+	if result.ProcessedEvents != 1 || result.TotalEvents != 1 {
+		t.Errorf("Expected truncated file to be reprocessed from start, got %+v", *result)
+	}
+
+	state, err := manager.stateStore.Load("claude", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.LastByteOffset != int64(len(shortLog)) {
+		t.Errorf("Expected offset %d, got %d", len(shortLog), state.LastByteOffset)
+	}
+}
+
+func TestBackfill_ReprocessesReplacedFile(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 2, 3)
+	logFile := filepath.Join(logDir, "workspace-00", "session.jsonl")
+	otherFile := filepath.Join(logDir, "workspace-01", "session.jsonl")
+
+	manager := newTestManager(t)
+	config := BackfillConfig{AgentName: "claude", LogPath: logFile, DryRun: true}
+
+	if _, err := manager.Backfill(context.Background(), config); err != nil {
+		t.Fatalf("first backfill failed: %v", err)
+	}
+
+	// Rotate: a different file of the same size takes over the path
+	if err := os.Rename(otherFile, logFile); err != nil {
+		t.Fatalf("failed to rotate log: %v", err)
+	}
+
+	result, err := manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second backfill failed: %v", err)
+	}
+	if fileIDSupported(t, logFile) && result.ProcessedEvents != 3 {
+		t.Errorf("Expected replaced file to be reprocessed, got %+v", *result)
+	}
+}
+
+// fileIDSupported reports whether the platform exposes file identities
+func fileIDSupported(t *testing.T, path string) bool {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+	return fileID(info) != ""
+}
+
+func TestBackfill_SkipsExcludedFiles(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 3, 2)
+
+	// Files the default excludes must skip, plus one skipped by config
+	excluded := []string{
+		"workspace-00/session.jsonl.tmp",
+		"workspace-00/session.log~",
+		"node_modules/dep/session.jsonl",
+		"workspace-02/session.jsonl", // matched by the configured exclude below
+	}
+	for _, name := range excluded[:3] {
+		path := filepath.Join(logDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		content := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"x","prompt":"no","prompt_tokens":1}` + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	manager := newTestManager(t)
+	result, err := manager.Backfill(context.Background(), BackfillConfig{
+		AgentName: "claude",
+		LogPath:   logDir,
+		DryRun:    true,
+		Filter:    watcher.NewPathFilter(nil, []string{"workspace-02"}),
+	})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	// Only workspace-00 and workspace-01 session logs remain, 2 events each
+	if result.TotalEvents != 4 {
+		t.Errorf("Expected 4 events from non-excluded files, got %d", result.TotalEvents)
+	}
+
+	states, err := manager.Status("claude")
+	if err != nil {
+		t.Fatalf("failed to list states: %v", err)
+	}
+	for _, state := range states {
+		for _, name := range excluded {
+			if state.LogFilePath == filepath.Join(logDir, name) {
+				t.Errorf("Excluded file was processed: %s", name)
+			}
+		}
+	}
+}
+
+// countingAdapter counts the lines handed to the wrapped adapter
+type countingAdapter struct {
+	adapters.AgentAdapter
+	lines int
+}
+
+func (c *countingAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	c.lines++
+	return c.AgentAdapter.ParseLogLine(line)
+}
+
+func TestBackfill_AbortsUnparseableFile(t *testing.T) {
+	// Copilot chat sessions can't be parsed line by line, so every line of
+	// an NDJSON file is a parse error for this adapter
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","prompt":"Prompt %d"}`, i))
+	}
+	logFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	adapter := &countingAdapter{AgentAdapter: adapters.NewCopilotAdapter("test-project", nil, log)}
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		ParseErrors: ParseErrorPolicy{MaxRate: 0.5, MinLines: 50},
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	manager.Backfill(context.Background(), BackfillConfig{AgentName: "github-copilot", LogPath: logFile, DryRun: true})
+
+	if adapter.lines != 50 {
+		t.Errorf("Expected the file to be aborted after 50 lines, parsed %d", adapter.lines)
+	}
+
+	state, err := manager.stateStore.Load("github-copilot", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Status != StatusFailed {
+		t.Errorf("Expected status %s, got %s", StatusFailed, state.Status)
+	}
+	if !strings.Contains(state.ErrorMessage, "maximum error rate") {
+		t.Errorf("Expected an error rate reason, got %q", state.ErrorMessage)
+	}
+}
+
+func TestParseErrorPolicy_Exceeded(t *testing.T) {
+	policy := ParseErrorPolicy{MaxRate: 0.5, MinLines: 10}
+
+	tests := []struct {
+		errors, lines int
+		want          bool
+	}{
+		{errors: 9, lines: 9, want: false},    // Below the minimum line count
+		{errors: 5, lines: 10, want: false},   // At, not above, the rate
+		{errors: 6, lines: 10, want: true},    // Above the rate
+		{errors: 10, lines: 100, want: false}, // Occasional errors
+	}
+	for _, tt := range tests {
+		if got := policy.exceeded(tt.errors, tt.lines); got != tt.want {
+			t.Errorf("exceeded(%d, %d) = %v, want %v", tt.errors, tt.lines, got, tt.want)
+		}
+	}
+
+	if (ParseErrorPolicy{MinLines: 10}).exceeded(10, 10) {
+		t.Error("Expected a zero MaxRate to disable the check")
+	}
+}
+
+func TestBackfill_WholeFileRespectsDateRange(t *testing.T) {
+	// A Copilot session with one request a day for four weeks
+	start := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	var requests []string
+	for day := 0; day < 28; day++ {
+		requests = append(requests, fmt.Sprintf(
+			`{"requestId":"req_%d","timestamp":%d,"message":{"text":"Day %d"},"response":[{"value":"Done"}]}`,
+			day, start.AddDate(0, 0, day).UnixMilli(), day))
+	}
+	logFile := filepath.Join(t.TempDir(), "session.json")
+	session := `{"version":3,"requests":[` + strings.Join(requests, ",") + `]}`
+	if err := os.WriteFile(logFile, []byte(session), 0644); err != nil {
+		t.Fatalf("failed to write session file: %v", err)
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewCopilotAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	// The last week only
+	result, err := manager.Backfill(context.Background(), BackfillConfig{
+		AgentName: "github-copilot",
+		LogPath:   logFile,
+		FromDate:  start.AddDate(0, 0, 21),
+		ToDate:    start.AddDate(0, 0, 28),
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	// Requests outside the range are skipped by the adapter, so their
+	// events are never built (each request yields a request and a response)
+	if result.TotalEvents != 14 {
+		t.Errorf("Expected 14 parsed events for 7 in-range requests, got %d", result.TotalEvents)
+	}
+	if result.ProcessedEvents != 14 {
+		t.Errorf("Expected 14 processed events, got %d", result.ProcessedEvents)
+	}
+}
+
+func TestBackfill_ModelUsage(t *testing.T) {
+	// Two sessions: turns on two models, and one turn without a model
+	writeSession := func(dir string, models ...string) string {
+		var requests []string
+		for i, model := range models {
+			requests = append(requests, fmt.Sprintf(
+				`{"requestId":"req_%d","timestamp":%d,"modelId":%q,"message":{"text":"Question %d"},"response":[{"value":"Answer"}]}`,
+				i, time.Date(2025, 10, 1, 12, i, 0, 0, time.UTC).UnixMilli(), model, i))
+		}
+		logFile := filepath.Join(dir, "session.json")
+		session := `{"version":3,"requests":[` + strings.Join(requests, ",") + `]}`
+		if err := os.WriteFile(logFile, []byte(session), 0644); err != nil {
+			t.Fatalf("failed to write session file: %v", err)
+		}
+		return logFile
+	}
+	first := writeSession(t.TempDir(), "copilot/gpt-4o", "copilot/claude-sonnet-4.5", "copilot/gpt-4o")
+	second := writeSession(t.TempDir(), "copilot/gpt-4o", "")
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewCopilotAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	result, err := manager.BackfillPaths(context.Background(), BackfillConfig{
+		AgentName: "github-copilot",
+		DryRun:    true,
+	}, []string{first, second})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	turns := map[string]int{"copilot/gpt-4o": 3, "copilot/claude-sonnet-4.5": 1, adapters.UnknownModel: 1}
+	if len(result.Models) != len(turns) {
+		t.Fatalf("Expected usage of %d models, got %+v", len(turns), result.Models)
+	}
+	for model, want := range turns {
+		usage := result.Models[model]
+		if usage.Turns != want {
+			t.Errorf("%s: expected %d turns, got %d", model, want, usage.Turns)
+		}
+		if usage.Tokens == 0 {
+			t.Errorf("%s: expected tokens to be counted", model)
+		}
+	}
+
+	// Each file's summary keeps its own breakdown
+	state, err := manager.stateStore.Load("github-copilot", first)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Summary == nil || len(state.Summary.Models) != 2 || state.Summary.Models["copilot/gpt-4o"].Turns != 2 {
+		t.Errorf("Expected the first file's summary to count 2 gpt-4o turns and 1 other, got %+v", state.Summary)
+	}
+}
+
+func TestBackfill_StructuredLogFields(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 5)
+
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewClaudeAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	if _, err := manager.Backfill(context.Background(), BackfillConfig{AgentName: "claude", LogPath: logDir, DryRun: true}); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	found := false
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, line)
+		}
+		if entry["msg"] == "Processing file" {
+			found = true
+			if entry["agent"] != "claude" {
+				t.Errorf("Expected agent=claude, got %v", entry["agent"])
+			}
+			if file, _ := entry["file"].(string); !strings.HasPrefix(file, logDir) {
+				t.Errorf("Expected file under %s, got %v", logDir, entry["file"])
+			}
+		}
+	}
+	if !found {
+		t.Errorf("No 'Processing file' entry in log output:\n%s", out.String())
+	}
+}
+
+func TestBackfill_GzippedLogFile(t *testing.T) {
+	line := func(i int) string {
+		return fmt.Sprintf(`{"timestamp":"2025-10-31T10:00:%02dZ","type":"llm_request","conversation_id":"conv_gz","prompt":"Prompt %d","prompt_tokens":2}`, i, i) + "\n"
+	}
+	writeGzip := func(path string, content string) {
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("failed to open log: %v", err)
+		}
+		defer file.Close()
+		gz := gzip.NewWriter(file)
+		if _, err := gz.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to compress log: %v", err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatalf("failed to compress log: %v", err)
+		}
+	}
+
+	logDir := t.TempDir()
+	logFile := filepath.Join(logDir, "session.jsonl.gz")
+	writeGzip(logFile, line(0)+line(1)+line(2))
+
+	manager := newTestManager(t)
+	config := BackfillConfig{AgentName: "claude", LogPath: logDir, DryRun: true}
+
+	result, err := manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("first backfill failed: %v", err)
+	}
+	if result.ProcessedEvents != 3 {
+		t.Fatalf("Expected 3 processed events, got %d", result.ProcessedEvents)
+	}
+
+	// The decompressed offset is past the compressed size, which mustn't be
+	// mistaken for truncation
+	result, err = manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second backfill failed: %v", err)
+	}
+	if result.ProcessedEvents != 0 || result.SkippedEvents != 3 {
+		t.Errorf("Expected completed file to be skipped, got %+v", *result)
+	}
+
+	// Resuming a paused run skips ahead through the decompressed stream
+	state, err := manager.stateStore.Load("claude", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	state.Status = StatusPaused
+	state.LastByteOffset = int64(len(line(0)))
+	state.TotalEventsProcessed = 1
+	if err := manager.stateStore.Save(state); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	result, err = manager.Backfill(context.Background(), config)
+	if err != nil {
+		t.Fatalf("resumed backfill failed: %v", err)
+	}
+	if result.TotalEvents != 2 {
+		t.Errorf("Expected 2 events after resuming, got %+v", *result)
+	}
+}
+
+func TestIsLogFile(t *testing.T) {
+	tests := map[string]bool{
+		"session.jsonl":     true,
+		"session.jsonl.gz":  true,
+		"chat.json.gz":      true,
+		"output.log.GZ":     true,
+		"archive.tar.gz":    false,
+		"screenshot.png":    false,
+		"screenshot.png.gz": false,
+	}
+	for path, want := range tests {
+		if got := isLogFile(path); got != want {
+			t.Errorf("isLogFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestBackfill_EventTypeFilter(t *testing.T) {
+	logDir := t.TempDir()
+	var lines []string
+	for i := 0; i < 4; i++ {
+		lines = append(lines,
+			fmt.Sprintf(`{"timestamp":"2025-10-31T10:00:%02dZ","type":"llm_request","conversation_id":"conv_1","prompt":"Prompt %d"}`, 2*i, i),
+			fmt.Sprintf(`{"timestamp":"2025-10-31T10:00:%02dZ","type":"file_read","conversation_id":"conv_1","file_path":"main.go"}`, 2*i+1))
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "session.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	manager := newTestManager(t)
+	if err := manager.registry.ConfigureAdapter("claude", adapters.Options{
+		EventTypes: adapters.EventTypeFilter{Exclude: []string{types.EventTypeFileRead}},
+	}); err != nil {
+		t.Fatalf("failed to configure adapter: %v", err)
+	}
+
+	result, err := manager.Backfill(context.Background(), BackfillConfig{AgentName: "claude", LogPath: logDir, DryRun: true})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	// Excluded events are dropped before they are counted
+	if result.TotalEvents != 4 || result.ProcessedEvents != 4 {
+		t.Errorf("Expected 4 total and processed events, got %d and %d", result.TotalEvents, result.ProcessedEvents)
+	}
+	if result.ErrorEvents != 0 {
+		t.Errorf("Expected filtered events not to count as errors, got %d", result.ErrorEvents)
+	}
+}
+
+// pausingAdapter cancels the backfill once it has been handed a number of
+// lines, as if the user pressed Ctrl+C mid-file
+type pausingAdapter struct {
+	adapters.AgentAdapter
+	cancel context.CancelFunc
+	after  int
+	lines  int
+}
+
+func (p *pausingAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	p.lines++
+	if p.cancel != nil && p.lines == p.after {
+		p.cancel()
+	}
+	return p.AgentAdapter.ParseLogLine(line)
+}
+
+func TestResume_ContinuesEveryPausedFile(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 3, 20)
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	adapter := &pausingAdapter{AgentAdapter: adapters.NewClaudeAdapter("test-project", nil, log)}
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), Logger: log})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		Buffer:      buf,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	// Pause each file two lines into its third batch
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(logDir, fmt.Sprintf("workspace-%02d", i), "session.jsonl")
+		paths = append(paths, path)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		adapter.cancel, adapter.after, adapter.lines = cancel, 12, 0
+		_, err := manager.Backfill(ctx, BackfillConfig{AgentName: "claude", LogPath: path, BatchSize: 5})
+		cancel()
+		if err != context.Canceled {
+			t.Fatalf("Expected the backfill of %s to be cancelled, got %v", path, err)
+		}
+	}
+	adapter.cancel = nil
+
+	result, err := manager.Resume(context.Background(), "claude")
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+
+	if len(result.ResumedFiles) != len(paths) {
+		t.Fatalf("Expected %d files resumed, got %v", len(paths), result.ResumedFiles)
+	}
+	for _, path := range paths {
+		state, err := manager.stateStore.Load("claude", path)
+		if err != nil {
+			t.Fatalf("failed to load state: %v", err)
+		}
+		if state.Status != StatusCompleted {
+			t.Errorf("Expected %s to be completed, got %s", path, state.Status)
+		}
+		if state.TotalEventsProcessed != 20 {
+			t.Errorf("Expected 20 events processed for %s, got %d", path, state.TotalEventsProcessed)
+		}
+	}
+	if result.ProcessedEvents != 60 {
+		t.Errorf("Expected 60 events processed across the resumed files, got %d", result.ProcessedEvents)
+	}
+
+	// Events pending in the interrupted batch are read again, not dropped
+	count, err := buf.Count()
+	if err != nil {
+		t.Fatalf("failed to count buffer: %v", err)
+	}
+	if count != 60 {
+		t.Errorf("Expected every event buffered exactly once, got %d", count)
+	}
+
+	// Nothing is left to resume
+	if _, err := manager.Resume(context.Background(), "claude"); !errors.Is(err, ErrNoPausedBackfill) {
+		t.Errorf("Expected ErrNoPausedBackfill after completion, got %v", err)
+	}
+}
+
+func TestResume_NoPausedBackfill(t *testing.T) {
+	manager := newTestManager(t)
+
+	result, err := manager.Resume(context.Background(), "claude")
+	if !errors.Is(err, ErrNoPausedBackfill) {
+		t.Fatalf("Expected ErrNoPausedBackfill, got %v", err)
+	}
+	if result != nil {
+		t.Errorf("Expected no result, got %+v", result)
+	}
+}
+
+func TestBackfill_SharesDedupWithLiveCollection(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 200)
+	logFile := filepath.Join(logDir, "workspace-00", "session.jsonl")
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	adapter := adapters.NewClaudeAdapter("test-project", nil, log)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: dbPath, Logger: log})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+
+	// Backfill and the live collector open the index separately, as two
+	// processes would
+	backfillIndex, err := dedup.NewIndex(dbPath, log)
+	if err != nil {
+		t.Fatalf("failed to open dedup index: %v", err)
+	}
+	defer backfillIndex.Close()
+	liveIndex, err := dedup.NewIndex(dbPath, log)
+	if err != nil {
+		t.Fatalf("failed to open dedup index: %v", err)
+	}
+	defer liveIndex.Close()
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		Buffer:      buf,
+		Dedup:       backfillIndex,
+		StateDBPath: dbPath,
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	// The live collector reads the same file while the backfill runs
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	liveSent := make(chan int)
+	go func() {
+		sent := 0
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			event, err := adapter.ParseLogLine(line)
+			if err != nil || event == nil {
+				continue
+			}
+			if liveIndex.Claim(buffer.EventHash(event)) {
+				sent++
+			}
+		}
+		liveSent <- sent
+	}()
+
+	if _, err := manager.Backfill(context.Background(), BackfillConfig{AgentName: "claude", LogPath: logFile, BatchSize: 10}); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	live := <-liveSent
+
+	backfilled, err := buf.Count()
+	if err != nil {
+		t.Fatalf("failed to count buffer: %v", err)
+	}
+	if live+backfilled != 200 {
+		t.Errorf("Expected each of 200 events sent once, live sent %d and backfill %d", live, backfilled)
+	}
+
+	// Resetting the sync state lets the events be sent again
+	if _, err := manager.Reset("claude"); err != nil {
+		t.Fatalf("failed to reset state: %v", err)
+	}
+	event, err := adapter.ParseLogLine(strings.SplitN(string(data), "\n", 2)[0])
+	if err != nil {
+		t.Fatalf("failed to parse line: %v", err)
+	}
+	if liveIndex.Seen(buffer.EventHash(event)) {
+		t.Error("Expected reset to clear the dedup index")
+	}
+}
+
+func TestBackfill_SegmentsInterleavedClaudeSessions(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("..", "adapters", "testdata", "claude-interleaved-sessions.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	logFile := filepath.Join(t.TempDir(), "archive.jsonl")
+	if err := os.WriteFile(logFile, content, 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewClaudeAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: dbPath, Logger: log})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		Buffer:      buf,
+		StateDBPath: dbPath,
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	if _, err := manager.Backfill(context.Background(), BackfillConfig{AgentName: "claude", LogPath: logFile, BatchSize: 4}); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+
+	events, err := buf.Peek(100, buffer.PeekFilter{})
+	if err != nil {
+		t.Fatalf("failed to peek buffer: %v", err)
+	}
+
+	// The archive's first conversation starts with the file; each other one
+	// is marked where it first appears
+	var starts []string
+	sessions := make(map[string]int)
+	for _, event := range events {
+		if event.Type == types.EventTypeSessionStart {
+			starts = append(starts, event.SessionID)
+			continue
+		}
+		sessions[event.SessionID]++
+	}
+	if strings.Join(starts, ",") != "conv_docs,conv_ci" {
+		t.Errorf("Expected session starts for conv_docs and conv_ci, got %v", starts)
+	}
+
+	// Lines without a conversation id stay with the conversation before them
+	want := map[string]int{"conv_api": 5, "conv_docs": 2, "conv_ci": 3}
+	for id, count := range want {
+		if sessions[id] != count {
+			t.Errorf("Expected %d events in %s, got %d", count, id, sessions[id])
+		}
+	}
+	if len(sessions) != len(want) {
+		t.Errorf("Expected sessions %v, got %v", want, sessions)
+	}
+}
+
+func TestBackfill_SkipsBinaryAndOversizedFiles(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 2, 3)
+
+	// A binary file with a log extension, and a log bigger than the limit
+	binary := filepath.Join(logDir, "workspace-00", "core.log")
+	if err := os.WriteFile(binary, []byte("\x7fELF\x02\x01\x01\x00\x00\x00"), 0644); err != nil {
+		t.Fatalf("failed to write binary file: %v", err)
+	}
+	oversized := filepath.Join(logDir, "workspace-01", "huge.jsonl")
+	line := `{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"big","prompt":"big","prompt_tokens":1}` + "\n"
+	if err := os.WriteFile(oversized, []byte(strings.Repeat(line, 100)), 0644); err != nil {
+		t.Fatalf("failed to write oversized file: %v", err)
+	}
+
+	manager := newTestManager(t)
+	manager.maxFileSize = 4 << 10
+
+	result, err := manager.Backfill(context.Background(), BackfillConfig{
+		AgentName: "claude",
+		LogPath:   logDir,
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	if result.TotalEvents != 6 {
+		t.Errorf("Expected 6 events from the text logs, got %d", result.TotalEvents)
+	}
+	skipped := map[string]bool{}
+	for _, path := range result.SkippedFiles {
+		skipped[path] = true
+	}
+	if len(skipped) != 2 || !skipped[binary] || !skipped[oversized] {
+		t.Errorf("Expected the binary and oversized files to be skipped, got %v", result.SkippedFiles)
+	}
+
+	states, err := manager.Status("claude")
+	if err != nil {
+		t.Fatalf("failed to list states: %v", err)
+	}
+	for _, state := range states {
+		if skipped[state.LogFilePath] {
+			t.Errorf("Skipped file has backfill state: %s", state.LogFilePath)
+		}
+	}
+}
+
+func TestCheckTextLog(t *testing.T) {
+	dir := t.TempDir()
+
+	// A multi-byte character straddling the end of the sniffed sample
+	straddling := append(bytes.Repeat([]byte("a"), sniffBytes-1), "é\n"...)
+
+	tests := []struct {
+		name    string
+		content []byte
+		text    bool
+	}{
+		{"Text log", []byte("{\"type\":\"llm_request\"}\n"), true},
+		{"Empty file", nil, true},
+		{"Null bytes", []byte("abc\x00def\n"), false},
+		{"Invalid UTF-8", []byte("abc\xff\xfe\n"), false},
+		{"Character cut by the sample", straddling, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("file-%d.log", i))
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+
+			reason, err := checkTextLog(path, 0)
+			if err != nil {
+				t.Fatalf("checkTextLog failed: %v", err)
+			}
+			if (reason == "") != tt.text {
+				t.Errorf("Expected text=%v, got reason %q", tt.text, reason)
+			}
+		})
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	tests := []struct {
+		name      string
+		done      int64
+		remaining int64
+		elapsed   time.Duration
+		expected  time.Duration
+	}{
+		{"Quarter read", 250, 1000, 10 * time.Second, 30 * time.Second},
+		{"Half read", 500, 1000, 4 * time.Second, 4 * time.Second},
+		{"Finished", 1000, 1000, 4 * time.Second, 0},
+		{"Nothing read yet", 0, 1000, 4 * time.Second, 0},
+		{"No time elapsed", 500, 1000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateRemaining(tt.done, tt.remaining, tt.elapsed); got != tt.expected {
+				t.Errorf("Expected %v remaining, got %v", tt.expected, got)
+			}
+		})
+	}
+}
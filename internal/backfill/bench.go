@@ -0,0 +1,204 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/watcher"
+)
+
+// BenchConfig specifies a parse throughput benchmark
+type BenchConfig struct {
+	Registry    *adapters.Registry
+	AgentName   string             // Adapter used for every file; empty detects per file
+	Concurrency int                // Files parsed concurrently; values below 1 mean sequential
+	Filter      watcher.PathFilter // Include/exclude patterns; zero value applies the default excludes
+}
+
+// BenchResult reports parse throughput. Nothing is sent or buffered.
+type BenchResult struct {
+	Concurrency  int
+	Files        int
+	SkippedFiles int // Files no adapter recognized
+	Events       int
+	ParseErrors  int
+	Bytes        int64
+	Duration     time.Duration
+}
+
+// FilesPerSec returns parsed files per second
+func (r *BenchResult) FilesPerSec() float64 {
+	return perSecond(float64(r.Files), r.Duration)
+}
+
+// EventsPerSec returns parsed events per second
+func (r *BenchResult) EventsPerSec() float64 {
+	return perSecond(float64(r.Events), r.Duration)
+}
+
+// MBPerSec returns parsed megabytes per second
+func (r *BenchResult) MBPerSec() float64 {
+	return perSecond(float64(r.Bytes)/(1024*1024), r.Duration)
+}
+
+func perSecond(n float64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return n / d.Seconds()
+}
+
+// Bench parses every log file under dir with the adapters' regular parse
+// paths and measures throughput at the configured concurrency
+func Bench(ctx context.Context, config BenchConfig, dir string) (*BenchResult, error) {
+	var fixed adapters.AgentAdapter
+	if config.AgentName != "" {
+		adapter, err := config.Registry.Get(config.AgentName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get adapter: %w", err)
+		}
+		fixed = adapter
+	}
+
+	files, err := findLogFiles(dir, config.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	workers := config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	result := &BenchResult{Concurrency: workers}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	start := time.Now()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				adapter := fixed
+				if adapter == nil {
+					adapter = detectAdapter(config.Registry, path)
+				}
+
+				mu.Lock()
+				if adapter == nil {
+					result.SkippedFiles++
+					mu.Unlock()
+					continue
+				}
+				mu.Unlock()
+
+				events, parseErrors, bytes := benchFile(adapter, path)
+
+				mu.Lock()
+				result.Files++
+				result.Events += events
+				result.ParseErrors += parseErrors
+				result.Bytes += bytes
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, path := range files {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- path:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result.Duration = time.Since(start)
+	return result, ctx.Err()
+}
+
+// benchFile parses one file the way backfill would and returns the number
+// of events, parse errors and bytes read
+func benchFile(adapter adapters.AgentAdapter, path string) (int, int, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 1, 0
+	}
+
+	if adapters.ParsesWholeFile(adapter, path) {
+		events, err := adapter.ParseLogFile(path)
+		if err != nil {
+			return 0, 1, info.Size()
+		}
+		return len(events), 0, info.Size()
+	}
+
+	file, err := adapters.OpenLogFile(path)
+	if err != nil {
+		return 0, 1, 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	const maxCapacity = 512 * 1024 // 512KB, as in line-based backfill
+	scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+
+	events, parseErrors := 0, 0
+	for scanner.Scan() {
+		event, err := adapter.ParseLogLine(scanner.Text())
+		if err != nil {
+			parseErrors++
+			continue
+		}
+		if event != nil {
+			events++
+		}
+	}
+	if scanner.Err() != nil {
+		parseErrors++
+	}
+
+	return events, parseErrors, info.Size()
+}
+
+// detectAdapter picks an adapter for path from its first line, falling back
+// to the whole document for JSON files such as Copilot chat sessions
+func detectAdapter(registry *adapters.Registry, path string) adapters.AgentAdapter {
+	file, err := adapters.OpenLogFile(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	firstLine, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil
+	}
+	if adapter, err := registry.DetectAdapter(strings.TrimSpace(firstLine)); err == nil {
+		return adapter
+	}
+
+	if adapters.LogExt(path) != ".json" {
+		return nil
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		return nil
+	}
+	if adapter, err := registry.DetectAdapter(firstLine + string(rest)); err == nil {
+		return adapter
+	}
+	return nil
+}
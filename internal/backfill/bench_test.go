@@ -0,0 +1,46 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBench_ReportsThroughput(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 6, 40)
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapters.NewClaudeAdapter("test-project", nil, log)); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	for _, agentName := range []string{"claude", ""} {
+		result, err := Bench(context.Background(), BenchConfig{
+			Registry:    registry,
+			AgentName:   agentName,
+			Concurrency: 3,
+		}, logDir)
+		if err != nil {
+			t.Fatalf("Bench(agent=%q) failed: %v", agentName, err)
+		}
+
+		if result.Files != 6 || result.SkippedFiles != 0 {
+			t.Errorf("agent=%q: expected 6 files parsed, got %d (%d skipped)", agentName, result.Files, result.SkippedFiles)
+		}
+		if result.Events != 240 {
+			t.Errorf("agent=%q: expected 240 events, got %d", agentName, result.Events)
+		}
+		if result.Concurrency != 3 {
+			t.Errorf("agent=%q: expected concurrency 3, got %d", agentName, result.Concurrency)
+		}
+		if result.FilesPerSec() <= 0 || result.EventsPerSec() <= 0 || result.MBPerSec() <= 0 {
+			t.Errorf("agent=%q: expected nonzero throughput, got %.1f files/s, %.1f events/s, %.3f MB/s",
+				agentName, result.FilesPerSec(), result.EventsPerSec(), result.MBPerSec())
+		}
+	}
+}
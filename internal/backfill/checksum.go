@@ -0,0 +1,136 @@
+package backfill
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// jsonClosers are trimmed from the end of the checksummed prefix. Appending
+// to a JSON document rewrites its closing brackets, which must not make the
+// append look like a rewrite.
+const jsonClosers = "]} \t\r\n"
+
+// tailChunk is how much of the end of a file is read at a time looking for
+// the end of its checksummed prefix
+const tailChunk = 4096
+
+// PrefixChecksum identifies the content of a whole-file log as it was last
+// parsed, so a re-scan can tell a file that was appended to (the prefix
+// still matches) from one that was rewritten. An append is parsed from
+// Length on.
+type PrefixChecksum struct {
+	Length int64  `json:"length"` // Bytes covered, trailing JSON closers excluded
+	SHA256 string `json:"sha256"`
+}
+
+// newPrefixChecksum checksums the content of the file at path before it is
+// parsed into events, returning the checksum and the size of the file. The
+// file is streamed rather than read into memory.
+func newPrefixChecksum(path string) (*PrefixChecksum, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	length, err := prefixLength(file, info.Size())
+	if err != nil {
+		return nil, 0, err
+	}
+	sum, err := hashPrefix(file, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &PrefixChecksum{Length: length, SHA256: sum}, info.Size(), nil
+}
+
+// prefixLength returns the length of the file of the given size once the
+// trailing JSON closers are trimmed, reading it backwards from the end
+func prefixLength(file *os.File, size int64) (int64, error) {
+	buf := make([]byte, tailChunk)
+	end := size
+	for end > 0 {
+		start := end - tailChunk
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		trimmed := bytes.TrimRight(chunk, jsonClosers)
+		if len(trimmed) > 0 {
+			return start + int64(len(trimmed)), nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// hashPrefix returns the hex SHA-256 of the first length bytes of file
+func hashPrefix(file *os.File, length int64) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(file, length)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// matches reports whether the file at path still starts with the
+// checksummed prefix, and its size
+func (c *PrefixChecksum) matches(path string) (bool, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, 0, err
+	}
+	if info.Size() < c.Length {
+		return false, info.Size(), nil
+	}
+	sum, err := hashPrefix(file, c.Length)
+	if err != nil {
+		return false, 0, err
+	}
+	return sum == c.SHA256, info.Size(), nil
+}
+
+// encodeChecksum serializes a checksum for the state table
+func encodeChecksum(checksum *PrefixChecksum) interface{} {
+	if checksum == nil {
+		return nil
+	}
+	data, err := json.Marshal(checksum)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// decodeChecksum reads a checksum stored by encodeChecksum
+func decodeChecksum(value sql.NullString) *PrefixChecksum {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	var checksum PrefixChecksum
+	if err := json.Unmarshal([]byte(value.String), &checksum); err != nil {
+		return nil
+	}
+	return &checksum
+}
@@ -0,0 +1,212 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// writeCopilotSession writes a Copilot session whose requests have the
+// given prompts, pretty-printed like VS Code does
+func writeCopilotSession(t *testing.T, path string, prompts ...string) {
+	t.Helper()
+
+	start := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	var requests []string
+	for i, prompt := range prompts {
+		requests = append(requests, fmt.Sprintf(
+			`    {"requestId":"req_%d","timestamp":%d,"message":{"text":%q},"response":[{"value":"Done"}]}`,
+			i, start.Add(time.Duration(i)*time.Minute).UnixMilli(), prompt))
+	}
+	session := "{\n  \"version\": 3,\n  \"requests\": [\n" + strings.Join(requests, ",\n") + "\n  ]\n}\n"
+	if err := os.WriteFile(path, []byte(session), 0644); err != nil {
+		t.Fatalf("failed to write session file: %v", err)
+	}
+}
+
+func TestBackfill_WholeFileChangeDetection(t *testing.T) {
+	// Each request yields a request and a response event; with summaries on,
+	// the session's summary follows its last event, once
+	tests := []struct {
+		name      string
+		summaries bool
+		extra     int // events the first parse adds to the requests'
+	}{
+		{"Plain", false, 0},
+		{"SessionSummaries", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testWholeFileChangeDetection(t, adapters.Options{SessionSummaries: tt.summaries}, tt.extra)
+		})
+	}
+}
+
+// testWholeFileChangeDetection scans a Copilot session parsed with opts as it
+// is appended to and rewritten, extra being the events the first parse adds
+// to those of its requests
+func testWholeFileChangeDetection(t *testing.T, opts adapters.Options, extra int) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	adapter := adapters.NewCopilotAdapter("test-project", nil, log)
+	adapter.SetOptions(opts)
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	buf, err := buffer.NewBuffer(buffer.Config{DBPath: dbPath, Logger: log})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		Buffer:      buf,
+		StateDBPath: dbPath,
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	logFile := filepath.Join(t.TempDir(), "session.json")
+	config := BackfillConfig{AgentName: "github-copilot", LogPath: logFile}
+	backfill := func() *BackfillResult {
+		t.Helper()
+		result, err := manager.Backfill(context.Background(), config)
+		if err != nil {
+			t.Fatalf("backfill failed: %v", err)
+		}
+		return result
+	}
+	// buffered returns and clears the events the last scan buffered
+	buffered := func() []*types.AgentEvent {
+		t.Helper()
+		events, err := buf.Peek(100, buffer.PeekFilter{})
+		if err != nil {
+			t.Fatalf("failed to peek buffer: %v", err)
+		}
+		if err := buf.Clear(); err != nil {
+			t.Fatalf("failed to clear buffer: %v", err)
+		}
+		return events
+	}
+	recorded := func() int {
+		t.Helper()
+		state, err := manager.stateStore.Load("github-copilot", logFile)
+		if err != nil {
+			t.Fatalf("failed to load state: %v", err)
+		}
+		if state.Checksum == nil {
+			t.Fatal("Expected the parsed content to be checksummed")
+		}
+		return state.TotalEventsProcessed
+	}
+
+	writeCopilotSession(t, logFile, "First", "Second")
+	if result := backfill(); result.ProcessedEvents != 4+extra {
+		t.Fatalf("Expected %d events from the first scan, got %d", 4+extra, result.ProcessedEvents)
+	}
+	buffered()
+
+	t.Run("Unchanged", func(t *testing.T) {
+		if result := backfill(); result.ProcessedEvents != 0 {
+			t.Errorf("Expected an unchanged file to be skipped, got %d events", result.ProcessedEvents)
+		}
+		if total := recorded(); total != 4+extra {
+			t.Errorf("Expected %d recorded events, got %d", 4+extra, total)
+		}
+	})
+
+	t.Run("Appended", func(t *testing.T) {
+		writeCopilotSession(t, logFile, "First", "Second", "Third")
+		result := backfill()
+		if result.TotalEvents != 2 || result.ProcessedEvents != 2 {
+			t.Errorf("Expected only the appended request's events, got %+v", *result)
+		}
+		var prompts []string
+		for _, event := range buffered() {
+			if event.Type == types.EventTypeLLMRequest {
+				prompts = append(prompts, event.Data["prompt"].(string))
+			}
+		}
+		if len(prompts) != 1 || prompts[0] != "Third" {
+			t.Errorf("Expected the request for Third, got prompts %v", prompts)
+		}
+		if total := recorded(); total != 6+extra {
+			t.Errorf("Expected %d recorded events after the append, got %d", 6+extra, total)
+		}
+	})
+
+	t.Run("Rewritten", func(t *testing.T) {
+		writeCopilotSession(t, logFile, "First, edited", "Second", "Third")
+		result := backfill()
+		if result.TotalEvents != 6 || result.ProcessedEvents != 6 {
+			t.Errorf("Expected the rewritten file to be parsed whole, got %+v", *result)
+		}
+		if total := recorded(); total != 6 {
+			t.Errorf("Expected the counts to start over at 6, got %d", total)
+		}
+		buffered()
+	})
+}
+
+func TestPrefixChecksum_Matches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write session file: %v", err)
+		}
+	}
+
+	// Closers past a chunk of the tail are trimmed too
+	original := `{"requests":[{"id":1}]}` + "\n" + strings.Repeat(" ", 2*tailChunk)
+	write(original)
+	checksum, size, err := newPrefixChecksum(path)
+	if err != nil {
+		t.Fatalf("failed to checksum: %v", err)
+	}
+	if size != int64(len(original)) {
+		t.Errorf("Expected size %d, got %d", len(original), size)
+	}
+	if want := int64(len(`{"requests":[{"id":1`)); checksum.Length != want {
+		t.Errorf("Expected the closers to be trimmed to %d bytes, got %d", want, checksum.Length)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"Unchanged", original, true},
+		{"Appended to the document", `{"requests":[{"id":1},{"id":2}]}`, true},
+		{"Edited", `{"requests":[{"id":9}]}`, false},
+		{"Truncated", `{"requests":[`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.content)
+			got, _, err := checksum.matches(path)
+			if err != nil {
+				t.Fatalf("failed to match: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected match %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package backfill
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID returns a device:inode identifier for the file, used to notice when
+// a log path has been replaced by a different file
+func fileID(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package backfill
+
+import "os"
+
+// fileID is not available on Windows; rotation is detected by size alone
+func fileID(info os.FileInfo) string {
+	return ""
+}
@@ -0,0 +1,67 @@
+package backfill
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// quarantineSuffix is appended to a log file's path to name the file its
+// unparseable lines are written to
+const quarantineSuffix = ".parse-errors"
+
+// QuarantinePath returns the file that lines of logPath failing to parse are
+// written to when BackfillConfig.DumpErrors is set
+func QuarantinePath(logPath string) string {
+	return logPath + quarantineSuffix
+}
+
+// quarantine collects the raw lines of a log file that failed to parse, one
+// per line as "<line number>\t<line>". The file is only created once the
+// first line is written, so clean logs leave nothing behind. A nil
+// quarantine discards everything.
+type quarantine struct {
+	path   string
+	resume bool // append to the file of an earlier, interrupted run
+	file   *os.File
+	w      *bufio.Writer
+}
+
+// newQuarantine returns the quarantine for logPath. A run resumed from an
+// offset appends to the existing file; a run from the start replaces it.
+func newQuarantine(logPath string, resume bool) *quarantine {
+	return &quarantine{path: QuarantinePath(logPath), resume: resume}
+}
+
+// write records line, found at lineNum, as unparseable
+func (q *quarantine) write(lineNum int, line string) error {
+	if q == nil {
+		return nil
+	}
+	if q.file == nil {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if q.resume {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(q.path, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create quarantine file: %w", err)
+		}
+		q.file = file
+		q.w = bufio.NewWriter(file)
+	}
+	_, err := fmt.Fprintf(q.w, "%d\t%s\n", lineNum, line)
+	return err
+}
+
+// Close flushes and closes the quarantine file, if one was created
+func (q *quarantine) Close() error {
+	if q == nil || q.file == nil {
+		return nil
+	}
+	err := q.w.Flush()
+	if cerr := q.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
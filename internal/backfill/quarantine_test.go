@@ -0,0 +1,146 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// strictAdapter reports lines that aren't JSON as parse errors, where the
+// Claude adapter it wraps skips them
+type strictAdapter struct {
+	adapters.AgentAdapter
+}
+
+func (s *strictAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	if !json.Valid([]byte(line)) {
+		return nil, fmt.Errorf("malformed JSON")
+	}
+	return s.AgentAdapter.ParseLogLine(line)
+}
+
+func newStrictManager(t *testing.T) *BackfillManager {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	registry := adapters.NewRegistry()
+	if err := registry.Register(&strictAdapter{adapters.NewClaudeAdapter("test-project", nil, log)}); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: filepath.Join(t.TempDir(), "state.db"),
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+
+	return manager
+}
+
+// writeMixedLog writes a Claude log whose 2nd and 4th lines are malformed
+func writeMixedLog(t *testing.T) string {
+	t.Helper()
+
+	lines := []string{
+		`{"timestamp":"2025-10-31T10:00:00Z","type":"llm_request","conversation_id":"conv_1","prompt":"Hello","prompt_tokens":1}`,
+		`{"timestamp":"2025-10-31T10:00:01Z","type":"llm_resp`,
+		`{"timestamp":"2025-10-31T10:00:02Z","level":"debug","message":"noise"}`,
+		`not json at all`,
+		`{"timestamp":"2025-10-31T10:00:03Z","type":"llm_request","conversation_id":"conv_1","prompt":"Again","prompt_tokens":1}`,
+	}
+	logFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+	return logFile
+}
+
+func TestBackfill_DumpErrorsQuarantinesMalformedLines(t *testing.T) {
+	logFile := writeMixedLog(t)
+	manager := newStrictManager(t)
+
+	result, err := manager.Backfill(context.Background(), BackfillConfig{
+		AgentName:  "claude",
+		LogPath:    logFile,
+		DryRun:     true,
+		DumpErrors: true,
+	})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	if result.ProcessedEvents != 2 {
+		t.Errorf("Expected 2 processed events, got %d", result.ProcessedEvents)
+	}
+	if got := result.ParseErrors[logFile]; got != 2 || len(result.ParseErrors) != 1 {
+		t.Errorf("Expected 2 parse errors for %s, got %v", logFile, result.ParseErrors)
+	}
+
+	data, err := os.ReadFile(QuarantinePath(logFile))
+	if err != nil {
+		t.Fatalf("failed to read quarantine file: %v", err)
+	}
+	expected := "2\t" + `{"timestamp":"2025-10-31T10:00:01Z","type":"llm_resp` + "\n" +
+		"4\tnot json at all\n"
+	if string(data) != expected {
+		t.Errorf("Expected quarantine file:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestBackfill_ParseErrorsWithoutDump(t *testing.T) {
+	logFile := writeMixedLog(t)
+	manager := newStrictManager(t)
+
+	result, err := manager.BackfillPaths(context.Background(), BackfillConfig{
+		AgentName: "claude",
+		DryRun:    true,
+	}, []string{filepath.Dir(logFile)})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	if got := result.ParseErrors[logFile]; got != 2 {
+		t.Errorf("Expected 2 parse errors for %s, got %v", logFile, result.ParseErrors)
+	}
+	if _, err := os.Stat(QuarantinePath(logFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no quarantine file without DumpErrors, got %v", err)
+	}
+}
+
+func TestBackfill_DumpErrorsLeavesCleanLogsAlone(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 3)
+	logFile := filepath.Join(logDir, "workspace-00", "session.jsonl")
+	manager := newTestManager(t)
+
+	result, err := manager.Backfill(context.Background(), BackfillConfig{
+		AgentName:  "claude",
+		LogPath:    logFile,
+		DryRun:     true,
+		DumpErrors: true,
+	})
+	if err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	if result.ParseErrors != nil {
+		t.Errorf("Expected no parse errors, got %v", result.ParseErrors)
+	}
+	if _, err := os.Stat(QuarantinePath(logFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no quarantine file for a clean log, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package backfill
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/codervisor/devlog/internal/adapters"
+)
+
+// sniffBytes is how much of a file is read to decide whether it is text
+const sniffBytes = 8 << 10
+
+// checkTextLog returns why filePath shouldn't be handed to an adapter, or ""
+// if it looks like a text log. Files larger than maxSize (0 = no limit) are
+// rejected outright; otherwise the first bytes, decompressed for gzipped
+// logs, must be valid UTF-8 without null bytes.
+func checkTextLog(filePath string, maxSize int64) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return fmt.Sprintf("file is %d bytes, over the %d byte limit", info.Size(), maxSize), nil
+	}
+
+	r, err := adapters.OpenLogFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	head = head[:n]
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "file contains null bytes", nil
+	}
+	// The sample may end partway through a multi-byte character
+	if n == sniffBytes {
+		head = trimPartialRune(head)
+	}
+	if !utf8.Valid(head) {
+		return "file is not valid UTF-8", nil
+	}
+	return "", nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of b
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
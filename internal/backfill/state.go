@@ -3,6 +3,7 @@ package backfill
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -17,6 +18,7 @@ const (
 	StatusPaused     BackfillStatus = "paused"
 	StatusCompleted  BackfillStatus = "completed"
 	StatusFailed     BackfillStatus = "failed"
+	StatusRetrying   BackfillStatus = "retrying" // Errored, but still within the grace policy
 )
 
 // BackfillState represents the persisted state of a backfill operation
@@ -31,11 +33,20 @@ type BackfillState struct {
 	StartedAt            time.Time
 	CompletedAt          *time.Time
 	ErrorMessage         string
+	RetryCount           int             // Consecutive failed attempts
+	FirstErrorAt         *time.Time      // Start of the current run of failures
+	FileID               string          // device:inode of the log when last processed
+	Summary              *FileSummary    // Events recorded as processed; nil if backfilled before summaries were kept
+	Checksum             *PrefixChecksum // Parsed content of a whole-file log; nil for line-based logs
 }
 
-// StateStore manages backfill state persistence
+// StateStore manages backfill state persistence. It is safe for concurrent
+// use; access is serialized so parallel backfill workers don't contend for
+// SQLite's single writer.
 type StateStore struct {
-	db *sql.DB
+	db       *sql.DB
+	mu       sync.Mutex
+	readOnly bool // Save is a no-op, for previews that must leave no trace
 }
 
 // NewStateStore creates a new state store
@@ -69,6 +80,9 @@ func (s *StateStore) initSchema() error {
 		started_at INTEGER NOT NULL,
 		completed_at INTEGER,
 		error_message TEXT,
+		retry_count INTEGER NOT NULL DEFAULT 0,
+		first_error_at INTEGER,
+		file_id TEXT,
 		UNIQUE(agent_name, log_file_path)
 	);
 
@@ -78,22 +92,71 @@ func (s *StateStore) initSchema() error {
 		ON backfill_state(agent_name);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.migrate()
+}
+
+// migrate adds columns introduced after the initial schema to existing databases
+func (s *StateStore) migrate() error {
+	rows, err := s.db.Query("PRAGMA table_info(backfill_state)")
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	columns := []struct{ name, def string }{
+		{"retry_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"first_error_at", "INTEGER"},
+		{"file_id", "TEXT"},
+		{"summary", "TEXT"},
+		{"checksum", "TEXT"},
+	}
+	for _, col := range columns {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE backfill_state ADD COLUMN %s %s", col.name, col.def)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", col.name, err)
+		}
+	}
+
+	return nil
 }
 
 // Load retrieves the backfill state for an agent and log file
 func (s *StateStore) Load(agentName, logFilePath string) (*BackfillState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	query := `
 		SELECT id, agent_name, log_file_path, last_byte_offset, last_timestamp,
-		       total_events_processed, status, started_at, completed_at, error_message
+		       total_events_processed, status, started_at, completed_at, error_message,
+		       retry_count, first_error_at, file_id, summary, checksum
 		FROM backfill_state
 		WHERE agent_name = ? AND log_file_path = ?
 	`
 
 	var state BackfillState
-	var lastTimestamp, startedAt, completedAt sql.NullInt64
-	var errorMessage sql.NullString
+	var lastTimestamp, startedAt, completedAt, firstErrorAt sql.NullInt64
+	var errorMessage, fileID, summary, checksum sql.NullString
 
 	err := s.db.QueryRow(query, agentName, logFilePath).Scan(
 		&state.ID,
@@ -106,6 +169,11 @@ func (s *StateStore) Load(agentName, logFilePath string) (*BackfillState, error)
 		&startedAt,
 		&completedAt,
 		&errorMessage,
+		&state.RetryCount,
+		&firstErrorAt,
+		&fileID,
+		&summary,
+		&checksum,
 	)
 
 	if err == sql.ErrNoRows {
@@ -137,12 +205,26 @@ func (s *StateStore) Load(agentName, logFilePath string) (*BackfillState, error)
 	if errorMessage.Valid {
 		state.ErrorMessage = errorMessage.String
 	}
+	if firstErrorAt.Valid {
+		t := time.Unix(firstErrorAt.Int64, 0)
+		state.FirstErrorAt = &t
+	}
+	state.FileID = fileID.String
+	state.Summary = decodeSummary(summary)
+	state.Checksum = decodeChecksum(checksum)
 
 	return &state, nil
 }
 
-// Save persists the backfill state
+// Save persists the backfill state. A read-only store discards it.
 func (s *StateStore) Save(state *BackfillState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return nil
+	}
+
 	if state.ID == 0 {
 		// Insert new state
 		return s.insert(state)
@@ -157,17 +239,21 @@ func (s *StateStore) insert(state *BackfillState) error {
 	query := `
 		INSERT INTO backfill_state (
 			agent_name, log_file_path, last_byte_offset, last_timestamp,
-			total_events_processed, status, started_at, completed_at, error_message
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			total_events_processed, status, started_at, completed_at, error_message,
+			retry_count, first_error_at, file_id, summary, checksum
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	var lastTimestamp, completedAt interface{}
+	var lastTimestamp, completedAt, firstErrorAt interface{}
 	if state.LastTimestamp != nil {
 		lastTimestamp = state.LastTimestamp.Unix()
 	}
 	if state.CompletedAt != nil {
 		completedAt = state.CompletedAt.Unix()
 	}
+	if state.FirstErrorAt != nil {
+		firstErrorAt = state.FirstErrorAt.Unix()
+	}
 
 	result, err := s.db.Exec(
 		query,
@@ -180,6 +266,11 @@ func (s *StateStore) insert(state *BackfillState) error {
 		state.StartedAt.Unix(),
 		completedAt,
 		state.ErrorMessage,
+		state.RetryCount,
+		firstErrorAt,
+		state.FileID,
+		encodeSummary(state.Summary),
+		encodeChecksum(state.Checksum),
 	)
 
 	if err != nil {
@@ -204,17 +295,25 @@ func (s *StateStore) update(state *BackfillState) error {
 		    total_events_processed = ?,
 		    status = ?,
 		    completed_at = ?,
-		    error_message = ?
+		    error_message = ?,
+		    retry_count = ?,
+		    first_error_at = ?,
+		    file_id = ?,
+		    summary = ?,
+		    checksum = ?
 		WHERE id = ?
 	`
 
-	var lastTimestamp, completedAt interface{}
+	var lastTimestamp, completedAt, firstErrorAt interface{}
 	if state.LastTimestamp != nil {
 		lastTimestamp = state.LastTimestamp.Unix()
 	}
 	if state.CompletedAt != nil {
 		completedAt = state.CompletedAt.Unix()
 	}
+	if state.FirstErrorAt != nil {
+		firstErrorAt = state.FirstErrorAt.Unix()
+	}
 
 	_, err := s.db.Exec(
 		query,
@@ -224,6 +323,11 @@ func (s *StateStore) update(state *BackfillState) error {
 		state.Status,
 		completedAt,
 		state.ErrorMessage,
+		state.RetryCount,
+		firstErrorAt,
+		state.FileID,
+		encodeSummary(state.Summary),
+		encodeChecksum(state.Checksum),
 		state.ID,
 	)
 
@@ -236,9 +340,13 @@ func (s *StateStore) update(state *BackfillState) error {
 
 // ListByAgent returns all backfill states for an agent
 func (s *StateStore) ListByAgent(agentName string) ([]*BackfillState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	query := `
 		SELECT id, agent_name, log_file_path, last_byte_offset, last_timestamp,
-		       total_events_processed, status, started_at, completed_at, error_message
+		       total_events_processed, status, started_at, completed_at, error_message,
+		       retry_count, first_error_at, file_id, summary, checksum
 		FROM backfill_state
 		WHERE agent_name = ?
 		ORDER BY started_at DESC
@@ -254,8 +362,8 @@ func (s *StateStore) ListByAgent(agentName string) ([]*BackfillState, error) {
 
 	for rows.Next() {
 		var state BackfillState
-		var lastTimestamp, startedAt, completedAt sql.NullInt64
-		var errorMessage sql.NullString
+		var lastTimestamp, startedAt, completedAt, firstErrorAt sql.NullInt64
+		var errorMessage, fileID, summary, checksum sql.NullString
 
 		err := rows.Scan(
 			&state.ID,
@@ -268,6 +376,11 @@ func (s *StateStore) ListByAgent(agentName string) ([]*BackfillState, error) {
 			&startedAt,
 			&completedAt,
 			&errorMessage,
+			&state.RetryCount,
+			&firstErrorAt,
+			&fileID,
+			&summary,
+			&checksum,
 		)
 
 		if err != nil {
@@ -288,6 +401,13 @@ func (s *StateStore) ListByAgent(agentName string) ([]*BackfillState, error) {
 		if errorMessage.Valid {
 			state.ErrorMessage = errorMessage.String
 		}
+		if firstErrorAt.Valid {
+			t := time.Unix(firstErrorAt.Int64, 0)
+			state.FirstErrorAt = &t
+		}
+		state.FileID = fileID.String
+		state.Summary = decodeSummary(summary)
+		state.Checksum = decodeChecksum(checksum)
 
 		states = append(states, &state)
 	}
@@ -297,10 +417,41 @@ func (s *StateStore) ListByAgent(agentName string) ([]*BackfillState, error) {
 
 // Delete removes a backfill state
 func (s *StateStore) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	_, err := s.db.Exec("DELETE FROM backfill_state WHERE id = ?", id)
 	return err
 }
 
+// DeleteByAgent removes every backfill state of an agent, so its logs are
+// processed from the start on the next sync. It returns the number removed.
+func (s *StateStore) DeleteByAgent(agentName string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM backfill_state WHERE agent_name = ?", agentName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete states for %s: %w", agentName, err)
+	}
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
+
+// ResetAll removes the backfill states of all agents and returns the
+// number removed
+func (s *StateStore) ResetAll() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM backfill_state")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete states: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
+
 // Close closes the database connection
 func (s *StateStore) Close() error {
 	return s.db.Close()
@@ -0,0 +1,104 @@
+package backfill
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStateStore(t *testing.T) *StateStore {
+	t.Helper()
+
+	store, err := NewStateStore(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("failed to create state store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	states := []struct{ agent, path string }{
+		{"github-copilot", "/logs/a.json"},
+		{"github-copilot", "/logs/b.json"},
+		{"claude", "/logs/c.jsonl"},
+	}
+	for _, s := range states {
+		state := &BackfillState{
+			AgentName:   s.agent,
+			LogFilePath: s.path,
+			Status:      StatusCompleted,
+			StartedAt:   time.Now(),
+		}
+		if err := store.Save(state); err != nil {
+			t.Fatalf("failed to save state: %v", err)
+		}
+	}
+	return store
+}
+
+func TestStateStore_DeleteByAgent(t *testing.T) {
+	store := newTestStateStore(t)
+
+	deleted, err := store.DeleteByAgent("github-copilot")
+	if err != nil {
+		t.Fatalf("DeleteByAgent failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted states, got %d", deleted)
+	}
+
+	remaining, err := store.ListByAgent("github-copilot")
+	if err != nil {
+		t.Fatalf("failed to list states: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected no copilot states, got %d", len(remaining))
+	}
+
+	// Other agents are untouched
+	claude, err := store.ListByAgent("claude")
+	if err != nil {
+		t.Fatalf("failed to list states: %v", err)
+	}
+	if len(claude) != 1 {
+		t.Errorf("Expected 1 claude state, got %d", len(claude))
+	}
+
+	// Nothing left to delete
+	deleted, err = store.DeleteByAgent("github-copilot")
+	if err != nil {
+		t.Fatalf("DeleteByAgent failed: %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("Expected 0 deleted states, got %d", deleted)
+	}
+}
+
+func TestStateStore_ResetAll(t *testing.T) {
+	store := newTestStateStore(t)
+
+	deleted, err := store.ResetAll()
+	if err != nil {
+		t.Fatalf("ResetAll failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 deleted states, got %d", deleted)
+	}
+
+	for _, agent := range []string{"github-copilot", "claude"} {
+		states, err := store.ListByAgent(agent)
+		if err != nil {
+			t.Fatalf("failed to list states: %v", err)
+		}
+		if len(states) != 0 {
+			t.Errorf("Expected no %s states after reset, got %d", agent, len(states))
+		}
+	}
+
+	// A reset file is loaded as new again
+	state, err := store.Load("claude", "/logs/c.jsonl")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if state.Status == StatusCompleted {
+		t.Error("Expected the reset file to no longer be completed")
+	}
+}
@@ -0,0 +1,256 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// FileSummary counts the events of a log file by type, with their tokens,
+// and the turns and tokens of each model its LLM events used
+type FileSummary struct {
+	Events int                            `json:"events"`
+	ByType map[string]int                 `json:"byType"`
+	Tokens int                            `json:"tokens"`
+	Models map[string]adapters.ModelUsage `json:"models,omitempty"`
+
+	models adapters.ModelTally
+}
+
+// add counts events into the summary
+func (s *FileSummary) add(events []*types.AgentEvent) {
+	if s.ByType == nil {
+		s.ByType = make(map[string]int)
+	}
+	s.models.Usage = s.Models
+	for _, event := range events {
+		s.Events++
+		s.ByType[event.Type]++
+		s.Tokens += eventTokens(event)
+		s.models.Add(event)
+	}
+	s.Models = s.models.Usage
+}
+
+// summarize returns the summary of events
+func summarize(events []*types.AgentEvent) *FileSummary {
+	summary := &FileSummary{}
+	summary.add(events)
+	return summary
+}
+
+// eventTokens returns the tokens an event accounts for
+func eventTokens(event *types.AgentEvent) int {
+	if event.Metrics == nil {
+		return 0
+	}
+	if event.Metrics.TokenCount > 0 {
+		return event.Metrics.TokenCount
+	}
+	return event.Metrics.PromptTokens + event.Metrics.ResponseTokens
+}
+
+// encodeSummary serializes a summary for the state database
+func encodeSummary(summary *FileSummary) interface{} {
+	if summary == nil {
+		return nil
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// decodeSummary reads a summary stored by encodeSummary
+func decodeSummary(value sql.NullString) *FileSummary {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	var summary FileSummary
+	if err := json.Unmarshal([]byte(value.String), &summary); err != nil {
+		return nil
+	}
+	return &summary
+}
+
+// FileDrift compares a fresh parse of a log file with what was recorded
+// when it was backfilled
+type FileDrift struct {
+	FilePath       string
+	Status         BackfillStatus
+	RecordedEvents int          // TotalEventsProcessed from the backfill state
+	Recorded       *FileSummary // nil when no summary was recorded
+	Parsed         *FileSummary
+	Err            error // Set when the file could not be parsed
+}
+
+// Differences describes how the parse differs from the recorded backfill,
+// one line per difference. Files backfilled before summaries were recorded
+// are compared by event count only.
+func (d *FileDrift) Differences() []string {
+	if d.Err != nil {
+		return []string{fmt.Sprintf("parse failed: %v", d.Err)}
+	}
+	if d.Status != StatusCompleted {
+		if d.Parsed.Events == 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("not backfilled (status %s), %d events to send", d.Status, d.Parsed.Events)}
+	}
+
+	var diffs []string
+	recorded := d.RecordedEvents
+	if d.Recorded != nil {
+		recorded = d.Recorded.Events
+	}
+	if recorded != d.Parsed.Events {
+		diffs = append(diffs, fmt.Sprintf("events: %d → %d", recorded, d.Parsed.Events))
+	}
+	if d.Recorded == nil {
+		return diffs
+	}
+
+	seen := make(map[string]bool)
+	for eventType := range d.Recorded.ByType {
+		seen[eventType] = true
+	}
+	for eventType := range d.Parsed.ByType {
+		seen[eventType] = true
+	}
+	sorted := make([]string, 0, len(seen))
+	for eventType := range seen {
+		sorted = append(sorted, eventType)
+	}
+	sort.Strings(sorted)
+	for _, eventType := range sorted {
+		before, after := d.Recorded.ByType[eventType], d.Parsed.ByType[eventType]
+		if before != after {
+			diffs = append(diffs, fmt.Sprintf("%s: %d → %d", eventType, before, after))
+		}
+	}
+
+	if d.Recorded.Tokens != d.Parsed.Tokens {
+		diffs = append(diffs, fmt.Sprintf("tokens: %d → %d", d.Recorded.Tokens, d.Parsed.Tokens))
+	}
+	return diffs
+}
+
+// Drifted reports whether the parse differs from the recorded backfill
+func (d *FileDrift) Drifted() bool {
+	return len(d.Differences()) > 0
+}
+
+// Verify re-parses the log files under paths and compares each with its
+// recorded backfill state, without sending events or changing any state.
+// Use it after adapter changes to find files whose events would now differ.
+// The date range of config should match the one the files were backfilled with.
+func (bm *BackfillManager) Verify(ctx context.Context, config BackfillConfig, paths []string) ([]*FileDrift, error) {
+	adapter, err := bm.registry.Get(config.AgentName)
+	if err != nil {
+		return nil, fmt.Errorf("no adapter found for agent %s: %w", config.AgentName, err)
+	}
+
+	var logFiles []string
+	for _, path := range paths {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat log path: %w", err)
+		}
+		if !fileInfo.IsDir() {
+			logFiles = append(logFiles, path)
+			continue
+		}
+		files, err := findLogFiles(path, config.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", path, err)
+		}
+		logFiles = append(logFiles, files...)
+	}
+
+	var drifts []*FileDrift
+	for _, filePath := range logFiles {
+		if err := ctx.Err(); err != nil {
+			return drifts, err
+		}
+
+		state, err := bm.stateStore.Load(config.AgentName, filePath)
+		if err != nil {
+			return drifts, fmt.Errorf("failed to load state: %w", err)
+		}
+
+		drift := &FileDrift{
+			FilePath:       filePath,
+			Status:         state.Status,
+			RecordedEvents: state.TotalEventsProcessed,
+			Recorded:       state.Summary,
+		}
+		events, err := bm.parseForVerify(config, adapter, filePath)
+		if err != nil {
+			drift.Err = err
+		} else {
+			drift.Parsed = summarize(events)
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+// parseForVerify parses a log file the way backfill does, keeping the
+// events that fall in the configured date range
+func (bm *BackfillManager) parseForVerify(config BackfillConfig, adapter adapters.AgentAdapter, filePath string) ([]*types.AgentEvent, error) {
+	var events []*types.AgentEvent
+
+	if bm.shouldUseFileParsing(adapter, filePath) {
+		var err error
+		if rangeParser, ok := adapter.(adapters.RangeParser); ok && (!config.FromDate.IsZero() || !config.ToDate.IsZero()) {
+			events, err = rangeParser.ParseLogFileInRange(filePath, config.FromDate, config.ToDate)
+		} else {
+			events, err = adapter.ParseLogFile(filePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse file: %w", err)
+		}
+	} else {
+		file, err := adapters.OpenLogFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		const maxCapacity = 512 * 1024 // Same limit as line-based backfill
+		scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+		for scanner.Scan() {
+			// Lines backfill counts as parse errors are not sent either
+			parsed, err := adapters.ParseLine(adapter, filePath, scanner.Text())
+			if err == nil {
+				events = append(events, parsed...)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("scanner error: %w", err)
+		}
+		events = append(events, adapters.EndOfLog(adapter, filePath)...)
+	}
+
+	inRange := events[:0]
+	for _, event := range events {
+		if !config.FromDate.IsZero() && event.Timestamp.Before(config.FromDate) {
+			continue
+		}
+		if !config.ToDate.IsZero() && event.Timestamp.After(config.ToDate) {
+			continue
+		}
+		inRange = append(inRange, event)
+	}
+	return inRange, nil
+}
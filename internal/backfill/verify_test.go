@@ -0,0 +1,163 @@
+package backfill
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// retypingAdapter simulates a parser change: every other event it parses
+// becomes a tool_use event instead of its original type
+type retypingAdapter struct {
+	adapters.AgentAdapter
+	parsed int
+}
+
+func (r *retypingAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	event, err := r.AgentAdapter.ParseLogLine(line)
+	if err != nil || event == nil {
+		return event, err
+	}
+	r.parsed++
+	if r.parsed%2 == 0 {
+		event.Type = types.EventTypeToolUse
+	}
+	return event, nil
+}
+
+// newVerifyManager creates a manager on the shared state database whose
+// Claude adapter is wrapped by wrap, if set
+func newVerifyManager(t *testing.T, statePath string, wrap func(adapters.AgentAdapter) adapters.AgentAdapter) *BackfillManager {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	var adapter adapters.AgentAdapter = adapters.NewClaudeAdapter("test-project", nil, log)
+	if wrap != nil {
+		adapter = wrap(adapter)
+	}
+	registry := adapters.NewRegistry()
+	if err := registry.Register(adapter); err != nil {
+		t.Fatalf("failed to register adapter: %v", err)
+	}
+
+	manager, err := NewBackfillManager(Config{
+		Registry:    registry,
+		StateDBPath: statePath,
+		Logger:      log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func TestVerify_DetectsParserDrift(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 2, 10)
+	statePath := filepath.Join(t.TempDir(), "state.db")
+	config := BackfillConfig{AgentName: "claude", DryRun: true}
+
+	manager := newVerifyManager(t, statePath, nil)
+	if _, err := manager.BackfillPaths(context.Background(), config, []string{logDir}); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	// Re-parsing with the same parser matches what was recorded
+	drifts, err := manager.Verify(context.Background(), config, []string{logDir})
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if len(drifts) != 2 {
+		t.Fatalf("expected 2 files verified, got %d", len(drifts))
+	}
+	for _, drift := range drifts {
+		if drift.Drifted() {
+			t.Errorf("unexpected drift for %s: %v", drift.FilePath, drift.Differences())
+		}
+		if drift.Recorded == nil || drift.Recorded.Events != 10 || drift.Recorded.Tokens != 20 {
+			t.Errorf("expected a recorded summary of 10 events and 20 tokens, got %+v", drift.Recorded)
+		}
+	}
+
+	// After a parser change the type breakdown differs
+	changed := newVerifyManager(t, statePath, func(a adapters.AgentAdapter) adapters.AgentAdapter {
+		return &retypingAdapter{AgentAdapter: a}
+	})
+	drifts, err = changed.Verify(context.Background(), config, []string{logDir})
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	for _, drift := range drifts {
+		diffs := drift.Differences()
+		want := []string{"llm_request: 10 → 5", "tool_use: 0 → 5"}
+		if strings.Join(diffs, "; ") != strings.Join(want, "; ") {
+			t.Errorf("%s: expected differences %v, got %v", drift.FilePath, want, diffs)
+		}
+	}
+
+	// Verifying changes no state
+	states, err := changed.Status("claude")
+	if err != nil {
+		t.Fatalf("failed to list states: %v", err)
+	}
+	for _, state := range states {
+		if state.Summary == nil || state.Summary.ByType[types.EventTypeToolUse] != 0 {
+			t.Errorf("expected verify to leave the recorded summary alone, got %+v", state.Summary)
+		}
+	}
+}
+
+func TestVerify_CountMismatchWithoutSummary(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 10)
+	logFile := filepath.Join(logDir, "workspace-00", "session.jsonl")
+
+	manager := newTestManager(t)
+
+	// A file backfilled before summaries were recorded, with fewer events
+	state, err := manager.stateStore.Load("claude", logFile)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	state.Status = StatusCompleted
+	state.TotalEventsProcessed = 8
+	if err := manager.stateStore.Save(state); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	drifts, err := manager.Verify(context.Background(), BackfillConfig{AgentName: "claude"}, []string{logFile})
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if len(drifts) != 1 {
+		t.Fatalf("expected 1 file verified, got %d", len(drifts))
+	}
+	if diffs := drifts[0].Differences(); len(diffs) != 1 || diffs[0] != "events: 8 → 10" {
+		t.Errorf("expected an event count difference, got %v", diffs)
+	}
+}
+
+func TestVerify_UnsyncedFile(t *testing.T) {
+	logDir := t.TempDir()
+	writeClaudeLogs(t, logDir, 1, 3)
+
+	manager := newTestManager(t)
+	drifts, err := manager.Verify(context.Background(), BackfillConfig{AgentName: "claude"}, []string{logDir})
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if len(drifts) != 1 || !drifts[0].Drifted() {
+		t.Fatalf("expected a never-backfilled file to be reported, got %+v", drifts)
+	}
+	if diffs := drifts[0].Differences(); !strings.Contains(diffs[0], "not backfilled") {
+		t.Errorf("unexpected difference %q", diffs[0])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,12 +13,21 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// DefaultLeaseTimeout is how long retrieved events stay leased to the caller
+// before they return to the pool
+const DefaultLeaseTimeout = 5 * time.Minute
+
+// busyTimeout is how long a write waits for another process's lock
+const busyTimeout = 5 * time.Second
+
 // Buffer provides SQLite-based offline event storage
 type Buffer struct {
-	db      *sql.DB
-	maxSize int
-	log     *logrus.Logger
-	mu      sync.Mutex
+	db          *sql.DB
+	maxSize     int
+	lease       time.Duration
+	maxAttempts int
+	log         *logrus.Logger
+	mu          sync.Mutex
 }
 
 // Config holds buffer configuration
@@ -25,6 +35,16 @@ type Config struct {
 	DBPath  string
 	MaxSize int
 	Logger  *logrus.Logger
+
+	// LeaseTimeout is how long events handed out by Retrieve are withheld
+	// from later retrievals while the caller sends them. Defaults to
+	// DefaultLeaseTimeout.
+	LeaseTimeout time.Duration
+
+	// MaxAttempts is how many times the backend may reject an event before
+	// Reject moves it to the dead-letter store. Defaults to
+	// DefaultMaxAttempts.
+	MaxAttempts int
 }
 
 // NewBuffer creates a new event buffer
@@ -37,16 +57,35 @@ func NewBuffer(config Config) (*Buffer, error) {
 		config.MaxSize = 10000
 	}
 
+	if config.LeaseTimeout == 0 {
+		config.LeaseTimeout = DefaultLeaseTimeout
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
 	// Open database
 	db, err := sql.Open("sqlite", config.DBPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// Collector processes running side by side write the same database;
+	// wait for their locks rather than failing. The pragma is per
+	// connection, so keep one; the buffer serializes access anyway.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout.Milliseconds())); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+
 	buffer := &Buffer{
-		db:      db,
-		maxSize: config.MaxSize,
-		log:     config.Logger,
+		db:          db,
+		maxSize:     config.MaxSize,
+		lease:       config.LeaseTimeout,
+		maxAttempts: config.MaxAttempts,
+		log:         config.Logger,
 	}
 
 	// Initialize schema
@@ -60,7 +99,7 @@ func NewBuffer(config Config) (*Buffer, error) {
 	return buffer, nil
 }
 
-// initSchema creates the events table
+// initSchema creates the events and dead_letter tables
 func (b *Buffer) initSchema() error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS events (
@@ -71,81 +110,285 @@ func (b *Buffer) initSchema() error {
 		session_id TEXT NOT NULL,
 		project_id TEXT NOT NULL,
 		data TEXT NOT NULL,
-		created_at INTEGER NOT NULL
+		created_at INTEGER NOT NULL,
+		content_hash TEXT,
+		leased_until INTEGER,
+		timestamp_ns INTEGER,
+		attempts INTEGER NOT NULL DEFAULT 0
 	);
 	CREATE INDEX IF NOT EXISTS idx_timestamp ON events(timestamp);
 	CREATE INDEX IF NOT EXISTS idx_created_at ON events(created_at);
 	`
 
-	_, err := b.db.Exec(schema)
+	if _, err := b.db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := b.migrate(); err != nil {
+		return err
+	}
+
+	if err := b.initDeadLetter(); err != nil {
+		return err
+	}
+
+	// Rows buffered before hashing was added keep a NULL hash, which the
+	// unique index doesn't constrain
+	if _, err := b.db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_content_hash ON events(content_hash)"); err != nil {
+		return err
+	}
+
+	// Events are sent in the order they happened; id breaks ties
+	_, err := b.db.Exec("CREATE INDEX IF NOT EXISTS idx_send_order ON events(timestamp_ns, id)")
 	return err
 }
 
-// Store adds an event to the buffer
-func (b *Buffer) Store(event *types.AgentEvent) error {
+// migrate adds columns introduced after the initial schema to existing databases
+func (b *Buffer) migrate() error {
+	rows, err := b.db.Query("PRAGMA table_info(events)")
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	columns := []struct{ name, definition string }{
+		{"content_hash", "TEXT"},
+		{"leased_until", "INTEGER"},
+		{"timestamp_ns", "INTEGER"},
+		{"attempts", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := b.db.Exec(fmt.Sprintf("ALTER TABLE events ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", column.name, err)
+		}
+	}
+
+	// Events buffered before the nanosecond timestamp are ordered by their
+	// timestamp in seconds
+	if !existing["timestamp_ns"] {
+		if _, err := b.db.Exec("UPDATE events SET timestamp_ns = timestamp * 1000000000 WHERE timestamp_ns IS NULL"); err != nil {
+			return fmt.Errorf("failed to fill timestamp_ns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Store adds an event to the buffer. Events whose content hash (see
+// EventHash) is already buffered are skipped; stored reports whether the
+// event was newly added. Each stored event gets the next insert sequence
+// (the AUTOINCREMENT id, never reused even after deletes), which orders
+// events with the same timestamp.
+func (b *Buffer) Store(event *types.AgentEvent) (stored bool, err error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	hash := EventHash(event)
+
+	// Skip duplicates before evicting anything to make room
+	var exists bool
+	if err := b.db.QueryRow("SELECT EXISTS(SELECT 1 FROM events WHERE content_hash = ?)", hash).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for duplicate event: %w", err)
+	}
+	if exists {
+		b.log.Debugf("Skipping duplicate event %s (%s)", event.ID, event.Type)
+		return false, nil
+	}
+
 	// Check if buffer is full
 	count, err := b.count()
 	if err != nil {
-		return fmt.Errorf("failed to count events: %w", err)
+		return false, fmt.Errorf("failed to count events: %w", err)
 	}
 
 	if count >= b.maxSize {
 		// Evict oldest event (FIFO)
 		if err := b.evictOldest(); err != nil {
-			return fmt.Errorf("failed to evict oldest event: %w", err)
+			return false, fmt.Errorf("failed to evict oldest event: %w", err)
 		}
 	}
 
 	// Serialize event data
 	dataJSON, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return false, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
 	// Insert event
 	query := `
-		INSERT INTO events (event_id, timestamp, agent_id, session_id, project_id, data, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO events (event_id, timestamp, timestamp_ns, agent_id, session_id, project_id, data, created_at, content_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = b.db.Exec(
 		query,
 		event.ID,
 		event.Timestamp.Unix(),
+		event.Timestamp.UnixNano(),
 		event.AgentID,
 		event.SessionID,
 		event.ProjectID,
 		string(dataJSON),
 		time.Now().Unix(),
+		hash,
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert event: %w", err)
+		return false, fmt.Errorf("failed to insert event: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
-// Retrieve fetches the next batch of events
+// Retrieve fetches the next batch of events, oldest timestamp first and in
+// insert order among equal timestamps, and leases them to the caller.
+// Leased events aren't retrieved again until the lease expires, so a batch
+// being sent can't be picked up and sent twice. The caller confirms a sent
+// event with Delete or hands it back with Release; events whose lease
+// expires without either, such as after a crash, return to the pool rather
+// than being lost.
 func (b *Buffer) Retrieve(limit int) ([]*types.AgentEvent, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
 	query := `
-		SELECT data FROM events
-		ORDER BY created_at ASC
+		SELECT id, data FROM events
+		WHERE leased_until IS NULL OR leased_until <= ?
+		ORDER BY timestamp_ns ASC, id ASC
 		LIMIT ?
 	`
 
-	rows, err := b.db.Query(query, limit)
+	rows, err := tx.Query(query, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+
+	var ids []interface{}
+	var events []*types.AgentEvent
+	for rows.Next() {
+		var id int64
+		var dataJSON string
+		if err := rows.Scan(&id, &dataJSON); err != nil {
+			b.log.Warnf("Failed to scan row: %v", err)
+			continue
+		}
+
+		var event types.AgentEvent
+		if err := json.Unmarshal([]byte(dataJSON), &event); err != nil {
+			b.log.Warnf("Failed to unmarshal event: %v", err)
+			continue
+		}
+
+		ids = append(ids, id)
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if len(ids) > 0 {
+		update := fmt.Sprintf("UPDATE events SET leased_until = ? WHERE id IN (%s)", placeholders(len(ids)))
+		args := append([]interface{}{now.Add(b.lease).UnixMilli()}, ids...)
+		if _, err := tx.Exec(update, args...); err != nil {
+			return nil, fmt.Errorf("failed to lease events: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit lease: %w", err)
+	}
+	return events, nil
+}
+
+// Release ends the lease on events that weren't sent, so the next Retrieve
+// returns them without waiting for the lease to expire
+func (b *Buffer) Release(eventIDs []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(eventIDs))
+	for i, id := range eventIDs {
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("UPDATE events SET leased_until = NULL WHERE event_id IN (%s)", placeholders(len(eventIDs)))
+	if _, err := b.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to release events: %w", err)
+	}
+	return nil
+}
+
+// PeekFilter narrows the events returned by Peek; zero fields match any event
+type PeekFilter struct {
+	AgentID   string
+	EventType string
+	Since     time.Time // Only events with a timestamp at or after Since
+}
+
+// Peek returns up to limit buffered events matching filter, in the order
+// they will be sent, without removing them or affecting later retrieval
+func (b *Buffer) Peek(limit int, filter PeekFilter) ([]*types.AgentEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	query := "SELECT data FROM events WHERE 1=1"
+	var args []interface{}
+	if filter.AgentID != "" {
+		query += " AND agent_id = ?"
+		args = append(args, filter.AgentID)
+	}
+	if filter.EventType != "" {
+		query += " AND json_extract(data, '$.eventType') = ?"
+		args = append(args, filter.EventType)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.Since.Unix())
+	}
+	query += " ORDER BY timestamp_ns ASC, id ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := b.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
 	defer rows.Close()
 
+	return b.scanEvents(rows)
+}
+
+// scanEvents decodes the event JSON of each row, skipping unreadable rows
+func (b *Buffer) scanEvents(rows *sql.Rows) ([]*types.AgentEvent, error) {
 	var events []*types.AgentEvent
 
 	for rows.Next() {
@@ -171,7 +414,7 @@ func (b *Buffer) Retrieve(limit int) ([]*types.AgentEvent, error) {
 	return events, nil
 }
 
-// Delete removes events from the buffer
+// Delete removes events from the buffer, confirming the send of leased ones
 func (b *Buffer) Delete(eventIDs []string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -180,18 +423,12 @@ func (b *Buffer) Delete(eventIDs []string) error {
 		return nil
 	}
 
-	// Build placeholders for IN clause
-	placeholders := ""
 	args := make([]interface{}, len(eventIDs))
 	for i, id := range eventIDs {
-		if i > 0 {
-			placeholders += ","
-		}
-		placeholders += "?"
 		args[i] = id
 	}
 
-	query := fmt.Sprintf("DELETE FROM events WHERE event_id IN (%s)", placeholders)
+	query := fmt.Sprintf("DELETE FROM events WHERE event_id IN (%s)", placeholders(len(eventIDs)))
 
 	result, err := b.db.Exec(query, args...)
 	if err != nil {
@@ -204,6 +441,11 @@ func (b *Buffer) Delete(eventIDs []string) error {
 	return nil
 }
 
+// placeholders returns n comma-separated parameter placeholders for an IN clause
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
 // Count returns the number of events in the buffer
 func (b *Buffer) Count() (int, error) {
 	b.mu.Lock()
@@ -293,3 +535,48 @@ func (b *Buffer) Vacuum() error {
 	_, err := b.db.Exec("VACUUM")
 	return err
 }
+
+// vacuumFreeRatio is the fraction of free pages above which pruning
+// triggers a VACUUM to return space to the filesystem
+const vacuumFreeRatio = 0.25
+
+// PruneOlderThan deletes events buffered longer ago than the given retention
+// window and returns the number of events removed. Age is measured from when
+// an event was buffered rather than its timestamp, so backfilled history
+// waiting to be sent isn't dropped as soon as it's stored.
+func (b *Buffer) PruneOlderThan(d time.Duration) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := time.Now().Add(-d).Unix()
+
+	result, err := b.db.Exec("DELETE FROM events WHERE created_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune events: %w", err)
+	}
+
+	pruned, _ := result.RowsAffected()
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	b.log.Infof("Pruned %d buffered events older than %s", pruned, d)
+
+	// Reclaim disk space if a significant part of the file is now free
+	var pageCount, freeCount int64
+	if err := b.db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return int(pruned), fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := b.db.QueryRow("PRAGMA freelist_count").Scan(&freeCount); err != nil {
+		return int(pruned), fmt.Errorf("failed to read freelist count: %w", err)
+	}
+
+	if pageCount > 0 && float64(freeCount)/float64(pageCount) >= vacuumFreeRatio {
+		b.log.Debugf("Vacuuming buffer (%d/%d pages free)", freeCount, pageCount)
+		if _, err := b.db.Exec("VACUUM"); err != nil {
+			return int(pruned), fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+
+	return int(pruned), nil
+}
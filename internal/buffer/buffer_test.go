@@ -1,7 +1,9 @@
 package buffer
 
 import (
+	"database/sql"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -42,7 +44,7 @@ func TestBuffer_StoreAndRetrieve(t *testing.T) {
 	}
 
 	// Store event
-	if err := buffer.Store(event); err != nil {
+	if _, err := buffer.Store(event); err != nil {
 		t.Fatalf("failed to store event: %v", err)
 	}
 
@@ -104,7 +106,7 @@ func TestBuffer_MaxSizeEviction(t *testing.T) {
 			Data:      map[string]interface{}{"index": i},
 		}
 
-		if err := buffer.Store(event); err != nil {
+		if _, err := buffer.Store(event); err != nil {
 			t.Fatalf("failed to store event %d: %v", i, err)
 		}
 
@@ -175,7 +177,7 @@ func TestBuffer_Delete(t *testing.T) {
 		}
 
 		eventIDs = append(eventIDs, event.ID)
-		if err := buffer.Store(event); err != nil {
+		if _, err := buffer.Store(event); err != nil {
 			t.Fatalf("failed to store event: %v", err)
 		}
 	}
@@ -243,7 +245,7 @@ func TestBuffer_GetStats(t *testing.T) {
 			Data:      map[string]interface{}{"index": i},
 		}
 
-		if err := buffer.Store(event); err != nil {
+		if _, err := buffer.Store(event); err != nil {
 			t.Fatalf("failed to store event: %v", err)
 		}
 	}
@@ -267,3 +269,607 @@ func TestBuffer_GetStats(t *testing.T) {
 		t.Errorf("expected usage=5%%, got %v%%", usage)
 	}
 }
+
+func TestBuffer_PruneOlderThan(t *testing.T) {
+	// Create temp database
+	tmpFile, err := os.CreateTemp("", "test-buffer-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	config := Config{
+		DBPath:  tmpFile.Name(),
+		MaxSize: 100,
+	}
+
+	buffer, err := NewBuffer(config)
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	// Store two events buffered long ago and two buffered recently
+	ages := []time.Duration{45 * 24 * time.Hour, 31 * 24 * time.Hour, 2 * 24 * time.Hour, time.Hour}
+	freshIDs := map[string]bool{}
+	for i, age := range ages {
+		event := &types.AgentEvent{
+			ID:        uuid.New().String(),
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+			ProjectID: 1,
+			Data:      map[string]interface{}{"index": i},
+		}
+		if age < 30*24*time.Hour {
+			freshIDs[event.ID] = true
+		}
+
+		if _, err := buffer.Store(event); err != nil {
+			t.Fatalf("failed to store event %d: %v", i, err)
+		}
+		if _, err := buffer.db.Exec("UPDATE events SET created_at = ? WHERE event_id = ?", time.Now().Add(-age).Unix(), event.ID); err != nil {
+			t.Fatalf("failed to backdate event %d: %v", i, err)
+		}
+	}
+
+	pruned, err := buffer.PruneOlderThan(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+
+	if pruned != 2 {
+		t.Errorf("expected 2 pruned events, got %d", pruned)
+	}
+
+	events, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 remaining events, got %d", len(events))
+	}
+
+	for _, event := range events {
+		if !freshIDs[event.ID] {
+			t.Errorf("stale event %s survived pruning", event.ID)
+		}
+	}
+
+	// Pruning again is a no-op
+	pruned, err = buffer.PruneOlderThan(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("expected 0 pruned events on second run, got %d", pruned)
+	}
+}
+
+func TestBuffer_PruneKeepsOldEventsBufferedRecently(t *testing.T) {
+	buffer, err := NewBuffer(Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	// A backfilled event from long before the retention window
+	event := &types.AgentEvent{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now().Add(-90 * 24 * time.Hour),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "test-agent",
+		SessionID: "test-session",
+		ProjectID: 1,
+	}
+	if _, err := buffer.Store(event); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	pruned, err := buffer.PruneOlderThan(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if pruned != 0 {
+		t.Errorf("expected 0 pruned events, got %d", pruned)
+	}
+
+	count, err := buffer.Count()
+	if err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the old event to survive pruning, got %d events", count)
+	}
+}
+
+func TestBuffer_StoreSkipsDuplicates(t *testing.T) {
+	buffer, err := NewBuffer(Config{
+		DBPath:  filepath.Join(t.TempDir(), "buffer.db"),
+		MaxSize: 100,
+	})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	timestamp := time.Now()
+	newEvent := func(index int) *types.AgentEvent {
+		// Each parse of a log assigns fresh IDs to the same content
+		return &types.AgentEvent{
+			ID:        uuid.New().String(),
+			Timestamp: timestamp,
+			Type:      types.EventTypeToolUse,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+			ProjectID: 1,
+			Data:      map[string]interface{}{"requestId": "req-1", "index": index},
+		}
+	}
+
+	stored, err := buffer.Store(newEvent(0))
+	if err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if !stored {
+		t.Error("expected first event to be stored")
+	}
+
+	stored, err = buffer.Store(newEvent(0))
+	if err != nil {
+		t.Fatalf("failed to store duplicate event: %v", err)
+	}
+	if stored {
+		t.Error("expected duplicate event to be skipped")
+	}
+
+	// Same request and timestamp but different content is not a duplicate
+	stored, err = buffer.Store(newEvent(1))
+	if err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if !stored {
+		t.Error("expected event with different data to be stored")
+	}
+
+	count, err := buffer.Count()
+	if err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected count=2, got %d", count)
+	}
+}
+
+func TestBuffer_MigratesLegacySchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+
+	// A buffer created before content hashes existed
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id TEXT NOT NULL,
+			timestamp INTEGER NOT NULL,
+			agent_id TEXT NOT NULL,
+			session_id TEXT NOT NULL,
+			project_id TEXT NOT NULL,
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+		INSERT INTO events (event_id, timestamp, agent_id, session_id, project_id, data, created_at)
+		VALUES ('legacy', 0, 'test-agent', 'test-session', '1', '{"id":"legacy"}', 0);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	buffer, err := NewBuffer(Config{DBPath: dbPath, MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to open legacy buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	event := &types.AgentEvent{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "test-agent",
+		SessionID: "test-session",
+		ProjectID: 1,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := buffer.Store(event); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+	}
+
+	count, err := buffer.Count()
+	if err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected the legacy event plus one new event, got %d", count)
+	}
+}
+
+func TestEventHash(t *testing.T) {
+	base := &types.AgentEvent{
+		ID:        "a",
+		Timestamp: time.Unix(1730372400, 0),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "test-agent",
+		SessionID: "test-session",
+		Data:      map[string]interface{}{"requestId": "req-1", "prompt": "hi"},
+	}
+
+	same := *base
+	same.ID = "b"
+	same.Data = map[string]interface{}{"prompt": "hi", "requestId": "req-1"}
+	if EventHash(base) != EventHash(&same) {
+		t.Error("expected events differing only in ID to hash equally")
+	}
+
+	later := *base
+	later.Timestamp = base.Timestamp.Add(time.Millisecond)
+	if EventHash(base) == EventHash(&later) {
+		t.Error("expected different timestamps to change the hash")
+	}
+
+	otherSession := *base
+	otherSession.SessionID = "other-session"
+	if EventHash(base) == EventHash(&otherSession) {
+		t.Error("expected different sessions to change the hash")
+	}
+}
+
+func TestBuffer_Peek(t *testing.T) {
+	buffer, err := NewBuffer(Config{
+		DBPath:  filepath.Join(t.TempDir(), "buffer.db"),
+		MaxSize: 100,
+	})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	now := time.Now()
+	events := []*types.AgentEvent{
+		{ID: "old-request", Timestamp: now.Add(-48 * time.Hour), Type: types.EventTypeLLMRequest, AgentID: "claude", SessionID: "s1"},
+		{ID: "claude-tool", Timestamp: now.Add(-time.Hour), Type: types.EventTypeToolUse, AgentID: "claude", SessionID: "s1"},
+		{ID: "copilot-request", Timestamp: now.Add(-time.Minute), Type: types.EventTypeLLMRequest, AgentID: "github-copilot", SessionID: "s2"},
+	}
+	for _, event := range events {
+		if _, err := buffer.Store(event); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+	}
+
+	ids := func(events []*types.AgentEvent) []string {
+		var ids []string
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		filter PeekFilter
+		want   []string
+	}{
+		{"all", 10, PeekFilter{}, []string{"old-request", "claude-tool", "copilot-request"}},
+		{"limit", 2, PeekFilter{}, []string{"old-request", "claude-tool"}},
+		{"agent", 10, PeekFilter{AgentID: "claude"}, []string{"old-request", "claude-tool"}},
+		{"event type", 10, PeekFilter{EventType: types.EventTypeLLMRequest}, []string{"old-request", "copilot-request"}},
+		{"since", 10, PeekFilter{Since: now.Add(-24 * time.Hour)}, []string{"claude-tool", "copilot-request"}},
+		{"combined", 10, PeekFilter{AgentID: "claude", EventType: types.EventTypeLLMRequest}, []string{"old-request"}},
+		{"no match", 10, PeekFilter{AgentID: "cursor"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peeked, err := buffer.Peek(tt.limit, tt.filter)
+			if err != nil {
+				t.Fatalf("failed to peek: %v", err)
+			}
+			got := ids(peeked)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+
+	// Peeking leaves events in place for sending
+	count, err := buffer.Count()
+	if err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected peek to leave 3 events, got %d", count)
+	}
+	retrieved, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(retrieved) != 3 {
+		t.Errorf("expected 3 events to remain retrievable, got %d", len(retrieved))
+	}
+}
+
+// storeLeaseEvents buffers n events and returns their IDs in retrieval order
+func storeLeaseEvents(t *testing.T, buffer *Buffer, n int) []string {
+	t.Helper()
+
+	var ids []string
+	for i := 0; i < n; i++ {
+		event := &types.AgentEvent{
+			ID:        uuid.New().String(),
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+			Data:      map[string]interface{}{"index": i},
+		}
+		if _, err := buffer.Store(event); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestBuffer_RetrieveLeasesEvents(t *testing.T) {
+	buffer, err := NewBuffer(Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	ids := storeLeaseEvents(t, buffer, 3)
+
+	first, err := buffer.Retrieve(2)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(first) != 2 || first[0].ID != ids[0] || first[1].ID != ids[1] {
+		t.Fatalf("expected the two oldest events, got %d", len(first))
+	}
+
+	// Leased events aren't handed out again while they are being sent
+	second, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(second) != 1 || second[0].ID != ids[2] {
+		t.Fatalf("expected only the unleased event, got %d events", len(second))
+	}
+
+	// Confirming one send and releasing the other returns only the unsent one
+	if err := buffer.Delete([]string{ids[0]}); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+	if err := buffer.Release([]string{ids[1]}); err != nil {
+		t.Fatalf("failed to release event: %v", err)
+	}
+	third, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(third) != 1 || third[0].ID != ids[1] {
+		t.Errorf("expected the released event, got %d events", len(third))
+	}
+
+	count, err := buffer.Count()
+	if err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected leased events to stay buffered, got count=%d", count)
+	}
+}
+
+func TestBuffer_CrashAfterSendKeepsEventsLeased(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+	config := Config{DBPath: dbPath, MaxSize: 100, LeaseTimeout: 200 * time.Millisecond}
+
+	buffer, err := NewBuffer(config)
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	ids := storeLeaseEvents(t, buffer, 2)
+
+	// The batch is sent, then the process dies before deleting it
+	if _, err := buffer.Retrieve(10); err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	buffer.Close()
+
+	restarted, err := NewBuffer(config)
+	if err != nil {
+		t.Fatalf("failed to reopen buffer: %v", err)
+	}
+	defer restarted.Close()
+
+	// A restart inside the lease doesn't send the batch again
+	events, err := restarted.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected leased events to be withheld after restart, got %d", len(events))
+	}
+
+	// Nor is it lost: once the lease expires the events are retried
+	time.Sleep(250 * time.Millisecond)
+	events, err = restarted.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != ids[0] || events[1].ID != ids[1] {
+		t.Errorf("expected both events back after the lease expired, got %d", len(events))
+	}
+}
+
+func TestBuffer_ExpiredLeaseReturnsEvents(t *testing.T) {
+	buffer, err := NewBuffer(Config{
+		DBPath:       filepath.Join(t.TempDir(), "buffer.db"),
+		MaxSize:      100,
+		LeaseTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	ids := storeLeaseEvents(t, buffer, 1)
+
+	if _, err := buffer.Retrieve(10); err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	// The expired lease is renewed by the next retrieval
+	events, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != ids[0] {
+		t.Fatalf("expected the event back after its lease expired, got %d", len(events))
+	}
+	events, err = buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected the re-leased event to be withheld, got %d", len(events))
+	}
+}
+
+func TestBuffer_RetrieveOrdersByTimestamp(t *testing.T) {
+	buffer, err := NewBuffer(Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	// Stored out of order, as after an offline period where a response was
+	// buffered before its request; same-second events differ in nanoseconds
+	base := time.Unix(1730372400, 0)
+	stored := []struct {
+		id     string
+		offset time.Duration
+	}{
+		{"response", 500 * time.Millisecond},
+		{"request", 0},
+		{"tool-b", time.Second},
+		{"earlier", -time.Hour},
+		{"tool-a", time.Second}, // Same timestamp as tool-b, stored after it
+	}
+	for i, s := range stored {
+		event := &types.AgentEvent{
+			ID:        s.id,
+			Timestamp: base.Add(s.offset),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+			Data:      map[string]interface{}{"index": i},
+		}
+		if _, err := buffer.Store(event); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+	}
+
+	want := []string{"earlier", "request", "response", "tool-b", "tool-a"}
+	for _, limit := range []int{2, 2, 1} {
+		events, err := buffer.Retrieve(limit)
+		if err != nil {
+			t.Fatalf("failed to retrieve events: %v", err)
+		}
+		if len(events) != limit {
+			t.Fatalf("expected %d events, got %d", limit, len(events))
+		}
+		for _, event := range events {
+			if event.ID != want[0] {
+				t.Errorf("expected %s next, got %s", want[0], event.ID)
+			}
+			want = want[1:]
+		}
+	}
+}
+
+func TestBuffer_MigratedEventsKeepOrder(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+
+	// Events buffered before nanosecond timestamps were stored
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id TEXT NOT NULL,
+			timestamp INTEGER NOT NULL,
+			agent_id TEXT NOT NULL,
+			session_id TEXT NOT NULL,
+			project_id TEXT NOT NULL,
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			content_hash TEXT,
+			leased_until INTEGER
+		);
+		INSERT INTO events (event_id, timestamp, agent_id, session_id, project_id, data, created_at)
+		VALUES ('late', 200, 'test-agent', 'test-session', '1', '{"id":"late"}', 0),
+		       ('early', 100, 'test-agent', 'test-session', '1', '{"id":"early"}', 0);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	buffer, err := NewBuffer(Config{DBPath: dbPath, MaxSize: 100})
+	if err != nil {
+		t.Fatalf("failed to open legacy buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	event := &types.AgentEvent{
+		ID:        "middle",
+		Timestamp: time.Unix(150, 0),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "test-agent",
+		SessionID: "test-session",
+	}
+	if _, err := buffer.Store(event); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	events, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	want := []string{"early", "middle", "late"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, event := range events {
+		if event.ID != want[i] {
+			t.Errorf("expected %s at %d, got %s", want[i], i, event.ID)
+		}
+	}
+}
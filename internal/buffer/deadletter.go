@@ -0,0 +1,186 @@
+package buffer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// DefaultMaxAttempts is how many times the backend may reject a buffered
+// event before it is moved to the dead-letter store
+const DefaultMaxAttempts = 3
+
+// eventColumns are the columns copied between the events and dead_letter
+// tables
+const eventColumns = "event_id, timestamp, timestamp_ns, agent_id, session_id, project_id, data, created_at, content_hash"
+
+// DeadLetter is an event the backend kept rejecting, with the reason it
+// gave the last time
+type DeadLetter struct {
+	Event    *types.AgentEvent
+	Reason   string
+	Attempts int
+	FailedAt time.Time
+}
+
+// initDeadLetter creates the dead_letter table, which holds events out of
+// the send order until they are retried
+func (b *Buffer) initDeadLetter() error {
+	_, err := b.db.Exec(`
+	CREATE TABLE IF NOT EXISTS dead_letter (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT NOT NULL,
+		timestamp INTEGER NOT NULL,
+		timestamp_ns INTEGER,
+		agent_id TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		project_id TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		content_hash TEXT,
+		reason TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		failed_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_dead_letter_event_id ON dead_letter(event_id);
+	`)
+	return err
+}
+
+// Reject records that the backend rejected leased events, with the reason
+// for each keyed by event ID. Events rejected fewer than MaxAttempts times
+// are released for the next retrieval; the others are moved to the
+// dead-letter store. It returns the number of events dead-lettered.
+func (b *Buffer) Reject(reasons map[string]string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(reasons) == 0 {
+		return 0, nil
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	deadLettered := 0
+	for id, reason := range reasons {
+		if _, err := tx.Exec("UPDATE events SET attempts = COALESCE(attempts, 0) + 1, leased_until = NULL WHERE event_id = ?", id); err != nil {
+			return 0, fmt.Errorf("failed to record rejection: %w", err)
+		}
+
+		move := fmt.Sprintf(`
+			INSERT INTO dead_letter (%s, reason, attempts, failed_at)
+			SELECT %s, ?, attempts, ? FROM events WHERE event_id = ? AND attempts >= ?
+		`, eventColumns, eventColumns)
+		result, err := tx.Exec(move, reason, now, id, b.maxAttempts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to dead-letter event: %w", err)
+		}
+		moved, _ := result.RowsAffected()
+		if moved == 0 {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM events WHERE event_id = ?", id); err != nil {
+			return 0, fmt.Errorf("failed to remove dead-lettered event: %w", err)
+		}
+		deadLettered += int(moved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rejections: %w", err)
+	}
+	if deadLettered > 0 {
+		b.log.Warnf("Moved %d repeatedly rejected events to the dead-letter store", deadLettered)
+	}
+	return deadLettered, nil
+}
+
+// DeadLetters returns up to limit dead-lettered events, most recently
+// failed first
+func (b *Buffer) DeadLetters(limit int) ([]*DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rows, err := b.db.Query("SELECT data, reason, attempts, failed_at FROM dead_letter ORDER BY failed_at DESC, id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []*DeadLetter
+	for rows.Next() {
+		var dataJSON string
+		var failedAt int64
+		letter := &DeadLetter{}
+		if err := rows.Scan(&dataJSON, &letter.Reason, &letter.Attempts, &failedAt); err != nil {
+			b.log.Warnf("Failed to scan row: %v", err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(dataJSON), &letter.Event); err != nil {
+			b.log.Warnf("Failed to unmarshal event: %v", err)
+			continue
+		}
+		letter.FailedAt = time.UnixMilli(failedAt)
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return letters, nil
+}
+
+// DeadLetterCount returns the number of dead-lettered events
+func (b *Buffer) DeadLetterCount() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var count int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM dead_letter").Scan(&count)
+	return count, err
+}
+
+// RetryDeadLetters moves dead-lettered events back into the send order with
+// their rejection count reset, all of them when eventIDs is empty. Events
+// already buffered again are dropped from the dead-letter store. It returns
+// the number of events requeued.
+func (b *Buffer) RetryDeadLetters(eventIDs []string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	where := "1=1"
+	args := make([]interface{}, len(eventIDs))
+	if len(eventIDs) > 0 {
+		where = fmt.Sprintf("event_id IN (%s)", placeholders(len(eventIDs)))
+		for i, id := range eventIDs {
+			args[i] = id
+		}
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	requeue := fmt.Sprintf("INSERT OR IGNORE INTO events (%s, attempts) SELECT %s, 0 FROM dead_letter WHERE %s ORDER BY id",
+		eventColumns, eventColumns, where)
+	result, err := tx.Exec(requeue, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead letters: %w", err)
+	}
+	requeued, _ := result.RowsAffected()
+
+	if _, err := tx.Exec("DELETE FROM dead_letter WHERE "+where, args...); err != nil {
+		return 0, fmt.Errorf("failed to remove requeued dead letters: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit requeue: %w", err)
+	}
+	return int(requeued), nil
+}
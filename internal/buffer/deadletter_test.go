@@ -0,0 +1,124 @@
+package buffer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBuffer_RejectMovesEventsToDeadLetter(t *testing.T) {
+	buffer, err := NewBuffer(Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100, MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	ids := storeLeaseEvents(t, buffer, 3)
+
+	// The first rejection releases the event for another attempt
+	if _, err := buffer.Retrieve(10); err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	moved, err := buffer.Reject(map[string]string{ids[0]: "missing field sessionId"})
+	if err != nil {
+		t.Fatalf("failed to reject event: %v", err)
+	}
+	if moved != 0 {
+		t.Fatalf("expected no event dead-lettered after one rejection, got %d", moved)
+	}
+	retried, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(retried) != 1 || retried[0].ID != ids[0] {
+		t.Fatalf("expected the rejected event to be retrieved again, got %d events", len(retried))
+	}
+
+	// The second rejection moves it out of the send order
+	moved, err = buffer.Reject(map[string]string{ids[0]: "schema version 3 required"})
+	if err != nil {
+		t.Fatalf("failed to reject event: %v", err)
+	}
+	if moved != 1 {
+		t.Fatalf("expected the event to be dead-lettered, got %d", moved)
+	}
+	if err := buffer.Release(ids[1:]); err != nil {
+		t.Fatalf("failed to release events: %v", err)
+	}
+	remaining, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	for _, event := range remaining {
+		if event.ID == ids[0] {
+			t.Error("expected the dead-lettered event to stay out of the flush")
+		}
+	}
+	if count, _ := buffer.Count(); count != 2 {
+		t.Errorf("expected 2 events left in the buffer, got %d", count)
+	}
+
+	letters, err := buffer.DeadLetters(10)
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(letters))
+	}
+	if letters[0].Event.ID != ids[0] || letters[0].Reason != "schema version 3 required" || letters[0].Attempts != 2 {
+		t.Errorf("unexpected dead letter %+v", letters[0])
+	}
+}
+
+func TestBuffer_RetryDeadLetters(t *testing.T) {
+	buffer, err := NewBuffer(Config{DBPath: filepath.Join(t.TempDir(), "buffer.db"), MaxSize: 100, MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buffer.Close()
+
+	ids := storeLeaseEvents(t, buffer, 3)
+	if _, err := buffer.Retrieve(10); err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	moved, err := buffer.Reject(map[string]string{ids[0]: "invalid", ids[1]: "invalid", ids[2]: "invalid"})
+	if err != nil || moved != 3 {
+		t.Fatalf("expected all 3 events dead-lettered, got %d (%v)", moved, err)
+	}
+
+	// Retrying by ID requeues just that event
+	requeued, err := buffer.RetryDeadLetters([]string{ids[1]})
+	if err != nil {
+		t.Fatalf("failed to retry dead letter: %v", err)
+	}
+	if requeued != 1 {
+		t.Fatalf("expected 1 event requeued, got %d", requeued)
+	}
+	events, err := buffer.Retrieve(10)
+	if err != nil {
+		t.Fatalf("failed to retrieve events: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != ids[1] {
+		t.Fatalf("expected the requeued event, got %d events", len(events))
+	}
+
+	// A requeued event gets MaxAttempts fresh attempts
+	moved, err = buffer.Reject(map[string]string{ids[1]: "invalid"})
+	if err != nil || moved != 1 {
+		t.Fatalf("expected the requeued event to be dead-lettered after one more rejection, got %d (%v)", moved, err)
+	}
+
+	// Retrying without IDs requeues everything
+	requeued, err = buffer.RetryDeadLetters(nil)
+	if err != nil {
+		t.Fatalf("failed to retry dead letters: %v", err)
+	}
+	if requeued != 3 {
+		t.Errorf("expected 3 events requeued, got %d", requeued)
+	}
+	if count, _ := buffer.DeadLetterCount(); count != 0 {
+		t.Errorf("expected an empty dead-letter store, got %d", count)
+	}
+	if count, _ := buffer.Count(); count != 3 {
+		t.Errorf("expected 3 buffered events, got %d", count)
+	}
+}
@@ -0,0 +1,29 @@
+package buffer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// EventHash returns a deterministic hash of an event's content. Event IDs
+// are generated per parse, so the hash ignores them and covers the agent,
+// session, type, timestamp and data instead: parsing the same log twice
+// yields the same hashes.
+func EventHash(event *types.AgentEvent) string {
+	// encoding/json sorts map keys, so equal data marshals identically
+	data, _ := json.Marshal(event.Data)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00",
+		event.AgentID,
+		event.SessionID,
+		event.Type,
+		event.Timestamp.UnixNano(),
+	)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
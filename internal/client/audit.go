@@ -0,0 +1,151 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultAuditLogMaxBytes is the size the audit log is rotated at when
+// Config.AuditLogMaxBytes is unset
+const DefaultAuditLogMaxBytes = 10 << 20
+
+// auditLogBackups is how many rotated audit logs (path.1, path.2, ...) are kept
+const auditLogBackups = 3
+
+// auditWarnInterval spaces out warnings about audit entries that couldn't be
+// written, so a full disk doesn't flood the operational log
+const auditWarnInterval = time.Minute
+
+// AuditEntry is one line of the audit log: a batch a destination accepted.
+// It identifies the events by content hash (see buffer.EventHash) and never
+// holds their payloads.
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Destination string    `json:"destination"`
+	URL         string    `json:"url"`
+	Stream      string    `json:"stream,omitempty"`
+	Sequence    uint64    `json:"sequence"`
+	Events      int       `json:"events"`
+	Accepted    int       `json:"accepted"`
+	Rejected    int       `json:"rejected"`
+	Hashes      []string  `json:"hashes"`
+}
+
+// auditLog appends an AuditEntry per delivered batch to a JSON-lines file,
+// rotating it once it would grow past maxBytes. Mirror clients share the
+// auditLog of the client fanning out.
+type auditLog struct {
+	path     string
+	maxBytes int64
+	log      *logrus.Logger
+
+	mu       sync.Mutex
+	lastWarn time.Time
+}
+
+func newAuditLog(path string, maxBytes int64, log *logrus.Logger) *auditLog {
+	if path == "" {
+		return nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultAuditLogMaxBytes
+	}
+	return &auditLog{path: path, maxBytes: maxBytes, log: log}
+}
+
+// record writes the entry of batch. Failures are logged rather than
+// returned: the batch was delivered either way.
+func (a *auditLog) record(destination, url, stream string, seq uint64, batch []*types.AgentEvent, result *BatchResult) {
+	if a == nil {
+		return
+	}
+
+	hashes := make([]string, len(batch))
+	for i, event := range batch {
+		hashes[i] = buffer.EventHash(event)
+	}
+	line, err := json.Marshal(AuditEntry{
+		Time:        time.Now().UTC(),
+		Destination: destination,
+		URL:         url,
+		Stream:      stream,
+		Sequence:    seq,
+		Events:      len(batch),
+		Accepted:    len(result.Accepted),
+		Rejected:    len(result.Rejected),
+		Hashes:      hashes,
+	})
+	if err != nil {
+		a.warn(err)
+		return
+	}
+
+	a.mu.Lock()
+	err = a.append(append(line, '\n'))
+	a.mu.Unlock()
+	if err != nil {
+		a.warn(err)
+	}
+}
+
+// append writes line to the log in a single write, so readers and crashes
+// never see part of an entry. Callers hold a.mu.
+func (a *auditLog) append(line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	if info, err := os.Stat(a.path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// rotate shifts path.1 to path.2 and so on, dropping the oldest, and moves
+// the current log to path.1
+func (a *auditLog) rotate() error {
+	for i := auditLogBackups - 1; i > 0; i-- {
+		older := fmt.Sprintf("%s.%d", a.path, i)
+		if err := os.Rename(older, fmt.Sprintf("%s.%d", a.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return nil
+}
+
+// warn logs a failed audit write at most once per auditWarnInterval
+func (a *auditLog) warn(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Since(a.lastWarn) < auditWarnInterval {
+		return
+	}
+	a.lastWarn = time.Now()
+	a.log.Warnf("Failed to write audit log %s: %v", a.path, err)
+}
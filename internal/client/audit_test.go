@@ -0,0 +1,165 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// readAuditLog returns the entries of the audit log at path
+func readAuditLog(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func sendAndFlush(t *testing.T, client *Client, events []*types.AgentEvent) error {
+	t.Helper()
+	for _, event := range events {
+		if err := client.SendEvent(event); err != nil {
+			t.Fatalf("SendEvent: %v", err)
+		}
+	}
+	return client.FlushBatch()
+}
+
+func TestClient_AuditLogOneEntryPerBatch(t *testing.T) {
+	server := newMirrorServer()
+	defer server.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit", "batches.jsonl")
+	client := NewClient(Config{
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		MaxRetries:     1,
+		MaxBackoff:     10 * time.Millisecond,
+		ManualFlush:    true,
+		AuditLogPath:   auditPath,
+		SequenceStream: "collector",
+	})
+
+	events := rateTestEvents(5)
+	if err := sendAndFlush(t, client, events[:3]); err != nil {
+		t.Fatalf("First batch: %v", err)
+	}
+	if err := sendAndFlush(t, client, events[3:]); err != nil {
+		t.Fatalf("Second batch: %v", err)
+	}
+
+	// A batch that isn't delivered isn't audited
+	server.down.Store(true)
+	if err := sendAndFlush(t, client, rateTestEvents(1)); err == nil {
+		t.Fatal("Expected the batch to fail while the server is down")
+	}
+
+	entries := readAuditLog(t, auditPath)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	for i, batch := range [][]*types.AgentEvent{events[:3], events[3:]} {
+		entry := entries[i]
+		if entry.Destination != PrimaryDestination || entry.URL != server.URL {
+			t.Errorf("Entry %d: expected destination %s at %s, got %s at %s", i, PrimaryDestination, server.URL, entry.Destination, entry.URL)
+		}
+		if entry.Events != len(batch) || entry.Accepted != len(batch) {
+			t.Errorf("Entry %d: expected %d events accepted, got %d events, %d accepted", i, len(batch), entry.Events, entry.Accepted)
+		}
+		if entry.Stream != "collector" || entry.Sequence != uint64(i+1) {
+			t.Errorf("Entry %d: expected sequence %d of collector, got %d of %q", i, i+1, entry.Sequence, entry.Stream)
+		}
+		if len(entry.Hashes) != len(batch) {
+			t.Fatalf("Entry %d: expected %d hashes, got %d", i, len(batch), len(entry.Hashes))
+		}
+		for j, event := range batch {
+			if entry.Hashes[j] != buffer.EventHash(event) {
+				t.Errorf("Entry %d: hash %d doesn't match event %s", i, j, event.ID)
+			}
+		}
+	}
+}
+
+func TestClient_AuditLogPerDestination(t *testing.T) {
+	primary, mirror := newMirrorServer(), newMirrorServer()
+	defer primary.Close()
+	defer mirror.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client := NewClient(Config{
+		BaseURL:      primary.URL,
+		APIKey:       "local-key",
+		MaxRetries:   1,
+		MaxBackoff:   10 * time.Millisecond,
+		ManualFlush:  true,
+		AuditLogPath: auditPath,
+		Destinations: []Destination{
+			{Name: "team", BaseURL: mirror.URL, APIKey: "team-key"},
+		},
+	})
+
+	if err := sendAndFlush(t, client, rateTestEvents(4)); err != nil {
+		t.Fatalf("FlushBatch: %v", err)
+	}
+
+	entries := readAuditLog(t, auditPath)
+	if len(entries) != 2 {
+		t.Fatalf("Expected an audit entry per destination, got %d", len(entries))
+	}
+	seen := make(map[string]int)
+	for _, entry := range entries {
+		seen[entry.Destination] = entry.Events
+	}
+	if seen[PrimaryDestination] != 4 || seen["team"] != 4 {
+		t.Errorf("Expected 4 events audited for primary and team, got %v", seen)
+	}
+}
+
+func TestAuditLog_Rotates(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit := newAuditLog(auditPath, 1, nil)
+
+	result := &BatchResult{Accepted: []string{"evt-0"}}
+	for i := 0; i < auditLogBackups+2; i++ {
+		audit.record(PrimaryDestination, "http://backend", "", uint64(i+1), rateTestEvents(1), result)
+	}
+
+	// Every write overflows the limit, so each file holds one entry and
+	// only the newest backups are kept
+	last := uint64(auditLogBackups + 2)
+	for backup := 0; backup <= auditLogBackups; backup++ {
+		path := auditPath
+		if backup > 0 {
+			path = fmt.Sprintf("%s.%d", auditPath, backup)
+		}
+		entries := readAuditLog(t, path)
+		if len(entries) != 1 {
+			t.Fatalf("%s: expected 1 entry, got %d", path, len(entries))
+		}
+		if want := last - uint64(backup); entries[0].Sequence != want {
+			t.Errorf("%s: expected sequence %d, got %d", path, want, entries[0].Sequence)
+		}
+	}
+	if _, err := os.Stat(auditPath + ".4"); !os.IsNotExist(err) {
+		t.Errorf("Expected no more than %d backups, got %v", auditLogBackups, err)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// retryBaseDelay is the backoff ceiling of the first retry; it doubles with
+// every further attempt up to the configured maximum
+const retryBaseDelay = time.Second
+
+// backoffCeiling returns the exponential backoff ceiling before retry
+// attempt (1-based): base, 2*base, 4*base... capped at max
+func backoffCeiling(attempt int, base, max time.Duration) time.Duration {
+	ceiling := base
+	for i := 1; i < attempt && ceiling < max; i++ {
+		ceiling *= 2
+	}
+	if ceiling > max {
+		ceiling = max
+	}
+	return ceiling
+}
+
+// retryBackoff returns a random delay between zero and the backoff ceiling
+// for attempt ("full jitter"), so collectors reconnecting to a recovering
+// backend don't retry in lockstep
+func retryBackoff(attempt int, base, max time.Duration) time.Duration {
+	ceiling := backoffCeiling(attempt, base, max)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// Jitter returns d randomly spread by up to ±fraction of its length, for
+// periodic work that many collectors would otherwise run at the same moment
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	spread := int64(float64(d) * fraction)
+	if spread <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
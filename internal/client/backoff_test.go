@@ -0,0 +1,79 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffCeiling(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // Capped
+		{64, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoffCeiling(tt.attempt, time.Second, 10*time.Second); got != tt.want {
+			t.Errorf("backoffCeiling(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryBackoff_WithinJitteredBounds(t *testing.T) {
+	max := 10 * time.Second
+	for attempt := 1; attempt <= 6; attempt++ {
+		ceiling := backoffCeiling(attempt, time.Second, max)
+
+		distinct := make(map[time.Duration]bool)
+		for i := 0; i < 200; i++ {
+			delay := retryBackoff(attempt, time.Second, max)
+			if delay < 0 || delay > ceiling {
+				t.Fatalf("attempt %d: delay %v outside [0, %v]", attempt, delay, ceiling)
+			}
+			distinct[delay] = true
+		}
+		if len(distinct) < 2 {
+			t.Errorf("attempt %d: expected randomized delays, got %v", attempt, distinct)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	base := 30 * time.Second
+	for i := 0; i < 200; i++ {
+		got := Jitter(base, 0.2)
+		if got < 24*time.Second || got > 36*time.Second {
+			t.Fatalf("Jitter(%v, 0.2) = %v, want within ±20%%", base, got)
+		}
+	}
+
+	if got := Jitter(base, 0); got != base {
+		t.Errorf("Jitter with no fraction = %v, want %v", got, base)
+	}
+}
+
+func TestSendBatchWithRetry_CancelDuringBackoff(t *testing.T) {
+	client := NewClient(Config{
+		BaseURL:    "http://127.0.0.1:1",
+		MaxRetries: 5,
+		MaxBackoff: time.Hour,
+		Timeout:    100 * time.Millisecond,
+	})
+
+	// Stopping the client must interrupt a pending backoff
+	time.AfterFunc(200*time.Millisecond, client.cancel)
+
+	start := time.Now()
+	_, err := client.sendBatchWithRetry(nil)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to stop retries promptly, took %v", elapsed)
+	}
+}
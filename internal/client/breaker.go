@@ -0,0 +1,104 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of contacting the backend while the
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open: backend unavailable")
+
+// BreakerState is the state of the client's circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Requests fail fast until the cooldown elapses
+	BreakerHalfOpen BreakerState = "half-open" // A single probe request is allowed through
+)
+
+// circuitBreaker opens after threshold consecutive batch failures, fails
+// fast for cooldown, then lets one probe through to decide whether to close
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a request may be sent. Once the cooldown has
+// elapsed an open breaker moves to half-open and admits a single probe.
+func (b *circuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// RecordFailure counts a failed batch, opening the breaker when the
+// threshold is reached or when a half-open probe fails
+func (b *circuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// State returns the current breaker state
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// advance moves an open breaker to half-open once the cooldown has elapsed.
+// Callers must hold b.mu.
+func (b *circuitBreaker) advance() {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+		b.probing = false
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	// Closed: failures below the threshold keep it closed
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected closed breaker to allow, got %v", err)
+		}
+		breaker.RecordFailure()
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("expected %s after 2 failures, got %s", BreakerClosed, state)
+	}
+
+	// Open: the third consecutive failure trips it
+	breaker.RecordFailure()
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected %s after 3 failures, got %s", BreakerOpen, state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen while open, got %v", err)
+	}
+
+	// Half-open: after the cooldown a single probe is admitted
+	now = now.Add(time.Minute)
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("expected %s after cooldown, got %s", BreakerHalfOpen, state)
+	}
+	if err := breaker.Allow(); err != nil {
+		t.Errorf("expected probe to be allowed, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected concurrent request during probe to be rejected, got %v", err)
+	}
+
+	// A failed probe reopens immediately
+	breaker.RecordFailure()
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected failed probe to reopen, got %s", state)
+	}
+
+	// Closed: a successful probe closes it again
+	now = now.Add(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	breaker.RecordSuccess()
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("expected %s after successful probe, got %s", BreakerClosed, state)
+	}
+	if err := breaker.Allow(); err != nil {
+		t.Errorf("expected closed breaker to allow, got %v", err)
+	}
+}
+
+func TestClient_CircuitOpenSkipsBackend(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:          server.URL,
+		BatchDelay:       time.Hour,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Hour,
+	})
+	defer client.Stop()
+
+	// Queue an event, then simulate a batch that exhausted its retries
+	if err := client.SendEvent(&types.AgentEvent{ID: "evt-1", Type: types.EventTypeLLMRequest, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("expected event to be queued, got %v", err)
+	}
+	client.breaker.RecordFailure()
+
+	if !client.CircuitOpen() {
+		t.Fatal("expected circuit to be open")
+	}
+	if state := client.GetStats()["breaker_state"]; state != string(BreakerOpen) {
+		t.Errorf("expected breaker_state=%s, got %v", BreakerOpen, state)
+	}
+
+	// New events are refused so the caller buffers them
+	if err := client.SendEvent(&types.AgentEvent{ID: "evt-2", Type: types.EventTypeLLMRequest, Timestamp: time.Now()}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected SendEvent to return ErrCircuitOpen, got %v", err)
+	}
+	if err := client.FlushBatch(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests while open, got %d", requests)
+	}
+}
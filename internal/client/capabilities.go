@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// Ingest payload shapes, identified by the schema version the backend reports
+const (
+	// SchemaEventsObject wraps the batch as {"events": [...]}, as the
+	// /api/v1/agent ingest endpoint of older backends expects
+	SchemaEventsObject = 1
+	// SchemaEventArray sends the batch as a bare JSON array (the default)
+	SchemaEventArray = 2
+)
+
+// DefaultIngestPath is the batch ingest path used when the backend doesn't
+// report one
+const DefaultIngestPath = "/api/events/batch"
+
+// Capabilities describes the ingest API a backend supports
+type Capabilities struct {
+	IngestPath    string `json:"ingestPath"`
+	SchemaVersion int    `json:"schemaVersion"`
+
+	// ValidatePath is where the backend checks events without storing
+	// them, empty if it has no such endpoint
+	ValidatePath string `json:"validatePath,omitempty"`
+
+	// Negotiated is false when the defaults are in use because the backend
+	// has no capabilities endpoint or reported something unusable
+	Negotiated bool `json:"-"`
+}
+
+// defaultCapabilities is the fallback for backends without a capabilities endpoint
+func defaultCapabilities() Capabilities {
+	return Capabilities{IngestPath: DefaultIngestPath, SchemaVersion: SchemaEventArray}
+}
+
+// capabilityCache holds the capabilities negotiated for the session
+type capabilityCache struct {
+	mu     sync.Mutex
+	caps   Capabilities
+	cached bool
+}
+
+// Negotiate asks the backend which ingest path and payload schema it
+// supports and caches the answer for the session. Backends without a
+// capabilities endpoint get the defaults. When the backend can't be
+// reached, the defaults are returned with the error and nothing is cached,
+// so the next send asks again. The request is made without holding the
+// cache, so a slow backend doesn't hold up callers; when several ask at
+// once, the first answer is kept.
+func (c *Client) Negotiate() (Capabilities, error) {
+	c.caps.mu.Lock()
+	cached, ok := c.caps.caps, c.caps.cached
+	c.caps.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	caps, err := c.fetchCapabilities()
+	if err != nil {
+		return c.configuredPath(defaultCapabilities()), err
+	}
+	caps = c.configuredPath(caps)
+
+	c.caps.mu.Lock()
+	defer c.caps.mu.Unlock()
+	if c.caps.cached {
+		return c.caps.caps, nil
+	}
+	if caps.Negotiated {
+		c.log.Infof("Backend ingest: %s (schema v%d)", caps.IngestPath, caps.SchemaVersion)
+	} else {
+		c.log.Debugf("Backend reports no capabilities, using %s", caps.IngestPath)
+	}
+	c.caps.caps = caps
+	c.caps.cached = true
+	return caps, nil
+}
+
+// fetchCapabilities queries the capabilities endpoint. Only transport
+// failures are errors; any response the collector can't use yields the
+// defaults.
+func (c *Client) fetchCapabilities() (Capabilities, error) {
+	url := fmt.Sprintf("%s/api/capabilities", c.baseURL)
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.fallback)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return defaultCapabilities(), nil
+	}
+
+	var caps Capabilities
+	if err := json.Unmarshal(body, &caps); err != nil || caps.IngestPath == "" {
+		return defaultCapabilities(), nil
+	}
+	if !strings.HasPrefix(caps.IngestPath, "/") {
+		c.log.Warnf("Backend reported an invalid ingest path %q, using %s", caps.IngestPath, DefaultIngestPath)
+		return defaultCapabilities(), nil
+	}
+
+	if caps.ValidatePath != "" && !strings.HasPrefix(caps.ValidatePath, "/") {
+		c.log.Warnf("Backend reported an invalid validate path %q, ignoring it", caps.ValidatePath)
+		caps.ValidatePath = ""
+	}
+
+	switch caps.SchemaVersion {
+	case 0:
+		caps.SchemaVersion = SchemaEventArray
+	case SchemaEventsObject, SchemaEventArray:
+	default:
+		c.log.Warnf("Backend reported unsupported schema version %d, using %s", caps.SchemaVersion, DefaultIngestPath)
+		return defaultCapabilities(), nil
+	}
+
+	caps.Negotiated = true
+	return caps, nil
+}
+
+// configuredPath replaces the ingest path of caps with the configured one, if any
+func (c *Client) configuredPath(caps Capabilities) Capabilities {
+	if c.paths.ingest != "" {
+		caps.IngestPath = c.paths.ingest
+	}
+	return caps
+}
+
+// ingestCapabilities returns the negotiated capabilities, negotiating on
+// first use, or the defaults while the backend is unreachable
+func (c *Client) ingestCapabilities() Capabilities {
+	caps, err := c.Negotiate()
+	if err != nil {
+		c.log.Debugf("Capability negotiation failed, using defaults: %v", err)
+	}
+	return caps
+}
+
+// encodeBatch marshals a batch in the payload shape of the schema version
+func encodeBatch(batch []*types.AgentEvent, schemaVersion int) ([]byte, error) {
+	if schemaVersion == SchemaEventsObject {
+		return json.Marshal(map[string]interface{}{"events": batch})
+	}
+	return json.Marshal(batch)
+}
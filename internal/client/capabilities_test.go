@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func capabilityEvents() []*types.AgentEvent {
+	return []*types.AgentEvent{{
+		ID:        "event-1",
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "github-copilot",
+		SessionID: "session-1",
+		ProjectID: 1,
+		Data:      map[string]interface{}{"prompt": "hi"},
+	}}
+}
+
+func TestClient_NegotiatedIngestPath(t *testing.T) {
+	var mu sync.Mutex
+	capabilityCalls := 0
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/capabilities":
+			capabilityCalls++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ingestPath":    "/api/v1/agent/events/batch",
+				"schemaVersion": SchemaEventsObject,
+			})
+		case "/api/v1/agent/events/batch":
+			var body struct {
+				Events []*types.AgentEvent `json:"events"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, event := range body.Events {
+				received = append(received, event.ID)
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key", MaxRetries: 1})
+
+	caps, err := client.Negotiate()
+	if err != nil {
+		t.Fatalf("Negotiate failed: %v", err)
+	}
+	if !caps.Negotiated || caps.IngestPath != "/api/v1/agent/events/batch" || caps.SchemaVersion != SchemaEventsObject {
+		t.Errorf("unexpected capabilities %+v", caps)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SendBatch(capabilityEvents()); err != nil {
+			t.Fatalf("SendBatch failed: %v", err)
+		}
+	}
+
+	if len(received) != 2 {
+		t.Errorf("expected 2 events at the negotiated path, got %d", len(received))
+	}
+	if capabilityCalls != 1 {
+		t.Errorf("expected capabilities to be fetched once per session, got %d", capabilityCalls)
+	}
+}
+
+func TestClient_CapabilitiesFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		response func(w http.ResponseWriter)
+	}{
+		{"no endpoint", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) }},
+		{"not json", func(w http.ResponseWriter) { w.Write([]byte("<html>ok</html>")) }},
+		{"relative path", func(w http.ResponseWriter) { w.Write([]byte(`{"ingestPath":"events","schemaVersion":2}`)) }},
+		{"unknown schema", func(w http.ResponseWriter) { w.Write([]byte(`{"ingestPath":"/api/v3/events","schemaVersion":3}`)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var received int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch r.URL.Path {
+				case "/api/capabilities":
+					tt.response(w)
+				case DefaultIngestPath:
+					var events []*types.AgentEvent
+					if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					received += len(events)
+					w.WriteHeader(http.StatusOK)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key", MaxRetries: 1})
+			if _, err := client.SendBatch(capabilityEvents()); err != nil {
+				t.Fatalf("SendBatch failed: %v", err)
+			}
+
+			caps, _ := client.Negotiate()
+			if caps.Negotiated || caps.IngestPath != DefaultIngestPath {
+				t.Errorf("expected default capabilities, got %+v", caps)
+			}
+			if received != 1 {
+				t.Errorf("expected the event at the default path, got %d", received)
+			}
+		})
+	}
+}
+
+func TestClient_NegotiateUnreachableIsNotCached(t *testing.T) {
+	client := NewClient(Config{BaseURL: "http://127.0.0.1:1", Timeout: 100 * time.Millisecond})
+
+	caps, err := client.Negotiate()
+	if err == nil {
+		t.Fatal("expected an error for an unreachable backend")
+	}
+	if caps.IngestPath != DefaultIngestPath {
+		t.Errorf("expected defaults while unreachable, got %+v", caps)
+	}
+	if client.caps.cached {
+		t.Error("expected a failed negotiation not to be cached")
+	}
+}
+
+func TestClient_NegotiateDoesNotSerializeCallers(t *testing.T) {
+	server := hangingServer("/api/capabilities", 5*time.Second)
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, Timeout: 200 * time.Millisecond})
+
+	// Callers give up together on a wedged backend rather than waiting
+	// out each other's requests
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Negotiate(); err == nil {
+				t.Error("expected an error for a wedged backend")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected concurrent negotiations to time out together, took %v", elapsed)
+	}
+}
@@ -8,29 +8,69 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codervisor/devlog/pkg/types"
 	"github.com/sirupsen/logrus"
 )
 
+// Default paths, relative to the base URL, of the single event and health
+// endpoints (see Config.SingleEventPath and Config.HealthPath)
+const (
+	DefaultSingleEventPath = "/api/events"
+	DefaultHealthPath      = "/api/health"
+)
+
 // Client handles sending events to the backend API
 type Client struct {
 	baseURL    string
 	apiKey     string
+	paths      endpointPaths
 	httpClient *http.Client
+	timeouts   requestTimeouts
 	batchSize  int
 	batchDelay time.Duration
 	maxRetries int
+	maxBackoff time.Duration
 	log        *logrus.Logger
+	eventLimit int
 	batch      []*types.AgentEvent
 	batchMu    sync.Mutex
+	sendMu     sync.RWMutex // Held for reading by every flush in flight
+	manual     bool
+	sequence   *sequenceCounter
+	breaker    *circuitBreaker
+	limiter    *rateLimiter
+	validator  *validator
+	audit      *auditLog
+	name       string         // Destination name in audit entries
+	targets    []*destination // Set when batches are mirrored
+	lastFlush  atomic.Int64   // Unix nanoseconds of the last batch sent
+	onFailure  func([]*types.AgentEvent, error)
+	caps       capabilityCache
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 }
 
+// endpointPaths are the configured backend paths. An empty ingest path
+// leaves it to capability negotiation.
+type endpointPaths struct {
+	ingest string
+	single string
+	health string
+}
+
+// requestTimeouts bound each kind of backend request
+type requestTimeouts struct {
+	fallback time.Duration // requests without a more specific timeout
+	health   time.Duration
+	batch    time.Duration
+}
+
 // Config holds client configuration
 type Config struct {
 	BaseURL    string
@@ -38,8 +78,113 @@ type Config struct {
 	BatchSize  int
 	BatchDelay time.Duration
 	MaxRetries int
-	Timeout    time.Duration
 	Logger     *logrus.Logger
+
+	// IngestPath, SingleEventPath and HealthPath locate the batch ingest,
+	// single event and health endpoints relative to BaseURL, for backends
+	// mounted elsewhere. IngestPath, when set, overrides the path the backend
+	// reports through capability negotiation; otherwise that path is used,
+	// or DefaultIngestPath. The others default to DefaultSingleEventPath and
+	// DefaultHealthPath.
+	IngestPath      string
+	SingleEventPath string
+	HealthPath      string
+
+	// Timeout bounds requests without a more specific timeout, such as
+	// hierarchy lookups (default 30s). HealthCheckTimeout bounds health
+	// checks (default 5s) and BatchTimeout each batch POST (default twice
+	// Timeout, so raising Timeout gives batches more time too). They are
+	// applied to each request's context, so a wedged backend fails health
+	// checks quickly while large batches still get time to upload.
+	Timeout            time.Duration
+	HealthCheckTimeout time.Duration
+	BatchTimeout       time.Duration
+
+	// KeepAlive is the TCP keep-alive interval of backend connections
+	// (negative disables it), IdleConnTimeout how long an idle connection is
+	// kept for reuse and MaxIdleConnsPerHost how many are kept. Zero values
+	// keep the net/http defaults. DisableKeepAlives opens a new connection
+	// for every request.
+	KeepAlive           time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConnsPerHost int
+	DisableKeepAlives   bool
+
+	// Proxy is the URL of the HTTP proxy to reach the backend through. When
+	// empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables are honored.
+	Proxy string
+
+	// CACertPath is a PEM file of extra CA certificates to trust, e.g. the
+	// root of a TLS-intercepting corporate gateway
+	CACertPath string
+
+	// InsecureSkipVerify disables TLS certificate verification. Only for
+	// debugging; it exposes the API key to anyone on the network path.
+	InsecureSkipVerify bool
+
+	// ClientCertPath and ClientKeyPath are a PEM certificate and private key
+	// the collector presents to backends that require TLS client
+	// authentication. The API key is sent as well.
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// MaxEventBytes caps the JSON size of a single event (0 = no limit).
+	// Larger events have their prompt, response and tool output truncated;
+	// events that still don't fit are dropped so they can't fail the batch.
+	MaxEventBytes int
+
+	// MaxBackoff caps the randomized exponential delay between retries of
+	// a failed batch (default 30s)
+	MaxBackoff time.Duration
+
+	// MaxEventsPerSec bounds the sustained rate events are sent at, with
+	// bursts of up to BatchSize events (0 = unlimited). Batches that would
+	// wait longer than BatchDelay fail with ErrRateLimited and go through
+	// OnSendFailure to be buffered.
+	MaxEventsPerSec float64
+
+	// Destinations mirrors every batch to further backends besides BaseURL,
+	// each with its own breaker, retries and rate limit. A batch counts as
+	// sent once it reached BaseURL and every destination not marked
+	// BestEffort; otherwise it fails with a DestinationError.
+	Destinations []Destination
+
+	// BreakerThreshold is the number of consecutive failed batches that
+	// opens the circuit breaker; BreakerCooldown is how long it stays open
+	// before a probe batch is allowed
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// SequencePath persists the batch sequence counter across restarts.
+	// When empty, numbering restarts at 1 with each client. SequenceStream
+	// names the sequence in BatchStreamHeader; clients that may run at the
+	// same time need their own stream and path.
+	SequencePath   string
+	SequenceStream string
+
+	// Validation sets how events are checked before sending (default off).
+	// KnownAgents restricts the accepted agent IDs when non-empty, and in
+	// strict mode dropped events are appended to DeadLetterPath if set.
+	Validation     ValidationMode
+	KnownAgents    []string
+	DeadLetterPath string
+
+	// AuditLogPath, when set, gets a JSON line per batch a destination
+	// accepted: when, where to, how many events and their content hashes,
+	// but not the events themselves. The file is rotated once it would grow
+	// past AuditLogMaxBytes (default DefaultAuditLogMaxBytes).
+	AuditLogPath     string
+	AuditLogMaxBytes int64
+
+	// ManualFlush disables the background flush ticker and size-triggered
+	// flushes, so batches are only sent by FlushBatch, FlushSync or Stop.
+	// This makes batch boundaries deterministic in tests.
+	ManualFlush bool
+
+	// OnSendFailure receives the events of a queued batch that could not be
+	// delivered, so the caller can buffer them. It is called from the
+	// flushing goroutine.
+	OnSendFailure func(events []*types.AgentEvent, err error)
 }
 
 // NewClient creates a new API client
@@ -54,6 +199,22 @@ func NewClient(config Config) *Client {
 		config.Timeout = 30 * time.Second
 	}
 
+	if config.HealthCheckTimeout == 0 {
+		config.HealthCheckTimeout = 5 * time.Second
+	}
+
+	if config.BatchTimeout == 0 {
+		config.BatchTimeout = 2 * config.Timeout
+	}
+
+	if config.SingleEventPath == "" {
+		config.SingleEventPath = DefaultSingleEventPath
+	}
+
+	if config.HealthPath == "" {
+		config.HealthPath = DefaultHealthPath
+	}
+
 	if config.BatchSize == 0 {
 		config.BatchSize = 100
 	}
@@ -66,27 +227,80 @@ func NewClient(config Config) *Client {
 		config.MaxRetries = 3
 	}
 
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+
+	if config.BreakerThreshold == 0 {
+		config.BreakerThreshold = 5
+	}
+
+	if config.BreakerCooldown == 0 {
+		config.BreakerCooldown = time.Minute
+	}
+
+	sequence, err := newSequenceCounter(config.SequencePath, config.SequenceStream)
+	if err != nil {
+		config.Logger.Warnf("Failed to load batch sequence, numbering continues from %d: %v", sequence.last, err)
+	}
+
+	if config.InsecureSkipVerify {
+		config.Logger.Warn("⚠️  TLS certificate verification is DISABLED (insecureSkipVerify). Traffic to the backend, including the API key, can be intercepted.")
+	}
+
+	// Timeouts are set per request through its context
+	httpClient := &http.Client{}
+	if transport, err := newTransport(config); err != nil {
+		config.Logger.Errorf("Failed to configure HTTP transport, using defaults: %v", err)
+	} else {
+		httpClient.Transport = transport
+	}
+
 	client := &Client{
-		baseURL: config.BaseURL,
-		apiKey:  config.APIKey,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
+		baseURL:    config.BaseURL,
+		apiKey:     config.APIKey,
+		httpClient: httpClient,
+		timeouts: requestTimeouts{
+			fallback: config.Timeout,
+			health:   config.HealthCheckTimeout,
+			batch:    config.BatchTimeout,
+		},
+		paths: endpointPaths{
+			ingest: config.IngestPath,
+			single: config.SingleEventPath,
+			health: config.HealthPath,
 		},
 		batchSize:  config.BatchSize,
 		batchDelay: config.BatchDelay,
 		maxRetries: config.MaxRetries,
+		maxBackoff: config.MaxBackoff,
 		log:        config.Logger,
+		eventLimit: config.MaxEventBytes,
 		batch:      make([]*types.AgentEvent, 0, config.BatchSize),
+		sequence:   sequence,
+		breaker:    newCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
+		limiter:    newRateLimiter(config.MaxEventsPerSec, config.BatchSize, config.BatchDelay),
+		validator:  newValidator(config.Validation, config.KnownAgents, config.DeadLetterPath),
+		audit:      newAuditLog(config.AuditLogPath, config.AuditLogMaxBytes, config.Logger),
+		name:       PrimaryDestination,
+		onFailure:  config.OnSendFailure,
+		manual:     config.ManualFlush,
 		ctx:        ctx,
 		cancel:     cancel,
 	}
+	if len(config.Destinations) > 0 {
+		client.targets = client.newDestinations(config)
+	}
 
 	return client
 }
 
-// Start begins the batch processing loop
+// Start begins the batch processing loop, unless flushing is manual
 func (c *Client) Start() {
 	c.log.Info("Starting API client...")
+	if c.manual {
+		return
+	}
 	c.wg.Add(1)
 	go c.processBatchLoop()
 }
@@ -110,15 +324,29 @@ func (c *Client) Stop() error {
 	return nil
 }
 
-// SendEvent adds an event to the batch queue
+// SendEvent adds an event to the batch queue. It returns an error when the
+// event was not queued, in which case the caller still owns it and should
+// buffer it; this happens while the circuit breaker is open. Queued events
+// whose batch later fails to send are passed to Config.OnSendFailure.
 func (c *Client) SendEvent(event *types.AgentEvent) error {
+	if c.CircuitOpen() {
+		return ErrCircuitOpen
+	}
+
+	if err := c.validator.check(event); err != nil {
+		c.log.Warnf("Invalid event %s: %v", eventID(event), err)
+		if c.validator.drops() {
+			return nil
+		}
+	}
+
 	c.batchMu.Lock()
 	defer c.batchMu.Unlock()
 
 	c.batch = append(c.batch, event)
 
 	// Auto-flush if batch is full
-	if len(c.batch) >= c.batchSize {
+	if len(c.batch) >= c.batchSize && !c.manual {
 		go c.FlushBatch()
 	}
 
@@ -127,6 +355,9 @@ func (c *Client) SendEvent(event *types.AgentEvent) error {
 
 // FlushBatch sends the current batch to the backend
 func (c *Client) FlushBatch() error {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
 	c.batchMu.Lock()
 
 	if len(c.batch) == 0 {
@@ -139,10 +370,71 @@ func (c *Client) FlushBatch() error {
 	c.batch = make([]*types.AgentEvent, 0, c.batchSize)
 	c.batchMu.Unlock()
 
-	c.log.Infof("Flushing batch of %d events", len(batch))
+	c.log.WithField("events", len(batch)).Info("Flushing batch")
+
+	// Send batch with retries; events were validated as they were queued
+	result, err := c.sendBatchWithRetry(batch)
+	if err != nil {
+		if c.onFailure != nil {
+			c.onFailure(batch, err)
+		}
+		return err
+	}
+
+	c.logRejected(result)
+	return nil
+}
+
+// FlushSync sends the current batch and blocks until it and every other
+// flush already in flight have completed, so that all events queued before
+// the call have reached the backend (or OnSendFailure) when it returns
+func (c *Client) FlushSync() error {
+	err := c.FlushBatch()
+
+	// Wait out auto-flushes that took their batch before this call
+	c.sendMu.Lock()
+	c.sendMu.Unlock()
 
-	// Send batch with retries
-	return c.sendBatchWithRetry(batch)
+	return err
+}
+
+// SendBatch sends events to the backend immediately, with retries, and
+// reports which of them were accepted. Events failing strict validation are
+// not sent and are listed in BatchResult.Invalid; events rejected by the
+// backend are logged with the reason it gave.
+func (c *Client) SendBatch(events []*types.AgentEvent) (*BatchResult, error) {
+	valid := make([]*types.AgentEvent, 0, len(events))
+	var invalid []RejectedEvent
+	for _, event := range events {
+		if err := c.validator.check(event); err != nil {
+			c.log.Warnf("Invalid event %s: %v", eventID(event), err)
+			if c.validator.drops() {
+				invalid = append(invalid, RejectedEvent{ID: eventID(event), Reason: err.Error()})
+				continue
+			}
+		}
+		valid = append(valid, event)
+	}
+
+	if len(valid) == 0 {
+		return &BatchResult{Invalid: invalid}, nil
+	}
+
+	result, err := c.sendBatchWithRetry(valid)
+	if err != nil {
+		return nil, err
+	}
+	result.Invalid = append(invalid, result.Invalid...)
+
+	c.logRejected(result)
+	return result, nil
+}
+
+// logRejected logs the reason for each event the backend rejected
+func (c *Client) logRejected(result *BatchResult) {
+	for _, rejected := range result.Rejected {
+		c.log.Warnf("Backend rejected event %s: %s", rejected.ID, rejected.Reason)
+	}
 }
 
 // processBatchLoop periodically flushes the batch
@@ -167,61 +459,129 @@ func (c *Client) processBatchLoop() {
 	}
 }
 
-// sendBatchWithRetry sends a batch with exponential backoff retry
-func (c *Client) sendBatchWithRetry(batch []*types.AgentEvent) error {
+// sendBatchWithRetry sends a batch to the backend, or to every destination
+// when batches are mirrored
+func (c *Client) sendBatchWithRetry(batch []*types.AgentEvent) (*BatchResult, error) {
+	send := c.deliverWithRetry
+	if c.targets != nil {
+		send = c.fanOut
+	}
+
+	result, err := send(batch)
+	if err == nil {
+		c.lastFlush.Store(time.Now().UnixNano())
+	}
+	return result, err
+}
+
+// deliverWithRetry sends a batch to c's backend, retrying with jittered
+// exponential backoff
+func (c *Client) deliverWithRetry(batch []*types.AgentEvent) (*BatchResult, error) {
 	var lastErr error
 
+	// An event over the size limit would fail every attempt
+	batch, oversized := c.fitEvents(batch)
+	if len(batch) == 0 && len(oversized) > 0 {
+		return &BatchResult{Invalid: oversized}, nil
+	}
+
+	// Fail fast while the backend is known to be down
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	// Bound the ingest rate; retries of this batch don't draw on it again
+	if err := c.waitForRate(len(batch)); err != nil {
+		return nil, err
+	}
+
+	caps := c.ingestCapabilities()
+
+	// Retries reuse the same sequence number so the backend sees one batch
+	seq, err := c.sequence.Next()
+	if err != nil {
+		c.log.Warnf("Failed to persist batch sequence: %v", err)
+	}
+
+	var throttled *throttledError
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s, 8s...
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			// Random delay up to 1s, 2s, 4s, 8s... capped at maxBackoff,
+			// unless the backend said how long to wait
+			backoff := retryBackoff(attempt, retryBaseDelay, c.maxBackoff)
+			if throttled != nil && throttled.retryAfter > 0 {
+				backoff = min(throttled.retryAfter, c.maxBackoff)
+			}
 
 			select {
 			case <-time.After(backoff):
 			case <-c.ctx.Done():
-				return fmt.Errorf("send cancelled: %w", c.ctx.Err())
+				return nil, fmt.Errorf("send cancelled: %w", c.ctx.Err())
 			}
 		}
 
-		err := c.sendBatch(batch)
+		result, err := c.sendBatch(batch, seq, caps)
 		if err == nil {
-			return nil
+			c.breaker.RecordSuccess()
+			c.audit.record(c.name, c.baseURL, c.sequence.stream, seq, batch, result)
+			result.Invalid = oversized
+			return result, nil
 		}
 
 		lastErr = err
+		throttled = nil
+		errors.As(err, &throttled)
+
 		// Only log warnings if not a context cancellation
 		if !errors.Is(err, context.Canceled) && c.ctx.Err() == nil {
 			c.log.Warnf("Failed to send batch (attempt %d/%d): %v", attempt+1, c.maxRetries+1, err)
 		}
 	}
 
-	return fmt.Errorf("failed after %d attempts: %w", c.maxRetries+1, lastErr)
+	// A rate-limiting backend is up, so it doesn't count toward opening the
+	// breaker; the batch is retried later like any other failed batch
+	if throttled != nil && throttled.rateLimited() {
+		c.breaker.RecordSuccess()
+		return nil, fmt.Errorf("rate limited after %d attempts: %w", c.maxRetries+1, lastErr)
+	}
+
+	c.breaker.RecordFailure()
+	if c.breaker.State() == BreakerOpen {
+		c.log.Warnf("Backend unavailable, pausing sends for %s", c.breaker.cooldown)
+	}
+
+	return nil, fmt.Errorf("failed after %d attempts: %w", c.maxRetries+1, lastErr)
 }
 
-// sendBatch sends a batch of events to the backend
-func (c *Client) sendBatch(batch []*types.AgentEvent) error {
-	// Prepare request body - API expects array directly, not wrapped in object
-	body, err := json.Marshal(batch)
+// sendBatch sends a batch of events to the backend's ingest endpoint
+func (c *Client) sendBatch(batch []*types.AgentEvent, seq uint64, caps Capabilities) (*BatchResult, error) {
+	body, err := encodeBatch(batch, caps.SchemaVersion)
 	if err != nil {
-		return fmt.Errorf("failed to marshal events: %w", err)
+		return nil, fmt.Errorf("failed to marshal events: %w", err)
 	}
 
 	// Create request
-	url := fmt.Sprintf("%s/api/events/batch", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "POST", url, bytes.NewReader(body))
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.batch)
+	defer cancel()
+	url := c.baseURL + caps.IngestPath
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	req.Header.Set("User-Agent", "devlog-collector/1.0")
+	req.Header.Set(BatchSequenceHeader, strconv.FormatUint(seq, 10))
+	if c.sequence.stream != "" {
+		req.Header.Set(BatchStreamHeader, c.sequence.stream)
+	}
 
 	// Send request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -230,11 +590,16 @@ func (c *Client) sendBatch(batch []*types.AgentEvent) error {
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return nil, responseError(resp, respBody)
 	}
 
-	c.log.Debugf("Successfully sent batch of %d events", len(batch))
-	return nil
+	result := parseBatchResult(batch, respBody)
+	c.log.WithFields(logrus.Fields{
+		"events":   len(batch),
+		"accepted": len(result.Accepted),
+		"rejected": len(result.Rejected),
+	}).Debug("Sent batch")
+	return result, nil
 }
 
 // SendSingleEvent sends a single event immediately (bypass batching)
@@ -244,8 +609,10 @@ func (c *Client) SendSingleEvent(event *types.AgentEvent) error {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/events", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "POST", url, bytes.NewReader(body))
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.fallback)
+	defer cancel()
+	url := c.baseURL + c.paths.single
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -261,7 +628,7 @@ func (c *Client) SendSingleEvent(event *types.AgentEvent) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return statusError(resp, respBody)
 	}
 
 	return nil
@@ -269,8 +636,10 @@ func (c *Client) SendSingleEvent(event *types.AgentEvent) error {
 
 // HealthCheck checks if the backend is reachable
 func (c *Client) HealthCheck() error {
-	url := fmt.Sprintf("%s/api/health", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "GET", url, nil)
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.health)
+	defer cancel()
+	url := c.baseURL + c.paths.health
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -297,5 +666,32 @@ func (c *Client) GetStats() map[string]interface{} {
 		"pending_events": len(c.batch),
 		"batch_size":     c.batchSize,
 		"batch_delay":    c.batchDelay.String(),
+		"breaker_state":  string(c.breaker.State()),
+		"invalid_events": c.validator.count(),
+	}
+}
+
+// LastFlush returns when a batch was last sent successfully, or the zero
+// time if none has been
+func (c *Client) LastFlush() time.Time {
+	nanos := c.lastFlush.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// CircuitOpen reports whether the circuit breaker, or that of a required
+// destination, is currently rejecting sends. Callers draining the offline
+// buffer should wait while it is open.
+func (c *Client) CircuitOpen() bool {
+	return c.breaker.State() == BreakerOpen || c.destinationsOpen()
+}
+
+// eventID returns the event's ID for logging, tolerating nil events
+func eventID(event *types.AgentEvent) string {
+	if event == nil {
+		return "<nil>"
 	}
+	return event.ID
 }
@@ -1,9 +1,15 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -178,3 +184,377 @@ func TestClient_GetStats(t *testing.T) {
 		t.Errorf("expected pending_events=0, got %v", stats["pending_events"])
 	}
 }
+
+func TestClient_BatchSequencePersistsAcrossRestarts(t *testing.T) {
+	var sequences []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/capabilities" {
+			http.NotFound(w, r)
+			return
+		}
+		sequences = append(sequences, r.Header.Get(BatchSequenceHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := Config{
+		BaseURL:      server.URL,
+		APIKey:       "test-key",
+		BatchSize:    100,
+		BatchDelay:   time.Hour,
+		SequencePath: filepath.Join(t.TempDir(), "batch.seq"),
+	}
+
+	sendOne := func(c *Client) {
+		event := &types.AgentEvent{
+			ID:        uuid.New().String(),
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+		}
+		if err := c.SendEvent(event); err != nil {
+			t.Fatalf("failed to send event: %v", err)
+		}
+		if err := c.FlushBatch(); err != nil {
+			t.Fatalf("failed to flush batch: %v", err)
+		}
+	}
+
+	client := NewClient(config)
+	sendOne(client)
+	sendOne(client)
+	client.Stop()
+
+	// Simulate a restart with a fresh client on the same sequence file
+	restarted := NewClient(config)
+	sendOne(restarted)
+	restarted.Stop()
+
+	expected := []string{"1", "2", "3"}
+	if len(sequences) != len(expected) {
+		t.Fatalf("Expected %d batches, got %d", len(expected), len(sequences))
+	}
+	for i, seq := range expected {
+		if sequences[i] != seq {
+			t.Errorf("Batch %d: expected sequence %s, got %s", i, seq, sequences[i])
+		}
+	}
+}
+
+func TestClient_BatchSequencePerStream(t *testing.T) {
+	var mu sync.Mutex
+	streams := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/capabilities" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		stream := r.Header.Get(BatchStreamHeader)
+		streams[stream] = append(streams[stream], r.Header.Get(BatchSequenceHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The collector and a backfill sending at once, from the same state dir
+	dir := t.TempDir()
+	newStreamClient := func(stream string) *Client {
+		return NewClient(Config{
+			BaseURL:        server.URL,
+			APIKey:         "test-key",
+			BatchSize:      100,
+			BatchDelay:     time.Hour,
+			SequencePath:   filepath.Join(dir, "batch-"+stream+".seq"),
+			SequenceStream: stream,
+		})
+	}
+	collector := newStreamClient("collector")
+	defer collector.Stop()
+	backfill := newStreamClient("backfill")
+	defer backfill.Stop()
+
+	for i := 0; i < 2; i++ {
+		for _, c := range []*Client{collector, backfill} {
+			event := &types.AgentEvent{
+				ID:        uuid.New().String(),
+				Timestamp: time.Now(),
+				Type:      types.EventTypeLLMRequest,
+				AgentID:   "test-agent",
+				SessionID: "test-session",
+			}
+			if err := c.SendEvent(event); err != nil {
+				t.Fatalf("failed to send event: %v", err)
+			}
+			if err := c.FlushBatch(); err != nil {
+				t.Fatalf("failed to flush batch: %v", err)
+			}
+		}
+	}
+
+	for _, stream := range []string{"collector", "backfill"} {
+		if got := strings.Join(streams[stream], ","); got != "1,2" {
+			t.Errorf("Expected stream %s numbered 1,2, got %s", stream, got)
+		}
+	}
+	if len(streams) != 2 {
+		t.Errorf("Expected batches in 2 streams, got %v", streams)
+	}
+}
+
+func TestClient_FlushSyncWaitsForDelivery(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// A slow backend: the flush must not return before this completes
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		received += len(events)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		BatchSize:   2,
+		ManualFlush: true,
+	})
+	client.Start()
+	defer client.Stop()
+
+	for i := 0; i < 5; i++ {
+		event := &types.AgentEvent{
+			ID:        uuid.New().String(),
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "test-agent",
+			SessionID: "test-session",
+		}
+		if err := client.SendEvent(event); err != nil {
+			t.Fatalf("failed to send event: %v", err)
+		}
+	}
+
+	// Manual flushing never sends on its own, even past the batch size
+	mu.Lock()
+	if received != 0 {
+		t.Errorf("Expected no events before flushing, got %d", received)
+	}
+	mu.Unlock()
+
+	if err := client.FlushSync(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 5 {
+		t.Errorf("Expected all 5 events delivered when FlushSync returns, got %d", received)
+	}
+}
+
+func TestClient_FlushSyncWaitsForAutoFlush(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		json.NewDecoder(r.Body).Decode(&events)
+
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		received += len(events)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:    server.URL,
+		APIKey:     "test-key",
+		BatchSize:  2,
+		BatchDelay: time.Hour,
+	})
+
+	// Filling the batch starts a background flush
+	for i := 0; i < 2; i++ {
+		client.SendEvent(&types.AgentEvent{ID: uuid.New().String(), Timestamp: time.Now()})
+	}
+
+	// Give the auto-flush time to take the batch before flushing
+	time.Sleep(20 * time.Millisecond)
+	if err := client.FlushSync(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 2 {
+		t.Errorf("Expected the in-flight batch to be delivered when FlushSync returns, got %d", received)
+	}
+}
+
+// hangingServer answers requests to path only after delay, or when the
+// client gives up
+func hangingServer(path string, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path {
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestClient_HealthCheckUsesShortTimeout(t *testing.T) {
+	server := hangingServer("/api/health", 5*time.Second)
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:            server.URL,
+		Timeout:            10 * time.Second,
+		HealthCheckTimeout: 100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	err := client.HealthCheck()
+	if err == nil {
+		t.Fatal("Expected a wedged backend to fail the health check")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the health check timeout to apply, took %v", elapsed)
+	}
+}
+
+func TestClient_BatchOutlastsHealthCheckTimeout(t *testing.T) {
+	server := hangingServer(DefaultIngestPath, 300*time.Millisecond)
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:            server.URL,
+		HealthCheckTimeout: 50 * time.Millisecond,
+		BatchTimeout:       5 * time.Second,
+		MaxRetries:         1,
+	})
+
+	event := &types.AgentEvent{
+		ID:        "evt-1",
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "github-copilot",
+		SessionID: "session-1",
+	}
+	if _, err := client.SendBatch([]*types.AgentEvent{event}); err != nil {
+		t.Errorf("Expected a slow batch to finish within the batch timeout, got %v", err)
+	}
+}
+
+func TestClient_BatchTimeout(t *testing.T) {
+	server := hangingServer(DefaultIngestPath, 5*time.Second)
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:      server.URL,
+		BatchTimeout: 100 * time.Millisecond,
+	})
+
+	caps := client.ingestCapabilities()
+	start := time.Now()
+	if _, err := client.sendBatch(nil, 1, caps); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the batch timeout to apply, took %v", elapsed)
+	}
+}
+
+func TestNewClient_BatchTimeoutFollowsTimeout(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		batch time.Duration
+	}{
+		{"Defaults", Config{}, 60 * time.Second},
+		{"Timeout", Config{Timeout: 2 * time.Minute}, 4 * time.Minute},
+		{"Explicit", Config{Timeout: 2 * time.Minute, BatchTimeout: 90 * time.Second}, 90 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.cfg)
+			defer client.Stop()
+			if client.timeouts.batch != tt.batch {
+				t.Errorf("Expected a batch timeout of %v, got %v", tt.batch, client.timeouts.batch)
+			}
+		})
+	}
+}
+
+func TestClient_ConfiguredEndpointPaths(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/capabilities":
+			// The configured ingest path wins over the reported one
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ingestPath":    "/api/v1/agent/events/batch",
+				"schemaVersion": SchemaEventArray,
+			})
+		case "/devlog/ingest", "/devlog/event", "/devlog/healthz":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:         server.URL,
+		APIKey:          "test-key",
+		MaxRetries:      1,
+		IngestPath:      "/devlog/ingest",
+		SingleEventPath: "/devlog/event",
+		HealthPath:      "/devlog/healthz",
+	})
+
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if _, err := client.SendBatch(capabilityEvents()); err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+	if err := client.SendSingleEvent(capabilityEvents()[0]); err != nil {
+		t.Fatalf("SendSingleEvent failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range []string{"/devlog/healthz", "/devlog/ingest", "/devlog/event"} {
+		if hits[path] != 1 {
+			t.Errorf("expected one request to %s, got %d (all requests: %v)", path, hits[path], hits)
+		}
+	}
+	if hits["/api/v1/agent/events/batch"] != 0 {
+		t.Errorf("expected the reported ingest path not to be used, got %v", hits)
+	}
+}
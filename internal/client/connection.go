@@ -0,0 +1,232 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
+)
+
+// DryRunHeader marks a batch the backend should check but not store
+const DryRunHeader = "X-Devlog-Dry-Run"
+
+// Steps of TestConnection, in the order they run
+const (
+	StepHealth       = "health"
+	StepCapabilities = "capabilities"
+	StepValidate     = "validate"
+)
+
+// ConnectionStep is the outcome of one check of TestConnection. Detail
+// describes what worked, or why the check was Skipped; Hint says what to
+// fix when Err is set.
+type ConnectionStep struct {
+	Name    string
+	Detail  string
+	Err     error
+	Hint    string
+	Skipped bool
+}
+
+// ConnectionReport lists the checks TestConnection ran. It stops at the
+// first failure, since later checks depend on the earlier ones.
+type ConnectionReport struct {
+	URL   string
+	Steps []ConnectionStep
+}
+
+// Skipped reports whether a check was skipped, leaving something unverified
+func (r *ConnectionReport) Skipped() bool {
+	for _, step := range r.Steps {
+		if step.Skipped {
+			return true
+		}
+	}
+	return false
+}
+
+// OK reports whether every check passed or was skipped
+func (r *ConnectionReport) OK() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return false
+		}
+	}
+	return len(r.Steps) > 0
+}
+
+// TestConnection checks that the backend is reachable, negotiates its
+// capabilities and sends one synthetic event flagged as a dry run to the
+// validate endpoint the backend advertises, so connectivity, TLS and
+// credentials can be verified without sending real data
+func (c *Client) TestConnection() *ConnectionReport {
+	report := &ConnectionReport{URL: c.baseURL}
+	add := func(step ConnectionStep) bool {
+		report.Steps = append(report.Steps, step)
+		return step.Err == nil
+	}
+
+	if !add(c.checkHealth()) {
+		return report
+	}
+
+	caps, err := c.Negotiate()
+	step := ConnectionStep{Name: StepCapabilities, Err: err, Hint: connectionHint(err, 0)}
+	if err == nil {
+		step.Detail = fmt.Sprintf("ingest %s (schema v%d)", caps.IngestPath, caps.SchemaVersion)
+		if caps.ValidatePath != "" {
+			step.Detail += ", validate " + caps.ValidatePath
+		}
+		if !caps.Negotiated {
+			step.Detail += ", backend reports no capabilities"
+		}
+	}
+	if !add(step) {
+		return report
+	}
+
+	add(c.checkValidate(caps))
+	return report
+}
+
+// checkHealth requests the health endpoint without credentials, separating
+// network and TLS problems from authentication
+func (c *Client) checkHealth() ConnectionStep {
+	step := ConnectionStep{Name: StepHealth}
+	status, err := c.probe("GET", c.paths.health, nil, false)
+	if err != nil {
+		step.Err = err
+		step.Hint = connectionHint(err, status)
+		return step
+	}
+	step.Detail = fmt.Sprintf("%s answered %d", c.paths.health, status)
+	return step
+}
+
+// checkValidate sends a synthetic event to the validate endpoint. It is
+// skipped when the backend advertises none, since a backend that ignores
+// the dry run flag would store an event sent to its ingest path.
+func (c *Client) checkValidate(caps Capabilities) ConnectionStep {
+	step := ConnectionStep{Name: StepValidate}
+	path := caps.ValidatePath
+	if path == "" {
+		step.Skipped = true
+		step.Detail = "backend advertises no validate endpoint, credentials not checked"
+		return step
+	}
+
+	body, err := encodeBatch([]*types.AgentEvent{connectionTestEvent()}, caps.SchemaVersion)
+	if err != nil {
+		step.Err = fmt.Errorf("failed to marshal test event: %w", err)
+		return step
+	}
+
+	status, err := c.probe("POST", path, body, true)
+	if err != nil {
+		step.Err = err
+		step.Hint = connectionHint(err, status)
+		return step
+	}
+	step.Detail = fmt.Sprintf("test event accepted by %s", path)
+	return step
+}
+
+// probe sends one request to path and returns the response status, with an
+// error for transport failures and non-2xx responses
+func (c *Client) probe(method, path string, body []byte, auth bool) (int, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.health)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(DryRunHeader, "true")
+	}
+	if auth {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	}
+	req.Header.Set("User-Agent", "devlog-collector/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, statusError(resp, respBody)
+	}
+	return resp.StatusCode, nil
+}
+
+// connectionTestEvent is the synthetic event TestConnection sends. It
+// carries no captured data.
+func connectionTestEvent() *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now().UTC(),
+		Type:      types.EventTypeSessionStart,
+		AgentID:   "devlog-collector",
+		SessionID: "connection-test",
+		Data:      map[string]interface{}{"dryRun": true},
+	}
+}
+
+// connectionHint explains a failed check from the response status or, for
+// transport failures (status 0), the TLS, DNS or network error
+func connectionHint(err error, status int) string {
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return "the API key was rejected; check apiKey"
+	case status == http.StatusForbidden:
+		return "the API key is valid but not allowed to send events; check its project and permissions"
+	case status == http.StatusNotFound:
+		return "the endpoint doesn't exist; check backendUrl and the ingestPath/healthPath settings"
+	case status >= 500:
+		return "the backend is failing; check its logs or try again later"
+	case status != 0:
+		return "the backend was reached but refused the request"
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the server's certificate isn't signed by a trusted CA; set caCertPath to the CA that issued it"
+	case errors.As(err, &hostname):
+		return "the server's certificate doesn't match the host name; check backendUrl"
+	case errors.As(err, &invalid):
+		return "the server's certificate is invalid or expired"
+	case errors.As(err, &recordHeader) || strings.Contains(err.Error(), "HTTP response to HTTPS client"):
+		return "the server doesn't speak TLS; use an http:// backendUrl or the TLS port"
+	case errors.As(err, &dnsErr):
+		return "the backend host name doesn't resolve; check backendUrl and DNS"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "nothing is listening at backendUrl; check the host and port"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "the backend didn't answer in time; check proxy and firewall settings"
+	}
+	return "the backend couldn't be reached; check backendUrl and proxy settings"
+}
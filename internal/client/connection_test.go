@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testValidatePath is the validate endpoint connectionTestServer advertises
+const testValidatePath = "/api/events/validate"
+
+// connectionTestServer answers the health endpoint and, with status, the
+// validate endpoint it then advertises; a zero status advertises none.
+// Requests to the ingest path are counted in ingested.
+func connectionTestServer(t *testing.T, validateStatus int, ingested *int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(DefaultHealthPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Health check shouldn't send credentials")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		caps := Capabilities{IngestPath: DefaultIngestPath, SchemaVersion: SchemaEventArray}
+		if validateStatus != 0 {
+			caps.ValidatePath = testValidatePath
+		}
+		json.NewEncoder(w).Encode(caps)
+	})
+	if validateStatus != 0 {
+		mux.HandleFunc(testValidatePath, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(DryRunHeader) != "true" {
+				t.Errorf("Expected the test event to be flagged as a dry run")
+			}
+			w.WriteHeader(validateStatus)
+		})
+	}
+	mux.HandleFunc(DefaultIngestPath, func(w http.ResponseWriter, r *http.Request) {
+		if ingested != nil {
+			*ingested++
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient_TestConnection(t *testing.T) {
+	server := connectionTestServer(t, http.StatusNoContent, nil)
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+	report := client.TestConnection()
+
+	if !report.OK() || report.Skipped() {
+		t.Fatalf("Expected the connection test to pass, got %+v", report.Steps)
+	}
+	var names []string
+	for _, step := range report.Steps {
+		names = append(names, step.Name)
+	}
+	if got := strings.Join(names, ","); got != "health,capabilities,validate" {
+		t.Errorf("Expected health, capabilities and validate steps, got %s", got)
+	}
+	if detail := report.Steps[2].Detail; !strings.Contains(detail, testValidatePath) {
+		t.Errorf("Expected the test event to go to %s, got %q", testValidatePath, detail)
+	}
+}
+
+func TestClient_TestConnectionSkipsValidateWithoutEndpoint(t *testing.T) {
+	var ingested int
+	server := connectionTestServer(t, 0, &ingested)
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+	report := client.TestConnection()
+
+	if !report.OK() {
+		t.Fatalf("Expected the connection test to pass, got %+v", report.Steps)
+	}
+	last := report.Steps[len(report.Steps)-1]
+	if last.Name != StepValidate || !last.Skipped || !report.Skipped() {
+		t.Errorf("Expected the validate step to be skipped, got %+v", last)
+	}
+	if ingested != 0 {
+		t.Errorf("Expected no test event on the ingest path, got %d", ingested)
+	}
+}
+
+func TestClient_TestConnectionDiagnosesStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		hint   string
+	}{
+		{"Unauthorized", http.StatusUnauthorized, "API key was rejected"},
+		{"Forbidden", http.StatusForbidden, "not allowed to send events"},
+		{"Server error", http.StatusBadGateway, "backend is failing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := connectionTestServer(t, tt.status, nil)
+			defer server.Close()
+
+			report := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"}).TestConnection()
+			if report.OK() {
+				t.Fatal("Expected the connection test to fail")
+			}
+			last := report.Steps[len(report.Steps)-1]
+			if last.Name != StepValidate {
+				t.Errorf("Expected the validate step to fail, got %s", last.Name)
+			}
+			if !strings.Contains(last.Hint, tt.hint) {
+				t.Errorf("Expected hint mentioning %q, got %q", tt.hint, last.Hint)
+			}
+		})
+	}
+}
+
+func TestClient_TestConnectionDiagnosesNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	report := NewClient(Config{BaseURL: server.URL + "/devlog"}).TestConnection()
+	if report.OK() || len(report.Steps) != 1 {
+		t.Fatalf("Expected the health step to fail alone, got %+v", report.Steps)
+	}
+	if !strings.Contains(report.Steps[0].Hint, "endpoint doesn't exist") {
+		t.Errorf("Unexpected hint %q", report.Steps[0].Hint)
+	}
+}
+
+func TestClient_TestConnectionDiagnosesTLS(t *testing.T) {
+	t.Run("Untrusted certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+
+		report := NewClient(Config{BaseURL: server.URL}).TestConnection()
+		if report.OK() {
+			t.Fatal("Expected the connection test to fail")
+		}
+		if hint := report.Steps[0].Hint; !strings.Contains(hint, "caCertPath") {
+			t.Errorf("Expected a hint about caCertPath, got %q", hint)
+		}
+	})
+
+	t.Run("Plain HTTP server", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		url := strings.Replace(server.URL, "http://", "https://", 1)
+		report := NewClient(Config{BaseURL: url}).TestConnection()
+		if report.OK() {
+			t.Fatal("Expected the connection test to fail")
+		}
+		if hint := report.Steps[0].Hint; !strings.Contains(hint, "doesn't speak TLS") {
+			t.Errorf("Expected a hint about TLS, got %q", hint)
+		}
+	})
+
+	t.Run("Connection refused", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		report := NewClient(Config{BaseURL: url}).TestConnection()
+		if hint := report.Steps[0].Hint; !strings.Contains(hint, "nothing is listening") {
+			t.Errorf("Expected a hint about the port, got %q", hint)
+		}
+	})
+}
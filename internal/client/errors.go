@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyLen bounds how much of an error response body is included in
+// error messages; proxies often answer with whole HTML pages
+const maxErrorBodyLen = 200
+
+// statusError builds the error for a non-2xx response, including the
+// content type and a compact, truncated excerpt of the body
+func statusError(resp *http.Response, body []byte) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "unknown content type"
+	}
+	return fmt.Errorf("unexpected status %d (%s): %s", resp.StatusCode, contentType, summarizeBody(contentType, body))
+}
+
+// summarizeBody returns the error message from a JSON body, or the body with
+// whitespace collapsed and cut to maxErrorBodyLen
+func summarizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return "empty body"
+	}
+
+	if strings.Contains(contentType, "json") || json.Valid(body) {
+		var payload struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &payload); err == nil {
+			if payload.Message != "" {
+				return truncate(payload.Message, len(body))
+			}
+			if payload.Error != "" {
+				return truncate(payload.Error, len(body))
+			}
+		}
+	}
+
+	return truncate(strings.Join(strings.Fields(string(body)), " "), len(body))
+}
+
+// truncate cuts s to maxErrorBodyLen, noting the full body size when it does
+func truncate(s string, bodyLen int) string {
+	if len(s) <= maxErrorBodyLen {
+		return s
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes)", strings.ToValidUTF8(s[:maxErrorBodyLen], ""), bodyLen)
+}
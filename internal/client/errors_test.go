@@ -0,0 +1,71 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func TestClient_TruncatesHTMLErrorBody(t *testing.T) {
+	page := "<!DOCTYPE html>\n<html>\n  <head><title>502 Bad Gateway</title></head>\n  <body>\n" +
+		strings.Repeat("    <p>upstream connect error or disconnect/reset before headers</p>\n", 500) +
+		"  </body>\n</html>\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:    server.URL,
+		APIKey:     "test-key",
+		MaxRetries: 1,
+	})
+
+	event := &types.AgentEvent{ID: "event-1", Timestamp: time.Now(), Type: types.EventTypeLLMRequest}
+	_, err := client.sendBatch([]*types.AgentEvent{event}, 1, defaultCapabilities())
+	if err == nil {
+		t.Fatal("expected an error for a 502 response")
+	}
+
+	msg := err.Error()
+	if len(msg) > 400 {
+		t.Errorf("expected a truncated error message, got %d bytes", len(msg))
+	}
+	for _, want := range []string{"502", "text/html", "502 Bad Gateway", "truncated", "bytes"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected error to mention %q, got: %s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "\n") {
+		t.Errorf("expected whitespace to be collapsed, got: %q", msg)
+	}
+}
+
+func TestClient_JSONErrorBodyUsesMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid batch: eventType is required"})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	_, err := client.sendBatch(nil, 1, defaultCapabilities())
+	if err == nil {
+		t.Fatal("expected an error for a 400 response")
+	}
+
+	want := "unexpected status 400 (application/json): invalid batch: eventType is required"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// PrimaryDestination names the destination at Config.BaseURL
+const PrimaryDestination = "primary"
+
+// maxDeliveredTracked bounds how many event IDs a destination remembers
+// having received while waiting for another destination to accept them.
+// Past it the record is dropped, and those events may be sent twice.
+const maxDeliveredTracked = 100_000
+
+// Destination is a further backend every batch is mirrored to
+type Destination struct {
+	Name    string
+	BaseURL string
+	APIKey  string
+
+	// BestEffort destinations don't hold events back: a batch that fails to
+	// reach one is logged and not retried for it. Batches that fail to reach
+	// a required destination go through OnSendFailure to be buffered.
+	BestEffort bool
+}
+
+// DestinationError is returned when a batch failed to reach one or more
+// required destinations. The destinations it did reach remember its events,
+// so a retry of the batch only goes to the ones that failed.
+type DestinationError struct {
+	Failed map[string]error
+}
+
+func (e *DestinationError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+	return "failed to reach " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the error of each failed destination
+func (e *DestinationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// destination is one target of a fanned-out batch
+type destination struct {
+	name     string
+	required bool
+	client   *Client
+	send     func([]*types.AgentEvent) (*BatchResult, error)
+
+	mu        sync.Mutex
+	delivered map[string]bool // events received while a required destination failed
+}
+
+// newDestinations returns the primary destination, sent to by c itself,
+// followed by one mirror client per entry of config.Destinations
+func (c *Client) newDestinations(config Config) []*destination {
+	primary := &destination{
+		name:      PrimaryDestination,
+		required:  true,
+		client:    c,
+		send:      c.deliverWithRetry,
+		delivered: make(map[string]bool),
+	}
+	destinations := []*destination{primary}
+
+	for _, d := range config.Destinations {
+		mirror := NewClient(mirrorConfig(config, d))
+		// Mirrors are stopped along with c
+		mirror.cancel()
+		mirror.ctx, mirror.cancel = context.WithCancel(c.ctx)
+
+		name := d.Name
+		if name == "" {
+			name = d.BaseURL
+		}
+		mirror.name = name
+		mirror.audit = c.audit
+		destinations = append(destinations, &destination{
+			name:      name,
+			required:  !d.BestEffort,
+			client:    mirror,
+			send:      mirror.deliverWithRetry,
+			delivered: make(map[string]bool),
+		})
+	}
+	return destinations
+}
+
+// mirrorConfig derives the client configuration of destination d. Events
+// are validated and failures buffered once, by the fanning-out client.
+func mirrorConfig(config Config, d Destination) Config {
+	config.BaseURL = d.BaseURL
+	config.APIKey = d.APIKey
+	config.Destinations = nil
+	config.Validation = ValidationOff
+	config.DeadLetterPath = ""
+	config.AuditLogPath = ""
+	config.OnSendFailure = nil
+	config.ManualFlush = true
+	if config.SequencePath != "" {
+		config.SequencePath += "." + d.Name
+	}
+	return config
+}
+
+// pending returns the events of batch not yet delivered to d
+func (d *destination) pending(batch []*types.AgentEvent) []*types.AgentEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.delivered) == 0 {
+		return batch
+	}
+	pending := make([]*types.AgentEvent, 0, len(batch))
+	for _, event := range batch {
+		if !d.delivered[event.ID] {
+			pending = append(pending, event)
+		}
+	}
+	return pending
+}
+
+// remember records events d accepted while the batch failed elsewhere
+func (d *destination) remember(ids []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.delivered)+len(ids) > maxDeliveredTracked {
+		d.client.log.Warnf("Too many events awaiting other destinations, %s may receive some twice", d.name)
+		d.delivered = make(map[string]bool)
+	}
+	for _, id := range ids {
+		d.delivered[id] = true
+	}
+}
+
+// forget drops the record of events that no longer need to be retried
+func (d *destination) forget(batch []*types.AgentEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, event := range batch {
+		delete(d.delivered, event.ID)
+	}
+}
+
+// fanOut sends batch to every destination concurrently. It fails with a
+// DestinationError if a required destination couldn't be reached; otherwise
+// an event counts as accepted once every required destination accepted it.
+func (c *Client) fanOut(batch []*types.AgentEvent) (*BatchResult, error) {
+	pending := make([][]*types.AgentEvent, len(c.targets))
+	results := make([]*BatchResult, len(c.targets))
+	errs := make([]error, len(c.targets))
+
+	var wg sync.WaitGroup
+	for i, d := range c.targets {
+		pending[i] = d.pending(batch)
+		if len(pending[i]) == 0 {
+			results[i] = &BatchResult{}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = d.send(pending[i])
+		}()
+	}
+	wg.Wait()
+
+	failed := make(map[string]error)
+	for i, d := range c.targets {
+		if errs[i] == nil {
+			continue
+		}
+		if d.required {
+			failed[d.name] = errs[i]
+		} else if !errors.Is(errs[i], context.Canceled) {
+			c.log.Warnf("Failed to send %d events to best-effort destination %s: %v", len(pending[i]), d.name, errs[i])
+		}
+	}
+
+	if len(failed) > 0 {
+		for i, d := range c.targets {
+			if errs[i] == nil {
+				d.remember(results[i].Accepted)
+			}
+		}
+		return nil, &DestinationError{Failed: failed}
+	}
+
+	for _, d := range c.targets {
+		d.forget(batch)
+	}
+	return c.mergeResults(batch, pending, results), nil
+}
+
+// mergeResults combines the results of the required destinations: events
+// are accepted when every one of them accepted the event now or before
+func (c *Client) mergeResults(batch []*types.AgentEvent, pending [][]*types.AgentEvent, results []*BatchResult) *BatchResult {
+	merged := &BatchResult{}
+	acceptedBy := make(map[string]int, len(batch))
+	required := 0
+	rejected := make(map[string]bool)
+	invalid := make(map[string]bool)
+
+	for i, d := range c.targets {
+		result := results[i]
+		if result == nil {
+			continue // A best-effort destination that failed
+		}
+		for _, r := range result.Invalid {
+			if !invalid[r.ID] {
+				invalid[r.ID] = true
+				merged.Invalid = append(merged.Invalid, r)
+			}
+		}
+		if !d.required {
+			continue
+		}
+		required++
+
+		accepted := make(map[string]bool, len(batch))
+		for _, id := range result.Accepted {
+			accepted[id] = true
+		}
+		sent := make(map[string]bool, len(pending[i]))
+		for _, event := range pending[i] {
+			sent[event.ID] = true
+		}
+		for _, event := range batch {
+			if accepted[event.ID] || !sent[event.ID] {
+				acceptedBy[event.ID]++
+			}
+		}
+
+		for _, r := range result.Rejected {
+			if !rejected[r.ID] {
+				rejected[r.ID] = true
+				merged.Rejected = append(merged.Rejected, r)
+			}
+		}
+	}
+
+	for _, event := range batch {
+		if acceptedBy[event.ID] == required && !rejected[event.ID] && !invalid[event.ID] {
+			merged.Accepted = append(merged.Accepted, event.ID)
+		}
+	}
+	return merged
+}
+
+// destinationsOpen reports whether the circuit breaker of any required
+// destination is open
+func (c *Client) destinationsOpen() bool {
+	for _, d := range c.targets {
+		if d.required && d.client.breaker.State() == BreakerOpen {
+			return true
+		}
+	}
+	return false
+}
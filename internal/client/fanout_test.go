@@ -0,0 +1,163 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// mirrorServer counts the events it ingests and fails while down is set
+type mirrorServer struct {
+	*httptest.Server
+	received atomic.Int64
+	down     atomic.Bool
+}
+
+func newMirrorServer() *mirrorServer {
+	s := &mirrorServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DefaultIngestPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var events []json.RawMessage
+		json.NewDecoder(r.Body).Decode(&events)
+		s.received.Add(int64(len(events)))
+		w.WriteHeader(http.StatusOK)
+	}))
+	return s
+}
+
+func fanOutClient(primary, mirror *mirrorServer, bestEffort bool, onFailure func([]*types.AgentEvent, error)) *Client {
+	return NewClient(Config{
+		BaseURL:     primary.URL,
+		APIKey:      "local-key",
+		MaxRetries:  1,
+		MaxBackoff:  10 * time.Millisecond,
+		ManualFlush: true,
+		Destinations: []Destination{
+			{Name: "team", BaseURL: mirror.URL, APIKey: "team-key", BestEffort: bestEffort},
+		},
+		OnSendFailure: onFailure,
+	})
+}
+
+func TestClient_FanOutRequiredDestinationFails(t *testing.T) {
+	primary, mirror := newMirrorServer(), newMirrorServer()
+	defer primary.Close()
+	defer mirror.Close()
+	mirror.down.Store(true)
+
+	var buffered []*types.AgentEvent
+	client := fanOutClient(primary, mirror, false, func(events []*types.AgentEvent, err error) {
+		buffered = append(buffered, events...)
+	})
+
+	for _, event := range rateTestEvents(5) {
+		if err := client.SendEvent(event); err != nil {
+			t.Fatalf("SendEvent: %v", err)
+		}
+	}
+
+	err := client.FlushBatch()
+	var destErr *DestinationError
+	if !errors.As(err, &destErr) {
+		t.Fatalf("Expected a DestinationError, got %v", err)
+	}
+	if _, ok := destErr.Failed["team"]; !ok || len(destErr.Failed) != 1 {
+		t.Errorf("Expected only the team destination to fail, got %v", destErr)
+	}
+	if len(buffered) != 5 {
+		t.Fatalf("Expected the batch to be buffered, got %d events", len(buffered))
+	}
+	if n := primary.received.Load(); n != 5 {
+		t.Errorf("Expected the primary backend to get 5 events, got %d", n)
+	}
+
+	// The retry from the buffer only goes where the batch didn't arrive
+	mirror.down.Store(false)
+	result, err := client.SendBatch(buffered)
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if len(result.Accepted) != 5 {
+		t.Errorf("Expected all 5 events to be accepted, got %d", len(result.Accepted))
+	}
+	if n := primary.received.Load(); n != 5 {
+		t.Errorf("Expected no events to be sent to the primary backend twice, got %d in total", n)
+	}
+	if n := mirror.received.Load(); n != 5 {
+		t.Errorf("Expected the team backend to get 5 events, got %d", n)
+	}
+}
+
+func TestClient_FanOutBestEffortDestinationFails(t *testing.T) {
+	primary, mirror := newMirrorServer(), newMirrorServer()
+	defer primary.Close()
+	defer mirror.Close()
+	mirror.down.Store(true)
+
+	var buffered int
+	client := fanOutClient(primary, mirror, true, func(events []*types.AgentEvent, err error) {
+		buffered += len(events)
+	})
+
+	for _, event := range rateTestEvents(5) {
+		if err := client.SendEvent(event); err != nil {
+			t.Fatalf("SendEvent: %v", err)
+		}
+	}
+	if err := client.FlushBatch(); err != nil {
+		t.Errorf("Expected a best-effort failure not to fail the batch, got %v", err)
+	}
+	if buffered != 0 {
+		t.Errorf("Expected nothing to be buffered, got %d events", buffered)
+	}
+
+	result, err := client.SendBatch(rateTestEvents(3))
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if len(result.Accepted) != 3 {
+		t.Errorf("Expected all 3 events to be accepted, got %d", len(result.Accepted))
+	}
+	if n := primary.received.Load(); n != 8 {
+		t.Errorf("Expected the primary backend to get 8 events, got %d", n)
+	}
+	if n := mirror.received.Load(); n != 0 {
+		t.Errorf("Expected the failing backend to get nothing, got %d", n)
+	}
+}
+
+func TestClient_FanOutCircuitOpen(t *testing.T) {
+	primary, mirror := newMirrorServer(), newMirrorServer()
+	defer primary.Close()
+	defer mirror.Close()
+
+	client := fanOutClient(primary, mirror, false, nil)
+	if client.CircuitOpen() {
+		t.Fatal("Expected the breakers to start closed")
+	}
+
+	// A required destination that is down holds back the whole client
+	for i := 0; i < 5; i++ {
+		client.targets[1].client.breaker.RecordFailure()
+	}
+	if !client.CircuitOpen() {
+		t.Error("Expected the circuit to be open while a required destination is down")
+	}
+	client.targets[1].required = false
+	if client.CircuitOpen() {
+		t.Error("Expected a best-effort destination not to hold back sends")
+	}
+}
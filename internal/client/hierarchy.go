@@ -2,10 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/codervisor/devlog/pkg/models"
 )
@@ -17,33 +20,9 @@ func (c *Client) UpsertMachine(machine *models.Machine) (*models.Machine, error)
 		return nil, fmt.Errorf("failed to marshal machine: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/machines", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
-	}
-
 	var result models.Machine
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.postWithRetry(c.ctx, "register machine", "/api/machines", body, &result); err != nil {
+		return nil, err
 	}
 
 	c.log.WithFields(map[string]interface{}{
@@ -57,7 +36,9 @@ func (c *Client) UpsertMachine(machine *models.Machine) (*models.Machine, error)
 // GetMachine retrieves machine information by machine ID
 func (c *Client) GetMachine(machineID string) (*models.Machine, error) {
 	url := fmt.Sprintf("%s/api/machines/%s", c.baseURL, machineID)
-	req, err := http.NewRequestWithContext(c.ctx, "GET", url, nil)
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.fallback)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -80,7 +61,7 @@ func (c *Client) GetMachine(machineID string) (*models.Machine, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp, respBody)
 	}
 
 	var machine models.Machine
@@ -98,33 +79,9 @@ func (c *Client) UpsertWorkspace(workspace *models.Workspace) (*models.Workspace
 		return nil, fmt.Errorf("failed to marshal workspace: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/workspaces", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
-	}
-
 	var result models.Workspace
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.postWithRetry(c.ctx, "register workspace", "/api/workspaces", body, &result); err != nil {
+		return nil, err
 	}
 
 	c.log.WithFields(map[string]interface{}{
@@ -136,10 +93,14 @@ func (c *Client) UpsertWorkspace(workspace *models.Workspace) (*models.Workspace
 	return &result, nil
 }
 
-// GetWorkspace retrieves workspace information by workspace ID
-func (c *Client) GetWorkspace(workspaceID string) (*models.Workspace, error) {
-	url := fmt.Sprintf("%s/api/workspaces/%s", c.baseURL, workspaceID)
-	req, err := http.NewRequestWithContext(c.ctx, "GET", url, nil)
+// GetWorkspace retrieves workspace information by workspace ID. The request
+// is abandoned when either ctx or the client is done.
+func (c *Client) GetWorkspace(ctx context.Context, workspaceID string) (*models.Workspace, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/api/workspaces/%s", c.baseURL, url.PathEscape(workspaceID))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -162,7 +123,7 @@ func (c *Client) GetWorkspace(workspaceID string) (*models.Workspace, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp, respBody)
 	}
 
 	var workspace models.Workspace
@@ -176,7 +137,9 @@ func (c *Client) GetWorkspace(workspaceID string) (*models.Workspace, error) {
 // ListWorkspaces retrieves all workspaces
 func (c *Client) ListWorkspaces() ([]*models.Workspace, error) {
 	url := fmt.Sprintf("%s/api/workspaces", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "GET", url, nil)
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeouts.fallback)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -195,7 +158,7 @@ func (c *Client) ListWorkspaces() ([]*models.Workspace, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp, respBody)
 	}
 
 	var workspaces []*models.Workspace
@@ -206,8 +169,9 @@ func (c *Client) ListWorkspaces() ([]*models.Workspace, error) {
 	return workspaces, nil
 }
 
-// ResolveProject resolves or creates a project from a Git remote URL
-func (c *Client) ResolveProject(gitRemoteURL string) (*models.Project, error) {
+// ResolveProject resolves or creates a project from a Git remote URL. The
+// request is abandoned when either ctx or the client is done.
+func (c *Client) ResolveProject(ctx context.Context, gitRemoteURL string) (*models.Project, error) {
 	body, err := json.Marshal(map[string]interface{}{
 		"repoUrl": gitRemoteURL,
 	})
@@ -215,10 +179,70 @@ func (c *Client) ResolveProject(gitRemoteURL string) (*models.Project, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/projects/resolve", c.baseURL)
-	req, err := http.NewRequestWithContext(c.ctx, "POST", url, bytes.NewReader(body))
+	var project models.Project
+	if err := c.postWithRetry(ctx, "resolve project", "/api/projects/resolve", body, &project); err != nil {
+		return nil, err
+	}
+
+	c.log.WithFields(map[string]interface{}{
+		"projectId": project.ID,
+		"fullName":  project.FullName,
+	}).Info("Project resolved successfully")
+
+	return &project, nil
+}
+
+// requestContext derives a request context from ctx that is also canceled
+// when the client is stopped or the client's Timeout passes, so callers'
+// deadlines, Stop and the timeout all apply.
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.fallback)
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// postWithRetry POSTs body to path and decodes the response into out,
+// retrying failures with the same jittered exponential backoff as event
+// batches. Client errors other than 429 won't succeed on a retry and are
+// returned at once, as is the last error when ctx or the client is done.
+func (c *Client) postWithRetry(ctx context.Context, what, path string, body []byte, out interface{}) error {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(retryBackoff(attempt, retryBaseDelay, c.maxBackoff)):
+			case <-ctx.Done():
+				return lastErr
+			case <-c.ctx.Done():
+				return lastErr
+			}
+		}
+
+		retryable, err := c.postJSON(ctx, path, body, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable || ctx.Err() != nil || c.ctx.Err() != nil {
+			return err
+		}
+		c.log.Warnf("Failed to %s (attempt %d/%d): %v", what, attempt+1, c.maxRetries+1, err)
+	}
+	return fmt.Errorf("failed after %d attempts: %w", c.maxRetries+1, lastErr)
+}
+
+// postJSON makes one POST of body to path and decodes the response into out.
+// It reports whether a failure is worth retrying.
+func (c *Client) postJSON(ctx context.Context, path string, body []byte, out interface{}) (bool, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -226,28 +250,22 @@ func (c *Client) ResolveProject(gitRemoteURL string) (*models.Project, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return true, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return true, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, statusError(resp, respBody)
 	}
 
-	var project models.Project
-	if err := json.Unmarshal(respBody, &project); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
-
-	c.log.WithFields(map[string]interface{}{
-		"projectId": project.ID,
-		"fullName":  project.FullName,
-	}).Info("Project resolved successfully")
-
-	return &project, nil
+	return false, nil
 }
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/models"
+)
+
+func slowServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Drain the body so the server notices when the client goes away
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetWorkspace_HonorsDeadline(t *testing.T) {
+	server := slowServer(t)
+	client := NewClient(Config{BaseURL: server.URL})
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetWorkspace(ctx, "ws-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("GetWorkspace took %s after its deadline", elapsed)
+	}
+}
+
+func TestGetWorkspace_EscapesWorkspaceID(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": 3, "workspaceId": "/home/dev/my repo"}`)
+	}))
+	defer server.Close()
+	client := NewClient(Config{BaseURL: server.URL})
+	defer client.Stop()
+
+	if _, err := client.GetWorkspace(context.Background(), "/home/dev/my repo"); err != nil {
+		t.Fatalf("GetWorkspace failed: %v", err)
+	}
+	if want := "/api/workspaces/%2Fhome%2Fdev%2Fmy%20repo"; path != want {
+		t.Errorf("expected request path %q, got %q", want, path)
+	}
+}
+
+func TestResolveProject_CanceledByStop(t *testing.T) {
+	server := slowServer(t)
+	client := NewClient(Config{BaseURL: server.URL})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ResolveProject(context.Background(), "https://github.com/owner/repo")
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	client.Stop()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected cancellation, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ResolveProject didn't return after Stop")
+	}
+}
+
+// flakyServer fails the first failures requests with status, then answers
+// with reply. It returns the server and its request count.
+func flakyServer(t *testing.T, failures int, status int, reply string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if int(requests.Add(1)) <= failures {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestResolveProject_RetriesTransientFailure(t *testing.T) {
+	server, requests := flakyServer(t, 1, http.StatusBadGateway, `{"id": 7, "fullName": "owner/repo"}`)
+	client := NewClient(Config{BaseURL: server.URL, MaxBackoff: 10 * time.Millisecond})
+	defer client.Stop()
+
+	project, err := client.ResolveProject(context.Background(), "https://github.com/owner/repo")
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if project.ID != 7 {
+		t.Errorf("unexpected project: %+v", project)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestUpsertWorkspace_RetriesTransientFailure(t *testing.T) {
+	server, requests := flakyServer(t, 1, http.StatusServiceUnavailable, `{"id": 3, "workspaceId": "ws-1", "projectId": 7}`)
+	client := NewClient(Config{BaseURL: server.URL, MaxBackoff: 10 * time.Millisecond})
+	defer client.Stop()
+
+	workspace, err := client.UpsertWorkspace(&models.Workspace{WorkspaceID: "ws-1", ProjectID: 7})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if workspace.ID != 3 {
+		t.Errorf("unexpected workspace: %+v", workspace)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestUpsertWorkspace_DoesNotRetryClientErrors(t *testing.T) {
+	server, requests := flakyServer(t, 1, http.StatusBadRequest, `{"id": 3}`)
+	client := NewClient(Config{BaseURL: server.URL, MaxBackoff: 10 * time.Millisecond})
+	defer client.Stop()
+
+	if _, err := client.UpsertWorkspace(&models.Workspace{WorkspaceID: "ws-1"}); err == nil {
+		t.Fatal("expected the bad request to fail")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected a single request, got %d", requests.Load())
+	}
+}
+
+func TestResolveProject_RetryHonorsCancellation(t *testing.T) {
+	server, _ := flakyServer(t, 1000, http.StatusInternalServerError, `{}`)
+	client := NewClient(Config{BaseURL: server.URL, MaxRetries: 100, MaxBackoff: time.Minute})
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.ResolveProject(ctx, "https://github.com/owner/repo"); err == nil {
+		t.Fatal("expected the failing backend to return an error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("ResolveProject kept retrying for %s after its context ended", elapsed)
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// truncatedMarker ends a string field shortened to fit MaxEventBytes
+const truncatedMarker = "...[truncated by devlog-collector]"
+
+// truncatableFields are the event data fields that may be shortened when an
+// event exceeds MaxEventBytes; they hold pasted files and model output
+var truncatableFields = []string{"prompt", "response", "toolOutput", "toolArgs", "content"}
+
+// fitEvents returns batch with every event that exceeds eventLimit
+// shortened to fit, and the events that still don't fit, which are dropped
+// rather than left to fail the whole batch on every retry
+func (c *Client) fitEvents(batch []*types.AgentEvent) ([]*types.AgentEvent, []RejectedEvent) {
+	if c.eventLimit <= 0 {
+		return batch, nil
+	}
+
+	fit := make([]*types.AgentEvent, 0, len(batch))
+	var dropped []RejectedEvent
+	for _, event := range batch {
+		shrunk, err := shrinkEvent(event, c.eventLimit)
+		if err != nil {
+			c.log.Warnf("Dropping event %s: %v", eventID(event), err)
+			dropped = append(dropped, RejectedEvent{ID: eventID(event), Reason: err.Error()})
+			continue
+		}
+		if shrunk != event {
+			c.log.Infof("Truncated event %s to fit the %d byte event limit", eventID(event), c.eventLimit)
+		}
+		fit = append(fit, shrunk)
+	}
+	return fit, dropped
+}
+
+// shrinkEvent returns event unchanged when its JSON fits in maxBytes, or a
+// copy whose largest truncatable fields are cut, longest first, until it
+// fits. Data["truncated"] lists the fields that were cut.
+func shrinkEvent(event *types.AgentEvent, maxBytes int) (*types.AgentEvent, error) {
+	size, err := eventSize(event)
+	if err != nil {
+		return nil, err
+	}
+	if size <= maxBytes {
+		return event, nil
+	}
+
+	shrunk := *event
+	shrunk.Data = make(map[string]interface{}, len(event.Data)+1)
+	for k, v := range event.Data {
+		shrunk.Data[k] = v
+	}
+
+	fields := make([]string, 0, len(truncatableFields))
+	for _, field := range truncatableFields {
+		if s, ok := shrunk.Data[field].(string); ok && len(s) > len(truncatedMarker) {
+			fields = append(fields, field)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return len(shrunk.Data[fields[i]].(string)) > len(shrunk.Data[fields[j]].(string))
+	})
+
+	var truncated []string
+	for _, field := range fields {
+		// Account for the list of truncated fields added below
+		excess := size - maxBytes + len(field) + len(`,"truncated":[""]`)
+		value := shrunk.Data[field].(string)
+		shrunk.Data[field] = cutString(value, len(value)-excess-len(truncatedMarker)) + truncatedMarker
+		truncated = append(truncated, field)
+		shrunk.Data["truncated"] = truncated
+
+		if size, err = eventSize(&shrunk); err != nil {
+			return nil, err
+		}
+		if size <= maxBytes {
+			return &shrunk, nil
+		}
+	}
+
+	if len(truncated) == 0 {
+		return nil, fmt.Errorf("event is %d bytes, over the %d byte limit, and has no fields to truncate", size, maxBytes)
+	}
+	return nil, fmt.Errorf("event is %d bytes, over the %d byte limit, even after truncating %s", size, maxBytes, strings.Join(truncated, ", "))
+}
+
+// eventSize returns the size of the event's JSON encoding
+func eventSize(event *types.AgentEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return len(body), nil
+}
+
+// cutString returns at most n bytes of s without splitting a UTF-8 sequence
+func cutString(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func sizedEvent(id string, data map[string]interface{}) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:        id,
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "github-copilot",
+		SessionID: "session-1",
+		ProjectID: 1,
+		Data:      data,
+	}
+}
+
+func TestClient_OversizedEventDoesNotPoisonBatch(t *testing.T) {
+	const limit = 4096
+
+	var mu sync.Mutex
+	var received []*types.AgentEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		json.NewDecoder(r.Body).Decode(&events)
+		mu.Lock()
+		received = append(received, events...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:       server.URL,
+		APIKey:        "test-key",
+		MaxRetries:    1,
+		MaxEventBytes: limit,
+	})
+
+	pasted := strings.Repeat("func main() {}\n", 2000)
+	huge := sizedEvent("huge", map[string]interface{}{"prompt": pasted, "response": "ok"})
+	hopeless := sizedEvent("hopeless", map[string]interface{}{"attachment": pasted})
+	small := sizedEvent("small", map[string]interface{}{"prompt": "hi"})
+
+	result, err := client.SendBatch([]*types.AgentEvent{huge, hopeless, small})
+	if err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+
+	if len(received) != 2 || received[0].ID != "huge" || received[1].ID != "small" {
+		t.Fatalf("expected the truncated and small events to be sent, got %d events", len(received))
+	}
+
+	body, _ := json.Marshal(received[0])
+	if len(body) > limit {
+		t.Errorf("truncated event is %d bytes, over the %d byte limit", len(body), limit)
+	}
+	prompt, _ := received[0].Data["prompt"].(string)
+	if !strings.HasSuffix(prompt, truncatedMarker) {
+		t.Errorf("expected truncated prompt to end with the marker")
+	}
+	if received[0].Data["response"] != "ok" {
+		t.Errorf("expected short response to be kept, got %v", received[0].Data["response"])
+	}
+	if huge.Data["prompt"] != pasted {
+		t.Errorf("expected the caller's event to be left unchanged")
+	}
+
+	if len(result.Invalid) != 1 || result.Invalid[0].ID != "hopeless" {
+		t.Fatalf("expected the untruncatable event to be dropped, got %+v", result.Invalid)
+	}
+	if !strings.Contains(result.Invalid[0].Reason, "byte limit") {
+		t.Errorf("expected a size reason, got %q", result.Invalid[0].Reason)
+	}
+}
+
+func TestClient_OversizedEventsOnlyAreNotSent(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:       server.URL,
+		APIKey:        "test-key",
+		MaxRetries:    1,
+		MaxEventBytes: 1024,
+	})
+
+	event := sizedEvent("hopeless", map[string]interface{}{"attachment": strings.Repeat("x", 4096)})
+	result, err := client.SendBatch([]*types.AgentEvent{event})
+	if err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no request for a batch of oversized events, got %d", requests)
+	}
+	if len(result.Invalid) != 1 {
+		t.Errorf("expected the event to be dropped, got %+v", result.Invalid)
+	}
+}
+
+func TestShrinkEvent(t *testing.T) {
+	small := sizedEvent("small", map[string]interface{}{"prompt": "hi"})
+	if got, err := shrinkEvent(small, 4096); err != nil || got != small {
+		t.Errorf("expected an event under the limit to be returned as is, got %v, %v", got, err)
+	}
+
+	// Multi-byte runes must not be split
+	event := sizedEvent("runes", map[string]interface{}{"response": strings.Repeat("日本語", 1000)})
+	got, err := shrinkEvent(event, 2048)
+	if err != nil {
+		t.Fatalf("shrinkEvent failed: %v", err)
+	}
+	response := got.Data["response"].(string)
+	if !strings.HasPrefix(response, "日本語") || !strings.HasSuffix(response, truncatedMarker) {
+		t.Errorf("unexpected truncated response %q", response)
+	}
+	if !json.Valid([]byte(`"` + strings.TrimSuffix(response, truncatedMarker) + `"`)) {
+		t.Errorf("truncated response is not valid UTF-8")
+	}
+	if fields, _ := got.Data["truncated"].([]string); len(fields) != 1 || fields[0] != "response" {
+		t.Errorf("expected truncated fields [response], got %v", got.Data["truncated"])
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for a batch that would have had to wait too
+// long under Config.MaxEventsPerSec. Queued batches are passed to
+// OnSendFailure like any other failed batch, so the overflow is buffered
+// instead of piling up behind the limit.
+var ErrRateLimited = errors.New("event rate limit exceeded")
+
+// rateLimiter is a token bucket of events that refills at rate events per
+// second up to burst. A batch may take the bucket below zero; the deficit is
+// the time later batches wait.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	maxWait time.Duration
+	now     func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter of rate events per second, or nil when
+// rate is not positive. A batch is refused once it would wait longer than
+// maxWait or than refilling a whole burst takes, whichever is longer.
+func newRateLimiter(rate float64, burst int, maxWait time.Duration) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	refill := time.Duration(float64(burst) / rate * float64(time.Second))
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		maxWait: max(maxWait, refill),
+		now:     time.Now,
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// reserve takes n events from the bucket and returns how long the caller
+// must wait before sending them. It takes nothing and returns false when
+// the wait would exceed the limiter's maximum, unless the bucket is full:
+// a batch larger than the burst is still let through on its own.
+func (l *rateLimiter) reserve(n int) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	wait := time.Duration(0)
+	if deficit := float64(n) - l.tokens; deficit > 0 {
+		wait = time.Duration(deficit / l.rate * float64(time.Second))
+	}
+	if wait > l.maxWait && l.tokens < l.burst {
+		return 0, false
+	}
+
+	l.tokens -= float64(n)
+	return wait, true
+}
+
+// waitForRate blocks until a batch of n events may be sent under the event
+// rate limit, or returns ErrRateLimited if that would take too long
+func (c *Client) waitForRate(n int) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	wait, ok := c.limiter.reserve(n)
+	if !ok {
+		return fmt.Errorf("%w: %d events over %.0f events/s", ErrRateLimited, n, c.limiter.rate)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	c.log.Debugf("Rate limit: delaying batch of %d events by %s", n, wait)
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-c.ctx.Done():
+		return fmt.Errorf("send cancelled: %w", c.ctx.Err())
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func rateTestEvents(n int) []*types.AgentEvent {
+	events := make([]*types.AgentEvent, n)
+	for i := range events {
+		events[i] = &types.AgentEvent{
+			ID:        fmt.Sprintf("evt-%d", i),
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "github-copilot",
+			SessionID: "session-1",
+		}
+	}
+	return events
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(10, 10, 100*time.Millisecond)
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	// The burst goes out at once; the next batch waits for the refill
+	if wait, ok := limiter.reserve(10); !ok || wait != 0 {
+		t.Errorf("burst: wait %v, ok %v; want 0, true", wait, ok)
+	}
+	if wait, ok := limiter.reserve(10); !ok || wait != time.Second {
+		t.Errorf("second batch: wait %v, ok %v; want 1s, true", wait, ok)
+	}
+
+	// Another would wait 2s, longer than refilling a whole burst takes
+	if _, ok := limiter.reserve(10); ok {
+		t.Error("Expected a batch behind a full backlog to be refused")
+	}
+
+	now = now.Add(2 * time.Second)
+	if wait, ok := limiter.reserve(10); !ok || wait != 0 {
+		t.Errorf("after refill: wait %v, ok %v; want 0, true", wait, ok)
+	}
+
+	// A batch larger than the burst still goes through on a full bucket
+	now = now.Add(time.Hour)
+	if wait, ok := limiter.reserve(25); !ok || wait != 1500*time.Millisecond {
+		t.Errorf("oversized batch: wait %v, ok %v; want 1.5s, true", wait, ok)
+	}
+}
+
+func TestNewRateLimiter_Disabled(t *testing.T) {
+	if newRateLimiter(0, 100, time.Second) != nil {
+		t.Error("Expected no limiter without a rate")
+	}
+}
+
+func TestClient_MaxEventsPerSec(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == DefaultIngestPath {
+			var events []json.RawMessage
+			json.NewDecoder(r.Body).Decode(&events)
+			received.Add(int64(len(events)))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	const rate, batchSize, batches = 500.0, 10, 40
+	client := NewClient(Config{
+		BaseURL:         server.URL,
+		BatchSize:       batchSize,
+		MaxEventsPerSec: rate,
+		ManualFlush:     true,
+	})
+
+	start := time.Now()
+	for i := 0; i < batches; i++ {
+		if _, err := client.SendBatch(rateTestEvents(batchSize)); err != nil {
+			t.Fatalf("batch %d: %v", i, err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	sent := received.Load()
+	if sent != batches*batchSize {
+		t.Fatalf("Expected %d events to arrive, got %d", batches*batchSize, sent)
+	}
+	// At most a burst plus the sustained rate over the elapsed time
+	if ceiling := batchSize + rate*elapsed.Seconds(); float64(sent) > ceiling {
+		t.Errorf("Sent %d events in %v, above the ceiling of %.0f", sent, elapsed, ceiling)
+	}
+}
+
+func TestClient_RateLimitOverflowIsBuffered(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var buffered []*types.AgentEvent
+	var bufferErr error
+	client := NewClient(Config{
+		BaseURL:         server.URL,
+		BatchSize:       10,
+		BatchDelay:      100 * time.Millisecond,
+		MaxEventsPerSec: 10,
+		ManualFlush:     true,
+		OnSendFailure: func(events []*types.AgentEvent, err error) {
+			buffered = append(buffered, events...)
+			bufferErr = err
+		},
+	})
+
+	// Two seconds of backlog already reserved
+	client.limiter.reserve(30)
+
+	for _, event := range rateTestEvents(10) {
+		if err := client.SendEvent(event); err != nil {
+			t.Fatalf("SendEvent: %v", err)
+		}
+	}
+
+	start := time.Now()
+	if err := client.FlushBatch(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the overflow to be handed off without waiting, took %v", elapsed)
+	}
+	if len(buffered) != 10 || !errors.Is(bufferErr, ErrRateLimited) {
+		t.Errorf("Expected the batch to go to OnSendFailure, got %d events (%v)", len(buffered), bufferErr)
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// RejectedEvent identifies an event the backend refused to ingest
+type RejectedEvent struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// BatchResult reports which events of a batch the backend ingested. A batch
+// can be accepted as a whole while individual malformed events are rejected.
+type BatchResult struct {
+	Accepted []string        `json:"accepted"`
+	Rejected []RejectedEvent `json:"rejected"`
+
+	// Invalid lists events dropped by strict pre-send validation or for
+	// exceeding MaxEventBytes; they were never sent and should not be retried
+	Invalid []RejectedEvent `json:"-"`
+}
+
+// parseBatchResult interprets the ingest response body for batch. Responses
+// that don't list accepted or rejected events (older backends) accept the
+// whole batch; when only rejections are listed, every other event is accepted.
+func parseBatchResult(batch []*types.AgentEvent, body []byte) *BatchResult {
+	var raw struct {
+		Accepted *[]string       `json:"accepted"`
+		Rejected []RejectedEvent `json:"rejected"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		// Not a structured result; treat the whole batch as accepted
+		raw.Accepted, raw.Rejected = nil, nil
+	}
+
+	result := &BatchResult{Rejected: raw.Rejected}
+	if raw.Accepted != nil {
+		result.Accepted = *raw.Accepted
+		return result
+	}
+
+	rejected := make(map[string]bool, len(raw.Rejected))
+	for _, r := range raw.Rejected {
+		rejected[r.ID] = true
+	}
+	for _, event := range batch {
+		if !rejected[event.ID] {
+			result.Accepted = append(result.Accepted, event.ID)
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// throttledError is returned for 429 and 503 responses. retryAfter is the
+// delay requested by the backend's Retry-After header, or zero without one.
+type throttledError struct {
+	status     int
+	retryAfter time.Duration
+	err        error
+}
+
+func (e *throttledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *throttledError) Unwrap() error {
+	return e.err
+}
+
+// rateLimited reports whether the backend rejected the request for sending
+// too much (429) rather than being unavailable
+func (e *throttledError) rateLimited() bool {
+	return e.status == http.StatusTooManyRequests
+}
+
+// responseError builds the error for a non-2xx ingest response, keeping
+// the Retry-After delay of throttling responses
+func responseError(resp *http.Response, body []byte) error {
+	err := statusError(resp, body)
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return err
+	}
+
+	delay, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return &throttledError{status: resp.StatusCode, retryAfter: delay, err: err}
+}
+
+// parseRetryAfter reads a Retry-After header given as delay-seconds or an
+// HTTP-date. A date in the past yields zero.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
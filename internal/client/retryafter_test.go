@@ -0,0 +1,136 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// throttlingServer answers the first throttled requests with status and
+// Retry-After, then accepts batches
+func throttlingServer(t *testing.T, status int, retryAfter string, throttled int32) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/capabilities" {
+			http.NotFound(w, r)
+			return
+		}
+		if atomic.AddInt32(&requests, 1) <= throttled {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testBatch() []*types.AgentEvent {
+	return []*types.AgentEvent{{
+		ID:        "evt-1",
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "test-agent",
+		SessionID: "test-session",
+		Data:      map[string]interface{}{"prompt": "hi"},
+	}}
+}
+
+func TestSendBatchWithRetry_HonorsRetryAfter(t *testing.T) {
+	server, requests := throttlingServer(t, http.StatusTooManyRequests, "1", 1)
+	client := NewClient(Config{BaseURL: server.URL, MaxRetries: 3})
+	defer client.Stop()
+
+	start := time.Now()
+	if _, err := client.sendBatchWithRetry(testBatch()); err != nil {
+		t.Fatalf("expected batch to be sent after waiting, got %v", err)
+	}
+
+	// The jittered backoff before the first retry is at most 1s, so only
+	// Retry-After explains a wait of a full second
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After, retried after %v", elapsed)
+	}
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestSendBatchWithRetry_RetryAfterCappedByMaxBackoff(t *testing.T) {
+	server, _ := throttlingServer(t, http.StatusServiceUnavailable, "3600", 1)
+	client := NewClient(Config{BaseURL: server.URL, MaxRetries: 3, MaxBackoff: 100 * time.Millisecond})
+	defer client.Stop()
+
+	start := time.Now()
+	if _, err := client.sendBatchWithRetry(testBatch()); err != nil {
+		t.Fatalf("expected batch to be sent, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Retry-After to be capped at maxBackoff, waited %v", elapsed)
+	}
+}
+
+func TestSendBatchWithRetry_RateLimitDoesNotOpenBreaker(t *testing.T) {
+	server, _ := throttlingServer(t, http.StatusTooManyRequests, "0", 1000)
+	client := NewClient(Config{
+		BaseURL:          server.URL,
+		MaxRetries:       1,
+		BreakerThreshold: 1,
+	})
+	defer client.Stop()
+
+	if _, err := client.sendBatchWithRetry(testBatch()); err == nil {
+		t.Fatal("expected an error while rate limited")
+	}
+	if client.CircuitOpen() {
+		t.Error("a rate-limiting backend should not open the breaker")
+	}
+}
+
+func TestSendBatchWithRetry_UnavailableOpensBreaker(t *testing.T) {
+	server, _ := throttlingServer(t, http.StatusServiceUnavailable, "0", 1000)
+	client := NewClient(Config{
+		BaseURL:          server.URL,
+		MaxRetries:       1,
+		BreakerThreshold: 1,
+	})
+	defer client.Stop()
+
+	if _, err := client.sendBatchWithRetry(testBatch()); err == nil {
+		t.Fatal("expected an error while unavailable")
+	}
+	if !client.CircuitOpen() {
+		t.Error("expected repeated 503s to open the breaker")
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BatchSequenceHeader carries the collector's batch sequence number so the
+// backend can detect dropped or reordered batches
+const BatchSequenceHeader = "X-Devlog-Batch-Sequence"
+
+// BatchStreamHeader names the sequence a batch is numbered in. Processes
+// sending from the same machine at once, such as the collector and a
+// backfill, each number their batches in a stream of their own.
+const BatchStreamHeader = "X-Devlog-Batch-Stream"
+
+// sequenceCounter hands out monotonically increasing batch sequence numbers
+// for a stream. The last issued number is persisted to path (when set) so
+// numbering continues across restarts. Only one process may use a path at
+// a time: the file is read once, when the counter is created.
+type sequenceCounter struct {
+	mu     sync.Mutex
+	path   string
+	stream string
+	last   uint64
+}
+
+// newSequenceCounter loads the last issued sequence number of stream from path
+func newSequenceCounter(path, stream string) (*sequenceCounter, error) {
+	counter := &sequenceCounter{path: path, stream: stream}
+	if path == "" {
+		return counter, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return counter, nil
+	}
+	if err != nil {
+		return counter, fmt.Errorf("failed to read sequence file: %w", err)
+	}
+
+	last, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return counter, fmt.Errorf("invalid sequence file %s: %w", path, err)
+	}
+	counter.last = last
+
+	return counter, nil
+}
+
+// Next reserves and persists the next sequence number. The number is
+// persisted before it is used so a crash can leave a gap but never a reuse.
+func (s *sequenceCounter) Next() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.last++
+	if s.path == "" {
+		return s.last, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return s.last, fmt.Errorf("failed to create sequence directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatUint(s.last, 10)), 0644); err != nil {
+		return s.last, fmt.Errorf("failed to write sequence file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return s.last, fmt.Errorf("failed to replace sequence file: %w", err)
+	}
+
+	return s.last, nil
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// writeServerCA writes the TLS test server's certificate as a PEM file
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	return path
+}
+
+func newHealthyTLSServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestClient_CustomCA(t *testing.T) {
+	server := newHealthyTLSServer()
+	defer server.Close()
+
+	// The self-signed test certificate isn't trusted by default
+	plain := NewClient(Config{BaseURL: server.URL})
+	if err := plain.HealthCheck(); err == nil {
+		t.Fatal("expected certificate verification to fail without the custom CA")
+	}
+
+	client := NewClient(Config{BaseURL: server.URL, CACertPath: writeServerCA(t, server)})
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("expected health check to succeed with the custom CA, got %v", err)
+	}
+}
+
+func TestClient_InsecureSkipVerify(t *testing.T) {
+	server := newHealthyTLSServer()
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, InsecureSkipVerify: true})
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("expected health check to succeed without verification, got %v", err)
+	}
+}
+
+func TestClient_Proxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL
+		proxiedHost = r.URL.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client := NewClient(Config{BaseURL: "http://devlog.invalid", Proxy: proxy.URL})
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("expected request through the proxy to succeed, got %v", err)
+	}
+	if proxiedHost != "devlog.invalid" {
+		t.Errorf("expected proxy to receive a request for devlog.invalid, got %q", proxiedHost)
+	}
+}
+
+func TestNewTransport_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"invalid proxy", Config{Proxy: "://nope"}},
+		{"missing CA file", Config{CACertPath: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"CA file without certificates", Config{CACertPath: writeFile(t, "not a certificate")}},
+		{"client certificate without key", Config{ClientCertPath: writeFile(t, "cert")}},
+		{"client key without certificate", Config{ClientKeyPath: writeFile(t, "key")}},
+		{"invalid client certificate", Config{ClientCertPath: writeFile(t, "cert"), ClientKeyPath: writeFile(t, "key")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTransport(tt.config); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	return path
+}
+
+func TestNewTransport_KeepAlive(t *testing.T) {
+	transport, err := newTransport(Config{
+		IdleConnTimeout:     15 * time.Second,
+		MaxIdleConnsPerHost: 8,
+		DisableKeepAlives:   true,
+	})
+	if err != nil {
+		t.Fatalf("newTransport failed: %v", err)
+	}
+	if transport.IdleConnTimeout != 15*time.Second {
+		t.Errorf("IdleConnTimeout = %v, want 15s", transport.IdleConnTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != 8 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 8", transport.MaxIdleConnsPerHost)
+	}
+	if !transport.DisableKeepAlives {
+		t.Error("Expected keep-alives to be disabled")
+	}
+
+	defaults, err := newTransport(Config{})
+	if err != nil {
+		t.Fatalf("newTransport failed: %v", err)
+	}
+	if defaults.IdleConnTimeout != http.DefaultTransport.(*http.Transport).IdleConnTimeout {
+		t.Errorf("Expected the default idle timeout, got %v", defaults.IdleConnTimeout)
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key as PEM
+// files and returns their paths with the parsed certificate
+func writeClientCert(t *testing.T) (certPath, keyPath string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "collector"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath = filepath.Join(dir, "client.pem")
+	keyPath = filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certPath, keyPath, cert
+}
+
+func TestClient_ClientCertificate(t *testing.T) {
+	certPath, keyPath, cert := writeClientCert(t)
+
+	var gotSubject, gotAuth string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSubject = r.TLS.PeerCertificates[0].Subject.CommonName
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caPath := writeServerCA(t, server)
+	event := &types.AgentEvent{ID: "evt-1", Type: types.EventTypeLLMRequest, Timestamp: time.Now()}
+
+	// Without a certificate the handshake is refused
+	plain := NewClient(Config{BaseURL: server.URL, APIKey: "secret", CACertPath: caPath})
+	if err := plain.SendSingleEvent(event); err == nil {
+		t.Fatal("expected the request to fail without a client certificate")
+	}
+
+	client := NewClient(Config{
+		BaseURL:        server.URL,
+		APIKey:         "secret",
+		CACertPath:     caPath,
+		ClientCertPath: certPath,
+		ClientKeyPath:  keyPath,
+	})
+	if err := client.SendSingleEvent(event); err != nil {
+		t.Fatalf("expected the request to succeed with the client certificate, got %v", err)
+	}
+	if gotSubject != "collector" {
+		t.Errorf("expected the server to verify the collector certificate, got subject %q", gotSubject)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("expected the API key alongside the certificate, got Authorization %q", gotAuth)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// ValidationMode controls how strictly events are checked before sending
+type ValidationMode string
+
+const (
+	ValidationOff    ValidationMode = "off"    // Events are sent unchecked
+	ValidationWarn   ValidationMode = "warn"   // Invalid events are counted and logged but still sent
+	ValidationStrict ValidationMode = "strict" // Invalid events are counted, dead-lettered and dropped
+)
+
+// validateEvent checks the fields the backend requires. An empty
+// knownAgents set accepts any agent.
+func validateEvent(event *types.AgentEvent, knownAgents map[string]bool) error {
+	switch {
+	case event == nil:
+		return fmt.Errorf("event is nil")
+	case event.ID == "":
+		return fmt.Errorf("missing id")
+	case event.SessionID == "":
+		return fmt.Errorf("missing sessionId")
+	case event.AgentID == "":
+		return fmt.Errorf("missing agentId")
+	case event.Timestamp.IsZero():
+		return fmt.Errorf("missing timestamp")
+	case !types.IsKnownEventType(event.Type):
+		return fmt.Errorf("unknown event type %q", event.Type)
+	case len(knownAgents) > 0 && !knownAgents[event.AgentID]:
+		return fmt.Errorf("unknown agent %q", event.AgentID)
+	}
+	return nil
+}
+
+// deadLetterEntry is one line of the dead-letter file
+type deadLetterEntry struct {
+	Reason     string            `json:"reason"`
+	RejectedAt time.Time         `json:"rejectedAt"`
+	Event      *types.AgentEvent `json:"event"`
+}
+
+// validator applies the configured validation mode and keeps a count of
+// invalid events
+type validator struct {
+	mode           ValidationMode
+	knownAgents    map[string]bool
+	deadLetterPath string
+
+	mu      sync.Mutex
+	invalid int
+}
+
+func newValidator(mode ValidationMode, knownAgents []string, deadLetterPath string) *validator {
+	if mode == "" {
+		mode = ValidationOff
+	}
+
+	agents := make(map[string]bool, len(knownAgents))
+	for _, name := range knownAgents {
+		agents[name] = true
+	}
+
+	return &validator{
+		mode:           mode,
+		knownAgents:    agents,
+		deadLetterPath: deadLetterPath,
+	}
+}
+
+// check validates event, returning the reason it is invalid or nil. Invalid
+// events are counted, and dead-lettered in strict mode.
+func (v *validator) check(event *types.AgentEvent) error {
+	if v.mode == ValidationOff {
+		return nil
+	}
+
+	err := validateEvent(event, v.knownAgents)
+	if err == nil {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.invalid++
+	if v.mode == ValidationStrict && v.deadLetterPath != "" {
+		if dlErr := v.deadLetter(event, err); dlErr != nil {
+			return fmt.Errorf("%v (dead-letter failed: %v)", err, dlErr)
+		}
+	}
+	return err
+}
+
+// drops reports whether invalid events are withheld from the backend
+func (v *validator) drops() bool {
+	return v.mode == ValidationStrict
+}
+
+// count returns the number of invalid events seen so far
+func (v *validator) count() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.invalid
+}
+
+// deadLetter appends event and the reason it was rejected to the dead-letter
+// file as a JSON line
+func (v *validator) deadLetter(event *types.AgentEvent, reason error) error {
+	line, err := json.Marshal(deadLetterEntry{
+		Reason:     reason.Error(),
+		RejectedAt: time.Now(),
+		Event:      event,
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(v.deadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func validationEvents() []*types.AgentEvent {
+	valid := func(id string) *types.AgentEvent {
+		return &types.AgentEvent{
+			ID:        id,
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "claude",
+			SessionID: "session-1",
+		}
+	}
+
+	noSession := valid("no-session")
+	noSession.SessionID = ""
+	badType := valid("bad-type")
+	badType.Type = "bogus"
+	unknownAgent := valid("unknown-agent")
+	unknownAgent.AgentID = "vim"
+
+	return []*types.AgentEvent{valid("ok-1"), noSession, badType, unknownAgent, valid("ok-2")}
+}
+
+func TestClient_StrictValidationDropsInvalidEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		json.NewDecoder(r.Body).Decode(&events)
+		mu.Lock()
+		for _, event := range events {
+			received = append(received, event.ID)
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	client := NewClient(Config{
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		MaxRetries:     1,
+		Validation:     ValidationStrict,
+		KnownAgents:    []string{"claude", "github-copilot"},
+		DeadLetterPath: deadLetterPath,
+	})
+
+	result, err := client.SendBatch(validationEvents())
+	if err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+
+	if len(received) != 2 || received[0] != "ok-1" || received[1] != "ok-2" {
+		t.Errorf("expected only valid events to be sent, got %v", received)
+	}
+	if len(result.Accepted) != 2 {
+		t.Errorf("expected 2 accepted events, got %d", len(result.Accepted))
+	}
+
+	invalid := map[string]bool{}
+	for _, rejected := range result.Invalid {
+		invalid[rejected.ID] = true
+	}
+	for _, id := range []string{"no-session", "bad-type", "unknown-agent"} {
+		if !invalid[id] {
+			t.Errorf("expected %s to be reported invalid", id)
+		}
+	}
+
+	if got := client.GetStats()["invalid_events"]; got != 3 {
+		t.Errorf("expected 3 invalid events counted, got %v", got)
+	}
+
+	f, err := os.Open(deadLetterPath)
+	if err != nil {
+		t.Fatalf("dead-letter file not written: %v", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry deadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid dead-letter line: %v", err)
+		}
+		if entry.Reason == "" || entry.Event == nil {
+			t.Errorf("dead-letter entry missing reason or event: %s", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 dead-letter entries, got %d", lines)
+	}
+}
+
+func TestClient_WarnValidationStillSends(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		json.NewDecoder(r.Body).Decode(&events)
+		received += len(events)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		MaxRetries:  1,
+		Validation:  ValidationWarn,
+		KnownAgents: []string{"claude"},
+	})
+
+	result, err := client.SendBatch(validationEvents())
+	if err != nil {
+		t.Fatalf("SendBatch failed: %v", err)
+	}
+
+	if received != 5 {
+		t.Errorf("expected all 5 events to be sent in warn mode, got %d", received)
+	}
+	if len(result.Invalid) != 0 {
+		t.Errorf("expected no dropped events in warn mode, got %d", len(result.Invalid))
+	}
+	if got := client.GetStats()["invalid_events"]; got != 3 {
+		t.Errorf("expected 3 invalid events counted, got %v", got)
+	}
+}
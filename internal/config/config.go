@@ -3,11 +3,16 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
 )
 
 // Config represents the collector configuration
@@ -20,6 +25,66 @@ type Config struct {
 	Buffer     BufferConfig           `json:"buffer"`
 	Agents     map[string]AgentConfig `json:"agents"`
 	Logging    LoggingConfig          `json:"logging"`
+
+	// ProjectResolutionMode decides whether projectId or the project resolved
+	// from the workspace wins: "prefer-hierarchy" (default), "prefer-config"
+	// or "hierarchy-only"
+	ProjectResolutionMode string `json:"projectResolutionMode,omitempty"`
+
+	// Proxy routes backend requests through an HTTP proxy (default: the
+	// HTTP_PROXY/HTTPS_PROXY environment variables). CACertPath adds a PEM
+	// bundle of trusted CAs for TLS-intercepting gateways.
+	// InsecureSkipVerify turns off certificate checks entirely.
+	Proxy              string `json:"proxy,omitempty"`
+	CACertPath         string `json:"caCertPath,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+
+	// ClientCertPath and ClientKeyPath are the PEM certificate and key the
+	// collector authenticates with to backends requiring mutual TLS, in
+	// addition to the API key
+	ClientCertPath string `json:"clientCertPath,omitempty"`
+	ClientKeyPath  string `json:"clientKeyPath,omitempty"`
+
+	// IngestPath, SingleEventPath and HealthPath override the paths of the
+	// backend's batch ingest, single event and health endpoints, relative to
+	// backendUrl (defaults: the path the backend reports, /api/events and
+	// /api/health)
+	IngestPath      string `json:"ingestPath,omitempty"`
+	SingleEventPath string `json:"singleEventPath,omitempty"`
+	HealthPath      string `json:"healthPath,omitempty"`
+
+	// Destinations mirrors events to further backends besides backendUrl,
+	// e.g. a team backend next to a local one
+	Destinations []DestinationConfig `json:"destinations,omitempty"`
+
+	// Sink sends events somewhere other than the backend, e.g. stdout
+	Sink SinkConfig `json:"sink"`
+
+	// StatusAddr is where a running collector serves /healthz and /status,
+	// which the status command queries; empty disables the status server
+	StatusAddr string `json:"statusAddr"`
+}
+
+// DestinationConfig is a further backend events are mirrored to. Events that
+// fail to reach it are buffered and retried, unless it is best-effort.
+type DestinationConfig struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	APIKey     string `json:"apiKey"`
+	BestEffort bool   `json:"bestEffort,omitempty"`
+}
+
+// SinkConfig selects where collected events are delivered
+type SinkConfig struct {
+	// Type is "backend" (default), "stdout" or "file". The stdout and file
+	// sinks write one JSON event per line and need no backend.
+	Type string `json:"type,omitempty"`
+	Path string `json:"path,omitempty"` // NDJSON file for the file sink
+}
+
+// Local reports whether events go to a local sink instead of the backend
+func (s SinkConfig) Local() bool {
+	return s.Type == "stdout" || s.Type == "file"
 }
 
 // CollectionConfig configures event collection behavior
@@ -28,27 +93,174 @@ type CollectionConfig struct {
 	BatchInterval string `json:"batchInterval"`
 	MaxRetries    int    `json:"maxRetries"`
 	RetryBackoff  string `json:"retryBackoff"`
+
+	// MaxBackoff caps the randomized delay between retries of a failed
+	// batch (e.g. "30s"; empty uses the client default)
+	MaxBackoff string `json:"maxBackoff,omitempty"`
+
+	// PromptSplitThreshold splits prompts larger than this many bytes into a
+	// truncated prompt event plus attachment events (0 = disabled)
+	PromptSplitThreshold int `json:"promptSplitThreshold,omitempty"`
+
+	// MaxPromptChars and MaxResponseChars truncate captured prompts and
+	// responses to that many characters (0 = unlimited)
+	MaxPromptChars   int `json:"maxPromptChars,omitempty"`
+	MaxResponseChars int `json:"maxResponseChars,omitempty"`
+
+	// SessionStartCommit attaches the commit the workspace was at when each
+	// session started to the session's events
+	SessionStartCommit bool `json:"sessionStartCommit,omitempty"`
+
+	// RepoInfo attaches repoOwner/repoName from the workspace git remote
+	RepoInfo bool `json:"repoInfo,omitempty"`
+
+	// GitHead attaches the branch and commit checked out in the workspace
+	GitHead bool `json:"gitHead,omitempty"`
+
+	// Redact masks secrets (API keys, tokens, high-entropy strings) in
+	// prompts, responses and tool arguments before events are sent. It is
+	// on by default; set it to false to send payloads unmasked.
+	// RedactPatterns adds custom regular expressions to mask.
+	Redact         bool     `json:"redact"`
+	RedactPatterns []string `json:"redactPatterns,omitempty"`
+
+	// SessionBudget flags sessions exceeding token or cost limits
+	SessionBudget SessionBudgetConfig `json:"sessionBudget"`
+
+	// SessionSummaries emits a session_summary event with per-session
+	// totals once each session ends or goes idle
+	SessionSummaries bool `json:"sessionSummaries,omitempty"`
+
+	// CanceledTurns emits an llm_canceled event for turns the user canceled
+	// instead of dropping them
+	CanceledTurns bool `json:"canceledTurns,omitempty"`
+
+	// CoalesceFileReads collapses repeated reads of the same file within a
+	// turn into a single event with a readCount
+	CoalesceFileReads bool `json:"coalesceFileReads,omitempty"`
+
+	// IncludeEventTypes, when set, keeps only events of the listed types
+	// (e.g. "llm_request"); ExcludeEventTypes drops the listed types.
+	// Filtered events are never buffered or sent.
+	IncludeEventTypes []string `json:"includeEventTypes,omitempty"`
+	ExcludeEventTypes []string `json:"excludeEventTypes,omitempty"`
+
+	// BackfillWorkers is the number of log files backfilled concurrently.
+	// The workers share one adapter per agent, so it defaults to one.
+	BackfillWorkers int `json:"backfillWorkers,omitempty"`
+
+	// FlushWorkers is the number of buffered batches sent concurrently when
+	// draining a backlog (0 or 1 = one batch at a time, in order)
+	FlushWorkers int `json:"flushWorkers,omitempty"`
+
+	// BackfillMaxAttempts and BackfillRetryWindow bound how long a backfill
+	// source that keeps erroring is retried before it is marked failed
+	BackfillMaxAttempts int    `json:"backfillMaxAttempts,omitempty"`
+	BackfillRetryWindow string `json:"backfillRetryWindow,omitempty"` // e.g. "1h"
+
+	// BackfillMaxParseErrorRate aborts backfilling a log file once more than
+	// this share of its lines (0-1) failed to parse, checked after
+	// BackfillParseErrorMinLines lines. 0 disables the check.
+	BackfillMaxParseErrorRate  float64 `json:"backfillMaxParseErrorRate"`
+	BackfillParseErrorMinLines int     `json:"backfillParseErrorMinLines,omitempty"`
+
+	// BackfillMaxFileBytes skips backfilling log files larger than this
+	// (0 = no limit). Files that look binary are always skipped.
+	BackfillMaxFileBytes int64 `json:"backfillMaxFileBytes,omitempty"`
+
+	// HierarchyCacheTTL is how long workspace hierarchy resolutions persisted
+	// in the state database are trusted before being refreshed from the
+	// backend (e.g. "24h"; empty disables the disk cache)
+	HierarchyCacheTTL string `json:"hierarchyCacheTTL,omitempty"`
+
+	// Validation checks events before sending: "off" (default), "warn" to
+	// log invalid events, or "strict" to drop them. In strict mode dropped
+	// events are appended to DeadLetterPath when set.
+	Validation     string `json:"validation,omitempty"`
+	DeadLetterPath string `json:"deadLetterPath,omitempty"`
+
+	// AuditLogPath, when set, records each batch sent as a JSON line with
+	// its destination, event count and event content hashes. It is rotated
+	// past AuditLogMaxBytes (0 = 10 MiB).
+	AuditLogPath     string `json:"auditLogPath,omitempty"`
+	AuditLogMaxBytes int64  `json:"auditLogMaxBytes,omitempty"`
+
+	// MaxEventBytes caps the JSON size of a single event sent to the
+	// backend; larger prompts and responses are truncated (0 = no limit)
+	MaxEventBytes int `json:"maxEventBytes,omitempty"`
+
+	// MaxEventsPerSec caps the sustained rate events are sent to the
+	// backend, with bursts of up to batchSize (0 = unlimited). Events over
+	// the limit are buffered and sent later.
+	MaxEventsPerSec float64 `json:"maxEventsPerSec,omitempty"`
+}
+
+// SessionBudgetConfig sets per-session limits; zero disables a limit
+type SessionBudgetConfig struct {
+	MaxTokens int     `json:"maxTokens,omitempty"`
+	MaxCost   float64 `json:"maxCost,omitempty"`
+	Alert     bool    `json:"alert,omitempty"` // Also emit a budget_exceeded event
 }
 
 // BufferConfig configures the local SQLite buffer
 type BufferConfig struct {
-	Enabled bool   `json:"enabled"`
-	MaxSize int    `json:"maxSize"`
-	DBPath  string `json:"dbPath"`
+	Enabled   bool   `json:"enabled"`
+	MaxSize   int    `json:"maxSize"`
+	DBPath    string `json:"dbPath"`
+	Retention string `json:"retention,omitempty"` // e.g. "30d"; empty disables pruning
 }
 
 // AgentConfig configures a specific agent
 type AgentConfig struct {
 	Enabled bool   `json:"enabled"`
 	LogPath string `json:"logPath"`
+
+	// Include and Exclude are glob patterns matched against paths relative
+	// to the discovered log directory. Temp files, editor backups and
+	// node_modules are always excluded.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Redact overrides collection.redact for this agent when set
+	Redact *bool `json:"redact,omitempty"`
+
+	// ProjectID pins this agent's events to a project, overriding the
+	// project resolved from the workspace hierarchy. WorkspaceID optionally
+	// pins the workspace as well.
+	ProjectID   string `json:"projectId,omitempty"`
+	WorkspaceID int    `json:"workspaceId,omitempty"`
+
+	// DebounceMs is how long the watcher waits for this agent's writes to
+	// settle before parsing, overriding the default when set
+	DebounceMs int `json:"debounceMs,omitempty"`
+
+	// Fields maps the parts of a logged call (request, response, model,
+	// usage, latency, timestamp, session, requestId) to dotted paths in the
+	// records of a gateway log. Only the proxy agent reads it.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// ProjectOverride returns the agent's pinned project ID, or 0 when unset
+func (a AgentConfig) ProjectOverride() int {
+	id, err := strconv.Atoi(a.ProjectID)
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
 }
 
 // LoggingConfig configures logging
 type LoggingConfig struct {
 	Level string `json:"level"`
 	File  string `json:"file"`
+
+	// Format is "text" (default) or "json" for log aggregation systems
+	Format string `json:"format,omitempty"`
 }
 
+// DefaultStatusAddr is the default address of the collector's status server
+const DefaultStatusAddr = "127.0.0.1:7441"
+
 // DefaultConfig returns configuration with sensible defaults
 func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
@@ -58,16 +270,27 @@ func DefaultConfig() *Config {
 		Version:    "1.0",
 		BackendURL: "http://localhost:3200",
 		ProjectID:  "default",
+		StatusAddr: DefaultStatusAddr,
 		Collection: CollectionConfig{
-			BatchSize:     100,
-			BatchInterval: "5s",
-			MaxRetries:    3,
-			RetryBackoff:  "exponential",
+			BatchSize:                  100,
+			BatchInterval:              "5s",
+			MaxRetries:                 3,
+			RetryBackoff:               "exponential",
+			BackfillWorkers:            1,
+			BackfillMaxAttempts:        3,
+			BackfillRetryWindow:        "1h",
+			BackfillMaxParseErrorRate:  0.5,
+			BackfillParseErrorMinLines: 100,
+			BackfillMaxFileBytes:       256 << 20,
+			HierarchyCacheTTL:          "24h",
+			MaxEventBytes:              1 << 20,
+			Redact:                     true,
 		},
 		Buffer: BufferConfig{
-			Enabled: true,
-			MaxSize: 10000,
-			DBPath:  filepath.Join(devlogDir, "buffer.db"),
+			Enabled:   true,
+			MaxSize:   10000,
+			DBPath:    filepath.Join(devlogDir, "buffer.db"),
+			Retention: "30d",
 		},
 		Agents: map[string]AgentConfig{
 			"copilot": {Enabled: true, LogPath: "auto"},
@@ -75,13 +298,16 @@ func DefaultConfig() *Config {
 			"cursor":  {Enabled: true, LogPath: "auto"},
 		},
 		Logging: LoggingConfig{
-			Level: "info",
-			File:  filepath.Join(devlogDir, "collector.log"),
+			Level:  "info",
+			File:   filepath.Join(devlogDir, "collector.log"),
+			Format: "text",
 		},
 	}
 }
 
-// LoadConfig loads configuration from the specified path
+// LoadConfig loads configuration from the specified path. DEVLOG_
+// environment variables override the file, which overrides the defaults.
+// Without a file or overrides the defaults are returned unvalidated.
 func LoadConfig(path string) (*Config, error) {
 	// Expand path
 	path = expandPath(path)
@@ -90,20 +316,29 @@ func LoadConfig(path string) (*Config, error) {
 	config := DefaultConfig()
 
 	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Return defaults if file doesn't exist
-		return config, nil
+	_, err := os.Stat(path)
+	exists := !os.IsNotExist(err)
+	if exists {
+		// Read file
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		// Parse JSON
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
 	}
 
-	// Read file
-	data, err := os.ReadFile(path)
+	// Apply DEVLOG_ environment overrides
+	overridden, err := applyEnvOverrides(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("invalid environment override: %w", err)
 	}
-
-	// Parse JSON
-	if err := json.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if !exists && !overridden {
+		// Return defaults if there is nothing to load
+		return config, nil
 	}
 
 	// Expand environment variables
@@ -143,28 +378,102 @@ func SaveConfig(config *Config, path string) error {
 	return nil
 }
 
+// validEndpointPath reports whether p is an absolute URL path with nothing
+// but the path in it
+func validEndpointPath(p string) bool {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.ContainsAny(p, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(p)
+	return err == nil && u.EscapedPath() == p && u.RawQuery == "" && u.Fragment == ""
+}
+
 // ValidateConfig validates the configuration
 func ValidateConfig(config *Config) error {
 	if config.Version == "" {
 		return fmt.Errorf("version is required")
 	}
 
-	if config.BackendURL == "" {
-		return fmt.Errorf("backendUrl is required")
+	switch config.Sink.Type {
+	case "", "backend", "stdout":
+	case "file":
+		if config.Sink.Path == "" {
+			return fmt.Errorf("sink.path is required for the file sink")
+		}
+	default:
+		return fmt.Errorf("sink.type must be one of: backend, stdout, file")
+	}
+
+	// A local sink doesn't talk to the backend
+	if !config.Sink.Local() {
+		if config.BackendURL == "" {
+			return fmt.Errorf("backendUrl is required")
+		}
+
+		if !strings.HasPrefix(config.BackendURL, "http://") && !strings.HasPrefix(config.BackendURL, "https://") {
+			return fmt.Errorf("backendUrl must start with http:// or https://")
+		}
+
+		if config.APIKey == "" {
+			return fmt.Errorf("apiKey is required")
+		}
+
+		names := map[string]bool{"primary": true}
+		for i, dest := range config.Destinations {
+			if dest.Name == "" {
+				return fmt.Errorf("destinations[%d].name is required", i)
+			}
+			if names[dest.Name] {
+				return fmt.Errorf("destinations[%d].name %q is already used", i, dest.Name)
+			}
+			names[dest.Name] = true
+
+			if !strings.HasPrefix(dest.URL, "http://") && !strings.HasPrefix(dest.URL, "https://") {
+				return fmt.Errorf("destinations[%d].url must start with http:// or https://", i)
+			}
+			if dest.APIKey == "" {
+				return fmt.Errorf("destinations[%d].apiKey is required", i)
+			}
+		}
+
+		for _, endpoint := range []struct{ name, path string }{
+			{"ingestPath", config.IngestPath},
+			{"singleEventPath", config.SingleEventPath},
+			{"healthPath", config.HealthPath},
+		} {
+			if endpoint.path != "" && !validEndpointPath(endpoint.path) {
+				return fmt.Errorf("%s must be a path starting with / such as /api/events, without query or fragment", endpoint.name)
+			}
+		}
 	}
 
-	if !strings.HasPrefix(config.BackendURL, "http://") && !strings.HasPrefix(config.BackendURL, "https://") {
-		return fmt.Errorf("backendUrl must start with http:// or https://")
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return fmt.Errorf("proxy must be a URL such as http://proxy.example.com:8080")
+		}
 	}
 
-	if config.APIKey == "" {
-		return fmt.Errorf("apiKey is required")
+	if (config.ClientCertPath == "") != (config.ClientKeyPath == "") {
+		return fmt.Errorf("clientCertPath and clientKeyPath must be set together")
 	}
 
 	if config.ProjectID == "" {
 		return fmt.Errorf("projectId is required")
 	}
 
+	if config.StatusAddr != "" {
+		if _, _, err := net.SplitHostPort(config.StatusAddr); err != nil {
+			return fmt.Errorf("statusAddr must be a host:port address such as %s", DefaultStatusAddr)
+		}
+	}
+
+	switch config.ProjectResolutionMode {
+	case "", "prefer-hierarchy", "prefer-config", "hierarchy-only":
+	default:
+		return fmt.Errorf("projectResolutionMode must be one of: prefer-hierarchy, prefer-config, hierarchy-only")
+	}
+
 	if config.Collection.BatchSize < 1 || config.Collection.BatchSize > 1000 {
 		return fmt.Errorf("collection.batchSize must be between 1 and 1000")
 	}
@@ -177,10 +486,124 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("collection.maxRetries must be between 0 and 10")
 	}
 
+	if config.Collection.PromptSplitThreshold < 0 {
+		return fmt.Errorf("collection.promptSplitThreshold must not be negative")
+	}
+
+	if config.Collection.MaxPromptChars < 0 {
+		return fmt.Errorf("collection.maxPromptChars must not be negative")
+	}
+
+	if config.Collection.MaxResponseChars < 0 {
+		return fmt.Errorf("collection.maxResponseChars must not be negative")
+	}
+
+	if config.Collection.BackfillWorkers < 0 || config.Collection.BackfillWorkers > 64 {
+		return fmt.Errorf("collection.backfillWorkers must be between 0 and 64")
+	}
+
+	if config.Collection.FlushWorkers < 0 || config.Collection.FlushWorkers > 16 {
+		return fmt.Errorf("collection.flushWorkers must be between 0 and 16")
+	}
+
+	if config.Collection.BackfillMaxAttempts < 0 {
+		return fmt.Errorf("collection.backfillMaxAttempts must not be negative")
+	}
+
+	if config.Collection.BackfillRetryWindow != "" {
+		if _, err := ParseDuration(config.Collection.BackfillRetryWindow); err != nil {
+			return fmt.Errorf("invalid collection.backfillRetryWindow: %w", err)
+		}
+	}
+
+	if config.Collection.MaxBackoff != "" {
+		if _, err := time.ParseDuration(config.Collection.MaxBackoff); err != nil {
+			return fmt.Errorf("invalid collection.maxBackoff: %w", err)
+		}
+	}
+
+	if config.Collection.BackfillMaxParseErrorRate < 0 || config.Collection.BackfillMaxParseErrorRate > 1 {
+		return fmt.Errorf("collection.backfillMaxParseErrorRate must be between 0 and 1")
+	}
+
+	if config.Collection.BackfillParseErrorMinLines < 0 {
+		return fmt.Errorf("collection.backfillParseErrorMinLines must not be negative")
+	}
+
+	if config.Collection.BackfillMaxFileBytes < 0 {
+		return fmt.Errorf("collection.backfillMaxFileBytes must not be negative")
+	}
+
+	if config.Collection.AuditLogMaxBytes < 0 {
+		return fmt.Errorf("collection.auditLogMaxBytes must not be negative")
+	}
+
+	if config.Collection.HierarchyCacheTTL != "" {
+		if _, err := ParseDuration(config.Collection.HierarchyCacheTTL); err != nil {
+			return fmt.Errorf("invalid collection.hierarchyCacheTTL: %w", err)
+		}
+	}
+
+	if config.Collection.MaxEventBytes < 0 {
+		return fmt.Errorf("collection.maxEventBytes must not be negative")
+	}
+
+	if config.Collection.MaxEventsPerSec < 0 {
+		return fmt.Errorf("collection.maxEventsPerSec must not be negative")
+	}
+
+	if config.Collection.SessionBudget.MaxTokens < 0 || config.Collection.SessionBudget.MaxCost < 0 {
+		return fmt.Errorf("collection.sessionBudget limits must not be negative")
+	}
+
+	for _, pattern := range config.Collection.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("collection.redactPatterns contains an invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, eventType := range config.Collection.IncludeEventTypes {
+		if !types.IsKnownEventType(eventType) {
+			return fmt.Errorf("collection.includeEventTypes contains an unknown event type %q", eventType)
+		}
+	}
+	for _, eventType := range config.Collection.ExcludeEventTypes {
+		if !types.IsKnownEventType(eventType) {
+			return fmt.Errorf("collection.excludeEventTypes contains an unknown event type %q", eventType)
+		}
+	}
+
+	switch config.Collection.Validation {
+	case "", "off", "warn", "strict":
+	default:
+		return fmt.Errorf("collection.validation must be one of: off, warn, strict")
+	}
+
+	for name, agent := range config.Agents {
+		if agent.ProjectID != "" && agent.ProjectOverride() == 0 {
+			return fmt.Errorf("agents.%s.projectId must be a positive integer", name)
+		}
+		if agent.WorkspaceID < 0 {
+			return fmt.Errorf("agents.%s.workspaceId must not be negative", name)
+		}
+		if agent.WorkspaceID > 0 && agent.ProjectID == "" {
+			return fmt.Errorf("agents.%s.workspaceId requires agents.%s.projectId", name, name)
+		}
+		if agent.DebounceMs < 0 {
+			return fmt.Errorf("agents.%s.debounceMs must not be negative", name)
+		}
+	}
+
 	if config.Buffer.MaxSize < 100 || config.Buffer.MaxSize > 100000 {
 		return fmt.Errorf("buffer.maxSize must be between 100 and 100000")
 	}
 
+	if config.Buffer.Retention != "" {
+		if _, err := ParseDuration(config.Buffer.Retention); err != nil {
+			return fmt.Errorf("buffer.retention is invalid: %w", err)
+		}
+	}
+
 	validLogLevels := map[string]bool{
 		"debug": true, "info": true, "warn": true, "error": true,
 	}
@@ -188,6 +611,12 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("logging.level must be one of: debug, info, warn, error")
 	}
 
+	switch config.Logging.Format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("logging.format must be one of: text, json")
+	}
+
 	return nil
 }
 
@@ -223,13 +652,71 @@ func expandEnvVars(config *Config) error {
 
 	config.BackendURL = expandString(config.BackendURL)
 	config.APIKey = expandString(config.APIKey)
+	for i := range config.Destinations {
+		config.Destinations[i].URL = expandString(config.Destinations[i].URL)
+		config.Destinations[i].APIKey = expandString(config.Destinations[i].APIKey)
+	}
 	config.ProjectID = expandString(config.ProjectID)
+	config.Proxy = expandString(config.Proxy)
+	config.CACertPath = expandPath(config.CACertPath)
+	config.ClientCertPath = expandPath(config.ClientCertPath)
+	config.ClientKeyPath = expandPath(config.ClientKeyPath)
 	config.Buffer.DBPath = expandPath(config.Buffer.DBPath)
+	config.Collection.DeadLetterPath = expandPath(config.Collection.DeadLetterPath)
+	config.Collection.AuditLogPath = expandPath(config.Collection.AuditLogPath)
 	config.Logging.File = expandPath(config.Logging.File)
+	config.Sink.Path = expandPath(config.Sink.Path)
 
 	return nil
 }
 
+// ParseDuration parses a duration string, additionally accepting a "d" suffix
+// for whole days (e.g. "30d")
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// GetBufferRetention returns the buffer retention window, or zero if pruning is disabled
+func (c *Config) GetBufferRetention() (time.Duration, error) {
+	if c.Buffer.Retention == "" {
+		return 0, nil
+	}
+	return ParseDuration(c.Buffer.Retention)
+}
+
+// GetBackfillRetryWindow returns how long a failing backfill source is
+// retried, or 0 when no time limit is configured
+func (c *Config) GetBackfillRetryWindow() (time.Duration, error) {
+	if c.Collection.BackfillRetryWindow == "" {
+		return 0, nil
+	}
+	return ParseDuration(c.Collection.BackfillRetryWindow)
+}
+
+// GetHierarchyCacheTTL returns how long persisted hierarchy resolutions are
+// trusted, or 0 when the disk cache is disabled
+func (c *Config) GetHierarchyCacheTTL() (time.Duration, error) {
+	if c.Collection.HierarchyCacheTTL == "" {
+		return 0, nil
+	}
+	return ParseDuration(c.Collection.HierarchyCacheTTL)
+}
+
+// GetMaxBackoff returns the retry backoff cap, or 0 to use the client default
+func (c *Config) GetMaxBackoff() (time.Duration, error) {
+	if c.Collection.MaxBackoff == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.Collection.MaxBackoff)
+}
+
 // GetBatchInterval returns the batch interval as a time.Duration
 func (c *Config) GetBatchInterval() (time.Duration, error) {
 	return time.ParseDuration(c.Collection.BatchInterval)
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -21,6 +22,10 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected batch interval 5s, got %s", config.Collection.BatchInterval)
 	}
 
+	if !config.Collection.Redact {
+		t.Error("Expected redaction to be enabled by default")
+	}
+
 	if !config.Buffer.Enabled {
 		t.Error("Expected buffer to be enabled by default")
 	}
@@ -252,6 +257,27 @@ func TestLoadConfig(t *testing.T) {
 	if config.Logging.Level != "debug" {
 		t.Errorf("Expected log level debug, got %s", config.Logging.Level)
 	}
+
+	if !config.Collection.Redact {
+		t.Error("Expected redaction to stay enabled when the file doesn't set it")
+	}
+}
+
+func TestLoadConfig_RedactOptOut(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configJSON := `{"apiKey": "test-key", "collection": {"redact": false}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0600); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.Collection.Redact {
+		t.Error("Expected redaction disabled by the config file")
+	}
 }
 
 func TestSaveConfig(t *testing.T) {
@@ -298,3 +324,250 @@ func TestGetBatchInterval(t *testing.T) {
 		t.Errorf("Expected 5 seconds, got %v", duration)
 	}
 }
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"xd", 0, true},
+		{"-1d", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := ParseDuration(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if d != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_AgentProjectOverride(t *testing.T) {
+	tests := []struct {
+		name      string
+		agent     AgentConfig
+		expectErr bool
+	}{
+		{"no override", AgentConfig{Enabled: true}, false},
+		{"project override", AgentConfig{Enabled: true, ProjectID: "12"}, false},
+		{"project and workspace override", AgentConfig{Enabled: true, ProjectID: "12", WorkspaceID: 4}, false},
+		{"non-numeric project", AgentConfig{Enabled: true, ProjectID: "my-project"}, true},
+		{"zero project", AgentConfig{Enabled: true, ProjectID: "0"}, true},
+		{"negative workspace", AgentConfig{Enabled: true, ProjectID: "12", WorkspaceID: -1}, true},
+		{"workspace without project", AgentConfig{Enabled: true, WorkspaceID: 4}, true},
+		{"debounce override", AgentConfig{Enabled: true, DebounceMs: 500}, false},
+		{"negative debounce", AgentConfig{Enabled: true, DebounceMs: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKey = "test-key"
+			config.ProjectID = "test"
+			config.Agents["cursor"] = tt.agent
+
+			err := ValidateConfig(config)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Destinations(t *testing.T) {
+	team := DestinationConfig{Name: "team", URL: "https://devlog.example.com", APIKey: "team-key"}
+
+	tests := []struct {
+		name         string
+		destinations []DestinationConfig
+		expectErr    bool
+	}{
+		{"required mirror", []DestinationConfig{team}, false},
+		{"best-effort mirror", []DestinationConfig{{Name: "dev", URL: "http://localhost:4000", APIKey: "k", BestEffort: true}}, false},
+		{"missing name", []DestinationConfig{{URL: team.URL, APIKey: "k"}}, true},
+		{"reserved name", []DestinationConfig{{Name: "primary", URL: team.URL, APIKey: "k"}}, true},
+		{"duplicate name", []DestinationConfig{team, team}, true},
+		{"invalid url", []DestinationConfig{{Name: "team", URL: "devlog.example.com", APIKey: "k"}}, true},
+		{"missing api key", []DestinationConfig{{Name: "team", URL: team.URL}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKey = "test-key"
+			config.Destinations = tt.destinations
+
+			err := ValidateConfig(config)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_EndpointPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		expectErr bool
+	}{
+		{"default", "", false},
+		{"subpath", "/devlog/api/v1/agent/events/batch", false},
+		{"relative", "api/events/batch", true},
+		{"full url", "https://devlog.example.com/api/events/batch", true},
+		{"protocol-relative", "//devlog.example.com/api", true},
+		{"query", "/api/events/batch?x=1", true},
+		{"fragment", "/api/events#batch", true},
+		{"whitespace", "/api/events batch", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, set := range []func(*Config){
+				func(c *Config) { c.IngestPath = tt.path },
+				func(c *Config) { c.SingleEventPath = tt.path },
+				func(c *Config) { c.HealthPath = tt.path },
+			} {
+				config := DefaultConfig()
+				config.APIKey = "test-key"
+				set(config)
+
+				err := ValidateConfig(config)
+				if tt.expectErr && err == nil {
+					t.Error("Expected error but got none")
+				}
+				if !tt.expectErr && err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig_EventTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		expectErr bool
+	}{
+		{"no filter", nil, nil, false},
+		{"known include", []string{"llm_request", "llm_response"}, nil, false},
+		{"known exclude", nil, []string{"file_read"}, false},
+		{"unknown include", []string{"llm_requests"}, nil, true},
+		{"unknown exclude", nil, []string{"FILE_READ"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKey = "test-key"
+			config.Collection.IncludeEventTypes = tt.include
+			config.Collection.ExcludeEventTypes = tt.exclude
+
+			err := ValidateConfig(config)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestAgentConfig_ProjectOverride(t *testing.T) {
+	if got := (AgentConfig{ProjectID: "12"}).ProjectOverride(); got != 12 {
+		t.Errorf("Expected 12, got %d", got)
+	}
+	if got := (AgentConfig{}).ProjectOverride(); got != 0 {
+		t.Errorf("Expected 0 without override, got %d", got)
+	}
+}
+
+func TestValidateConfig_Sink(t *testing.T) {
+	tests := []struct {
+		name      string
+		sink      SinkConfig
+		apiKey    string
+		expectErr bool
+	}{
+		{"backend requires api key", SinkConfig{}, "", true},
+		{"explicit backend", SinkConfig{Type: "backend"}, "test-key", false},
+		{"stdout without backend", SinkConfig{Type: "stdout"}, "", false},
+		{"file sink", SinkConfig{Type: "file", Path: "/tmp/events.ndjson"}, "", false},
+		{"file sink without path", SinkConfig{Type: "file"}, "", true},
+		{"unknown sink", SinkConfig{Type: "kafka"}, "test-key", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKey = tt.apiKey
+			config.ProjectID = "test"
+			config.Sink = tt.sink
+
+			err := ValidateConfig(config)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ClientCertificate(t *testing.T) {
+	tests := []struct {
+		name      string
+		cert, key string
+		expectErr bool
+	}{
+		{"none", "", "", false},
+		{"certificate and key", "/etc/devlog/client.pem", "/etc/devlog/client-key.pem", false},
+		{"certificate only", "/etc/devlog/client.pem", "", true},
+		{"key only", "", "/etc/devlog/client-key.pem", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.APIKey = "test-key"
+			config.ProjectID = "test"
+			config.ClientCertPath = tt.cert
+			config.ClientKeyPath = tt.key
+
+			err := ValidateConfig(config)
+			if tt.expectErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
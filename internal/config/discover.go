@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultPath is the configuration file used when no other is found
+const DefaultPath = "~/.devlog/collector.json"
+
+// PathEnv names the environment variable that points at a configuration file
+const PathEnv = "DEVLOG_CONFIG"
+
+// Discover returns the configuration file to use and how it was chosen. In
+// order: flagPath when set, $DEVLOG_CONFIG when set, then the first of
+// $XDG_CONFIG_HOME/devlog/collector.json and ./.devlog/collector.json that
+// exists, and DefaultPath otherwise. Explicit paths are returned whether or
+// not they exist, so a typo isn't silently replaced by another file.
+func Discover(flagPath string) (path, source string) {
+	if flagPath != "" {
+		return flagPath, "--config flag"
+	}
+	if env := os.Getenv(PathEnv); env != "" {
+		return env, "$" + PathEnv
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidate := filepath.Join(xdg, "devlog", "collector.json")
+		if Exists(candidate) {
+			return candidate, "$XDG_CONFIG_HOME"
+		}
+	}
+	local := filepath.Join(".devlog", "collector.json")
+	if Exists(local) {
+		if abs, err := filepath.Abs(local); err == nil {
+			local = abs
+		}
+		return local, "current directory"
+	}
+	return DefaultPath, "default location"
+}
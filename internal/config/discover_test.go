@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestDiscover_Precedence(t *testing.T) {
+	xdgDir := t.TempDir()
+	xdgConfig := filepath.Join(xdgDir, "devlog", "collector.json")
+	workDir := t.TempDir()
+	localConfig := filepath.Join(workDir, ".devlog", "collector.json")
+	envConfig := filepath.Join(t.TempDir(), "env.json")
+
+	tests := []struct {
+		name       string
+		flag       string
+		env        string
+		xdg        bool
+		local      bool
+		wantPath   string
+		wantSource string
+	}{
+		{"flag wins", "/etc/devlog.json", envConfig, true, true, "/etc/devlog.json", "--config flag"},
+		{"environment variable", "", envConfig, true, true, envConfig, "$DEVLOG_CONFIG"},
+		{"XDG config home", "", "", true, true, xdgConfig, "$XDG_CONFIG_HOME"},
+		{"project-local", "", "", false, true, localConfig, "current directory"},
+		{"home default", "", "", false, false, DefaultPath, "default location"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.RemoveAll(filepath.Dir(xdgConfig))
+			os.RemoveAll(filepath.Dir(localConfig))
+			if tt.xdg {
+				writeConfigFile(t, xdgConfig)
+			}
+			if tt.local {
+				writeConfigFile(t, localConfig)
+			}
+			t.Setenv(PathEnv, tt.env)
+			t.Setenv("XDG_CONFIG_HOME", xdgDir)
+			t.Chdir(workDir)
+
+			path, source := Discover(tt.flag)
+			if path != tt.wantPath {
+				t.Errorf("Expected path %s, got %s", tt.wantPath, path)
+			}
+			if source != tt.wantSource {
+				t.Errorf("Expected source %q, got %q", tt.wantSource, source)
+			}
+		})
+	}
+}
+
+func TestDiscover_ExplicitPathNeedNotExist(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	t.Setenv(PathEnv, missing)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if path, _ := Discover(""); path != missing {
+		t.Errorf("Expected $%s to be used even though the file is missing, got %s", PathEnv, path)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix starts the names of environment variables overriding config
+// fields, e.g. DEVLOG_BACKEND_URL for backendUrl or
+// DEVLOG_COLLECTION_BATCH_SIZE for collection.batchSize
+const EnvPrefix = "DEVLOG_"
+
+// envAliases are shorter names accepted for some overrides, keyed by the
+// full name without the prefix. The full name wins when both are set.
+var envAliases = map[string]string{
+	"LOGGING_LEVEL":  "LOG_LEVEL",
+	"LOGGING_FORMAT": "LOG_FORMAT",
+	"LOGGING_FILE":   "LOG_FILE",
+}
+
+// applyEnvOverrides sets config fields from DEVLOG_ environment variables.
+// Each field's name is its JSON path in upper snake case. Lists are
+// comma-separated; empty variables are ignored. Agents and destinations
+// can only be configured in the file. It reports whether any field was set.
+func applyEnvOverrides(config *Config) (bool, error) {
+	return applyEnvFields(reflect.ValueOf(config).Elem(), "")
+}
+
+// applyEnvFields overrides the fields of a config struct whose variable
+// names start with prefix
+func applyEnvFields(v reflect.Value, prefix string) (bool, error) {
+	overridden := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + envName(tag)
+
+		if field.Type.Kind() == reflect.Struct {
+			set, err := applyEnvFields(v.Field(i), name+"_")
+			if err != nil {
+				return false, err
+			}
+			overridden = overridden || set
+			continue
+		}
+
+		value, ok := lookupEnvOverride(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(v.Field(i), value); err != nil {
+			return false, fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		overridden = true
+	}
+	return overridden, nil
+}
+
+// lookupEnvOverride returns the non-empty value of the variable for name or
+// its alias
+func lookupEnvOverride(name string) (string, bool) {
+	if value := os.Getenv(EnvPrefix + name); value != "" {
+		return value, true
+	}
+	if alias, ok := envAliases[name]; ok {
+		if value := os.Getenv(EnvPrefix + alias); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// setEnvField parses value into a config field
+func setEnvField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can only be set in the config file")
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("can only be set in the config file")
+	}
+	return nil
+}
+
+// envName converts a camelCase JSON field name to upper snake case, keeping
+// acronyms together ("hierarchyCacheTTL" becomes "HIERARCHY_CACHE_TTL")
+func envName(jsonName string) string {
+	runes := []rune(jsonName)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"backendUrl":        "BACKEND_URL",
+		"apiKey":            "API_KEY",
+		"caCertPath":        "CA_CERT_PATH",
+		"hierarchyCacheTTL": "HIERARCHY_CACHE_TTL",
+		"maxEventsPerSec":   "MAX_EVENTS_PER_SEC",
+		"level":             "LEVEL",
+	}
+	for jsonName, want := range tests {
+		if got := envName(jsonName); got != want {
+			t.Errorf("envName(%q) = %q, want %q", jsonName, got, want)
+		}
+	}
+}
+
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configJSON := `{
+		"backendUrl": "http://file:3200",
+		"apiKey": "file-key",
+		"projectId": "file-project",
+		"collection": {"batchSize": 50, "batchInterval": "10s", "redact": false},
+		"logging": {"level": "info", "format": "text"}
+	}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0600); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	t.Setenv("DEVLOG_BACKEND_URL", "https://env.example.com")
+	t.Setenv("DEVLOG_API_KEY", "env-key")
+	t.Setenv("DEVLOG_PROJECT_ID", "env-project")
+	t.Setenv("DEVLOG_LOG_LEVEL", "debug")
+	t.Setenv("DEVLOG_COLLECTION_BATCH_SIZE", "200")
+	t.Setenv("DEVLOG_COLLECTION_REDACT", "true")
+	t.Setenv("DEVLOG_COLLECTION_MAX_EVENTS_PER_SEC", "12.5")
+	t.Setenv("DEVLOG_COLLECTION_EXCLUDE_EVENT_TYPES", "file_read, tool_use")
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.BackendURL != "https://env.example.com" {
+		t.Errorf("Expected backendUrl from the environment, got %s", config.BackendURL)
+	}
+	if config.APIKey != "env-key" {
+		t.Errorf("Expected apiKey from the environment, got %s", config.APIKey)
+	}
+	if config.ProjectID != "env-project" {
+		t.Errorf("Expected projectId from the environment, got %s", config.ProjectID)
+	}
+	if config.Logging.Level != "debug" {
+		t.Errorf("Expected log level from the environment, got %s", config.Logging.Level)
+	}
+	if config.Collection.BatchSize != 200 {
+		t.Errorf("Expected batch size 200, got %d", config.Collection.BatchSize)
+	}
+	if !config.Collection.Redact {
+		t.Error("Expected redaction enabled from the environment")
+	}
+	if config.Collection.MaxEventsPerSec != 12.5 {
+		t.Errorf("Expected 12.5 events/sec, got %v", config.Collection.MaxEventsPerSec)
+	}
+	if want := []string{"file_read", "tool_use"}; !reflect.DeepEqual(config.Collection.ExcludeEventTypes, want) {
+		t.Errorf("Expected excluded types %v, got %v", want, config.Collection.ExcludeEventTypes)
+	}
+
+	// Fields without an override keep the file's value
+	if config.Collection.BatchInterval != "10s" {
+		t.Errorf("Expected batch interval from the file, got %s", config.Collection.BatchInterval)
+	}
+}
+
+func TestLoadConfig_EnvOverridesWithoutFile(t *testing.T) {
+	t.Setenv("DEVLOG_BACKEND_URL", "https://env.example.com")
+	t.Setenv("DEVLOG_API_KEY", "env-key")
+	t.Setenv("DEVLOG_LOGGING_LEVEL", "warn")
+	t.Setenv("DEVLOG_LOG_LEVEL", "debug")
+
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.BackendURL != "https://env.example.com" {
+		t.Errorf("Expected backendUrl from the environment, got %s", config.BackendURL)
+	}
+	if config.Logging.Level != "warn" {
+		t.Errorf("Expected the full variable name to win over its alias, got %s", config.Logging.Level)
+	}
+	if config.Collection.BatchSize != DefaultConfig().Collection.BatchSize {
+		t.Errorf("Expected the default batch size, got %d", config.Collection.BatchSize)
+	}
+}
+
+func TestLoadConfig_InvalidEnvOverride(t *testing.T) {
+	tests := map[string]string{
+		"DEVLOG_COLLECTION_BATCH_SIZE": "lots",
+		"DEVLOG_COLLECTION_REDACT":     "maybe",
+		"DEVLOG_AGENTS":                "copilot",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+				t.Errorf("Expected %s=%s to be rejected", name, value)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_EnvOverrideIsValidated(t *testing.T) {
+	t.Setenv("DEVLOG_API_KEY", "env-key")
+	t.Setenv("DEVLOG_COLLECTION_BATCH_SIZE", "5000")
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an out-of-range batch size from the environment to fail validation")
+	}
+}
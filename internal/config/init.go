@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConfigExists is returned by WriteInitConfig when the target file is
+// already present and overwriting wasn't requested
+var ErrConfigExists = errors.New("config file already exists")
+
+// InitOptions are the values a user supplies when generating a config;
+// empty fields keep the DefaultConfig value
+type InitOptions struct {
+	BackendURL string
+	APIKey     string
+	ProjectID  string
+}
+
+// NewInitConfig builds a configuration from DefaultConfig with opts applied
+// and validates it
+func NewInitConfig(opts InitOptions) (*Config, error) {
+	config := DefaultConfig()
+	if opts.BackendURL != "" {
+		config.BackendURL = opts.BackendURL
+	}
+	if opts.APIKey != "" {
+		config.APIKey = opts.APIKey
+	}
+	if opts.ProjectID != "" {
+		config.ProjectID = opts.ProjectID
+	}
+
+	if err := ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// WriteInitConfig saves a generated configuration to path. An existing file
+// is only replaced when force is set.
+func WriteInitConfig(config *Config, path string, force bool) error {
+	if !force && Exists(path) {
+		return fmt.Errorf("%w: %s (use --force to overwrite)", ErrConfigExists, path)
+	}
+	return SaveConfig(config, path)
+}
+
+// Exists reports whether a configuration file is present at path
+func Exists(path string) bool {
+	_, err := os.Stat(expandPath(path))
+	return err == nil
+}
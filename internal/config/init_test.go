@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewInitConfig(t *testing.T) {
+	config, err := NewInitConfig(InitOptions{
+		BackendURL: "https://devlog.example.com",
+		APIKey:     "test-key",
+		ProjectID:  "42",
+	})
+	if err != nil {
+		t.Fatalf("NewInitConfig failed: %v", err)
+	}
+
+	if config.BackendURL != "https://devlog.example.com" {
+		t.Errorf("Expected backend URL from options, got %s", config.BackendURL)
+	}
+	if config.APIKey != "test-key" {
+		t.Errorf("Expected API key from options, got %s", config.APIKey)
+	}
+	if config.ProjectID != "42" {
+		t.Errorf("Expected project ID from options, got %s", config.ProjectID)
+	}
+
+	// Everything else comes from the defaults
+	if config.Collection.BatchSize != DefaultConfig().Collection.BatchSize {
+		t.Errorf("Expected default batch size, got %d", config.Collection.BatchSize)
+	}
+}
+
+func TestNewInitConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts InitOptions
+	}{
+		{"missing api key", InitOptions{ProjectID: "42"}},
+		{"bad backend url", InitOptions{BackendURL: "devlog.example.com", APIKey: "test-key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewInitConfig(tt.opts); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestWriteInitConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "nested", "collector.json")
+
+	config, err := NewInitConfig(InitOptions{APIKey: "first-key"})
+	if err != nil {
+		t.Fatalf("NewInitConfig failed: %v", err)
+	}
+	if err := WriteInitConfig(config, configPath, false); err != nil {
+		t.Fatalf("WriteInitConfig failed: %v", err)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load generated config: %v", err)
+	}
+	if loaded.APIKey != "first-key" {
+		t.Errorf("Expected API key 'first-key', got '%s'", loaded.APIKey)
+	}
+
+	// A second write must not clobber the file without force
+	config.APIKey = "second-key"
+	err = WriteInitConfig(config, configPath, false)
+	if !errors.Is(err, ErrConfigExists) {
+		t.Fatalf("Expected ErrConfigExists, got %v", err)
+	}
+	if loaded, _ := LoadConfig(configPath); loaded.APIKey != "first-key" {
+		t.Errorf("Existing config was overwritten, API key is '%s'", loaded.APIKey)
+	}
+
+	if err := WriteInitConfig(config, configPath, true); err != nil {
+		t.Fatalf("WriteInitConfig with force failed: %v", err)
+	}
+	if loaded, _ := LoadConfig(configPath); loaded.APIKey != "second-key" {
+		t.Errorf("Expected forced write to replace the API key, got '%s'", loaded.APIKey)
+	}
+}
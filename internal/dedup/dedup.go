@@ -0,0 +1,123 @@
+// Package dedup keeps a persistent index of the events the collector has
+// already taken responsibility for sending, so backfill and live collection
+// running side by side don't both send an event they each parsed.
+package dedup
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+// busyTimeout is how long a write waits for another process's lock
+const busyTimeout = 5 * time.Second
+
+// Index records content hashes (see buffer.EventHash) of events that have
+// been sent or queued for sending. It lives in the buffer database, so every
+// collector process sharing that database shares the index.
+type Index struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+// NewIndex opens (or creates) the dedup table in dbPath
+func NewIndex(dbPath string, log *logrus.Logger) (*Index, error) {
+	if log == nil {
+		log = logrus.New()
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dedup database: %w", err)
+	}
+
+	// Other collector processes write the same database; wait for their
+	// locks rather than failing. The pragma is per connection, so keep one.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout.Milliseconds())); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS sent_events (
+		content_hash TEXT PRIMARY KEY,
+		marked_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sent_events_marked_at ON sent_events(marked_at);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize dedup schema: %w", err)
+	}
+
+	return &Index{db: db, log: log}, nil
+}
+
+// Seen reports whether hash has been marked. An index that can't be read
+// reports false: sending an event twice beats not sending it.
+func (i *Index) Seen(hash string) bool {
+	var seen bool
+	if err := i.db.QueryRow("SELECT EXISTS(SELECT 1 FROM sent_events WHERE content_hash = ?)", hash).Scan(&seen); err != nil {
+		i.log.Warnf("Failed to check dedup index: %v", err)
+		return false
+	}
+	return seen
+}
+
+// Mark records hash as sent
+func (i *Index) Mark(hash string) {
+	i.Claim(hash)
+}
+
+// Claim marks hash and reports whether it was unmarked before, in one step,
+// so of two senders claiming the same event only one wins. Like Seen, it
+// fails open when the index can't be written.
+func (i *Index) Claim(hash string) bool {
+	result, err := i.db.Exec("INSERT OR IGNORE INTO sent_events (content_hash, marked_at) VALUES (?, ?)", hash, time.Now().Unix())
+	if err != nil {
+		i.log.Warnf("Failed to update dedup index: %v", err)
+		return true
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return true
+	}
+	return inserted > 0
+}
+
+// PruneOlderThan forgets hashes marked longer ago than the given retention
+// window and returns how many were removed. It runs alongside the buffer's
+// own pruning: an event older than the window has left the buffer, and a log
+// re-parsed after that long is left to the backfill state to skip.
+func (i *Index) PruneOlderThan(d time.Duration) (int, error) {
+	cutoff := time.Now().Add(-d).Unix()
+
+	result, err := i.db.Exec("DELETE FROM sent_events WHERE marked_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune dedup index: %w", err)
+	}
+	pruned, _ := result.RowsAffected()
+	if pruned > 0 {
+		i.log.Debugf("Pruned %d dedup hashes older than %s", pruned, d)
+	}
+	return int(pruned), nil
+}
+
+// Clear forgets every marked hash, so events are sent again when their logs
+// are re-imported
+func (i *Index) Clear() error {
+	if _, err := i.db.Exec("DELETE FROM sent_events"); err != nil {
+		return fmt.Errorf("failed to clear dedup index: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (i *Index) Close() error {
+	return i.db.Close()
+}
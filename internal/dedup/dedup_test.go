@@ -0,0 +1,130 @@
+package dedup
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T, dbPath string) *Index {
+	t.Helper()
+
+	index, err := NewIndex(dbPath, nil)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	t.Cleanup(func() { index.Close() })
+	return index
+}
+
+func TestIndex_SeenAfterMark(t *testing.T) {
+	index := newTestIndex(t, filepath.Join(t.TempDir(), "buffer.db"))
+
+	if index.Seen("abc") {
+		t.Fatal("Expected an unmarked hash not to be seen")
+	}
+	index.Mark("abc")
+	if !index.Seen("abc") {
+		t.Error("Expected a marked hash to be seen")
+	}
+	if index.Seen("def") {
+		t.Error("Expected other hashes to stay unseen")
+	}
+
+	// Marking again is harmless
+	index.Mark("abc")
+	if !index.Seen("abc") {
+		t.Error("Expected the hash to stay seen")
+	}
+}
+
+func TestIndex_ClaimOnce(t *testing.T) {
+	index := newTestIndex(t, filepath.Join(t.TempDir(), "buffer.db"))
+
+	if !index.Claim("abc") {
+		t.Fatal("Expected the first claim to win")
+	}
+	if index.Claim("abc") {
+		t.Error("Expected a second claim to lose")
+	}
+	if !index.Seen("abc") {
+		t.Error("Expected a claimed hash to be seen")
+	}
+}
+
+func TestIndex_ConcurrentClaims(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+	backfill := newTestIndex(t, dbPath)
+	live := newTestIndex(t, dbPath)
+
+	// Two collectors claim the same events through their own connections
+	var won atomic.Int64
+	var wg sync.WaitGroup
+	for _, index := range []*Index{backfill, live} {
+		wg.Add(1)
+		go func(index *Index) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if index.Claim(fmt.Sprintf("hash-%d", i)) {
+					won.Add(1)
+				}
+			}
+		}(index)
+	}
+	wg.Wait()
+
+	if won.Load() != 50 {
+		t.Errorf("Expected each of 50 events to be claimed exactly once, got %d claims", won.Load())
+	}
+}
+
+func TestIndex_PersistsAndClears(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "buffer.db")
+
+	first, err := NewIndex(dbPath, nil)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	first.Mark("abc")
+	first.Close()
+
+	index := newTestIndex(t, dbPath)
+	if !index.Seen("abc") {
+		t.Fatal("Expected the hash to be seen after reopening")
+	}
+
+	if err := index.Clear(); err != nil {
+		t.Fatalf("failed to clear index: %v", err)
+	}
+	if index.Seen("abc") {
+		t.Error("Expected the hash to be forgotten after Clear")
+	}
+}
+
+func TestIndex_PruneOlderThan(t *testing.T) {
+	index := newTestIndex(t, filepath.Join(t.TempDir(), "buffer.db"))
+
+	index.Mark("old")
+	index.Mark("recent")
+	old := time.Now().Add(-48 * time.Hour).Unix()
+	if _, err := index.db.Exec("UPDATE sent_events SET marked_at = ? WHERE content_hash = ?", old, "old"); err != nil {
+		t.Fatalf("failed to age hash: %v", err)
+	}
+
+	pruned, err := index.PruneOlderThan(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("PruneOlderThan failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Expected 1 hash pruned, got %d", pruned)
+	}
+	if index.Seen("old") {
+		t.Error("Expected the old hash to be forgotten")
+	}
+	if !index.Seen("recent") {
+		t.Error("Expected the recent hash to be kept")
+	}
+}
@@ -1,8 +1,10 @@
 package hierarchy
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/codervisor/devlog/internal/client"
 	"github.com/codervisor/devlog/pkg/models"
@@ -18,11 +20,18 @@ type WorkspaceContext struct {
 	MachineName string
 }
 
+// DefaultResolveTimeout bounds a single backend lookup in Resolve so a slow
+// or unresponsive backend can't stall parsing.
+const DefaultResolveTimeout = 10 * time.Second
+
 // HierarchyCache provides fast lookups for workspace context
 type HierarchyCache struct {
 	workspaces map[string]*WorkspaceContext
+	projects   map[string]*WorkspaceContext // project folder -> context, see ResolveProjectRoot
 	mu         sync.RWMutex
 	client     *client.Client
+	disk       *DiskCache
+	timeout    time.Duration
 	log        *logrus.Logger
 }
 
@@ -33,11 +42,32 @@ func NewHierarchyCache(client *client.Client, log *logrus.Logger) *HierarchyCach
 	}
 	return &HierarchyCache{
 		workspaces: make(map[string]*WorkspaceContext),
+		projects:   make(map[string]*WorkspaceContext),
 		client:     client,
+		timeout:    DefaultResolveTimeout,
 		log:        log,
 	}
 }
 
+// SetResolveTimeout changes how long Resolve waits for the backend before
+// giving up on a workspace. Zero disables the per-resolve timeout.
+func (hc *HierarchyCache) SetResolveTimeout(timeout time.Duration) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.timeout = timeout
+}
+
+// SetDiskCache backs the cache with a persistent store. Resolve then serves
+// unseen workspaces from disk before asking the backend, and falls back to
+// expired entries when the backend can't be reached.
+func (hc *HierarchyCache) SetDiskCache(disk *DiskCache) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.disk = disk
+}
+
 // Initialize populates the cache with workspaces
 func (hc *HierarchyCache) Initialize(workspaces []*models.Workspace) {
 	hc.mu.Lock()
@@ -66,27 +96,77 @@ func (hc *HierarchyCache) Initialize(workspaces []*models.Workspace) {
 	hc.log.Infof("Hierarchy cache initialized with %d workspaces", len(hc.workspaces))
 }
 
-// Resolve looks up workspace context, with lazy loading from backend
-func (hc *HierarchyCache) Resolve(workspaceID string) (*WorkspaceContext, error) {
+// Resolve looks up workspace context, with lazy loading from backend. The
+// backend lookup is bounded by ctx and the cache's resolve timeout.
+func (hc *HierarchyCache) Resolve(ctx context.Context, workspaceID string) (*WorkspaceContext, error) {
 	// Try cache first
 	hc.mu.RLock()
-	ctx, ok := hc.workspaces[workspaceID]
+	wsCtx, ok := hc.workspaces[workspaceID]
 	hc.mu.RUnlock()
 
 	if ok {
 		hc.log.Debugf("Cache hit for workspace: %s", workspaceID)
-		return ctx, nil
+		return wsCtx, nil
+	}
+
+	hc.mu.RLock()
+	disk := hc.disk
+	timeout := hc.timeout
+	hc.mu.RUnlock()
+
+	if disk != nil {
+		wsCtx, err := disk.Get(workspaceID)
+		if err != nil {
+			hc.log.Warnf("Failed to read hierarchy disk cache: %v", err)
+		} else if wsCtx != nil {
+			hc.log.Debugf("Disk cache hit for workspace: %s", workspaceID)
+			hc.store(workspaceID, wsCtx)
+			return wsCtx, nil
+		}
 	}
 
 	hc.log.Debugf("Cache miss for workspace: %s, loading from backend", workspaceID)
 
-	// Lazy load from backend
-	workspace, err := hc.client.GetWorkspace(workspaceID)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	wsCtx, err := hc.fetch(ctx, workspaceID)
+	if err != nil {
+		// Serve the last-known mapping so backfill can proceed offline
+		if disk != nil {
+			if stale, staleErr := disk.LastKnown(workspaceID); staleErr == nil && stale != nil {
+				hc.log.Warnf("Using expired cached hierarchy for workspace %s: %v", workspaceID, err)
+				hc.store(workspaceID, stale)
+				return stale, nil
+			}
+		}
+		return nil, err
+	}
+
+	hc.store(workspaceID, wsCtx)
+	if disk != nil {
+		if err := disk.Put(workspaceID, wsCtx); err != nil {
+			hc.log.Warnf("Failed to write hierarchy disk cache: %v", err)
+		}
+	}
+
+	return wsCtx, nil
+}
+
+// fetch loads a workspace context from the backend
+func (hc *HierarchyCache) fetch(ctx context.Context, workspaceID string) (*WorkspaceContext, error) {
+	if hc.client == nil {
+		return nil, fmt.Errorf("workspace not found: %s (no backend client)", workspaceID)
+	}
+	workspace, err := hc.client.GetWorkspace(ctx, workspaceID)
 	if err != nil {
 		return nil, fmt.Errorf("workspace not found: %w", err)
 	}
 
-	ctx = &WorkspaceContext{
+	wsCtx := &WorkspaceContext{
 		ProjectID:   workspace.ProjectID,
 		MachineID:   workspace.MachineID,
 		WorkspaceID: workspace.ID,
@@ -94,23 +174,67 @@ func (hc *HierarchyCache) Resolve(workspaceID string) (*WorkspaceContext, error)
 
 	// Load additional info if needed
 	if workspace.Project != nil {
-		ctx.ProjectName = workspace.Project.FullName
+		wsCtx.ProjectName = workspace.Project.FullName
 	} else {
-		ctx.ProjectName = "unknown"
+		wsCtx.ProjectName = "unknown"
 	}
 
 	if workspace.Machine != nil {
-		ctx.MachineName = workspace.Machine.Hostname
+		wsCtx.MachineName = workspace.Machine.Hostname
 	} else {
-		ctx.MachineName = "unknown"
+		wsCtx.MachineName = "unknown"
+	}
+
+	return wsCtx, nil
+}
+
+// ResolveProjectRoot looks up the context of a project folder that has no
+// VS Code workspace, such as one a JetBrains or Neovim log names. The
+// project is resolved from the Git remote of the folder, as workspace
+// discovery does, and the context carries no workspace or machine. The
+// backend lookup is bounded by ctx and the cache's resolve timeout.
+func (hc *HierarchyCache) ResolveProjectRoot(ctx context.Context, root string) (*WorkspaceContext, error) {
+	hc.mu.RLock()
+	wsCtx, ok := hc.projects[root]
+	timeout := hc.timeout
+	hc.mu.RUnlock()
+
+	if ok {
+		return wsCtx, nil
+	}
+	if hc.client == nil {
+		return nil, fmt.Errorf("project not found: %s (no backend client)", root)
+	}
+
+	// Folders outside Git are still projects, named by their path
+	remoteURL := fmt.Sprintf("file://%s", root)
+	if gitInfo, err := GetGitInfo(root); err == nil {
+		remoteURL = gitInfo.RemoteURL
 	}
 
-	// Cache it
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	project, err := hc.client.ResolveProject(ctx, remoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+	wsCtx = &WorkspaceContext{ProjectID: project.ID, ProjectName: project.FullName}
+
 	hc.mu.Lock()
-	hc.workspaces[workspaceID] = ctx
+	hc.projects[root] = wsCtx
 	hc.mu.Unlock()
+	return wsCtx, nil
+}
 
-	return ctx, nil
+// store caches a context in memory
+func (hc *HierarchyCache) store(workspaceID string, ctx *WorkspaceContext) {
+	hc.mu.Lock()
+	hc.workspaces[workspaceID] = ctx
+	hc.mu.Unlock()
 }
 
 // Refresh re-fetches all workspaces from backend
@@ -169,6 +293,7 @@ func (hc *HierarchyCache) Clear() {
 	defer hc.mu.Unlock()
 
 	hc.workspaces = make(map[string]*WorkspaceContext)
+	hc.projects = make(map[string]*WorkspaceContext)
 
 	hc.log.Info("Hierarchy cache cleared")
 }
@@ -1,8 +1,14 @@
 package hierarchy
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/codervisor/devlog/internal/client"
 	"github.com/codervisor/devlog/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -39,7 +45,7 @@ func TestHierarchyCache_Initialize(t *testing.T) {
 	assert.Equal(t, 2, cache.Size())
 
 	// Verify first workspace
-	ctx, err := cache.Resolve("ws-1")
+	ctx, err := cache.Resolve(context.Background(), "ws-1")
 	require.NoError(t, err)
 	assert.Equal(t, 1, ctx.WorkspaceID)
 	assert.Equal(t, 10, ctx.ProjectID)
@@ -48,7 +54,7 @@ func TestHierarchyCache_Initialize(t *testing.T) {
 	assert.Equal(t, "machine1", ctx.MachineName)
 
 	// Verify second workspace
-	ctx, err = cache.Resolve("ws-2")
+	ctx, err = cache.Resolve(context.Background(), "ws-2")
 	require.NoError(t, err)
 	assert.Equal(t, 2, ctx.WorkspaceID)
 	assert.Equal(t, 11, ctx.ProjectID)
@@ -73,12 +79,12 @@ func TestHierarchyCache_Resolve_CacheHit(t *testing.T) {
 	cache.Initialize(workspaces)
 
 	// First resolve - cache hit
-	ctx, err := cache.Resolve("ws-1")
+	ctx, err := cache.Resolve(context.Background(), "ws-1")
 	require.NoError(t, err)
 	assert.Equal(t, 1, ctx.WorkspaceID)
 
 	// Second resolve - should also be cache hit
-	ctx2, err := cache.Resolve("ws-1")
+	ctx2, err := cache.Resolve(context.Background(), "ws-1")
 	require.NoError(t, err)
 	assert.Equal(t, ctx, ctx2)
 }
@@ -107,7 +113,7 @@ func TestHierarchyCache_Add(t *testing.T) {
 	assert.Equal(t, 1, cache.Size())
 
 	// Verify it can be resolved
-	ctx, err := cache.Resolve("ws-1")
+	ctx, err := cache.Resolve(context.Background(), "ws-1")
 	require.NoError(t, err)
 	assert.Equal(t, 1, ctx.WorkspaceID)
 	assert.Equal(t, "owner/repo", ctx.ProjectName)
@@ -144,7 +150,7 @@ func TestHierarchyCache_Remove(t *testing.T) {
 	// Note: Can't test Resolve("ws-1") without a mock client
 	// since it will try to lazy-load from backend
 	// Just verify the size decreased and ws-2 is still accessible
-	ctx, err := cache.Resolve("ws-2")
+	ctx, err := cache.Resolve(context.Background(), "ws-2")
 	require.NoError(t, err)
 	assert.Equal(t, 2, ctx.WorkspaceID)
 }
@@ -236,7 +242,7 @@ func TestHierarchyCache_ConcurrentAccess(t *testing.T) {
 	done := make(chan bool, 10)
 	for i := 0; i < 10; i++ {
 		go func() {
-			_, _ = cache.Resolve("ws-1")
+			_, _ = cache.Resolve(context.Background(), "ws-1")
 			done <- true
 		}()
 	}
@@ -268,3 +274,91 @@ func TestHierarchyCache_ConcurrentAccess(t *testing.T) {
 	// Verify cache is still functional
 	assert.Greater(t, cache.Size(), 0)
 }
+
+func TestHierarchyCache_ResolveTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	apiClient := client.NewClient(client.Config{BaseURL: server.URL, Logger: log})
+	defer apiClient.Stop()
+
+	cache := NewHierarchyCache(apiClient, log)
+	cache.SetResolveTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := cache.Resolve(context.Background(), "ws-slow")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// A failed lookup isn't cached, so the workspace degrades to no hierarchy
+	assert.Equal(t, 0, cache.Size())
+}
+
+func TestHierarchyCache_ResolveHonorsCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	apiClient := client.NewClient(client.Config{BaseURL: server.URL, Logger: log})
+	defer apiClient.Stop()
+
+	cache := NewHierarchyCache(apiClient, log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := cache.Resolve(ctx, "ws-slow")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestHierarchyCache_ResolveProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	var requests int
+	var repoURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/api/projects/resolve", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		repoURL = body["repoUrl"]
+		json.NewEncoder(w).Encode(models.Project{ID: 7, FullName: "owner/repo"})
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	apiClient := client.NewClient(client.Config{BaseURL: server.URL, Logger: log})
+	defer apiClient.Stop()
+
+	cache := NewHierarchyCache(apiClient, log)
+	ctx, err := cache.ResolveProjectRoot(context.Background(), root)
+	require.NoError(t, err)
+	assert.Equal(t, 7, ctx.ProjectID)
+	assert.Equal(t, "owner/repo", ctx.ProjectName)
+	assert.Equal(t, 0, ctx.WorkspaceID)
+	// A folder outside Git is named by its path
+	assert.Equal(t, "file://"+root, repoURL)
+
+	// The folder is looked up once
+	_, err = cache.ResolveProjectRoot(context.Background(), root)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 0, cache.Size())
+}
@@ -0,0 +1,245 @@
+package hierarchy
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// busyTimeout is how long a write waits for another process's lock
+const busyTimeout = 5 * time.Second
+
+// DiskCache persists resolved workspace contexts in the collector's SQLite
+// state database, so repeated resolutions are served locally across runs.
+// Entries older than the TTL are treated as misses and refreshed lazily, but
+// stay available through LastKnown for resolving while the backend is down.
+// Pinned entries, set by hand with Pin, never expire and aren't replaced by
+// backend resolutions.
+type DiskCache struct {
+	db  *sql.DB
+	ttl time.Duration
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// NewDiskCache opens (or creates) the hierarchy cache table in dbPath
+func NewDiskCache(dbPath string, ttl time.Duration) (*DiskCache, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hierarchy cache database: %w", err)
+	}
+
+	// Other collector processes write the same database; wait for their
+	// locks rather than failing. The pragma is per connection, so keep one.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout.Milliseconds())); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS hierarchy_cache (
+		workspace_id TEXT PRIMARY KEY,
+		project_id INTEGER NOT NULL,
+		machine_id INTEGER NOT NULL,
+		workspace_db_id INTEGER NOT NULL,
+		project_name TEXT,
+		machine_name TEXT,
+		cached_at INTEGER NOT NULL,
+		pinned INTEGER NOT NULL DEFAULT 0
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize hierarchy cache schema: %w", err)
+	}
+
+	c := &DiskCache{db: db, ttl: ttl, now: time.Now}
+	if err := c.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate hierarchy cache schema: %w", err)
+	}
+	return c, nil
+}
+
+// migrate adds columns introduced after the initial schema to existing databases
+func (c *DiskCache) migrate() error {
+	rows, err := c.db.Query("PRAGMA table_info(hierarchy_cache)")
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !existing["pinned"] {
+		if _, err := c.db.Exec("ALTER TABLE hierarchy_cache ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to add column pinned: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the cached context for a workspace, or nil if there is none or
+// it is older than the TTL
+func (c *DiskCache) Get(workspaceID string) (*WorkspaceContext, error) {
+	ctx, cachedAt, pinned, err := c.load(workspaceID)
+	if err != nil || ctx == nil {
+		return nil, err
+	}
+
+	if !pinned && c.ttl > 0 && c.now().Sub(cachedAt) > c.ttl {
+		return nil, nil
+	}
+	return ctx, nil
+}
+
+// LastKnown returns the cached context for a workspace regardless of its age,
+// or nil if the workspace was never cached
+func (c *DiskCache) LastKnown(workspaceID string) (*WorkspaceContext, error) {
+	ctx, _, _, err := c.load(workspaceID)
+	return ctx, err
+}
+
+// Put stores the context for a workspace, resetting its age. Pinned
+// workspaces are left unchanged.
+func (c *DiskCache) Put(workspaceID string, ctx *WorkspaceContext) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.db.Exec(`
+		INSERT INTO hierarchy_cache (
+			workspace_id, project_id, machine_id, workspace_db_id,
+			project_name, machine_name, cached_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(workspace_id) DO UPDATE SET
+			project_id = excluded.project_id,
+			machine_id = excluded.machine_id,
+			workspace_db_id = excluded.workspace_db_id,
+			project_name = excluded.project_name,
+			machine_name = excluded.machine_name,
+			cached_at = excluded.cached_at
+		WHERE hierarchy_cache.pinned = 0
+	`, workspaceID, ctx.ProjectID, ctx.MachineID, ctx.WorkspaceID,
+		ctx.ProjectName, ctx.MachineName, c.now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to cache workspace %s: %w", workspaceID, err)
+	}
+	return nil
+}
+
+// Pin maps a workspace to projectID until it is unpinned, overriding what
+// the backend resolves. The cached machine and workspace IDs are kept.
+func (c *DiskCache) Pin(workspaceID string, projectID int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.db.Exec(`
+		INSERT INTO hierarchy_cache (
+			workspace_id, project_id, machine_id, workspace_db_id, cached_at, pinned
+		) VALUES (?, ?, 0, 0, ?, 1)
+		ON CONFLICT(workspace_id) DO UPDATE SET
+			project_id = excluded.project_id,
+			project_name = NULL,
+			cached_at = excluded.cached_at,
+			pinned = 1
+	`, workspaceID, projectID, c.now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to pin workspace %s: %w", workspaceID, err)
+	}
+	return nil
+}
+
+// Unpin removes a workspace's pinned mapping so it is resolved from the
+// backend again. It reports whether the workspace was pinned.
+func (c *DiskCache) Unpin(workspaceID string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, err := c.db.Exec(`DELETE FROM hierarchy_cache WHERE workspace_id = ? AND pinned = 1`, workspaceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpin workspace %s: %w", workspaceID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Pinned returns the project ID of every pinned workspace
+func (c *DiskCache) Pinned() (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rows, err := c.db.Query(`SELECT workspace_id, project_id FROM hierarchy_cache WHERE pinned = 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	pinned := make(map[string]int)
+	for rows.Next() {
+		var workspaceID string
+		var projectID int
+		if err := rows.Scan(&workspaceID, &projectID); err != nil {
+			return nil, fmt.Errorf("failed to scan pinned workspace: %w", err)
+		}
+		pinned[workspaceID] = projectID
+	}
+	return pinned, rows.Err()
+}
+
+// Close closes the database connection
+func (c *DiskCache) Close() error {
+	return c.db.Close()
+}
+
+// load reads a cached context, the time it was stored and whether it is pinned
+func (c *DiskCache) load(workspaceID string) (*WorkspaceContext, time.Time, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ctx WorkspaceContext
+	var projectName, machineName sql.NullString
+	var cachedAt int64
+	var pinned bool
+
+	err := c.db.QueryRow(`
+		SELECT project_id, machine_id, workspace_db_id, project_name, machine_name, cached_at, pinned
+		FROM hierarchy_cache
+		WHERE workspace_id = ?
+	`, workspaceID).Scan(
+		&ctx.ProjectID,
+		&ctx.MachineID,
+		&ctx.WorkspaceID,
+		&projectName,
+		&machineName,
+		&cachedAt,
+		&pinned,
+	)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, false, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("failed to load cached workspace %s: %w", workspaceID, err)
+	}
+
+	ctx.ProjectName = projectName.String
+	ctx.MachineName = machineName.String
+	return &ctx, time.Unix(cachedAt, 0), pinned, nil
+}
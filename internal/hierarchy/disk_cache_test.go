@@ -0,0 +1,256 @@
+package hierarchy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskCache(t *testing.T, ttl time.Duration) *DiskCache {
+	t.Helper()
+
+	disk, err := NewDiskCache(filepath.Join(t.TempDir(), "state.db"), ttl)
+	require.NoError(t, err)
+	t.Cleanup(func() { disk.Close() })
+	return disk
+}
+
+func TestDiskCache_HitMissExpiry(t *testing.T) {
+	disk := newTestDiskCache(t, time.Hour)
+	now := time.Unix(1730372400, 0)
+	disk.now = func() time.Time { return now }
+
+	ctx, err := disk.Get("ws-1")
+	require.NoError(t, err)
+	assert.Nil(t, ctx, "unknown workspace is a miss")
+
+	stored := &WorkspaceContext{ProjectID: 10, MachineID: 20, WorkspaceID: 1, ProjectName: "owner/repo", MachineName: "host"}
+	require.NoError(t, disk.Put("ws-1", stored))
+
+	ctx, err = disk.Get("ws-1")
+	require.NoError(t, err)
+	assert.Equal(t, stored, ctx)
+
+	// Past the TTL the entry is a miss but stays available as last-known
+	now = now.Add(2 * time.Hour)
+	ctx, err = disk.Get("ws-1")
+	require.NoError(t, err)
+	assert.Nil(t, ctx)
+
+	ctx, err = disk.LastKnown("ws-1")
+	require.NoError(t, err)
+	assert.Equal(t, stored, ctx)
+
+	// Put refreshes the entry
+	stored.ProjectID = 11
+	require.NoError(t, disk.Put("ws-1", stored))
+	ctx, err = disk.Get("ws-1")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 11, ctx.ProjectID)
+}
+
+func TestDiskCache_PersistsAcrossOpens(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	disk, err := NewDiskCache(dbPath, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, disk.Put("ws-1", &WorkspaceContext{ProjectID: 10}))
+	require.NoError(t, disk.Close())
+
+	disk, err = NewDiskCache(dbPath, time.Hour)
+	require.NoError(t, err)
+	defer disk.Close()
+
+	ctx, err := disk.Get("ws-1")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 10, ctx.ProjectID)
+}
+
+func TestDiskCache_ConcurrentWriters(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	var caches []*DiskCache
+	for i := 0; i < 2; i++ {
+		disk, err := NewDiskCache(dbPath, time.Hour)
+		require.NoError(t, err)
+		t.Cleanup(func() { disk.Close() })
+		caches = append(caches, disk)
+	}
+
+	// Two collectors resolve workspaces into the same database at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for n, disk := range caches {
+		wg.Add(1)
+		go func(n int, disk *DiskCache) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := disk.Put(fmt.Sprintf("ws-%d-%d", n, i), &WorkspaceContext{ProjectID: i}); err != nil {
+					errs <- err
+				}
+			}
+		}(n, disk)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Put failed: %v", err)
+	}
+
+	ctx, err := caches[0].Get("ws-1-49")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 49, ctx.ProjectID)
+}
+
+func TestHierarchyCache_ResolveUsesDiskCache(t *testing.T) {
+	var requests int32
+	online := int32(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&online) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(models.Workspace{
+			ID:          1,
+			ProjectID:   10,
+			MachineID:   20,
+			WorkspaceID: "ws-1",
+			Project:     &models.Project{FullName: "owner/repo"},
+			Machine:     &models.Machine{Hostname: "host"},
+		})
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	apiClient := client.NewClient(client.Config{BaseURL: server.URL, Logger: log})
+
+	disk := newTestDiskCache(t, time.Hour)
+	now := time.Now()
+	disk.now = func() time.Time { return now }
+
+	// First resolution goes to the backend and is persisted
+	cache := NewHierarchyCache(apiClient, log)
+	cache.SetDiskCache(disk)
+	ctx, err := cache.Resolve(context.Background(), "ws-1")
+	require.NoError(t, err)
+	assert.Equal(t, 10, ctx.ProjectID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// A fresh cache (new run) is served from disk
+	cache = NewHierarchyCache(apiClient, log)
+	cache.SetDiskCache(disk)
+	ctx, err = cache.Resolve(context.Background(), "ws-1")
+	require.NoError(t, err)
+	assert.Equal(t, "owner/repo", ctx.ProjectName)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Once expired and offline, the last-known mapping is used
+	now = now.Add(2 * time.Hour)
+	atomic.StoreInt32(&online, 0)
+	cache = NewHierarchyCache(apiClient, log)
+	cache.SetDiskCache(disk)
+	ctx, err = cache.Resolve(context.Background(), "ws-1")
+	require.NoError(t, err)
+	assert.Equal(t, 10, ctx.ProjectID)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Without any cached mapping, offline resolution still fails
+	_, err = cache.Resolve(context.Background(), "ws-2")
+	assert.Error(t, err)
+}
+
+func TestDiskCache_Pin(t *testing.T) {
+	disk := newTestDiskCache(t, time.Hour)
+	now := time.Unix(1730372400, 0)
+	disk.now = func() time.Time { return now }
+
+	resolved := &WorkspaceContext{ProjectID: 10, MachineID: 20, WorkspaceID: 1, ProjectName: "owner/repo"}
+	require.NoError(t, disk.Put("ws-1", resolved))
+	require.NoError(t, disk.Pin("ws-1", 42))
+
+	ctx, err := disk.Get("ws-1")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 42, ctx.ProjectID)
+	assert.Equal(t, 20, ctx.MachineID, "pinning keeps the cached machine")
+	assert.Empty(t, ctx.ProjectName, "the old project's name no longer applies")
+
+	// Backend resolutions don't replace a pin, and pins never expire
+	require.NoError(t, disk.Put("ws-1", resolved))
+	now = now.Add(48 * time.Hour)
+	ctx, err = disk.Get("ws-1")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 42, ctx.ProjectID)
+
+	// Pinning an uncached workspace
+	require.NoError(t, disk.Pin("ws-2", 7))
+	pinned, err := disk.Pinned()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"ws-1": 42, "ws-2": 7}, pinned)
+
+	removed, err := disk.Unpin("ws-1")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	ctx, err = disk.LastKnown("ws-1")
+	require.NoError(t, err)
+	assert.Nil(t, ctx, "unpinned workspaces are resolved afresh")
+
+	removed, err = disk.Unpin("ws-1")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestDiskCache_MigratesPinnedColumn(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	// A cache table created before pins existed
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+	CREATE TABLE hierarchy_cache (
+		workspace_id TEXT PRIMARY KEY,
+		project_id INTEGER NOT NULL,
+		machine_id INTEGER NOT NULL,
+		workspace_db_id INTEGER NOT NULL,
+		project_name TEXT,
+		machine_name TEXT,
+		cached_at INTEGER NOT NULL
+	);
+	INSERT INTO hierarchy_cache VALUES ('ws-1', 10, 20, 1, 'owner/repo', 'host', 1730372400);
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	disk, err := NewDiskCache(dbPath, 0)
+	require.NoError(t, err)
+	defer disk.Close()
+
+	ctx, err := disk.Get("ws-1")
+	require.NoError(t, err)
+	require.NotNil(t, ctx)
+	assert.Equal(t, 10, ctx.ProjectID)
+
+	require.NoError(t, disk.Pin("ws-1", 42))
+	pinned, err := disk.Pinned()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"ws-1": 42}, pinned)
+}
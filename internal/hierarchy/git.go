@@ -1,9 +1,12 @@
 package hierarchy
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 )
@@ -53,6 +56,65 @@ func GetGitInfo(path string) (*GitInfo, error) {
 	}, nil
 }
 
+// GetHeadInfo returns the branch and commit checked out in the repository
+// containing path. Unlike GetGitInfo it does not require an origin remote;
+// RemoteURL is left empty when none is configured and Branch is empty for a
+// detached HEAD.
+func GetHeadInfo(path string) (*GitInfo, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	info := &GitInfo{
+		Commit: head.Hash().String(),
+	}
+	if head.Name().IsBranch() {
+		info.Branch = head.Name().Short()
+	}
+
+	if remote, err := repo.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		info.RemoteURL = normalizeGitURL(remote.Config().URLs[0])
+	}
+
+	return info, nil
+}
+
+// CommitBefore returns the newest commit reachable from HEAD in the
+// repository containing path that was committed at or before t, like
+// `git rev-list -1 --before=t HEAD`. It returns "" if every commit is newer.
+func CommitBefore(path string, t time.Time) (string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime, Until: &t})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk history: %w", err)
+	}
+	defer commits.Close()
+
+	commit, err := commits.Next()
+	if errors.Is(err, io.EOF) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to walk history: %w", err)
+	}
+	return commit.Hash.String(), nil
+}
+
 // normalizeGitURL normalizes Git URLs to a consistent format
 func normalizeGitURL(url string) string {
 	// Convert SSH URLs to HTTPS format for consistency
@@ -77,6 +139,34 @@ func normalizeGitURL(url string) string {
 	return url
 }
 
+// ParseRepoOwnerName extracts the owner and repository name from a git
+// remote URL in any format normalizeGitURL accepts. For nested groups
+// (e.g. GitLab subgroups) the owner is the full group path.
+func ParseRepoOwnerName(remoteURL string) (owner, name string, ok bool) {
+	if remoteURL == "" || strings.HasPrefix(remoteURL, "file://") {
+		return "", "", false
+	}
+
+	normalized := normalizeGitURL(remoteURL)
+	normalized = strings.TrimPrefix(normalized, "https://")
+	normalized = strings.TrimPrefix(normalized, "http://")
+	normalized = strings.TrimSuffix(normalized, "/")
+
+	// Drop the host, keeping owner/.../repo
+	slash := strings.Index(normalized, "/")
+	if slash < 0 {
+		return "", "", false
+	}
+	path := normalized[slash+1:]
+
+	last := strings.LastIndex(path, "/")
+	if last <= 0 || last == len(path)-1 {
+		return "", "", false
+	}
+
+	return path[:last], path[last+1:], true
+}
+
 // FindGitRoot finds the Git repository root from a given path
 func FindGitRoot(path string) (string, error) {
 	// Try to open as-is first
@@ -0,0 +1,48 @@
+package hierarchy
+
+import (
+	"sync"
+	"time"
+)
+
+// GitCache caches HEAD lookups per workspace path for a short window so that
+// per-event enrichment doesn't reopen the repository every time
+type GitCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]gitCacheEntry
+}
+
+type gitCacheEntry struct {
+	info      *GitInfo
+	err       error
+	fetchedAt time.Time
+}
+
+// NewGitCache creates a git lookup cache with the given TTL
+func NewGitCache(ttl time.Duration) *GitCache {
+	return &GitCache{
+		ttl:     ttl,
+		entries: make(map[string]gitCacheEntry),
+	}
+}
+
+// Get returns the HEAD info for the repository containing path, reusing a
+// previous lookup (including a failed one) if it is younger than the TTL
+func (gc *GitCache) Get(path string) (*GitInfo, error) {
+	gc.mu.Lock()
+	entry, ok := gc.entries[path]
+	gc.mu.Unlock()
+
+	if ok && time.Since(entry.fetchedAt) < gc.ttl {
+		return entry.info, entry.err
+	}
+
+	info, err := GetHeadInfo(path)
+
+	gc.mu.Lock()
+	gc.entries[path] = gitCacheEntry{info: info, err: err, fetchedAt: time.Now()}
+	gc.mu.Unlock()
+
+	return info, err
+}
@@ -68,3 +68,30 @@ func TestGetGitInfo(t *testing.T) {
 	// Verify URL normalization
 	assert.Contains(t, info.RemoteURL, "http", "URL should be normalized to HTTP(S)")
 }
+
+func TestParseRepoOwnerName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		owner string
+		repo  string
+		ok    bool
+	}{
+		{"SSH format", "git@github.com:codervisor/devlog.git", "codervisor", "devlog", true},
+		{"HTTPS with .git", "https://github.com/codervisor/devlog.git", "codervisor", "devlog", true},
+		{"Nested groups", "https://gitlab.com/group/subgroup/project", "group/subgroup", "project", true},
+		{"Trailing slash", "https://github.com/codervisor/devlog/", "codervisor", "devlog", true},
+		{"Local path", "file:///home/user/project", "", "", false},
+		{"Host only", "https://github.com", "", "", false},
+		{"Empty", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, ok := ParseRepoOwnerName(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.owner, owner)
+			assert.Equal(t, tt.repo, repo)
+		})
+	}
+}
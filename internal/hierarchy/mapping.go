@@ -0,0 +1,95 @@
+package hierarchy
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkspaceMapping describes the project folder and Git repository a VS Code
+// workspace storage directory resolves to on this machine
+type WorkspaceMapping struct {
+	WorkspaceID   string `json:"workspaceId"`
+	WorkspaceType string `json:"workspaceType,omitempty"`
+	Path          string `json:"path,omitempty"`
+	StoragePath   string `json:"storagePath"`
+	RemoteURL     string `json:"remoteUrl,omitempty"`
+	RepoOwner     string `json:"repoOwner,omitempty"`
+	RepoName      string `json:"repoName,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+
+	// PinnedProjectID is the project pinned with DiskCache.Pin, if any
+	PinnedProjectID int `json:"pinnedProjectId,omitempty"`
+
+	// Error explains why the workspace couldn't be resolved
+	Error string `json:"error,omitempty"`
+}
+
+// Project returns the best human-readable project name of the mapping:
+// owner/repo, or else the folder name
+func (m *WorkspaceMapping) Project() string {
+	if m.RepoName != "" {
+		return m.RepoOwner + "/" + m.RepoName
+	}
+	if m.Path != "" {
+		return filepath.Base(m.Path)
+	}
+	return ""
+}
+
+// ListWorkspaceMappings resolves every VS Code and Cursor workspace on this
+// machine to its project folder and Git remote without contacting the
+// backend. Workspaces that can't be resolved are listed with Error set.
+func ListWorkspaceMappings(log *logrus.Logger) ([]*WorkspaceMapping, error) {
+	if log == nil {
+		log = logrus.New()
+	}
+	wd := &WorkspaceDiscovery{log: log}
+
+	storageDirs, err := wd.findVSCodeWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	return mapWorkspaces(storageDirs), nil
+}
+
+// mapWorkspaces resolves workspace storage directories, sorted by project
+// path and then workspace ID
+func mapWorkspaces(storageDirs []string) []*WorkspaceMapping {
+	mappings := make([]*WorkspaceMapping, 0, len(storageDirs))
+	for _, dir := range storageDirs {
+		mapping := &WorkspaceMapping{
+			WorkspaceID: filepath.Base(dir),
+			StoragePath: dir,
+		}
+		mappings = append(mappings, mapping)
+
+		path, wsType, err := ResolveWorkspace(dir)
+		if err != nil {
+			mapping.Error = err.Error()
+			continue
+		}
+		mapping.Path = path
+		mapping.WorkspaceType = wsType
+
+		// Non-Git folders are still valid workspaces
+		gitInfo, err := GetGitInfo(path)
+		if err != nil {
+			continue
+		}
+		mapping.RemoteURL = gitInfo.RemoteURL
+		mapping.Branch = gitInfo.Branch
+		if owner, name, ok := ParseRepoOwnerName(gitInfo.RemoteURL); ok {
+			mapping.RepoOwner, mapping.RepoName = owner, name
+		}
+	}
+
+	sort.SliceStable(mappings, func(i, j int) bool {
+		if mappings[i].Path != mappings[j].Path {
+			return mappings[i].Path < mappings[j].Path
+		}
+		return mappings[i].WorkspaceID < mappings[j].WorkspaceID
+	})
+	return mappings
+}
@@ -0,0 +1,121 @@
+package hierarchy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVSCodeStoragePaths(t *testing.T) {
+	home := filepath.Join("home", "dev")
+	appData := filepath.Join("C:", "Users", "dev", "AppData", "Roaming")
+
+	tests := []struct {
+		goos string
+		want []string
+	}{
+		{"darwin", []string{
+			filepath.Join(home, "Library", "Application Support", "Code", "User", "workspaceStorage"),
+			filepath.Join(home, "Library", "Application Support", "Code - Insiders", "User", "workspaceStorage"),
+			filepath.Join(home, "Library", "Application Support", "Cursor", "User", "workspaceStorage"),
+		}},
+		{"linux", []string{
+			filepath.Join(home, ".config", "Code", "User", "workspaceStorage"),
+			filepath.Join(home, ".config", "Code - Insiders", "User", "workspaceStorage"),
+			filepath.Join(home, ".config", "Cursor", "User", "workspaceStorage"),
+		}},
+		{"windows", []string{
+			filepath.Join(appData, "Code", "User", "workspaceStorage"),
+			filepath.Join(appData, "Code - Insiders", "User", "workspaceStorage"),
+			filepath.Join(appData, "Cursor", "User", "workspaceStorage"),
+		}},
+		{"plan9", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			assert.Equal(t, tt.want, vscodeStoragePaths(tt.goos, home, appData))
+		})
+	}
+
+	// Without a home or %APPDATA% directory there is nothing to search
+	assert.Empty(t, vscodeStoragePaths("linux", "", appData))
+	assert.Empty(t, vscodeStoragePaths("windows", home, ""))
+}
+
+// writeWorkspaceStorage writes workspaceStorage/{id}/workspace.json under
+// root and returns the storage directory
+func writeWorkspaceStorage(t *testing.T, root, id string, storage VSCodeStorage) string {
+	t.Helper()
+
+	dir := filepath.Join(root, "workspaceStorage", id)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	if storage != (VSCodeStorage{}) {
+		data, err := json.Marshal(storage)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "workspace.json"), data, 0644))
+	}
+	return dir
+}
+
+func TestMapWorkspaces(t *testing.T) {
+	root := t.TempDir()
+
+	// A Git repository with an origin remote
+	repoDir := filepath.Join(root, "devlog")
+	repo, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:codervisor/devlog.git"},
+	})
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("hello"), 0644))
+	_, err = wt.Add("README.md")
+	require.NoError(t, err)
+	_, err = wt.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	plainDir := filepath.Join(root, "notes")
+	require.NoError(t, os.MkdirAll(plainDir, 0755))
+
+	storageDirs := []string{
+		writeWorkspaceStorage(t, root, "ws-notes", VSCodeStorage{Folder: "file://" + plainDir}),
+		writeWorkspaceStorage(t, root, "ws-repo", VSCodeStorage{Folder: "file://" + repoDir}),
+		writeWorkspaceStorage(t, root, "ws-empty", VSCodeStorage{}),
+	}
+
+	mappings := mapWorkspaces(storageDirs)
+	require.Len(t, mappings, 3)
+
+	// Unresolvable workspaces sort first (empty path) and carry the error
+	assert.Equal(t, "ws-empty", mappings[0].WorkspaceID)
+	assert.NotEmpty(t, mappings[0].Error)
+	assert.Empty(t, mappings[0].Project())
+
+	repoMapping := mappings[1]
+	assert.Equal(t, "ws-repo", repoMapping.WorkspaceID)
+	assert.Equal(t, repoDir, repoMapping.Path)
+	assert.Equal(t, WorkspaceTypeFolder, repoMapping.WorkspaceType)
+	assert.Equal(t, "https://github.com/codervisor/devlog", repoMapping.RemoteURL)
+	assert.Equal(t, "codervisor/devlog", repoMapping.Project())
+	assert.NotEmpty(t, repoMapping.Branch)
+
+	notesMapping := mappings[2]
+	assert.Equal(t, "ws-notes", notesMapping.WorkspaceID)
+	assert.Empty(t, notesMapping.RemoteURL, "non-Git folders have no repository")
+	assert.Equal(t, "notes", notesMapping.Project())
+	assert.Empty(t, notesMapping.Error)
+}
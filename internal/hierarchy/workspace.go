@@ -1,8 +1,11 @@
 package hierarchy
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -10,6 +13,7 @@ import (
 
 	"github.com/codervisor/devlog/internal/client"
 	"github.com/codervisor/devlog/pkg/models"
+	"github.com/go-git/go-git/v5"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,10 +26,16 @@ type WorkspaceDiscovery struct {
 
 // VSCodeStorage represents the VS Code storage.json structure
 type VSCodeStorage struct {
-	Folder string `json:"folder,omitempty"`
-	// Other fields can be added as needed
+	Folder    string `json:"folder,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
 }
 
+// Workspace types recorded on models.Workspace
+const (
+	WorkspaceTypeFolder    = "folder"
+	WorkspaceTypeMultiRoot = "multi-root"
+)
+
 // NewWorkspaceDiscovery creates a new workspace discovery service
 func NewWorkspaceDiscovery(client *client.Client, machineID int, log *logrus.Logger) *WorkspaceDiscovery {
 	if log == nil {
@@ -38,8 +48,9 @@ func NewWorkspaceDiscovery(client *client.Client, machineID int, log *logrus.Log
 	}
 }
 
-// DiscoverAll discovers all VS Code workspaces
-func (wd *WorkspaceDiscovery) DiscoverAll() ([]*models.Workspace, error) {
+// DiscoverAll discovers all VS Code workspaces, resolving their projects
+// within ctx
+func (wd *WorkspaceDiscovery) DiscoverAll(ctx context.Context) ([]*models.Workspace, error) {
 	// Find all VS Code workspace storage directories
 	workspacePaths, err := wd.findVSCodeWorkspaces()
 	if err != nil {
@@ -50,7 +61,7 @@ func (wd *WorkspaceDiscovery) DiscoverAll() ([]*models.Workspace, error) {
 
 	var workspaces []*models.Workspace
 	for _, path := range workspacePaths {
-		ws, err := wd.processWorkspace(path)
+		ws, err := wd.processWorkspace(ctx, path)
 		if err != nil {
 			wd.log.Warnf("Failed to process workspace %s: %v", path, err)
 			continue
@@ -65,12 +76,12 @@ func (wd *WorkspaceDiscovery) DiscoverAll() ([]*models.Workspace, error) {
 }
 
 // processWorkspace processes a single workspace directory
-func (wd *WorkspaceDiscovery) processWorkspace(workspaceStoragePath string) (*models.Workspace, error) {
+func (wd *WorkspaceDiscovery) processWorkspace(ctx context.Context, workspaceStoragePath string) (*models.Workspace, error) {
 	// Extract workspace ID from directory name
 	workspaceID := filepath.Base(workspaceStoragePath)
 
 	// Find actual project path from storage.json
-	projectPath, err := wd.resolveProjectPath(workspaceStoragePath)
+	projectPath, workspaceType, err := ResolveWorkspace(workspaceStoragePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve project path: %w", err)
 	}
@@ -93,7 +104,7 @@ func (wd *WorkspaceDiscovery) processWorkspace(workspaceStoragePath string) (*mo
 	}
 
 	// Resolve project from Git remote
-	project, err := wd.client.ResolveProject(gitInfo.RemoteURL)
+	project, err := wd.client.ResolveProject(ctx, gitInfo.RemoteURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve project: %w", err)
 	}
@@ -104,7 +115,7 @@ func (wd *WorkspaceDiscovery) processWorkspace(workspaceStoragePath string) (*mo
 		MachineID:     wd.machineID,
 		WorkspaceID:   workspaceID,
 		WorkspacePath: projectPath,
-		WorkspaceType: "folder",
+		WorkspaceType: workspaceType,
 		Branch:        gitInfo.Branch,
 		Commit:        gitInfo.Commit,
 	}
@@ -124,33 +135,41 @@ func (wd *WorkspaceDiscovery) processWorkspace(workspaceStoragePath string) (*mo
 	return registered, nil
 }
 
-// resolveProjectPath resolves the actual project path from VS Code storage
-func (wd *WorkspaceDiscovery) resolveProjectPath(workspaceStoragePath string) (string, error) {
+// ResolveWorkspacePath resolves the actual project path from a VS Code
+// workspaceStorage/{workspace-id} directory
+func ResolveWorkspacePath(workspaceStoragePath string) (string, error) {
+	path, _, err := ResolveWorkspace(workspaceStoragePath)
+	return path, err
+}
+
+// ResolveWorkspace resolves the project path and workspace type from a VS Code
+// workspaceStorage/{workspace-id} directory. Multi-root workspaces resolve to
+// the first member folder that is a Git root.
+func ResolveWorkspace(workspaceStoragePath string) (string, string, error) {
 	storageFile := filepath.Join(workspaceStoragePath, "workspace.json")
 
 	// Try workspace.json first
 	if _, err := os.Stat(storageFile); err == nil {
 		data, err := os.ReadFile(storageFile)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 
 		var storage VSCodeStorage
 		if err := json.Unmarshal(data, &storage); err != nil {
-			return "", err
+			return "", "", err
 		}
 
 		if storage.Folder != "" {
-			// Parse URI format: file:///path/to/folder
-			folder := storage.Folder
-			if strings.HasPrefix(folder, "file://") {
-				folder = strings.TrimPrefix(folder, "file://")
-				// On Windows, remove the leading slash if present
-				if runtime.GOOS == "windows" && strings.HasPrefix(folder, "/") {
-					folder = folder[1:]
-				}
+			return uriToPath(storage.Folder), WorkspaceTypeFolder, nil
+		}
+
+		if storage.Workspace != "" {
+			path, err := resolveMultiRoot(uriToPath(storage.Workspace))
+			if err != nil {
+				return "", "", err
 			}
-			return folder, nil
+			return path, WorkspaceTypeMultiRoot, nil
 		}
 	}
 
@@ -160,10 +179,125 @@ func (wd *WorkspaceDiscovery) resolveProjectPath(workspaceStoragePath string) (s
 		// Parse meta.json which might contain path hints
 		// This is a simplified approach - you might need to enhance this
 		// based on actual VS Code storage format
-		return "", fmt.Errorf("workspace path resolution from meta.json not implemented")
+		return "", "", fmt.Errorf("workspace path resolution from meta.json not implemented")
+	}
+
+	return "", "", fmt.Errorf("could not resolve workspace path from %s", workspaceStoragePath)
+}
+
+// codeWorkspace represents the folders of a .code-workspace file
+type codeWorkspace struct {
+	Folders []struct {
+		Path string `json:"path,omitempty"`
+		URI  string `json:"uri,omitempty"`
+	} `json:"folders"`
+}
+
+// resolveMultiRoot reads a .code-workspace file and picks the member folder
+// to attribute the workspace to: the first Git root, or else the first folder
+func resolveMultiRoot(workspaceFile string) (string, error) {
+	data, err := os.ReadFile(workspaceFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read workspace file: %w", err)
+	}
+
+	var ws codeWorkspace
+	if err := json.Unmarshal(stripJSONC(data), &ws); err != nil {
+		return "", fmt.Errorf("failed to parse workspace file %s: %w", workspaceFile, err)
+	}
+
+	// Relative folder paths are relative to the workspace file
+	baseDir := filepath.Dir(workspaceFile)
+	var folders []string
+	for _, f := range ws.Folders {
+		var folder string
+		switch {
+		case f.Path != "":
+			folder = filepath.FromSlash(f.Path)
+			if !filepath.IsAbs(folder) {
+				folder = filepath.Join(baseDir, folder)
+			}
+		case strings.HasPrefix(f.URI, "file://"):
+			folder = uriToPath(f.URI)
+		default:
+			continue
+		}
+		folders = append(folders, filepath.Clean(folder))
+	}
+
+	if len(folders) == 0 {
+		return "", fmt.Errorf("workspace file %s has no local folders", workspaceFile)
+	}
+
+	for _, folder := range folders {
+		if _, err := git.PlainOpen(folder); err == nil {
+			return folder, nil
+		}
+	}
+	return folders[0], nil
+}
+
+// uriToPath converts a file:// URI to a local path
+func uriToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	path := strings.TrimPrefix(uri, "file://")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	// On Windows, remove the leading slash if present
+	if runtime.GOOS == "windows" && strings.HasPrefix(path, "/") {
+		path = path[1:]
 	}
+	return path
+}
+
+// stripJSONC removes comments and trailing commas, which VS Code allows in
+// .code-workspace files but encoding/json does not
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
 
-	return "", fmt.Errorf("could not resolve workspace path from %s", workspaceStoragePath)
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
 }
 
 // findVSCodeWorkspaces finds all VS Code workspace storage directories
@@ -172,30 +306,26 @@ func (wd *WorkspaceDiscovery) findVSCodeWorkspaces() ([]string, error) {
 
 	var workspaces []string
 	for _, base := range basePaths {
-		// Expand home directory
-		if strings.HasPrefix(base, "~") {
-			home, err := os.UserHomeDir()
-			if err != nil {
+		// Non-default profiles keep their own workspaceStorage
+		storagePaths := append([]string{base}, profileStoragePaths(base)...)
+
+		for _, storagePath := range storagePaths {
+			// Check if the storage path exists
+			if _, err := os.Stat(storagePath); os.IsNotExist(err) {
 				continue
 			}
-			base = filepath.Join(home, base[1:])
-		}
 
-		// Check if the base path exists
-		if _, err := os.Stat(base); os.IsNotExist(err) {
-			continue
-		}
-
-		// List all directories in the workspace storage
-		entries, err := os.ReadDir(base)
-		if err != nil {
-			wd.log.Warnf("Failed to read directory %s: %v", base, err)
-			continue
-		}
+			// List all directories in the workspace storage
+			entries, err := os.ReadDir(storagePath)
+			if err != nil {
+				wd.log.Warnf("Failed to read directory %s: %v", storagePath, err)
+				continue
+			}
 
-		for _, entry := range entries {
-			if entry.IsDir() {
-				workspaces = append(workspaces, filepath.Join(base, entry.Name()))
+			for _, entry := range entries {
+				if entry.IsDir() {
+					workspaces = append(workspaces, filepath.Join(storagePath, entry.Name()))
+				}
 			}
 		}
 	}
@@ -203,28 +333,57 @@ func (wd *WorkspaceDiscovery) findVSCodeWorkspaces() ([]string, error) {
 	return workspaces, nil
 }
 
+// profileStoragePaths returns the workspaceStorage directories of VS Code
+// profiles (User/profiles/*/workspaceStorage) next to a default storage path
+func profileStoragePaths(storagePath string) []string {
+	userDir := filepath.Dir(storagePath)
+	matches, err := filepath.Glob(filepath.Join(userDir, "profiles", "*", "workspaceStorage"))
+	if err != nil {
+		return nil
+	}
+	return matches
+}
+
 // getVSCodeStoragePaths returns platform-specific VS Code storage paths
 func (wd *WorkspaceDiscovery) getVSCodeStoragePaths() []string {
-	switch runtime.GOOS {
+	home, _ := os.UserHomeDir()
+	return vscodeStoragePaths(runtime.GOOS, home, os.Getenv("APPDATA"))
+}
+
+// vscodeStoragePaths returns the workspaceStorage directories of VS Code,
+// VS Code Insiders and Cursor on goos, under the user's home directory or,
+// on Windows, %APPDATA%
+func vscodeStoragePaths(goos, home, appData string) []string {
+	var userDir func(app string) string
+	switch goos {
 	case "darwin":
-		return []string{
-			"~/Library/Application Support/Code/User/workspaceStorage",
-			"~/Library/Application Support/Code - Insiders/User/workspaceStorage",
-			"~/Library/Application Support/Cursor/User/workspaceStorage",
+		if home == "" {
+			return nil
+		}
+		userDir = func(app string) string {
+			return filepath.Join(home, "Library", "Application Support", app, "User")
 		}
 	case "linux":
-		return []string{
-			"~/.config/Code/User/workspaceStorage",
-			"~/.config/Code - Insiders/User/workspaceStorage",
-			"~/.config/Cursor/User/workspaceStorage",
+		if home == "" {
+			return nil
+		}
+		userDir = func(app string) string {
+			return filepath.Join(home, ".config", app, "User")
 		}
 	case "windows":
-		return []string{
-			"%APPDATA%/Code/User/workspaceStorage",
-			"%APPDATA%/Code - Insiders/User/workspaceStorage",
-			"%APPDATA%/Cursor/User/workspaceStorage",
+		if appData == "" {
+			return nil
+		}
+		userDir = func(app string) string {
+			return filepath.Join(appData, app, "User")
 		}
 	default:
-		return []string{}
+		return nil
+	}
+
+	var paths []string
+	for _, app := range []string{"Code", "Code - Insiders", "Cursor"} {
+		paths = append(paths, filepath.Join(userDir(app), "workspaceStorage"))
 	}
+	return paths
 }
@@ -0,0 +1,110 @@
+package hierarchy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeStorage writes a workspaceStorage/{id}/workspace.json and returns the
+// storage directory
+func writeStorage(t *testing.T, root string, storage VSCodeStorage) string {
+	t.Helper()
+
+	storageDir := filepath.Join(root, "workspaceStorage", "ws-1")
+	require.NoError(t, os.MkdirAll(storageDir, 0755))
+
+	data, err := json.Marshal(storage)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "workspace.json"), data, 0644))
+	return storageDir
+}
+
+func TestResolveWorkspace_Folder(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "project")
+	storageDir := writeStorage(t, root, VSCodeStorage{Folder: "file://" + projectDir})
+
+	path, wsType, err := ResolveWorkspace(storageDir)
+	require.NoError(t, err)
+	assert.Equal(t, projectDir, path)
+	assert.Equal(t, WorkspaceTypeFolder, wsType)
+}
+
+func TestResolveWorkspace_MultiRoot(t *testing.T) {
+	root := t.TempDir()
+
+	docsDir := filepath.Join(root, "docs")
+	repoDir := filepath.Join(root, "src", "app")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+	_, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+
+	// VS Code writes JSON with comments and trailing commas
+	workspaceFile := filepath.Join(root, "team.code-workspace")
+	content := `{
+	// Member folders
+	"folders": [
+		{ "path": "docs" },
+		{ "path": "src/app" }, /* the repository */
+	],
+	"settings": { "editor.tabSize": 2, },
+}`
+	require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+
+	storageDir := writeStorage(t, root, VSCodeStorage{Workspace: "file://" + workspaceFile})
+
+	path, wsType, err := ResolveWorkspace(storageDir)
+	require.NoError(t, err)
+	assert.Equal(t, repoDir, path)
+	assert.Equal(t, WorkspaceTypeMultiRoot, wsType)
+
+	resolved, err := ResolveWorkspacePath(storageDir)
+	require.NoError(t, err)
+	assert.Equal(t, repoDir, resolved)
+}
+
+func TestResolveWorkspace_MultiRootWithoutGit(t *testing.T) {
+	root := t.TempDir()
+	otherDir := filepath.Join(t.TempDir(), "other")
+
+	workspaceFile := filepath.Join(root, "plain.code-workspace")
+	content := `{"folders": [{"uri": "file://` + otherDir + `"}, {"path": "local"}]}`
+	require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+
+	storageDir := writeStorage(t, root, VSCodeStorage{Workspace: "file://" + workspaceFile})
+
+	path, wsType, err := ResolveWorkspace(storageDir)
+	require.NoError(t, err)
+	assert.Equal(t, otherDir, path, "falls back to the first folder")
+	assert.Equal(t, WorkspaceTypeMultiRoot, wsType)
+}
+
+func TestResolveWorkspace_MultiRootErrors(t *testing.T) {
+	root := t.TempDir()
+
+	missing := writeStorage(t, root, VSCodeStorage{Workspace: "file://" + filepath.Join(root, "missing.code-workspace")})
+	_, _, err := ResolveWorkspace(missing)
+	assert.Error(t, err)
+
+	empty := filepath.Join(root, "empty.code-workspace")
+	require.NoError(t, os.WriteFile(empty, []byte(`{"folders": []}`), 0644))
+	storageDir := writeStorage(t, root, VSCodeStorage{Workspace: "file://" + empty})
+	_, _, err = ResolveWorkspace(storageDir)
+	assert.Error(t, err)
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := `{"url": "http://example.com/*x*/", // comment
+	"list": [1, 2,],}`
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(stripJSONC([]byte(input)), &out))
+	assert.Equal(t, "http://example.com/*x*/", out["url"])
+	assert.Len(t, out["list"], 2)
+}
@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -20,6 +21,32 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// copilotSession returns a Copilot chat session with one answered request per
+// prompt, a second apart
+func copilotSession(t *testing.T, prompts ...string) []byte {
+	t.Helper()
+
+	start := time.Date(2025, 10, 30, 10, 0, 0, 0, time.UTC)
+	session := adapters.CopilotChatSession{Version: 3}
+	for i, prompt := range prompts {
+		response, _ := json.Marshal("Answer to " + prompt)
+		session.Requests = append(session.Requests, adapters.CopilotRequest{
+			RequestID:  fmt.Sprintf("req-%d", i+1),
+			ResponseID: fmt.Sprintf("resp-%d", i+1),
+			Timestamp:  start.Add(time.Duration(i) * time.Second).UnixMilli(),
+			ModelID:    "gpt-4",
+			Message:    adapters.CopilotMessage{Text: prompt},
+			Response:   []adapters.CopilotResponseItem{{Value: response}},
+		})
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatalf("failed to marshal chat session: %v", err)
+	}
+	return data
+}
+
 // TestEndToEnd_CopilotLogParsing tests the complete flow from log file to backend
 func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 	// Create temporary directories
@@ -36,26 +63,15 @@ func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 	var mu sync.Mutex
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/v1/agent/events/batch" {
-			var body map[string]interface{}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if r.URL.Path == client.DefaultIngestPath {
+			var events []*types.AgentEvent
+			if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			events, ok := body["events"].([]interface{})
-			if !ok {
-				http.Error(w, "invalid events", http.StatusBadRequest)
-				return
-			}
-
 			mu.Lock()
-			for _, e := range events {
-				eventJSON, _ := json.Marshal(e)
-				var event types.AgentEvent
-				json.Unmarshal(eventJSON, &event)
-				receivedEvents = append(receivedEvents, &event)
-			}
+			receivedEvents = append(receivedEvents, events...)
 			mu.Unlock()
 
 			w.WriteHeader(http.StatusOK)
@@ -83,11 +99,11 @@ func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 	defer buf.Close()
 
 	clientConfig := client.Config{
-		BaseURL:    server.URL,
-		APIKey:     "test-key",
-		BatchSize:  2,
-		BatchDelay: 500 * time.Millisecond,
-		Logger:     log,
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		BatchSize:   2,
+		Logger:      log,
+		ManualFlush: true,
 	}
 	apiClient := client.NewClient(clientConfig)
 	apiClient.Start()
@@ -110,12 +126,10 @@ func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 	}
 
 	// Create log file BEFORE watching (so it gets parsed on initial scan)
-	logFile := filepath.Join(logDir, "copilot.log")
-	logContent := `{"timestamp":"2025-10-30T10:00:00Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-1","model":"gpt-4","prompt":"function add","completion":"function add(a, b) { return a + b; }","promptLen":12,"completionLen":35,"tokensUsed":47,"durationMs":250,"filePath":"test.js","language":"javascript"}
-{"timestamp":"2025-10-30T10:00:01Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-2","model":"gpt-4","prompt":"const x","completion":"const x = 10;","promptLen":7,"completionLen":14,"tokensUsed":21,"durationMs":150,"filePath":"test.js","language":"javascript"}
-`
+	logFile := filepath.Join(logDir, "session.json")
+	logContent := copilotSession(t, "function add", "const x")
 
-	if err := os.WriteFile(logFile, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFile, logContent, 0644); err != nil {
 		t.Fatalf("failed to write log file: %v", err)
 	}
 
@@ -155,16 +169,19 @@ func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 		}
 	}()
 
-	// Wait for events to be processed
-	time.Sleep(2 * time.Second)
+	// Deliver the queued events
+	if err := apiClient.FlushSync(); err != nil {
+		t.Fatalf("failed to flush events: %v", err)
+	}
 
 	// Verify events were received by backend
 	mu.Lock()
 	eventCount := len(receivedEvents)
 	mu.Unlock()
 
-	if eventCount != 2 {
-		t.Errorf("expected 2 events received, got %d", eventCount)
+	// A request and a response per turn
+	if eventCount != 4 {
+		t.Errorf("expected 4 events received, got %d", eventCount)
 	}
 
 	// Verify event content
@@ -177,12 +194,12 @@ func TestEndToEnd_CopilotLogParsing(t *testing.T) {
 			t.Errorf("expected agent ID 'github-copilot', got %s", firstEvent.AgentID)
 		}
 
-		if firstEvent.Type != types.EventTypeLLMResponse {
-			t.Errorf("expected type %s, got %s", types.EventTypeLLMResponse, firstEvent.Type)
+		if firstEvent.Type != types.EventTypeLLMRequest {
+			t.Errorf("expected type %s, got %s", types.EventTypeLLMRequest, firstEvent.Type)
 		}
 
-		if firstEvent.Data["filePath"] != "test.js" {
-			t.Errorf("expected filePath 'test.js', got %v", firstEvent.Data["filePath"])
+		if firstEvent.Data["prompt"] != "function add" {
+			t.Errorf("expected prompt 'function add', got %v", firstEvent.Data["prompt"])
 		}
 	}
 }
@@ -208,24 +225,16 @@ func TestEndToEnd_OfflineBuffering(t *testing.T) {
 			return
 		}
 
-		if r.URL.Path == "/api/v1/agent/events/batch" {
-			var body map[string]interface{}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if r.URL.Path == client.DefaultIngestPath {
+			var events []*types.AgentEvent
+			if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			events, ok := body["events"].([]interface{})
-			if ok {
-				mu.Lock()
-				for _, e := range events {
-					eventJSON, _ := json.Marshal(e)
-					var event types.AgentEvent
-					json.Unmarshal(eventJSON, &event)
-					receivedEvents = append(receivedEvents, &event)
-				}
-				mu.Unlock()
-			}
+			mu.Lock()
+			receivedEvents = append(receivedEvents, events...)
+			mu.Unlock()
 
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -252,12 +261,12 @@ func TestEndToEnd_OfflineBuffering(t *testing.T) {
 	defer buf.Close()
 
 	clientConfig := client.Config{
-		BaseURL:    server.URL,
-		APIKey:     "test-key",
-		BatchSize:  10,
-		BatchDelay: 500 * time.Millisecond,
-		MaxRetries: 1, // Fail fast
-		Logger:     log,
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		BatchSize:   10,
+		MaxRetries:  1, // Fail fast
+		Logger:      log,
+		ManualFlush: true,
 	}
 	apiClient := client.NewClient(clientConfig)
 	apiClient.Start()
@@ -302,12 +311,10 @@ func TestEndToEnd_OfflineBuffering(t *testing.T) {
 	}()
 
 	// Write log events while backend is down
-	logFile := filepath.Join(logDir, "copilot.log")
-	logContent := `{"timestamp":"2025-10-30T10:00:00Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-1","model":"gpt-4","completion":"test1","tokensUsed":10}
-{"timestamp":"2025-10-30T10:00:01Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-2","model":"gpt-4","completion":"test2","tokensUsed":10}
-`
+	logFile := filepath.Join(logDir, "session.json")
+	logContent := copilotSession(t, "test1", "test2")
 
-	if err := os.WriteFile(logFile, []byte(logContent), 0644); err != nil {
+	if err := os.WriteFile(logFile, logContent, 0644); err != nil {
 		t.Fatalf("failed to write log file: %v", err)
 	}
 
@@ -321,15 +328,12 @@ func TestEndToEnd_OfflineBuffering(t *testing.T) {
 	for _, event := range events {
 		if err := apiClient.SendSingleEvent(event); err != nil {
 			// Buffer on failure
-			if err := buf.Store(event); err != nil {
+			if _, err := buf.Store(event); err != nil {
 				t.Fatalf("failed to buffer event: %v", err)
 			}
 		}
 	}
 
-	// Wait for processing and buffering
-	time.Sleep(1 * time.Second)
-
 	// Verify events are buffered
 	bufferedCount, err := buf.Count()
 	if err != nil {
@@ -361,8 +365,10 @@ func TestEndToEnd_OfflineBuffering(t *testing.T) {
 		}
 	}
 
-	// Wait for batch to be sent
-	time.Sleep(1 * time.Second)
+	// Deliver the queued events
+	if err := apiClient.FlushSync(); err != nil {
+		t.Fatalf("failed to flush events: %v", err)
+	}
 
 	// Delete sent events from buffer
 	if len(sentIDs) > 0 {
@@ -402,19 +408,16 @@ func TestEndToEnd_LogRotation(t *testing.T) {
 	var mu sync.Mutex
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/v1/agent/events/batch" {
-			var body map[string]interface{}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if r.URL.Path == client.DefaultIngestPath {
+			var events []*types.AgentEvent
+			if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			events, ok := body["events"].([]interface{})
-			if ok {
-				mu.Lock()
-				eventCount += len(events)
-				mu.Unlock()
-			}
+			mu.Lock()
+			eventCount += len(events)
+			mu.Unlock()
 
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -430,11 +433,11 @@ func TestEndToEnd_LogRotation(t *testing.T) {
 	log.SetLevel(logrus.WarnLevel)
 
 	clientConfig := client.Config{
-		BaseURL:    server.URL,
-		APIKey:     "test-key",
-		BatchSize:  10,
-		BatchDelay: 300 * time.Millisecond,
-		Logger:     log,
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		BatchSize:   10,
+		Logger:      log,
+		ManualFlush: true,
 	}
 	apiClient := client.NewClient(clientConfig)
 	apiClient.Start()
@@ -475,10 +478,9 @@ func TestEndToEnd_LogRotation(t *testing.T) {
 	}()
 
 	// Write initial log file
-	logFile := filepath.Join(logDir, "copilot.log")
-	logContent1 := `{"timestamp":"2025-10-30T10:00:00Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-1","model":"gpt-4","completion":"before rotation","tokensUsed":10}
-`
-	if err := os.WriteFile(logFile, []byte(logContent1), 0644); err != nil {
+	logFile := filepath.Join(logDir, "session.json")
+	logContent1 := copilotSession(t, "before rotation")
+	if err := os.WriteFile(logFile, logContent1, 0644); err != nil {
 		t.Fatalf("failed to write initial log file: %v", err)
 	}
 
@@ -488,18 +490,19 @@ func TestEndToEnd_LogRotation(t *testing.T) {
 		apiClient.SendEvent(event)
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	if err := apiClient.FlushSync(); err != nil {
+		t.Fatalf("failed to flush events: %v", err)
+	}
 
 	// Simulate log rotation: rename old file, create new file
-	rotatedFile := filepath.Join(logDir, "copilot.log.1")
+	rotatedFile := filepath.Join(logDir, "session.json.1")
 	if err := os.Rename(logFile, rotatedFile); err != nil {
 		t.Fatalf("failed to rotate log file: %v", err)
 	}
 
 	// Write to new log file
-	logContent2 := `{"timestamp":"2025-10-30T10:00:02Z","level":"info","message":"completion accepted","source":"copilot","requestId":"req-2","model":"gpt-4","completion":"after rotation","tokensUsed":10}
-`
-	if err := os.WriteFile(logFile, []byte(logContent2), 0644); err != nil {
+	logContent2 := copilotSession(t, "after rotation")
+	if err := os.WriteFile(logFile, logContent2, 0644); err != nil {
 		t.Fatalf("failed to write new log file: %v", err)
 	}
 
@@ -509,8 +512,10 @@ func TestEndToEnd_LogRotation(t *testing.T) {
 		apiClient.SendEvent(event)
 	}
 
-	// Wait for processing
-	time.Sleep(1 * time.Second)
+	// Deliver the queued events
+	if err := apiClient.FlushSync(); err != nil {
+		t.Fatalf("failed to flush events: %v", err)
+	}
 
 	// Verify events from both files were processed
 	mu.Lock()
@@ -541,19 +546,16 @@ func TestEndToEnd_HighVolume(t *testing.T) {
 	var mu sync.Mutex
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/v1/agent/events/batch" {
-			var body map[string]interface{}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if r.URL.Path == client.DefaultIngestPath {
+			var events []*types.AgentEvent
+			if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			events, ok := body["events"].([]interface{})
-			if ok {
-				mu.Lock()
-				eventCount += len(events)
-				mu.Unlock()
-			}
+			mu.Lock()
+			eventCount += len(events)
+			mu.Unlock()
 
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -569,11 +571,11 @@ func TestEndToEnd_HighVolume(t *testing.T) {
 	log.SetLevel(logrus.ErrorLevel) // Minimal logging for performance
 
 	clientConfig := client.Config{
-		BaseURL:    server.URL,
-		APIKey:     "test-key",
-		BatchSize:  50,
-		BatchDelay: 200 * time.Millisecond,
-		Logger:     log,
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		BatchSize:   50,
+		Logger:      log,
+		ManualFlush: true,
 	}
 	apiClient := client.NewClient(clientConfig)
 	apiClient.Start()
@@ -613,20 +615,16 @@ func TestEndToEnd_HighVolume(t *testing.T) {
 		}
 	}()
 
-	// Generate 100 log events
-	logFile := filepath.Join(logDir, "copilot.log")
-	file, err := os.Create(logFile)
-	if err != nil {
-		t.Fatalf("failed to create log file: %v", err)
+	// Generate 100 turns, a request and a response each
+	prompts := make([]string, 100)
+	for i := range prompts {
+		prompts[i] = fmt.Sprintf("event %d", i)
 	}
-
-	expectedEvents := 100
-	for i := 0; i < expectedEvents; i++ {
-		logLine := fmt.Sprintf(`{"timestamp":"2025-10-30T10:%02d:%02dZ","level":"info","message":"completion accepted","source":"copilot","requestId":"req-%d","model":"gpt-4","completion":"event %d","tokensUsed":10}
-`, i/60, i%60, i, i)
-		file.WriteString(logLine)
+	logFile := filepath.Join(logDir, "session.json")
+	if err := os.WriteFile(logFile, copilotSession(t, prompts...), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
 	}
-	file.Close()
+	expectedEvents := 2 * len(prompts)
 
 	// Parse and send all events directly
 	events, err := adapter.ParseLogFile(logFile)
@@ -639,8 +637,10 @@ func TestEndToEnd_HighVolume(t *testing.T) {
 		apiClient.SendEvent(event)
 	}
 
-	// Wait for all events to be processed
-	time.Sleep(3 * time.Second)
+	// Deliver the queued events
+	if err := apiClient.FlushSync(); err != nil {
+		t.Fatalf("failed to flush events: %v", err)
+	}
 
 	// Verify event count
 	mu.Lock()
@@ -655,3 +655,173 @@ func TestEndToEnd_HighVolume(t *testing.T) {
 			expectedEvents*8/10, count, successRate)
 	}
 }
+
+// TestEndToEnd_PartialBatchRejection tests that events rejected by the backend
+// stay buffered while accepted events are removed
+func TestEndToEnd_PartialBatchRejection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*types.AgentEvent
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := client.BatchResult{}
+		for _, event := range events {
+			if event.ID == "event-bad" {
+				result.Rejected = append(result.Rejected, client.RejectedEvent{ID: event.ID, Reason: "missing sessionId"})
+			} else {
+				result.Accepted = append(result.Accepted, event.ID)
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+
+	buf, err := buffer.NewBuffer(buffer.Config{
+		DBPath:  filepath.Join(tmpDir, "buffer.db"),
+		MaxSize: 1000,
+		Logger:  log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+
+	apiClient := client.NewClient(client.Config{
+		BaseURL:    server.URL,
+		APIKey:     "test-key",
+		MaxRetries: 1,
+		Logger:     log,
+	})
+
+	for _, id := range []string{"event-1", "event-bad", "event-2"} {
+		event := &types.AgentEvent{
+			ID:        id,
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "github-copilot",
+			SessionID: "session-1",
+		}
+		if _, err := buf.Store(event); err != nil {
+			t.Fatalf("failed to buffer event: %v", err)
+		}
+	}
+
+	// Flush the buffer the way the collector does
+	events, err := buf.Retrieve(100)
+	if err != nil {
+		t.Fatalf("failed to retrieve buffered events: %v", err)
+	}
+
+	result, err := apiClient.SendBatch(events)
+	if err != nil {
+		t.Fatalf("failed to send batch: %v", err)
+	}
+
+	if len(result.Accepted) != 2 {
+		t.Errorf("expected 2 accepted events, got %d", len(result.Accepted))
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Reason != "missing sessionId" {
+		t.Errorf("expected event-bad to be rejected with a reason, got %+v", result.Rejected)
+	}
+
+	if err := buf.Delete(result.Accepted); err != nil {
+		t.Fatalf("failed to delete sent events: %v", err)
+	}
+	if err := buf.Release([]string{result.Rejected[0].ID}); err != nil {
+		t.Fatalf("failed to release rejected events: %v", err)
+	}
+
+	remaining, err := buf.Retrieve(100)
+	if err != nil {
+		t.Fatalf("failed to retrieve buffered events: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != "event-bad" {
+		t.Errorf("expected only the rejected event to stay buffered, got %d events", len(remaining))
+	}
+}
+
+// TestEndToEnd_FailedBatchesAreBuffered tests that queued events are handed
+// back for buffering when their batch can't be delivered, and that events are
+// refused outright while the circuit breaker is open
+func TestEndToEnd_FailedBatchesAreBuffered(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	buf, err := buffer.NewBuffer(buffer.Config{
+		DBPath:  filepath.Join(tmpDir, "buffer.db"),
+		MaxSize: 1000,
+		Logger:  log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create buffer: %v", err)
+	}
+	defer buf.Close()
+
+	apiClient := client.NewClient(client.Config{
+		BaseURL:          server.URL,
+		APIKey:           "test-key",
+		BatchSize:        100,
+		MaxRetries:       1,
+		BreakerThreshold: 1,
+		Logger:           log,
+		OnSendFailure: func(events []*types.AgentEvent, err error) {
+			for _, event := range events {
+				if _, err := buf.Store(event); err != nil {
+					t.Errorf("failed to buffer event: %v", err)
+				}
+			}
+		},
+	})
+
+	newEvent := func(id string) *types.AgentEvent {
+		return &types.AgentEvent{
+			ID:        id,
+			Timestamp: time.Now(),
+			Type:      types.EventTypeLLMRequest,
+			AgentID:   "github-copilot",
+			SessionID: "session-1",
+		}
+	}
+
+	// Queued events are accepted, then handed back when the flush fails
+	for _, id := range []string{"event-1", "event-2"} {
+		if err := apiClient.SendEvent(newEvent(id)); err != nil {
+			t.Fatalf("expected event to be queued, got %v", err)
+		}
+	}
+	if err := apiClient.FlushBatch(); err == nil {
+		t.Fatal("expected flush to fail while the backend is unavailable")
+	}
+
+	count, err := buf.Count()
+	if err != nil {
+		t.Fatalf("failed to count buffered events: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 buffered events after the failed flush, got %d", count)
+	}
+
+	// With the circuit open, SendEvent reports the failure to the caller
+	if !apiClient.CircuitOpen() {
+		t.Fatal("expected the circuit breaker to be open")
+	}
+	if err := apiClient.SendEvent(newEvent("event-3")); !errors.Is(err, client.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Package logging configures the collector's logrus logger from the
+// logging section of the configuration.
+package logging
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewFormatter returns the logrus formatter for format; empty means text
+func NewFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return &logrus.TextFormatter{FullTimestamp: true}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected %s or %s)", format, FormatText, FormatJSON)
+	}
+}
+
+// Configure applies a level and format to log. An empty level leaves the
+// current level unchanged.
+func Configure(log *logrus.Logger, level, format string) error {
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(formatter)
+
+	if level != "" {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid log level: %w", err)
+		}
+		log.SetLevel(parsed)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConfigure_JSON(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+
+	if err := Configure(log, "debug", FormatJSON); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if log.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected debug level, got %s", log.GetLevel())
+	}
+
+	log.WithFields(logrus.Fields{
+		"agent":  "github-copilot",
+		"file":   "/tmp/session.json",
+		"events": 12,
+	}).Info("Parsed events")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Log output is not JSON: %v\n%s", err, out.String())
+	}
+
+	expected := map[string]interface{}{
+		"level":  "info",
+		"msg":    "Parsed events",
+		"agent":  "github-copilot",
+		"file":   "/tmp/session.json",
+		"events": float64(12),
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Error("Expected a time field")
+	}
+}
+
+func TestConfigure_Text(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+
+	if err := Configure(log, "", ""); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if log.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected the level to stay info, got %s", log.GetLevel())
+	}
+
+	log.WithField("agent", "claude").Info("Parsed events")
+	if !strings.Contains(out.String(), `msg="Parsed events" agent=claude`) {
+		t.Errorf("Unexpected text output: %s", out.String())
+	}
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	log := logrus.New()
+	if err := Configure(log, "info", "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if err := Configure(log, "loud", FormatText); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
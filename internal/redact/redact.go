@@ -0,0 +1,168 @@
+// Package redact masks secrets in captured prompts, responses and tool
+// arguments before events leave the machine.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// pattern is a named secret pattern. When the pattern has a capture group,
+// only the first group is masked so surrounding context (e.g. the variable
+// name of an .env assignment) is kept.
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// builtinPatterns are the secret formats masked by every Redactor
+var builtinPatterns = []pattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{8,}=*)`)},
+	{"env-secret", regexp.MustCompile(`\b[A-Za-z0-9_]*(?i:secret|token|passw(?:or)?d|api_?key)[A-Za-z0-9_]*\s*[=:]\s*["']?([^\s"']{6,})`)},
+}
+
+// candidatePattern finds long token-like strings checked for high entropy
+var candidatePattern = regexp.MustCompile(`[A-Za-z0-9+/_\-]{32,}={0,2}`)
+
+// entropyThreshold is the Shannon entropy (bits per character) above which
+// a long mixed-case alphanumeric token is treated as a secret
+const entropyThreshold = 4.2
+
+// Mask returns the replacement text for a secret of the given kind
+func Mask(kind string) string {
+	return fmt.Sprintf("[REDACTED:%s]", kind)
+}
+
+// Redactor masks secrets in strings. A nil *Redactor leaves text unchanged.
+type Redactor struct {
+	patterns []pattern
+}
+
+// New creates a Redactor using the built-in secret patterns plus the given
+// custom regular expressions
+func New(custom []string) (*Redactor, error) {
+	patterns := append([]pattern(nil), builtinPatterns...)
+	for _, expr := range custom {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, pattern{name: "custom", re: re})
+	}
+	return &Redactor{patterns: patterns}, nil
+}
+
+// String returns s with all detected secrets masked
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	for _, p := range r.patterns {
+		s = maskMatches(s, p)
+	}
+
+	return candidatePattern.ReplaceAllStringFunc(s, func(token string) string {
+		if !highEntropy(token) {
+			return token
+		}
+		return Mask("high-entropy")
+	})
+}
+
+// Value masks secrets in v, descending into maps and slices as produced by
+// encoding/json. Maps and slices are modified in place.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = r.Value(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.Value(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// maskMatches replaces matches of p in s, masking only the first capture
+// group when the pattern has one
+func maskMatches(s string, p pattern) string {
+	if p.re.NumSubexp() == 0 {
+		return p.re.ReplaceAllString(s, Mask(p.name))
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range p.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[0], m[1]
+		}
+		if strings.HasPrefix(s[start:end], "[REDACTED:") {
+			continue // Already masked by an earlier pattern
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Mask(p.name))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// highEntropy reports whether token looks like a random secret: it mixes
+// upper case, lower case and digits and has high Shannon entropy. Hex
+// digests and identifiers rarely satisfy both.
+func highEntropy(token string) bool {
+	var upper, lower, digit bool
+	for _, c := range token {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	return shannonEntropy(token) >= entropyThreshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	n := float64(len(s))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,93 @@
+// Package sink delivers collected events to their destination: the backend
+// API client, or a local NDJSON stream for trying the collector without one.
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codervisor/devlog/internal/client"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// Sink receives events from the watcher and buffer flush loops.
+// *client.Client is the backend sink.
+type Sink interface {
+	// SendEvent delivers or queues a single event
+	SendEvent(event *types.AgentEvent) error
+
+	// SendBatch delivers events and reports which ones were accepted
+	SendBatch(events []*types.AgentEvent) (*client.BatchResult, error)
+}
+
+var _ Sink = (*client.Client)(nil)
+
+// NDJSONSink writes each event as one JSON line
+type NDJSONSink struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewNDJSONSink writes events to w. Closing the sink doesn't close w.
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	return &NDJSONSink{enc: json.NewEncoder(w)}
+}
+
+// NewStdoutSink writes events to standard output
+func NewStdoutSink() *NDJSONSink {
+	return NewNDJSONSink(os.Stdout)
+}
+
+// NewFileSink appends events to the file at path, creating it and its
+// directory if needed
+func NewFileSink(path string) (*NDJSONSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sink directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+
+	sink := NewNDJSONSink(file)
+	sink.closer = file
+	return sink, nil
+}
+
+// SendEvent writes a single event line
+func (s *NDJSONSink) SendEvent(event *types.AgentEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.enc.Encode(event); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// SendBatch writes events in order. Events written before a failure are
+// reported as accepted.
+func (s *NDJSONSink) SendBatch(events []*types.AgentEvent) (*client.BatchResult, error) {
+	result := &client.BatchResult{}
+	for _, event := range events {
+		if err := s.SendEvent(event); err != nil {
+			return result, err
+		}
+		result.Accepted = append(result.Accepted, event.ID)
+	}
+	return result, nil
+}
+
+// Close closes the underlying file of a file sink
+func (s *NDJSONSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+func testEvent(id string) *types.AgentEvent {
+	return &types.AgentEvent{
+		ID:        id,
+		Timestamp: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   "github-copilot",
+		SessionID: "session-1",
+		Data:      map[string]interface{}{"prompt": "hello\nworld"},
+	}
+}
+
+func readLines(t *testing.T, path string) []*types.AgentEvent {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open sink file: %v", err)
+	}
+	defer file.Close()
+
+	var events []*types.AgentEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event types.AgentEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not a JSON event: %v", scanner.Text(), err)
+		}
+		events = append(events, &event)
+	}
+	return events
+}
+
+func TestFileSink_WritesNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "events.ndjson")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+
+	if err := sink.SendEvent(testEvent("evt-1")); err != nil {
+		t.Fatalf("SendEvent: %v", err)
+	}
+	result, err := sink.SendBatch([]*types.AgentEvent{testEvent("evt-2"), testEvent("evt-3")})
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if len(result.Accepted) != 2 || result.Accepted[0] != "evt-2" || result.Accepted[1] != "evt-3" {
+		t.Fatalf("expected evt-2 and evt-3 accepted, got %v", result.Accepted)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	events := readLines(t, path)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(events))
+	}
+	for i, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		if events[i].ID != id {
+			t.Errorf("line %d: expected %s, got %s", i, id, events[i].ID)
+		}
+	}
+	if events[0].Data["prompt"] != "hello\nworld" {
+		t.Errorf("multi-line prompt not preserved: %q", events[0].Data["prompt"])
+	}
+}
+
+func TestFileSink_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	for _, id := range []string{"evt-1", "evt-2"} {
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatalf("NewFileSink: %v", err)
+		}
+		if err := sink.SendEvent(testEvent(id)); err != nil {
+			t.Fatalf("SendEvent: %v", err)
+		}
+		sink.Close()
+	}
+
+	if events := readLines(t, path); len(events) != 2 {
+		t.Fatalf("expected events from both runs, got %d", len(events))
+	}
+}
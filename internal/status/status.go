@@ -0,0 +1,134 @@
+// Package status serves the state of a running collector over HTTP, so the
+// status command and monitoring can query the daemon instead of inspecting
+// its files.
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Snapshot is the live state of a collector, served on /status
+type Snapshot struct {
+	Version   string    `json:"version"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+	Uptime    string    `json:"uptime"`
+
+	// Sink is "backend" or the local sink type
+	Sink string `json:"sink"`
+
+	// Watcher and Client are the components' GetStats maps; Client is
+	// omitted for local sinks
+	Watcher map[string]interface{} `json:"watcher"`
+	Client  map[string]interface{} `json:"client,omitempty"`
+
+	// BufferDepth is the number of events waiting in the offline buffer
+	BufferDepth int `json:"bufferDepth"`
+
+	// LastFlush is when a batch last reached the backend; zero if none has
+	// yet or events go to a local sink
+	LastFlush time.Time `json:"lastFlush"`
+}
+
+// Server serves /healthz and /status for a running collector
+type Server struct {
+	snapshot func() Snapshot
+	log      *logrus.Logger
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer creates a status server reporting the snapshot function's result
+func NewServer(snapshot func() Snapshot, log *logrus.Logger) *Server {
+	if log == nil {
+		log = logrus.New()
+	}
+	s := &Server{snapshot: snapshot, log: log}
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the server's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.snapshot())
+	})
+	return mux
+}
+
+// Start listens on addr and serves in the background
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Errorf("Status server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server listens on, once started
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Stop shuts the server down, waiting for requests in flight until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Fetch returns the snapshot of the collector whose status server listens on
+// addr, or an error if none is reachable there
+func Fetch(ctx context.Context, addr string) (*Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://"+addr+"/status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode status: %w", err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,93 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, snapshot Snapshot) *Server {
+	t.Helper()
+	server := NewServer(func() Snapshot { return snapshot }, nil)
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start status server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+	return server
+}
+
+func TestServer_Healthz(t *testing.T) {
+	server := startTestServer(t, Snapshot{})
+
+	resp, err := http.Get("http://" + server.Addr() + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body["status"] != "ok" {
+		t.Errorf("Expected status ok, got %v", body)
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	lastFlush := time.Date(2025, 10, 31, 10, 0, 0, 0, time.UTC)
+	server := startTestServer(t, Snapshot{
+		Version:     "1.2.3",
+		PID:         42,
+		Sink:        "backend",
+		Watcher:     map[string]interface{}{"watching_count": 3},
+		Client:      map[string]interface{}{"breaker_state": "closed"},
+		BufferDepth: 7,
+		LastFlush:   lastFlush,
+	})
+
+	snapshot, err := Fetch(context.Background(), server.Addr())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if snapshot.Version != "1.2.3" || snapshot.PID != 42 || snapshot.BufferDepth != 7 {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+	if !snapshot.LastFlush.Equal(lastFlush) {
+		t.Errorf("Expected last flush %v, got %v", lastFlush, snapshot.LastFlush)
+	}
+	if snapshot.Watcher["watching_count"] != float64(3) {
+		t.Errorf("Expected watcher stats, got %v", snapshot.Watcher)
+	}
+	if snapshot.Client["breaker_state"] != "closed" {
+		t.Errorf("Expected client stats, got %v", snapshot.Client)
+	}
+}
+
+func TestServer_RejectsOtherMethods(t *testing.T) {
+	server := startTestServer(t, Snapshot{})
+
+	resp, err := http.Post("http://"+server.Addr()+"/status", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST /status: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestFetch_NoCollector(t *testing.T) {
+	server := startTestServer(t, Snapshot{})
+	addr := server.Addr()
+	server.Stop(context.Background())
+
+	if _, err := Fetch(context.Background(), addr); err == nil {
+		t.Error("Expected an error without a running collector")
+	}
+}
@@ -12,16 +12,36 @@ import (
 var AgentLogLocations = map[string]map[string][]string{
 	"copilot": {
 		"darwin": {
+			// Agent mode keeps file edits in chatEditingSessions
 			"~/Library/Application Support/Code/User/workspaceStorage/*/chatSessions",
+			"~/Library/Application Support/Code/User/workspaceStorage/*/chatEditingSessions",
 			"~/Library/Application Support/Code - Insiders/User/workspaceStorage/*/chatSessions",
+			"~/Library/Application Support/Code - Insiders/User/workspaceStorage/*/chatEditingSessions",
+			// Non-default profiles keep their own workspaceStorage
+			"~/Library/Application Support/Code/User/profiles/*/workspaceStorage/*/chatSessions",
+			"~/Library/Application Support/Code/User/profiles/*/workspaceStorage/*/chatEditingSessions",
+			"~/Library/Application Support/Code - Insiders/User/profiles/*/workspaceStorage/*/chatSessions",
+			"~/Library/Application Support/Code - Insiders/User/profiles/*/workspaceStorage/*/chatEditingSessions",
 		},
 		"linux": {
 			"~/.config/Code/User/workspaceStorage/*/chatSessions",
+			"~/.config/Code/User/workspaceStorage/*/chatEditingSessions",
 			"~/.config/Code - Insiders/User/workspaceStorage/*/chatSessions",
+			"~/.config/Code - Insiders/User/workspaceStorage/*/chatEditingSessions",
+			"~/.config/Code/User/profiles/*/workspaceStorage/*/chatSessions",
+			"~/.config/Code/User/profiles/*/workspaceStorage/*/chatEditingSessions",
+			"~/.config/Code - Insiders/User/profiles/*/workspaceStorage/*/chatSessions",
+			"~/.config/Code - Insiders/User/profiles/*/workspaceStorage/*/chatEditingSessions",
 		},
 		"windows": {
 			"%APPDATA%\\Code\\User\\workspaceStorage\\*\\chatSessions",
+			"%APPDATA%\\Code\\User\\workspaceStorage\\*\\chatEditingSessions",
 			"%APPDATA%\\Code - Insiders\\User\\workspaceStorage\\*\\chatSessions",
+			"%APPDATA%\\Code - Insiders\\User\\workspaceStorage\\*\\chatEditingSessions",
+			"%APPDATA%\\Code\\User\\profiles\\*\\workspaceStorage\\*\\chatSessions",
+			"%APPDATA%\\Code\\User\\profiles\\*\\workspaceStorage\\*\\chatEditingSessions",
+			"%APPDATA%\\Code - Insiders\\User\\profiles\\*\\workspaceStorage\\*\\chatSessions",
+			"%APPDATA%\\Code - Insiders\\User\\profiles\\*\\workspaceStorage\\*\\chatEditingSessions",
 		},
 	},
 	"claude": {
@@ -82,6 +102,70 @@ var AgentLogLocations = map[string]map[string][]string{
 			"%USERPROFILE%\\.aider\\.aider.history",
 		},
 	},
+	"neovim": {
+		"darwin": {
+			"~/.local/state/nvim/avante/history",
+			"~/.local/share/nvim/codecompanion-history",
+		},
+		"linux": {
+			"~/.local/state/nvim/avante/history",
+			"~/.local/share/nvim/codecompanion-history",
+		},
+		"windows": {
+			"%LOCALAPPDATA%\\nvim-data\\avante\\history",
+			"%LOCALAPPDATA%\\nvim-data\\codecompanion-history",
+		},
+	},
+	"continue": {
+		"darwin": {
+			"~/.continue/sessions",
+		},
+		"linux": {
+			"~/.continue/sessions",
+		},
+		"windows": {
+			"%USERPROFILE%\\.continue\\sessions",
+		},
+	},
+	"jetbrains": {
+		"darwin": {
+			"~/Library/Caches/JetBrains/*/aia/chats",
+			"~/Library/Application Support/JetBrains/*/aia/chats",
+			"~/.config/github-copilot/intellij/chats",
+		},
+		"linux": {
+			"~/.cache/JetBrains/*/aia/chats",
+			"~/.config/JetBrains/*/aia/chats",
+			"~/.config/github-copilot/intellij/chats",
+		},
+		"windows": {
+			"%LOCALAPPDATA%\\JetBrains\\*\\aia\\chats",
+			"%APPDATA%\\JetBrains\\*\\aia\\chats",
+			"%LOCALAPPDATA%\\github-copilot\\intellij\\chats",
+		},
+	},
+	"gemini": {
+		"darwin": {
+			"~/.gemini/tmp",
+		},
+		"linux": {
+			"~/.gemini/tmp",
+		},
+		"windows": {
+			"%USERPROFILE%\\.gemini\\tmp",
+		},
+	},
+	"tabnine": {
+		"darwin": {
+			"~/.tabnine/chat/conversations",
+		},
+		"linux": {
+			"~/.tabnine/chat/conversations",
+		},
+		"windows": {
+			"%USERPROFILE%\\.tabnine\\chat\\conversations",
+		},
+	},
 }
 
 // DiscoveredLog represents a discovered log file or directory
@@ -105,9 +189,15 @@ func DiscoverAgentLogs(agentName string) ([]DiscoveredLog, error) {
 		return nil, fmt.Errorf("agent %s not supported on %s", agentName, osName)
 	}
 
+	return discoverPatterns(agentName, osPlatterns), nil
+}
+
+// discoverPatterns returns the existing files and directories matching the
+// path patterns of an agent
+func discoverPatterns(agentName string, patterns []string) []DiscoveredLog {
 	var discovered []DiscoveredLog
 
-	for _, pattern := range osPlatterns {
+	for _, pattern := range patterns {
 		// Expand path variables
 		expanded := expandPath(pattern)
 
@@ -134,17 +224,56 @@ func DiscoverAgentLogs(agentName string) ([]DiscoveredLog, error) {
 		}
 	}
 
-	return discovered, nil
+	return discovered
+}
+
+// AgentSettings adjusts the discovery of one agent. Agents without settings
+// are discovered at their default locations.
+type AgentSettings struct {
+	// Disabled skips the agent entirely
+	Disabled bool
+
+	// LogPath, when set, is searched instead of the default locations. It
+	// may be a glob pattern.
+	LogPath string
 }
 
 // DiscoverAllAgentLogs discovers logs for all known agents
 func DiscoverAllAgentLogs() (map[string][]DiscoveredLog, error) {
+	return DiscoverConfiguredAgentLogs(nil)
+}
+
+// DiscoverConfiguredAgentLogs discovers logs like DiscoverAllAgentLogs,
+// applying settings keyed by agent name. Agents with a LogPath are
+// discovered even when they have no default locations.
+func DiscoverConfiguredAgentLogs(settings map[string]AgentSettings) (map[string][]DiscoveredLog, error) {
 	result := make(map[string][]DiscoveredLog)
 
+	agentNames := make(map[string]bool)
 	for agentName := range AgentLogLocations {
-		logs, err := DiscoverAgentLogs(agentName)
-		if err != nil {
-			continue // Skip agents that aren't supported on this OS
+		agentNames[agentName] = true
+	}
+	for agentName, agentSettings := range settings {
+		if agentSettings.LogPath != "" {
+			agentNames[agentName] = true
+		}
+	}
+
+	for agentName := range agentNames {
+		agentSettings := settings[agentName]
+		if agentSettings.Disabled {
+			continue
+		}
+
+		var logs []DiscoveredLog
+		if agentSettings.LogPath != "" {
+			logs = discoverPatterns(agentName, []string{agentSettings.LogPath})
+		} else {
+			var err error
+			logs, err = DiscoverAgentLogs(agentName)
+			if err != nil {
+				continue // Skip agents that aren't supported on this OS
+			}
 		}
 
 		if len(logs) > 0 {
@@ -155,32 +284,10 @@ func DiscoverAllAgentLogs() (map[string][]DiscoveredLog, error) {
 	return result, nil
 }
 
-// FindLogFiles recursively finds log files in a directory
+// FindLogFiles recursively finds log files in a directory, skipping
+// DefaultExcludes
 func FindLogFiles(dirPath string) ([]string, error) {
-	var logFiles []string
-
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Continue on errors
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// Check if file is a log file
-		if isLogFile(path) {
-			logFiles = append(logFiles, path)
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return logFiles, nil
+	return NewPathFilter(nil, nil).WalkLogFiles(dirPath)
 }
 
 // isLogFile checks if a file is likely a log file
@@ -188,6 +295,12 @@ func isLogFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	base := strings.ToLower(filepath.Base(path))
 
+	// Compressed rotations are never appended to; backfill reads them. Lines
+	// backfill quarantined with --dump-errors aren't agent logs either.
+	if ext == ".gz" || ext == ".parse-errors" {
+		return false
+	}
+
 	// Check common log file extensions
 	logExtensions := []string{".log", ".txt", ".json", ".jsonl", ".ndjson"}
 	for _, logExt := range logExtensions {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -58,6 +59,8 @@ func TestIsLogFile(t *testing.T) {
 		{"Contains error", "error-messages.dat", true},
 		{"Regular file", "config.json", false},
 		{"Binary file", "binary.exe", false},
+		{"Compressed rotation", "application.log.gz", false},
+		{"Quarantined parse errors", "session.jsonl.parse-errors", false},
 	}
 
 	for _, tt := range tests {
@@ -99,6 +102,60 @@ func TestDiscoverAgentLogsInvalidAgent(t *testing.T) {
 	}
 }
 
+func TestDiscoverAgentLogs_JetBrains(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("JetBrains locations on Windows are under %LOCALAPPDATA%")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// One chat directory per installed IDE, plus Copilot's own
+	var want []string
+	for _, pattern := range AgentLogLocations["jetbrains"][runtime.GOOS] {
+		dir := filepath.Join(home, strings.ReplaceAll(pattern[2:], "*", "GoLand2024.3"))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create chat directory: %v", err)
+		}
+		want = append(want, dir)
+	}
+
+	logs, err := DiscoverAgentLogs("jetbrains")
+	if err != nil {
+		t.Fatalf("Failed to discover JetBrains logs: %v", err)
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("Expected %d JetBrains chat directories, found %d: %v", len(want), len(logs), logs)
+	}
+	for i, log := range logs {
+		if log.Path != want[i] || !log.IsDir || log.AgentName != "jetbrains" {
+			t.Errorf("Unexpected discovered log %d: %+v, expected directory %s", i, log, want[i])
+		}
+	}
+}
+
+func TestDiscoverAgentLogs_Tabnine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Tabnine locations on Windows are under the user profile")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, AgentLogLocations["tabnine"][runtime.GOOS][0][2:])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create conversations directory: %v", err)
+	}
+
+	logs, err := DiscoverAgentLogs("tabnine")
+	if err != nil {
+		t.Fatalf("Failed to discover Tabnine logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Path != dir || !logs[0].IsDir || logs[0].AgentName != "tabnine" {
+		t.Fatalf("Expected conversations directory %s, found %v", dir, logs)
+	}
+}
+
 func TestDiscoverAllAgentLogs(t *testing.T) {
 	discovered, err := DiscoverAllAgentLogs()
 	if err != nil {
@@ -224,3 +281,159 @@ func TestAgentLogLocations(t *testing.T) {
 		}
 	}
 }
+
+func TestDiscoverAgentLogs_VSCodeProfiles(t *testing.T) {
+	var userDir string
+	switch runtime.GOOS {
+	case "linux":
+		userDir = filepath.Join(".config", "Code", "User")
+	case "darwin":
+		userDir = filepath.Join("Library", "Application Support", "Code", "User")
+	default:
+		t.Skip("profile fixture only laid out for linux and darwin")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	defaultSessions := filepath.Join(home, userDir, "workspaceStorage", "ws-default", "chatSessions")
+	profileSessions := filepath.Join(home, userDir, "profiles", "5f3a2b", "workspaceStorage", "ws-profile", "chatSessions")
+	for _, dir := range []string{defaultSessions, profileSessions} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create fixture dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "session.json"), []byte(`{"version":3,"requests":[]}`), 0644); err != nil {
+			t.Fatalf("Failed to write session: %v", err)
+		}
+	}
+
+	logs, err := DiscoverAgentLogs("copilot")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, log := range logs {
+		found[log.Path] = true
+	}
+	if !found[defaultSessions] {
+		t.Errorf("Expected default profile sessions %s to be discovered", defaultSessions)
+	}
+	if !found[profileSessions] {
+		t.Errorf("Expected profile sessions %s to be discovered, got %v", profileSessions, logs)
+	}
+}
+
+func TestDiscoverAgentLogs_CopilotEditingSessions(t *testing.T) {
+	var userDir string
+	switch runtime.GOOS {
+	case "linux":
+		userDir = filepath.Join(".config", "Code", "User")
+	case "darwin":
+		userDir = filepath.Join("Library", "Application Support", "Code", "User")
+	default:
+		t.Skip("editing session fixture only laid out for linux and darwin")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	workspace := filepath.Join(home, userDir, "workspaceStorage", "ws-agent")
+	chatSessions := filepath.Join(workspace, "chatSessions")
+	editingSessions := filepath.Join(workspace, "chatEditingSessions")
+	stateDir := filepath.Join(editingSessions, "session-1")
+	for _, dir := range []string{chatSessions, filepath.Join(stateDir, "contents")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create fixture dir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "state.json"), []byte(`{"version":2,"sessionId":"session-1"}`), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "contents", "9f86d081"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	logs, err := DiscoverAgentLogs("copilot")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, log := range logs {
+		found[log.Path] = true
+	}
+	if !found[chatSessions] || !found[editingSessions] {
+		t.Fatalf("Expected both %s and %s to be discovered, got %v", chatSessions, editingSessions, logs)
+	}
+
+	// Only the state file is parsed; content snapshots aren't logs
+	files, err := FindLogFiles(editingSessions)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "state.json" {
+		t.Errorf("Expected only state.json, got %v", files)
+	}
+}
+
+func TestDiscoverConfiguredAgentLogs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("default locations only laid out for linux")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Default locations of claude and gemini
+	for _, dir := range []string{".claude/logs", ".gemini/tmp"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+			t.Fatalf("Failed to create fixture dir: %v", err)
+		}
+	}
+	customGemini := filepath.Join(t.TempDir(), "gemini-sessions")
+	gatewayLogs := t.TempDir()
+	for _, dir := range []string{customGemini, filepath.Join(gatewayLogs, "eu"), filepath.Join(gatewayLogs, "us")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create fixture dir: %v", err)
+		}
+	}
+
+	discovered, err := DiscoverConfiguredAgentLogs(map[string]AgentSettings{
+		"claude": {Disabled: true},
+		"gemini": {LogPath: customGemini},
+		"proxy":  {LogPath: filepath.Join(gatewayLogs, "*")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to discover agent logs: %v", err)
+	}
+
+	if logs, ok := discovered["claude"]; ok {
+		t.Errorf("Expected disabled claude to be skipped, found %v", logs)
+	}
+
+	gemini := discovered["gemini"]
+	if len(gemini) != 1 || gemini[0].Path != customGemini {
+		t.Errorf("Expected only the custom gemini path %s, found %v", customGemini, gemini)
+	}
+
+	// Agents without default locations are found at their custom path
+	proxy := discovered["proxy"]
+	if len(proxy) != 2 {
+		t.Fatalf("Expected both gateway directories matched by the glob, found %v", proxy)
+	}
+	for _, log := range proxy {
+		if log.AgentName != "proxy" || !log.IsDir {
+			t.Errorf("Unexpected discovered log %+v", log)
+		}
+	}
+
+	// Without settings every agent is discovered at its defaults
+	discovered, err = DiscoverConfiguredAgentLogs(nil)
+	if err != nil {
+		t.Fatalf("Failed to discover agent logs: %v", err)
+	}
+	if len(discovered["claude"]) != 1 || len(discovered["gemini"]) != 1 {
+		t.Errorf("Expected default claude and gemini locations, found %v", discovered)
+	}
+}
@@ -0,0 +1,106 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExcludes are always excluded from log discovery: editor temp and
+// backup files, and dependency trees
+var DefaultExcludes = []string{"*.tmp", "*~", "node_modules"}
+
+// PathFilter selects log files by glob patterns matched against the path
+// relative to the discovery root. A pattern without a separator is also
+// tried against each path component, so "node_modules" excludes everything
+// beneath any node_modules directory and "*.tmp" matches at any depth.
+type PathFilter struct {
+	Include []string // When non-empty, only matching files are kept
+	Exclude []string // Matching files and directories are skipped
+}
+
+// NewPathFilter builds a filter from configured patterns plus DefaultExcludes
+func NewPathFilter(include, exclude []string) PathFilter {
+	return PathFilter{
+		Include: include,
+		Exclude: append(append([]string{}, DefaultExcludes...), exclude...),
+	}
+}
+
+// Excluded reports whether relPath matches an exclude pattern
+func (f PathFilter) Excluded(relPath string) bool {
+	return matchesAny(f.Exclude, relPath)
+}
+
+// Allows reports whether the file at relPath passes the filter
+func (f PathFilter) Allows(relPath string) bool {
+	if f.Excluded(relPath) {
+		return false
+	}
+	return len(f.Include) == 0 || matchesAny(f.Include, relPath)
+}
+
+// WalkLogFiles walks root and returns log files allowed by the filter,
+// skipping excluded directories entirely
+func (f PathFilter) WalkLogFiles(root string) ([]string, error) {
+	return f.Walk(root, isLogFile)
+}
+
+// Walk walks root and returns the files that match accepts and the filter
+// allows, skipping excluded directories entirely. Entries beneath root that
+// can't be read are skipped; a root that can't be read is an error.
+func (f PathFilter) Walk(root string, match func(path string) bool) ([]string, error) {
+	var logFiles []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // Continue on errors
+		}
+
+		relPath, relErr := filepath.Rel(root, path)
+		if relErr != nil || relPath == "." {
+			return nil
+		}
+
+		if info.IsDir() {
+			if f.Excluded(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if match(path) && f.Allows(relPath) {
+			logFiles = append(logFiles, path)
+		}
+		return nil
+	})
+
+	return logFiles, err
+}
+
+// matchesAny reports whether relPath, or one of its components for
+// separator-free patterns, matches any of the patterns
+func matchesAny(patterns []string, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	components := strings.Split(relPath, "/")
+
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if strings.Contains(pattern, "/") {
+			continue
+		}
+		for _, component := range components {
+			if ok, _ := filepath.Match(pattern, component); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPathFilter_Allows(t *testing.T) {
+	filter := NewPathFilter([]string{"chatSessions/*.json", "*.jsonl"}, []string{"backup-*"})
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"chatSessions/abc.json", true},
+		{"events.jsonl", true},
+		{"nested/dir/events.jsonl", true},
+		{"settings.json", false},                 // not included
+		{"chatSessions/abc.json.tmp", false},     // default exclude
+		{"events.jsonl~", false},                 // default exclude
+		{"node_modules/pkg/events.jsonl", false}, // default exclude on a directory
+		{"backup-1/events.jsonl", false},         // configured exclude
+	}
+
+	for _, tt := range tests {
+		if got := filter.Allows(tt.path); got != tt.allowed {
+			t.Errorf("Allows(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+}
+
+// writeFilterFixture creates log files, some of which the default and
+// configured excludes should skip
+func writeFilterFixture(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := []string{
+		"session.jsonl",
+		"session.jsonl.tmp",
+		"session.log~",
+		"node_modules/dep/debug.log",
+		"archive/old.jsonl",
+	}
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestPathFilter_WalkLogFiles(t *testing.T) {
+	root := writeFilterFixture(t)
+
+	files, err := NewPathFilter(nil, []string{"archive"}).WalkLogFiles(root)
+	if err != nil {
+		t.Fatalf("failed to walk: %v", err)
+	}
+
+	if len(files) != 1 || files[0] != filepath.Join(root, "session.jsonl") {
+		t.Errorf("expected only session.jsonl, got %v", files)
+	}
+}
+
+func TestPathFilter_Walk(t *testing.T) {
+	root := writeFilterFixture(t)
+	if err := os.WriteFile(filepath.Join(root, "session.jsonl.gz"), nil, 0644); err != nil {
+		t.Fatalf("failed to write session.jsonl.gz: %v", err)
+	}
+
+	// The caller decides what counts as a log; the filter still applies
+	gzipped := func(path string) bool { return filepath.Ext(path) == ".gz" }
+	files, err := NewPathFilter(nil, []string{"archive"}).Walk(root, gzipped)
+	if err != nil {
+		t.Fatalf("failed to walk: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(root, "session.jsonl.gz") {
+		t.Errorf("expected only session.jsonl.gz, got %v", files)
+	}
+
+	if _, err := NewPathFilter(nil, nil).Walk(filepath.Join(root, "missing"), gzipped); err == nil {
+		t.Error("expected an error walking a missing root")
+	}
+}
+
+func TestWatcher_WatchDirHonorsExcludes(t *testing.T) {
+	root := writeFilterFixture(t)
+	adapter := adapters.NewClaudeAdapter("test-project", nil, nil)
+
+	watcher, err := NewWatcher(Config{
+		Registry: adapters.NewRegistry(),
+		Logger:   logrus.New(),
+		Filters: map[string]PathFilter{
+			"claude": NewPathFilter(nil, []string{"archive"}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	if err := watcher.Watch(root, adapter); err != nil {
+		t.Fatalf("failed to watch directory: %v", err)
+	}
+
+	var watched []string
+	for path := range watcher.watching {
+		if path != root {
+			watched = append(watched, path)
+		}
+	}
+	sort.Strings(watched)
+
+	if len(watched) != 1 || watched[0] != filepath.Join(root, "session.jsonl") {
+		t.Errorf("expected only session.jsonl to be watched, got %v", watched)
+	}
+}
@@ -0,0 +1,260 @@
+package watcher
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/buffer"
+	"github.com/codervisor/devlog/pkg/types"
+)
+
+// fileStamp is the size and modification time of a file when it was last
+// processed
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func stampOf(info os.FileInfo) fileStamp {
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}
+}
+
+// markConsumed records where watching a file starts. A file the watcher
+// processed before a restart resumes from its stored offset, or from where
+// backfill has since got to if that is further; any other file starts at its
+// current size, its existing content being covered by historical sync. For
+// the same reason the events a new whole-file log holds are recorded as
+// emitted when offsets are persisted. That means parsing the whole log, so
+// markConsumed only reports that the log needs seeding, reserving its first
+// parse for seedEmitted, which the caller runs once it released w.mu.
+func (w *Watcher) markConsumed(filePath string, adapter adapters.AgentAdapter) (seed bool) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	wholeFile := adapter != nil && adapters.ParsesWholeFile(adapter, filePath)
+
+	if stored, ok := w.loadOffset(filePath); ok {
+		offset := stored.Offset
+		if adapter != nil && !wholeFile && w.processedBytes != nil {
+			if processed := w.processedBytes(adapter.Name(), filePath); processed > offset && processed <= info.Size() {
+				offset = processed
+			}
+		}
+
+		w.offsetMu.Lock()
+		w.offsets[filePath] = offset
+		w.stamps[filePath] = fileStamp{size: stored.Size, modTime: stored.ModTime}
+		w.offsetMu.Unlock()
+		return false
+	}
+
+	w.offsetMu.Lock()
+	w.offsets[filePath] = info.Size()
+	w.stamps[filePath] = stampOf(info)
+	w.offsetMu.Unlock()
+
+	if wholeFile && w.store != nil {
+		// Writes made meanwhile are parsed once the seeding is done
+		w.parsing[filePath] = false
+		w.parses.Add(1)
+		return true
+	}
+	w.saveOffset(filePath)
+	return false
+}
+
+// seedEmitted records the events a whole-file log holds as emitted, then
+// persists its offset, so a restart in between seeds it again. It runs as
+// the parse of the file reserved by markConsumed.
+func (w *Watcher) seedEmitted(filePath string, adapter adapters.AgentAdapter) {
+	defer w.parses.Done()
+
+	if w.ctx.Err() == nil {
+		if events, err := adapter.ParseLogFile(filePath); err == nil {
+			w.newEvents(filePath, events)
+		}
+		w.saveOffset(filePath)
+	}
+	for w.parseAgain(filePath) {
+		w.processLogFile(filePath)
+	}
+}
+
+// loadOffset returns the persisted offset of filePath, if any
+func (w *Watcher) loadOffset(filePath string) (FileOffset, bool) {
+	if w.store == nil {
+		return FileOffset{}, false
+	}
+	stored, ok, err := w.store.Load(filePath)
+	if err != nil {
+		w.log.Warnf("Failed to load offset of %s: %v", filepath.Base(filePath), err)
+		return FileOffset{}, false
+	}
+	return stored, ok
+}
+
+// saveOffset persists the offset and stamp of filePath
+func (w *Watcher) saveOffset(filePath string) {
+	if w.store == nil {
+		return
+	}
+
+	w.offsetMu.Lock()
+	stamp := w.stamps[filePath]
+	offset := FileOffset{Offset: w.offsets[filePath], Size: stamp.size, ModTime: stamp.modTime}
+	w.offsetMu.Unlock()
+
+	if err := w.store.Save(filePath, offset); err != nil {
+		w.log.Warnf("Failed to save offset of %s: %v", filepath.Base(filePath), err)
+	}
+}
+
+// newEvents drops the events of a whole-file log that were emitted before,
+// recognized by content hash, and records the rest as emitted. Re-parsing a
+// log rewritten in place thus emits only what was added or changed. Events
+// the log no longer holds are forgotten, so what is recorded for a log
+// doesn't outgrow its content.
+func (w *Watcher) newEvents(filePath string, events []*types.AgentEvent) []*types.AgentEvent {
+	w.offsetMu.Lock()
+	defer w.offsetMu.Unlock()
+
+	emitted, ok := w.emitted[filePath]
+	if !ok {
+		emitted = make(map[string]bool)
+		if w.store != nil {
+			stored, err := w.store.Emitted(filePath)
+			if err != nil {
+				w.log.Warnf("Failed to load emitted events of %s: %v", filepath.Base(filePath), err)
+			} else {
+				emitted = stored
+			}
+		}
+		w.emitted[filePath] = emitted
+	}
+
+	current := make(map[string]bool, len(events))
+	var fresh []*types.AgentEvent
+	var hashes []string
+	for _, event := range events {
+		hash := buffer.EventHash(event)
+		if current[hash] {
+			continue
+		}
+		current[hash] = true
+		if emitted[hash] {
+			continue
+		}
+		hashes = append(hashes, hash)
+		fresh = append(fresh, event)
+	}
+
+	var stale []string
+	for hash := range emitted {
+		if !current[hash] {
+			stale = append(stale, hash)
+		}
+	}
+	w.emitted[filePath] = current
+
+	if w.store != nil && (len(hashes) > 0 || len(stale) > 0) {
+		if err := w.store.UpdateEmitted(filePath, hashes, stale); err != nil {
+			w.log.Warnf("Failed to record emitted events of %s: %v", filepath.Base(filePath), err)
+		}
+	}
+	return fresh
+}
+
+// resetOffset forgets the consumed offset so the file is read from the start
+func (w *Watcher) resetOffset(filePath string) {
+	w.offsetMu.Lock()
+	delete(w.offsets, filePath)
+	delete(w.stamps, filePath)
+	w.offsetMu.Unlock()
+}
+
+// fileChanged returns the file's current stamp and whether it differs from
+// the one it was last processed at. Files that can't be stat'ed count as
+// changed so processing reports the error.
+func (w *Watcher) fileChanged(filePath string) (fileStamp, bool) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fileStamp{}, true
+	}
+	stamp := stampOf(info)
+
+	w.offsetMu.Lock()
+	last, ok := w.stamps[filePath]
+	w.offsetMu.Unlock()
+
+	return stamp, !ok || last.size != stamp.size || !last.modTime.Equal(stamp.modTime)
+}
+
+// recordStamp records the stamp a file was processed at
+func (w *Watcher) recordStamp(filePath string, stamp fileStamp) {
+	w.offsetMu.Lock()
+	w.stamps[filePath] = stamp
+	w.offsetMu.Unlock()
+}
+
+// readAppendedLines parses complete lines written since the last read of a
+// line-based log, followed by the events due at the end of the log. A file
+// smaller than the stored offset was truncated and is read again from the
+// start. A trailing partial line is left for the next write.
+func (w *Watcher) readAppendedLines(filePath string, adapter adapters.AgentAdapter) ([]*types.AgentEvent, error) {
+	w.offsetMu.Lock()
+	defer w.offsetMu.Unlock()
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := w.offsets[filePath]
+	if info.Size() < offset {
+		w.log.Infof("Log %s was truncated, reading from start", filepath.Base(filePath))
+		offset = 0
+	}
+
+	if offset == 0 {
+		adapters.ResetLog(adapter, filePath)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var events []*types.AgentEvent
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.offsets[filePath] = offset
+			return events, err
+		}
+		offset += int64(len(line))
+
+		parsed, err := adapters.ParseLine(adapter, filePath, strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			w.log.Debugf("Failed to parse line in %s: %v", filepath.Base(filePath), err)
+			continue
+		}
+		events = append(events, parsed...)
+	}
+
+	w.offsets[filePath] = offset
+	return append(events, adapters.EndOfLog(adapter, filePath)...), nil
+}
@@ -0,0 +1,225 @@
+package watcher
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// busyTimeout is how long a write waits for another process's lock
+const busyTimeout = 5 * time.Second
+
+// FileOffset is how far the watcher got in a file: the bytes of a line-based
+// log consumed, and the size and mtime the file was last processed at
+type FileOffset struct {
+	Offset  int64
+	Size    int64
+	ModTime time.Time
+}
+
+// OffsetStore persists watcher progress per file so a restarted collector
+// resumes where it stopped instead of emitting active files again. It lives
+// in the buffer database next to the backfill state.
+type OffsetStore struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+// NewOffsetStore opens (or creates) the watcher tables in dbPath
+func NewOffsetStore(dbPath string) (*OffsetStore, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open offset database: %w", err)
+	}
+
+	// Other collector processes write the same database; wait for their
+	// locks rather than failing. The pragma is per connection, so keep one.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout.Milliseconds())); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS watcher_offsets (
+		file_path TEXT PRIMARY KEY,
+		byte_offset INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		mod_time INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS watcher_emitted (
+		file_path TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		PRIMARY KEY (file_path, content_hash)
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize offset schema: %w", err)
+	}
+
+	return &OffsetStore{db: db}, nil
+}
+
+// Load returns the stored offset of filePath and whether there is one
+func (s *OffsetStore) Load(filePath string) (FileOffset, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var offset FileOffset
+	var modTime int64
+	err := s.db.QueryRow(
+		"SELECT byte_offset, size, mod_time FROM watcher_offsets WHERE file_path = ?", filePath,
+	).Scan(&offset.Offset, &offset.Size, &modTime)
+	if err == sql.ErrNoRows {
+		return FileOffset{}, false, nil
+	}
+	if err != nil {
+		return FileOffset{}, false, fmt.Errorf("failed to load offset: %w", err)
+	}
+	offset.ModTime = time.Unix(0, modTime)
+	return offset, true, nil
+}
+
+// Save stores the offset of filePath
+func (s *OffsetStore) Save(filePath string, offset FileOffset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO watcher_offsets (file_path, byte_offset, size, mod_time, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(file_path) DO UPDATE SET
+			byte_offset = excluded.byte_offset,
+			size = excluded.size,
+			mod_time = excluded.mod_time,
+			updated_at = excluded.updated_at
+	`, filePath, offset.Offset, offset.Size, offset.ModTime.UnixNano(), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save offset: %w", err)
+	}
+	return nil
+}
+
+// Emitted returns the content hashes (see buffer.EventHash) of the events
+// emitted from a whole-file log
+func (s *OffsetStore) Emitted(filePath string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.db.Query("SELECT content_hash FROM watcher_emitted WHERE file_path = ?", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load emitted events: %w", err)
+	}
+	defer rows.Close()
+
+	emitted := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan emitted event: %w", err)
+		}
+		emitted[hash] = true
+	}
+	return emitted, rows.Err()
+}
+
+// UpdateEmitted records hashes as emitted from filePath and forgets the
+// stale ones, those of events the file no longer holds
+func (s *OffsetStore) UpdateEmitted(filePath string, hashes, stale []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.Prepare("INSERT OR IGNORE INTO watcher_emitted (file_path, content_hash) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close()
+
+	for _, hash := range hashes {
+		if _, err := insert.Exec(filePath, hash); err != nil {
+			return fmt.Errorf("failed to mark emitted event: %w", err)
+		}
+	}
+
+	remove, err := tx.Prepare("DELETE FROM watcher_emitted WHERE file_path = ? AND content_hash = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer remove.Close()
+
+	for _, hash := range stale {
+		if _, err := remove.Exec(filePath, hash); err != nil {
+			return fmt.Errorf("failed to forget emitted event: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneMissing forgets the offsets and emitted events of files that no
+// longer exist, such as logs deleted while the collector wasn't running. It
+// returns how many files were forgotten.
+func (s *OffsetStore) PruneMissing() (int, error) {
+	s.mu.Lock()
+	rows, err := s.db.Query("SELECT file_path FROM watcher_offsets UNION SELECT DISTINCT file_path FROM watcher_emitted")
+	if err != nil {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("failed to list watched files: %w", err)
+	}
+	var missing []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			s.mu.Unlock()
+			return 0, fmt.Errorf("failed to scan watched file: %w", err)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list watched files: %w", err)
+	}
+
+	for _, path := range missing {
+		if err := s.Delete(path); err != nil {
+			return 0, err
+		}
+	}
+	return len(missing), nil
+}
+
+// Delete forgets the offset and emitted events of filePath
+func (s *OffsetStore) Delete(filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("DELETE FROM watcher_offsets WHERE file_path = ?", filePath); err != nil {
+		return fmt.Errorf("failed to delete offset: %w", err)
+	}
+	if _, err := s.db.Exec("DELETE FROM watcher_emitted WHERE file_path = ?", filePath); err != nil {
+		return fmt.Errorf("failed to delete emitted events: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (s *OffsetStore) Close() error {
+	return s.db.Close()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,17 +17,30 @@ import (
 
 // Watcher monitors log files for changes
 type Watcher struct {
-	fsWatcher  *fsnotify.Watcher
-	registry   *adapters.Registry
-	eventQueue chan *types.AgentEvent
-	log        *logrus.Logger
-	mu         sync.Mutex
-	watching   map[string]bool             // tracked file paths
-	adapters   map[string]adapters.AgentAdapter // path -> adapter mapping for new file detection
-	debounce   time.Duration
-	debouncers map[string]*time.Timer
-	ctx        context.Context
-	cancel     context.CancelFunc
+	fsWatcher      *fsnotify.Watcher
+	registry       *adapters.Registry
+	eventQueue     chan *types.AgentEvent
+	log            *logrus.Logger
+	mu             sync.Mutex
+	watching       map[string]bool                  // tracked file paths
+	adapters       map[string]adapters.AgentAdapter // path -> adapter mapping for new file detection
+	debounce       time.Duration
+	debounces      map[string]time.Duration // adapter name -> debounce overriding debounce
+	debouncers     map[string]*time.Timer
+	parsing        map[string]bool // file path -> parse running, true if another is due
+	parses         sync.WaitGroup  // parses running, waited for by Stop
+	removed        int             // watched paths that were deleted or renamed away
+	offsetMu       sync.Mutex
+	offsets        map[string]int64           // line-based file path -> bytes consumed
+	stamps         map[string]fileStamp       // file path -> size and mtime last processed
+	emitted        map[string]map[string]bool // whole-file path -> hashes of emitted events
+	store          *OffsetStore
+	processedBytes func(agentName, filePath string) int64
+	filters        map[string]PathFilter    // adapter name -> discovery filter
+	discovery      map[string]AgentSettings // agent name -> discovery settings
+	roots          map[string]bool          // directories passed to Watch
+	ctx            context.Context
+	cancel         context.CancelFunc
 }
 
 // Config holds watcher configuration
@@ -35,6 +49,27 @@ type Config struct {
 	EventQueueSize int
 	DebounceMs     int
 	Logger         *logrus.Logger
+
+	// AgentDebounceMs overrides DebounceMs per adapter name, for agents
+	// whose write patterns need a longer or shorter window
+	AgentDebounceMs map[string]int
+
+	// Filters holds include/exclude patterns per adapter name. Adapters
+	// without an entry use DefaultExcludes only.
+	Filters map[string]PathFilter
+
+	// Discovery disables agents or overrides their log locations, keyed by
+	// agent name, for the periodic scan of StartDynamicDiscovery
+	Discovery map[string]AgentSettings
+
+	// Offsets persists per-file progress so a restart resumes where the
+	// last run stopped. Nil keeps progress in memory only.
+	Offsets *OffsetStore
+
+	// ProcessedBytes, if set, returns how far backfill has read a
+	// line-based log, so a resumed file skips content backfill already
+	// collected
+	ProcessedBytes func(agentName, filePath string) int64
 }
 
 // NewWatcher creates a new file system watcher
@@ -59,16 +94,32 @@ func NewWatcher(config Config) (*Watcher, error) {
 	}
 
 	w := &Watcher{
-		fsWatcher:  fsWatcher,
-		registry:   config.Registry,
-		eventQueue: make(chan *types.AgentEvent, config.EventQueueSize),
-		log:        config.Logger,
-		watching:   make(map[string]bool),
-		adapters:   make(map[string]adapters.AgentAdapter),
-		debounce:   time.Duration(config.DebounceMs) * time.Millisecond,
-		debouncers: make(map[string]*time.Timer),
-		ctx:        ctx,
-		cancel:     cancel,
+		fsWatcher:      fsWatcher,
+		registry:       config.Registry,
+		eventQueue:     make(chan *types.AgentEvent, config.EventQueueSize),
+		log:            config.Logger,
+		watching:       make(map[string]bool),
+		adapters:       make(map[string]adapters.AgentAdapter),
+		debounce:       time.Duration(config.DebounceMs) * time.Millisecond,
+		debounces:      make(map[string]time.Duration),
+		debouncers:     make(map[string]*time.Timer),
+		parsing:        make(map[string]bool),
+		offsets:        make(map[string]int64),
+		stamps:         make(map[string]fileStamp),
+		emitted:        make(map[string]map[string]bool),
+		store:          config.Offsets,
+		processedBytes: config.ProcessedBytes,
+		filters:        config.Filters,
+		discovery:      config.Discovery,
+		roots:          make(map[string]bool),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+
+	for name, ms := range config.AgentDebounceMs {
+		if ms > 0 {
+			w.debounces[name] = time.Duration(ms) * time.Millisecond
+		}
 	}
 
 	return w, nil
@@ -88,6 +139,15 @@ func (w *Watcher) Stop() error {
 	w.log.Info("Stopping file watcher...")
 	w.cancel()
 
+	// No parse starts once cancelled; let those running finish before the
+	// queue they send to is closed
+	w.mu.Lock()
+	for _, timer := range w.debouncers {
+		timer.Stop()
+	}
+	w.mu.Unlock()
+	w.parses.Wait()
+
 	// Close fs watcher
 	if err := w.fsWatcher.Close(); err != nil {
 		return fmt.Errorf("failed to close fs watcher: %w", err)
@@ -101,18 +161,30 @@ func (w *Watcher) Stop() error {
 
 // Watch adds a file or directory to watch
 func (w *Watcher) Watch(path string, adapter adapters.AgentAdapter) error {
+	seeds, err := w.watch(path, adapter)
+
+	// Seeding parses whole logs; file events are handled meanwhile
+	for _, filePath := range seeds {
+		w.seedEmitted(filePath, adapter)
+	}
+	return err
+}
+
+// watch adds a file or directory to watch and returns the whole-file logs
+// found that are to be seeded (see markConsumed)
+func (w *Watcher) watch(path string, adapter adapters.AgentAdapter) ([]string, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	// Check if already watching
 	if w.watching[path] {
-		return nil
+		return nil, nil
 	}
 
 	// Check if path exists
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("failed to stat path: %w", err)
+		return nil, fmt.Errorf("failed to stat path: %w", err)
 	}
 
 	// Store adapter for this path (for new file detection)
@@ -120,30 +192,40 @@ func (w *Watcher) Watch(path string, adapter adapters.AgentAdapter) error {
 
 	// If it's a directory, watch recursively
 	if info.IsDir() {
-		if err := w.watchDir(path, adapter); err != nil {
-			return fmt.Errorf("failed to watch directory: %w", err)
-		}
-	} else {
-		// Watch single file
-		if err := w.fsWatcher.Add(path); err != nil {
-			return fmt.Errorf("failed to add file to watcher: %w", err)
+		seeds, err := w.watchDir(path, adapter)
+		if err != nil {
+			return seeds, fmt.Errorf("failed to watch directory: %w", err)
 		}
-		w.watching[path] = true
-		w.log.Infof("Watching file: %s", path)
+		return seeds, nil
 	}
 
-	return nil
+	// Watch single file
+	if err := w.fsWatcher.Add(path); err != nil {
+		return nil, fmt.Errorf("failed to add file to watcher: %w", err)
+	}
+	w.watching[path] = true
+	var seeds []string
+	if w.markConsumed(path, adapter) {
+		seeds = append(seeds, path)
+	}
+	w.log.Infof("Watching file: %s", path)
+
+	return seeds, nil
 }
 
-// watchDir recursively watches a directory
-func (w *Watcher) watchDir(dirPath string, adapter adapters.AgentAdapter) error {
+// watchDir recursively watches a directory and returns the whole-file logs
+// found that are to be seeded
+func (w *Watcher) watchDir(dirPath string, adapter adapters.AgentAdapter) ([]string, error) {
+	w.roots[dirPath] = true
+
 	// Find all log files in directory
-	logFiles, err := FindLogFiles(dirPath)
+	logFiles, err := w.filterFor(adapter).WalkLogFiles(dirPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Watch each log file
+	var seeds []string
 	for _, logFile := range logFiles {
 		if err := w.fsWatcher.Add(logFile); err != nil {
 			w.log.Warnf("Failed to watch %s: %v", logFile, err)
@@ -151,17 +233,20 @@ func (w *Watcher) watchDir(dirPath string, adapter adapters.AgentAdapter) error
 		}
 		w.watching[logFile] = true
 		w.adapters[logFile] = adapter
+		if w.markConsumed(logFile, adapter) {
+			seeds = append(seeds, logFile)
+		}
 		w.log.Debugf("Watching file: %s", logFile)
 	}
 
 	// Also watch the directory itself for new files
 	if err := w.fsWatcher.Add(dirPath); err != nil {
-		return fmt.Errorf("failed to watch directory: %w", err)
+		return seeds, fmt.Errorf("failed to watch directory: %w", err)
 	}
 	w.watching[dirPath] = true
 	w.adapters[dirPath] = adapter
 
-	return nil
+	return seeds, nil
 }
 
 // EventQueue returns the channel for receiving parsed events
@@ -200,6 +285,12 @@ func (w *Watcher) handleFileEvent(event fsnotify.Event) {
 		return
 	}
 
+	// Files deleted or renamed away are no longer watched
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		w.handleRemovedFile(event.Name)
+		return
+	}
+
 	// Only handle Write events for existing files
 	if event.Op&fsnotify.Write == 0 {
 		return
@@ -213,40 +304,102 @@ func (w *Watcher) handleFileEvent(event fsnotify.Event) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// Cancel existing debounce timer
+	// Each write restarts the file's timer, so a burst is parsed once it
+	// has been quiet for the debounce window
 	if timer, exists := w.debouncers[event.Name]; exists {
 		timer.Stop()
 	}
-
-	// Create new debounce timer
-	w.debouncers[event.Name] = time.AfterFunc(w.debounce, func() {
-		w.processLogFile(event.Name)
-
-		// Clean up debouncer
+	w.debouncers[event.Name] = time.AfterFunc(w.debounceFor(event.Name), func() {
 		w.mu.Lock()
 		delete(w.debouncers, event.Name)
 		w.mu.Unlock()
+
+		w.parseCoalesced(event.Name)
 	})
 }
 
-// handleNewFile handles newly created files (file rotation / new chat sessions)
-func (w *Watcher) handleNewFile(filePath string) {
-	// Check if it's a log file
-	if !isLogFile(filePath) {
+// debounceFor returns the debounce window of the adapter watching filePath.
+// The caller holds w.mu.
+func (w *Watcher) debounceFor(filePath string) time.Duration {
+	if adapter, ok := w.adapters[filePath]; ok {
+		if debounce, ok := w.debounces[adapter.Name()]; ok {
+			return debounce
+		}
+	}
+	return w.debounce
+}
+
+// parseCoalesced parses filePath, unless a parse of it is already running:
+// then that parse runs once more when it is done, however many bursts
+// arrived meanwhile. Nothing is parsed once the watcher is stopped.
+func (w *Watcher) parseCoalesced(filePath string) {
+	w.mu.Lock()
+	if w.ctx.Err() != nil {
+		w.mu.Unlock()
+		return
+	}
+	if _, running := w.parsing[filePath]; running {
+		w.parsing[filePath] = true
+		w.mu.Unlock()
 		return
 	}
+	w.parsing[filePath] = false
+	w.parses.Add(1)
+	w.mu.Unlock()
+	defer w.parses.Done()
 
-	// Check if it's a new directory (new workspace)
+	for {
+		w.processLogFile(filePath)
+		if !w.parseAgain(filePath) {
+			return
+		}
+	}
+}
+
+// parseAgain reports whether another parse of filePath was asked for while
+// one ran, and otherwise records that none is running
+func (w *Watcher) parseAgain(filePath string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.parsing[filePath] {
+		delete(w.parsing, filePath)
+		return false
+	}
+	w.parsing[filePath] = false
+	return true
+}
+
+// handleNewFile handles newly created files (file rotation / new chat sessions)
+func (w *Watcher) handleNewFile(filePath string) {
+	// Check if it's a new directory (new workspace or editing session)
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return
 	}
 
+	// Check if it's a log file
+	if !info.IsDir() && !isLogFile(filePath) {
+		return
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	// Already watching this path
 	if w.watching[filePath] {
+		if !info.IsDir() {
+			// A new file took over a watched path (rotation by rename)
+			w.log.Infof("Log file replaced: %s", filepath.Base(filePath))
+			w.resetOffset(filePath)
+			if err := w.fsWatcher.Add(filePath); err != nil {
+				w.log.Debugf("Failed to re-watch %s: %v", filePath, err)
+			}
+			go func() {
+				time.Sleep(500 * time.Millisecond)
+				w.parseCoalesced(filePath)
+			}()
+		}
 		return
 	}
 
@@ -267,6 +420,14 @@ func (w *Watcher) handleNewFile(filePath string) {
 		}
 	}
 
+	// Respect the adapter's include/exclude patterns
+	filter := w.filterFor(adapter)
+	relPath := w.relToRoot(filePath)
+	if filter.Excluded(relPath) || (!info.IsDir() && !filter.Allows(relPath)) {
+		w.log.Debugf("Ignoring excluded path: %s", filePath)
+		return
+	}
+
 	if info.IsDir() {
 		// New directory (possibly new workspace)
 		w.log.Infof("New directory detected: %s", filePath)
@@ -285,38 +446,133 @@ func (w *Watcher) handleNewFile(filePath string) {
 		}
 		w.watching[filePath] = true
 		w.adapters[filePath] = adapter
+		w.resetOffset(filePath)
 
 		// Process the new file after a short delay (let it finish writing)
 		go func() {
 			time.Sleep(500 * time.Millisecond)
-			w.processLogFile(filePath)
+			w.parseCoalesced(filePath)
 		}()
 	}
 }
 
+// handleRemovedFile forgets a watched file or directory that was deleted or
+// renamed away, e.g. when the user clears VS Code's storage: its tracking,
+// adapter, pending parse, offsets and fsnotify watch, and those of the files
+// under a directory. A path that exists again was replaced, by rotation or
+// an atomic save, and is left to the Create event of its replacement.
+func (w *Watcher) handleRemovedFile(filePath string) {
+	if _, err := os.Stat(filePath); err == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.watching[filePath] {
+		return
+	}
+
+	removed := []string{filePath}
+	prefix := filePath + string(filepath.Separator)
+	for path := range w.watching {
+		if strings.HasPrefix(path, prefix) {
+			removed = append(removed, path)
+		}
+	}
+	for _, path := range removed {
+		w.forget(path)
+	}
+	w.removed += len(removed)
+	w.log.Infof("Log path removed, no longer watching: %s", filePath)
+}
+
+// forget drops everything the watcher keeps about path. The caller holds
+// w.mu.
+func (w *Watcher) forget(path string) {
+	if timer, ok := w.debouncers[path]; ok {
+		timer.Stop()
+		delete(w.debouncers, path)
+	}
+	delete(w.watching, path)
+	delete(w.adapters, path)
+	delete(w.roots, path)
+
+	// The kernel drops the watch of a deleted file itself, so this only
+	// matters for renames
+	if err := w.fsWatcher.Remove(path); err != nil {
+		w.log.Debugf("Failed to unwatch %s: %v", path, err)
+	}
+
+	w.offsetMu.Lock()
+	delete(w.offsets, path)
+	delete(w.stamps, path)
+	delete(w.emitted, path)
+	w.offsetMu.Unlock()
+
+	if w.store != nil {
+		if err := w.store.Delete(path); err != nil {
+			w.log.Warnf("Failed to delete offset of %s: %v", filepath.Base(path), err)
+		}
+	}
+}
+
 // processLogFile reads and parses a log file
 func (w *Watcher) processLogFile(filePath string) {
-	w.log.Debugf("Processing log file: %s", filePath)
-
-	// Detect adapter for this file
-	sample, err := readFileSample(filePath, 1024)
-	if err != nil {
-		w.log.Warnf("Failed to read sample from %s: %v", filePath, err)
+	// A file removed after the write that scheduled this parse is gone
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		w.log.Debugf("Skipping removed log file: %s", filePath)
 		return
 	}
 
-	adapter, err := w.registry.DetectAdapter(sample)
-	if err != nil {
-		w.log.Debugf("No adapter found for %s", filePath)
+	// Writes that left size and mtime alone (and repeated notifications)
+	// have nothing new to parse
+	stamp, changed := w.fileChanged(filePath)
+	if !changed {
+		w.log.Debugf("Skipping unchanged log file: %s", filePath)
 		return
 	}
 
-	// Parse log file
-	events, err := adapter.ParseLogFile(filePath)
+	w.log.Debugf("Processing log file: %s", filePath)
+
+	// Prefer the adapter the file was watched with, then detect from content
+	w.mu.Lock()
+	adapter, ok := w.adapters[filePath]
+	w.mu.Unlock()
+
+	if !ok {
+		sample, err := readFileSample(filePath, 1024)
+		if err != nil {
+			w.log.Warnf("Failed to read sample from %s: %v", filePath, err)
+			return
+		}
+
+		adapter, err = w.registry.DetectAdapter(sample)
+		if err != nil {
+			w.log.Debugf("No adapter found for %s", filePath)
+			return
+		}
+	}
+
+	// Line-based logs are tailed from the last offset; whole-file formats
+	// are re-parsed and only events not emitted before are kept
+	var events []*types.AgentEvent
+	var err error
+	if adapters.ParsesWholeFile(adapter, filePath) {
+		events, err = adapter.ParseLogFile(filePath)
+		if err == nil {
+			events = w.newEvents(filePath, events)
+		}
+	} else {
+		events, err = w.readAppendedLines(filePath, adapter)
+	}
 	if err != nil {
-		w.log.Warnf("Failed to parse log file %s: %v", filePath, err)
+		w.log.WithFields(logrus.Fields{"agent": adapter.Name(), "file": filePath}).
+			WithError(err).Warn("Failed to parse log file")
 		return
 	}
+	w.recordStamp(filePath, stamp)
+	w.saveOffset(filePath)
 
 	// Send events to queue
 	for _, event := range events {
@@ -327,13 +583,17 @@ func (w *Watcher) processLogFile(filePath string) {
 			return
 		default:
 			// Queue full, log warning
-			w.log.Warn("Event queue full, dropping event")
+			w.log.WithFields(logrus.Fields{"agent": adapter.Name(), "file": filePath}).
+				Warn("Event queue full, dropping event")
 		}
 	}
 
 	if len(events) > 0 {
-		w.log.Infof("Parsed %d events from %s using %s adapter",
-			len(events), filepath.Base(filePath), adapter.Name())
+		w.log.WithFields(logrus.Fields{
+			"agent":  adapter.Name(),
+			"file":   filePath,
+			"events": len(events),
+		}).Info("Parsed events from log file")
 	}
 }
 
@@ -364,6 +624,7 @@ func (w *Watcher) GetStats() map[string]interface{} {
 		"queue_size":        len(w.eventQueue),
 		"queue_capacity":    cap(w.eventQueue),
 		"active_debouncers": len(w.debouncers),
+		"removed_count":     w.removed,
 	}
 }
 
@@ -404,8 +665,13 @@ func (w *Watcher) WatchParentDirs(paths []string, adapter adapters.AgentAdapter)
 	return nil
 }
 
-// StartDynamicDiscovery starts a background goroutine that periodically scans for new workspaces
-func (w *Watcher) StartDynamicDiscovery(interval time.Duration, onNewWorkspace func(string, adapters.AgentAdapter)) {
+// StartDynamicDiscovery starts a background goroutine that periodically scans for new workspaces.
+// It reports whether the goroutine was started; a non-positive interval disables discovery.
+func (w *Watcher) StartDynamicDiscovery(interval time.Duration, onNewWorkspace func(string, adapters.AgentAdapter)) bool {
+	if interval <= 0 {
+		return false
+	}
+
 	go func() {
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
@@ -416,7 +682,7 @@ func (w *Watcher) StartDynamicDiscovery(interval time.Duration, onNewWorkspace f
 				return
 			case <-ticker.C:
 				// Discover all agent logs and check for new ones
-				discovered, err := DiscoverAllAgentLogs()
+				discovered, err := DiscoverConfiguredAgentLogs(w.discovery)
 				if err != nil {
 					w.log.Warnf("Dynamic discovery error: %v", err)
 					continue
@@ -445,4 +711,33 @@ func (w *Watcher) StartDynamicDiscovery(interval time.Duration, onNewWorkspace f
 			}
 		}
 	}()
+	return true
+}
+
+// filterFor returns the discovery filter for an adapter
+func (w *Watcher) filterFor(adapter adapters.AgentAdapter) PathFilter {
+	if filter, ok := w.filters[adapter.Name()]; ok {
+		return filter
+	}
+	return NewPathFilter(nil, nil)
+}
+
+// relToRoot returns filePath relative to the outermost watched directory
+// containing it, or its base name when it is outside every root
+func (w *Watcher) relToRoot(filePath string) string {
+	best := ""
+	for root := range w.roots {
+		if strings.HasPrefix(filePath, root+string(filepath.Separator)) && (best == "" || len(root) < len(best)) {
+			best = root
+		}
+	}
+	if best == "" {
+		return filepath.Base(filePath)
+	}
+
+	rel, err := filepath.Rel(best, filePath)
+	if err != nil {
+		return filepath.Base(filePath)
+	}
+	return rel
 }
@@ -1,12 +1,18 @@
 package watcher
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/codervisor/devlog/internal/adapters"
+	"github.com/codervisor/devlog/internal/hierarchy"
+	"github.com/codervisor/devlog/pkg/types"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -177,3 +183,308 @@ func TestWatcher_WatchDirectory(t *testing.T) {
 		t.Errorf("expected watching_count>=1, got %d", watchingCount)
 	}
 }
+
+// toolLogAdapter parses "TOOLLOG <prompt>" lines of a fictional in-house tool
+type toolLogAdapter struct {
+	*adapters.BaseAdapter
+}
+
+func (a *toolLogAdapter) ParseLogLine(line string) (*types.AgentEvent, error) {
+	prompt, ok := strings.CutPrefix(line, "TOOLLOG ")
+	if !ok {
+		return nil, fmt.Errorf("not a tool log line")
+	}
+	return &types.AgentEvent{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now(),
+		Type:      types.EventTypeLLMRequest,
+		AgentID:   a.Name(),
+		SessionID: "tool-session",
+		Data:      map[string]interface{}{"prompt": prompt},
+	}, nil
+}
+
+func (a *toolLogAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	return nil, fmt.Errorf("line-based parsing only")
+}
+
+func (a *toolLogAdapter) SupportsFormat(sample string) bool {
+	return strings.HasPrefix(sample, "TOOLLOG ")
+}
+
+func TestWatcher_RegisteredAdapter(t *testing.T) {
+	adapters.Register("watcher-test-tool", func(projectID string, hierarchyCache *hierarchy.HierarchyCache, log *logrus.Logger) adapters.AgentAdapter {
+		return &toolLogAdapter{adapters.NewBaseAdapter("watcher-test-tool", projectID, log)}
+	})
+
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+	registry := adapters.DefaultRegistry("test-project", nil, log)
+	adapter, err := registry.Get("watcher-test-tool")
+	if err != nil {
+		t.Fatalf("registered adapter missing from the default registry: %v", err)
+	}
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "tool.log")
+	if err := os.WriteFile(logFile, nil, 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	watcher, err := NewWatcher(Config{
+		Registry:       registry,
+		EventQueueSize: 100,
+		DebounceMs:     50,
+		Logger:         log,
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer watcher.Stop()
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	if err := watcher.Watch(dir, adapter); err != nil {
+		t.Fatalf("failed to watch directory: %v", err)
+	}
+
+	appendToFile(t, logFile, "TOOLLOG first\nTOOLLOG second\n")
+	assertPrompts(t, collectPrompts(t, watcher), "first", "second")
+}
+
+// slowAdapter parses a whole file by counting the parse and taking delay
+type slowAdapter struct {
+	adapters.AgentAdapter
+	delay  time.Duration
+	parses atomic.Int32
+}
+
+func (a *slowAdapter) ParsesWholeFile(filePath string) bool {
+	return true
+}
+
+func (a *slowAdapter) ParseLogFile(filePath string) ([]*types.AgentEvent, error) {
+	a.parses.Add(1)
+	time.Sleep(a.delay)
+	return nil, nil
+}
+
+func startSlowWatcher(t *testing.T, dir string, adapter *slowAdapter, config Config) {
+	t.Helper()
+
+	config.Registry = adapters.NewRegistry()
+	config.Logger = logrus.New()
+	config.Logger.SetLevel(logrus.WarnLevel)
+	watcher, err := NewWatcher(config)
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	t.Cleanup(func() { watcher.Stop() })
+
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	if err := watcher.Watch(dir, adapter); err != nil {
+		t.Fatalf("failed to watch directory: %v", err)
+	}
+}
+
+func TestWatcher_AgentDebounceCoalescesRapidWrites(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "session.json")
+	if err := os.WriteFile(logFile, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	adapter := &slowAdapter{AgentAdapter: adapters.NewClaudeAdapter("test-project", nil, nil)}
+	startSlowWatcher(t, dir, adapter, Config{
+		DebounceMs:      10,
+		AgentDebounceMs: map[string]int{adapter.Name(): 300},
+	})
+
+	// Writes further apart than the default window but within the agent's
+	for i := 0; i < 10; i++ {
+		appendToFile(t, logFile, "{}\n")
+		time.Sleep(30 * time.Millisecond)
+	}
+	time.Sleep(800 * time.Millisecond)
+
+	if parses := adapter.parses.Load(); parses != 1 {
+		t.Errorf("Expected the burst to be parsed once, got %d parses", parses)
+	}
+}
+
+func TestWatcher_CoalescesWritesDuringParse(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "session.json")
+	if err := os.WriteFile(logFile, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	adapter := &slowAdapter{AgentAdapter: adapters.NewClaudeAdapter("test-project", nil, nil), delay: 400 * time.Millisecond}
+	startSlowWatcher(t, dir, adapter, Config{DebounceMs: 20})
+
+	appendToFile(t, logFile, "{}\n")
+	time.Sleep(100 * time.Millisecond)
+
+	// Bursts arriving while the first parse runs add a single parse after it
+	for i := 0; i < 3; i++ {
+		appendToFile(t, logFile, "{}\n")
+		time.Sleep(60 * time.Millisecond)
+	}
+	time.Sleep(1200 * time.Millisecond)
+
+	if parses := adapter.parses.Load(); parses != 2 {
+		t.Errorf("Expected one parse and one coalesced re-parse, got %d parses", parses)
+	}
+}
+
+func TestWatcher_ForgetsRemovedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(path string) error
+	}{
+		{"deleted", os.Remove},
+		{"renamed away", func(path string) error { return os.Rename(path, path+".bak") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			gone := filepath.Join(dir, "gone.log")
+			kept := filepath.Join(dir, "kept.log")
+			for _, path := range []string{gone, kept} {
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatalf("failed to write log: %v", err)
+				}
+			}
+
+			log := logrus.New()
+			log.SetLevel(logrus.WarnLevel)
+			watcher, err := NewWatcher(Config{
+				Registry:       adapters.NewRegistry(),
+				EventQueueSize: 100,
+				DebounceMs:     5000, // Keeps the write's parse pending
+				Logger:         log,
+			})
+			if err != nil {
+				t.Fatalf("failed to create watcher: %v", err)
+			}
+			defer watcher.Stop()
+			if err := watcher.Start(); err != nil {
+				t.Fatalf("failed to start watcher: %v", err)
+			}
+			adapter := &toolLogAdapter{adapters.NewBaseAdapter("tool", "test-project", log)}
+			if err := watcher.Watch(dir, adapter); err != nil {
+				t.Fatalf("failed to watch directory: %v", err)
+			}
+
+			appendToFile(t, gone, "TOOLLOG pending\n")
+			waitUntil(t, "the write to be debounced", func() bool {
+				watcher.mu.Lock()
+				defer watcher.mu.Unlock()
+				return watcher.debouncers[gone] != nil
+			})
+
+			if err := tt.remove(gone); err != nil {
+				t.Fatalf("failed to remove log: %v", err)
+			}
+			waitUntil(t, "the removed file to be forgotten", func() bool {
+				watcher.mu.Lock()
+				defer watcher.mu.Unlock()
+				return !watcher.watching[gone]
+			})
+
+			watcher.mu.Lock()
+			_, hasAdapter := watcher.adapters[gone]
+			_, hasDebouncer := watcher.debouncers[gone]
+			keptWatched := watcher.watching[kept] && watcher.watching[dir]
+			watcher.mu.Unlock()
+			if hasAdapter || hasDebouncer {
+				t.Errorf("Expected the adapter and pending parse of the removed file to be dropped")
+			}
+			if !keptWatched {
+				t.Errorf("Expected the other file and the directory to stay watched")
+			}
+
+			watcher.offsetMu.Lock()
+			_, hasOffset := watcher.offsets[gone]
+			watcher.offsetMu.Unlock()
+			if hasOffset {
+				t.Errorf("Expected the offset of the removed file to be dropped")
+			}
+
+			for _, path := range watcher.fsWatcher.WatchList() {
+				if path == gone {
+					t.Errorf("Expected fsnotify to stop watching %s", gone)
+				}
+			}
+			if removed := watcher.GetStats()["removed_count"].(int); removed != 1 {
+				t.Errorf("Expected removed_count=1, got %d", removed)
+			}
+		})
+	}
+}
+
+// waitUntil polls condition for up to two seconds
+func waitUntil(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatcher_DynamicDiscovery(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     bool
+	}{
+		{"enabled", 10 * time.Millisecond, true},
+		{"disabled", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher, err := NewWatcher(Config{
+				Registry:       adapters.DefaultRegistry("test-project", nil, nil),
+				EventQueueSize: 100,
+				DebounceMs:     50,
+				Discovery:      map[string]AgentSettings{"tabnine": {LogPath: logDir}},
+			})
+			if err != nil {
+				t.Fatalf("failed to create watcher: %v", err)
+			}
+			defer watcher.Stop()
+
+			found := make(chan string, 10)
+			started := watcher.StartDynamicDiscovery(tt.interval, func(path string, adapter adapters.AgentAdapter) {
+				found <- path
+			})
+			if started != tt.want {
+				t.Fatalf("expected StartDynamicDiscovery to return %v, got %v", tt.want, started)
+			}
+
+			select {
+			case path := <-found:
+				if !tt.want {
+					t.Fatalf("expected no discovery scan when disabled, found %s", path)
+				}
+				if path != logDir {
+					t.Errorf("expected to discover %s, got %s", logDir, path)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.want {
+					t.Fatal("expected the discovery scan to find the log directory")
+				}
+			}
+		})
+	}
+}
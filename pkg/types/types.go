@@ -47,6 +47,7 @@ type SessionInfo struct {
 const (
 	EventTypeLLMRequest      = "llm_request"
 	EventTypeLLMResponse     = "llm_response"
+	EventTypeLLMCanceled     = "llm_canceled"
 	EventTypeToolUse         = "tool_use"
 	EventTypeFileRead        = "file_read"
 	EventTypeFileWrite       = "file_write"
@@ -56,4 +57,33 @@ const (
 	EventTypeError           = "error_encountered"
 	EventTypeSessionStart    = "session_start"
 	EventTypeSessionEnd      = "session_end"
+	EventTypeAttachment      = "attachment"
+	EventTypeBudgetExceeded  = "budget_exceeded"
+	EventTypeSessionSummary  = "session_summary"
+	EventTypeContext         = "context"
 )
+
+// knownEventTypes is the set of event types the backend understands
+var knownEventTypes = map[string]bool{
+	EventTypeLLMRequest:      true,
+	EventTypeLLMResponse:     true,
+	EventTypeLLMCanceled:     true,
+	EventTypeToolUse:         true,
+	EventTypeFileRead:        true,
+	EventTypeFileWrite:       true,
+	EventTypeFileModify:      true,
+	EventTypeCommandExec:     true,
+	EventTypeUserInteraction: true,
+	EventTypeError:           true,
+	EventTypeSessionStart:    true,
+	EventTypeSessionEnd:      true,
+	EventTypeAttachment:      true,
+	EventTypeBudgetExceeded:  true,
+	EventTypeSessionSummary:  true,
+	EventTypeContext:         true,
+}
+
+// IsKnownEventType reports whether eventType is one of the EventType constants
+func IsKnownEventType(eventType string) bool {
+	return knownEventTypes[eventType]
+}